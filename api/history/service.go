@@ -1,21 +1,30 @@
+// Package history defines the history service: historic process instances, activities,
+// tasks and variables, their reports, cleanup and search. Its types are those of the
+// implementation in internal/history, so that values pass between both without conversion.
 package history
 
-import "context"
+import internal "github.com/muixstudio/flowgo/internal/history"
 
-// Service provides operations for querying historical process data.
-type Service interface {
-	// Initialize initializes the history service
-	Initialize(ctx context.Context) error
+// HistoryService provides operations for querying historical process data.
+// This service is responsible for:
+// - Querying historical process instances
+// - Querying historical tasks
+// - Querying historical variables
+// - Querying historical activities
+// - Deleting historical data
+type HistoryService = internal.HistoryService
 
-	// Shutdown gracefully shuts down the history service
-	Shutdown(ctx context.Context) error
+// HistoricDetailQuery provides a fluent API for querying historic details
+type HistoricDetailQuery = internal.HistoricDetailQuery
 
-	// CreateHistoricProcessInstanceQuery creates a new historic process instance query
-	CreateHistoricProcessInstanceQuery() *HistoricProcessInstanceQuery
+// HistoricProcessInstanceQuery provides a fluent API for querying historic process instances
+type HistoricProcessInstanceQuery = internal.HistoricProcessInstanceQuery
 
-	// CreateHistoricTaskInstanceQuery creates a new historic task instance query
-	CreateHistoricTaskInstanceQuery() *HistoricTaskInstanceQuery
+// HistoricTaskInstanceQuery provides a fluent API for querying historic task instances
+type HistoricTaskInstanceQuery = internal.HistoricTaskInstanceQuery
 
-	// DeleteHistoricProcessInstance deletes a historic process instance
-	DeleteHistoricProcessInstance(ctx context.Context, processInstanceID string) error
-}
+// HistoricActivityInstanceQuery provides a fluent API for querying historic activity instances
+type HistoricActivityInstanceQuery = internal.HistoricActivityInstanceQuery
+
+// HistoricVariableInstanceQuery provides a fluent API for querying historic variable instances
+type HistoricVariableInstanceQuery = internal.HistoricVariableInstanceQuery
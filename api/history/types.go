@@ -1,108 +1,127 @@
 package history
 
 import (
-	"context"
-	"time"
+	"io"
+
+	internal "github.com/muixstudio/flowgo/internal/history"
 )
 
-// HistoricProcessInstance represents a completed or running process instance in history
-type HistoricProcessInstance struct {
-	ID                       string
-	BusinessKey              string
-	ProcessDefinitionID      string
-	ProcessDefinitionKey     string
-	ProcessDefinitionName    string
-	ProcessDefinitionVersion int
-	StartTime                time.Time
-	EndTime                  *time.Time
-	DurationInMillis         *int64
-	StartUserID              string
-	DeleteReason             string
-	TenantID                 string
-}
+// ElasticsearchConfig configures an ElasticsearchIndexer
+type ElasticsearchConfig = internal.ElasticsearchConfig
 
-// HistoricTaskInstance represents a completed or running task in history
-type HistoricTaskInstance struct {
-	ID                   string
-	ProcessDefinitionID  string
-	ProcessDefinitionKey string
-	ProcessInstanceID    string
-	Name                 string
-	Assignee             string
-	StartTime            time.Time
-	EndTime              *time.Time
-	DurationInMillis     *int64
-	Priority             int
-	TenantID             string
-}
+// ElasticsearchIndexer is a HistoryIndexer backed by Elasticsearch or OpenSearch.
+// Process instances, tasks and variables are stored in separate indices that
+// share one mapping, so a single search covers all of them.
+type ElasticsearchIndexer = internal.ElasticsearchIndexer
 
-// HistoricProcessInstanceQuery provides a fluent API for querying historic process instances
-type HistoricProcessInstanceQuery struct {
-	processInstanceID     string
-	processDefinitionKey  string
-	finished              *bool
-	startedAfter          *time.Time
-	orderBy               string
-	ascending             bool
-	service               Service
+// NewElasticsearchIndexer creates an indexer for an Elasticsearch or OpenSearch cluster
+func NewElasticsearchIndexer(config ElasticsearchConfig) (*ElasticsearchIndexer, error) {
+	return internal.NewElasticsearchIndexer(config)
 }
 
-// ProcessInstanceID filters by process instance ID
-func (q *HistoricProcessInstanceQuery) ProcessInstanceID(id string) *HistoricProcessInstanceQuery {
-	q.processInstanceID = id
-	return q
-}
+// Historic detail types
+const HistoricDetailTypeVariableUpdate = internal.HistoricDetailTypeVariableUpdate
 
-// ProcessDefinitionKey filters by process definition key
-func (q *HistoricProcessInstanceQuery) ProcessDefinitionKey(key string) *HistoricProcessInstanceQuery {
-	q.processDefinitionKey = key
-	return q
-}
+// HistoricDetail is a single change recorded at HistoryLevelFull, e.g. one update of
+// a variable with the value it replaced. Details let auditors reconstruct how a
+// variable changed over the life of a process instance.
+type HistoricDetail = internal.HistoricDetail
 
-// Finished filters to only finished process instances
-func (q *HistoricProcessInstanceQuery) Finished() *HistoricProcessInstanceQuery {
-	trueVal := true
-	q.finished = &trueVal
-	return q
-}
+// HistoryArchiver exports historic process instances before history cleanup or a
+// deletion batch removes them, e.g. to cold storage
+type HistoryArchiver = internal.HistoryArchiver
 
-// OrderByStartTime orders results by start time
-func (q *HistoricProcessInstanceQuery) OrderByStartTime() *HistoricProcessInstanceQuery {
-	q.orderBy = "start_time"
-	return q
-}
+// HistoricProcessInstanceArchive is a historic process instance together with its
+// tasks, activities, variables and details
+type HistoricProcessInstanceArchive = internal.HistoricProcessInstanceArchive
 
-// Desc sets descending order
-func (q *HistoricProcessInstanceQuery) Desc() *HistoricProcessInstanceQuery {
-	q.ascending = false
-	return q
+// NewJSONArchiver creates an archiver writing each archived process instance as one
+// line of JSON to a writer
+func NewJSONArchiver(writer io.Writer) HistoryArchiver {
+	return internal.NewJSONArchiver(writer)
 }
 
-// List executes the query and returns a list of historic process instances
-func (q *HistoricProcessInstanceQuery) List(ctx context.Context) ([]*HistoricProcessInstance, error) {
-	// TODO: Implement
-	return nil, nil
-}
+// Historic document types stored by a HistoryIndexer
+const (
+	SearchTypeProcessInstance = internal.SearchTypeProcessInstance
+	SearchTypeTaskInstance    = internal.SearchTypeTaskInstance
+	SearchTypeVariable        = internal.SearchTypeVariable
+)
 
-// HistoricTaskInstanceQuery provides a fluent API for querying historic task instances
-type HistoricTaskInstanceQuery struct {
-	taskID              string
-	processInstanceID   string
-	assignee            string
-	finished            *bool
-	orderBy             string
-	ascending           bool
-	service             Service
-}
+// HistoryIndexer mirrors history data into a full-text search store.
+// The history service stays the system of record; the index only serves searches.
+type HistoryIndexer = internal.HistoryIndexer
 
-// TaskID filters by task ID
-func (q *HistoricTaskInstanceQuery) TaskID(id string) *HistoricTaskInstanceQuery {
-	q.taskID = id
-	return q
-}
+// SearchFilters narrows a historic search. Empty fields do not filter.
+type SearchFilters = internal.SearchFilters
+
+// SearchResult is a page of historic search hits
+type SearchResult = internal.SearchResult
+
+// SearchHit is a single document matched by a historic search
+type SearchHit = internal.SearchHit
+
+// HistoryLevel controls how much history the engine records. Each level records
+// everything the lower levels record.
+type HistoryLevel = internal.HistoryLevel
+
+// History levels
+const (
+	HistoryLevelNone     = internal.HistoryLevelNone
+	HistoryLevelActivity = internal.HistoryLevelActivity
+	HistoryLevelAudit    = internal.HistoryLevelAudit
+	HistoryLevelFull     = internal.HistoryLevelFull
+)
 
-// List executes the query and returns a list of historic task instances
-func (q *HistoricTaskInstanceQuery) List(ctx context.Context) ([]*HistoricTaskInstance, error) {
-	// TODO: Implement
-	return nil, nil
+// ParseHistoryLevel returns the history level with a name, e.g. "audit"
+func ParseHistoryLevel(name string) (HistoryLevel, error) {
+	return internal.ParseHistoryLevel(name)
 }
+
+// DurationReportResult holds the duration statistics of the finished instances of a
+// process definition
+type DurationReportResult = internal.DurationReportResult
+
+// DailyCountReportResult holds the number of process instances started on a day
+type DailyCountReportResult = internal.DailyCountReportResult
+
+// AssigneeCountReportResult holds the number of tasks an assignee completed
+type AssigneeCountReportResult = internal.AssigneeCountReportResult
+
+// HistoricProcessInstanceReport provides a fluent API for aggregating historic process
+// instances, e.g. for dashboards
+type HistoricProcessInstanceReport = internal.HistoricProcessInstanceReport
+
+// HistoricTaskInstanceReport provides a fluent API for aggregating historic task
+// instances, e.g. for dashboards
+type HistoricTaskInstanceReport = internal.HistoricTaskInstanceReport
+
+// HistoricProcessInstance represents a completed or running process instance in history
+type HistoricProcessInstance = internal.HistoricProcessInstance
+
+// HistoricTaskInstance represents a completed or running task in history
+type HistoricTaskInstance = internal.HistoricTaskInstance
+
+// HistoricActivityInstance represents a completed or running activity in history
+type HistoricActivityInstance = internal.HistoricActivityInstance
+
+// HistoricVariableInstance represents a variable value at a point in history
+type HistoricVariableInstance = internal.HistoricVariableInstance
+
+// Batch types
+const (
+	BatchTypeHistoricProcessInstanceDeletion = internal.BatchTypeHistoricProcessInstanceDeletion
+	BatchTypeHistoryCleanup                  = internal.BatchTypeHistoryCleanup
+)
+
+// Batch statuses
+const (
+	BatchStatusRunning   = internal.BatchStatusRunning
+	BatchStatusSuspended = internal.BatchStatusSuspended
+	BatchStatusCompleted = internal.BatchStatusCompleted
+	BatchStatusCanceled  = internal.BatchStatusCanceled
+)
+
+// Batch represents a long running asynchronous operation on history data, run by the
+// batch service
+type Batch = internal.Batch
@@ -1,60 +1,39 @@
+// Package runtime defines the runtime service: process instances, executions, their
+// variables and the behaviors of activities. Its types are those of the implementation
+// in internal/runtime, so that values pass between both without conversion.
 package runtime
 
-import "context"
+import internal "github.com/muixstudio/flowgo/internal/runtime"
 
-// Service provides operations for managing process instances and executions.
-type Service interface {
-	// Initialize initializes the runtime service
-	Initialize(ctx context.Context) error
+// RuntimeService provides operations for managing process instances and executions.
+// This service is responsible for:
+// - Starting process instances
+// - Managing process instance lifecycle
+// - Setting and retrieving process variables
+// - Signaling events
+// - Managing executions
+type RuntimeService = internal.RuntimeService
 
-	// Shutdown gracefully shuts down the runtime service
-	Shutdown(ctx context.Context) error
+// NativeProcessInstanceQuery selects running process instances with a native SQL query
+// or a filter expression, for reporting queries the ProcessInstanceQuery API cannot express.
+type NativeProcessInstanceQuery = internal.NativeProcessInstanceQuery
 
-	// StartProcessInstanceByKey starts a process instance by process definition key
-	StartProcessInstanceByKey(ctx context.Context, processDefinitionKey string, variables map[string]interface{}) (*ProcessInstance, error)
+// ProcessInstanceBuilder provides a fluent API for starting a process instance with
+// any combination of options, e.g. a business key, a name and the activities to start at
+type ProcessInstanceBuilder = internal.ProcessInstanceBuilder
 
-	// StartProcessInstanceByID starts a process instance by process definition ID
-	StartProcessInstanceByID(ctx context.Context, processDefinitionID string, variables map[string]interface{}) (*ProcessInstance, error)
+// ProcessInstanceModificationBuilder provides a fluent API for modifying a running
+// process instance, e.g. to repair an instance stuck at a failed activity. The
+// instructions are applied in order by Execute. An activity instance is identified
+// by the ID of the execution at the activity.
+type ProcessInstanceModificationBuilder = internal.ProcessInstanceModificationBuilder
 
-	// StartProcessInstanceByKeyWithBusinessKey starts a process instance with a business key
-	StartProcessInstanceByKeyWithBusinessKey(ctx context.Context, processDefinitionKey, businessKey string, variables map[string]interface{}) (*ProcessInstance, error)
+// ProcessInstanceQuery provides a fluent API for querying process instances
+type ProcessInstanceQuery = internal.ProcessInstanceQuery
 
-	// DeleteProcessInstance deletes a process instance
-	DeleteProcessInstance(ctx context.Context, processInstanceID, deleteReason string) error
+// ExecutionQuery provides a fluent API for querying executions
+type ExecutionQuery = internal.ExecutionQuery
 
-	// SuspendProcessInstance suspends a process instance
-	SuspendProcessInstance(ctx context.Context, processInstanceID string) error
-
-	// ActivateProcessInstance activates a suspended process instance
-	ActivateProcessInstance(ctx context.Context, processInstanceID string) error
-
-	// CreateProcessInstanceQuery creates a new process instance query
-	CreateProcessInstanceQuery() *ProcessInstanceQuery
-
-	// GetProcessInstance retrieves a process instance by ID
-	GetProcessInstance(ctx context.Context, processInstanceID string) (*ProcessInstance, error)
-
-	// SetVariable sets a variable on a process instance
-	SetVariable(ctx context.Context, executionID, variableName string, value interface{}) error
-
-	// SetVariables sets multiple variables on a process instance
-	SetVariables(ctx context.Context, executionID string, variables map[string]interface{}) error
-
-	// GetVariable gets a variable from a process instance
-	GetVariable(ctx context.Context, executionID, variableName string) (interface{}, error)
-
-	// GetVariables gets all variables from a process instance
-	GetVariables(ctx context.Context, executionID string) (map[string]interface{}, error)
-
-	// RemoveVariable removes a variable from a process instance
-	RemoveVariable(ctx context.Context, executionID, variableName string) error
-
-	// Signal triggers a signal event
-	Signal(ctx context.Context, executionID string) error
-
-	// SignalWithVariables triggers a signal event with variables
-	SignalWithVariables(ctx context.Context, executionID string, variables map[string]interface{}) error
-
-	// CreateExecutionQuery creates a new execution query
-	CreateExecutionQuery() *ExecutionQuery
-}
+// VariableInstanceQuery provides a fluent API for querying the variables of running
+// process instances
+type VariableInstanceQuery = internal.VariableInstanceQuery
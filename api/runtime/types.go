@@ -1,110 +1,169 @@
 package runtime
 
-import (
-	"context"
-	"time"
-)
+import internal "github.com/muixstudio/flowgo/internal/runtime"
 
-// ProcessInstance represents a running or completed process instance
-type ProcessInstance struct {
-	ID                      string
-	ProcessDefinitionID     string
-	ProcessDefinitionKey    string
-	ProcessDefinitionName   string
-	BusinessKey             string
-	StartTime               time.Time
-	EndTime                 *time.Time
-	StartUserID             string
-	Suspended               bool
-	TenantID                string
-	RootProcessInstanceID   string
-	ParentProcessInstanceID string
-}
+// ActivityBehavior implements the execution semantics of a node type.
+// Execute is called when an execution arrives at a node; the behavior either
+// leaves the node right away or keeps the execution waiting until it is triggered.
+type ActivityBehavior = internal.ActivityBehavior
 
-// Execution represents an execution (thread of control) within a process instance
-type Execution struct {
-	ID                string
-	ProcessInstanceID string
-	ParentID          string
-	ActivityID        string
-	IsActive          bool
-	IsConcurrent      bool
-	IsScope           bool
-	IsEventScope      bool
-	Suspended         bool
-	TenantID          string
-}
+// TriggerableActivityBehavior is an activity behavior for wait states that
+// continue when their execution is signaled, e.g. user tasks
+type TriggerableActivityBehavior = internal.TriggerableActivityBehavior
 
-// ProcessInstanceQuery provides a fluent API for querying process instances
-type ProcessInstanceQuery struct {
-	processInstanceID        string
-	processInstanceBusinessKey string
-	processDefinitionID      string
-	processDefinitionKey     string
-	suspended                *bool
-	active                   *bool
-	variableValueEquals      map[string]interface{}
-	orderBy                  string
-	ascending                bool
-	service                  Service
-}
+// CancelableActivityBehavior is an activity behavior that cleans up when the activity
+// of an execution is cancelled, e.g. by an interrupting boundary event
+type CancelableActivityBehavior = internal.CancelableActivityBehavior
 
-// ProcessInstanceID filters by process instance ID
-func (q *ProcessInstanceQuery) ProcessInstanceID(id string) *ProcessInstanceQuery {
-	q.processInstanceID = id
-	return q
-}
+// SuspendableActivityBehavior is an activity behavior that follows the suspension of
+// the process instance an execution waits in its activity, e.g. to suspend the tasks
+// it created
+type SuspendableActivityBehavior = internal.SuspendableActivityBehavior
 
-// ProcessInstanceBusinessKey filters by business key
-func (q *ProcessInstanceQuery) ProcessInstanceBusinessKey(businessKey string) *ProcessInstanceQuery {
-	q.processInstanceBusinessKey = businessKey
-	return q
-}
+// ServiceTaskDelegate implements the logic of a service task.
+// Service tasks reference delegates by name through their "implementation" property.
+// A delegate that also implements TriggerableActivityBehavior makes the service task
+// a wait state, e.g. for work done by external workers.
+type ServiceTaskDelegate = internal.ServiceTaskDelegate
 
-// ProcessDefinitionKey filters by process definition key
-func (q *ProcessInstanceQuery) ProcessDefinitionKey(key string) *ProcessInstanceQuery {
-	q.processDefinitionKey = key
-	return q
-}
+// ServiceTaskFunc adapts a function to the ServiceTaskDelegate interface
+type ServiceTaskFunc = internal.ServiceTaskFunc
 
-// Active filters to only active process instances
-func (q *ProcessInstanceQuery) Active() *ProcessInstanceQuery {
-	trueVal := true
-	q.active = &trueVal
-	return q
-}
+// ActivityExecution is the view of an execution at a node that activity behaviors
+// and service task delegates work with
+type ActivityExecution = internal.ActivityExecution
 
-// List executes the query and returns a list of process instances
-func (q *ProcessInstanceQuery) List(ctx context.Context) ([]*ProcessInstance, error) {
-	// TODO: Implement
-	return nil, nil
-}
+// ActivityInstance is an activity a process instance is currently at. The root is
+// the process instance itself; subprocesses contain the activity instances running
+// inside them. An activity instance is identified by the ID of its execution, as in
+// ProcessInstanceModificationBuilder.CancelActivityInstance.
+type ActivityInstance = internal.ActivityInstance
 
-// ExecutionQuery provides a fluent API for querying executions
-type ExecutionQuery struct {
-	executionID         string
-	processInstanceID   string
-	activityID          string
-	active              *bool
-	orderBy             string
-	ascending           bool
-	service             Service
-}
+// ActivityTypeProcessDefinition is the activity type of the root activity instance
+const ActivityTypeProcessDefinition = internal.ActivityTypeProcessDefinition
 
-// ExecutionID filters by execution ID
-func (q *ExecutionQuery) ExecutionID(id string) *ExecutionQuery {
-	q.executionID = id
-	return q
-}
+// BusinessError is a BPMN error thrown by an activity, e.g. returned by a service
+// task delegate. Instead of failing the process instance, the engine continues at the
+// nearest error boundary event or error event subprocess that catches its code.
+type BusinessError = internal.BusinessError
 
-// ProcessInstanceID filters by process instance ID
-func (q *ExecutionQuery) ProcessInstanceID(id string) *ExecutionQuery {
-	q.processInstanceID = id
-	return q
+// NewBusinessError creates a business error with an error code
+func NewBusinessError(code, message string) *BusinessError {
+	return internal.NewBusinessError(code, message)
 }
 
-// List executes the query and returns a list of executions
-func (q *ExecutionQuery) List(ctx context.Context) ([]*Execution, error) {
-	// TODO: Implement
-	return nil, nil
+// EventSubscription is a subscription of an execution to a message or signal, e.g.
+// of an execution waiting at a message catch event or of the scope of an event
+// subprocess with a message start event
+type EventSubscription = internal.EventSubscription
+
+// History event types
+const (
+	HistoryEventProcessInstanceStart  = internal.HistoryEventProcessInstanceStart
+	HistoryEventProcessInstanceEnd    = internal.HistoryEventProcessInstanceEnd
+	HistoryEventProcessInstanceUpdate = internal.HistoryEventProcessInstanceUpdate
+	HistoryEventActivityStart         = internal.HistoryEventActivityStart
+	HistoryEventActivityEnd           = internal.HistoryEventActivityEnd
+	HistoryEventVariableUpdate        = internal.HistoryEventVariableUpdate
+)
+
+// HistoryEventHandler is told about the progress of process instances, e.g. by the
+// history manager recording it to history. Events are delivered after the process
+// instance they belong to was saved, in the order they happened.
+type HistoryEventHandler = internal.HistoryEventHandler
+
+// HistoryEvent describes a step in the progress of a process instance
+type HistoryEvent = internal.HistoryEvent
+
+// DeleteReasonCanceled is the delete reason of activities cancelled before they completed,
+// e.g. by an interrupting boundary event
+const DeleteReasonCanceled = internal.DeleteReasonCanceled
+
+// DeleteReasonDeleted is the delete reason of process instances deleted without a reason
+const DeleteReasonDeleted = internal.DeleteReasonDeleted
+
+// Job types
+const (
+	JobTypeAsyncBefore               = internal.JobTypeAsyncBefore
+	JobTypeAsyncAfter                = internal.JobTypeAsyncAfter
+	JobTypeTimer                     = internal.JobTypeTimer
+	JobTypeTimerStart                = internal.JobTypeTimerStart
+	JobTypeSuspendProcessDefinition  = internal.JobTypeSuspendProcessDefinition
+	JobTypeActivateProcessDefinition = internal.JobTypeActivateProcessDefinition
+)
+
+// Job is a continuation of a process instance that runs in the background, e.g. at
+// an activity marked "asyncBefore" or "asyncAfter", or a due timer. The caller that
+// created it returns once the state up to the job is saved; the job executor acquires
+// and runs it. Jobs of timer start events and scheduled suspensions belong to a
+// process definition only.
+type Job = internal.Job
+
+// JobNotifier is told when jobs can be acquired, e.g. by the job executor to
+// acquire them without waiting for its next poll
+type JobNotifier = internal.JobNotifier
+
+// JSON patch operations (RFC 6902)
+const (
+	PatchOpAdd     = internal.PatchOpAdd
+	PatchOpRemove  = internal.PatchOpRemove
+	PatchOpReplace = internal.PatchOpReplace
+	PatchOpMove    = internal.PatchOpMove
+	PatchOpCopy    = internal.PatchOpCopy
+	PatchOpTest    = internal.PatchOpTest
+)
+
+// PatchOperation is a single operation of a JSON patch document.
+// Paths are JSON pointers whose first segment is the variable name,
+// e.g. "/order/items/0/quantity".
+type PatchOperation = internal.PatchOperation
+
+// ParsePatch parses a JSON patch document
+func ParsePatch(patch []byte) ([]PatchOperation, error) {
+	return internal.ParsePatch(patch)
 }
+
+// Message correlation result types
+const (
+	MessageCorrelationResultTypeExecution         = internal.MessageCorrelationResultTypeExecution
+	MessageCorrelationResultTypeProcessDefinition = internal.MessageCorrelationResultTypeProcessDefinition
+)
+
+// MessageCorrelationResult describes where a correlated message was delivered: to an
+// execution waiting for it, or to a message start event that started a process instance
+type MessageCorrelationResult = internal.MessageCorrelationResult
+
+// Process instance events listeners are notified of
+const (
+	ProcessInstanceEventCancelled = internal.ProcessInstanceEventCancelled
+	ProcessInstanceEventAll       = internal.ProcessInstanceEventAll
+)
+
+// ProcessInstanceListener is notified of the lifecycle transitions of process instances,
+// e.g. to clean up records kept outside the engine. Listeners run after the process
+// instance was saved; their error is returned to the caller, but the transition stays.
+type ProcessInstanceListener = internal.ProcessInstanceListener
+
+// ProcessInstanceListenerFunc adapts a function to the ProcessInstanceListener interface
+type ProcessInstanceListenerFunc = internal.ProcessInstanceListenerFunc
+
+// ProcessInstanceEvent describes a lifecycle transition of a process instance
+type ProcessInstanceEvent = internal.ProcessInstanceEvent
+
+// DefaultInitiatorVariable is the variable holding the user who started a process
+// instance, unless the start event names another one with the "initiator" property
+const DefaultInitiatorVariable = internal.DefaultInitiatorVariable
+
+// ProcessInstance represents a running or completed process instance
+type ProcessInstance = internal.ProcessInstance
+
+// Execution represents an execution (thread of control) within a process instance
+type Execution = internal.Execution
+
+// ScriptEngine evaluates the scripts of script tasks written in one language
+type ScriptEngine = internal.ScriptEngine
+
+// VariableInstance is a variable of a running process instance as it is stored in a
+// scope: the process instance itself or an embedded subprocess. Variables are
+// identified by their scope and name.
+type VariableInstance = internal.VariableInstance
@@ -1,104 +1,87 @@
 package task
 
-import (
-	"context"
-	"time"
+import internal "github.com/muixstudio/flowgo/internal/task"
+
+// Built-in assignment strategy names
+const (
+	AssignmentStrategyRoundRobin          = internal.AssignmentStrategyRoundRobin
+	AssignmentStrategyLeastLoaded         = internal.AssignmentStrategyLeastLoaded
+	AssignmentStrategyStickyByBusinessKey = internal.AssignmentStrategyStickyByBusinessKey
 )
 
-// Task represents a user task in a process
-type Task struct {
-	ID                  string
-	Name                string
-	Description         string
-	Priority            int
-	Owner               string
-	Assignee            string
-	DueDate             *time.Time
-	Category            string
-	FormKey             string
-	ProcessInstanceID   string
-	ProcessDefinitionID string
-	ExecutionID         string
-	TaskDefinitionKey   string
-	CreateTime          time.Time
-	ClaimTime           *time.Time
-	TenantID            string
-	Suspended           bool
-	CandidateUsers      []string
-	CandidateGroups     []string
-}
+// AssignmentStrategy picks an assignee for a newly created task that has
+// candidates but no assignee. Returning an empty user ID leaves the task unassigned.
+type AssignmentStrategy = internal.AssignmentStrategy
 
-// Comment represents a comment on a task
-type Comment struct {
-	ID      string
-	TaskID  string
-	UserID  string
-	Message string
-	Time    time.Time
-}
+// GroupMemberResolver resolves the members of a candidate group
+type GroupMemberResolver = internal.GroupMemberResolver
 
-// TaskQuery provides a fluent API for querying tasks
-type TaskQuery struct {
-	taskID                string
-	taskName              string
-	assignee              string
-	owner                 string
-	candidateUser         string
-	candidateGroup        string
-	processInstanceID     string
-	processDefinitionKey  string
-	suspended             *bool
-	active                *bool
-	orderBy               string
-	ascending             bool
-	service               Service
-}
+// Assignment holds the information an AssignmentStrategy decides on
+type Assignment = internal.Assignment
 
-// TaskID filters by task ID
-func (q *TaskQuery) TaskID(id string) *TaskQuery {
-	q.taskID = id
-	return q
-}
+// RoundRobinStrategy assigns tasks to the candidates in turn.
+// A separate rotation is kept per distinct candidate set.
+type RoundRobinStrategy = internal.RoundRobinStrategy
 
-// TaskAssignee filters by assignee
-func (q *TaskQuery) TaskAssignee(assignee string) *TaskQuery {
-	q.assignee = assignee
-	return q
+// NewRoundRobinStrategy creates a new round-robin assignment strategy
+func NewRoundRobinStrategy() *RoundRobinStrategy {
+	return internal.NewRoundRobinStrategy()
 }
 
-// TaskCandidateUser filters by candidate user
-func (q *TaskQuery) TaskCandidateUser(userID string) *TaskQuery {
-	q.candidateUser = userID
-	return q
-}
+// LeastLoadedStrategy assigns tasks to the candidate with the fewest open tasks.
+// Ties are broken by the candidate order.
+type LeastLoadedStrategy = internal.LeastLoadedStrategy
 
-// ProcessInstanceID filters by process instance ID
-func (q *TaskQuery) ProcessInstanceID(id string) *TaskQuery {
-	q.processInstanceID = id
-	return q
+// NewLeastLoadedStrategy creates a new least-loaded assignment strategy
+func NewLeastLoadedStrategy() *LeastLoadedStrategy {
+	return internal.NewLeastLoadedStrategy()
 }
 
-// Active filters to only active tasks
-func (q *TaskQuery) Active() *TaskQuery {
-	trueVal := true
-	q.active = &trueVal
-	return q
-}
+// StickyByBusinessKeyStrategy assigns all tasks of the same business key to the
+// same user as long as that user is still a candidate. The first task of a
+// business key is assigned by the fallback strategy.
+type StickyByBusinessKeyStrategy = internal.StickyByBusinessKeyStrategy
 
-// OrderByTaskCreateTime orders results by create time
-func (q *TaskQuery) OrderByTaskCreateTime() *TaskQuery {
-	q.orderBy = "create_time"
-	return q
+// NewStickyByBusinessKeyStrategy creates a new sticky assignment strategy.
+// If fallback is nil, a round-robin strategy is used.
+func NewStickyByBusinessKeyStrategy(fallback AssignmentStrategy) *StickyByBusinessKeyStrategy {
+	return internal.NewStickyByBusinessKeyStrategy(fallback)
 }
 
-// Asc sets ascending order
-func (q *TaskQuery) Asc() *TaskQuery {
-	q.ascending = true
-	return q
-}
+// Task events listeners are notified of
+const (
+	TaskEventCreate     = internal.TaskEventCreate
+	TaskEventAssignment = internal.TaskEventAssignment
+	TaskEventComplete   = internal.TaskEventComplete
+	TaskEventDelete     = internal.TaskEventDelete
+	TaskEventEscalation = internal.TaskEventEscalation
+	TaskEventDue        = internal.TaskEventDue
+	TaskEventFollowUp   = internal.TaskEventFollowUp
+	TaskEventAll        = internal.TaskEventAll
+)
 
-// List executes the query and returns a list of tasks
-func (q *TaskQuery) List(ctx context.Context) ([]*Task, error) {
-	// TODO: Implement
-	return nil, nil
-}
+// TaskListener is notified of the lifecycle transitions of tasks, e.g. to send
+// notifications or keep SLA records.
+type TaskListener = internal.TaskListener
+
+// TaskListenerFunc adapts a function to the TaskListener interface
+type TaskListenerFunc = internal.TaskListenerFunc
+
+// TaskEvent describes a lifecycle transition of a task
+type TaskEvent = internal.TaskEvent
+
+// Task represents a user task in a process
+type Task = internal.Task
+
+// InboxCounts holds task counts per inbox bucket. A task counts as overdue
+// when it is assigned to or claimable by the user and its due date has passed.
+type InboxCounts = internal.InboxCounts
+
+// InboxSummary aggregates the open tasks of a user for dashboards
+type InboxSummary = internal.InboxSummary
+
+// Comment represents a comment on a task
+type Comment = internal.Comment
+
+// Attachment represents an attachment on a task
+type Attachment = internal.Attachment
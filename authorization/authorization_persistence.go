@@ -0,0 +1,40 @@
+package authorization
+
+import (
+	"context"
+
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// SetStore sets the store authorizations are persisted to
+func (s *authorizationServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// saveAuthorizationLocked writes an authorization to the store, if any.
+// Authorizations that no longer exist are deleted from the store.
+// Callers must hold the write lock.
+func (s *authorizationServiceImpl) saveAuthorizationLocked(ctx context.Context, authorizationID string) error {
+	if s.store == nil {
+		return nil
+	}
+
+	authorization, exists := s.authorizations[authorizationID]
+	if !exists {
+		return s.store.Delete(ctx, persistence.TableAuthorizations, authorizationID)
+	}
+	return s.store.Save(ctx, persistence.TableAuthorizations, authorizationID, authorization)
+}
+
+// load reads authorizations from the store
+func (s *authorizationServiceImpl) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return persistence.Load(ctx, s.store, persistence.TableAuthorizations, func(authorization *Authorization) {
+		s.authorizations[authorization.ID] = authorization
+	})
+}
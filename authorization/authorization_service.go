@@ -0,0 +1,92 @@
+// Package authorization restricts what users may do with process definitions,
+// process instances and tasks.
+//
+// An Authorization grants a user or a group permissions on one resource, or on all
+// resources of a type with the resource ID AnyResourceID. The engine checks the
+// permissions of the authenticated user of a context (see identity.WithAuthentication)
+// before it executes a command; calls without an authenticated user are not checked.
+// Permissions to create resources are checked against AnyResourceID.
+package authorization
+
+import (
+	"context"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// Permissions
+const (
+	PermissionAll    = "ALL"
+	PermissionCreate = "CREATE"
+	PermissionRead   = "READ"
+	PermissionUpdate = "UPDATE"
+	PermissionDelete = "DELETE"
+)
+
+// Resource types. Process definitions are identified by their key, process instances
+// and tasks by their ID.
+const (
+	ResourceProcessDefinition = "processDefinition"
+	ResourceProcessInstance   = "processInstance"
+	ResourceTask              = "task"
+)
+
+// AnyResourceID grants permissions on all resources of a type
+const AnyResourceID = "*"
+
+// AuthorizationService manages authorizations and checks permissions
+type AuthorizationService interface {
+	// Initialize initializes the authorization service
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the authorization service
+	Shutdown(ctx context.Context) error
+
+	// CreateAuthorization grants a user or a group permissions on a resource
+	CreateAuthorization(ctx context.Context, authorization *Authorization) error
+
+	// DeleteAuthorization revokes an authorization
+	DeleteAuthorization(ctx context.Context, authorizationID string) error
+
+	// GetAuthorization retrieves an authorization by ID
+	GetAuthorization(ctx context.Context, authorizationID string) (*Authorization, error)
+
+	// GetAuthorizations returns the authorizations of a resource type, and of one
+	// resource if resourceID is not empty
+	GetAuthorizations(ctx context.Context, resourceType, resourceID string) ([]*Authorization, error)
+
+	// IsUserAuthorized checks whether a user, directly or through one of the groups,
+	// has a permission on a resource
+	IsUserAuthorized(ctx context.Context, userID string, groupIDs []string, permission, resourceType, resourceID string) (bool, error)
+
+	// CheckAuthorization checks the permissions of the authenticated user of the context.
+	// It returns an error for the first permission the user lacks, and nil if the
+	// context has no authenticated user.
+	CheckAuthorization(ctx context.Context, checks ...PermissionCheck) error
+
+	// SetStore sets the store authorizations are persisted to
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock the create times of authorizations are based on
+	SetClock(c clock.Clock)
+}
+
+// Authorization grants a user or a group permissions on a resource
+type Authorization struct {
+	ID           string
+	UserID       string
+	GroupID      string
+	ResourceType string
+	ResourceID   string
+	Permissions  []string
+	CreateTime   time.Time
+}
+
+// PermissionCheck is a permission required on a resource
+type PermissionCheck struct {
+	Permission   string
+	ResourceType string
+	ResourceID   string
+}
@@ -0,0 +1,192 @@
+package authorization
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// authorizationServiceImpl is the default implementation of AuthorizationService
+type authorizationServiceImpl struct {
+	identityProvider identity.IdentityProvider
+	store            *persistence.Store
+	clock            clock.Clock
+	authorizations   map[string]*Authorization
+	mu               sync.RWMutex
+}
+
+// NewAuthorizationService creates a new authorization service. The groups of the
+// authenticated user are looked up with identityProvider, which may be nil.
+func NewAuthorizationService(identityProvider identity.IdentityProvider) AuthorizationService {
+	return &authorizationServiceImpl{
+		identityProvider: identityProvider,
+		authorizations:   make(map[string]*Authorization),
+		clock:            clock.System(),
+	}
+}
+
+// SetClock sets the clock the create times of authorizations are based on
+func (s *authorizationServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Initialize initializes the authorization service
+func (s *authorizationServiceImpl) Initialize(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+	return s.load(ctx)
+}
+
+// Shutdown gracefully shuts down the authorization service
+func (s *authorizationServiceImpl) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// CreateAuthorization grants a user or a group permissions on a resource
+func (s *authorizationServiceImpl) CreateAuthorization(ctx context.Context, authorization *Authorization) error {
+	if (authorization.UserID == "") == (authorization.GroupID == "") {
+		return fmt.Errorf("authorization must have either a user ID or a group ID")
+	}
+	if authorization.ResourceType == "" {
+		return fmt.Errorf("authorization resource type cannot be empty")
+	}
+	if authorization.ResourceID == "" {
+		return fmt.Errorf("authorization resource ID cannot be empty")
+	}
+	if len(authorization.Permissions) == 0 {
+		return fmt.Errorf("authorization must grant at least one permission")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if authorization.ID == "" {
+		authorization.ID = uuid.New().String()
+	}
+	authorization.CreateTime = s.clock.Now()
+	s.authorizations[authorization.ID] = authorization
+
+	return s.saveAuthorizationLocked(ctx, authorization.ID)
+}
+
+// DeleteAuthorization revokes an authorization
+func (s *authorizationServiceImpl) DeleteAuthorization(ctx context.Context, authorizationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.authorizations[authorizationID]; !exists {
+		return fmt.Errorf("authorization %w: %s", errors.ErrNotFound, authorizationID)
+	}
+	delete(s.authorizations, authorizationID)
+
+	return s.saveAuthorizationLocked(ctx, authorizationID)
+}
+
+// GetAuthorization retrieves an authorization by ID
+func (s *authorizationServiceImpl) GetAuthorization(ctx context.Context, authorizationID string) (*Authorization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	authorization, exists := s.authorizations[authorizationID]
+	if !exists {
+		return nil, fmt.Errorf("authorization %w: %s", errors.ErrNotFound, authorizationID)
+	}
+	return authorization, nil
+}
+
+// GetAuthorizations returns the authorizations of a resource type, and of one
+// resource if resourceID is not empty, in the order they were created
+func (s *authorizationServiceImpl) GetAuthorizations(ctx context.Context, resourceType, resourceID string) ([]*Authorization, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Authorization, 0)
+	for _, authorization := range s.authorizations {
+		if authorization.ResourceType != resourceType {
+			continue
+		}
+		if resourceID != "" && authorization.ResourceID != resourceID {
+			continue
+		}
+		result = append(result, authorization)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreateTime.Before(result[j].CreateTime)
+	})
+	return result, nil
+}
+
+// IsUserAuthorized checks whether a user, directly or through one of the groups,
+// has a permission on a resource
+func (s *authorizationServiceImpl) IsUserAuthorized(ctx context.Context, userID string, groupIDs []string, permission, resourceType, resourceID string) (bool, error) {
+	groups := make(map[string]bool, len(groupIDs))
+	for _, groupID := range groupIDs {
+		groups[groupID] = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, authorization := range s.authorizations {
+		if authorization.ResourceType != resourceType {
+			continue
+		}
+		if authorization.ResourceID != AnyResourceID && authorization.ResourceID != resourceID {
+			continue
+		}
+		if authorization.UserID != "" && authorization.UserID != userID {
+			continue
+		}
+		if authorization.GroupID != "" && !groups[authorization.GroupID] {
+			continue
+		}
+		for _, granted := range authorization.Permissions {
+			if granted == permission || granted == PermissionAll {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// CheckAuthorization checks the permissions of the authenticated user of the context
+func (s *authorizationServiceImpl) CheckAuthorization(ctx context.Context, checks ...PermissionCheck) error {
+	authentication, ok := identity.GetAuthentication(ctx)
+	if !ok || len(checks) == 0 {
+		return nil
+	}
+
+	groupIDs := append([]string(nil), authentication.GroupIDs...)
+	if s.identityProvider != nil {
+		groups, err := s.identityProvider.GetGroupsForUser(ctx, authentication.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get groups of user %s: %w", authentication.UserID, err)
+		}
+		for _, group := range groups {
+			groupIDs = append(groupIDs, group.ID)
+		}
+	}
+
+	for _, check := range checks {
+		authorized, err := s.IsUserAuthorized(ctx, authentication.UserID, groupIDs, check.Permission, check.ResourceType, check.ResourceID)
+		if err != nil {
+			return err
+		}
+		if !authorized {
+			return fmt.Errorf("user '%s' does not have %s permission on %s '%s'",
+				authentication.UserID, check.Permission, check.ResourceType, check.ResourceID)
+		}
+	}
+	return nil
+}
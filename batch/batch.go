@@ -0,0 +1,94 @@
+package batch
+
+import (
+	"context"
+	"time"
+)
+
+// Batch types of the engine
+const (
+	// TypeProcessInstanceDeletion deletes process instances, cancelling their tasks,
+	// jobs and called instances
+	TypeProcessInstanceDeletion = "process-instance-deletion"
+	// TypeHistoricProcessInstanceDeletion deletes historic process instances with their
+	// tasks, activities and variables
+	TypeHistoricProcessInstanceDeletion = "historic-process-instance-deletion"
+	// TypeHistoryCleanup deletes historic process instances whose removal time passed
+	TypeHistoryCleanup = "history-cleanup"
+)
+
+// Batch statuses
+const (
+	StatusRunning   = "running"
+	StatusSuspended = "suspended"
+	StatusCompleted = "completed"
+	StatusCanceled  = "canceled"
+)
+
+// ConfigurationDeleteReason is the configuration key of the reason deletion batches
+// delete with
+const ConfigurationDeleteReason = "deleteReason"
+
+// DefaultJobSize is the number of items a job of a batch processes
+const DefaultJobSize = 100
+
+// Batch is a long-running administrative operation on many items, e.g. process
+// instance IDs. It is split into jobs of JobSize items that a worker runs one after
+// another, so that no single command holds the engine for the whole operation. The
+// worker locks the batch, so that of the engines sharing a database one runs it.
+type Batch struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Status        string            `json:"status"`
+	Configuration map[string]string `json:"configuration,omitempty"`
+	TotalItems    int64             `json:"totalItems"`
+	JobSize       int               `json:"jobSize"`
+	TotalJobs     int64             `json:"totalJobs"`
+	CompletedJobs int64             `json:"completedJobs"`
+	// FailedJobs counts the jobs whose handler returned an error on every attempt; the
+	// batch continues with the next job
+	FailedJobs  int64  `json:"failedJobs"`
+	LastFailure string `json:"lastFailure,omitempty"`
+	// Retries is the number of attempts left of the next job
+	Retries    int        `json:"retries"`
+	CreateTime time.Time  `json:"createTime"`
+	EndTime    *time.Time `json:"endTime,omitempty"`
+	// LockOwner is the batch service running the jobs of the batch, e.g. of one of the
+	// engines sharing a database; other services wait until LockExpirationTime
+	LockOwner          string     `json:"lockOwner,omitempty"`
+	LockExpirationTime *time.Time `json:"lockExpirationTime,omitempty"`
+}
+
+// IsLocked returns whether a batch service owns the batch at the given point in time
+func (b *Batch) IsLocked(now time.Time) bool {
+	return b.LockOwner != "" && b.LockExpirationTime != nil && b.LockExpirationTime.After(now)
+}
+
+// Progress returns the finished fraction of the batch between 0 and 1, counting
+// failed jobs as finished
+func (b *Batch) Progress() float64 {
+	if b.TotalJobs == 0 {
+		return 1
+	}
+	return float64(b.CompletedJobs+b.FailedJobs) / float64(b.TotalJobs)
+}
+
+// IsEnded reports whether the batch completed or was canceled
+func (b *Batch) IsEnded() bool {
+	return b.Status == StatusCompleted || b.Status == StatusCanceled
+}
+
+// JobHandler runs the jobs of a type of batch
+type JobHandler interface {
+	// ExecuteJob runs the operation of a batch on the items of one of its jobs. An
+	// error counts the job as failed.
+	ExecuteJob(ctx context.Context, batch *Batch, items []string) error
+}
+
+// JobHandlerFunc adapts a function to a JobHandler
+type JobHandlerFunc func(ctx context.Context, batch *Batch, items []string) error
+
+// ExecuteJob calls the function
+func (f JobHandlerFunc) ExecuteJob(ctx context.Context, batch *Batch, items []string) error {
+	return f(ctx, batch, items)
+}
@@ -0,0 +1,163 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// BatchService runs long-running administrative operations as batches.
+// This service is responsible for:
+// - Creating batches, each split into jobs run one after another by a worker
+// - Running the jobs of each type of batch with the job handler registered for it
+// - Monitoring the progress of batches
+// - Suspending, activating, canceling and deleting batches
+// - Resuming the running batches of a store when the engine starts
+// - Locking running batches, so that one of the engines sharing a store runs each
+type BatchService interface {
+	// Initialize loads the batches of the store, if any, and resumes the running ones
+	Initialize(ctx context.Context) error
+
+	// Shutdown stops the workers after their current job; running batches resume on
+	// the next Initialize of a store
+	Shutdown(ctx context.Context) error
+
+	// RegisterJobHandler registers the handler running the jobs of a type of batch. It
+	// must be called before Initialize.
+	RegisterJobHandler(batchType string, handler JobHandler)
+
+	// CreateBatch creates a batch running an operation on items in jobs of the job size,
+	// and starts running it. The configuration is passed to the job handler with the batch.
+	CreateBatch(ctx context.Context, batchType string, items []string, configuration map[string]string) (*Batch, error)
+
+	// GetBatch retrieves a snapshot of the state of a batch by ID
+	GetBatch(ctx context.Context, batchID string) (*Batch, error)
+
+	// CreateBatchQuery creates a new batch query
+	CreateBatchQuery() *BatchQuery
+
+	// SuspendBatch stops running the jobs of a batch after the current one until it is activated
+	SuspendBatch(ctx context.Context, batchID string) error
+
+	// ActivateBatch continues running the jobs of a suspended batch
+	ActivateBatch(ctx context.Context, batchID string) error
+
+	// CancelBatch stops running the jobs of a batch after the current one; the jobs
+	// already run are not undone
+	CancelBatch(ctx context.Context, batchID string) error
+
+	// DeleteBatch cancels a batch if it did not end yet and removes it
+	DeleteBatch(ctx context.Context, batchID string) error
+
+	// SetJobSize sets the number of items a job of a new batch processes
+	SetJobSize(size int)
+
+	// SetStore sets the store batches are persisted to, so that running batches
+	// survive a restart of the engine
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock the create and end times of batches are based on
+	SetClock(c clock.Clock)
+
+	// SetLogger sets the logger failures of jobs and of saving batches are logged to
+	SetLogger(logger *slog.Logger)
+
+	// SetExecutorConfig sets how the workers lock batches and retry failed jobs. It
+	// must be called before Initialize.
+	SetExecutorConfig(config ExecutorConfig)
+}
+
+// ExecutorConfig configures the workers running the jobs of batches
+type ExecutorConfig struct {
+	// LockOwner is the ID the workers lock batches with, e.g. the lock owner of the
+	// job executor of the engine. Batches locked by another owner are not run until
+	// the lock expires.
+	LockOwner string
+
+	// LockDuration is the time after which batches of a service that stopped
+	// responding can be run by other services. Each finished job renews the lock.
+	LockDuration time.Duration
+
+	// PollInterval is the time between checks whether a batch locked by another
+	// service or suspended by another engine can run
+	PollInterval time.Duration
+
+	// JobRetries is the number of attempts of a job before it counts as failed
+	JobRetries int
+
+	// RetryInterval is the time before a failed job is attempted again
+	RetryInterval time.Duration
+}
+
+// DefaultExecutorConfig returns a batch executor configuration with default values
+// and a new lock owner
+func DefaultExecutorConfig() ExecutorConfig {
+	return ExecutorConfig{
+		LockOwner:     uuid.New().String(),
+		LockDuration:  5 * time.Minute,
+		PollInterval:  5 * time.Second,
+		JobRetries:    3,
+		RetryInterval: 10 * time.Second,
+	}
+}
+
+// BatchQuery provides a fluent API for querying batches
+type BatchQuery struct {
+	batchID   string
+	batchType string
+	status    string
+	service   BatchService
+}
+
+// BatchID filters by batch ID
+func (q *BatchQuery) BatchID(id string) *BatchQuery {
+	q.batchID = id
+	return q
+}
+
+// Type filters by batch type, e.g. TypeHistoryCleanup
+func (q *BatchQuery) Type(batchType string) *BatchQuery {
+	q.batchType = batchType
+	return q
+}
+
+// Status filters by status, e.g. StatusRunning
+func (q *BatchQuery) Status(status string) *BatchQuery {
+	q.status = status
+	return q
+}
+
+// List executes the query and returns snapshots of the batches ordered by create time
+func (q *BatchQuery) List(ctx context.Context) ([]*Batch, error) {
+	if impl, ok := q.service.(*batchServiceImpl); ok {
+		return impl.listBatches(q), nil
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Count returns the count of matching batches
+func (q *BatchQuery) Count(ctx context.Context) (int64, error) {
+	batches, err := q.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(batches)), nil
+}
+
+// SingleResult returns a single batch or error if not exactly one result
+func (q *BatchQuery) SingleResult(ctx context.Context) (*Batch, error) {
+	batches, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) != 1 {
+		return nil, fmt.Errorf("expected one batch, found %d", len(batches))
+	}
+	return batches[0], nil
+}
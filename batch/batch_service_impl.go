@@ -0,0 +1,571 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// batchRecord is the persisted form of a batch with the items it runs on
+type batchRecord struct {
+	Batch    *Batch   `json:"batch"`
+	Items    []string `json:"items"`
+	Revision int      `json:"revision"` // persisted revision of the record
+}
+
+// batchServiceImpl is the default implementation of BatchService. Every batch that
+// did not end has a worker goroutine running its jobs one after another while it
+// holds the lock of the batch.
+type batchServiceImpl struct {
+	handlers map[string]JobHandler
+	batches  map[string]*batchRecord
+	wake     map[string]chan struct{} // wakes the worker of a waiting batch
+	jobSize  int
+	config   ExecutorConfig
+	store    *persistence.Store
+	clock    clock.Clock
+	logger   *slog.Logger
+	context  context.Context
+	stop     context.CancelFunc
+	workers  sync.WaitGroup
+	mu       sync.RWMutex
+}
+
+// NewBatchService creates a new batch service
+func NewBatchService() BatchService {
+	workerContext, stop := context.WithCancel(context.Background())
+	return &batchServiceImpl{
+		handlers: make(map[string]JobHandler),
+		batches:  make(map[string]*batchRecord),
+		wake:     make(map[string]chan struct{}),
+		jobSize:  DefaultJobSize,
+		config:   DefaultExecutorConfig(),
+		clock:    clock.System(),
+		logger:   slog.Default(),
+		context:  workerContext,
+		stop:     stop,
+	}
+}
+
+// SetClock sets the clock the create and end times of batches are based on
+func (s *batchServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// SetLogger sets the logger failures of jobs and of saving batches are logged to
+func (s *batchServiceImpl) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// SetStore sets the store batches are persisted to
+func (s *batchServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// SetJobSize sets the number of items a job of a new batch processes
+func (s *batchServiceImpl) SetJobSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if size <= 0 {
+		size = DefaultJobSize
+	}
+	s.jobSize = size
+}
+
+// SetExecutorConfig sets how the workers lock batches and retry failed jobs. Zero
+// values in config are replaced by the defaults.
+func (s *batchServiceImpl) SetExecutorConfig(config ExecutorConfig) {
+	defaults := DefaultExecutorConfig()
+	if config.LockOwner == "" {
+		config.LockOwner = defaults.LockOwner
+	}
+	if config.LockDuration <= 0 {
+		config.LockDuration = defaults.LockDuration
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.JobRetries <= 0 {
+		config.JobRetries = defaults.JobRetries
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaults.RetryInterval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config = config
+}
+
+// RegisterJobHandler registers the handler running the jobs of a type of batch
+func (s *batchServiceImpl) RegisterJobHandler(batchType string, handler JobHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[batchType] = handler
+}
+
+// Initialize loads the batches of the store and resumes those that did not end. The
+// workers of batches locked by another service wait until the lock expires.
+func (s *batchServiceImpl) Initialize(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return persistence.Load(ctx, s.store, persistence.TableBatches, func(record *batchRecord) {
+		if record.Batch == nil {
+			return
+		}
+		if _, exists := s.batches[record.Batch.ID]; exists {
+			return
+		}
+		s.batches[record.Batch.ID] = record
+		if !record.Batch.IsEnded() {
+			s.startWorkerLocked(record.Batch.ID)
+		}
+	})
+}
+
+// Shutdown stops the workers after their current job and waits for them, at most
+// until the context is done. The workers release the locks of their batches.
+func (s *batchServiceImpl) Shutdown(ctx context.Context) error {
+	s.stop()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CreateBatch creates a batch and starts its worker
+func (s *batchServiceImpl) CreateBatch(ctx context.Context, batchType string, items []string, configuration map[string]string) (*Batch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.handlers[batchType]; !exists {
+		return nil, fmt.Errorf("no job handler registered for batch type '%s'", batchType)
+	}
+
+	batch := &Batch{
+		ID:            uuid.New().String(),
+		Type:          batchType,
+		Status:        StatusRunning,
+		Configuration: configuration,
+		TotalItems:    int64(len(items)),
+		JobSize:       s.jobSize,
+		TotalJobs:     int64((len(items) + s.jobSize - 1) / s.jobSize),
+		Retries:       s.config.JobRetries,
+		CreateTime:    s.clock.Now(),
+	}
+	record := &batchRecord{Batch: batch, Items: append([]string(nil), items...)}
+	if err := s.saveLocked(ctx, record); err != nil {
+		return nil, err
+	}
+	s.batches[batch.ID] = record
+	s.startWorkerLocked(batch.ID)
+
+	snapshot := *batch
+	return &snapshot, nil
+}
+
+// GetBatch retrieves a snapshot of a batch
+func (s *batchServiceImpl) GetBatch(ctx context.Context, batchID string) (*Batch, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, exists := s.batches[batchID]
+	if !exists {
+		return nil, fmt.Errorf("batch %w: %s", errors.ErrNotFound, batchID)
+	}
+
+	snapshot := *record.Batch
+	return &snapshot, nil
+}
+
+// CreateBatchQuery creates a new batch query
+func (s *batchServiceImpl) CreateBatchQuery() *BatchQuery {
+	return &BatchQuery{service: s}
+}
+
+// listBatches executes a batch query
+func (s *batchServiceImpl) listBatches(q *BatchQuery) []*Batch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Batch, 0)
+	for _, record := range s.batches {
+		batch := record.Batch
+		if q.batchID != "" && batch.ID != q.batchID {
+			continue
+		}
+		if q.batchType != "" && batch.Type != q.batchType {
+			continue
+		}
+		if q.status != "" && batch.Status != q.status {
+			continue
+		}
+		snapshot := *batch
+		result = append(result, &snapshot)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].CreateTime.Equal(result[j].CreateTime) {
+			return result[i].CreateTime.Before(result[j].CreateTime)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// SuspendBatch suspends a running batch
+func (s *batchServiceImpl) SuspendBatch(ctx context.Context, batchID string) error {
+	return s.setStatus(ctx, batchID, StatusRunning, StatusSuspended)
+}
+
+// ActivateBatch activates a suspended batch
+func (s *batchServiceImpl) ActivateBatch(ctx context.Context, batchID string) error {
+	return s.setStatus(ctx, batchID, StatusSuspended, StatusRunning)
+}
+
+// CancelBatch cancels a running or suspended batch
+func (s *batchServiceImpl) CancelBatch(ctx context.Context, batchID string) error {
+	return s.setStatus(ctx, batchID, "", StatusCanceled)
+}
+
+// setStatus changes the status of a batch that did not end and has the expected
+// status, if any, and wakes its worker. The status is changed on the state in the
+// store, which the worker of another engine may have advanced.
+func (s *batchServiceImpl) setStatus(ctx context.Context, batchID, expected, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.batches[batchID]
+	if exists {
+		var err error
+		if record, err = s.reloadLocked(ctx, batchID); err != nil {
+			return err
+		}
+		exists = record != nil
+	}
+	if !exists {
+		return fmt.Errorf("batch %w: %s", errors.ErrNotFound, batchID)
+	}
+	batch := record.Batch
+	if batch.IsEnded() || (expected != "" && batch.Status != expected) {
+		return fmt.Errorf("batch '%s' is %s", batchID, batch.Status)
+	}
+
+	batch.Status = status
+	if status == StatusCanceled {
+		endTime := s.clock.Now()
+		batch.EndTime = &endTime
+	}
+	s.wakeLocked(batchID)
+	return s.saveLocked(ctx, record)
+}
+
+// DeleteBatch cancels a batch and removes it
+func (s *batchServiceImpl) DeleteBatch(ctx context.Context, batchID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.batches[batchID]; !exists {
+		return fmt.Errorf("batch %w: %s", errors.ErrNotFound, batchID)
+	}
+
+	delete(s.batches, batchID)
+	s.wakeLocked(batchID)
+	if s.store != nil {
+		return s.store.Delete(ctx, persistence.TableBatches, batchID)
+	}
+	return nil
+}
+
+// startWorkerLocked starts the worker of a batch. Callers must hold the lock.
+func (s *batchServiceImpl) startWorkerLocked(batchID string) {
+	s.wake[batchID] = make(chan struct{}, 1)
+	s.workers.Add(1)
+	go s.run(batchID)
+}
+
+// wakeLocked wakes the worker of a batch waiting while it is suspended or before
+// a retry. Callers must hold the lock.
+func (s *batchServiceImpl) wakeLocked(batchID string) {
+	select {
+	case s.wake[batchID] <- struct{}{}:
+	default:
+	}
+}
+
+// wait waits until a worker is woken, the timeout passed or the service shuts down
+func (s *batchServiceImpl) wait(wake chan struct{}, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-wake:
+	case <-timer.C:
+	case <-s.context.Done():
+	}
+}
+
+// run runs the jobs of a batch one after another until it ends, is deleted or the
+// service shuts down. It waits while the batch is suspended, locked by another
+// service or before a failed job is attempted again.
+func (s *batchServiceImpl) run(batchID string) {
+	defer s.workers.Done()
+	defer s.release(batchID)
+
+	for {
+		if s.context.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		record, exists := s.batches[batchID]
+		if !exists || record.Batch.IsEnded() {
+			delete(s.wake, batchID)
+			s.mu.Unlock()
+			return
+		}
+		wake := s.wake[batchID]
+		if record.Batch.Status == StatusSuspended {
+			s.mu.Unlock()
+			s.wait(wake, s.config.PollInterval)
+			s.refresh(batchID)
+			continue
+		}
+		if !s.acquireLocked(record) {
+			s.mu.Unlock()
+			s.wait(wake, s.config.PollInterval)
+			s.refresh(batchID)
+			continue
+		}
+		// Acquiring may have read a newer state of the batch
+		if record = s.batches[batchID]; record == nil || record.Batch.IsEnded() || record.Batch.Status != StatusRunning {
+			s.mu.Unlock()
+			continue
+		}
+
+		batch := record.Batch
+		job := batch.CompletedJobs + batch.FailedJobs
+		if job >= batch.TotalJobs {
+			endTime := s.clock.Now()
+			batch.Status = StatusCompleted
+			batch.EndTime = &endTime
+			batch.LockOwner = ""
+			batch.LockExpirationTime = nil
+			s.saveOrLogLocked(record)
+			delete(s.wake, batchID)
+			s.mu.Unlock()
+			return
+		}
+		items := jobItems(record, job)
+		handler := s.handlers[batch.Type]
+		snapshot := *batch
+		s.mu.Unlock()
+
+		err := s.execute(handler, &snapshot, items)
+
+		s.mu.Lock()
+		retry := false
+		if record, exists = s.batches[batchID]; exists && record.Batch.CompletedJobs+record.Batch.FailedJobs == job {
+			batch = record.Batch
+			if batch.Retries <= 0 {
+				batch.Retries = s.config.JobRetries
+			}
+			if err != nil {
+				batch.LastFailure = err.Error()
+				batch.Retries--
+				if batch.Retries > 0 {
+					s.logger.Warn("batch job failed, retrying",
+						slog.String("batchId", batchID), slog.Int64("job", job+1), slog.Int("retries", batch.Retries), slog.Any("error", err))
+					retry = true
+				} else {
+					s.logger.Error("batch job failed",
+						slog.String("batchId", batchID), slog.Int64("job", job+1), slog.Any("error", err))
+					batch.FailedJobs++
+					batch.Retries = s.config.JobRetries
+				}
+			} else {
+				batch.CompletedJobs++
+				batch.Retries = s.config.JobRetries
+			}
+			s.renewLocked(batch)
+			s.saveOrLogLocked(record)
+		}
+		s.mu.Unlock()
+
+		if retry {
+			s.wait(wake, s.config.RetryInterval)
+		}
+	}
+}
+
+// acquireLocked locks a batch for this service, unless another service holds an
+// unexpired lock. A lock this service holds is kept; otherwise the lock is written
+// as a new revision, so that of the services racing for a batch only one wins.
+// Callers must hold the lock.
+func (s *batchServiceImpl) acquireLocked(record *batchRecord) bool {
+	batch := record.Batch
+	now := s.clock.Now()
+	if batch.IsLocked(now) {
+		return batch.LockOwner == s.config.LockOwner
+	}
+
+	s.renewLocked(batch)
+	if err := s.saveLocked(context.Background(), record); err != nil {
+		if !errors.Is(err, errors.ErrOptimisticLock) {
+			s.logger.Error("failed to lock batch", slog.String("batchId", batch.ID), slog.Any("error", err))
+		}
+		return false
+	}
+	return true
+}
+
+// renewLocked locks a batch for this service for the lock duration. Callers must
+// hold the lock.
+func (s *batchServiceImpl) renewLocked(batch *Batch) {
+	expiration := s.clock.Now().Add(s.config.LockDuration)
+	batch.LockOwner = s.config.LockOwner
+	batch.LockExpirationTime = &expiration
+}
+
+// release removes the lock this service holds on a batch, so that another service
+// can continue it right away, e.g. when the engine shuts down
+func (s *batchServiceImpl) release(batchID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, exists := s.batches[batchID]
+	if !exists || record.Batch.LockOwner != s.config.LockOwner {
+		return
+	}
+	record.Batch.LockOwner = ""
+	record.Batch.LockExpirationTime = nil
+	s.saveOrLogLocked(record)
+}
+
+// refresh reads the state of a batch from the store, e.g. after another service
+// advanced, suspended or released it
+func (s *batchServiceImpl) refresh(batchID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.batches[batchID]; !exists {
+		return
+	}
+	if _, err := s.reloadLocked(context.Background(), batchID); err != nil {
+		s.logger.Error("failed to reload batch", slog.String("batchId", batchID), slog.Any("error", err))
+	}
+}
+
+// reloadLocked replaces the state of a batch with the state in the store and returns
+// it, or nil if the batch was deleted. Callers must hold the lock.
+func (s *batchServiceImpl) reloadLocked(ctx context.Context, batchID string) (*batchRecord, error) {
+	if s.store == nil {
+		return s.batches[batchID], nil
+	}
+
+	data, err := s.store.Get(ctx, persistence.TableBatches, batchID)
+	if errors.Is(err, errors.ErrNotFound) {
+		delete(s.batches, batchID)
+		s.wakeLocked(batchID)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record := &batchRecord{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("failed to load batch %s: %w", batchID, err)
+	}
+	if record.Batch == nil {
+		return nil, fmt.Errorf("failed to load batch %s: no batch in record", batchID)
+	}
+	s.batches[batchID] = record
+	return record, nil
+}
+
+// execute runs a job with its handler, turning a panic of the handler into an error
+func (s *batchServiceImpl) execute(handler JobHandler, batch *Batch, items []string) (err error) {
+	if handler == nil {
+		return fmt.Errorf("no job handler registered for batch type '%s'", batch.Type)
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job handler panicked: %v", recovered)
+		}
+	}()
+	return handler.ExecuteJob(s.context, batch, items)
+}
+
+// jobItems returns the items of a job of a batch
+func jobItems(record *batchRecord, job int64) []string {
+	start := int(job) * record.Batch.JobSize
+	end := start + record.Batch.JobSize
+	if end > len(record.Items) {
+		end = len(record.Items)
+	}
+	return record.Items[start:end]
+}
+
+// saveLocked persists a batch as its next revision, if there is a store. When
+// another service saved the batch meanwhile, the state it saved replaces the batch
+// and an *persistence.OptimisticLockingError is returned. Callers must hold the lock.
+func (s *batchServiceImpl) saveLocked(ctx context.Context, record *batchRecord) error {
+	if s.store == nil {
+		return nil
+	}
+
+	record.Revision++
+	err := s.store.SaveRevision(ctx, persistence.TableBatches, record.Batch.ID, record, record.Revision)
+	var conflict *persistence.OptimisticLockingError
+	if errors.As(err, &conflict) {
+		// Continue from the state the other service saved
+		if _, reloadErr := s.reloadLocked(ctx, record.Batch.ID); reloadErr != nil {
+			return reloadErr
+		}
+	} else if err != nil {
+		record.Revision--
+	}
+	return err
+}
+
+// saveOrLogLocked persists the progress of a batch, logging failures: the worker
+// continues from the state in the store, and a batch resumed from an older state
+// runs some jobs again
+func (s *batchServiceImpl) saveOrLogLocked(record *batchRecord) {
+	if err := s.saveLocked(context.Background(), record); err != nil && !errors.Is(err, errors.ErrOptimisticLock) {
+		s.logger.Error("failed to save batch", slog.String("batchId", record.Batch.ID), slog.Any("error", err))
+	}
+}
@@ -0,0 +1,71 @@
+// Package clock provides the current time to the services of the engine. Services
+// read the time from a Clock instead of calling time.Now, so that tests can control
+// it with a TestClock: timers become due, tasks become overdue and history records
+// get their times deterministically.
+//
+//	testClock := clock.NewTestClock(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+//	engine, err := flowgo.NewProcessEngineBuilder().WithClock(testClock).Build()
+//	...
+//	testClock.Advance(2 * time.Hour) // timers due within two hours can be acquired
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock tells the current time
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+// System returns the clock of the operating system
+func System() Clock {
+	return systemClock{}
+}
+
+// systemClock is the clock of the operating system
+type systemClock struct{}
+
+// Now returns time.Now()
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// TestClock is a clock that stands still until it is set or advanced.
+// It is safe for concurrent use.
+type TestClock struct {
+	now time.Time
+	mu  sync.RWMutex
+}
+
+// NewTestClock creates a clock showing a point in time
+func NewTestClock(now time.Time) *TestClock {
+	return &TestClock{now: now}
+}
+
+// Now returns the time the clock shows
+func (c *TestClock) Now() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.now
+}
+
+// Set sets the time the clock shows
+func (c *TestClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = now
+}
+
+// Advance moves the clock forward by a duration and returns the new time
+func (c *TestClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	return c.now
+}
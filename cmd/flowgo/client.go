@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/rest"
+)
+
+// apiError is an error response of the REST API
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+// isNotFound tells whether an error is a response for a missing resource
+func isNotFound(err error) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound
+}
+
+// client calls the REST API of an engine
+type client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// newClient creates a client for the REST API at a base URL
+func newClient(baseURL string) *client {
+	return &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// get reads a resource into result
+func (c *client) get(path string, query url.Values, result interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(http.MethodGet, path, "", nil, result)
+}
+
+// post sends a JSON body to a resource and reads the response into result, if not nil
+func (c *client) post(path string, body interface{}, result interface{}) error {
+	content, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return c.do(http.MethodPost, path, "application/json", bytes.NewReader(content), result)
+}
+
+// deploy uploads files as a deployment
+func (c *client) deploy(name string, filterDuplicates bool, files []string) (*rest.DeploymentDTO, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("name", name); err != nil {
+		return nil, err
+	}
+	if err := form.WriteField("enableDuplicateFiltering", strconv.FormatBool(filterDuplicates)); err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		part, err := form.CreateFormFile("resource", filepath.Base(file))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	var deployment rest.DeploymentDTO
+	if err := c.do(http.MethodPost, "/deployments", form.FormDataContentType(), &body, &deployment); err != nil {
+		return nil, err
+	}
+	return &deployment, nil
+}
+
+// do sends a request and reads a JSON response into result, if not nil. Error
+// responses are returned as errors with the message of the server.
+func (c *client) do(method, path, contentType string, body io.Reader, result interface{}) error {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var body rest.ErrorDTO
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Message == "" {
+			body.Message = fmt.Sprintf("%s %s: %s", method, path, resp.Status)
+		}
+		return &apiError{status: resp.StatusCode, message: body.Message}
+	}
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/muixstudio/flowgo/api/history"
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/pkg/variable"
+	"github.com/muixstudio/flowgo/rest"
+)
+
+// runServe runs an in-memory engine serving the REST API until the process is stopped
+func runServe(_ *client, flags *flag.FlagSet, args []string) error {
+	addr := flags.String("addr", ":8080", "address to listen on")
+	historyLevel := flags.String("history", history.HistoryLevelAudit.String(), "history level: none, activity, audit or full")
+	flags.Parse(args)
+
+	level, err := history.ParseHistoryLevel(*historyLevel)
+	if err != nil {
+		return err
+	}
+	processEngine, err := engine.NewProcessEngineBuilder().
+		WithEngineName("flowgo").
+		WithHistoryLevel(level).
+		Build()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	if err := processEngine.Start(ctx); err != nil {
+		return err
+	}
+	defer processEngine.Stop(ctx)
+
+	if flags.NArg() > 0 {
+		builder := processEngine.GetRepositoryService().CreateDeployment().Name("flowgo serve")
+		for _, file := range flags.Args() {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			builder.AddResource(filepath.Base(file), content)
+		}
+		deployment, err := builder.Deploy(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("[FlowGo] Deployed %d resources as deployment %s", len(deployment.Resources), deployment.ID)
+	}
+
+	log.Printf("[FlowGo] Serving the REST API on %s", *addr)
+	return http.ListenAndServe(*addr, rest.NewServer(processEngine))
+}
+
+// runDeploy deploys process definition files as one deployment
+func runDeploy(c *client, flags *flag.FlagSet, args []string) error {
+	name := flags.String("name", "", "name of the deployment, by default the name of the first file")
+	filterDuplicates := flags.Bool("filter-duplicates", false, "keep the latest deployment of the name if the files did not change")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *name == "" {
+		*name = filepath.Base(flags.Arg(0))
+	}
+
+	deployment, err := c.deploy(*name, *filterDuplicates, flags.Args())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deployed %s (%s)\n", deployment.ID, deployment.Name)
+
+	definitions, err := c.definitions(url.Values{"deploymentId": {deployment.ID}})
+	if err != nil {
+		return err
+	}
+	printDefinitions(definitions)
+	return nil
+}
+
+// runDefinitions lists process definitions
+func runDefinitions(c *client, flags *flag.FlagSet, args []string) error {
+	key := flags.String("key", "", "only definitions with this key")
+	all := flags.Bool("all", false, "list all versions instead of the latest ones")
+	flags.Parse(args)
+
+	query := url.Values{"sortBy": {"key"}}
+	if *key != "" {
+		query.Set("key", *key)
+	}
+	if !*all {
+		query.Set("latestVersion", "true")
+	}
+	definitions, err := c.definitions(query)
+	if err != nil {
+		return err
+	}
+	printDefinitions(definitions)
+	return nil
+}
+
+// runInstances lists process instances
+func runInstances(c *client, flags *flag.FlagSet, args []string) error {
+	key := flags.String("key", "", "only instances of the process definition with this key")
+	businessKey := flags.String("business-key", "", "only instances with this business key")
+	flags.Parse(args)
+
+	query := url.Values{"sortBy": {"startTime"}}
+	if *key != "" {
+		query.Set("processDefinitionKey", *key)
+	}
+	if *businessKey != "" {
+		query.Set("businessKey", *businessKey)
+	}
+	var page rest.PageDTO[*rest.ProcessInstanceDTO]
+	if err := c.get("/process-instances", query, &page); err != nil {
+		return err
+	}
+
+	w := newTable("ID", "DEFINITION", "BUSINESS KEY", "STARTED", "STATE")
+	for _, instance := range page.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", instance.ID, instance.ProcessDefinitionID, instance.BusinessKey,
+			formatTime(&instance.StartTime), suspensionState(instance.Suspended))
+	}
+	return flushTable(w, len(page.Items), page.Count)
+}
+
+// runTasks lists tasks
+func runTasks(c *client, flags *flag.FlagSet, args []string) error {
+	assignee := flags.String("assignee", "", "only tasks assigned to this user")
+	candidateUser := flags.String("candidate-user", "", "only tasks this user is a candidate for")
+	candidateGroup := flags.String("candidate-group", "", "only tasks this group is a candidate for")
+	instance := flags.String("instance", "", "only tasks of this process instance")
+	flags.Parse(args)
+
+	query := url.Values{"sortBy": {"createTime"}}
+	if *assignee != "" {
+		query.Set("assignee", *assignee)
+	}
+	if *candidateUser != "" {
+		query.Set("candidateUser", *candidateUser)
+	}
+	if *candidateGroup != "" {
+		query.Set("candidateGroup", *candidateGroup)
+	}
+	if *instance != "" {
+		query.Set("processInstanceId", *instance)
+	}
+	var page rest.PageDTO[*rest.TaskDTO]
+	if err := c.get("/tasks", query, &page); err != nil {
+		return err
+	}
+
+	w := newTable("ID", "NAME", "ASSIGNEE", "PRIORITY", "DUE", "PROCESS INSTANCE")
+	for _, t := range page.Items {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\n", t.ID, t.Name, t.Assignee, t.Priority, formatTime(t.DueDate), t.ProcessInstanceID)
+	}
+	return flushTable(w, len(page.Items), page.Count)
+}
+
+// runStart starts a process instance of the latest version of a process definition
+func runStart(c *client, flags *flag.FlagSet, args []string) error {
+	businessKey := flags.String("business-key", "", "business key of the process instance")
+	vars := flags.String("vars", "", `variables as JSON object, e.g. '{"amount": 42}'`)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	variables, err := parseVariables(*vars)
+	if err != nil {
+		return err
+	}
+
+	var instance rest.ProcessInstanceDTO
+	start := &rest.StartProcessInstanceDTO{BusinessKey: *businessKey, Variables: variables}
+	if err := c.post("/process-definitions/key/"+url.PathEscape(flags.Arg(0))+"/start", start, &instance); err != nil {
+		return err
+	}
+	fmt.Printf("Started process instance %s of %s\n", instance.ID, instance.ProcessDefinitionID)
+	return nil
+}
+
+// runComplete completes a task
+func runComplete(c *client, flags *flag.FlagSet, args []string) error {
+	vars := flags.String("vars", "", `variables as JSON object, e.g. '{"approved": true}'`)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	variables, err := parseVariables(*vars)
+	if err != nil {
+		return err
+	}
+
+	taskID := flags.Arg(0)
+	if err := c.post("/tasks/"+url.PathEscape(taskID)+"/complete", &rest.VariablesDTO{Variables: variables}, nil); err != nil {
+		return err
+	}
+	fmt.Printf("Completed task %s\n", taskID)
+	return nil
+}
+
+// instanceDump is the state of a process instance printed by dump. A running instance
+// has a process instance; the history of an instance is included while history is enabled.
+type instanceDump struct {
+	ProcessInstance         *rest.ProcessInstanceDTO            `json:"processInstance,omitempty"`
+	HistoricProcessInstance *rest.HistoricProcessInstanceDTO    `json:"historicProcessInstance,omitempty"`
+	Variables               variable.Map                        `json:"variables"`
+	Tasks                   []*rest.TaskDTO                     `json:"tasks"`
+	Activities              []*rest.HistoricActivityInstanceDTO `json:"activities"`
+}
+
+// runDump prints the state of a process instance as JSON: the instance, its variables,
+// open tasks and the activities it passed. Ended instances are dumped from history.
+func runDump(c *client, flags *flag.FlagSet, args []string) error {
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	instanceID := flags.Arg(0)
+	byInstance := url.Values{"processInstanceId": {instanceID}}
+	dump := &instanceDump{Variables: variable.Map{}, Tasks: []*rest.TaskDTO{}}
+
+	var historicInstances rest.PageDTO[*rest.HistoricProcessInstanceDTO]
+	if err := c.get("/history/process-instances", byInstance, &historicInstances); err != nil {
+		return err
+	}
+	if len(historicInstances.Items) > 0 {
+		dump.HistoricProcessInstance = historicInstances.Items[0]
+	}
+
+	var instance rest.ProcessInstanceDTO
+	err := c.get("/process-instances/"+url.PathEscape(instanceID), nil, &instance)
+	switch {
+	case err == nil && instance.EndTime == nil:
+		dump.ProcessInstance = &instance
+		if err := c.get("/process-instances/"+url.PathEscape(instanceID)+"/variables", nil, &dump.Variables); err != nil {
+			return err
+		}
+		var tasks rest.PageDTO[*rest.TaskDTO]
+		if err := c.get("/tasks", byInstance, &tasks); err != nil {
+			return err
+		}
+		dump.Tasks = tasks.Items
+	case err == nil || isNotFound(err) && dump.HistoricProcessInstance != nil:
+		// Ended instances have no executions left, their variables are in history
+		if err == nil {
+			dump.ProcessInstance = &instance
+		}
+		var variables rest.PageDTO[*rest.HistoricVariableInstanceDTO]
+		if err := c.get("/history/variable-instances", byInstance, &variables); err != nil {
+			return err
+		}
+		for _, v := range variables.Items {
+			dump.Variables[v.Name] = v.Value.Value
+		}
+	default:
+		return err
+	}
+
+	var activities rest.PageDTO[*rest.HistoricActivityInstanceDTO]
+	query := url.Values{"processInstanceId": {instanceID}, "sortBy": {"startTime"}, "maxResults": {"1000"}}
+	if err := c.get("/history/activity-instances", query, &activities); err != nil {
+		return err
+	}
+	dump.Activities = activities.Items
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(dump)
+}
+
+// definitions lists the process definitions matching a query
+func (c *client) definitions(query url.Values) (*rest.PageDTO[*rest.ProcessDefinitionDTO], error) {
+	var page rest.PageDTO[*rest.ProcessDefinitionDTO]
+	if err := c.get("/process-definitions", query, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// printDefinitions prints process definitions as table
+func printDefinitions(page *rest.PageDTO[*rest.ProcessDefinitionDTO]) {
+	w := newTable("KEY", "VERSION", "NAME", "ID", "STATE")
+	for _, definition := range page.Items {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", definition.Key, definition.Version, definition.Name, definition.ID, suspensionState(definition.Suspended))
+	}
+	flushTable(w, len(page.Items), page.Count)
+}
+
+// parseVariables parses variables given as JSON object. Values may be plain JSON or
+// typed as {"type": "long", "value": 42}.
+func parseVariables(vars string) (variable.Map, error) {
+	if vars == "" {
+		return nil, nil
+	}
+	var variables variable.Map
+	if err := json.Unmarshal([]byte(vars), &variables); err != nil {
+		return nil, fmt.Errorf("invalid variables: %v", err)
+	}
+	return variables, nil
+}
+
+// newTable returns a writer aligning the columns of a table with a header
+func newTable(columns ...string) *tabwriter.Writer {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for i, column := range columns {
+		if i > 0 {
+			fmt.Fprint(w, "\t")
+		}
+		fmt.Fprint(w, column)
+	}
+	fmt.Fprintln(w)
+	return w
+}
+
+// flushTable writes a table, noting when a list was truncated
+func flushTable(w *tabwriter.Writer, shown int, count int64) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if int64(shown) < count {
+		fmt.Printf("(%d of %d shown)\n", shown, count)
+	}
+	return nil
+}
+
+// formatTime formats an optional time for tables
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// suspensionState returns the state of a suspendable entity for tables
+func suspensionState(suspended bool) string {
+	if suspended {
+		return "suspended"
+	}
+	return "active"
+}
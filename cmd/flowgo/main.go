@@ -0,0 +1,91 @@
+// Command flowgo operates a FlowGo engine from the command line. It talks to the REST
+// API of an engine, e.g. one started with "flowgo serve", to deploy process definitions,
+// start process instances, work on tasks and inspect the state of process instances.
+//
+//	flowgo serve -addr :8080 leave_approval.json
+//	flowgo start -vars '{"days": 3}' leave_approval
+//	flowgo tasks -assignee john.doe
+//	flowgo complete -vars '{"approved": true}' <task-id>
+//	flowgo dump <process-instance-id>
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// defaultServer is the REST API used when neither -server nor FLOWGO_SERVER is set
+const defaultServer = "http://localhost:8080"
+
+// command is a subcommand of flowgo
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(c *client, flags *flag.FlagSet, args []string) error
+}
+
+// commands are the subcommands of flowgo in the order of the usage
+var commands = []*command{
+	{name: "serve", usage: "serve [-addr address] [-history level] [file...]", summary: "run an in-memory engine serving the REST API, deploying the files", run: runServe},
+	{name: "deploy", usage: "deploy [-name name] [-filter-duplicates] file...", summary: "deploy process definition files", run: runDeploy},
+	{name: "definitions", usage: "definitions [-key key] [-all]", summary: "list the latest versions of process definitions", run: runDefinitions},
+	{name: "instances", usage: "instances [-key key] [-business-key key]", summary: "list process instances", run: runInstances},
+	{name: "tasks", usage: "tasks [-assignee user] [-candidate-user user] [-candidate-group group] [-instance id]", summary: "list tasks", run: runTasks},
+	{name: "start", usage: "start [-business-key key] [-vars json] definition-key", summary: "start a process instance", run: runStart},
+	{name: "complete", usage: "complete [-vars json] task-id", summary: "complete a task", run: runComplete},
+	{name: "dump", usage: "dump instance-id", summary: "print the state of a process instance as JSON", run: runDump},
+}
+
+func main() {
+	flags := flag.NewFlagSet("flowgo", flag.ExitOnError)
+	server := flags.String("server", serverFromEnv(), "base URL of the REST API")
+	flags.Usage = usage
+	flags.Parse(os.Args[1:])
+
+	if flags.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name, args := flags.Arg(0), flags.Args()[1:]
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(newClient(*server), newFlagSet(cmd), args); err != nil {
+				fmt.Fprintf(os.Stderr, "flowgo %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "flowgo: unknown command %q\n", name)
+	usage()
+	os.Exit(2)
+}
+
+// serverFromEnv returns the REST API named by FLOWGO_SERVER, or the default one
+func serverFromEnv() string {
+	if server := os.Getenv("FLOWGO_SERVER"); server != "" {
+		return server
+	}
+	return defaultServer
+}
+
+// usage prints the usage of flowgo
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: flowgo [-server url] command [arguments]\n\nCommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nThe server defaults to $FLOWGO_SERVER or %s.\nRun 'flowgo command -h' for the arguments of a command.\n", defaultServer)
+}
+
+// newFlagSet returns the flags of a command, printing its usage on errors
+func newFlagSet(cmd *command) *flag.FlagSet {
+	flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: flowgo %s\n", cmd.usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
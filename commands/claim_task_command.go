@@ -4,7 +4,12 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/muixstudio/flowgo/authorization"
 	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/history"
+	"github.com/muixstudio/flowgo/internal/task"
+	"github.com/muixstudio/flowgo/tracing"
 )
 
 // ClaimTaskCommand claims a task for a user
@@ -13,8 +18,8 @@ type ClaimTaskCommand struct {
 	UserID string
 }
 
-// Execute claims the task
-func (c *ClaimTaskCommand) Execute(ctx context.Context, commandContext *engine.CommandContext) (interface{}, error) {
+// Execute claims the task and returns it with its new assignee
+func (c *ClaimTaskCommand) Execute(ctx context.Context, commandContext *engine.CommandContext) (*task.Task, error) {
 	if c.TaskID == "" {
 		return nil, fmt.Errorf("task ID cannot be empty")
 	}
@@ -32,7 +37,7 @@ func (c *ClaimTaskCommand) Execute(ctx context.Context, commandContext *engine.C
 
 	// Check if task is already claimed by another user
 	if task.Assignee != "" && task.Assignee != c.UserID {
-		return nil, fmt.Errorf("task '%s' is already claimed by user '%s'", c.TaskID, task.Assignee)
+		return nil, fmt.Errorf("task '%s' is %w by user '%s'", c.TaskID, errors.ErrAlreadyClaimed, task.Assignee)
 	}
 
 	// Check if user is a candidate for this task
@@ -55,11 +60,23 @@ func (c *ClaimTaskCommand) Execute(ctx context.Context, commandContext *engine.C
 	}
 
 	// Record to history if enabled
-	if commandContext.Engine.GetConfiguration().EnableHistory {
+	if commandContext.Engine.GetConfiguration().HistoryLevel != history.HistoryLevelNone {
 		// TODO: Record task claim event
 	}
 
-	return nil, nil
+	return taskService.GetTask(ctx, c.TaskID)
+}
+
+// RequiredPermissions requires UPDATE permission on the task
+func (c *ClaimTaskCommand) RequiredPermissions(ctx context.Context, processEngine engine.ProcessEngine) ([]authorization.PermissionCheck, error) {
+	return []authorization.PermissionCheck{
+		{Permission: authorization.PermissionUpdate, ResourceType: authorization.ResourceTask, ResourceID: c.TaskID},
+	}, nil
+}
+
+// TraceAttributes describes the span with the task and its process instance
+func (c *ClaimTaskCommand) TraceAttributes(ctx context.Context, processEngine engine.ProcessEngine) []tracing.Attribute {
+	return taskTraceAttributes(ctx, processEngine, c.TaskID)
 }
 
 // NewClaimTaskCommand creates a new claim task command
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/muixstudio/flowgo/authorization"
 	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/history"
+	"github.com/muixstudio/flowgo/tracing"
 )
 
 // CompleteTaskCommand completes a user task
@@ -14,9 +18,9 @@ type CompleteTaskCommand struct {
 }
 
 // Execute completes the task
-func (c *CompleteTaskCommand) Execute(ctx context.Context, commandContext *engine.CommandContext) (interface{}, error) {
+func (c *CompleteTaskCommand) Execute(ctx context.Context, commandContext *engine.CommandContext) (struct{}, error) {
 	if c.TaskID == "" {
-		return nil, fmt.Errorf("task ID cannot be empty")
+		return struct{}{}, fmt.Errorf("task ID cannot be empty")
 	}
 
 	taskService := commandContext.Engine.GetTaskService()
@@ -25,28 +29,28 @@ func (c *CompleteTaskCommand) Execute(ctx context.Context, commandContext *engin
 	// Get the task to verify it exists
 	task, err := taskService.GetTask(ctx, c.TaskID)
 	if err != nil {
-		return nil, fmt.Errorf("task not found: %w", err)
+		return struct{}{}, fmt.Errorf("task not found: %w", err)
 	}
 
 	// Check if task is suspended
 	if task.Suspended {
-		return nil, fmt.Errorf("cannot complete suspended task '%s'", c.TaskID)
+		return struct{}{}, fmt.Errorf("cannot complete task: %w", &errors.SuspendedError{EntityType: "task", ID: c.TaskID})
 	}
 
 	// Set variables on the execution if provided
 	if c.Variables != nil && len(c.Variables) > 0 && task.ExecutionID != "" {
 		if err := runtimeService.SetVariables(ctx, task.ExecutionID, c.Variables); err != nil {
-			return nil, fmt.Errorf("failed to set variables: %w", err)
+			return struct{}{}, fmt.Errorf("failed to set variables: %w", err)
 		}
 	}
 
 	// Complete the task
 	if err := taskService.Complete(ctx, c.TaskID); err != nil {
-		return nil, fmt.Errorf("failed to complete task: %w", err)
+		return struct{}{}, fmt.Errorf("failed to complete task: %w", err)
 	}
 
 	// Record to history if enabled
-	if commandContext.Engine.GetConfiguration().EnableHistory {
+	if commandContext.Engine.GetConfiguration().HistoryLevel != history.HistoryLevelNone {
 		// TODO: Record historic task instance completion
 	}
 
@@ -56,7 +60,19 @@ func (c *CompleteTaskCommand) Execute(ctx context.Context, commandContext *engin
 	// 2. Evaluating conditions on outgoing edges
 	// 3. Creating new tasks or executing service tasks
 
-	return nil, nil
+	return struct{}{}, nil
+}
+
+// RequiredPermissions requires UPDATE permission on the task
+func (c *CompleteTaskCommand) RequiredPermissions(ctx context.Context, processEngine engine.ProcessEngine) ([]authorization.PermissionCheck, error) {
+	return []authorization.PermissionCheck{
+		{Permission: authorization.PermissionUpdate, ResourceType: authorization.ResourceTask, ResourceID: c.TaskID},
+	}, nil
+}
+
+// TraceAttributes describes the span with the task and its process instance
+func (c *CompleteTaskCommand) TraceAttributes(ctx context.Context, processEngine engine.ProcessEngine) []tracing.Attribute {
+	return taskTraceAttributes(ctx, processEngine, c.TaskID)
 }
 
 // NewCompleteTaskCommand creates a new complete task command
@@ -4,8 +4,9 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/muixstudio/flowgo/authorization"
 	"github.com/muixstudio/flowgo/engine"
-	"github.com/muixstudio/flowgo/repository"
+	"github.com/muixstudio/flowgo/internal/repository"
 )
 
 // DeployCommand deploys a process definition
@@ -45,6 +46,13 @@ func (c *DeployCommand) Execute(ctx context.Context, commandContext *engine.Comm
 	return deployment, nil
 }
 
+// RequiredPermissions requires CREATE permission on process definitions
+func (c *DeployCommand) RequiredPermissions(ctx context.Context, processEngine engine.ProcessEngine) ([]authorization.PermissionCheck, error) {
+	return []authorization.PermissionCheck{
+		{Permission: authorization.PermissionCreate, ResourceType: authorization.ResourceProcessDefinition, ResourceID: authorization.AnyResourceID},
+	}, nil
+}
+
 // NewDeployCommand creates a new deploy command
 func NewDeployCommand(name, resourceName string, content []byte) *DeployCommand {
 	return &DeployCommand{
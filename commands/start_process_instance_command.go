@@ -4,8 +4,12 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/muixstudio/flowgo/authorization"
 	"github.com/muixstudio/flowgo/engine"
-	"github.com/muixstudio/flowgo/runtime"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/history"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/tracing"
 )
 
 // StartProcessInstanceCommand starts a new process instance
@@ -32,7 +36,7 @@ func (c *StartProcessInstanceCommand) Execute(ctx context.Context, commandContex
 			return nil, fmt.Errorf("process definition not found: %w", err)
 		}
 		if processDef.Suspended {
-			return nil, fmt.Errorf("cannot start process instance: process definition '%s' is suspended", c.ProcessDefinitionID)
+			return nil, fmt.Errorf("cannot start process instance: %w", &errors.SuspendedError{EntityType: "process definition", ID: c.ProcessDefinitionID})
 		}
 
 		if c.BusinessKey != "" {
@@ -47,7 +51,7 @@ func (c *StartProcessInstanceCommand) Execute(ctx context.Context, commandContex
 			return nil, fmt.Errorf("process definition not found with key '%s': %w", c.ProcessDefinitionKey, err)
 		}
 		if processDef.Suspended {
-			return nil, fmt.Errorf("cannot start process instance: process definition '%s' is suspended", c.ProcessDefinitionKey)
+			return nil, fmt.Errorf("cannot start process instance: %w", &errors.SuspendedError{EntityType: "process definition", ID: c.ProcessDefinitionKey})
 		}
 
 		if c.BusinessKey != "" {
@@ -64,13 +68,43 @@ func (c *StartProcessInstanceCommand) Execute(ctx context.Context, commandContex
 	}
 
 	// Record to history if enabled
-	if commandContext.Engine.GetConfiguration().EnableHistory {
+	if commandContext.Engine.GetConfiguration().HistoryLevel != history.HistoryLevelNone {
 		// TODO: Record historic process instance
 	}
 
 	return instance, nil
 }
 
+// RequiredPermissions requires READ permission on the process definition and
+// CREATE permission on process instances
+func (c *StartProcessInstanceCommand) RequiredPermissions(ctx context.Context, processEngine engine.ProcessEngine) ([]authorization.PermissionCheck, error) {
+	processDefinitionKey := c.ProcessDefinitionKey
+	if c.ProcessDefinitionID != "" {
+		processDef, err := processEngine.GetRepositoryService().GetProcessDefinition(ctx, c.ProcessDefinitionID)
+		if err != nil {
+			return nil, fmt.Errorf("process definition not found: %w", err)
+		}
+		processDefinitionKey = processDef.Key
+	}
+	return []authorization.PermissionCheck{
+		{Permission: authorization.PermissionRead, ResourceType: authorization.ResourceProcessDefinition, ResourceID: processDefinitionKey},
+		{Permission: authorization.PermissionCreate, ResourceType: authorization.ResourceProcessInstance, ResourceID: authorization.AnyResourceID},
+	}, nil
+}
+
+// TraceAttributes describes the span with the process definition; the process instance
+// is added when it started
+func (c *StartProcessInstanceCommand) TraceAttributes(ctx context.Context, processEngine engine.ProcessEngine) []tracing.Attribute {
+	attributes := make([]tracing.Attribute, 0, 2)
+	if c.ProcessDefinitionID != "" {
+		attributes = append(attributes, tracing.String(tracing.AttributeProcessDefinitionID, c.ProcessDefinitionID))
+	}
+	if c.ProcessDefinitionKey != "" {
+		attributes = append(attributes, tracing.String(tracing.AttributeProcessDefinitionKey, c.ProcessDefinitionKey))
+	}
+	return attributes
+}
+
 // NewStartProcessInstanceByKeyCommand creates a command to start a process by key
 func NewStartProcessInstanceByKeyCommand(key string, variables map[string]interface{}) *StartProcessInstanceCommand {
 	return &StartProcessInstanceCommand{
@@ -0,0 +1,22 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/tracing"
+)
+
+// taskTraceAttributes returns the span attributes of a command working on a task: the
+// task and, if it still exists, its process instance and definition
+func taskTraceAttributes(ctx context.Context, processEngine engine.ProcessEngine, taskID string) []tracing.Attribute {
+	attributes := []tracing.Attribute{tracing.String(tracing.AttributeTaskID, taskID)}
+	task, err := processEngine.GetTaskService().GetTask(ctx, taskID)
+	if err != nil || task.ProcessInstanceID == "" {
+		return attributes
+	}
+	return append(attributes,
+		tracing.String(tracing.AttributeProcessInstanceID, task.ProcessInstanceID),
+		tracing.String(tracing.AttributeProcessDefinitionID, task.ProcessDefinitionID),
+	)
+}
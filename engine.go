@@ -1,62 +1,41 @@
+// Package flowgo is the entry point of the FlowGo workflow engine.
+//
+// The packages under api define the interfaces and types of the repository, runtime,
+// task and history services, whose implementations live under internal. This package
+// gives access to the engine that runs them:
+//
+//	processEngine, err := flowgo.NewProcessEngineBuilder().
+//		WithEngineName("my-engine").
+//		Build()
 package flowgo
 
 import (
-	"context"
-
-	"github.com/muixstudio/flowgo/api/history"
-	"github.com/muixstudio/flowgo/api/repository"
-	"github.com/muixstudio/flowgo/api/runtime"
-	"github.com/muixstudio/flowgo/api/task"
-	"github.com/muixstudio/flowgo/internal/engine"
+	"github.com/muixstudio/flowgo/engine"
 )
 
 // ProcessEngine is the main entry point for the FlowGo workflow engine.
 // It provides access to all core services and manages the engine lifecycle.
-type ProcessEngine interface {
-	// GetRepositoryService returns the repository service for managing process definitions
-	GetRepositoryService() repository.Service
-
-	// GetRuntimeService returns the runtime service for managing process instances
-	GetRuntimeService() runtime.Service
-
-	// GetTaskService returns the task service for managing user tasks
-	GetTaskService() task.Service
-
-	// GetHistoryService returns the history service for querying historical data
-	GetHistoryService() history.Service
-
-	// Start initializes and starts the process engine
-	Start(ctx context.Context) error
+type ProcessEngine = engine.ProcessEngine
 
-	// Stop gracefully shuts down the process engine
-	Stop(ctx context.Context) error
+// Configuration holds the configuration for creating a ProcessEngine
+type Configuration = engine.ProcessEngineConfiguration
 
-	// GetName returns the name of this process engine
-	GetName() string
+// Builder provides a fluent API for building a ProcessEngine
+type Builder = engine.ProcessEngineBuilder
 
-	// IsRunning returns whether the engine is currently running
-	IsRunning() bool
+// DefaultConfiguration returns a configuration with sensible default values
+func DefaultConfiguration() *Configuration {
+	return engine.DefaultProcessEngineConfiguration()
 }
 
 // NewProcessEngine creates a new ProcessEngine with the given configuration.
 // This is the primary way to create a process engine instance.
 func NewProcessEngine(config *Configuration) (ProcessEngine, error) {
-	internalConfig := &engine.Configuration{
-		EngineName:     config.EngineName,
-		DatabaseDriver: config.DatabaseDriver,
-		DatabaseURL:    config.DatabaseURL,
-		EnableHistory:  config.EnableHistory,
-		EnableAsync:    config.EnableAsync,
-		MaxPoolSize:    config.MaxPoolSize,
-		IdleTimeout:    config.IdleTimeout,
-	}
-	return engine.NewEngine(internalConfig)
+	return engine.NewProcessEngine(config)
 }
 
 // NewProcessEngineBuilder creates a new builder for constructing a process engine.
 // This provides a fluent API for engine configuration.
 func NewProcessEngineBuilder() *Builder {
-	return &Builder{
-		config: DefaultConfiguration(),
-	}
+	return engine.NewProcessEngineBuilder()
 }
@@ -1,6 +1,13 @@
 package engine
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/authorization"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/tracing"
+)
 
 // Command represents an operation that can be executed by the process engine.
 // All operations on the engine are encapsulated as commands.
@@ -9,6 +16,61 @@ type Command[T any] interface {
 	Execute(ctx context.Context, commandContext *CommandContext) (T, error)
 }
 
+// ExecuteCommand executes a command through the command executor of an engine and
+// returns its result with the type of the command, e.g. the *repository.Deployment of a
+// deploy command, so that callers need no type assertion
+func ExecuteCommand[T any](ctx context.Context, engine ProcessEngine, command Command[T]) (T, error) {
+	var zero T
+	result, err := engine.ExecuteCommand(ctx, &typedCommand[T]{command: command})
+	if err != nil || result == nil {
+		return zero, err
+	}
+	typed, ok := result.(T)
+	if !ok {
+		return zero, fmt.Errorf("command %T returned %T instead of %T", command, result, zero)
+	}
+	return typed, nil
+}
+
+// typedCommand adapts a command with a typed result to the interceptor chain, which
+// executes commands with results of any type
+type typedCommand[T any] struct {
+	command Command[T]
+}
+
+// Execute runs the adapted command
+func (c *typedCommand[T]) Execute(ctx context.Context, commandContext *CommandContext) (any, error) {
+	return c.command.Execute(ctx, commandContext)
+}
+
+// unwrap returns the adapted command
+func (c *typedCommand[T]) unwrap() any {
+	return c.command
+}
+
+// unwrapCommand returns the command an adapter of a typed command wraps, for
+// interceptors that inspect commands, or the command itself
+func unwrapCommand(command Command[any]) any {
+	if adapter, ok := command.(interface{ unwrap() any }); ok {
+		return adapter.unwrap()
+	}
+	return command
+}
+
+// AuthorizedCommand is a command that requires permissions of the authenticated user.
+// The AuthorizationInterceptor checks them before the command executes.
+type AuthorizedCommand interface {
+	// RequiredPermissions returns the permissions the command requires
+	RequiredPermissions(ctx context.Context, engine ProcessEngine) ([]authorization.PermissionCheck, error)
+}
+
+// TracedCommand is a command that describes its span with attributes, e.g. the process
+// instance it works on. The TracingInterceptor adds them before the command executes.
+type TracedCommand interface {
+	// TraceAttributes returns the attributes of the span of the command
+	TraceAttributes(ctx context.Context, engine ProcessEngine) []tracing.Attribute
+}
+
 // CommandExecutor is responsible for executing commands.
 // It manages the command execution lifecycle including interceptors and transaction management.
 type CommandExecutor interface {
@@ -25,8 +87,9 @@ type CommandContext struct {
 	// Engine is the process engine instance
 	Engine *ProcessEngineImpl
 
-	// Session holds the current database session/transaction
-	Session interface{}
+	// Session is the unit of work collecting the writes of the command, flushed by the
+	// TransactionInterceptor; nil without database
+	Session *persistence.Session
 
 	// Attributes stores custom attributes for this command execution
 	Attributes map[string]interface{}
@@ -40,9 +103,11 @@ type CommandContext struct {
 
 // NewCommandContext creates a new command context
 func NewCommandContext(ctx context.Context, engine *ProcessEngineImpl) *CommandContext {
+	session, _ := persistence.GetSession(ctx)
 	return &CommandContext{
 		Context:    ctx,
 		Engine:     engine,
+		Session:    session,
 		Attributes: make(map[string]interface{}),
 	}
 }
@@ -82,11 +147,9 @@ func (c *CommandContext) SetResult(result interface{}) {
 	c.Result = result
 }
 
-// Close releases resources associated with this command context
+// Close releases resources associated with this command context. The session is
+// flushed or discarded by the TransactionInterceptor.
 func (c *CommandContext) Close() error {
-	// Clean up session/transaction if any
-	if c.Session != nil {
-		// TODO: Close database session
-	}
+	c.Session = nil
 	return nil
 }
@@ -3,6 +3,8 @@ package engine
 import (
 	"context"
 	"fmt"
+
+	"github.com/muixstudio/flowgo/tracing"
 )
 
 // CommandExecutorImpl is the default implementation of CommandExecutor
@@ -65,12 +67,15 @@ func (i *CommandInvoker) Execute(ctx context.Context, command Command[any], exec
 
 // DefaultCommandExecutorBuilder helps build a CommandExecutor with default interceptors
 type DefaultCommandExecutorBuilder struct {
-	engine            *ProcessEngineImpl
-	interceptors      []CommandInterceptor
-	enableLogging     bool
-	enableTransaction bool
-	enableRetry       bool
-	retryAttempts     int
+	engine              *ProcessEngineImpl
+	interceptors        []CommandInterceptor
+	enableLogging       bool
+	enableMetrics       bool
+	tracer              tracing.Tracer
+	enableTransaction   bool
+	enableAuthorization bool
+	enableRetry         bool
+	retryAttempts       int
 }
 
 // NewDefaultCommandExecutorBuilder creates a new builder
@@ -91,12 +96,32 @@ func (b *DefaultCommandExecutorBuilder) WithLogging(enabled bool) *DefaultComman
 	return b
 }
 
+// WithMetrics enables or disables metrics interceptor, recording to the metrics of
+// the engine
+func (b *DefaultCommandExecutorBuilder) WithMetrics(enabled bool) *DefaultCommandExecutorBuilder {
+	b.enableMetrics = enabled
+	return b
+}
+
+// WithTracer enables tracing interceptor reporting spans to the tracer; a nil tracer
+// disables it
+func (b *DefaultCommandExecutorBuilder) WithTracer(tracer tracing.Tracer) *DefaultCommandExecutorBuilder {
+	b.tracer = tracer
+	return b
+}
+
 // WithTransaction enables or disables transaction interceptor
 func (b *DefaultCommandExecutorBuilder) WithTransaction(enabled bool) *DefaultCommandExecutorBuilder {
 	b.enableTransaction = enabled
 	return b
 }
 
+// WithAuthorization enables or disables authorization interceptor
+func (b *DefaultCommandExecutorBuilder) WithAuthorization(enabled bool) *DefaultCommandExecutorBuilder {
+	b.enableAuthorization = enabled
+	return b
+}
+
 // WithRetry enables retry interceptor with specified attempts
 func (b *DefaultCommandExecutorBuilder) WithRetry(enabled bool, attempts int) *DefaultCommandExecutorBuilder {
 	b.enableRetry = enabled
@@ -116,20 +141,37 @@ func (b *DefaultCommandExecutorBuilder) Build() *CommandExecutorImpl {
 
 	// Add logging interceptor first (outermost)
 	if b.enableLogging {
-		interceptors = append(interceptors, NewLoggingInterceptor())
+		interceptors = append(interceptors, NewLoggingInterceptor(b.engine.logger))
+	}
+
+	// Add tracing interceptor, whose span encloses the retries of a command
+	if b.tracer != nil {
+		interceptors = append(interceptors, NewTracingInterceptor(b.engine, b.tracer))
+	}
+
+	// Add metrics interceptor, measuring commands including their retries
+	if b.enableMetrics {
+		interceptors = append(interceptors, NewMetricsInterceptor(b.engine.metrics))
 	}
 
 	// Add retry interceptor
 	if b.enableRetry {
-		interceptors = append(interceptors, NewRetryInterceptor(b.retryAttempts, 0))
+		retry := NewRetryInterceptor(b.retryAttempts, 0)
+		retry.logger = b.engine.logger
+		interceptors = append(interceptors, retry)
 	}
 
 	// Add custom interceptors
 	interceptors = append(interceptors, b.interceptors...)
 
+	// Add authorization interceptor, rejecting commands before a transaction starts
+	if b.enableAuthorization {
+		interceptors = append(interceptors, NewAuthorizationInterceptor(b.engine))
+	}
+
 	// Add transaction interceptor
 	if b.enableTransaction {
-		interceptors = append(interceptors, NewTransactionInterceptor())
+		interceptors = append(interceptors, NewTransactionInterceptor(b.engine))
 	}
 
 	// Add context interceptor (must be before invoker)
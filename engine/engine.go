@@ -2,11 +2,24 @@ package engine
 
 import (
 	"context"
+	"log/slog"
+	"time"
 
-	"github.com/muixstudio/flowgo/history"
-	"github.com/muixstudio/flowgo/repository"
-	"github.com/muixstudio/flowgo/runtime"
-	"github.com/muixstudio/flowgo/task"
+	"github.com/muixstudio/flowgo/authorization"
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/externaltask"
+	"github.com/muixstudio/flowgo/form"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/history"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/internal/task"
+	"github.com/muixstudio/flowgo/job"
+	"github.com/muixstudio/flowgo/management"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/rules"
+	"github.com/muixstudio/flowgo/tracing"
 )
 
 // ProcessEngine is the main entry point for the FlowGo workflow engine.
@@ -21,11 +34,41 @@ type ProcessEngine interface {
 	// GetTaskService returns the task service for managing user tasks
 	GetTaskService() task.TaskService
 
+	// GetExternalTaskService returns the external task service for workers running outside the engine
+	GetExternalTaskService() externaltask.ExternalTaskService
+
 	// GetHistoryService returns the history service for querying historical data
 	GetHistoryService() history.HistoryService
 
-	// Execute executes a command through the command executor
-	//Execute[T any](ctx context.Context, command Command[T]) (T, error)
+	// GetJobService returns the job service running async continuations and timers in the background
+	GetJobService() job.JobService
+
+	// GetManagementService returns the management service for jobs, tables and engine properties
+	GetManagementService() management.ManagementService
+
+	// GetFormService returns the form service for start and task forms
+	GetFormService() form.FormService
+
+	// GetRulesService returns the rules service for evaluating decision tables
+	GetRulesService() rules.RulesService
+
+	// GetBatchService returns the batch service running long-running administrative
+	// operations, e.g. bulk deletions, in the background
+	GetBatchService() batch.BatchService
+
+	// GetIdentityProvider returns the provider of users and groups
+	GetIdentityProvider() identity.IdentityProvider
+
+	// GetAuthorizationService returns the authorization service for managing permissions
+	GetAuthorizationService() authorization.AuthorizationService
+
+	// GetMetrics returns the count, duration and failures of the commands executed, and
+	// the numbers of active process instances, open tasks and pending jobs
+	GetMetrics(ctx context.Context) (*EngineMetrics, error)
+
+	// ExecuteCommand executes a command through the command executor. Use the
+	// package-level ExecuteCommand for commands with typed results.
+	ExecuteCommand(ctx context.Context, command Command[any]) (any, error)
 
 	// Start initializes and starts the process engine
 	Start(ctx context.Context) error
@@ -45,34 +88,125 @@ type ProcessEngineConfiguration struct {
 	// EngineName is the name of the engine instance
 	EngineName string
 
-	// DatabaseDriver is the database driver to use (e.g., "postgres", "mysql")
+	// DatabaseDriver is the database driver to use (e.g., "postgres", "mysql", "sqlite"),
+	// or "memory" to keep the state in memory, e.g. for tests and demos.
+	// The matching database/sql driver package must be imported by the application.
 	DatabaseDriver string
 
-	// DatabaseURL is the connection string for the database
+	// DatabaseURL is the connection string for the database.
+	// When empty, the engine keeps its state in memory as with the "memory" driver.
 	DatabaseURL string
 
-	// EnableHistory determines if history data should be recorded
-	EnableHistory bool
+	// SchemaUpdate is what Start does with the schema of the database: apply the
+	// pending migrations (persistence.SchemaUpdateMigrate, the default), or only check
+	// that the schema is up to date (persistence.SchemaUpdateValidate)
+	SchemaUpdate persistence.SchemaUpdate
+
+	// FlushBatchSize is the number of documents a command writes with one statement,
+	// e.g. the history of many started process instances with multi-row inserts;
+	// 1 writes every document with its own statements. When zero,
+	// persistence.DefaultBatchSize is used.
+	FlushBatchSize int
+
+	// Storage is a custom backend the engine keeps its state in. When set, it is used
+	// instead of DatabaseDriver and DatabaseURL.
+	Storage persistence.Storage
+
+	// DataManagers keep some kinds of entities, e.g. process instances or history, in
+	// custom data stores. The other entities stay in the database or storage.
+	DataManagers *persistence.DataManagers
+
+	// HistoryLevel controls how much history data is recorded, from none to the
+	// full detail of every variable update
+	HistoryLevel history.HistoryLevel
+
+	// HistoryCleanupInterval is the interval history cleanup removes the history of
+	// finished process instances whose history time to live expired; zero disables it
+	HistoryCleanupInterval time.Duration
+
+	// HistoryArchiver exports historic process instances before they are deleted.
+	// When nil, they are deleted without export.
+	HistoryArchiver history.HistoryArchiver
+
+	// TaskSLAMonitorInterval is the interval the task service checks for tasks whose
+	// due or follow-up date passed and notifies their listeners; zero disables it
+	TaskSLAMonitorInterval time.Duration
+
+	// BatchJobSize is the number of items, e.g. process instances, a job of a batch
+	// processes. When zero, batch.DefaultJobSize is used.
+	BatchJobSize int
 
 	// EnableAsync determines if async executors should be enabled
 	EnableAsync bool
 
+	// JobWorkers is the number of jobs the job executor runs concurrently
+	JobWorkers int
+
+	// JobPollInterval is the time between job acquisitions when no jobs were due
+	JobPollInterval time.Duration
+
+	// JobLockDuration is the time a job executor owns the jobs it acquired
+	JobLockDuration time.Duration
+
 	// MaxPoolSize is the maximum number of database connections
 	MaxPoolSize int
 
 	// IdleTimeout is the idle timeout for database connections
 	IdleTimeout int
+
+	// ProcessDefinitionCacheLimit is the maximum number of parsed process models kept
+	// in memory; the least recently used are evicted first. Zero means unlimited.
+	ProcessDefinitionCacheLimit int
+
+	// ContentStore keeps large binary and JSON variable values, e.g. in an object storage.
+	// When nil, they are kept in a table of the database.
+	ContentStore persistence.ContentStore
+
+	// IdentityProvider provides users and groups, e.g. from an LDAP directory.
+	// When nil, the engine uses a built-in in-memory identity store.
+	IdentityProvider identity.IdentityProvider
+
+	// EnableAuthorization determines if commands check the permissions of the
+	// authenticated user
+	EnableAuthorization bool
+
+	// EnableMetrics determines if the count, duration and failures of commands are recorded
+	EnableMetrics bool
+
+	// Clock tells the services the current time, e.g. a clock.TestClock to test timers
+	// and due dates deterministically. When nil, the clock of the operating system is used.
+	Clock clock.Clock
+
+	// Logger receives the structured log records of the engine, e.g. of command
+	// execution. When nil, the default logger of log/slog is used.
+	Logger *slog.Logger
+
+	// LogLevel is the minimum level of the log records of the engine
+	LogLevel slog.Level
+
+	// Tracer reports spans of commands, jobs and service tasks, e.g. to OpenTelemetry.
+	// When nil, nothing is traced.
+	Tracer tracing.Tracer
 }
 
 // DefaultProcessEngineConfiguration returns a configuration with default values
 func DefaultProcessEngineConfiguration() *ProcessEngineConfiguration {
 	return &ProcessEngineConfiguration{
-		EngineName:     "default",
-		DatabaseDriver: "postgres",
-		EnableHistory:  true,
-		EnableAsync:    true,
-		MaxPoolSize:    10,
-		IdleTimeout:    300,
+		EngineName:                  "default",
+		DatabaseDriver:              "postgres",
+		HistoryLevel:                history.HistoryLevelAudit,
+		EnableAsync:                 true,
+		JobWorkers:                  4,
+		JobPollInterval:             5 * time.Second,
+		JobLockDuration:             5 * time.Minute,
+		MaxPoolSize:                 10,
+		IdleTimeout:                 300,
+		LogLevel:                    slog.LevelInfo,
+		EnableMetrics:               true,
+		ProcessDefinitionCacheLimit: repository.DefaultProcessDefinitionCacheLimit,
+		SchemaUpdate:                persistence.SchemaUpdateMigrate,
+		FlushBatchSize:              persistence.DefaultBatchSize,
+		BatchJobSize:                batch.DefaultJobSize,
 	}
 }
 
@@ -101,9 +235,71 @@ func (b *ProcessEngineBuilder) WithDatabase(driver, url string) *ProcessEngineBu
 	return b
 }
 
-// WithHistory enables or disables history recording
+// WithSchemaUpdate sets whether Start migrates the schema of the database or only validates it
+func (b *ProcessEngineBuilder) WithSchemaUpdate(update persistence.SchemaUpdate) *ProcessEngineBuilder {
+	b.config.SchemaUpdate = update
+	return b
+}
+
+// WithFlushBatchSize sets the number of documents a command writes with one statement
+func (b *ProcessEngineBuilder) WithFlushBatchSize(size int) *ProcessEngineBuilder {
+	b.config.FlushBatchSize = size
+	return b
+}
+
+// WithMemoryStorage keeps the state of the engine in memory instead of a database
+func (b *ProcessEngineBuilder) WithMemoryStorage() *ProcessEngineBuilder {
+	b.config.DatabaseDriver = persistence.DriverMemory
+	b.config.DatabaseURL = ""
+	return b
+}
+
+// WithStorage keeps the state of the engine in a custom storage backend
+func (b *ProcessEngineBuilder) WithStorage(storage persistence.Storage) *ProcessEngineBuilder {
+	b.config.Storage = storage
+	return b
+}
+
+// WithDataManagers keeps the entities of the data managers set in custom data stores
+func (b *ProcessEngineBuilder) WithDataManagers(managers persistence.DataManagers) *ProcessEngineBuilder {
+	b.config.DataManagers = &managers
+	return b
+}
+
+// WithHistory enables history recording at the audit level or disables it
 func (b *ProcessEngineBuilder) WithHistory(enabled bool) *ProcessEngineBuilder {
-	b.config.EnableHistory = enabled
+	if enabled {
+		b.config.HistoryLevel = history.HistoryLevelAudit
+	} else {
+		b.config.HistoryLevel = history.HistoryLevelNone
+	}
+	return b
+}
+
+// WithHistoryLevel sets how much history data is recorded
+func (b *ProcessEngineBuilder) WithHistoryLevel(level history.HistoryLevel) *ProcessEngineBuilder {
+	b.config.HistoryLevel = level
+	return b
+}
+
+// WithHistoryCleanup sets the interval history cleanup runs at and the archiver
+// exporting the removed process instances, which may be nil
+func (b *ProcessEngineBuilder) WithHistoryCleanup(interval time.Duration, archiver history.HistoryArchiver) *ProcessEngineBuilder {
+	b.config.HistoryCleanupInterval = interval
+	b.config.HistoryArchiver = archiver
+	return b
+}
+
+// WithTaskSLAMonitor sets the interval the task service notifies the TaskEventDue and
+// TaskEventFollowUp listeners of tasks whose due or follow-up date passed
+func (b *ProcessEngineBuilder) WithTaskSLAMonitor(interval time.Duration) *ProcessEngineBuilder {
+	b.config.TaskSLAMonitorInterval = interval
+	return b
+}
+
+// WithBatchJobSize sets the number of items a job of a batch processes
+func (b *ProcessEngineBuilder) WithBatchJobSize(size int) *ProcessEngineBuilder {
+	b.config.BatchJobSize = size
 	return b
 }
 
@@ -113,17 +309,86 @@ func (b *ProcessEngineBuilder) WithAsync(enabled bool) *ProcessEngineBuilder {
 	return b
 }
 
+// WithJobExecutor sets the number of job workers and the job acquisition poll interval
+func (b *ProcessEngineBuilder) WithJobExecutor(workers int, pollInterval time.Duration) *ProcessEngineBuilder {
+	b.config.JobWorkers = workers
+	b.config.JobPollInterval = pollInterval
+	return b
+}
+
 // WithPoolSize sets the database connection pool size
 func (b *ProcessEngineBuilder) WithPoolSize(size int) *ProcessEngineBuilder {
 	b.config.MaxPoolSize = size
 	return b
 }
 
+// WithProcessDefinitionCacheLimit sets the maximum number of cached process models
+func (b *ProcessEngineBuilder) WithProcessDefinitionCacheLimit(limit int) *ProcessEngineBuilder {
+	b.config.ProcessDefinitionCacheLimit = limit
+	return b
+}
+
+// WithAuthorization enables or disables permission checks for authenticated users
+func (b *ProcessEngineBuilder) WithAuthorization(enabled bool) *ProcessEngineBuilder {
+	b.config.EnableAuthorization = enabled
+	return b
+}
+
+// WithMetrics enables or disables recording the count, duration and failures of commands
+func (b *ProcessEngineBuilder) WithMetrics(enabled bool) *ProcessEngineBuilder {
+	b.config.EnableMetrics = enabled
+	return b
+}
+
+// WithClock sets the clock the services tell the time by
+func (b *ProcessEngineBuilder) WithClock(c clock.Clock) *ProcessEngineBuilder {
+	b.config.Clock = c
+	return b
+}
+
+// WithLogger sets the logger receiving the structured log records of the engine
+func (b *ProcessEngineBuilder) WithLogger(logger *slog.Logger) *ProcessEngineBuilder {
+	b.config.Logger = logger
+	return b
+}
+
+// WithLogLevel sets the minimum level of the log records of the engine
+func (b *ProcessEngineBuilder) WithLogLevel(level slog.Level) *ProcessEngineBuilder {
+	b.config.LogLevel = level
+	return b
+}
+
+// WithTracer sets the tracer reporting spans of commands, jobs and service tasks
+func (b *ProcessEngineBuilder) WithTracer(tracer tracing.Tracer) *ProcessEngineBuilder {
+	b.config.Tracer = tracer
+	return b
+}
+
+// WithIdentityProvider sets the provider of users and groups
+func (b *ProcessEngineBuilder) WithIdentityProvider(provider identity.IdentityProvider) *ProcessEngineBuilder {
+	b.config.IdentityProvider = provider
+	return b
+}
+
+// WithContentStore sets the store large variable values are kept in
+func (b *ProcessEngineBuilder) WithContentStore(contentStore persistence.ContentStore) *ProcessEngineBuilder {
+	b.config.ContentStore = contentStore
+	return b
+}
+
 // Build creates and returns a new ProcessEngine instance
 func (b *ProcessEngineBuilder) Build() (ProcessEngine, error) {
 	return NewProcessEngine(b.config)
 }
 
+// SetAuthenticatedUser returns a context on whose behalf the engine acts for userID.
+// Process instances started with it record the user as their initiator, comments
+// record the user as their author and, with authorization enabled, commands check
+// the permissions of the user and the groups.
+func SetAuthenticatedUser(ctx context.Context, userID string, groupIDs ...string) context.Context {
+	return identity.WithAuthentication(ctx, userID, groupIDs...)
+}
+
 // NewProcessEngine creates a new ProcessEngine with the given configuration
 func NewProcessEngine(config *ProcessEngineConfiguration) (ProcessEngine, error) {
 	return newProcessEngineImpl(config)
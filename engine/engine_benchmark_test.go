@@ -0,0 +1,87 @@
+package engine_test
+
+import (
+	"testing"
+
+	"github.com/muixstudio/flowgo/api/history"
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/flowgotest"
+)
+
+// approvalProcess waits at a user task before it ends
+const approvalProcess = `{
+	"id": "approval",
+	"name": "Approval",
+	"nodes": [
+		{"id": "start", "type": "startEvent"},
+		{"id": "approve", "type": "userTask", "name": "Approve"},
+		{"id": "end", "type": "endEvent"}
+	],
+	"edges": [
+		{"id": "flow1", "source": "start", "target": "approve"},
+		{"id": "flow2", "source": "approve", "target": "end"}
+	]
+}`
+
+// withHistoryLevel sets the history level of a benchmarked engine
+func withHistoryLevel(level history.HistoryLevel) func(config *engine.ProcessEngineConfiguration) {
+	return func(config *engine.ProcessEngineConfiguration) {
+		config.HistoryLevel = level
+	}
+}
+
+// BenchmarkStartProcessInstance starts process instances on memory storage, recording
+// the history of each history level
+func BenchmarkStartProcessInstance(b *testing.B) {
+	levels := []history.HistoryLevel{
+		history.HistoryLevelNone,
+		history.HistoryLevelActivity,
+		history.HistoryLevelAudit,
+		history.HistoryLevelFull,
+	}
+	for _, level := range levels {
+		b.Run(level.String(), func(b *testing.B) {
+			e := flowgotest.NewEngine(b, withHistoryLevel(level))
+			e.Deploy("approval.json", []byte(approvalProcess))
+			variables := map[string]interface{}{"employee": "benchmark", "days": 3}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e.StartProcessInstance("approval", variables)
+			}
+		})
+	}
+}
+
+// BenchmarkStartProcessInstanceParallel starts process instances concurrently
+func BenchmarkStartProcessInstanceParallel(b *testing.B) {
+	e := flowgotest.NewEngine(b)
+	e.Deploy("approval.json", []byte(approvalProcess))
+	runtimeService := e.GetRuntimeService()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := runtimeService.StartProcessInstanceByKey(e.Context(), "approval", nil); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkCompleteTask completes the task of started process instances, ending them
+func BenchmarkCompleteTask(b *testing.B) {
+	e := flowgotest.NewEngine(b)
+	e.Deploy("approval.json", []byte(approvalProcess))
+	taskIDs := make([]string, b.N)
+	for i := range taskIDs {
+		processInstance := e.StartProcessInstance("approval", nil)
+		taskIDs[i] = e.AssertThat(processInstance.ID).Task("approve").ID
+	}
+
+	b.ResetTimer()
+	for _, taskID := range taskIDs {
+		e.CompleteTask(taskID, map[string]interface{}{"approved": true})
+	}
+}
@@ -3,24 +3,48 @@ package engine
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
-
-	"github.com/muixstudio/flowgo/history"
-	"github.com/muixstudio/flowgo/repository"
-	"github.com/muixstudio/flowgo/runtime"
-	"github.com/muixstudio/flowgo/task"
+	"time"
+
+	"github.com/muixstudio/flowgo/authorization"
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/externaltask"
+	"github.com/muixstudio/flowgo/form"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/history"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/internal/task"
+	"github.com/muixstudio/flowgo/job"
+	"github.com/muixstudio/flowgo/management"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/rules"
+	"github.com/muixstudio/flowgo/scripting"
 )
 
 // ProcessEngineImpl is the default implementation of ProcessEngine
 type ProcessEngineImpl struct {
-	config            *ProcessEngineConfiguration
-	repositoryService repository.RepositoryService
-	runtimeService    runtime.RuntimeService
-	taskService       task.TaskService
-	historyService    history.HistoryService
-	commandExecutor   CommandExecutor
-	running           bool
-	mu                sync.RWMutex
+	config               *ProcessEngineConfiguration
+	repositoryService    repository.RepositoryService
+	runtimeService       runtime.RuntimeService
+	taskService          task.TaskService
+	externalTaskService  externaltask.ExternalTaskService
+	historyService       history.HistoryService
+	jobService           job.JobService
+	managementService    management.ManagementService
+	formService          form.FormService
+	rulesService         rules.RulesService
+	batchService         batch.BatchService
+	identityProvider     identity.IdentityProvider
+	authorizationService authorization.AuthorizationService
+	store                *persistence.Store
+	commandExecutor      CommandExecutor
+	metrics              *Metrics
+	logger               *slog.Logger
+	running              bool
+	mu                   sync.RWMutex
 }
 
 // newProcessEngineImpl creates a new process engine implementation
@@ -31,13 +55,18 @@ func newProcessEngineImpl(config *ProcessEngineConfiguration) (*ProcessEngineImp
 
 	engine := &ProcessEngineImpl{
 		config:  config,
+		metrics: NewMetrics(),
+		logger:  newEngineLogger(config),
 		running: false,
 	}
 
 	// Initialize command executor (one instance for all commands)
 	engine.commandExecutor = NewDefaultCommandExecutorBuilder(engine).
 		WithLogging(true).
+		WithTracer(config.Tracer).
+		WithMetrics(config.EnableMetrics).
 		WithTransaction(true).
+		WithAuthorization(config.EnableAuthorization).
 		Build()
 
 	// Initialize services
@@ -52,23 +81,166 @@ func newProcessEngineImpl(config *ProcessEngineConfiguration) (*ProcessEngineImp
 func (e *ProcessEngineImpl) initializeServices() error {
 	// Initialize repository service
 	e.repositoryService = repository.NewRepositoryService(e.config.DatabaseDriver, e.config.DatabaseURL)
+	e.repositoryService.SetProcessDefinitionCacheLimit(e.config.ProcessDefinitionCacheLimit)
 
 	// Initialize runtime service
 	e.runtimeService = runtime.NewRuntimeService(e.repositoryService, e.config.EnableAsync)
+	if e.config.Tracer != nil {
+		e.runtimeService.SetTracer(e.config.Tracer)
+	}
+
+	// Script tasks run JavaScript, their default script format, in the embedded engine
+	e.runtimeService.RegisterScriptEngine("javascript", scripting.NewJavaScriptEngine())
+
+	// Initialize batch service; services register the job handlers of their batches in it
+	e.batchService = batch.NewBatchService()
+	e.batchService.SetJobSize(e.config.BatchJobSize)
+	e.runtimeService.SetBatchService(e.batchService)
 
 	// Initialize task service
 	e.taskService = task.NewTaskService(e.runtimeService)
 
+	// Candidate groups of tasks are expanded through the identity provider
+	e.identityProvider = e.config.IdentityProvider
+	if e.identityProvider == nil {
+		e.identityProvider = identity.NewInMemoryIdentityStore()
+	}
+	e.taskService.SetGroupMemberResolver(e.identityProvider)
+	e.taskService.SetSLAMonitorInterval(e.config.TaskSLAMonitorInterval)
+
+	// Initialize authorization service
+	e.authorizationService = authorization.NewAuthorizationService(e.identityProvider)
+
+	// Initialize form service
+	e.formService = form.NewFormService(e.repositoryService, e.runtimeService, e.taskService)
+
+	// Initialize rules service; business rule tasks evaluate their decisions in it
+	e.rulesService = rules.NewRulesService(e.repositoryService, e.runtimeService)
+
+	// Initialize external task service
+	e.externalTaskService = externaltask.NewExternalTaskService(e.runtimeService, externaltask.DefaultWorkerTimeout)
+
+	// Initialize job service; the job executor runs when async execution is enabled
+	e.jobService = job.NewJobService(e.runtimeService, job.ExecutorConfig{
+		Enabled:      e.config.EnableAsync,
+		Workers:      e.config.JobWorkers,
+		PollInterval: e.config.JobPollInterval,
+		LockDuration: e.config.JobLockDuration,
+	})
+
+	// Batches are locked with the lock owner of the job executor, so that of the
+	// engines sharing a database one runs each batch
+	e.batchService.SetExecutorConfig(batch.ExecutorConfig{
+		LockOwner:    e.jobService.GetLockOwner(),
+		LockDuration: e.config.JobLockDuration,
+		PollInterval: e.config.JobPollInterval,
+	})
+
+	// Initialize management service
+	e.managementService = management.NewManagementService(e.runtimeService, e.repositoryService)
+
 	// Initialize history service (if enabled)
-	if e.config.EnableHistory {
+	if e.config.HistoryLevel != history.HistoryLevelNone {
 		e.historyService = history.NewHistoryService(e.config.DatabaseDriver, e.config.DatabaseURL)
+		e.historyService.SetHistoryLevel(e.config.HistoryLevel)
+		e.historyService.SetBatchService(e.batchService)
+		e.historyService.SetCleanupInterval(e.config.HistoryCleanupInterval)
+		if e.config.HistoryArchiver != nil {
+			e.historyService.SetArchiver(e.config.HistoryArchiver)
+		}
+
+		// Process instances, activities, tasks and variables are recorded as they progress
+		history.NewHistoryManager(e.historyService, e.repositoryService).Register(e.runtimeService, e.taskService)
 	} else {
 		e.historyService = history.NewNoOpHistoryService()
 	}
 
+	// All services tell the time by the configured clock, e.g. a test clock
+	if e.config.Clock != nil {
+		e.repositoryService.SetClock(e.config.Clock)
+		e.runtimeService.SetClock(e.config.Clock)
+		e.taskService.SetClock(e.config.Clock)
+		e.authorizationService.SetClock(e.config.Clock)
+		e.externalTaskService.SetClock(e.config.Clock)
+		e.managementService.SetClock(e.config.Clock)
+		e.historyService.SetClock(e.config.Clock)
+		e.batchService.SetClock(e.config.Clock)
+	}
+
+	// Failures in the background, e.g. of jobs, batches and history indexing, are
+	// logged through the engine logger
+	e.runtimeService.SetLogger(e.logger)
+	e.taskService.SetLogger(e.logger)
+	e.jobService.SetLogger(e.logger)
+	e.historyService.SetLogger(e.logger)
+	e.batchService.SetLogger(e.logger)
+
+	// Persist all services to one store: a database when a database URL is configured,
+	// otherwise memory
+	store, err := e.openStore()
+	if err != nil {
+		return err
+	}
+	e.store = store
+	if e.config.Clock != nil {
+		store.SetClock(e.config.Clock)
+	}
+	e.repositoryService.SetStore(store)
+	e.runtimeService.SetStore(store)
+	e.taskService.SetStore(store)
+	e.externalTaskService.SetStore(store)
+	e.historyService.SetStore(store)
+	e.managementService.SetStore(store)
+	e.authorizationService.SetStore(store)
+	e.batchService.SetStore(store)
+
+	if e.config.ContentStore != nil {
+		e.runtimeService.SetContentStore(e.config.ContentStore)
+		e.taskService.SetContentStore(e.config.ContentStore)
+	}
+
 	return nil
 }
 
+// openStore opens the store of the configured storage or database, or a memory store
+// for the "memory" driver or without database URL, with the configured data managers
+func (e *ProcessEngineImpl) openStore() (*persistence.Store, error) {
+	store, err := e.openStorage()
+	if err != nil {
+		return nil, err
+	}
+	if e.config.DataManagers != nil {
+		store = store.WithDataManagers(*e.config.DataManagers)
+	}
+	if e.config.FlushBatchSize != 0 {
+		store.SetBatchSize(e.config.FlushBatchSize)
+	}
+	return store, nil
+}
+
+// openStorage opens the store of the configured storage or database, or a memory store
+func (e *ProcessEngineImpl) openStorage() (*persistence.Store, error) {
+	if e.config.Storage != nil {
+		return persistence.NewStorageStore(e.config.Storage), nil
+	}
+	if e.config.DatabaseURL == "" || strings.EqualFold(e.config.DatabaseDriver, persistence.DriverMemory) {
+		return persistence.NewMemoryStore(), nil
+	}
+
+	store, err := persistence.Open(e.config.DatabaseDriver, e.config.DatabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	if e.config.MaxPoolSize > 0 && !store.Dialect().SingleConnection {
+		store.DB().SetMaxOpenConns(e.config.MaxPoolSize)
+	}
+	if e.config.IdleTimeout > 0 {
+		store.DB().SetConnMaxIdleTime(time.Duration(e.config.IdleTimeout) * time.Second)
+	}
+	store.SetSchemaUpdate(e.config.SchemaUpdate)
+	return store, nil
+}
+
 // GetRepositoryService returns the repository service
 func (e *ProcessEngineImpl) GetRepositoryService() repository.RepositoryService {
 	return e.repositoryService
@@ -84,18 +256,58 @@ func (e *ProcessEngineImpl) GetTaskService() task.TaskService {
 	return e.taskService
 }
 
+// GetExternalTaskService returns the external task service
+func (e *ProcessEngineImpl) GetExternalTaskService() externaltask.ExternalTaskService {
+	return e.externalTaskService
+}
+
 // GetHistoryService returns the history service
 func (e *ProcessEngineImpl) GetHistoryService() history.HistoryService {
 	return e.historyService
 }
 
+// GetJobService returns the job service
+func (e *ProcessEngineImpl) GetJobService() job.JobService {
+	return e.jobService
+}
+
+// GetManagementService returns the management service
+func (e *ProcessEngineImpl) GetManagementService() management.ManagementService {
+	return e.managementService
+}
+
+// GetFormService returns the form service
+func (e *ProcessEngineImpl) GetFormService() form.FormService {
+	return e.formService
+}
+
+// GetBatchService returns the batch service
+func (e *ProcessEngineImpl) GetBatchService() batch.BatchService {
+	return e.batchService
+}
+
+// GetRulesService returns the rules service
+func (e *ProcessEngineImpl) GetRulesService() rules.RulesService {
+	return e.rulesService
+}
+
+// GetIdentityProvider returns the provider of users and groups
+func (e *ProcessEngineImpl) GetIdentityProvider() identity.IdentityProvider {
+	return e.identityProvider
+}
+
+// GetAuthorizationService returns the authorization service
+func (e *ProcessEngineImpl) GetAuthorizationService() authorization.AuthorizationService {
+	return e.authorizationService
+}
+
 // GetCommandExecutor returns the command executor
 func (e *ProcessEngineImpl) GetCommandExecutor() CommandExecutor {
 	return e.commandExecutor
 }
 
-// ExecuteCommand executes a command through the command executor
-// This method accepts Command[any] and returns any (requires type assertion by caller)
+// ExecuteCommand executes a command through the command executor. Commands with typed
+// results are executed with the package-level ExecuteCommand, which returns their type.
 func (e *ProcessEngineImpl) ExecuteCommand(ctx context.Context, command Command[any]) (any, error) {
 	if !e.IsRunning() {
 		return nil, fmt.Errorf("engine '%s' is not running", e.config.EngineName)
@@ -125,12 +337,34 @@ func (e *ProcessEngineImpl) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start task service: %w", err)
 	}
 
-	if e.config.EnableHistory {
+	if err := e.externalTaskService.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to start external task service: %w", err)
+	}
+
+	if e.config.HistoryLevel != history.HistoryLevelNone {
 		if err := e.historyService.Initialize(ctx); err != nil {
 			return fmt.Errorf("failed to start history service: %w", err)
 		}
 	}
 
+	if err := e.managementService.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to start management service: %w", err)
+	}
+
+	if err := e.authorizationService.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to start authorization service: %w", err)
+	}
+
+	// Resume the running batches once the services running their jobs are started
+	if err := e.batchService.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to start batch service: %w", err)
+	}
+
+	// Start the job executor last: jobs may use all other services
+	if err := e.jobService.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to start job service: %w", err)
+	}
+
 	e.running = true
 	return nil
 }
@@ -145,12 +379,32 @@ func (e *ProcessEngineImpl) Stop(ctx context.Context) error {
 	}
 
 	// Stop all services in reverse order
-	if e.config.EnableHistory {
+	if err := e.jobService.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop job service: %w", err)
+	}
+
+	if err := e.batchService.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop batch service: %w", err)
+	}
+
+	if err := e.authorizationService.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop authorization service: %w", err)
+	}
+
+	if err := e.managementService.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop management service: %w", err)
+	}
+
+	if e.config.HistoryLevel != history.HistoryLevelNone {
 		if err := e.historyService.Shutdown(ctx); err != nil {
 			return fmt.Errorf("failed to stop history service: %w", err)
 		}
 	}
 
+	if err := e.externalTaskService.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop external task service: %w", err)
+	}
+
 	if err := e.taskService.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to stop task service: %w", err)
 	}
@@ -163,6 +417,12 @@ func (e *ProcessEngineImpl) Stop(ctx context.Context) error {
 		return fmt.Errorf("failed to stop repository service: %w", err)
 	}
 
+	if e.store != nil {
+		if err := e.store.Close(); err != nil {
+			return fmt.Errorf("failed to close database: %w", err)
+		}
+	}
+
 	e.running = false
 	return nil
 }
@@ -2,9 +2,22 @@ package engine
 
 import (
 	"context"
+	"database/sql/driver"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"syscall"
 	"time"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/internal/task"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/tracing"
 )
 
 // CommandInterceptor intercepts command execution to add cross-cutting concerns.
@@ -43,68 +56,150 @@ func (i *BaseCommandInterceptor) Execute(ctx context.Context, command Command[an
 	return executor.Execute(ctx, command)
 }
 
-// LoggingInterceptor logs command execution
+// LoggingInterceptor logs command execution as structured records: the start of a
+// command at debug level, its completion at info level and its failure at error level,
+// with the command type, duration and, where known, process instance and tenant
 type LoggingInterceptor struct {
 	BaseCommandInterceptor
-	logger *log.Logger
+	logger *slog.Logger
 }
 
-// NewLoggingInterceptor creates a new logging interceptor
-func NewLoggingInterceptor() *LoggingInterceptor {
+// NewLoggingInterceptor creates a new logging interceptor writing to logger, or to the
+// default logger if it is nil
+func NewLoggingInterceptor(logger *slog.Logger) *LoggingInterceptor {
+	if logger == nil {
+		logger = slog.Default()
+	}
 	return &LoggingInterceptor{
-		logger: log.Default(),
+		logger: logger,
 	}
 }
 
 // Execute logs command execution
 func (i *LoggingInterceptor) Execute(ctx context.Context, command Command[any], executor CommandExecutor) (any, error) {
-	commandName := fmt.Sprintf("%T", command)
-	i.logger.Printf("[FlowGo] Executing command: %s", commandName)
+	logger := i.logger.With(slog.String("command", commandName(command)))
+	if userID := identity.AuthenticatedUserID(ctx); userID != "" {
+		logger = logger.With(slog.String("user", userID))
+	}
+	if tenantIDs, restricted := identity.GetTenantIDs(ctx); restricted {
+		logger = logger.With(slog.Any("tenants", tenantIDs))
+	}
+	logger.DebugContext(ctx, "executing command")
 
 	start := time.Now()
 	result, err := i.next.Execute(ctx, command, executor)
 	duration := time.Since(start)
 
 	if err != nil {
-		i.logger.Printf("[FlowGo] Command %s failed after %v: %v", commandName, duration, err)
+		logger.ErrorContext(ctx, "command failed", slog.Duration("duration", duration), slog.Any("error", err))
 		return nil, err
 	}
 
-	i.logger.Printf("[FlowGo] Command %s completed successfully in %v", commandName, duration)
+	logger.InfoContext(ctx, "command completed", append([]any{slog.Duration("duration", duration)}, resultLogAttrs(result)...)...)
 	return result, nil
 }
 
-// TransactionInterceptor manages transactions for command execution
+// resultLogAttrs returns the process instance and tenant of the result of a command,
+// if it is a process instance or task
+func resultLogAttrs(result any) []any {
+	var processInstanceID, tenantID string
+	switch r := result.(type) {
+	case *runtime.ProcessInstance:
+		if r != nil {
+			processInstanceID, tenantID = r.ID, r.TenantID
+		}
+	case *task.Task:
+		if r != nil {
+			processInstanceID, tenantID = r.ProcessInstanceID, r.TenantID
+		}
+	}
+
+	attrs := make([]any, 0, 2)
+	if processInstanceID != "" {
+		attrs = append(attrs, slog.String("processInstanceId", processInstanceID))
+	}
+	if tenantID != "" {
+		attrs = append(attrs, slog.String("tenant", tenantID))
+	}
+	return attrs
+}
+
+// TransactionInterceptor runs commands in a unit of work: the writes of the services
+// are collected in a persistence session and flushed in one database transaction when
+// the command succeeds, or discarded when it fails. Commands executed by commands join
+// the session of the outer command.
 type TransactionInterceptor struct {
 	BaseCommandInterceptor
+	engine *ProcessEngineImpl
 }
 
 // NewTransactionInterceptor creates a new transaction interceptor
-func NewTransactionInterceptor() *TransactionInterceptor {
-	return &TransactionInterceptor{}
+func NewTransactionInterceptor(engine *ProcessEngineImpl) *TransactionInterceptor {
+	return &TransactionInterceptor{
+		engine: engine,
+	}
 }
 
 // Execute wraps command execution in a transaction
 func (i *TransactionInterceptor) Execute(ctx context.Context, command Command[any], executor CommandExecutor) (any, error) {
-	// TODO: Begin transaction
-	// tx, err := beginTransaction()
-	// if err != nil {
-	//     return nil, err
-	// }
-
-	result, err := i.next.Execute(ctx, command, executor)
+	store := i.engine.store
+	if _, inSession := persistence.GetSession(ctx); store == nil || inSession {
+		return i.next.Execute(ctx, command, executor)
+	}
 
+	session := store.NewSession()
+	result, err := i.next.Execute(persistence.WithSession(ctx, session), command, executor)
 	if err != nil {
-		// TODO: Rollback transaction
-		// tx.Rollback()
+		session.Discard(ctx)
 		return nil, err
 	}
 
-	// TODO: Commit transaction
-	// if err := tx.Commit(); err != nil {
-	//     return nil, err
-	// }
+	if err := session.Flush(ctx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// TracingInterceptor runs commands in a span named after the command type. Its
+// attributes are those of TracedCommands and, for commands returning a process
+// instance, the process instance and its definition.
+type TracingInterceptor struct {
+	BaseCommandInterceptor
+	engine *ProcessEngineImpl
+	tracer tracing.Tracer
+}
+
+// NewTracingInterceptor creates a new tracing interceptor
+func NewTracingInterceptor(engine *ProcessEngineImpl, tracer tracing.Tracer) *TracingInterceptor {
+	return &TracingInterceptor{
+		engine: engine,
+		tracer: tracer,
+	}
+}
+
+// Execute traces command execution
+func (i *TracingInterceptor) Execute(ctx context.Context, command Command[any], executor CommandExecutor) (any, error) {
+	name := commandName(command)
+	attributes := []tracing.Attribute{tracing.String(tracing.AttributeCommandType, name)}
+	if traced, ok := unwrapCommand(command).(TracedCommand); ok {
+		attributes = append(attributes, traced.TraceAttributes(ctx, i.engine)...)
+	}
 
+	ctx, span := i.tracer.Start(ctx, name, attributes...)
+	defer span.End()
+
+	result, err := i.next.Execute(ctx, command, executor)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	if processInstance, ok := result.(*runtime.ProcessInstance); ok && processInstance != nil {
+		span.SetAttributes(
+			tracing.String(tracing.AttributeProcessInstanceID, processInstance.ID),
+			tracing.String(tracing.AttributeProcessDefinitionID, processInstance.ProcessDefinitionID),
+			tracing.String(tracing.AttributeProcessDefinitionKey, processInstance.ProcessDefinitionKey),
+		)
+	}
 	return result, nil
 }
 
@@ -141,50 +236,160 @@ func (i *ContextInterceptor) Execute(ctx context.Context, command Command[any],
 	return result, nil
 }
 
-// RetryInterceptor provides retry logic for failed commands
+// AuthorizationInterceptor checks the permissions of the authenticated user before
+// an AuthorizedCommand executes
+type AuthorizationInterceptor struct {
+	BaseCommandInterceptor
+	engine *ProcessEngineImpl
+}
+
+// NewAuthorizationInterceptor creates a new authorization interceptor
+func NewAuthorizationInterceptor(engine *ProcessEngineImpl) *AuthorizationInterceptor {
+	return &AuthorizationInterceptor{
+		engine: engine,
+	}
+}
+
+// Execute rejects the command if the authenticated user lacks a required permission
+func (i *AuthorizationInterceptor) Execute(ctx context.Context, command Command[any], executor CommandExecutor) (any, error) {
+	if authorizedCommand, ok := unwrapCommand(command).(AuthorizedCommand); ok {
+		checks, err := authorizedCommand.RequiredPermissions(ctx, i.engine)
+		if err != nil {
+			return nil, err
+		}
+		if err := i.engine.GetAuthorizationService().CheckAuthorization(ctx, checks...); err != nil {
+			return nil, err
+		}
+	}
+	return i.next.Execute(ctx, command, executor)
+}
+
+// Delays of the retry interceptor
+const (
+	defaultRetryDelay    = 10 * time.Millisecond
+	defaultMaxRetryDelay = 2 * time.Second
+)
+
+// RetryInterceptor retries commands that failed for transient reasons: optimistic
+// locking failures, deadlocks and connection problems. The delay before a retry grows
+// exponentially from retryDelay up to maxRetryDelay, with random jitter so that
+// engines conflicting on the same entity do not retry in lockstep.
 type RetryInterceptor struct {
 	BaseCommandInterceptor
-	maxRetries int
-	retryDelay time.Duration
+	maxRetries    int
+	retryDelay    time.Duration
+	maxRetryDelay time.Duration
+	logger        *slog.Logger
 }
 
-// NewRetryInterceptor creates a new retry interceptor
+// NewRetryInterceptor creates a new retry interceptor. The delay before the first
+// retry is retryDelay, or 10ms if it is not positive, and doubles with every retry.
 func NewRetryInterceptor(maxRetries int, retryDelay time.Duration) *RetryInterceptor {
+	if retryDelay <= 0 {
+		retryDelay = defaultRetryDelay
+	}
 	return &RetryInterceptor{
-		maxRetries: maxRetries,
-		retryDelay: retryDelay,
+		maxRetries:    maxRetries,
+		retryDelay:    retryDelay,
+		maxRetryDelay: max(retryDelay, defaultMaxRetryDelay),
+		logger:        slog.Default(),
 	}
 }
 
-// Execute retries command execution on failure
+// Execute retries command execution on retryable failures. Waiting for a retry ends
+// when the context is canceled.
 func (i *RetryInterceptor) Execute(ctx context.Context, command Command[any], executor CommandExecutor) (any, error) {
-	var result any
-	var err error
-
-	for attempt := 0; attempt <= i.maxRetries; attempt++ {
-		if attempt > 0 {
-			log.Printf("[FlowGo] Retrying command (attempt %d/%d)", attempt, i.maxRetries)
-			time.Sleep(i.retryDelay)
-		}
-
-		result, err = i.next.Execute(ctx, command, executor)
+	for attempt := 0; ; attempt++ {
+		result, err := i.next.Execute(ctx, command, executor)
 		if err == nil {
 			return result, nil
 		}
-
-		// Check if error is retryable
 		if !isRetryableError(err) {
-			break
+			return nil, err
+		}
+		if attempt == i.maxRetries {
+			return nil, fmt.Errorf("command failed after %d retries: %w", i.maxRetries, err)
 		}
+
+		delay := i.backoff(attempt)
+		i.logger.WarnContext(ctx, "retrying command",
+			slog.String("command", commandName(command)),
+			slog.Duration("delay", delay),
+			slog.Int("attempt", attempt+1),
+			slog.Int("maxRetries", i.maxRetries),
+			slog.Any("error", err))
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("command canceled before retry %d: %w (last error: %v)", attempt+1, ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay before a retry: the retry delay doubled with every previous
+// retry, capped at the maximum delay, of which a random half is waited
+func (i *RetryInterceptor) backoff(attempt int) time.Duration {
+	delay := i.maxRetryDelay
+	if attempt < 32 {
+		delay = min(i.retryDelay<<attempt, i.maxRetryDelay)
+	}
+	if delay <= 0 {
+		// Overflow of the shift
+		delay = i.maxRetryDelay
 	}
+	return delay/2 + rand.N(delay/2+1)
+}
 
-	return nil, fmt.Errorf("command failed after %d retries: %w", i.maxRetries, err)
+// transientErrorMessages are parts of the messages of database errors that go away when
+// the transaction is repeated, for drivers that do not expose typed errors: deadlocks,
+// serialization failures and lock timeouts of MySQL, PostgreSQL and SQLite
+var transientErrorMessages = []string{
+	"deadlock",
+	"could not serialize access",
+	"serialization failure",
+	"lock wait timeout",
+	"database is locked",
+	"database table is locked",
+	"connection reset",
+	"connection refused",
+	"broken pipe",
 }
 
-// isRetryableError checks if an error should trigger a retry
+// isRetryableError checks if an error should trigger a retry. Optimistic locking
+// failures, deadlocks and transient database or network errors are retryable; missing
+// entities, invalid input, conflicting states and canceled contexts are not.
 func isRetryableError(err error) bool {
-	// TODO: Implement proper error classification
-	// For now, we don't retry any errors
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, errors.ErrNotFound),
+		errors.Is(err, errors.ErrValidation),
+		errors.Is(err, errors.ErrSuspended),
+		errors.Is(err, errors.ErrAlreadyClaimed):
+		return false
+	case errors.Is(err, errors.ErrOptimisticLock),
+		errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, transient := range transientErrorMessages {
+		if strings.Contains(message, transient) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+)
+
+// newEngineLogger returns the logger of an engine: the configured logger, or the
+// default logger, restricted to records of at least the configured level and tagged
+// with the engine name
+func newEngineLogger(config *ProcessEngineConfiguration) *slog.Logger {
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return slog.New(&levelHandler{handler: logger.Handler(), level: config.LogLevel}).
+		With(slog.String("engine", config.EngineName))
+}
+
+// levelHandler drops the records below a minimum level before they reach a handler
+type levelHandler struct {
+	handler slog.Handler
+	level   slog.Leveler
+}
+
+// Enabled reports whether records of a level are handled
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+// Handle passes a record to the handler
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a handler adding attributes to the records
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{handler: h.handler.WithAttrs(attrs), level: h.level}
+}
+
+// WithGroup returns a handler qualifying the attributes of the records with a group
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{handler: h.handler.WithGroup(name), level: h.level}
+}
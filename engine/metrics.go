@@ -0,0 +1,161 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DurationBuckets are the upper bounds, in seconds, of the buckets command durations
+// are counted in
+var DurationBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// CommandMetrics are the executions of one command type
+type CommandMetrics struct {
+	// Command is the type of the command, e.g. "commands.CompleteTaskCommand"
+	Command string
+	// Count is the number of executions
+	Count int64
+	// Errors is the number of executions that failed
+	Errors int64
+	// TotalDuration is the time all executions took together
+	TotalDuration time.Duration
+	// BucketCounts are the numbers of executions that took at most the duration of the
+	// DurationBuckets at the same index; they are cumulative
+	BucketCounts []int64
+}
+
+// ErrorRate returns the share of executions that failed, between 0 and 1
+func (m CommandMetrics) ErrorRate() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Count)
+}
+
+// AverageDuration returns the mean time an execution took
+func (m CommandMetrics) AverageDuration() time.Duration {
+	if m.Count == 0 {
+		return 0
+	}
+	return m.TotalDuration / time.Duration(m.Count)
+}
+
+// EngineMetrics are the command metrics of an engine together with gauges of its state
+type EngineMetrics struct {
+	// Commands are the metrics of the command types executed, ordered by type
+	Commands []CommandMetrics
+	// ActiveProcessInstances is the number of process instances that are not suspended
+	ActiveProcessInstances int64
+	// OpenTasks is the number of tasks not completed yet
+	OpenTasks int64
+	// PendingJobs is the number of jobs waiting to be executed, without dead-letter jobs
+	PendingJobs int64
+}
+
+// Metrics records the executions of commands. It is safe for concurrent use.
+type Metrics struct {
+	commands map[string]*CommandMetrics
+	mu       sync.Mutex
+}
+
+// NewMetrics creates empty metrics
+func NewMetrics() *Metrics {
+	return &Metrics{commands: make(map[string]*CommandMetrics)}
+}
+
+// Record counts an execution of a command type, as failed if err is not nil
+func (m *Metrics) Record(command string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics, exists := m.commands[command]
+	if !exists {
+		metrics = &CommandMetrics{Command: command, BucketCounts: make([]int64, len(DurationBuckets))}
+		m.commands[command] = metrics
+	}
+	metrics.Count++
+	if err != nil {
+		metrics.Errors++
+	}
+	metrics.TotalDuration += duration
+	seconds := duration.Seconds()
+	for i, bound := range DurationBuckets {
+		if seconds <= bound {
+			metrics.BucketCounts[i]++
+		}
+	}
+}
+
+// Commands returns a copy of the metrics of all command types, ordered by type
+func (m *Metrics) Commands() []CommandMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]CommandMetrics, 0, len(m.commands))
+	for _, metrics := range m.commands {
+		copied := *metrics
+		copied.BucketCounts = append([]int64(nil), metrics.BucketCounts...)
+		result = append(result, copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Command < result[j].Command
+	})
+	return result
+}
+
+// Reset removes the metrics of all command types
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.commands = make(map[string]*CommandMetrics)
+}
+
+// MetricsInterceptor records the count, duration and failures of every command type
+type MetricsInterceptor struct {
+	BaseCommandInterceptor
+	metrics *Metrics
+}
+
+// NewMetricsInterceptor creates a new metrics interceptor recording to metrics
+func NewMetricsInterceptor(metrics *Metrics) *MetricsInterceptor {
+	return &MetricsInterceptor{
+		metrics: metrics,
+	}
+}
+
+// Execute measures command execution
+func (i *MetricsInterceptor) Execute(ctx context.Context, command Command[any], executor CommandExecutor) (any, error) {
+	start := time.Now()
+	result, err := i.next.Execute(ctx, command, executor)
+	i.metrics.Record(commandName(command), time.Since(start), err)
+	return result, err
+}
+
+// commandName returns the type of a command without pointer, e.g.
+// "commands.CompleteTaskCommand"
+func commandName(command Command[any]) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", unwrapCommand(command)), "*")
+}
+
+// GetMetrics returns the command metrics and counts the active process instances,
+// open tasks and pending jobs
+func (e *ProcessEngineImpl) GetMetrics(ctx context.Context) (*EngineMetrics, error) {
+	result := &EngineMetrics{Commands: e.metrics.Commands()}
+
+	var err error
+	if result.ActiveProcessInstances, err = e.runtimeService.CreateProcessInstanceQuery().Active().Count(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count process instances: %w", err)
+	}
+	if result.OpenTasks, err = e.taskService.CreateTaskQuery().Count(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count tasks: %w", err)
+	}
+	if result.PendingJobs, err = e.managementService.CreateJobQuery().Count(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,107 @@
+// Package errors defines the kinds of errors the services of the engine return.
+//
+// Services wrap the sentinel errors with the details of the failure, e.g.
+// "process instance not found: 42" wraps ErrNotFound, so that callers can branch on
+// the kind of an error with Is instead of matching messages:
+//
+//	if errors.Is(err, errors.ErrNotFound) {
+//		...
+//	}
+//
+// The package forwards the functions of the standard errors package, so it can be
+// imported in its place.
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kinds of errors returned by the services
+var (
+	// ErrNotFound is returned when an entity, e.g. a process instance or task, does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrSuspended is returned when a suspended process definition, process instance or
+	// task is used
+	ErrSuspended = errors.New("suspended")
+	// ErrAlreadyClaimed is returned when a task claimed by another user is claimed
+	ErrAlreadyClaimed = errors.New("already claimed")
+	// ErrOptimisticLock is returned when an entity was modified by another transaction
+	// since it was read. The command can be retried.
+	ErrOptimisticLock = errors.New("optimistic locking failure")
+	// ErrValidation is returned when a process model or the arguments of a call are invalid
+	ErrValidation = errors.New("validation failed")
+	// ErrNotSignalable is returned when an execution is signaled that does not wait at a
+	// wait state, e.g. one passing a gateway or waiting in a subprocess
+	ErrNotSignalable = errors.New("not signalable")
+)
+
+// SuspendedError is returned when a suspended process definition, process instance or
+// task is used, e.g. a task of a suspended process instance is completed. It matches
+// ErrSuspended with Is.
+type SuspendedError struct {
+	// EntityType is the kind of the suspended entity, e.g. "process instance"
+	EntityType string
+	// ID is the ID of the suspended entity
+	ID string
+}
+
+// Error describes the suspended entity
+func (e *SuspendedError) Error() string {
+	return fmt.Sprintf("%s '%s' is suspended", e.EntityType, e.ID)
+}
+
+// Is reports whether target is ErrSuspended
+func (e *SuspendedError) Is(target error) bool {
+	return target == ErrSuspended
+}
+
+// NotSignalableError is returned when an execution is signaled that does not wait at a
+// receive task, user task, waiting service task or intermediate catch event. It matches
+// ErrNotSignalable with Is.
+type NotSignalableError struct {
+	// ExecutionID is the ID of the signaled execution
+	ExecutionID string
+	// ActivityID is the ID of the activity the execution is at, if any
+	ActivityID string
+	// Reason describes why the execution cannot be signaled
+	Reason string
+}
+
+// Error describes the execution and why it cannot be signaled
+func (e *NotSignalableError) Error() string {
+	if e.ActivityID == "" {
+		return fmt.Sprintf("execution '%s' cannot be signaled: %s", e.ExecutionID, e.Reason)
+	}
+	return fmt.Sprintf("execution '%s' at activity '%s' cannot be signaled: %s", e.ExecutionID, e.ActivityID, e.Reason)
+}
+
+// Is reports whether target is ErrNotSignalable
+func (e *NotSignalableError) Is(target error) bool {
+	return target == ErrNotSignalable
+}
+
+// New returns an error with a message
+func New(text string) error {
+	return errors.New(text)
+}
+
+// Is reports whether an error in the chain of err matches target
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// As finds the first error in the chain of err that matches target and sets target to it
+func As(err error, target interface{}) bool {
+	return errors.As(err, target)
+}
+
+// Unwrap returns the error err wraps, if any
+func Unwrap(err error) error {
+	return errors.Unwrap(err)
+}
+
+// Join returns an error wrapping the errors
+func Join(errs ...error) error {
+	return errors.Join(errs...)
+}
@@ -4,16 +4,17 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/muixstudio/flowgo"
+	"github.com/muixstudio/flowgo/api/task"
+	"github.com/muixstudio/flowgo/examples"
 )
 
 func main() {
-	// Create a process engine with default configuration
+	// Create a process engine keeping its data in memory
 	engine, err := flowgo.NewProcessEngineBuilder().
 		WithEngineName("flowgo-example").
-		WithDatabase("postgres", "postgresql://localhost:5432/flowgo").
+		WithMemoryStorage().
 		WithHistory(true).
 		WithAsync(true).
 		Build()
@@ -39,15 +40,10 @@ func main() {
 
 	// 1. Deploy a process definition
 	fmt.Println("\n=== Deploying Process Definition ===")
-	processDefinitionJSON, err := os.ReadFile("../leave_approval.json")
-	if err != nil {
-		log.Fatalf("Failed to read process definition: %v", err)
-	}
-
 	deployment, err := repositoryService.CreateDeployment().
 		Name("Leave Approval Process").
 		Category("HR").
-		AddProcessDefinition("leave_approval.json", processDefinitionJSON).
+		AddProcessDefinition("leave_approval.json", examples.LeaveApproval).
 		Deploy(ctx)
 
 	if err != nil {
@@ -105,10 +101,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to query tasks: %v", err)
 	}
-
-	for _, task := range tasks {
-		fmt.Printf("Task: %s - %s (Priority: %d)\n", task.ID, task.Name, task.Priority)
-	}
+	printTasks(tasks)
 
 	// 5. Claim and complete a task (if exists)
 	if len(tasks) > 0 {
@@ -176,3 +169,10 @@ func main() {
 
 	fmt.Println("\n=== Example completed successfully ===")
 }
+
+// printTasks prints the ID, name and priority of tasks
+func printTasks(tasks []*task.Task) {
+	for _, task := range tasks {
+		fmt.Printf("Task: %s - %s (Priority: %d)\n", task.ID, task.Name, task.Priority)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/muixstudio/flowgo"
+	"github.com/muixstudio/flowgo/commands"
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/examples"
 )
 
 func main() {
@@ -15,7 +17,7 @@ func main() {
 	// Create process engine
 	processEngine, err := flowgo.NewProcessEngineBuilder().
 		WithEngineName("command-pattern-example").
-		WithDatabase("postgres", "postgresql://localhost:5432/flowgo").
+		WithMemoryStorage().
 		WithHistory(true).
 		Build()
 
@@ -34,25 +36,19 @@ func main() {
 
 	// Example 1: Deploy a process using DeployCommand
 	fmt.Println("1. Deploying process definition using DeployCommand...")
-	processJSON, err := os.ReadFile("../leave_approval.json")
-	if err != nil {
-		log.Fatalf("Failed to read process definition: %v", err)
-	}
-
 	deployCommand := commands.NewDeployCommand(
 		"Leave Approval Process",
 		"leave_approval.json",
-		processJSON,
+		examples.LeaveApproval,
 	)
 	deployCommand.Category = "HR"
 
 	// Execute command through engine
-	result, err := processEngine.Execute(ctx, deployCommand)
+	deployment, err := engine.ExecuteCommand(ctx, processEngine, deployCommand)
 	if err != nil {
 		log.Fatalf("Deploy command failed: %v", err)
 	}
 
-	deployment := result.(*repository.Deployment)
 	fmt.Printf("✓ Deployment successful: %s (ID: %s)\n", deployment.Name, deployment.ID)
 	fmt.Println()
 
@@ -73,12 +69,11 @@ func main() {
 		variables,
 	)
 
-	result, err = processEngine.Execute(ctx, startCommand)
+	processInstance, err := engine.ExecuteCommand(ctx, processEngine, startCommand)
 	if err != nil {
 		log.Fatalf("Start process command failed: %v", err)
 	}
 
-	processInstance := result.(*runtime.ProcessInstance)
 	fmt.Printf("✓ Process instance started: %s (Business Key: %s)\n",
 		processInstance.ID, processInstance.BusinessKey)
 	fmt.Println()
@@ -110,11 +105,11 @@ func main() {
 		fmt.Printf("4. Claiming task '%s' using ClaimTaskCommand...\n", task.Name)
 
 		claimCommand := commands.NewClaimTaskCommand(task.ID, "alice.johnson")
-		_, err = processEngine.Execute(ctx, claimCommand)
+		claimed, err := engine.ExecuteCommand(ctx, processEngine, claimCommand)
 		if err != nil {
 			log.Printf("Claim command failed: %v", err)
 		} else {
-			fmt.Printf("✓ Task claimed by alice.johnson\n")
+			fmt.Printf("✓ Task claimed by %s\n", claimed.Assignee)
 		}
 		fmt.Println()
 
@@ -126,7 +121,7 @@ func main() {
 		}
 
 		completeCommand := commands.NewCompleteTaskCommand(task.ID, outputVars)
-		_, err = processEngine.Execute(ctx, completeCommand)
+		_, err = engine.ExecuteCommand(ctx, processEngine, completeCommand)
 		if err != nil {
 			log.Printf("Complete command failed: %v", err)
 		} else {
@@ -0,0 +1,10 @@
+// Package examples holds the process definitions the example programs deploy, embedded
+// so that the programs run from any working directory.
+package examples
+
+import _ "embed"
+
+// LeaveApproval is the leave approval process of leave_approval.json
+//
+//go:embed leave_approval.json
+var LeaveApproval []byte
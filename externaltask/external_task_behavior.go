@@ -0,0 +1,88 @@
+package externaltask
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// externalTaskBehavior is the service task implementation "external". It creates an
+// external task for the "topic" of the node and keeps the execution waiting until a
+// worker completes the task or throws a BPMN error.
+type externalTaskBehavior struct {
+	service *externalTaskServiceImpl
+}
+
+// Execute creates the external task
+func (b *externalTaskBehavior) Execute(ctx context.Context, execution *runtime.ActivityExecution) error {
+	node := execution.Node
+
+	topic := node.GetString("topic")
+	if expression.IsExpression(topic) {
+		value, err := execution.ExpressionManager().Evaluate(topic, execution.GetVariables())
+		if err != nil {
+			return fmt.Errorf("invalid topic of external task '%s': %w", node.ID, err)
+		}
+		topic = fmt.Sprintf("%v", value)
+	}
+	if topic == "" {
+		return fmt.Errorf("external task '%s' must have a 'topic' property", node.ID)
+	}
+
+	task := &ExternalTask{
+		Topic:               topic,
+		ActivityID:          node.ID,
+		ExecutionID:         execution.Execution.ID,
+		ProcessInstanceID:   execution.ProcessInstance.ID,
+		ProcessDefinitionID: execution.ProcessInstance.ProcessDefinitionID,
+		TenantID:            execution.ProcessInstance.TenantID,
+	}
+	if priority, ok := node.GetInt("priority"); ok {
+		task.Priority = priority
+	}
+	return b.service.CreateExternalTask(ctx, task)
+}
+
+// Trigger removes the task of the execution and leaves the node, or throws the BPMN
+// error reported by the worker
+func (b *externalTaskBehavior) Trigger(ctx context.Context, execution *runtime.ActivityExecution, variables map[string]interface{}) error {
+	bpmnError, err := b.removeTasks(ctx, execution.Execution.ID)
+	if err != nil {
+		return err
+	}
+	if bpmnError != nil {
+		return bpmnError
+	}
+	return execution.Leave()
+}
+
+// Cancel removes the task of the execution, e.g. when an interrupting boundary event fires
+func (b *externalTaskBehavior) Cancel(ctx context.Context, execution *runtime.ActivityExecution) error {
+	_, err := b.removeTasks(ctx, execution.Execution.ID)
+	return err
+}
+
+// removeTasks removes the tasks of an execution and returns a BPMN error thrown for them
+func (b *externalTaskBehavior) removeTasks(ctx context.Context, executionID string) (*runtime.BusinessError, error) {
+	s := b.service
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bpmnError *runtime.BusinessError
+	for id, task := range s.tasks {
+		if task.ExecutionID != executionID {
+			continue
+		}
+		if err, exists := s.bpmnErrors[id]; exists {
+			bpmnError = err
+			delete(s.bpmnErrors, id)
+		}
+		delete(s.tasks, id)
+		if err := s.saveTaskLocked(ctx, id); err != nil {
+			return nil, err
+		}
+	}
+	return bpmnError, nil
+}
@@ -0,0 +1,40 @@
+package externaltask
+
+import (
+	"context"
+
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// SetStore sets the store external tasks are persisted to
+func (s *externalTaskServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// saveTaskLocked writes an external task to the store, if any.
+// Tasks that no longer exist are deleted from the store.
+// Callers must hold the write lock.
+func (s *externalTaskServiceImpl) saveTaskLocked(ctx context.Context, taskID string) error {
+	if s.store == nil {
+		return nil
+	}
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return s.store.Delete(ctx, persistence.TableExternalTasks, taskID)
+	}
+	return s.store.Save(ctx, persistence.TableExternalTasks, taskID, task)
+}
+
+// load reads external tasks from the store
+func (s *externalTaskServiceImpl) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return persistence.Load(ctx, s.store, persistence.TableExternalTasks, func(task *ExternalTask) {
+		s.tasks[task.ID] = task
+	})
+}
@@ -0,0 +1,109 @@
+package externaltask
+
+import (
+	"context"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// ExternalTaskService provides operations for work items that are processed by
+// workers running outside the engine.
+// This service is responsible for:
+// - Creating external tasks for service tasks of type "external"
+// - Handing out external tasks to workers under a time-bound lock
+// - Completing or failing tasks, which continues or retries the process instance
+// - Extending locks held by long-running workers
+// - Tracking worker heartbeats so tasks of crashed workers are re-offered
+type ExternalTaskService interface {
+	// Initialize initializes the external task service
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the external task service
+	Shutdown(ctx context.Context) error
+
+	// CreateExternalTask registers a new external task that workers can fetch
+	CreateExternalTask(ctx context.Context, task *ExternalTask) error
+
+	// GetExternalTask retrieves an external task by ID
+	GetExternalTask(ctx context.Context, taskID string) (*ExternalTask, error)
+
+	// FetchAndLock locks up to maxTasks available tasks of a topic for a worker
+	FetchAndLock(ctx context.Context, workerID, topic string, maxTasks int, lockDuration time.Duration) ([]*ExternalTask, error)
+
+	// ExtendLock extends the lock a worker holds on a task by the given duration from now
+	ExtendLock(ctx context.Context, taskID, workerID string, duration time.Duration) error
+
+	// Unlock releases the lock on a task so it can be fetched again
+	Unlock(ctx context.Context, taskID string) error
+
+	// Complete completes a task locked by the worker. The variables are set on the
+	// process instance, which continues after the service task.
+	Complete(ctx context.Context, taskID, workerID string, variables map[string]interface{}) error
+
+	// Fail reports that the worker could not process a task. The task can be fetched
+	// again after retryTimeout; with no retries left it is not offered anymore until
+	// SetRetries is called.
+	Fail(ctx context.Context, taskID, workerID, errorMessage string, retries int, retryTimeout time.Duration) error
+
+	// HandleBPMNError throws a business error at the service task of a task locked by
+	// the worker, which is caught by error boundary events or error event subprocesses
+	HandleBPMNError(ctx context.Context, taskID, workerID, errorCode, errorMessage string) error
+
+	// SetRetries sets the retries of a task, e.g. to offer a task without retries left again
+	SetRetries(ctx context.Context, taskID string, retries int) error
+
+	// Heartbeat records that a worker is still alive
+	Heartbeat(ctx context.Context, workerID string) error
+
+	// GetWorker retrieves the heartbeat information of a worker
+	GetWorker(ctx context.Context, workerID string) (*Worker, error)
+
+	// SetStore sets the store external tasks are persisted to
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock locks, retries and worker heartbeats are based on
+	SetClock(c clock.Clock)
+}
+
+// ExternalTask represents a unit of work to be processed by an external worker
+type ExternalTask struct {
+	ID                  string
+	Topic               string
+	ActivityID          string
+	ExecutionID         string
+	ProcessInstanceID   string
+	ProcessDefinitionID string
+	WorkerID            string
+	LockExpirationTime  *time.Time
+	Retries             int
+	ErrorMessage        string // message of the last failure
+	Priority            int
+	CreateTime          time.Time
+	TenantID            string
+
+	// Variables are the process variables visible to the execution when the task was fetched
+	Variables map[string]interface{} `json:"-"`
+}
+
+// IsLocked returns whether the task is locked at the given point in time
+func (t *ExternalTask) IsLocked(now time.Time) bool {
+	return t.WorkerID != "" && t.LockExpirationTime != nil && t.LockExpirationTime.After(now)
+}
+
+// HasIncident returns whether the task failed without retries left
+func (t *ExternalTask) HasIncident() bool {
+	return t.ErrorMessage != "" && t.Retries <= 0
+}
+
+// Worker represents an external worker known to the engine
+type Worker struct {
+	ID            string
+	LastHeartbeat time.Time
+}
+
+// IsAlive returns whether the worker sent a heartbeat within the given timeout
+func (w *Worker) IsAlive(now time.Time, timeout time.Duration) bool {
+	return now.Sub(w.LastHeartbeat) <= timeout
+}
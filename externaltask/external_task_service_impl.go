@@ -0,0 +1,379 @@
+package externaltask
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// DefaultWorkerTimeout is the time after which a worker without heartbeats is considered dead
+const DefaultWorkerTimeout = 30 * time.Second
+
+// externalTaskServiceImpl is the default implementation of ExternalTaskService
+type externalTaskServiceImpl struct {
+	runtimeService runtime.RuntimeService
+	store          *persistence.Store
+	clock          clock.Clock
+	workerTimeout  time.Duration
+	tasks          map[string]*ExternalTask
+	workers        map[string]*Worker
+	bpmnErrors     map[string]*runtime.BusinessError // task ID -> error thrown by the worker
+	mu             sync.RWMutex
+}
+
+// NewExternalTaskService creates a new external task service.
+// Tasks locked by a worker that has not sent a heartbeat within workerTimeout
+// are offered to other workers even if their lock has not expired yet.
+// The service registers the service task implementation "external" with the
+// runtime service, so that service tasks with the "type" "external" create tasks.
+func NewExternalTaskService(runtimeService runtime.RuntimeService, workerTimeout time.Duration) ExternalTaskService {
+	if workerTimeout <= 0 {
+		workerTimeout = DefaultWorkerTimeout
+	}
+	s := &externalTaskServiceImpl{
+		runtimeService: runtimeService,
+		workerTimeout:  workerTimeout,
+		tasks:          make(map[string]*ExternalTask),
+		workers:        make(map[string]*Worker),
+		bpmnErrors:     make(map[string]*runtime.BusinessError),
+		clock:          clock.System(),
+	}
+
+	if runtimeService != nil {
+		runtimeService.RegisterServiceTask("external", &externalTaskBehavior{service: s})
+	}
+
+	return s
+}
+
+// SetClock sets the clock locks, retries and worker heartbeats are based on
+func (s *externalTaskServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Initialize initializes the external task service
+func (s *externalTaskServiceImpl) Initialize(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+	return s.load(ctx)
+}
+
+// Shutdown gracefully shuts down the external task service
+func (s *externalTaskServiceImpl) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// CreateExternalTask registers a new external task that workers can fetch
+func (s *externalTaskServiceImpl) CreateExternalTask(ctx context.Context, task *ExternalTask) error {
+	if task.Topic == "" {
+		return fmt.Errorf("external task topic cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	if task.CreateTime.IsZero() {
+		task.CreateTime = s.clock.Now()
+	}
+
+	s.tasks[task.ID] = task
+	return s.saveTaskLocked(ctx, task.ID)
+}
+
+// GetExternalTask retrieves an external task by ID
+func (s *externalTaskServiceImpl) GetExternalTask(ctx context.Context, taskID string) (*ExternalTask, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("external task %w: %s", errors.ErrNotFound, taskID)
+	}
+	return task, nil
+}
+
+// FetchAndLock locks up to maxTasks available tasks of a topic for a worker
+func (s *externalTaskServiceImpl) FetchAndLock(ctx context.Context, workerID, topic string, maxTasks int, lockDuration time.Duration) ([]*ExternalTask, error) {
+	if workerID == "" {
+		return nil, fmt.Errorf("worker ID cannot be empty")
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("topic cannot be empty")
+	}
+	if maxTasks <= 0 {
+		return nil, fmt.Errorf("max tasks must be positive")
+	}
+	if lockDuration <= 0 {
+		return nil, fmt.Errorf("lock duration must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.recordHeartbeat(workerID, now)
+
+	candidates := make([]*ExternalTask, 0)
+	for _, task := range s.tasks {
+		if task.Topic == topic && s.isAvailable(task, now) {
+			candidates = append(candidates, task)
+		}
+	}
+
+	// Higher priority first, then oldest first
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Priority != candidates[j].Priority {
+			return candidates[i].Priority > candidates[j].Priority
+		}
+		return candidates[i].CreateTime.Before(candidates[j].CreateTime)
+	})
+
+	if len(candidates) > maxTasks {
+		candidates = candidates[:maxTasks]
+	}
+
+	expiration := now.Add(lockDuration)
+	for _, task := range candidates {
+		lockExpiration := expiration
+		task.WorkerID = workerID
+		task.LockExpirationTime = &lockExpiration
+		if err := s.saveTaskLocked(ctx, task.ID); err != nil {
+			return nil, err
+		}
+
+		if s.runtimeService != nil && task.ExecutionID != "" {
+			variables, err := s.runtimeService.GetVariables(ctx, task.ExecutionID)
+			if err != nil {
+				return nil, err
+			}
+			task.Variables = variables
+		}
+	}
+
+	return candidates, nil
+}
+
+// ExtendLock extends the lock a worker holds on a task by the given duration from now
+func (s *externalTaskServiceImpl) ExtendLock(ctx context.Context, taskID, workerID string, duration time.Duration) error {
+	if duration <= 0 {
+		return fmt.Errorf("lock duration must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("external task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	if task.WorkerID != workerID {
+		return fmt.Errorf("external task '%s' is not locked by worker '%s'", taskID, workerID)
+	}
+
+	now := s.clock.Now()
+	if !task.IsLocked(now) {
+		return fmt.Errorf("lock on external task '%s' has expired", taskID)
+	}
+
+	// Extending a lock is a sign of life as well
+	s.recordHeartbeat(workerID, now)
+
+	expiration := now.Add(duration)
+	task.LockExpirationTime = &expiration
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// Unlock releases the lock on a task so it can be fetched again
+func (s *externalTaskServiceImpl) Unlock(ctx context.Context, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("external task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	task.WorkerID = ""
+	task.LockExpirationTime = nil
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// Complete completes a task locked by the worker and continues its process instance
+func (s *externalTaskServiceImpl) Complete(ctx context.Context, taskID, workerID string, variables map[string]interface{}) error {
+	s.mu.Lock()
+	task, err := s.getLockedTaskLocked(taskID, workerID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	if s.runtimeService == nil || task.ExecutionID == "" {
+		delete(s.tasks, taskID)
+		err := s.saveTaskLocked(ctx, taskID)
+		s.mu.Unlock()
+		return err
+	}
+	executionID := task.ExecutionID
+	s.mu.Unlock()
+
+	// The service task behavior removes the task when the execution is signaled
+	return s.runtimeService.SignalWithVariables(ctx, executionID, variables)
+}
+
+// Fail records a failure of a task locked by the worker and offers it again after retryTimeout
+func (s *externalTaskServiceImpl) Fail(ctx context.Context, taskID, workerID, errorMessage string, retries int, retryTimeout time.Duration) error {
+	if errorMessage == "" {
+		return fmt.Errorf("error message cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, err := s.getLockedTaskLocked(taskID, workerID)
+	if err != nil {
+		return err
+	}
+
+	task.ErrorMessage = errorMessage
+	task.Retries = retries
+	task.WorkerID = ""
+	task.LockExpirationTime = nil
+	if retryTimeout > 0 {
+		retryTime := s.clock.Now().Add(retryTimeout)
+		task.LockExpirationTime = &retryTime
+	}
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// HandleBPMNError throws a business error at the service task of a task locked by the worker
+func (s *externalTaskServiceImpl) HandleBPMNError(ctx context.Context, taskID, workerID, errorCode, errorMessage string) error {
+	if errorCode == "" {
+		return fmt.Errorf("error code cannot be empty")
+	}
+
+	s.mu.Lock()
+	task, err := s.getLockedTaskLocked(taskID, workerID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if s.runtimeService == nil || task.ExecutionID == "" {
+		s.mu.Unlock()
+		return fmt.Errorf("external task '%s' does not belong to a process instance", taskID)
+	}
+	executionID := task.ExecutionID
+	s.bpmnErrors[taskID] = runtime.NewBusinessError(errorCode, errorMessage)
+	s.mu.Unlock()
+
+	// The service task behavior throws the error when the execution is signaled
+	if err := s.runtimeService.Signal(ctx, executionID); err != nil {
+		s.mu.Lock()
+		delete(s.bpmnErrors, taskID)
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// SetRetries sets the retries of a task
+func (s *externalTaskServiceImpl) SetRetries(ctx context.Context, taskID string, retries int) error {
+	if retries < 0 {
+		return fmt.Errorf("retries cannot be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("external task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	task.Retries = retries
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// Heartbeat records that a worker is still alive
+func (s *externalTaskServiceImpl) Heartbeat(ctx context.Context, workerID string) error {
+	if workerID == "" {
+		return fmt.Errorf("worker ID cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordHeartbeat(workerID, s.clock.Now())
+	return nil
+}
+
+// GetWorker retrieves the heartbeat information of a worker
+func (s *externalTaskServiceImpl) GetWorker(ctx context.Context, workerID string) (*Worker, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	worker, exists := s.workers[workerID]
+	if !exists {
+		return nil, fmt.Errorf("worker %w: %s", errors.ErrNotFound, workerID)
+	}
+	return worker, nil
+}
+
+// getLockedTaskLocked returns a task that is locked by a worker. Callers must hold the lock.
+func (s *externalTaskServiceImpl) getLockedTaskLocked(taskID, workerID string) (*ExternalTask, error) {
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("external task %w: %s", errors.ErrNotFound, taskID)
+	}
+	if task.WorkerID == "" || task.WorkerID != workerID {
+		return nil, fmt.Errorf("external task '%s' is not locked by worker '%s'", taskID, workerID)
+	}
+	return task, nil
+}
+
+// recordHeartbeat updates the last heartbeat of a worker. Callers must hold the lock.
+func (s *externalTaskServiceImpl) recordHeartbeat(workerID string, now time.Time) {
+	worker, exists := s.workers[workerID]
+	if !exists {
+		worker = &Worker{ID: workerID}
+		s.workers[workerID] = worker
+	}
+	worker.LastHeartbeat = now
+}
+
+// isAvailable returns whether a task can be handed out to a worker.
+// A task is available when it is unlocked, its lock has expired, or the worker
+// holding the lock stopped sending heartbeats. Failed tasks are available once their
+// retry timeout has passed, as long as retries are left. Callers must hold the lock.
+func (s *externalTaskServiceImpl) isAvailable(task *ExternalTask, now time.Time) bool {
+	if task.HasIncident() {
+		return false
+	}
+	if task.WorkerID == "" {
+		return task.LockExpirationTime == nil || !task.LockExpirationTime.After(now)
+	}
+	if !task.IsLocked(now) {
+		return true
+	}
+
+	worker, exists := s.workers[task.WorkerID]
+	if !exists {
+		return true
+	}
+	return !worker.IsAlive(now, s.workerTimeout)
+}
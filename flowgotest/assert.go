@@ -0,0 +1,214 @@
+package flowgotest
+
+import (
+	"strings"
+
+	"github.com/muixstudio/flowgo/api/task"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// ProcessInstanceAssert asserts the state of a process instance. Failed assertions
+// fail the test and return the assert, so that assertions can be chained.
+type ProcessInstanceAssert struct {
+	engine            *Engine
+	processInstanceID string
+}
+
+// AssertThat returns the assertions of a process instance
+func (e *Engine) AssertThat(processInstanceID string) *ProcessInstanceAssert {
+	return &ProcessInstanceAssert{engine: e, processInstanceID: processInstanceID}
+}
+
+// AssertProcessEnded asserts that the process instance reached its end
+func (a *ProcessInstanceAssert) AssertProcessEnded() *ProcessInstanceAssert {
+	a.engine.t.Helper()
+
+	if !a.ended() {
+		a.engine.t.Errorf("process instance %s is not ended, active activities: %s",
+			a.processInstanceID, strings.Join(a.activeActivityIDs(), ", "))
+	}
+	return a
+}
+
+// AssertProcessNotEnded asserts that the process instance is still running
+func (a *ProcessInstanceAssert) AssertProcessNotEnded() *ProcessInstanceAssert {
+	a.engine.t.Helper()
+
+	if a.ended() {
+		a.engine.t.Errorf("process instance %s is ended", a.processInstanceID)
+	}
+	return a
+}
+
+// AssertTaskExists asserts that the process instance has an open task of a task definition
+func (a *ProcessInstanceAssert) AssertTaskExists(taskDefinitionKey string) *ProcessInstanceAssert {
+	a.engine.t.Helper()
+
+	if a.findTask(taskDefinitionKey) == nil {
+		a.engine.t.Errorf("process instance %s has no task %s, active activities: %s",
+			a.processInstanceID, taskDefinitionKey, strings.Join(a.activeActivityIDs(), ", "))
+	}
+	return a
+}
+
+// Task returns the open task of a task definition of the process instance, failing
+// the test if there is none
+func (a *ProcessInstanceAssert) Task(taskDefinitionKey string) *task.Task {
+	a.engine.t.Helper()
+
+	result := a.findTask(taskDefinitionKey)
+	if result == nil {
+		a.engine.t.Fatalf("process instance %s has no task %s", a.processInstanceID, taskDefinitionKey)
+	}
+	return result
+}
+
+// AssertVariableEquals asserts the value of a process variable. Numbers are equal if
+// their values are, e.g. 5 and 5.0, since variables read from JSON are float64.
+// The variables of ended process instances are read from the history.
+func (a *ProcessInstanceAssert) AssertVariableEquals(name string, expected interface{}) *ProcessInstanceAssert {
+	a.engine.t.Helper()
+
+	actual, exists := a.variable(name)
+	if !exists {
+		a.engine.t.Errorf("process instance %s has no variable %s", a.processInstanceID, name)
+		return a
+	}
+	if !variable.Equal(expected, actual) {
+		a.engine.t.Errorf("variable %s of process instance %s is %#v, expected %#v",
+			name, a.processInstanceID, actual, expected)
+	}
+	return a
+}
+
+// AssertActivityPassed asserts that the process instance completed activities, in any
+// order. It needs the activity history, which the engines of NewEngine record.
+func (a *ProcessInstanceAssert) AssertActivityPassed(activityIDs ...string) *ProcessInstanceAssert {
+	a.engine.t.Helper()
+
+	activities, err := a.engine.GetHistoryService().CreateHistoricActivityInstanceQuery().
+		ProcessInstanceID(a.processInstanceID).
+		Finished().
+		List(a.engine.ctx)
+	if err != nil {
+		a.engine.t.Fatalf("failed to query activities of process instance %s: %v", a.processInstanceID, err)
+	}
+
+	passed := make(map[string]bool, len(activities))
+	for _, activity := range activities {
+		passed[activity.ActivityID] = true
+	}
+	var missing []string
+	for _, activityID := range activityIDs {
+		if !passed[activityID] {
+			missing = append(missing, activityID)
+		}
+	}
+	if len(missing) > 0 {
+		a.engine.t.Errorf("process instance %s did not pass activities: %s",
+			a.processInstanceID, strings.Join(missing, ", "))
+	}
+	return a
+}
+
+// AssertActivitiesActive asserts that the process instance is currently at activities,
+// in any order, e.g. waiting at a user task inside a subprocess
+func (a *ProcessInstanceAssert) AssertActivitiesActive(activityIDs ...string) *ProcessInstanceAssert {
+	a.engine.t.Helper()
+
+	active := a.activeActivityIDs()
+	isActive := make(map[string]bool, len(active))
+	for _, activityID := range active {
+		isActive[activityID] = true
+	}
+	var missing []string
+	for _, activityID := range activityIDs {
+		if !isActive[activityID] {
+			missing = append(missing, activityID)
+		}
+	}
+	if len(missing) > 0 {
+		a.engine.t.Errorf("process instance %s is not at activities: %s, active activities: %s",
+			a.processInstanceID, strings.Join(missing, ", "), strings.Join(active, ", "))
+	}
+	return a
+}
+
+// ended reports whether the process instance ended; process instances no longer known
+// to the runtime are looked up in the history
+func (a *ProcessInstanceAssert) ended() bool {
+	a.engine.t.Helper()
+
+	processInstance, err := a.engine.GetRuntimeService().GetProcessInstance(a.engine.ctx, a.processInstanceID)
+	if err == nil {
+		return processInstance.EndTime != nil
+	}
+	if !errors.Is(err, errors.ErrNotFound) {
+		a.engine.t.Fatalf("failed to get process instance %s: %v", a.processInstanceID, err)
+	}
+
+	count, err := a.engine.GetHistoryService().CreateHistoricProcessInstanceQuery().
+		ProcessInstanceID(a.processInstanceID).
+		Finished().
+		Count(a.engine.ctx)
+	if err != nil {
+		a.engine.t.Fatalf("failed to query historic process instance %s: %v", a.processInstanceID, err)
+	}
+	return count > 0
+}
+
+// activeActivityIDs returns the activities the process instance waits in, for the
+// messages of failed assertions
+func (a *ProcessInstanceAssert) activeActivityIDs() []string {
+	activityIDs, err := a.engine.GetRuntimeService().GetActiveActivityIds(a.engine.ctx, a.processInstanceID)
+	if err != nil {
+		return nil
+	}
+	return activityIDs
+}
+
+// findTask returns the open task of a task definition of the process instance, if any
+func (a *ProcessInstanceAssert) findTask(taskDefinitionKey string) *task.Task {
+	a.engine.t.Helper()
+
+	tasks, err := a.engine.GetTaskService().CreateTaskQuery().
+		ProcessInstanceID(a.processInstanceID).
+		TaskDefinitionKey(taskDefinitionKey).
+		List(a.engine.ctx)
+	if err != nil {
+		a.engine.t.Fatalf("failed to query tasks of process instance %s: %v", a.processInstanceID, err)
+	}
+	if len(tasks) == 0 {
+		return nil
+	}
+	return tasks[0]
+}
+
+// variable returns the value of a process variable, from the history once the
+// process instance is no longer known to the runtime
+func (a *ProcessInstanceAssert) variable(name string) (interface{}, bool) {
+	a.engine.t.Helper()
+
+	variables, err := a.engine.GetRuntimeService().GetVariables(a.engine.ctx, a.processInstanceID)
+	if err == nil {
+		value, exists := variables[name]
+		return value, exists
+	}
+	if !errors.Is(err, errors.ErrNotFound) {
+		a.engine.t.Fatalf("failed to get variables of process instance %s: %v", a.processInstanceID, err)
+	}
+
+	historicVariables, err := a.engine.GetHistoryService().CreateHistoricVariableInstanceQuery().
+		ProcessInstanceID(a.processInstanceID).
+		VariableName(name).
+		ExcludeTaskVariables().
+		List(a.engine.ctx)
+	if err != nil {
+		a.engine.t.Fatalf("failed to query historic variables of process instance %s: %v", a.processInstanceID, err)
+	}
+	if len(historicVariables) == 0 {
+		return nil, false
+	}
+	return historicVariables[0].Value, true
+}
@@ -0,0 +1,191 @@
+// Package flowgotest helps to test processes: it creates an engine keeping its state
+// in memory with a test clock and without job executor, runs the due jobs on demand and
+// asserts the state of process instances.
+//
+//	func TestLeaveApproval(t *testing.T) {
+//		e := flowgotest.NewEngine(t)
+//		e.Deploy("leave_approval.json", processDefinitionJSON)
+//		processInstance := e.StartProcessInstance("leave-approval-process", nil)
+//
+//		task := e.AssertThat(processInstance.ID).AssertTaskExists("approve").Task("approve")
+//		e.CompleteTask(task.ID, map[string]interface{}{"approved": true})
+//
+//		e.AdvanceClock(24 * time.Hour) // runs the timers due within a day
+//		e.AssertThat(processInstance.ID).
+//			AssertActivityPassed("approve", "notify").
+//			AssertVariableEquals("approved", true).
+//			AssertProcessEnded()
+//	}
+package flowgotest
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/muixstudio/flowgo/api/history"
+	"github.com/muixstudio/flowgo/api/repository"
+	"github.com/muixstudio/flowgo/api/runtime"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// StartTime is the time the test clock of a new engine starts at
+var StartTime = time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// maxJobRounds limits the rounds of ExecuteJobs, so that jobs creating due jobs over
+// and over, e.g. a timer cycle without end, fail the test instead of hanging it
+const maxJobRounds = 1000
+
+// T is the part of *testing.T the harness reports failures to
+type T interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+// Engine is a started process engine for a test. It keeps its state in memory, tells
+// time by a test clock and runs jobs only when the test asks for it. The engine is
+// stopped when the test ends.
+type Engine struct {
+	engine.ProcessEngine
+
+	// Clock is the clock of the engine; it stands still until the test moves it
+	Clock *clock.TestClock
+
+	t   T
+	ctx context.Context
+}
+
+// NewEngine creates and starts an engine for a test, with full history so that the
+// activities passed can be asserted. Options change the configuration before the
+// engine is created, e.g. to register an identity provider.
+func NewEngine(t T, options ...func(config *engine.ProcessEngineConfiguration)) *Engine {
+	t.Helper()
+
+	testClock := clock.NewTestClock(StartTime)
+	config := engine.DefaultProcessEngineConfiguration()
+	config.EngineName = "flowgotest"
+	config.DatabaseDriver = persistence.DriverMemory
+	config.DatabaseURL = ""
+	config.EnableAsync = false
+	config.HistoryLevel = history.HistoryLevelFull
+	config.Clock = testClock
+	config.LogLevel = slog.LevelWarn
+	for _, option := range options {
+		option(config)
+	}
+
+	processEngine, err := engine.NewProcessEngine(config)
+	if err != nil {
+		t.Fatalf("failed to create process engine: %v", err)
+	}
+	ctx := context.Background()
+	if err := processEngine.Start(ctx); err != nil {
+		t.Fatalf("failed to start process engine: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = processEngine.Stop(ctx)
+	})
+
+	return &Engine{
+		ProcessEngine: processEngine,
+		Clock:         testClock,
+		t:             t,
+		ctx:           ctx,
+	}
+}
+
+// Context returns the context the harness calls the engine with
+func (e *Engine) Context() context.Context {
+	return e.ctx
+}
+
+// Deploy deploys a process definition
+func (e *Engine) Deploy(resourceName string, content []byte) *repository.Deployment {
+	e.t.Helper()
+
+	deployment, err := e.GetRepositoryService().CreateDeployment().
+		Name(resourceName).
+		AddProcessDefinition(resourceName, content).
+		Deploy(e.ctx)
+	if err != nil {
+		e.t.Fatalf("failed to deploy %s: %v", resourceName, err)
+	}
+	return deployment
+}
+
+// StartProcessInstance starts a process instance of the latest version of a process definition
+func (e *Engine) StartProcessInstance(processDefinitionKey string, variables map[string]interface{}) *runtime.ProcessInstance {
+	e.t.Helper()
+
+	processInstance, err := e.GetRuntimeService().StartProcessInstanceByKey(e.ctx, processDefinitionKey, variables)
+	if err != nil {
+		e.t.Fatalf("failed to start process instance of %s: %v", processDefinitionKey, err)
+	}
+	return processInstance
+}
+
+// CompleteTask completes a task with variables
+func (e *Engine) CompleteTask(taskID string, variables map[string]interface{}) {
+	e.t.Helper()
+
+	if err := e.GetTaskService().CompleteWithVariables(e.ctx, taskID, variables); err != nil {
+		e.t.Fatalf("failed to complete task %s: %v", taskID, err)
+	}
+}
+
+// AdvanceClock moves the test clock forward and runs the jobs that became due, e.g.
+// timers
+func (e *Engine) AdvanceClock(d time.Duration) {
+	e.t.Helper()
+
+	e.Clock.Advance(d)
+	e.ExecuteJobs()
+}
+
+// ExecuteJobs runs the due jobs, and the jobs they create that are due as well, until
+// none are left. It returns the number of jobs run. A failing job fails the test; it
+// keeps its retries as with the job executor, due again after its retry interval. Jobs
+// of suspended process instances are skipped, as by the job executor.
+func (e *Engine) ExecuteJobs() int {
+	e.t.Helper()
+
+	executed := 0
+	suspended := make(map[string]bool)
+	for round := 0; ; round++ {
+		if round == maxJobRounds {
+			e.t.Fatalf("jobs still due after %d rounds of job execution", maxJobRounds)
+		}
+
+		jobs, err := e.GetManagementService().CreateJobQuery().Executable().List(e.ctx)
+		if err != nil {
+			e.t.Fatalf("failed to query jobs: %v", err)
+		}
+		due := 0
+		for _, job := range jobs {
+			if suspended[job.ID] {
+				continue
+			}
+			due++
+			err := e.GetManagementService().ExecuteJob(e.ctx, job.ID)
+			if errors.Is(err, errors.ErrNotFound) {
+				continue // removed by a job before it, e.g. the timer of a boundary event left
+			}
+			if errors.Is(err, errors.ErrSuspended) {
+				suspended[job.ID] = true
+				continue
+			}
+			if err != nil {
+				e.t.Errorf("job %s of activity %s failed: %v", job.ID, job.ActivityID, err)
+			}
+			executed++
+		}
+		if due == 0 {
+			return executed
+		}
+	}
+}
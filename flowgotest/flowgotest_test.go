@@ -0,0 +1,687 @@
+package flowgotest_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/muixstudio/flowgo/api/history"
+	"github.com/muixstudio/flowgo/api/runtime"
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/flowgotest"
+)
+
+// routingProcess routes large amounts to a manager and the others to a clerk
+const routingProcess = `{
+	"id": "routing",
+	"name": "Routing",
+	"nodes": [
+		{"id": "start", "type": "startEvent"},
+		{"id": "check-amount", "type": "exclusiveGateway"},
+		{"id": "manager", "type": "userTask", "name": "Manager"},
+		{"id": "clerk", "type": "userTask", "name": "Clerk"},
+		{"id": "end-manager", "type": "endEvent"},
+		{"id": "end-clerk", "type": "endEvent"}
+	],
+	"edges": [
+		{"id": "flow1", "source": "start", "target": "check-amount"},
+		{"id": "flow2", "source": "check-amount", "target": "manager", "condition": "${amount > 1000}"},
+		{"id": "flow3", "source": "check-amount", "target": "clerk", "isDefault": true},
+		{"id": "flow4", "source": "manager", "target": "end-manager"},
+		{"id": "flow5", "source": "clerk", "target": "end-clerk"}
+	]
+}`
+
+// parallelProcess reviews in two parallel tasks and archives when both are done
+const parallelProcess = `{
+	"id": "parallel",
+	"name": "Parallel",
+	"nodes": [
+		{"id": "start", "type": "startEvent"},
+		{"id": "fork", "type": "parallelGateway"},
+		{"id": "legal", "type": "userTask", "name": "Legal"},
+		{"id": "finance", "type": "userTask", "name": "Finance"},
+		{"id": "join", "type": "parallelGateway"},
+		{"id": "archive", "type": "userTask", "name": "Archive"},
+		{"id": "end", "type": "endEvent"}
+	],
+	"edges": [
+		{"id": "flow1", "source": "start", "target": "fork"},
+		{"id": "flow2", "source": "fork", "target": "legal"},
+		{"id": "flow3", "source": "fork", "target": "finance"},
+		{"id": "flow4", "source": "legal", "target": "join"},
+		{"id": "flow5", "source": "finance", "target": "join"},
+		{"id": "flow6", "source": "join", "target": "archive"},
+		{"id": "flow7", "source": "archive", "target": "end"}
+	]
+}`
+
+// approvalProcess names its instances by order and waits at a user task before it ends
+const approvalProcess = `{
+	"id": "approval",
+	"name": "Approval",
+	"processInstanceName": "Order ${orderId}",
+	"nodes": [
+		{"id": "start", "type": "startEvent"},
+		{"id": "approve", "type": "userTask", "name": "Approve"},
+		{"id": "end", "type": "endEvent"}
+	],
+	"edges": [
+		{"id": "flow1", "source": "start", "target": "approve"},
+		{"id": "flow2", "source": "approve", "target": "end"}
+	]
+}`
+
+// timerProcess waits at a timer with the event definition given to fmt.Sprintf, then
+// at a user task
+const timerProcess = `{
+	"id": "timer",
+	"name": "Timer",
+	"nodes": [
+		{"id": "start", "type": "startEvent"},
+		{"id": "wait", "type": "intermediateEvent", "properties": {"eventType": "timer", "eventDefinition": %s}},
+		{"id": "follow-up", "type": "userTask", "name": "Follow up"},
+		{"id": "end", "type": "endEvent"}
+	],
+	"edges": [
+		{"id": "flow1", "source": "start", "target": "wait"},
+		{"id": "flow2", "source": "wait", "target": "follow-up"},
+		{"id": "flow3", "source": "follow-up", "target": "end"}
+	]
+}`
+
+func TestGatewayRouting(t *testing.T) {
+	tests := []struct {
+		name       string
+		process    string
+		key        string
+		variables  map[string]interface{}
+		complete   []string // tasks completed in order after the start
+		passed     []string
+		active     []string
+		notPassed  []string
+		processEnd bool
+	}{
+		{
+			name:      "exclusive gateway takes the matching condition",
+			process:   routingProcess,
+			key:       "routing",
+			variables: map[string]interface{}{"amount": 5000},
+			passed:    []string{"start", "check-amount"},
+			active:    []string{"manager"},
+			notPassed: []string{"clerk"},
+		},
+		{
+			name:      "exclusive gateway takes the default flow",
+			process:   routingProcess,
+			key:       "routing",
+			variables: map[string]interface{}{"amount": 200},
+			passed:    []string{"start", "check-amount"},
+			active:    []string{"clerk"},
+			notPassed: []string{"manager"},
+		},
+		{
+			name:       "exclusive gateway path ends at its own end event",
+			process:    routingProcess,
+			key:        "routing",
+			variables:  map[string]interface{}{"amount": 1001},
+			complete:   []string{"manager"},
+			passed:     []string{"check-amount", "manager", "end-manager"},
+			notPassed:  []string{"clerk", "end-clerk"},
+			processEnd: true,
+		},
+		{
+			name:    "parallel gateway forks into all flows",
+			process: parallelProcess,
+			key:     "parallel",
+			passed:  []string{"start", "fork"},
+			active:  []string{"legal", "finance"},
+		},
+		{
+			name:      "parallel gateway joins after all flows arrived",
+			process:   parallelProcess,
+			key:       "parallel",
+			complete:  []string{"legal"},
+			passed:    []string{"legal"},
+			active:    []string{"finance"},
+			notPassed: []string{"archive"},
+		},
+		{
+			name:       "parallel gateway continues after the join",
+			process:    parallelProcess,
+			key:        "parallel",
+			complete:   []string{"finance", "legal", "archive"},
+			passed:     []string{"fork", "legal", "finance", "join", "archive", "end"},
+			processEnd: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := flowgotest.NewEngine(t)
+			e.Deploy(tt.key+".json", []byte(tt.process))
+			processInstance := e.StartProcessInstance(tt.key, tt.variables)
+
+			for _, taskDefinitionKey := range tt.complete {
+				e.CompleteTask(e.AssertThat(processInstance.ID).Task(taskDefinitionKey).ID, nil)
+			}
+
+			assert := e.AssertThat(processInstance.ID).AssertActivityPassed(tt.passed...)
+			if len(tt.active) > 0 {
+				assert.AssertActivitiesActive(tt.active...)
+			}
+			if tt.processEnd {
+				assert.AssertProcessEnded()
+			} else {
+				assert.AssertProcessNotEnded()
+			}
+
+			passed := passedActivities(t, e, processInstance.ID)
+			for _, activityID := range tt.notPassed {
+				if passed[activityID] {
+					t.Errorf("activity %s was passed", activityID)
+				}
+			}
+		})
+	}
+}
+
+func TestSuspension(t *testing.T) {
+	tests := []struct {
+		name   string
+		action func(e *flowgotest.Engine, taskID string) error
+	}{
+		{
+			name: "complete task",
+			action: func(e *flowgotest.Engine, taskID string) error {
+				return e.GetTaskService().Complete(e.Context(), taskID)
+			},
+		},
+		{
+			name: "claim task",
+			action: func(e *flowgotest.Engine, taskID string) error {
+				return e.GetTaskService().Claim(e.Context(), taskID, "alice")
+			},
+		},
+		{
+			name: "set assignee",
+			action: func(e *flowgotest.Engine, taskID string) error {
+				return e.GetTaskService().SetAssignee(e.Context(), taskID, "bob")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := flowgotest.NewEngine(t)
+			e.Deploy("approval.json", []byte(approvalProcess))
+			processInstance := e.StartProcessInstance("approval", map[string]interface{}{"orderId": "A-1"})
+			taskID := e.AssertThat(processInstance.ID).Task("approve").ID
+
+			runtimeService := e.GetRuntimeService()
+			if err := runtimeService.SuspendProcessInstance(e.Context(), processInstance.ID); err != nil {
+				t.Fatalf("failed to suspend: %v", err)
+			}
+			assertSuspended(t, e, processInstance.ID, true)
+
+			if err := tt.action(e, taskID); !errors.Is(err, errors.ErrSuspended) {
+				t.Fatalf("expected a suspended error, got %v", err)
+			}
+
+			if err := runtimeService.ActivateProcessInstance(e.Context(), processInstance.ID); err != nil {
+				t.Fatalf("failed to activate: %v", err)
+			}
+			assertSuspended(t, e, processInstance.ID, false)
+
+			e.CompleteTask(taskID, nil)
+			e.AssertThat(processInstance.ID).AssertActivityPassed("approve", "end").AssertProcessEnded()
+		})
+	}
+}
+
+func TestTimer(t *testing.T) {
+	tests := []struct {
+		name            string
+		eventDefinition string
+		variables       map[string]interface{}
+		before          time.Duration // the timer is not due yet after this time
+		due             time.Duration // the timer is due after this time
+	}{
+		{
+			name:            "duration",
+			eventDefinition: `{"timerType": "duration", "timerValue": "PT1H"}`,
+			before:          59 * time.Minute,
+			due:             time.Hour,
+		},
+		{
+			name:            "duration in Go syntax",
+			eventDefinition: `{"timerType": "duration", "timerValue": "90m"}`,
+			before:          89 * time.Minute,
+			due:             90 * time.Minute,
+		},
+		{
+			name:            "duration expression",
+			eventDefinition: `{"timerType": "duration", "timerValue": "${wait}"}`,
+			variables:       map[string]interface{}{"wait": "PT45M"},
+			before:          44 * time.Minute,
+			due:             45 * time.Minute,
+		},
+		{
+			name:            "date",
+			eventDefinition: `{"timerType": "date", "timerValue": "2025-01-02T09:00:00Z"}`,
+			before:          23 * time.Hour,
+			due:             24 * time.Hour,
+		},
+		{
+			name:            "cycle",
+			eventDefinition: `{"timerType": "cycle", "timerValue": "R3/PT2H"}`,
+			before:          time.Hour,
+			due:             2 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := flowgotest.NewEngine(t)
+			e.Deploy("timer.json", []byte(fmt.Sprintf(timerProcess, tt.eventDefinition)))
+			processInstance := e.StartProcessInstance("timer", tt.variables)
+
+			e.AdvanceClock(tt.before)
+			e.AssertThat(processInstance.ID).AssertActivitiesActive("wait")
+
+			e.AdvanceClock(tt.due - tt.before)
+			e.AssertThat(processInstance.ID).
+				AssertActivityPassed("wait").
+				AssertTaskExists("follow-up")
+		})
+	}
+}
+
+func TestTimerOfSuspendedProcessInstance(t *testing.T) {
+	tests := []struct {
+		name      string
+		suspended time.Duration // how long the instance is suspended, starting half an hour after the start
+	}{
+		{name: "suspended before the due date", suspended: 15 * time.Minute},
+		{name: "suspended past the due date", suspended: 2 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := flowgotest.NewEngine(t)
+			e.Deploy("timer.json", []byte(fmt.Sprintf(timerProcess, `{"timerType": "duration", "timerValue": "PT1H"}`)))
+			processInstance := e.StartProcessInstance("timer", nil)
+			runtimeService := e.GetRuntimeService()
+
+			e.AdvanceClock(30 * time.Minute)
+			if err := runtimeService.SuspendProcessInstance(e.Context(), processInstance.ID); err != nil {
+				t.Fatalf("failed to suspend: %v", err)
+			}
+			e.AdvanceClock(tt.suspended)
+			e.AssertThat(processInstance.ID).AssertActivitiesActive("wait")
+
+			// the timer is postponed by the time the instance was suspended
+			if err := runtimeService.ActivateProcessInstance(e.Context(), processInstance.ID); err != nil {
+				t.Fatalf("failed to activate: %v", err)
+			}
+			e.AdvanceClock(29 * time.Minute)
+			e.AssertThat(processInstance.ID).AssertActivitiesActive("wait")
+
+			e.AdvanceClock(time.Minute)
+			e.AssertThat(processInstance.ID).AssertTaskExists("follow-up")
+		})
+	}
+}
+
+func TestProcessInstanceQueryPaging(t *testing.T) {
+	e := startOrders(t)
+
+	tests := []struct {
+		name     string
+		query    func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery
+		first    int
+		max      int
+		expected []string
+	}{
+		{
+			name: "first page by name",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.OrderByProcessInstanceName().Asc()
+			},
+			first:    0,
+			max:      2,
+			expected: []string{"Order A-1", "Order A-2"},
+		},
+		{
+			name: "middle page by name",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.OrderByProcessInstanceName().Asc()
+			},
+			first:    2,
+			max:      2,
+			expected: []string{"Order B-1", "Order B-12"},
+		},
+		{
+			name: "last page is partial",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.OrderByProcessInstanceName().Asc()
+			},
+			first:    4,
+			max:      2,
+			expected: []string{"Order C-3"},
+		},
+		{
+			name: "page past the end is empty",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.OrderByProcessInstanceName().Asc()
+			},
+			first:    10,
+			max:      2,
+			expected: []string{},
+		},
+		{
+			name: "descending by name",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.OrderByProcessInstanceName().Desc()
+			},
+			first:    0,
+			max:      3,
+			expected: []string{"Order C-3", "Order B-12", "Order B-1"},
+		},
+		{
+			name:     "by start time",
+			query:    func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery { return q.OrderByStartTime().Asc() },
+			first:    1,
+			max:      3,
+			expected: []string{"Order B-12", "Order A-1", "Order C-3"},
+		},
+		{
+			name: "filtered and paged",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.VariableValueEquals("region", "north").OrderByProcessInstanceName().Desc()
+			},
+			first:    0,
+			max:      5,
+			expected: []string{"Order A-2", "Order A-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query(e.GetRuntimeService().CreateProcessInstanceQuery())
+			processInstances, err := query.ListPage(e.Context(), tt.first, tt.max)
+			if err != nil {
+				t.Fatalf("failed to list page: %v", err)
+			}
+			assertNames(t, processInstances, tt.expected)
+
+			count, err := query.Count(e.Context())
+			if err != nil {
+				t.Fatalf("failed to count: %v", err)
+			}
+			all, err := tt.query(e.GetRuntimeService().CreateProcessInstanceQuery()).List(e.Context())
+			if err != nil {
+				t.Fatalf("failed to list: %v", err)
+			}
+			if count != int64(len(all)) {
+				t.Errorf("expected count %d regardless of paging, got %d", len(all), count)
+			}
+		})
+	}
+}
+
+func TestProcessInstanceQueryLikeAndOr(t *testing.T) {
+	e := startOrders(t)
+
+	tests := []struct {
+		name     string
+		query    func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery
+		expected []string
+	}{
+		{
+			name: "prefix",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.ProcessInstanceNameLike("Order A-%")
+			},
+			expected: []string{"Order A-1", "Order A-2"},
+		},
+		{
+			name: "single character",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.ProcessInstanceNameLike("Order B-_")
+			},
+			expected: []string{"Order B-1"},
+		},
+		{
+			name: "infix",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.ProcessInstanceNameLike("%-1%")
+			},
+			expected: []string{"Order A-1", "Order B-1", "Order B-12"},
+		},
+		{
+			name: "whole value",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.ProcessInstanceNameLike("A-1")
+			},
+			expected: []string{},
+		},
+		{
+			name: "or of filters",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.Or().ProcessInstanceNameLike("Order A-%").ProcessInstanceBusinessKey("C-3").EndOr()
+			},
+			expected: []string{"Order A-1", "Order A-2", "Order C-3"},
+		},
+		{
+			name: "or combined with a filter",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.VariableValueEquals("region", "south").
+					Or().ProcessInstanceNameLike("Order A-%").ProcessInstanceNameLike("Order B-%").EndOr()
+			},
+			expected: []string{"Order B-1", "Order B-12"},
+		},
+		{
+			name: "or without match",
+			query: func(q *runtime.ProcessInstanceQuery) *runtime.ProcessInstanceQuery {
+				return q.Or().ProcessInstanceNameLike("Order D-%").ProcessInstanceBusinessKey("D-1").EndOr()
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processInstances, err := tt.query(e.GetRuntimeService().CreateProcessInstanceQuery()).
+				OrderByProcessInstanceName().Asc().
+				List(e.Context())
+			if err != nil {
+				t.Fatalf("failed to list: %v", err)
+			}
+			assertNames(t, processInstances, tt.expected)
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		name             string
+		level            history.HistoryLevel
+		processInstances int64
+		activities       int64
+		tasks            int64
+		variables        int64
+		details          bool
+	}{
+		{name: "none", level: history.HistoryLevelNone},
+		{name: "activity", level: history.HistoryLevelActivity, processInstances: 1, activities: 3, tasks: 1},
+		{name: "audit", level: history.HistoryLevelAudit, processInstances: 1, activities: 3, tasks: 1, variables: 2},
+		{name: "full", level: history.HistoryLevelFull, processInstances: 1, activities: 3, tasks: 1, variables: 2, details: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := flowgotest.NewEngine(t, func(config *engine.ProcessEngineConfiguration) {
+				config.HistoryLevel = tt.level
+			})
+			deployment := e.Deploy("approval.json", []byte(approvalProcess))
+			processInstance := e.StartProcessInstance("approval", map[string]interface{}{"orderId": "A-1"})
+			e.AdvanceClock(time.Hour)
+			e.CompleteTask(e.AssertThat(processInstance.ID).Task("approve").ID, map[string]interface{}{"approved": true})
+
+			historyService := e.GetHistoryService()
+			if historyService.GetHistoryLevel() != tt.level {
+				t.Fatalf("expected history level %s, got %s", tt.level, historyService.GetHistoryLevel())
+			}
+			if tt.level == history.HistoryLevelNone {
+				return // the engine records nothing and has no history to query
+			}
+			ctx := e.Context()
+			processInstances, err := historyService.CreateHistoricProcessInstanceQuery().
+				ProcessInstanceID(processInstance.ID).Finished().List(ctx)
+			if err != nil {
+				t.Fatalf("failed to query historic process instances: %v", err)
+			}
+			if int64(len(processInstances)) != tt.processInstances {
+				t.Fatalf("expected %d finished historic process instances, got %d", tt.processInstances, len(processInstances))
+			}
+			if len(processInstances) == 1 {
+				historicProcessInstance := processInstances[0]
+				if historicProcessInstance.Name != "Order A-1" {
+					t.Errorf("expected name Order A-1, got %q", historicProcessInstance.Name)
+				}
+				if historicProcessInstance.DeploymentID != deployment.ID || historicProcessInstance.ProcessDefinitionVersion != 1 {
+					t.Errorf("expected deployment %s version 1, got %s version %d", deployment.ID,
+						historicProcessInstance.DeploymentID, historicProcessInstance.ProcessDefinitionVersion)
+				}
+				if historicProcessInstance.StartActivityID != "start" || historicProcessInstance.EndActivityID != "end" {
+					t.Errorf("expected start and end activities start and end, got %s and %s",
+						historicProcessInstance.StartActivityID, historicProcessInstance.EndActivityID)
+				}
+				if historicProcessInstance.DurationInMillis == nil || *historicProcessInstance.DurationInMillis != time.Hour.Milliseconds() {
+					t.Errorf("expected a duration of one hour, got %v", historicProcessInstance.DurationInMillis)
+				}
+			}
+
+			activities, err := historyService.CreateHistoricActivityInstanceQuery().
+				ProcessInstanceID(processInstance.ID).Finished().OrderByStartTime().Asc().Count(ctx)
+			if err != nil {
+				t.Fatalf("failed to count historic activity instances: %v", err)
+			}
+			if activities != tt.activities {
+				t.Errorf("expected %d finished historic activity instances, got %d", tt.activities, activities)
+			}
+
+			tasks, err := historyService.CreateHistoricTaskInstanceQuery().ProcessInstanceID(processInstance.ID).Count(ctx)
+			if err != nil {
+				t.Fatalf("failed to count historic task instances: %v", err)
+			}
+			if tasks != tt.tasks {
+				t.Errorf("expected %d historic task instances, got %d", tt.tasks, tasks)
+			}
+
+			variables, err := historyService.CreateHistoricVariableInstanceQuery().ProcessInstanceID(processInstance.ID).Count(ctx)
+			if err != nil {
+				t.Fatalf("failed to count historic variable instances: %v", err)
+			}
+			if variables != tt.variables {
+				t.Errorf("expected %d historic variable instances, got %d", tt.variables, variables)
+			}
+			if tt.variables > 0 {
+				approved, err := historyService.CreateHistoricVariableInstanceQuery().
+					ProcessInstanceID(processInstance.ID).VariableValueEquals("approved", true).Count(ctx)
+				if err != nil {
+					t.Fatalf("failed to query historic variable instances: %v", err)
+				}
+				if approved != 1 {
+					t.Errorf("expected the approved variable in history, got %d matches", approved)
+				}
+			}
+
+			details, err := historyService.CreateHistoricDetailQuery().ProcessInstanceID(processInstance.ID).Count(ctx)
+			if err != nil {
+				t.Fatalf("failed to count historic details: %v", err)
+			}
+			if (details > 0) != tt.details {
+				t.Errorf("expected historic details %v, got %d", tt.details, details)
+			}
+		})
+	}
+}
+
+// startOrders starts an approval process instance for each order, a minute apart
+func startOrders(t *testing.T) *flowgotest.Engine {
+	t.Helper()
+
+	e := flowgotest.NewEngine(t)
+	e.Deploy("approval.json", []byte(approvalProcess))
+	orders := []struct {
+		id     string
+		region string
+	}{
+		{"B-1", "south"},
+		{"B-12", "south"},
+		{"A-1", "north"},
+		{"C-3", "east"},
+		{"A-2", "north"},
+	}
+	for _, order := range orders {
+		_, err := e.GetRuntimeService().StartProcessInstanceByKeyWithBusinessKey(e.Context(), "approval", order.id,
+			map[string]interface{}{"orderId": order.id, "region": order.region})
+		if err != nil {
+			t.Fatalf("failed to start order %s: %v", order.id, err)
+		}
+		e.AdvanceClock(time.Minute)
+	}
+	return e
+}
+
+// passedActivities returns the IDs of the activities a process instance passed
+func passedActivities(t *testing.T, e *flowgotest.Engine, processInstanceID string) map[string]bool {
+	t.Helper()
+
+	activities, err := e.GetHistoryService().CreateHistoricActivityInstanceQuery().
+		ProcessInstanceID(processInstanceID).List(e.Context())
+	if err != nil {
+		t.Fatalf("failed to query historic activity instances: %v", err)
+	}
+	passed := make(map[string]bool, len(activities))
+	for _, activity := range activities {
+		passed[activity.ActivityID] = true
+	}
+	return passed
+}
+
+// assertSuspended asserts the suspension state of a process instance and of its tasks
+func assertSuspended(t *testing.T, e *flowgotest.Engine, processInstanceID string, suspended bool) {
+	t.Helper()
+
+	processInstances, err := e.GetRuntimeService().CreateProcessInstanceQuery().
+		ProcessInstanceID(processInstanceID).Suspended().Count(e.Context())
+	if err != nil {
+		t.Fatalf("failed to query process instances: %v", err)
+	}
+	tasks, err := e.GetTaskService().CreateTaskQuery().
+		ProcessInstanceID(processInstanceID).Suspended().Count(e.Context())
+	if err != nil {
+		t.Fatalf("failed to query tasks: %v", err)
+	}
+	if (processInstances == 1) != suspended || (tasks == 1) != suspended {
+		t.Errorf("expected suspended %v, found %d suspended process instances and %d suspended tasks",
+			suspended, processInstances, tasks)
+	}
+}
+
+// assertNames asserts the names of process instances in order
+func assertNames(t *testing.T, processInstances []*runtime.ProcessInstance, expected []string) {
+	t.Helper()
+
+	names := make([]string, len(processInstances))
+	for i, processInstance := range processInstances {
+		names[i] = processInstance.Name
+	}
+	if fmt.Sprint(names) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
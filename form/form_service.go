@@ -0,0 +1,60 @@
+// Package form provides the forms users fill in to start processes and complete tasks.
+//
+// Form definitions are JSON resources deployed alongside process definitions (see
+// repository.DeploymentBuilder.AddFormDefinition). Start events and user tasks
+// reference a form with the "formKey" property; each field of the form reads and
+// writes the process variable named by its ID.
+package form
+
+import (
+	"context"
+
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/internal/task"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// FormService provides the forms of process definitions and tasks and submits them.
+// This service is responsible for:
+// - Rendering start and task forms with their current values
+// - Validating and converting submitted form values
+// - Starting process instances and completing tasks from submitted forms
+type FormService interface {
+	// GetStartFormData returns the start form of a process definition with the default values
+	GetStartFormData(ctx context.Context, processDefinitionID string) (*StartFormData, error)
+
+	// GetTaskFormData returns the form of a task with the current values of its variables
+	GetTaskFormData(ctx context.Context, taskID string) (*TaskFormData, error)
+
+	// SubmitStartForm validates the submitted values and starts a process instance with them
+	SubmitStartForm(ctx context.Context, processDefinitionID, businessKey string, values map[string]interface{}) (*runtime.ProcessInstance, error)
+
+	// SubmitTaskForm validates the submitted values and completes the task with them
+	SubmitTaskForm(ctx context.Context, taskID string, values map[string]interface{}) error
+}
+
+// FormData is a form with the values of its fields
+type FormData struct {
+	FormKey string
+	Name    string
+	Fields  []*FormField
+}
+
+// StartFormData is the start form of a process definition
+type StartFormData struct {
+	FormData
+	ProcessDefinition *repository.ProcessDefinition
+}
+
+// TaskFormData is the form of a task
+type TaskFormData struct {
+	FormData
+	Task *task.Task
+}
+
+// FormField is a field of a form with its current value
+type FormField struct {
+	model.FormField
+	Value interface{}
+}
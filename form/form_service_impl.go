@@ -0,0 +1,300 @@
+package form
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/internal/task"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// formServiceImpl is the default implementation of FormService
+type formServiceImpl struct {
+	repositoryService repository.RepositoryService
+	runtimeService    runtime.RuntimeService
+	taskService       task.TaskService
+}
+
+// NewFormService creates a new form service
+func NewFormService(repositoryService repository.RepositoryService, runtimeService runtime.RuntimeService, taskService task.TaskService) FormService {
+	return &formServiceImpl{
+		repositoryService: repositoryService,
+		runtimeService:    runtimeService,
+		taskService:       taskService,
+	}
+}
+
+// GetStartFormData returns the start form of a process definition with the default values
+func (s *formServiceImpl) GetStartFormData(ctx context.Context, processDefinitionID string) (*StartFormData, error) {
+	processDefinition, form, err := s.getStartForm(ctx, processDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	return &StartFormData{
+		FormData:          newFormData(form, nil),
+		ProcessDefinition: processDefinition,
+	}, nil
+}
+
+// GetTaskFormData returns the form of a task with the current values of its variables
+func (s *formServiceImpl) GetTaskFormData(ctx context.Context, taskID string) (*TaskFormData, error) {
+	t, form, err := s.getTaskForm(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := s.taskService.GetTaskVariables(ctx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &TaskFormData{
+		FormData: newFormData(form, variables),
+		Task:     t,
+	}, nil
+}
+
+// SubmitStartForm validates the submitted values and starts a process instance with them
+func (s *formServiceImpl) SubmitStartForm(ctx context.Context, processDefinitionID, businessKey string, values map[string]interface{}) (*runtime.ProcessInstance, error) {
+	processDefinition, form, err := s.getStartForm(ctx, processDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := validateFormValues(form, values, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if businessKey != "" {
+		return s.runtimeService.StartProcessInstanceByKeyWithBusinessKey(ctx, processDefinition.Key, businessKey, variables)
+	}
+	return s.runtimeService.StartProcessInstanceByID(ctx, processDefinitionID, variables)
+}
+
+// SubmitTaskForm validates the submitted values and completes the task with them
+func (s *formServiceImpl) SubmitTaskForm(ctx context.Context, taskID string, values map[string]interface{}) error {
+	t, form, err := s.getTaskForm(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	current, err := s.taskService.GetTaskVariables(ctx, t.ID)
+	if err != nil {
+		return err
+	}
+	variables, err := validateFormValues(form, values, current)
+	if err != nil {
+		return err
+	}
+	return s.taskService.CompleteWithVariables(ctx, taskID, variables)
+}
+
+// getStartForm returns a process definition with its start form
+func (s *formServiceImpl) getStartForm(ctx context.Context, processDefinitionID string) (*repository.ProcessDefinition, *model.FormDefinition, error) {
+	processDefinition, err := s.repositoryService.GetProcessDefinition(ctx, processDefinitionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !processDefinition.HasStartFormKey {
+		return nil, nil, fmt.Errorf("process definition '%s' has no start form", processDefinitionID)
+	}
+	form, err := s.repositoryService.GetFormDefinition(ctx, processDefinition.StartFormKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return processDefinition, form, nil
+}
+
+// getTaskForm returns a task with its form
+func (s *formServiceImpl) getTaskForm(ctx context.Context, taskID string) (*task.Task, *model.FormDefinition, error) {
+	t, err := s.taskService.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.FormKey == "" {
+		return nil, nil, fmt.Errorf("task '%s' has no form", taskID)
+	}
+	form, err := s.repositoryService.GetFormDefinition(ctx, t.FormKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return t, form, nil
+}
+
+// newFormData fills the fields of a form with the variables, falling back to the default values
+func newFormData(form *model.FormDefinition, variables map[string]interface{}) FormData {
+	data := FormData{
+		FormKey: form.Key,
+		Name:    form.Name,
+		Fields:  make([]*FormField, 0, len(form.Fields)),
+	}
+	for _, field := range form.Fields {
+		value, exists := variables[field.ID]
+		if !exists {
+			value = field.DefaultValue
+		}
+		data.Fields = append(data.Fields, &FormField{FormField: *field, Value: value})
+	}
+	return data
+}
+
+// validateFormValues checks submitted values against the fields of a form and converts
+// them to the field types. Values of readonly fields are ignored; a required field
+// must be submitted unless it has a current value or a default value.
+func validateFormValues(form *model.FormDefinition, values, current map[string]interface{}) (map[string]interface{}, error) {
+	for name := range values {
+		if form.GetField(name) == nil {
+			return nil, fmt.Errorf("unknown field '%s' of form '%s'", name, form.Key)
+		}
+	}
+
+	variables := make(map[string]interface{})
+	for _, field := range form.Fields {
+		value, submitted := values[field.ID]
+		if field.Readonly || !submitted || value == nil {
+			if _, exists := current[field.ID]; exists {
+				continue
+			}
+			if field.DefaultValue != nil {
+				variables[field.ID] = field.DefaultValue
+				continue
+			}
+			if field.Validation != nil && field.Validation.Required {
+				return nil, fmt.Errorf("form field '%s' is required", field.ID)
+			}
+			continue
+		}
+
+		converted, err := convertFormValue(field, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of form field '%s': %w", field.ID, err)
+		}
+		if err := checkConstraints(field, converted); err != nil {
+			return nil, fmt.Errorf("invalid value of form field '%s': %w", field.ID, err)
+		}
+		variables[field.ID] = converted
+	}
+	return variables, nil
+}
+
+// convertFormValue converts a submitted value to the type of a form field. Values may
+// be submitted as strings, e.g. from an HTML form, or as decoded JSON values.
+func convertFormValue(field *model.FormField, value interface{}) (interface{}, error) {
+	switch field.Type {
+	case model.FormFieldTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case model.FormFieldTypeLong:
+		switch v := value.(type) {
+		case int:
+			return int64(v), nil
+		case int32:
+			return int64(v), nil
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int64(v), nil
+			}
+		case json.Number:
+			return v.Int64()
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case model.FormFieldTypeDouble:
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case float32:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case json.Number:
+			return v.Float64()
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+	case model.FormFieldTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	case model.FormFieldTypeDate:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			if t, err := time.Parse(time.RFC3339, v); err == nil {
+				return t, nil
+			}
+			return time.Parse(time.DateOnly, v)
+		}
+	case model.FormFieldTypeEnum:
+		if s, ok := value.(string); ok {
+			for _, option := range field.Options {
+				if option.ID == s {
+					return s, nil
+				}
+			}
+			return nil, fmt.Errorf("'%s' is not an option", s)
+		}
+	}
+	return nil, fmt.Errorf("%T is not a %s value", value, field.Type)
+}
+
+// checkConstraints checks a converted value against the validation constraints of a field
+func checkConstraints(field *model.FormField, value interface{}) error {
+	validation := field.Validation
+	if validation == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if validation.Required && length == 0 {
+			return fmt.Errorf("value is required")
+		}
+		if validation.MinLength != nil && length < *validation.MinLength {
+			return fmt.Errorf("must be at least %d characters", *validation.MinLength)
+		}
+		if validation.MaxLength != nil && length > *validation.MaxLength {
+			return fmt.Errorf("must be at most %d characters", *validation.MaxLength)
+		}
+		if validation.Pattern != "" {
+			matched, err := regexp.MatchString(validation.Pattern, v)
+			if err != nil {
+				return err
+			}
+			if !matched {
+				return fmt.Errorf("does not match pattern %s", validation.Pattern)
+			}
+		}
+	case int64:
+		return checkRange(validation, float64(v))
+	case float64:
+		return checkRange(validation, v)
+	}
+	return nil
+}
+
+// checkRange checks a numeric value against the minimum and maximum of a field
+func checkRange(validation *model.Validation, value float64) error {
+	if validation.Min != nil && value < *validation.Min {
+		return fmt.Errorf("must be at least %v", *validation.Min)
+	}
+	if validation.Max != nil && value > *validation.Max {
+		return fmt.Errorf("must be at most %v", *validation.Max)
+	}
+	return nil
+}
@@ -2,4 +2,14 @@ module github.com/muixstudio/flowgo
 
 go 1.25.1
 
-require github.com/google/uuid v1.6.0
+require (
+	github.com/dop251/goja v0.0.0-20260106131823-651366fbe6e3
+	github.com/google/uuid v1.6.0
+)
+
+require (
+	github.com/dlclark/regexp2 v1.11.4 // indirect
+	github.com/go-sourcemap/sourcemap v2.1.4+incompatible // indirect
+	github.com/google/pprof v0.0.0-20230207041349-798e818bf904 // indirect
+	golang.org/x/text v0.40.0 // indirect
+)
@@ -1,173 +0,0 @@
-package history
-
-import (
-	"context"
-	"fmt"
-	"sync"
-)
-
-// historyServiceImpl is the default implementation of HistoryService
-type historyServiceImpl struct {
-	databaseDriver      string
-	databaseURL         string
-	processInstances    map[string]*HistoricProcessInstance
-	tasks               map[string]*HistoricTaskInstance
-	activities          map[string]*HistoricActivityInstance
-	variables           map[string]*HistoricVariableInstance
-	mu                  sync.RWMutex
-}
-
-// NewHistoryService creates a new history service
-func NewHistoryService(databaseDriver, databaseURL string) HistoryService {
-	return &historyServiceImpl{
-		databaseDriver:   databaseDriver,
-		databaseURL:      databaseURL,
-		processInstances: make(map[string]*HistoricProcessInstance),
-		tasks:            make(map[string]*HistoricTaskInstance),
-		activities:       make(map[string]*HistoricActivityInstance),
-		variables:        make(map[string]*HistoricVariableInstance),
-	}
-}
-
-// Initialize initializes the history service
-func (s *historyServiceImpl) Initialize(ctx context.Context) error {
-	// TODO: Initialize database connection
-	return nil
-}
-
-// Shutdown gracefully shuts down the history service
-func (s *historyServiceImpl) Shutdown(ctx context.Context) error {
-	// TODO: Close database connections
-	return nil
-}
-
-// CreateHistoricProcessInstanceQuery creates a new historic process instance query
-func (s *historyServiceImpl) CreateHistoricProcessInstanceQuery() *HistoricProcessInstanceQuery {
-	return &HistoricProcessInstanceQuery{
-		service: s,
-	}
-}
-
-// CreateHistoricTaskInstanceQuery creates a new historic task instance query
-func (s *historyServiceImpl) CreateHistoricTaskInstanceQuery() *HistoricTaskInstanceQuery {
-	return &HistoricTaskInstanceQuery{
-		service: s,
-	}
-}
-
-// CreateHistoricActivityInstanceQuery creates a new historic activity instance query
-func (s *historyServiceImpl) CreateHistoricActivityInstanceQuery() *HistoricActivityInstanceQuery {
-	return &HistoricActivityInstanceQuery{
-		service: s,
-	}
-}
-
-// CreateHistoricVariableInstanceQuery creates a new historic variable instance query
-func (s *historyServiceImpl) CreateHistoricVariableInstanceQuery() *HistoricVariableInstanceQuery {
-	return &HistoricVariableInstanceQuery{
-		service: s,
-	}
-}
-
-// DeleteHistoricProcessInstance deletes a historic process instance
-func (s *historyServiceImpl) DeleteHistoricProcessInstance(ctx context.Context, processInstanceID string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.processInstances[processInstanceID]; !exists {
-		return fmt.Errorf("historic process instance not found: %s", processInstanceID)
-	}
-
-	delete(s.processInstances, processInstanceID)
-
-	// Delete related data
-	for id, task := range s.tasks {
-		if task.ProcessInstanceID == processInstanceID {
-			delete(s.tasks, id)
-		}
-	}
-
-	for id, activity := range s.activities {
-		if activity.ProcessInstanceID == processInstanceID {
-			delete(s.activities, id)
-		}
-	}
-
-	for id, variable := range s.variables {
-		if variable.ProcessInstanceID == processInstanceID {
-			delete(s.variables, id)
-		}
-	}
-
-	return nil
-}
-
-// DeleteHistoricTaskInstance deletes a historic task instance
-func (s *historyServiceImpl) DeleteHistoricTaskInstance(ctx context.Context, taskID string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if _, exists := s.tasks[taskID]; !exists {
-		return fmt.Errorf("historic task instance not found: %s", taskID)
-	}
-
-	delete(s.tasks, taskID)
-	return nil
-}
-
-// RecordProcessInstance records a process instance to history
-func (s *historyServiceImpl) RecordProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.processInstances[instance.ID] = instance
-	return nil
-}
-
-// RecordTaskInstance records a task instance to history
-func (s *historyServiceImpl) RecordTaskInstance(ctx context.Context, task *HistoricTaskInstance) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.tasks[task.ID] = task
-	return nil
-}
-
-// RecordActivityInstance records an activity instance to history
-func (s *historyServiceImpl) RecordActivityInstance(ctx context.Context, activity *HistoricActivityInstance) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.activities[activity.ID] = activity
-	return nil
-}
-
-// RecordVariableInstance records a variable instance to history
-func (s *historyServiceImpl) RecordVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.variables[variable.ID] = variable
-	return nil
-}
-
-// noOpHistoryService is a no-op implementation when history is disabled
-type noOpHistoryService struct{}
-
-// NewNoOpHistoryService creates a no-op history service
-func NewNoOpHistoryService() HistoryService {
-	return &noOpHistoryService{}
-}
-
-func (s *noOpHistoryService) Initialize(ctx context.Context) error                                   { return nil }
-func (s *noOpHistoryService) Shutdown(ctx context.Context) error                                     { return nil }
-func (s *noOpHistoryService) CreateHistoricProcessInstanceQuery() *HistoricProcessInstanceQuery      { return nil }
-func (s *noOpHistoryService) CreateHistoricTaskInstanceQuery() *HistoricTaskInstanceQuery            { return nil }
-func (s *noOpHistoryService) CreateHistoricActivityInstanceQuery() *HistoricActivityInstanceQuery    { return nil }
-func (s *noOpHistoryService) CreateHistoricVariableInstanceQuery() *HistoricVariableInstanceQuery    { return nil }
-func (s *noOpHistoryService) DeleteHistoricProcessInstance(ctx context.Context, processInstanceID string) error { return nil }
-func (s *noOpHistoryService) DeleteHistoricTaskInstance(ctx context.Context, taskID string) error    { return nil }
-func (s *noOpHistoryService) RecordProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error { return nil }
-func (s *noOpHistoryService) RecordTaskInstance(ctx context.Context, task *HistoricTaskInstance) error { return nil }
-func (s *noOpHistoryService) RecordActivityInstance(ctx context.Context, activity *HistoricActivityInstance) error { return nil }
-func (s *noOpHistoryService) RecordVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error { return nil }
@@ -0,0 +1,40 @@
+package identity
+
+import (
+	"context"
+)
+
+// Authentication is the user on whose behalf the engine is called
+type Authentication struct {
+	UserID string
+
+	// GroupIDs are groups the caller vouches for, e.g. from the claims of a token.
+	// They are used in addition to the groups of the IdentityProvider.
+	GroupIDs []string
+}
+
+// authenticationKey is the key for storing the Authentication in context.Context
+type authenticationKey struct{}
+
+// WithAuthentication returns a context carrying the authenticated user and their groups
+func WithAuthentication(ctx context.Context, userID string, groupIDs ...string) context.Context {
+	return context.WithValue(ctx, authenticationKey{}, &Authentication{
+		UserID:   userID,
+		GroupIDs: groupIDs,
+	})
+}
+
+// GetAuthentication returns the authenticated user of a context, if any
+func GetAuthentication(ctx context.Context) (*Authentication, bool) {
+	authentication, ok := ctx.Value(authenticationKey{}).(*Authentication)
+	return authentication, ok && authentication.UserID != ""
+}
+
+// AuthenticatedUserID returns the ID of the authenticated user of a context, or an
+// empty string if there is none
+func AuthenticatedUserID(ctx context.Context) string {
+	if authentication, ok := GetAuthentication(ctx); ok {
+		return authentication.UserID
+	}
+	return ""
+}
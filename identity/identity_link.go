@@ -0,0 +1,21 @@
+package identity
+
+// Identity link types
+const (
+	IdentityLinkTypeAssignee    = "assignee"
+	IdentityLinkTypeCandidate   = "candidate"
+	IdentityLinkTypeOwner       = "owner"
+	IdentityLinkTypeParticipant = "participant"
+	IdentityLinkTypeStarter     = "starter"
+)
+
+// IdentityLink relates a user or a group to a task or a process instance, e.g. as
+// candidate of a task or participant of a process instance. Exactly one of UserID
+// and GroupID is set, and one of TaskID and ProcessInstanceID.
+type IdentityLink struct {
+	Type              string
+	UserID            string
+	GroupID           string
+	TaskID            string
+	ProcessInstanceID string
+}
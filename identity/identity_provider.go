@@ -0,0 +1,42 @@
+// Package identity provides the users and groups the engine consults, e.g. to
+// expand candidate groups of tasks into users.
+//
+// The engine reads users and groups through an IdentityProvider. The built-in
+// provider keeps them in memory; organizations plug in their directory, e.g. an
+// LDAP server or the group claims of an OIDC provider, by implementing the interface.
+package identity
+
+import (
+	"context"
+)
+
+// IdentityProvider looks up users, groups and group memberships
+type IdentityProvider interface {
+	// GetUser retrieves a user by ID
+	GetUser(ctx context.Context, userID string) (*User, error)
+
+	// GetGroup retrieves a group by ID
+	GetGroup(ctx context.Context, groupID string) (*Group, error)
+
+	// GetGroupsForUser returns the groups a user is a member of
+	GetGroupsForUser(ctx context.Context, userID string) ([]*Group, error)
+
+	// GetGroupMembers returns the user IDs of all members of a group.
+	// An unknown group has no members.
+	GetGroupMembers(ctx context.Context, groupID string) ([]string, error)
+}
+
+// User is a person who can work on tasks and start processes
+type User struct {
+	ID        string
+	FirstName string
+	LastName  string
+	Email     string
+}
+
+// Group is a set of users, e.g. a department or a role
+type Group struct {
+	ID   string
+	Name string
+	Type string
+}
@@ -0,0 +1,188 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// IdentityStore is the built-in IdentityProvider, which also manages the users and groups
+type IdentityStore interface {
+	IdentityProvider
+
+	// SaveUser creates or updates a user
+	SaveUser(ctx context.Context, user *User) error
+
+	// DeleteUser deletes a user with its memberships
+	DeleteUser(ctx context.Context, userID string) error
+
+	// SaveGroup creates or updates a group
+	SaveGroup(ctx context.Context, group *Group) error
+
+	// DeleteGroup deletes a group with its memberships
+	DeleteGroup(ctx context.Context, groupID string) error
+
+	// CreateMembership adds a user to a group
+	CreateMembership(ctx context.Context, userID, groupID string) error
+
+	// DeleteMembership removes a user from a group
+	DeleteMembership(ctx context.Context, userID, groupID string) error
+}
+
+// inMemoryIdentityStore keeps users and groups in memory
+type inMemoryIdentityStore struct {
+	users       map[string]*User
+	groups      map[string]*Group
+	memberships map[string]map[string]bool // group ID -> member user IDs
+	mu          sync.RWMutex
+}
+
+// NewInMemoryIdentityStore creates an empty built-in identity store
+func NewInMemoryIdentityStore() IdentityStore {
+	return &inMemoryIdentityStore{
+		users:       make(map[string]*User),
+		groups:      make(map[string]*Group),
+		memberships: make(map[string]map[string]bool),
+	}
+}
+
+// GetUser retrieves a user by ID
+func (s *inMemoryIdentityStore) GetUser(ctx context.Context, userID string) (*User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, exists := s.users[userID]
+	if !exists {
+		return nil, fmt.Errorf("user %w: %s", errors.ErrNotFound, userID)
+	}
+	return user, nil
+}
+
+// GetGroup retrieves a group by ID
+func (s *inMemoryIdentityStore) GetGroup(ctx context.Context, groupID string) (*Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	group, exists := s.groups[groupID]
+	if !exists {
+		return nil, fmt.Errorf("group %w: %s", errors.ErrNotFound, groupID)
+	}
+	return group, nil
+}
+
+// GetGroupsForUser returns the groups a user is a member of, ordered by ID
+func (s *inMemoryIdentityStore) GetGroupsForUser(ctx context.Context, userID string) ([]*Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	groups := make([]*Group, 0)
+	for groupID, members := range s.memberships {
+		if members[userID] {
+			groups = append(groups, s.groups[groupID])
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+	return groups, nil
+}
+
+// GetGroupMembers returns the user IDs of all members of a group, ordered by ID
+func (s *inMemoryIdentityStore) GetGroupMembers(ctx context.Context, groupID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := make([]string, 0, len(s.memberships[groupID]))
+	for userID := range s.memberships[groupID] {
+		members = append(members, userID)
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// SaveUser creates or updates a user
+func (s *inMemoryIdentityStore) SaveUser(ctx context.Context, user *User) error {
+	if user == nil || user.ID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[user.ID] = user
+	return nil
+}
+
+// DeleteUser deletes a user with its memberships
+func (s *inMemoryIdentityStore) DeleteUser(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[userID]; !exists {
+		return fmt.Errorf("user %w: %s", errors.ErrNotFound, userID)
+	}
+	delete(s.users, userID)
+	for _, members := range s.memberships {
+		delete(members, userID)
+	}
+	return nil
+}
+
+// SaveGroup creates or updates a group
+func (s *inMemoryIdentityStore) SaveGroup(ctx context.Context, group *Group) error {
+	if group == nil || group.ID == "" {
+		return fmt.Errorf("group ID is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[group.ID] = group
+	return nil
+}
+
+// DeleteGroup deletes a group with its memberships
+func (s *inMemoryIdentityStore) DeleteGroup(ctx context.Context, groupID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.groups[groupID]; !exists {
+		return fmt.Errorf("group %w: %s", errors.ErrNotFound, groupID)
+	}
+	delete(s.groups, groupID)
+	delete(s.memberships, groupID)
+	return nil
+}
+
+// CreateMembership adds a user to a group
+func (s *inMemoryIdentityStore) CreateMembership(ctx context.Context, userID, groupID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[userID]; !exists {
+		return fmt.Errorf("user %w: %s", errors.ErrNotFound, userID)
+	}
+	if _, exists := s.groups[groupID]; !exists {
+		return fmt.Errorf("group %w: %s", errors.ErrNotFound, groupID)
+	}
+	if s.memberships[groupID] == nil {
+		s.memberships[groupID] = make(map[string]bool)
+	}
+	s.memberships[groupID][userID] = true
+	return nil
+}
+
+// DeleteMembership removes a user from a group
+func (s *inMemoryIdentityStore) DeleteMembership(ctx context.Context, userID, groupID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.memberships[groupID][userID] {
+		return fmt.Errorf("membership %w: user %s in group %s", errors.ErrNotFound, userID, groupID)
+	}
+	delete(s.memberships[groupID], userID)
+	return nil
+}
@@ -0,0 +1,38 @@
+package identity
+
+import (
+	"context"
+)
+
+// tenantKey is the key for storing the tenants of the caller in context.Context
+type tenantKey struct{}
+
+// WithTenantIDs returns a context restricting the caller to tenants. Queries then only
+// return entities of these tenants or without tenant, and entities of other tenants
+// cannot be claimed or resolved by key.
+func WithTenantIDs(ctx context.Context, tenantIDs ...string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, append([]string(nil), tenantIDs...))
+}
+
+// GetTenantIDs returns the tenants the caller of a context is restricted to, and
+// whether a tenant context is set at all
+func GetTenantIDs(ctx context.Context) ([]string, bool) {
+	tenantIDs, ok := ctx.Value(tenantKey{}).([]string)
+	return tenantIDs, ok
+}
+
+// IsTenantAccessible reports whether the caller of a context may access an entity of
+// a tenant: always without tenant context, otherwise if the entity belongs to no tenant
+// or to one of the tenants of the context
+func IsTenantAccessible(ctx context.Context, tenantID string) bool {
+	tenantIDs, ok := GetTenantIDs(ctx)
+	if !ok || tenantID == "" {
+		return true
+	}
+	for _, id := range tenantIDs {
+		if id == tenantID {
+			return true
+		}
+	}
+	return false
+}
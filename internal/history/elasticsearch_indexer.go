@@ -0,0 +1,361 @@
+package history
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ElasticsearchConfig configures an ElasticsearchIndexer
+type ElasticsearchConfig struct {
+	// URL is the base URL of the cluster, e.g. "http://localhost:9200"
+	URL string
+
+	// IndexPrefix prefixes the names of the indices (default "flowgo-history")
+	IndexPrefix string
+
+	// Username and Password enable basic authentication
+	Username string
+	Password string
+
+	// APIKey enables API key authentication
+	APIKey string
+
+	// Refresh makes indexed documents visible to searches immediately.
+	// Useful for tests; expensive at high write volumes.
+	Refresh bool
+
+	// HTTPClient is the client used for requests (default: 10s timeout)
+	HTTPClient *http.Client
+}
+
+// searchableFields are the fields matched by free-text searches, with boosts
+var searchableFields = []string{"businessKey^3", "name^2", "description", "value", "assignee", "owner", "startUserId", "category", "deleteReason"}
+
+// ElasticsearchIndexer is a HistoryIndexer backed by Elasticsearch or OpenSearch.
+// Process instances, tasks and variables are stored in separate indices that
+// share one mapping, so a single search covers all of them.
+type ElasticsearchIndexer struct {
+	config ElasticsearchConfig
+	client *http.Client
+}
+
+// NewElasticsearchIndexer creates an indexer for an Elasticsearch or OpenSearch cluster
+func NewElasticsearchIndexer(config ElasticsearchConfig) (*ElasticsearchIndexer, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("elasticsearch URL cannot be empty")
+	}
+	if _, err := url.Parse(config.URL); err != nil {
+		return nil, fmt.Errorf("invalid elasticsearch URL: %w", err)
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	if config.IndexPrefix == "" {
+		config.IndexPrefix = "flowgo-history"
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &ElasticsearchIndexer{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// indexFor returns the index holding documents of a type
+func (i *ElasticsearchIndexer) indexFor(documentType string) string {
+	switch documentType {
+	case SearchTypeProcessInstance:
+		return i.config.IndexPrefix + "-process-instances"
+	case SearchTypeTaskInstance:
+		return i.config.IndexPrefix + "-tasks"
+	default:
+		return i.config.IndexPrefix + "-variables"
+	}
+}
+
+// allIndices returns the comma separated list of all indices
+func (i *ElasticsearchIndexer) allIndices() string {
+	return strings.Join([]string{
+		i.indexFor(SearchTypeProcessInstance),
+		i.indexFor(SearchTypeTaskInstance),
+		i.indexFor(SearchTypeVariable),
+	}, ",")
+}
+
+// Initialize creates the indices with their mappings if they do not exist yet
+func (i *ElasticsearchIndexer) Initialize(ctx context.Context) error {
+	keyword := map[string]interface{}{"type": "keyword"}
+	text := map[string]interface{}{"type": "text"}
+	date := map[string]interface{}{"type": "date"}
+
+	mapping := map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"type":                 keyword,
+				"id":                   keyword,
+				"processInstanceId":    keyword,
+				"processDefinitionId":  keyword,
+				"processDefinitionKey": keyword,
+				"taskId":               keyword,
+				"tenantId":             keyword,
+				"assignee":             keyword,
+				"owner":                keyword,
+				"startUserId":          keyword,
+				"typeName":             keyword,
+				"tags":                 keyword,
+				"businessKey": map[string]interface{}{
+					"type":   "text",
+					"fields": map[string]interface{}{"raw": keyword},
+				},
+				"name":         text,
+				"description":  text,
+				"category":     text,
+				"deleteReason": text,
+				"value":        text,
+				"startTime":    date,
+				"endTime":      date,
+			},
+		},
+	}
+
+	for _, documentType := range []string{SearchTypeProcessInstance, SearchTypeTaskInstance, SearchTypeVariable} {
+		index := i.indexFor(documentType)
+
+		status, _, err := i.do(ctx, http.MethodHead, "/"+index, nil)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusOK {
+			continue
+		}
+
+		status, body, err := i.do(ctx, http.MethodPut, "/"+index, mapping)
+		if err != nil {
+			return err
+		}
+		// Another node may have created the index concurrently
+		if status >= 300 && !bytes.Contains(body, []byte("resource_already_exists_exception")) {
+			return fmt.Errorf("failed to create index %s: status %d: %s", index, status, body)
+		}
+	}
+	return nil
+}
+
+// IndexProcessInstance adds or updates a historic process instance in the index
+func (i *ElasticsearchIndexer) IndexProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error {
+	return i.put(ctx, SearchTypeProcessInstance, instance.ID, processInstanceDocument(instance))
+}
+
+// IndexTaskInstance adds or updates a historic task instance in the index
+func (i *ElasticsearchIndexer) IndexTaskInstance(ctx context.Context, task *HistoricTaskInstance) error {
+	return i.put(ctx, SearchTypeTaskInstance, task.ID, taskInstanceDocument(task))
+}
+
+// IndexVariableInstance adds or updates a historic variable instance in the index
+func (i *ElasticsearchIndexer) IndexVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error {
+	return i.put(ctx, SearchTypeVariable, variable.ID, variableInstanceDocument(variable))
+}
+
+// put stores a document under its ID
+func (i *ElasticsearchIndexer) put(ctx context.Context, documentType, id string, document map[string]interface{}) error {
+	path := fmt.Sprintf("/%s/_doc/%s", i.indexFor(documentType), url.PathEscape(id))
+	if i.config.Refresh {
+		path += "?refresh=true"
+	}
+
+	status, body, err := i.do(ctx, http.MethodPut, path, document)
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("failed to index %s %s: status %d: %s", documentType, id, status, body)
+	}
+	return nil
+}
+
+// DeleteProcessInstance removes a process instance and its tasks and variables from the index
+func (i *ElasticsearchIndexer) DeleteProcessInstance(ctx context.Context, processInstanceID string) error {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"processInstanceId": processInstanceID},
+		},
+	}
+
+	path := "/" + i.allIndices() + "/_delete_by_query?conflicts=proceed"
+	if i.config.Refresh {
+		path += "&refresh=true"
+	}
+
+	status, body, err := i.do(ctx, http.MethodPost, path, query)
+	if err != nil {
+		return err
+	}
+	if status >= 300 {
+		return fmt.Errorf("failed to delete process instance %s from index: status %d: %s", processInstanceID, status, body)
+	}
+	return nil
+}
+
+// Search runs a full-text search over the indexed documents
+func (i *ElasticsearchIndexer) Search(ctx context.Context, freeText string, filters *SearchFilters) (*SearchResult, error) {
+	if filters == nil {
+		filters = &SearchFilters{}
+	}
+
+	must := make([]interface{}, 0, 1)
+	if strings.TrimSpace(freeText) != "" {
+		must = append(must, map[string]interface{}{
+			"simple_query_string": map[string]interface{}{
+				"query":            freeText,
+				"fields":           searchableFields,
+				"default_operator": "and",
+				"lenient":          true,
+			},
+		})
+	} else {
+		must = append(must, map[string]interface{}{"match_all": map[string]interface{}{}})
+	}
+
+	filter := make([]interface{}, 0)
+	if len(filters.Types) > 0 {
+		filter = append(filter, map[string]interface{}{"terms": map[string]interface{}{"type": filters.Types}})
+	}
+	for field, value := range map[string]string{
+		"processDefinitionKey": filters.ProcessDefinitionKey,
+		"processInstanceId":    filters.ProcessInstanceID,
+		"tenantId":             filters.TenantID,
+	} {
+		if value != "" {
+			filter = append(filter, map[string]interface{}{"term": map[string]interface{}{field: value}})
+		}
+	}
+	if filters.StartedAfter != nil || filters.StartedBefore != nil {
+		rangeQuery := make(map[string]interface{})
+		if filters.StartedAfter != nil {
+			rangeQuery["gt"] = filters.StartedAfter.Format(time.RFC3339Nano)
+		}
+		if filters.StartedBefore != nil {
+			rangeQuery["lt"] = filters.StartedBefore.Format(time.RFC3339Nano)
+		}
+		filter = append(filter, map[string]interface{}{"range": map[string]interface{}{"startTime": rangeQuery}})
+	}
+
+	size := filters.MaxResults
+	if size <= 0 {
+		size = 20
+	}
+
+	request := map[string]interface{}{
+		"from":             filters.FirstResult,
+		"size":             size,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   must,
+				"filter": filter,
+			},
+		},
+	}
+
+	status, body, err := i.do(ctx, http.MethodPost, "/"+i.allIndices()+"/_search", request)
+	if err != nil {
+		return nil, err
+	}
+	if status >= 300 {
+		return nil, fmt.Errorf("history search failed: status %d: %s", status, body)
+	}
+
+	var response struct {
+		Hits struct {
+			Total json.RawMessage `json:"total"`
+			Hits  []struct {
+				ID     string                 `json:"_id"`
+				Score  float64                `json:"_score"`
+				Source map[string]interface{} `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("invalid search response: %w", err)
+	}
+
+	result := &SearchResult{
+		Total: parseTotalHits(response.Hits.Total),
+		Hits:  make([]*SearchHit, 0, len(response.Hits.Hits)),
+	}
+	for _, hit := range response.Hits.Hits {
+		documentType, _ := hit.Source["type"].(string)
+		processInstanceID, _ := hit.Source["processInstanceId"].(string)
+		result.Hits = append(result.Hits, &SearchHit{
+			Type:              documentType,
+			ID:                hit.ID,
+			ProcessInstanceID: processInstanceID,
+			Score:             hit.Score,
+			Document:          hit.Source,
+		})
+	}
+	return result, nil
+}
+
+// parseTotalHits reads the total hit count, which is an object since
+// Elasticsearch 7 and a plain number in older versions and some OpenSearch setups
+func parseTotalHits(raw json.RawMessage) int64 {
+	var total struct {
+		Value int64 `json:"value"`
+	}
+	if err := json.Unmarshal(raw, &total); err == nil {
+		return total.Value
+	}
+	var count int64
+	if err := json.Unmarshal(raw, &count); err == nil {
+		return count
+	}
+	return 0
+}
+
+// do sends a request to the cluster and returns the status code and response body
+func (i *ElasticsearchIndexer) do(ctx context.Context, method, path string, payload interface{}) (int, []byte, error) {
+	var reader io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, i.config.URL+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	switch {
+	case i.config.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+i.config.APIKey)
+	case i.config.Username != "":
+		req.SetBasicAuth(i.config.Username, i.config.Password)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
@@ -0,0 +1,262 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// Historic detail types
+const (
+	HistoricDetailTypeVariableUpdate = "variableUpdate"
+)
+
+// HistoricDetail is a single change recorded at HistoryLevelFull, e.g. one update of
+// a variable with the value it replaced. Details let auditors reconstruct how a
+// variable changed over the life of a process instance.
+type HistoricDetail struct {
+	ID                   string
+	Type                 string
+	ProcessInstanceID    string
+	ProcessDefinitionKey string
+	ExecutionID          string
+	ActivityID           string // activity of the execution that made the change, if any
+	VariableName         string
+	TypeName             string
+	Value                interface{}
+	OldValue             interface{} // nil when the update created the variable
+	UserID               string      // authenticated user who made the change
+	Revision             int         // number of the update of the variable, starting at 1
+	Time                 time.Time
+	TenantID             string
+}
+
+// HistoricDetailQuery provides a fluent API for querying historic details
+type HistoricDetailQuery struct {
+	processInstanceID string
+	executionID       string
+	activityID        string
+	variableName      string
+	userID            string
+	occurredBefore    *time.Time
+	occurredAfter     *time.Time
+	orderBy           string
+	ascending         bool
+	firstResult       int
+	maxResults        int
+	service           HistoryService
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *HistoricDetailQuery) ProcessInstanceID(id string) *HistoricDetailQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ExecutionID filters by the execution that made the change
+func (q *HistoricDetailQuery) ExecutionID(id string) *HistoricDetailQuery {
+	q.executionID = id
+	return q
+}
+
+// ActivityID filters by the activity at which the change was made
+func (q *HistoricDetailQuery) ActivityID(id string) *HistoricDetailQuery {
+	q.activityID = id
+	return q
+}
+
+// VariableName filters by variable name
+func (q *HistoricDetailQuery) VariableName(name string) *HistoricDetailQuery {
+	q.variableName = name
+	return q
+}
+
+// UserID filters by the user who made the change
+func (q *HistoricDetailQuery) UserID(userID string) *HistoricDetailQuery {
+	q.userID = userID
+	return q
+}
+
+// OccurredBefore filters to changes made before a date
+func (q *HistoricDetailQuery) OccurredBefore(date time.Time) *HistoricDetailQuery {
+	q.occurredBefore = &date
+	return q
+}
+
+// OccurredAfter filters to changes made after a date
+func (q *HistoricDetailQuery) OccurredAfter(date time.Time) *HistoricDetailQuery {
+	q.occurredAfter = &date
+	return q
+}
+
+// OrderByTime orders results by the time of the change
+func (q *HistoricDetailQuery) OrderByTime() *HistoricDetailQuery {
+	q.orderBy = "time"
+	return q
+}
+
+// OrderByVariableName orders results by variable name
+func (q *HistoricDetailQuery) OrderByVariableName() *HistoricDetailQuery {
+	q.orderBy = "variable_name"
+	return q
+}
+
+// Asc sets ascending order
+func (q *HistoricDetailQuery) Asc() *HistoricDetailQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *HistoricDetailQuery) Desc() *HistoricDetailQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *HistoricDetailQuery) Limit(maxResults int) *HistoricDetailQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *HistoricDetailQuery) Offset(firstResult int) *HistoricDetailQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of historic details
+func (q *HistoricDetailQuery) List(ctx context.Context) ([]*HistoricDetail, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.listDetails(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching historic details, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *HistoricDetailQuery) Iterate(ctx context.Context) iter.Seq2[*HistoricDetail, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*HistoricDetail, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of historic details
+func (q *HistoricDetailQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*HistoricDetail, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching historic details
+func (q *HistoricDetailQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	details, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(details)), nil
+}
+
+// CreateHistoricDetailQuery creates a new historic detail query
+func (s *historyServiceImpl) CreateHistoricDetailQuery() *HistoricDetailQuery {
+	return &HistoricDetailQuery{
+		service:   s,
+		ascending: true,
+	}
+}
+
+// RecordDetail records a historic detail
+func (s *historyServiceImpl) RecordDetail(ctx context.Context, detail *HistoricDetail) error {
+	if !s.GetHistoryLevel().Includes(HistoryLevelFull) {
+		return nil
+	}
+	value, err := variable.Of(detail.Value)
+	if err != nil {
+		return fmt.Errorf("historic detail %s: %w", detail.ID, err)
+	}
+	oldValue, err := variable.Of(detail.OldValue)
+	if err != nil {
+		return fmt.Errorf("historic detail %s: %w", detail.ID, err)
+	}
+	detail.Value, detail.OldValue = value.Value, oldValue.Value
+	if detail.TypeName == "" {
+		detail.TypeName = value.Type
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.details[detail.ID] = detail
+	return s.saveLocked(ctx, persistence.TableHistoricDetails, detail.ID, &historicDetailState{
+		HistoricDetail: detail,
+		Value:          value,
+		OldValue:       oldValue,
+	})
+}
+
+// listDetails executes a historic detail query
+func (s *historyServiceImpl) listDetails(ctx context.Context, q *HistoricDetailQuery) ([]*HistoricDetail, error) {
+	s.mu.RLock()
+	result := make([]*HistoricDetail, 0)
+	for _, detail := range s.details {
+		if identity.IsTenantAccessible(ctx, detail.TenantID) && matchesDetailQuery(detail, q) {
+			result = append(result, detail)
+		}
+	}
+	s.mu.RUnlock()
+
+	paging.Sort(result, func(a, b *HistoricDetail) bool {
+		return a.ID < b.ID
+	}, func(a, b *HistoricDetail) bool {
+		var c int
+		switch q.orderBy {
+		case "variable_name":
+			c = strings.Compare(a.VariableName, b.VariableName)
+		case "time":
+			c = a.Time.Compare(b.Time)
+		default:
+			// Unordered queries return details in the order they occurred
+			return a.Time.Before(b.Time) || a.Time.Equal(b.Time) && a.Revision < b.Revision
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// matchesDetailQuery checks a historic detail against the query filters
+func matchesDetailQuery(detail *HistoricDetail, q *HistoricDetailQuery) bool {
+	if q.processInstanceID != "" && detail.ProcessInstanceID != q.processInstanceID {
+		return false
+	}
+	if q.executionID != "" && detail.ExecutionID != q.executionID {
+		return false
+	}
+	if q.activityID != "" && detail.ActivityID != q.activityID {
+		return false
+	}
+	if q.variableName != "" && detail.VariableName != q.variableName {
+		return false
+	}
+	if q.userID != "" && detail.UserID != q.userID {
+		return false
+	}
+	if q.occurredBefore != nil && !detail.Time.Before(*q.occurredBefore) {
+		return false
+	}
+	if q.occurredAfter != nil && !detail.Time.After(*q.occurredAfter) {
+		return false
+	}
+	return true
+}
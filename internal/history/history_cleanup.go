@@ -0,0 +1,181 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// HistoryArchiver exports historic process instances before history cleanup or a
+// deletion batch removes them, e.g. to cold storage
+type HistoryArchiver interface {
+	// Archive exports a historic process instance with its related history. An error
+	// keeps the instance in history; the batch continues with the next one.
+	Archive(ctx context.Context, archive *HistoricProcessInstanceArchive) error
+}
+
+// HistoricProcessInstanceArchive is a historic process instance together with its
+// tasks, activities, variables and details
+type HistoricProcessInstanceArchive struct {
+	ProcessInstance *HistoricProcessInstance    `json:"processInstance"`
+	Tasks           []*HistoricTaskInstance     `json:"tasks"`
+	Activities      []*HistoricActivityInstance `json:"activities"`
+	Variables       []*HistoricVariableInstance `json:"variables"`
+	Details         []*HistoricDetail           `json:"details"`
+}
+
+// jsonArchiver writes archives as JSON lines
+type jsonArchiver struct {
+	writer io.Writer
+	mu     sync.Mutex
+}
+
+// NewJSONArchiver creates an archiver writing each archived process instance as one
+// line of JSON to a writer
+func NewJSONArchiver(writer io.Writer) HistoryArchiver {
+	return &jsonArchiver{writer: writer}
+}
+
+// Archive writes an archive as a line of JSON
+func (a *jsonArchiver) Archive(ctx context.Context, archive *HistoricProcessInstanceArchive) error {
+	line, err := json.Marshal(archive)
+	if err != nil {
+		return fmt.Errorf("failed to encode historic process instance %s: %w", archive.ProcessInstance.ID, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, err = a.writer.Write(append(line, '\n'))
+	return err
+}
+
+// DeleteHistoricProcessInstancesBefore deletes the historic process instances finished before a date as a batch
+func (s *historyServiceImpl) DeleteHistoricProcessInstancesBefore(ctx context.Context, date time.Time) (*Batch, error) {
+	instances, err := s.listProcessInstances(ctx, &HistoricProcessInstanceQuery{finishedBefore: &date})
+	if err != nil {
+		return nil, err
+	}
+	processInstanceIDs := make([]string, len(instances))
+	for i, instance := range instances {
+		processInstanceIDs[i] = instance.ID
+	}
+
+	return s.startDeletionBatch(ctx, BatchTypeHistoricProcessInstanceDeletion, "finished before "+date.Format(time.RFC3339), processInstanceIDs)
+}
+
+// CleanupHistory deletes the historic process instances whose removal time passed as a batch
+func (s *historyServiceImpl) CleanupHistory(ctx context.Context) (*Batch, error) {
+	now := s.clock.Now()
+
+	s.mu.RLock()
+	processInstanceIDs := make([]string, 0)
+	for id, instance := range s.processInstances {
+		if instance.EndTime != nil && instance.RemovalTime != nil && !instance.RemovalTime.After(now) {
+			processInstanceIDs = append(processInstanceIDs, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	return s.startDeletionBatch(ctx, BatchTypeHistoryCleanup, "history time to live expired", processInstanceIDs)
+}
+
+// SetCleanupInterval sets the interval history cleanup runs at
+func (s *historyServiceImpl) SetCleanupInterval(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cleanupInterval = interval
+}
+
+// SetArchiver sets the archiver historic process instances are exported to before deletion
+func (s *historyServiceImpl) SetArchiver(archiver HistoryArchiver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.archiver = archiver
+}
+
+// runCleanup runs history cleanup at an interval until the service shuts down
+func (s *historyServiceImpl) runCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.cleanupContext.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CleanupHistory(s.cleanupContext); err != nil {
+				s.logger.ErrorContext(s.cleanupContext, "history cleanup failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// archive exports historic process instances to the archiver, if any. It returns
+// the instances that may be deleted, i.e. all but those failing to archive.
+func (s *historyServiceImpl) archive(ctx context.Context, batch *Batch, processInstanceIDs []string) map[string]bool {
+	archivable := make(map[string]bool, len(processInstanceIDs))
+	for _, id := range processInstanceIDs {
+		archivable[id] = true
+	}
+
+	s.mu.RLock()
+	archiver := s.archiver
+	archives := make([]*HistoricProcessInstanceArchive, 0, len(processInstanceIDs))
+	if archiver != nil {
+		for _, id := range processInstanceIDs {
+			if instance, exists := s.processInstances[id]; exists {
+				archives = append(archives, s.archiveLocked(instance))
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, archive := range archives {
+		if err := archiver.Archive(ctx, archive); err != nil {
+			s.logger.ErrorContext(ctx, "failed to archive historic process instance",
+				slog.String("processInstanceId", archive.ProcessInstance.ID), slog.String("batchId", batch.ID), slog.Any("error", err))
+			delete(archivable, archive.ProcessInstance.ID)
+		}
+	}
+	return archivable
+}
+
+// archiveLocked collects a historic process instance with its related history.
+// Callers must hold the read lock.
+func (s *historyServiceImpl) archiveLocked(instance *HistoricProcessInstance) *HistoricProcessInstanceArchive {
+	archive := &HistoricProcessInstanceArchive{
+		ProcessInstance: instance,
+		Tasks:           make([]*HistoricTaskInstance, 0),
+		Activities:      make([]*HistoricActivityInstance, 0),
+		Variables:       make([]*HistoricVariableInstance, 0),
+		Details:         make([]*HistoricDetail, 0),
+	}
+	for _, task := range s.tasks {
+		if task.ProcessInstanceID == instance.ID {
+			archive.Tasks = append(archive.Tasks, task)
+		}
+	}
+	for _, activity := range s.activities {
+		if activity.ProcessInstanceID == instance.ID {
+			archive.Activities = append(archive.Activities, activity)
+		}
+	}
+	for _, variable := range s.variables {
+		if variable.ProcessInstanceID == instance.ID {
+			archive.Variables = append(archive.Variables, variable)
+		}
+	}
+	for _, detail := range s.details {
+		if detail.ProcessInstanceID == instance.ID {
+			archive.Details = append(archive.Details, detail)
+		}
+	}
+	return archive
+}
@@ -0,0 +1,140 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Historic document types stored by a HistoryIndexer
+const (
+	SearchTypeProcessInstance = "processInstance"
+	SearchTypeTaskInstance    = "taskInstance"
+	SearchTypeVariable        = "variable"
+)
+
+// HistoryIndexer mirrors history data into a full-text search store.
+// The history service stays the system of record; the index only serves searches.
+type HistoryIndexer interface {
+	// Initialize prepares the index, e.g. by creating indices and mappings
+	Initialize(ctx context.Context) error
+
+	// IndexProcessInstance adds or updates a historic process instance in the index
+	IndexProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error
+
+	// IndexTaskInstance adds or updates a historic task instance in the index
+	IndexTaskInstance(ctx context.Context, task *HistoricTaskInstance) error
+
+	// IndexVariableInstance adds or updates a historic variable instance in the index
+	IndexVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error
+
+	// DeleteProcessInstance removes a process instance and its tasks and variables from the index
+	DeleteProcessInstance(ctx context.Context, processInstanceID string) error
+
+	// Search runs a full-text search over the indexed documents
+	Search(ctx context.Context, freeText string, filters *SearchFilters) (*SearchResult, error)
+}
+
+// SearchFilters narrows a historic search. Empty fields do not filter.
+type SearchFilters struct {
+	// Types restricts the document types searched (SearchType* constants)
+	Types                []string
+	ProcessDefinitionKey string
+	ProcessInstanceID    string
+	TenantID             string
+	StartedAfter         *time.Time
+	StartedBefore        *time.Time
+	FirstResult          int
+	MaxResults           int
+}
+
+// SearchResult is a page of historic search hits
+type SearchResult struct {
+	Total int64
+	Hits  []*SearchHit
+}
+
+// SearchHit is a single document matched by a historic search
+type SearchHit struct {
+	Type              string
+	ID                string
+	ProcessInstanceID string
+	Score             float64
+	Document          map[string]interface{}
+}
+
+// processInstanceDocument converts a historic process instance into an index document
+func processInstanceDocument(instance *HistoricProcessInstance) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 SearchTypeProcessInstance,
+		"id":                   instance.ID,
+		"processInstanceId":    instance.ID,
+		"businessKey":          instance.BusinessKey,
+		"processDefinitionId":  instance.ProcessDefinitionID,
+		"processDefinitionKey": instance.ProcessDefinitionKey,
+		"name":                 instance.ProcessDefinitionName,
+		"tags":                 instance.Tags,
+		"startUserId":          instance.StartUserID,
+		"deleteReason":         instance.DeleteReason,
+		"tenantId":             instance.TenantID,
+		"startTime":            instance.StartTime,
+		"endTime":              instance.EndTime,
+	}
+}
+
+// taskInstanceDocument converts a historic task instance into an index document
+func taskInstanceDocument(task *HistoricTaskInstance) map[string]interface{} {
+	return map[string]interface{}{
+		"type":                 SearchTypeTaskInstance,
+		"id":                   task.ID,
+		"processInstanceId":    task.ProcessInstanceID,
+		"processDefinitionId":  task.ProcessDefinitionID,
+		"processDefinitionKey": task.ProcessDefinitionKey,
+		"name":                 task.Name,
+		"description":          task.Description,
+		"assignee":             task.Assignee,
+		"owner":                task.Owner,
+		"category":             task.Category,
+		"deleteReason":         task.DeleteReason,
+		"tenantId":             task.TenantID,
+		"startTime":            task.StartTime,
+		"endTime":              task.EndTime,
+	}
+}
+
+// variableInstanceDocument converts a historic variable instance into an index document.
+// The value is indexed as text so that structured business data is searchable.
+func variableInstanceDocument(variable *HistoricVariableInstance) map[string]interface{} {
+	document := map[string]interface{}{
+		"type":              SearchTypeVariable,
+		"id":                variable.ID,
+		"processInstanceId": variable.ProcessInstanceID,
+		"taskId":            variable.TaskID,
+		"name":              variable.Name,
+		"typeName":          variable.TypeName,
+		"value":             variableText(variable.Value),
+		"startTime":         variable.CreateTime,
+	}
+	if variable.ProcessDefinitionKey != "" {
+		document["processDefinitionKey"] = variable.ProcessDefinitionKey
+	}
+	if variable.TenantID != "" {
+		document["tenantId"] = variable.TenantID
+	}
+	return document
+}
+
+// variableText renders a variable value as searchable text
+func variableText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	if data, err := json.Marshal(value); err == nil {
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
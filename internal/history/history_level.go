@@ -0,0 +1,56 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HistoryLevel controls how much history the engine records. Each level records
+// everything the lower levels record.
+type HistoryLevel int
+
+// History levels
+const (
+	// HistoryLevelNone records no history
+	HistoryLevelNone HistoryLevel = iota
+
+	// HistoryLevelActivity records process instances, activity instances and task instances
+	HistoryLevelActivity
+
+	// HistoryLevelAudit additionally records the latest value of every variable
+	HistoryLevelAudit
+
+	// HistoryLevelFull additionally records every variable update and form submission
+	HistoryLevelFull
+)
+
+// historyLevelNames are the names of the history levels, e.g. in configuration files
+var historyLevelNames = map[HistoryLevel]string{
+	HistoryLevelNone:     "none",
+	HistoryLevelActivity: "activity",
+	HistoryLevelAudit:    "audit",
+	HistoryLevelFull:     "full",
+}
+
+// String returns the name of the history level
+func (l HistoryLevel) String() string {
+	if name, exists := historyLevelNames[l]; exists {
+		return name
+	}
+	return fmt.Sprintf("HistoryLevel(%d)", int(l))
+}
+
+// Includes reports whether the level records the history of another level
+func (l HistoryLevel) Includes(level HistoryLevel) bool {
+	return l >= level
+}
+
+// ParseHistoryLevel returns the history level with a name, e.g. "audit"
+func ParseHistoryLevel(name string) (HistoryLevel, error) {
+	for level, levelName := range historyLevelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+	return HistoryLevelNone, fmt.Errorf("unknown history level: %s", name)
+}
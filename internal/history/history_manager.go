@@ -0,0 +1,372 @@
+package history
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/internal/task"
+)
+
+// HistoryManager records the progress of process instances and the lifecycle of
+// tasks to a history service. It is the history event handler of the runtime service
+// and a task listener for all task events; the engine wires it up when history is enabled.
+// What is recorded depends on the history level of the service.
+type HistoryManager struct {
+	service           HistoryService
+	repositoryService repository.RepositoryService // resolves the history time to live of process definitions
+}
+
+// NewHistoryManager creates a history manager recording to a history service
+func NewHistoryManager(service HistoryService, repositoryService repository.RepositoryService) *HistoryManager {
+	return &HistoryManager{service: service, repositoryService: repositoryService}
+}
+
+// Register makes the manager record the process instances of a runtime service and
+// the tasks of a task service
+func (m *HistoryManager) Register(runtimeService runtime.RuntimeService, taskService task.TaskService) {
+	runtimeService.SetHistoryEventHandler(m)
+	taskService.AddTaskListener(task.TaskEventAll, m)
+}
+
+// HandleHistoryEvent records a step in the progress of a process instance
+func (m *HistoryManager) HandleHistoryEvent(ctx context.Context, event *runtime.HistoryEvent) error {
+	level := m.service.GetHistoryLevel()
+	switch event.Type {
+	case runtime.HistoryEventProcessInstanceStart:
+		if level.Includes(HistoryLevelActivity) {
+			return m.processInstanceStarted(ctx, event)
+		}
+	case runtime.HistoryEventProcessInstanceEnd:
+		if level.Includes(HistoryLevelActivity) {
+			return m.processInstanceEnded(ctx, event)
+		}
+	case runtime.HistoryEventProcessInstanceUpdate:
+		if level.Includes(HistoryLevelActivity) {
+			return m.processInstanceUpdated(ctx, event)
+		}
+	case runtime.HistoryEventActivityStart:
+		if level.Includes(HistoryLevelActivity) {
+			return m.activityStarted(ctx, event)
+		}
+	case runtime.HistoryEventActivityEnd:
+		if level.Includes(HistoryLevelActivity) {
+			return m.activityEnded(ctx, event)
+		}
+	case runtime.HistoryEventVariableUpdate:
+		if !level.Includes(HistoryLevelAudit) {
+			return nil
+		}
+		if err := m.variableUpdated(ctx, event); err != nil {
+			return err
+		}
+		if level.Includes(HistoryLevelFull) {
+			return m.recordVariableUpdate(ctx, event)
+		}
+	}
+	return nil
+}
+
+// Notify records a lifecycle transition of a task
+func (m *HistoryManager) Notify(ctx context.Context, event *task.TaskEvent) error {
+	if !m.service.GetHistoryLevel().Includes(HistoryLevelActivity) {
+		return nil
+	}
+
+	switch event.EventName {
+	case task.TaskEventCreate:
+		return m.taskCreated(ctx, event.Task)
+	case task.TaskEventAssignment:
+		return m.taskAssigned(ctx, event.Task)
+	case task.TaskEventComplete:
+		return m.updateTask(ctx, event.Task, func(historic *HistoricTaskInstance) {
+			endTask(historic, event.Time, "")
+		})
+	case task.TaskEventDelete:
+		return m.updateTask(ctx, event.Task, func(historic *HistoricTaskInstance) {
+			endTask(historic, event.Time, "deleted")
+		})
+	}
+	return nil
+}
+
+// processInstanceStarted records a new historic process instance
+func (m *HistoryManager) processInstanceStarted(ctx context.Context, event *runtime.HistoryEvent) error {
+	processInstance := event.ProcessInstance
+	instance := &HistoricProcessInstance{
+		ID:                     processInstance.ID,
+		BusinessKey:            processInstance.BusinessKey,
+		Name:                   processInstance.Name,
+		Tags:                   processInstance.Tags,
+		ProcessDefinitionID:    processInstance.ProcessDefinitionID,
+		ProcessDefinitionKey:   processInstance.ProcessDefinitionKey,
+		ProcessDefinitionName:  processInstance.ProcessDefinitionName,
+		StartTime:              processInstance.StartTime,
+		StartUserID:            processInstance.StartUserID,
+		StartActivityID:        event.ActivityID,
+		SuperProcessInstanceID: processInstance.SuperProcessInstanceID,
+		TenantID:               processInstance.TenantID,
+	}
+
+	// The version and deployment are those of the definition the instance was started from
+	processDefinition, err := m.repositoryService.GetProcessDefinition(ctx, processInstance.ProcessDefinitionID)
+	if err == nil {
+		instance.ProcessDefinitionVersion = processDefinition.Version
+		instance.DeploymentID = processDefinition.DeploymentID
+	}
+	return m.service.RecordProcessInstance(ctx, instance)
+}
+
+// processInstanceEnded sets the end of a historic process instance
+func (m *HistoryManager) processInstanceEnded(ctx context.Context, event *runtime.HistoryEvent) error {
+	instances, err := m.service.CreateHistoricProcessInstanceQuery().ProcessInstanceID(event.ProcessInstance.ID).List(ctx)
+	if err != nil || len(instances) == 0 {
+		return err
+	}
+
+	instance := *instances[0]
+	instance.EndTime = &event.Time
+	instance.DurationInMillis = durationInMillis(instance.StartTime, event.Time)
+	instance.EndActivityID = event.ActivityID
+	instance.DeleteReason = event.DeleteReason
+
+	// History cleanup removes the instance once the time to live of its definition expired
+	processDefinition, err := m.repositoryService.GetProcessDefinition(ctx, instance.ProcessDefinitionID)
+	if err == nil && processDefinition.HistoryTimeToLive != nil {
+		removalTime := event.Time.AddDate(0, 0, *processDefinition.HistoryTimeToLive)
+		instance.RemovalTime = &removalTime
+	}
+	return m.service.RecordProcessInstance(ctx, &instance)
+}
+
+// processInstanceUpdated copies the name and tags of a renamed or retagged process
+// instance to its historic process instance
+func (m *HistoryManager) processInstanceUpdated(ctx context.Context, event *runtime.HistoryEvent) error {
+	instances, err := m.service.CreateHistoricProcessInstanceQuery().ProcessInstanceID(event.ProcessInstance.ID).List(ctx)
+	if err != nil || len(instances) == 0 {
+		return err
+	}
+
+	instance := *instances[0]
+	instance.Name = event.ProcessInstance.Name
+	instance.Tags = event.ProcessInstance.Tags
+	return m.service.RecordProcessInstance(ctx, &instance)
+}
+
+// activityStarted records a new historic activity instance. The activity of a user task
+// is linked to the task, which is created while the activity starts.
+func (m *HistoryManager) activityStarted(ctx context.Context, event *runtime.HistoryEvent) error {
+	activity := &HistoricActivityInstance{
+		ID:                  uuid.New().String(),
+		ActivityID:          event.ActivityID,
+		ActivityName:        event.ActivityName,
+		ActivityType:        event.ActivityType,
+		ProcessDefinitionID: event.ProcessInstance.ProcessDefinitionID,
+		ProcessInstanceID:   event.ProcessInstance.ID,
+		ExecutionID:         event.ExecutionID,
+		StartTime:           event.Time,
+		TenantID:            event.ProcessInstance.TenantID,
+	}
+
+	tasks, err := m.service.CreateHistoricTaskInstanceQuery().
+		ExecutionID(event.ExecutionID).
+		TaskDefinitionKey(event.ActivityID).
+		Unfinished().
+		List(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tasks) > 0 {
+		// The task was created before the process instance was recorded
+		historic := *tasks[0]
+		historic.ProcessDefinitionKey = event.ProcessInstance.ProcessDefinitionKey
+		if err := m.service.RecordTaskInstance(ctx, &historic); err != nil {
+			return err
+		}
+		activity.TaskID = historic.ID
+		activity.Assignee = historic.Assignee
+	}
+	return m.service.RecordActivityInstance(ctx, activity)
+}
+
+// activityEnded sets the end of the open historic activity instance of an execution
+func (m *HistoryManager) activityEnded(ctx context.Context, event *runtime.HistoryEvent) error {
+	activity, err := m.openActivity(ctx, event.ExecutionID, event.ActivityID)
+	if err != nil || activity == nil {
+		return err
+	}
+
+	activity.EndTime = &event.Time
+	activity.DurationInMillis = durationInMillis(activity.StartTime, event.Time)
+	activity.DeleteReason = event.DeleteReason
+	return m.service.RecordActivityInstance(ctx, activity)
+}
+
+// openActivity returns a copy of the unfinished historic activity instance of an
+// execution at an activity, or nil if there is none
+func (m *HistoryManager) openActivity(ctx context.Context, executionID, activityID string) (*HistoricActivityInstance, error) {
+	activities, err := m.service.CreateHistoricActivityInstanceQuery().
+		ExecutionID(executionID).
+		ActivityID(activityID).
+		Unfinished().
+		List(ctx)
+	if err != nil || len(activities) == 0 {
+		return nil, err
+	}
+	activity := *activities[len(activities)-1]
+	return &activity, nil
+}
+
+// variableUpdated records the latest value of a process variable
+func (m *HistoryManager) variableUpdated(ctx context.Context, event *runtime.HistoryEvent) error {
+	variables, err := m.service.CreateHistoricVariableInstanceQuery().
+		ProcessInstanceID(event.ProcessInstance.ID).
+		VariableName(event.VariableName).
+		ExcludeTaskVariables().
+		List(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(variables) > 0 {
+		updated := *variables[0]
+		updated.Value = event.VariableValue
+		updated.TypeName = ""
+		updated.LastUpdatedTime = &event.Time
+		return m.service.RecordVariableInstance(ctx, &updated)
+	}
+	return m.service.RecordVariableInstance(ctx, &HistoricVariableInstance{
+		ID:                   uuid.New().String(),
+		Name:                 event.VariableName,
+		Value:                event.VariableValue,
+		ProcessInstanceID:    event.ProcessInstance.ID,
+		ProcessDefinitionKey: event.ProcessInstance.ProcessDefinitionKey,
+		TenantID:             event.ProcessInstance.TenantID,
+		CreateTime:           event.Time,
+	})
+}
+
+// recordVariableUpdate records a variable update with the value it replaced as a historic detail
+func (m *HistoryManager) recordVariableUpdate(ctx context.Context, event *runtime.HistoryEvent) error {
+	revision, err := m.service.CreateHistoricDetailQuery().
+		ProcessInstanceID(event.ProcessInstance.ID).
+		VariableName(event.VariableName).
+		Count(ctx)
+	if err != nil {
+		return err
+	}
+
+	return m.service.RecordDetail(ctx, &HistoricDetail{
+		ID:                   uuid.New().String(),
+		Type:                 HistoricDetailTypeVariableUpdate,
+		ProcessInstanceID:    event.ProcessInstance.ID,
+		ProcessDefinitionKey: event.ProcessInstance.ProcessDefinitionKey,
+		ExecutionID:          event.ExecutionID,
+		ActivityID:           event.ActivityID,
+		VariableName:         event.VariableName,
+		Value:                event.VariableValue,
+		OldValue:             event.OldValue,
+		UserID:               identity.AuthenticatedUserID(ctx),
+		Revision:             int(revision) + 1,
+		Time:                 event.Time,
+		TenantID:             event.ProcessInstance.TenantID,
+	})
+}
+
+// taskCreated records a new historic task instance and links it to the open
+// activity instance of its execution
+func (m *HistoryManager) taskCreated(ctx context.Context, t *task.Task) error {
+	historic := &HistoricTaskInstance{
+		ID:                  t.ID,
+		ProcessDefinitionID: t.ProcessDefinitionID,
+		ProcessInstanceID:   t.ProcessInstanceID,
+		ExecutionID:         t.ExecutionID,
+		Name:                t.Name,
+		Description:         t.Description,
+		TaskDefinitionKey:   t.TaskDefinitionKey,
+		Owner:               t.Owner,
+		Assignee:            t.Assignee,
+		StartTime:           t.CreateTime,
+		Priority:            t.Priority,
+		DueDate:             t.DueDate,
+		FollowUpDate:        t.FollowUpDate,
+		FormKey:             t.FormKey,
+		Category:            t.Category,
+		CandidateUsers:      append([]string(nil), t.CandidateUsers...),
+		CandidateGroups:     append([]string(nil), t.CandidateGroups...),
+		TenantID:            t.TenantID,
+	}
+	if t.ProcessInstanceID != "" {
+		instances, err := m.service.CreateHistoricProcessInstanceQuery().ProcessInstanceID(t.ProcessInstanceID).List(ctx)
+		if err != nil {
+			return err
+		}
+		if len(instances) > 0 {
+			historic.ProcessDefinitionKey = instances[0].ProcessDefinitionKey
+		}
+	}
+	if err := m.service.RecordTaskInstance(ctx, historic); err != nil {
+		return err
+	}
+
+	if t.ExecutionID == "" {
+		return nil
+	}
+	activity, err := m.openActivity(ctx, t.ExecutionID, t.TaskDefinitionKey)
+	if err != nil || activity == nil {
+		return err
+	}
+	activity.TaskID = t.ID
+	activity.Assignee = t.Assignee
+	return m.service.RecordActivityInstance(ctx, activity)
+}
+
+// taskAssigned records the new assignee of a task on its historic task instance and
+// on the open activity instance of its user task
+func (m *HistoryManager) taskAssigned(ctx context.Context, t *task.Task) error {
+	err := m.updateTask(ctx, t, func(historic *HistoricTaskInstance) {
+		historic.Assignee = t.Assignee
+		historic.Owner = t.Owner
+	})
+	if err != nil || t.ExecutionID == "" {
+		return err
+	}
+
+	activity, err := m.openActivity(ctx, t.ExecutionID, t.TaskDefinitionKey)
+	if err != nil || activity == nil || activity.TaskID != t.ID {
+		return err
+	}
+	activity.Assignee = t.Assignee
+	return m.service.RecordActivityInstance(ctx, activity)
+}
+
+// updateTask changes the historic task instance of a task, if it was recorded. The
+// candidates are taken over as they may have changed since the last task event.
+func (m *HistoryManager) updateTask(ctx context.Context, t *task.Task, update func(historic *HistoricTaskInstance)) error {
+	tasks, err := m.service.CreateHistoricTaskInstanceQuery().TaskID(t.ID).List(ctx)
+	if err != nil || len(tasks) == 0 {
+		return err
+	}
+
+	historic := *tasks[0]
+	historic.CandidateUsers = append([]string(nil), t.CandidateUsers...)
+	historic.CandidateGroups = append([]string(nil), t.CandidateGroups...)
+	update(&historic)
+	return m.service.RecordTaskInstance(ctx, &historic)
+}
+
+// endTask sets the end of a historic task instance
+func endTask(historic *HistoricTaskInstance, endTime time.Time, deleteReason string) {
+	historic.EndTime = &endTime
+	historic.DurationInMillis = durationInMillis(historic.StartTime, endTime)
+	historic.DeleteReason = deleteReason
+}
+
+// durationInMillis returns the milliseconds between two times
+func durationInMillis(start, end time.Time) *int64 {
+	duration := end.Sub(start).Milliseconds()
+	return &duration
+}
@@ -0,0 +1,119 @@
+package history
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// historicVariableInstanceState is the persisted form of a historic variable instance,
+// whose value keeps its type
+type historicVariableInstanceState struct {
+	*HistoricVariableInstance
+	Value variable.TypedValue
+}
+
+// newHistoricVariableInstanceState returns the persisted form of a historic variable instance
+func newHistoricVariableInstanceState(instance *HistoricVariableInstance) *historicVariableInstanceState {
+	// The value was checked when the variable instance was recorded
+	value, _ := variable.Of(instance.Value)
+	return &historicVariableInstanceState{
+		HistoricVariableInstance: instance,
+		Value:                    value,
+	}
+}
+
+// historicDetailState is the persisted form of a historic detail, whose values keep their type
+type historicDetailState struct {
+	*HistoricDetail
+	Value    variable.TypedValue
+	OldValue variable.TypedValue
+}
+
+// setVariableType converts the value of a historic variable instance to the Go type of
+// its variable type and, unless given, sets its type name
+func setVariableType(instance *HistoricVariableInstance) error {
+	value, err := variable.Of(instance.Value)
+	if err != nil {
+		return fmt.Errorf("historic variable instance %s: %w", instance.ID, err)
+	}
+	instance.Value = value.Value
+	if instance.TypeName == "" {
+		instance.TypeName = value.Type
+	}
+	return nil
+}
+
+// SetStore sets the store history data is persisted to
+func (s *historyServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// saveLocked writes a history entity to the store, if any.
+// Callers must hold the write lock.
+func (s *historyServiceImpl) saveLocked(ctx context.Context, table, id string, value interface{}) error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.Save(ctx, table, id, value)
+}
+
+// deleteFromStore deletes history entities by table from the store, if any
+func (s *historyServiceImpl) deleteFromStore(ctx context.Context, deleted map[string][]string) error {
+	if s.store == nil {
+		return nil
+	}
+	for table, ids := range deleted {
+		if err := s.store.Delete(ctx, table, ids...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load reads all history data from the store
+func (s *historyServiceImpl) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := persistence.Load(ctx, s.store, persistence.TableHistoricProcessInstances, func(instance *HistoricProcessInstance) {
+		s.processInstances[instance.ID] = instance
+	})
+	if err != nil {
+		return err
+	}
+
+	err = persistence.Load(ctx, s.store, persistence.TableHistoricTaskInstances, func(task *HistoricTaskInstance) {
+		s.tasks[task.ID] = task
+	})
+	if err != nil {
+		return err
+	}
+
+	err = persistence.Load(ctx, s.store, persistence.TableHistoricActivityInstances, func(activity *HistoricActivityInstance) {
+		s.activities[activity.ID] = activity
+	})
+	if err != nil {
+		return err
+	}
+
+	err = persistence.Load(ctx, s.store, persistence.TableHistoricVariableInstances, func(state *historicVariableInstanceState) {
+		instance := state.HistoricVariableInstance
+		instance.Value = state.Value.Value
+		s.variables[instance.ID] = instance
+	})
+	if err != nil {
+		return err
+	}
+
+	return persistence.Load(ctx, s.store, persistence.TableHistoricDetails, func(state *historicDetailState) {
+		detail := state.HistoricDetail
+		detail.Value, detail.OldValue = state.Value.Value, state.OldValue.Value
+		s.details[detail.ID] = detail
+	})
+}
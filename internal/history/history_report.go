@@ -0,0 +1,285 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/identity"
+)
+
+// DurationReportResult holds the duration statistics of the finished instances of a
+// process definition
+type DurationReportResult struct {
+	ProcessDefinitionKey string
+	Count                int64
+	AverageMillis        int64
+	MedianMillis         int64
+	MinimumMillis        int64
+	MaximumMillis        int64
+}
+
+// DailyCountReportResult holds the number of process instances started on a day
+type DailyCountReportResult struct {
+	Day   time.Time // midnight starting the day in the location of the report
+	Count int64
+}
+
+// AssigneeCountReportResult holds the number of tasks an assignee completed
+type AssigneeCountReportResult struct {
+	Assignee string // empty for tasks completed without an assignee
+	Count    int64
+}
+
+// HistoricProcessInstanceReport provides a fluent API for aggregating historic process
+// instances, e.g. for dashboards
+type HistoricProcessInstanceReport struct {
+	processDefinitionKeys []string
+	tenantID              string
+	startedBefore         *time.Time
+	startedAfter          *time.Time
+	location              *time.Location
+	service               HistoryService
+}
+
+// ProcessDefinitionKeyIn restricts the report to instances of any of the process definitions
+func (r *HistoricProcessInstanceReport) ProcessDefinitionKeyIn(keys ...string) *HistoricProcessInstanceReport {
+	r.processDefinitionKeys = keys
+	return r
+}
+
+// TenantID restricts the report to instances of a tenant
+func (r *HistoricProcessInstanceReport) TenantID(tenantID string) *HistoricProcessInstanceReport {
+	r.tenantID = tenantID
+	return r
+}
+
+// StartedBefore restricts the report to instances started before a date
+func (r *HistoricProcessInstanceReport) StartedBefore(date time.Time) *HistoricProcessInstanceReport {
+	r.startedBefore = &date
+	return r
+}
+
+// StartedAfter restricts the report to instances started after a date
+func (r *HistoricProcessInstanceReport) StartedAfter(date time.Time) *HistoricProcessInstanceReport {
+	r.startedAfter = &date
+	return r
+}
+
+// In sets the location days are counted in. Defaults to the local time zone.
+func (r *HistoricProcessInstanceReport) In(location *time.Location) *HistoricProcessInstanceReport {
+	r.location = location
+	return r
+}
+
+// DurationByProcessDefinition returns the duration statistics of the finished instances
+// per process definition, ordered by process definition key
+func (r *HistoricProcessInstanceReport) DurationByProcessDefinition(ctx context.Context) ([]*DurationReportResult, error) {
+	if impl, ok := r.service.(*historyServiceImpl); ok {
+		return impl.durationReport(ctx, r)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// StartedPerDay returns the number of instances started per day, ordered by day. Days
+// without started instances are left out.
+func (r *HistoricProcessInstanceReport) StartedPerDay(ctx context.Context) ([]*DailyCountReportResult, error) {
+	if impl, ok := r.service.(*historyServiceImpl); ok {
+		return impl.startedPerDayReport(ctx, r)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// HistoricTaskInstanceReport provides a fluent API for aggregating historic task
+// instances, e.g. for dashboards
+type HistoricTaskInstanceReport struct {
+	processDefinitionKey string
+	taskDefinitionKey    string
+	tenantID             string
+	completedBefore      *time.Time
+	completedAfter       *time.Time
+	service              HistoryService
+}
+
+// ProcessDefinitionKey restricts the report to tasks of a process definition
+func (r *HistoricTaskInstanceReport) ProcessDefinitionKey(key string) *HistoricTaskInstanceReport {
+	r.processDefinitionKey = key
+	return r
+}
+
+// TaskDefinitionKey restricts the report to tasks of a user task in the process model
+func (r *HistoricTaskInstanceReport) TaskDefinitionKey(key string) *HistoricTaskInstanceReport {
+	r.taskDefinitionKey = key
+	return r
+}
+
+// TenantID restricts the report to tasks of a tenant
+func (r *HistoricTaskInstanceReport) TenantID(tenantID string) *HistoricTaskInstanceReport {
+	r.tenantID = tenantID
+	return r
+}
+
+// CompletedBefore restricts the report to tasks completed before a date
+func (r *HistoricTaskInstanceReport) CompletedBefore(date time.Time) *HistoricTaskInstanceReport {
+	r.completedBefore = &date
+	return r
+}
+
+// CompletedAfter restricts the report to tasks completed after a date
+func (r *HistoricTaskInstanceReport) CompletedAfter(date time.Time) *HistoricTaskInstanceReport {
+	r.completedAfter = &date
+	return r
+}
+
+// CountByAssignee returns the number of completed tasks per assignee, most completions first.
+// Deleted tasks do not count.
+func (r *HistoricTaskInstanceReport) CountByAssignee(ctx context.Context) ([]*AssigneeCountReportResult, error) {
+	if impl, ok := r.service.(*historyServiceImpl); ok {
+		return impl.assigneeCountReport(ctx, r)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// CreateHistoricProcessInstanceReport creates a new historic process instance report
+func (s *historyServiceImpl) CreateHistoricProcessInstanceReport() *HistoricProcessInstanceReport {
+	return &HistoricProcessInstanceReport{service: s}
+}
+
+// CreateHistoricTaskInstanceReport creates a new historic task instance report
+func (s *historyServiceImpl) CreateHistoricTaskInstanceReport() *HistoricTaskInstanceReport {
+	return &HistoricTaskInstanceReport{service: s}
+}
+
+// durationReport aggregates the durations of finished historic process instances
+func (s *historyServiceImpl) durationReport(ctx context.Context, r *HistoricProcessInstanceReport) ([]*DurationReportResult, error) {
+	s.mu.RLock()
+	durations := make(map[string][]int64)
+	for _, instance := range s.processInstances {
+		if identity.IsTenantAccessible(ctx, instance.TenantID) && matchesProcessInstanceReport(instance, r) && instance.DurationInMillis != nil {
+			durations[instance.ProcessDefinitionKey] = append(durations[instance.ProcessDefinitionKey], *instance.DurationInMillis)
+		}
+	}
+	s.mu.RUnlock()
+
+	result := make([]*DurationReportResult, 0, len(durations))
+	for key, values := range durations {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		var total int64
+		for _, value := range values {
+			total += value
+		}
+		count := int64(len(values))
+		median := values[count/2]
+		if count%2 == 0 {
+			median = (values[count/2-1] + median) / 2
+		}
+		result = append(result, &DurationReportResult{
+			ProcessDefinitionKey: key,
+			Count:                count,
+			AverageMillis:        total / count,
+			MedianMillis:         median,
+			MinimumMillis:        values[0],
+			MaximumMillis:        values[count-1],
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProcessDefinitionKey < result[j].ProcessDefinitionKey
+	})
+	return result, nil
+}
+
+// startedPerDayReport counts the historic process instances started per day
+func (s *historyServiceImpl) startedPerDayReport(ctx context.Context, r *HistoricProcessInstanceReport) ([]*DailyCountReportResult, error) {
+	location := r.location
+	if location == nil {
+		location = time.Local
+	}
+
+	s.mu.RLock()
+	counts := make(map[time.Time]int64)
+	for _, instance := range s.processInstances {
+		if identity.IsTenantAccessible(ctx, instance.TenantID) && matchesProcessInstanceReport(instance, r) {
+			start := instance.StartTime.In(location)
+			counts[time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location)]++
+		}
+	}
+	s.mu.RUnlock()
+
+	result := make([]*DailyCountReportResult, 0, len(counts))
+	for day, count := range counts {
+		result = append(result, &DailyCountReportResult{Day: day, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Day.Before(result[j].Day)
+	})
+	return result, nil
+}
+
+// assigneeCountReport counts the completed historic task instances per assignee
+func (s *historyServiceImpl) assigneeCountReport(ctx context.Context, r *HistoricTaskInstanceReport) ([]*AssigneeCountReportResult, error) {
+	s.mu.RLock()
+	counts := make(map[string]int64)
+	for _, task := range s.tasks {
+		if identity.IsTenantAccessible(ctx, task.TenantID) && matchesTaskInstanceReport(task, r) {
+			counts[task.Assignee]++
+		}
+	}
+	s.mu.RUnlock()
+
+	result := make([]*AssigneeCountReportResult, 0, len(counts))
+	for assignee, count := range counts {
+		result = append(result, &AssigneeCountReportResult{Assignee: assignee, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return strings.Compare(a.Assignee, b.Assignee) < 0
+	})
+	return result, nil
+}
+
+// matchesProcessInstanceReport checks a historic process instance against the report filters
+func matchesProcessInstanceReport(instance *HistoricProcessInstance, r *HistoricProcessInstanceReport) bool {
+	if r.processDefinitionKeys != nil && !slices.Contains(r.processDefinitionKeys, instance.ProcessDefinitionKey) {
+		return false
+	}
+	if r.tenantID != "" && instance.TenantID != r.tenantID {
+		return false
+	}
+	if r.startedBefore != nil && !instance.StartTime.Before(*r.startedBefore) {
+		return false
+	}
+	if r.startedAfter != nil && !instance.StartTime.After(*r.startedAfter) {
+		return false
+	}
+	return true
+}
+
+// matchesTaskInstanceReport checks a historic task instance against the report filters.
+// Only completed tasks match.
+func matchesTaskInstanceReport(task *HistoricTaskInstance, r *HistoricTaskInstanceReport) bool {
+	if task.EndTime == nil || task.DeleteReason != "" {
+		return false
+	}
+	if r.processDefinitionKey != "" && task.ProcessDefinitionKey != r.processDefinitionKey {
+		return false
+	}
+	if r.taskDefinitionKey != "" && task.TaskDefinitionKey != r.taskDefinitionKey {
+		return false
+	}
+	if r.tenantID != "" && task.TenantID != r.tenantID {
+		return false
+	}
+	if r.completedBefore != nil && !task.EndTime.Before(*r.completedBefore) {
+		return false
+	}
+	if r.completedAfter != nil && !task.EndTime.After(*r.completedAfter) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,1065 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"time"
+
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/paging"
+)
+
+// HistoryService provides operations for querying historical process data.
+// This service is responsible for:
+// - Querying historical process instances
+// - Querying historical tasks
+// - Querying historical variables
+// - Querying historical activities
+// - Deleting historical data
+type HistoryService interface {
+	// Initialize initializes the history service
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the history service
+	Shutdown(ctx context.Context) error
+
+	// CreateHistoricProcessInstanceQuery creates a new historic process instance query
+	CreateHistoricProcessInstanceQuery() *HistoricProcessInstanceQuery
+
+	// CreateHistoricTaskInstanceQuery creates a new historic task instance query
+	CreateHistoricTaskInstanceQuery() *HistoricTaskInstanceQuery
+
+	// CreateHistoricActivityInstanceQuery creates a new historic activity instance query
+	CreateHistoricActivityInstanceQuery() *HistoricActivityInstanceQuery
+
+	// CreateHistoricVariableInstanceQuery creates a new historic variable instance query
+	CreateHistoricVariableInstanceQuery() *HistoricVariableInstanceQuery
+
+	// CreateHistoricDetailQuery creates a new historic detail query
+	CreateHistoricDetailQuery() *HistoricDetailQuery
+
+	// CreateHistoricProcessInstanceReport creates a new report aggregating historic process
+	// instances, e.g. durations per process definition
+	CreateHistoricProcessInstanceReport() *HistoricProcessInstanceReport
+
+	// CreateHistoricTaskInstanceReport creates a new report aggregating historic task
+	// instances, e.g. completions per assignee
+	CreateHistoricTaskInstanceReport() *HistoricTaskInstanceReport
+
+	// DeleteHistoricProcessInstance deletes a historic process instance
+	DeleteHistoricProcessInstance(ctx context.Context, processInstanceID string) error
+
+	// DeleteHistoricProcessInstancesByQuery deletes all historic process instances matching the query,
+	// together with their tasks, activities and variables. The deletion runs asynchronously as a batch
+	// in jobs; the returned batch reports its progress through GetBatch.
+	DeleteHistoricProcessInstancesByQuery(ctx context.Context, query *HistoricProcessInstanceQuery, deleteReason string) (*Batch, error)
+
+	// DeleteHistoricProcessInstancesBefore deletes all historic process instances that finished
+	// before a date, together with their tasks, activities and variables, as a batch like
+	// DeleteHistoricProcessInstancesByQuery
+	DeleteHistoricProcessInstancesBefore(ctx context.Context, date time.Time) (*Batch, error)
+
+	// CleanupHistory deletes the historic process instances whose removal time passed, i.e.
+	// whose process definition's history time to live expired, as a batch
+	CleanupHistory(ctx context.Context) (*Batch, error)
+
+	// SetCleanupInterval makes the service run CleanupHistory periodically while it is
+	// running. It must be called before Initialize; zero disables the cleanup.
+	SetCleanupInterval(interval time.Duration)
+
+	// SetArchiver sets the archiver historic process instances are exported to before
+	// batches delete them. It must be called before Initialize.
+	SetArchiver(archiver HistoryArchiver)
+
+	// GetBatch retrieves a snapshot of the state of a batch by ID
+	GetBatch(ctx context.Context, batchID string) (*Batch, error)
+
+	// SetBatchService sets the batch service deletion batches and history cleanup run
+	// in, registering their job handlers. It must be called before Initialize; without
+	// one, the service runs its batches in a batch service of its own.
+	SetBatchService(batches batch.BatchService)
+
+	// DeleteHistoricTaskInstance deletes a historic task instance
+	DeleteHistoricTaskInstance(ctx context.Context, taskID string) error
+
+	// SetIndexer enables mirroring of history data into a full-text search index.
+	// It must be called before Initialize.
+	SetIndexer(indexer HistoryIndexer)
+
+	// SetStore sets the store history data is persisted to.
+	// Without a store, or a database URL to open one from, the service keeps its data in memory.
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock history cleanup and batches are based on
+	SetClock(c clock.Clock)
+
+	// SetLogger sets the logger failures of cleanup, archiving and indexing are logged to
+	SetLogger(logger *slog.Logger)
+
+	// SearchHistoric runs a full-text search over historic process instances, tasks and
+	// variables. It requires an indexer to be set.
+	SearchHistoric(ctx context.Context, freeText string, filters *SearchFilters) (*SearchResult, error)
+
+	// GetHistoryLevel returns the level of history the service records
+	GetHistoryLevel() HistoryLevel
+
+	// SetHistoryLevel sets the level of history the service records. Records below
+	// the level, e.g. variables at HistoryLevelActivity, are ignored. Defaults to HistoryLevelFull.
+	SetHistoryLevel(level HistoryLevel)
+
+	// RecordProcessInstance records a process instance to history
+	RecordProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error
+
+	// RecordTaskInstance records a task instance to history
+	RecordTaskInstance(ctx context.Context, task *HistoricTaskInstance) error
+
+	// RecordActivityInstance records an activity instance to history
+	RecordActivityInstance(ctx context.Context, activity *HistoricActivityInstance) error
+
+	// RecordVariableInstance records a variable instance to history
+	RecordVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error
+
+	// RecordDetail records a detail, e.g. a variable update, to history. Details are only
+	// recorded at HistoryLevelFull.
+	RecordDetail(ctx context.Context, detail *HistoricDetail) error
+}
+
+// HistoricProcessInstance represents a completed or running process instance in history
+type HistoricProcessInstance struct {
+	ID                   string
+	BusinessKey          string
+	Name                 string
+	Tags                 []string
+	ProcessDefinitionID  string
+	ProcessDefinitionKey string
+	ProcessDefinitionName string
+	ProcessDefinitionVersion int
+	DeploymentID         string
+	StartTime            time.Time
+	EndTime              *time.Time
+	DurationInMillis     *int64
+	StartUserID          string
+	StartActivityID      string
+	EndActivityID        string
+	DeleteReason         string
+	SuperProcessInstanceID string
+	TenantID             string
+	RemovalTime          *time.Time // when history cleanup removes the finished instance, if ever
+}
+
+// HistoricTaskInstance represents a completed or running task in history
+type HistoricTaskInstance struct {
+	ID                  string
+	ProcessDefinitionID string
+	ProcessDefinitionKey string
+	ProcessInstanceID   string
+	ExecutionID         string
+	Name                string
+	Description         string
+	TaskDefinitionKey   string
+	Owner               string
+	Assignee            string
+	StartTime           time.Time
+	EndTime             *time.Time
+	DurationInMillis    *int64
+	DeleteReason        string
+	Priority            int
+	DueDate             *time.Time
+	FollowUpDate        *time.Time
+	FormKey             string
+	Category            string
+	CandidateUsers      []string
+	CandidateGroups     []string
+	TenantID            string
+}
+
+// HistoricActivityInstance represents a completed or running activity in history
+type HistoricActivityInstance struct {
+	ID                  string
+	ActivityID          string
+	ActivityName        string
+	ActivityType        string
+	ProcessDefinitionID string
+	ProcessInstanceID   string
+	ExecutionID         string
+	TaskID              string
+	Assignee            string
+	StartTime           time.Time
+	EndTime             *time.Time
+	DurationInMillis    *int64
+	DeleteReason        string
+	TenantID            string
+}
+
+// HistoricVariableInstance represents a variable value at a point in history
+type HistoricVariableInstance struct {
+	ID                  string
+	Name                string
+	TypeName            string
+	Value               interface{}
+	ProcessInstanceID   string
+	ProcessDefinitionKey string
+	TaskID              string
+	TenantID            string
+	CreateTime          time.Time
+	LastUpdatedTime     *time.Time
+}
+
+// Batch types
+const (
+	BatchTypeHistoricProcessInstanceDeletion = batch.TypeHistoricProcessInstanceDeletion
+	BatchTypeHistoryCleanup                  = batch.TypeHistoryCleanup
+)
+
+// Batch statuses
+const (
+	BatchStatusRunning   = batch.StatusRunning
+	BatchStatusSuspended = batch.StatusSuspended
+	BatchStatusCompleted = batch.StatusCompleted
+	BatchStatusCanceled  = batch.StatusCanceled
+)
+
+// Batch represents a long running asynchronous operation on history data, run by the
+// batch service
+type Batch = batch.Batch
+
+// HistoricProcessInstanceQuery provides a fluent API for querying historic process instances
+type HistoricProcessInstanceQuery struct {
+	processInstanceID        string
+	processInstanceBusinessKey string
+	tags                     []string
+	tagIn                    []string
+	processDefinitionID      string
+	processDefinitionKey     string
+	processDefinitionName    string
+	deploymentID             string
+	startUserID              string
+	superProcessInstanceID   string
+	tenantID                 string
+	finished                 *bool
+	unfinished               *bool
+	startedBefore            *time.Time
+	startedAfter             *time.Time
+	finishedBefore           *time.Time
+	finishedAfter            *time.Time
+	variableValueEquals      map[string]interface{}
+	orderBy                  string
+	ascending                bool
+	firstResult              int
+	maxResults               int
+	service                  HistoryService
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *HistoricProcessInstanceQuery) ProcessInstanceID(id string) *HistoricProcessInstanceQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessInstanceBusinessKey filters by business key
+func (q *HistoricProcessInstanceQuery) ProcessInstanceBusinessKey(businessKey string) *HistoricProcessInstanceQuery {
+	q.processInstanceBusinessKey = businessKey
+	return q
+}
+
+// Tag filters to process instances with a tag. Process instances must have the tags of
+// all calls.
+func (q *HistoricProcessInstanceQuery) Tag(tag string) *HistoricProcessInstanceQuery {
+	q.tags = append(q.tags, tag)
+	return q
+}
+
+// TagIn filters to process instances with at least one of the tags
+func (q *HistoricProcessInstanceQuery) TagIn(tags ...string) *HistoricProcessInstanceQuery {
+	q.tagIn = tags
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *HistoricProcessInstanceQuery) ProcessDefinitionID(id string) *HistoricProcessInstanceQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *HistoricProcessInstanceQuery) ProcessDefinitionKey(key string) *HistoricProcessInstanceQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// StartUserID filters by the user who started the process
+func (q *HistoricProcessInstanceQuery) StartUserID(userID string) *HistoricProcessInstanceQuery {
+	q.startUserID = userID
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *HistoricProcessInstanceQuery) TenantID(tenantID string) *HistoricProcessInstanceQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// VariableValueEquals filters to process instances with a historic variable of the given name and value
+func (q *HistoricProcessInstanceQuery) VariableValueEquals(name string, value interface{}) *HistoricProcessInstanceQuery {
+	if q.variableValueEquals == nil {
+		q.variableValueEquals = make(map[string]interface{})
+	}
+	q.variableValueEquals[name] = value
+	return q
+}
+
+// Finished filters to only finished process instances
+func (q *HistoricProcessInstanceQuery) Finished() *HistoricProcessInstanceQuery {
+	trueVal := true
+	q.finished = &trueVal
+	return q
+}
+
+// Unfinished filters to only unfinished process instances
+func (q *HistoricProcessInstanceQuery) Unfinished() *HistoricProcessInstanceQuery {
+	trueVal := true
+	q.unfinished = &trueVal
+	return q
+}
+
+// StartedBefore filters to process instances started before a specific date
+func (q *HistoricProcessInstanceQuery) StartedBefore(date time.Time) *HistoricProcessInstanceQuery {
+	q.startedBefore = &date
+	return q
+}
+
+// StartedAfter filters to process instances started after a specific date
+func (q *HistoricProcessInstanceQuery) StartedAfter(date time.Time) *HistoricProcessInstanceQuery {
+	q.startedAfter = &date
+	return q
+}
+
+// FinishedBefore filters to process instances finished before a specific date
+func (q *HistoricProcessInstanceQuery) FinishedBefore(date time.Time) *HistoricProcessInstanceQuery {
+	q.finishedBefore = &date
+	return q
+}
+
+// FinishedAfter filters to process instances finished after a specific date
+func (q *HistoricProcessInstanceQuery) FinishedAfter(date time.Time) *HistoricProcessInstanceQuery {
+	q.finishedAfter = &date
+	return q
+}
+
+// OrderByProcessInstanceID orders results by process instance ID
+func (q *HistoricProcessInstanceQuery) OrderByProcessInstanceID() *HistoricProcessInstanceQuery {
+	q.orderBy = "id"
+	return q
+}
+
+// OrderByStartTime orders results by start time
+func (q *HistoricProcessInstanceQuery) OrderByStartTime() *HistoricProcessInstanceQuery {
+	q.orderBy = "start_time"
+	return q
+}
+
+// OrderByEndTime orders results by end time
+func (q *HistoricProcessInstanceQuery) OrderByEndTime() *HistoricProcessInstanceQuery {
+	q.orderBy = "end_time"
+	return q
+}
+
+// OrderByDuration orders results by duration
+func (q *HistoricProcessInstanceQuery) OrderByDuration() *HistoricProcessInstanceQuery {
+	q.orderBy = "duration"
+	return q
+}
+
+// Asc sets ascending order
+func (q *HistoricProcessInstanceQuery) Asc() *HistoricProcessInstanceQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *HistoricProcessInstanceQuery) Desc() *HistoricProcessInstanceQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *HistoricProcessInstanceQuery) Limit(maxResults int) *HistoricProcessInstanceQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *HistoricProcessInstanceQuery) Offset(firstResult int) *HistoricProcessInstanceQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of historic process instances
+func (q *HistoricProcessInstanceQuery) List(ctx context.Context) ([]*HistoricProcessInstance, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.listProcessInstances(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching historic process instances, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *HistoricProcessInstanceQuery) Iterate(ctx context.Context) iter.Seq2[*HistoricProcessInstance, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*HistoricProcessInstance, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of historic process instances
+func (q *HistoricProcessInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*HistoricProcessInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching historic process instances
+func (q *HistoricProcessInstanceQuery) Count(ctx context.Context) (int64, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.countProcessInstances(ctx, q)
+	}
+	return 0, fmt.Errorf("unsupported service implementation")
+}
+
+// HistoricTaskInstanceQuery provides a fluent API for querying historic task instances
+type HistoricTaskInstanceQuery struct {
+	taskID                string
+	processInstanceID     string
+	processDefinitionID   string
+	processDefinitionKey  string
+	executionID           string
+	taskDefinitionKey     string
+	taskDefinitionKeys    []string
+	assignee              string
+	owner                 string
+	candidateUser         string
+	candidateGroup        string
+	candidateGroupIn      []string
+	taskName              string
+	tenantID              string
+	finished              *bool
+	unfinished            *bool
+	startedBefore         *time.Time
+	startedAfter          *time.Time
+	finishedBefore        *time.Time
+	finishedAfter         *time.Time
+	priorityMin           *int
+	priorityMax           *int
+	dueBefore             *time.Time
+	dueAfter              *time.Time
+	variableValueEquals   map[string]interface{}
+	orderBy               string
+	ascending             bool
+	firstResult           int
+	maxResults            int
+	service               HistoryService
+}
+
+// TaskID filters by task ID
+func (q *HistoricTaskInstanceQuery) TaskID(id string) *HistoricTaskInstanceQuery {
+	q.taskID = id
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *HistoricTaskInstanceQuery) ProcessInstanceID(id string) *HistoricTaskInstanceQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *HistoricTaskInstanceQuery) ProcessDefinitionID(id string) *HistoricTaskInstanceQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *HistoricTaskInstanceQuery) ProcessDefinitionKey(key string) *HistoricTaskInstanceQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// ExecutionID filters by execution ID
+func (q *HistoricTaskInstanceQuery) ExecutionID(id string) *HistoricTaskInstanceQuery {
+	q.executionID = id
+	return q
+}
+
+// TaskDefinitionKey filters by the ID of the user task in the process model
+func (q *HistoricTaskInstanceQuery) TaskDefinitionKey(key string) *HistoricTaskInstanceQuery {
+	q.taskDefinitionKey = key
+	return q
+}
+
+// TaskDefinitionKeyIn filters to tasks of any of the user tasks in the process model
+func (q *HistoricTaskInstanceQuery) TaskDefinitionKeyIn(keys ...string) *HistoricTaskInstanceQuery {
+	q.taskDefinitionKeys = keys
+	return q
+}
+
+// TaskName filters by task name
+func (q *HistoricTaskInstanceQuery) TaskName(name string) *HistoricTaskInstanceQuery {
+	q.taskName = name
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *HistoricTaskInstanceQuery) TenantID(tenantID string) *HistoricTaskInstanceQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// TaskAssignee filters by assignee
+func (q *HistoricTaskInstanceQuery) TaskAssignee(assignee string) *HistoricTaskInstanceQuery {
+	q.assignee = assignee
+	return q
+}
+
+// TaskOwner filters by owner
+func (q *HistoricTaskInstanceQuery) TaskOwner(owner string) *HistoricTaskInstanceQuery {
+	q.owner = owner
+	return q
+}
+
+// TaskCandidateUser filters to tasks the user was a candidate user of
+func (q *HistoricTaskInstanceQuery) TaskCandidateUser(userID string) *HistoricTaskInstanceQuery {
+	q.candidateUser = userID
+	return q
+}
+
+// TaskCandidateGroup filters to tasks the group was a candidate group of
+func (q *HistoricTaskInstanceQuery) TaskCandidateGroup(groupID string) *HistoricTaskInstanceQuery {
+	q.candidateGroup = groupID
+	return q
+}
+
+// TaskCandidateGroupIn filters to tasks any of the groups was a candidate group of
+func (q *HistoricTaskInstanceQuery) TaskCandidateGroupIn(groupIDs ...string) *HistoricTaskInstanceQuery {
+	q.candidateGroupIn = groupIDs
+	return q
+}
+
+// Finished filters to only finished tasks
+func (q *HistoricTaskInstanceQuery) Finished() *HistoricTaskInstanceQuery {
+	trueVal := true
+	q.finished = &trueVal
+	return q
+}
+
+// Unfinished filters to only unfinished tasks
+func (q *HistoricTaskInstanceQuery) Unfinished() *HistoricTaskInstanceQuery {
+	trueVal := true
+	q.unfinished = &trueVal
+	return q
+}
+
+// StartedBefore filters to tasks created before a specific date
+func (q *HistoricTaskInstanceQuery) StartedBefore(date time.Time) *HistoricTaskInstanceQuery {
+	q.startedBefore = &date
+	return q
+}
+
+// StartedAfter filters to tasks created after a specific date
+func (q *HistoricTaskInstanceQuery) StartedAfter(date time.Time) *HistoricTaskInstanceQuery {
+	q.startedAfter = &date
+	return q
+}
+
+// FinishedBefore filters to tasks that ended before a specific date
+func (q *HistoricTaskInstanceQuery) FinishedBefore(date time.Time) *HistoricTaskInstanceQuery {
+	q.finishedBefore = &date
+	return q
+}
+
+// FinishedAfter filters to tasks that ended after a specific date
+func (q *HistoricTaskInstanceQuery) FinishedAfter(date time.Time) *HistoricTaskInstanceQuery {
+	q.finishedAfter = &date
+	return q
+}
+
+// TaskPriority filters by priority
+func (q *HistoricTaskInstanceQuery) TaskPriority(priority int) *HistoricTaskInstanceQuery {
+	q.priorityMin = &priority
+	q.priorityMax = &priority
+	return q
+}
+
+// TaskPriorityMin filters by minimum priority
+func (q *HistoricTaskInstanceQuery) TaskPriorityMin(minPriority int) *HistoricTaskInstanceQuery {
+	q.priorityMin = &minPriority
+	return q
+}
+
+// TaskPriorityMax filters by maximum priority
+func (q *HistoricTaskInstanceQuery) TaskPriorityMax(maxPriority int) *HistoricTaskInstanceQuery {
+	q.priorityMax = &maxPriority
+	return q
+}
+
+// DueBefore filters tasks due before a specific date
+func (q *HistoricTaskInstanceQuery) DueBefore(date time.Time) *HistoricTaskInstanceQuery {
+	q.dueBefore = &date
+	return q
+}
+
+// DueAfter filters tasks due after a specific date
+func (q *HistoricTaskInstanceQuery) DueAfter(date time.Time) *HistoricTaskInstanceQuery {
+	q.dueAfter = &date
+	return q
+}
+
+// ProcessVariableValueEquals filters to tasks whose process instance has a historic variable of the given name and value
+func (q *HistoricTaskInstanceQuery) ProcessVariableValueEquals(name string, value interface{}) *HistoricTaskInstanceQuery {
+	if q.variableValueEquals == nil {
+		q.variableValueEquals = make(map[string]interface{})
+	}
+	q.variableValueEquals[name] = value
+	return q
+}
+
+// OrderByTaskID orders results by task ID
+func (q *HistoricTaskInstanceQuery) OrderByTaskID() *HistoricTaskInstanceQuery {
+	q.orderBy = "id"
+	return q
+}
+
+// OrderByTaskName orders results by task name
+func (q *HistoricTaskInstanceQuery) OrderByTaskName() *HistoricTaskInstanceQuery {
+	q.orderBy = "name"
+	return q
+}
+
+// OrderByTaskPriority orders results by priority
+func (q *HistoricTaskInstanceQuery) OrderByTaskPriority() *HistoricTaskInstanceQuery {
+	q.orderBy = "priority"
+	return q
+}
+
+// OrderByDueDate orders results by due date
+func (q *HistoricTaskInstanceQuery) OrderByDueDate() *HistoricTaskInstanceQuery {
+	q.orderBy = "due_date"
+	return q
+}
+
+// OrderByStartTime orders results by the time the task was created
+func (q *HistoricTaskInstanceQuery) OrderByStartTime() *HistoricTaskInstanceQuery {
+	q.orderBy = "start_time"
+	return q
+}
+
+// OrderByEndTime orders results by the time the task ended
+func (q *HistoricTaskInstanceQuery) OrderByEndTime() *HistoricTaskInstanceQuery {
+	q.orderBy = "end_time"
+	return q
+}
+
+// OrderByDuration orders results by duration
+func (q *HistoricTaskInstanceQuery) OrderByDuration() *HistoricTaskInstanceQuery {
+	q.orderBy = "duration"
+	return q
+}
+
+// Asc sets ascending order
+func (q *HistoricTaskInstanceQuery) Asc() *HistoricTaskInstanceQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *HistoricTaskInstanceQuery) Desc() *HistoricTaskInstanceQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *HistoricTaskInstanceQuery) Limit(maxResults int) *HistoricTaskInstanceQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *HistoricTaskInstanceQuery) Offset(firstResult int) *HistoricTaskInstanceQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of historic task instances
+func (q *HistoricTaskInstanceQuery) List(ctx context.Context) ([]*HistoricTaskInstance, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.listTaskInstances(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching historic task instances, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *HistoricTaskInstanceQuery) Iterate(ctx context.Context) iter.Seq2[*HistoricTaskInstance, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*HistoricTaskInstance, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of historic task instances
+func (q *HistoricTaskInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*HistoricTaskInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching historic task instances
+func (q *HistoricTaskInstanceQuery) Count(ctx context.Context) (int64, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.countTaskInstances(ctx, q)
+	}
+	return 0, fmt.Errorf("unsupported service implementation")
+}
+
+// HistoricActivityInstanceQuery provides a fluent API for querying historic activity instances
+type HistoricActivityInstanceQuery struct {
+	activityID          string
+	activityType        string
+	processInstanceID   string
+	processDefinitionID string
+	executionID         string
+	taskID              string
+	assignee            string
+	finished            *bool
+	startedBefore       *time.Time
+	startedAfter        *time.Time
+	finishedBefore      *time.Time
+	finishedAfter       *time.Time
+	orderBy             string
+	ascending           bool
+	firstResult         int
+	maxResults          int
+	service             HistoryService
+}
+
+// ActivityID filters by activity ID
+func (q *HistoricActivityInstanceQuery) ActivityID(id string) *HistoricActivityInstanceQuery {
+	q.activityID = id
+	return q
+}
+
+// ActivityType filters by activity type
+func (q *HistoricActivityInstanceQuery) ActivityType(activityType string) *HistoricActivityInstanceQuery {
+	q.activityType = activityType
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *HistoricActivityInstanceQuery) ProcessInstanceID(id string) *HistoricActivityInstanceQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *HistoricActivityInstanceQuery) ProcessDefinitionID(id string) *HistoricActivityInstanceQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ExecutionID filters by execution ID
+func (q *HistoricActivityInstanceQuery) ExecutionID(id string) *HistoricActivityInstanceQuery {
+	q.executionID = id
+	return q
+}
+
+// TaskID filters by the task created by the activity
+func (q *HistoricActivityInstanceQuery) TaskID(id string) *HistoricActivityInstanceQuery {
+	q.taskID = id
+	return q
+}
+
+// TaskAssignee filters by the assignee of the activity's task
+func (q *HistoricActivityInstanceQuery) TaskAssignee(assignee string) *HistoricActivityInstanceQuery {
+	q.assignee = assignee
+	return q
+}
+
+// Finished filters to only finished activities
+func (q *HistoricActivityInstanceQuery) Finished() *HistoricActivityInstanceQuery {
+	trueVal := true
+	q.finished = &trueVal
+	return q
+}
+
+// Unfinished filters to only unfinished activities
+func (q *HistoricActivityInstanceQuery) Unfinished() *HistoricActivityInstanceQuery {
+	falseVal := false
+	q.finished = &falseVal
+	return q
+}
+
+// StartedBefore filters activities started before the given date
+func (q *HistoricActivityInstanceQuery) StartedBefore(date time.Time) *HistoricActivityInstanceQuery {
+	q.startedBefore = &date
+	return q
+}
+
+// StartedAfter filters activities started after the given date
+func (q *HistoricActivityInstanceQuery) StartedAfter(date time.Time) *HistoricActivityInstanceQuery {
+	q.startedAfter = &date
+	return q
+}
+
+// FinishedBefore filters activities finished before the given date
+func (q *HistoricActivityInstanceQuery) FinishedBefore(date time.Time) *HistoricActivityInstanceQuery {
+	q.finishedBefore = &date
+	return q
+}
+
+// FinishedAfter filters activities finished after the given date
+func (q *HistoricActivityInstanceQuery) FinishedAfter(date time.Time) *HistoricActivityInstanceQuery {
+	q.finishedAfter = &date
+	return q
+}
+
+// OrderByActivityID orders results by activity ID
+func (q *HistoricActivityInstanceQuery) OrderByActivityID() *HistoricActivityInstanceQuery {
+	q.orderBy = "activity_id"
+	return q
+}
+
+// OrderByActivityType orders results by activity type
+func (q *HistoricActivityInstanceQuery) OrderByActivityType() *HistoricActivityInstanceQuery {
+	q.orderBy = "activity_type"
+	return q
+}
+
+// OrderByStartTime orders results by start time
+func (q *HistoricActivityInstanceQuery) OrderByStartTime() *HistoricActivityInstanceQuery {
+	q.orderBy = "start_time"
+	return q
+}
+
+// OrderByEndTime orders results by end time
+func (q *HistoricActivityInstanceQuery) OrderByEndTime() *HistoricActivityInstanceQuery {
+	q.orderBy = "end_time"
+	return q
+}
+
+// OrderByDuration orders results by duration
+func (q *HistoricActivityInstanceQuery) OrderByDuration() *HistoricActivityInstanceQuery {
+	q.orderBy = "duration"
+	return q
+}
+
+// Asc sets ascending order
+func (q *HistoricActivityInstanceQuery) Asc() *HistoricActivityInstanceQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *HistoricActivityInstanceQuery) Desc() *HistoricActivityInstanceQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *HistoricActivityInstanceQuery) Limit(maxResults int) *HistoricActivityInstanceQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *HistoricActivityInstanceQuery) Offset(firstResult int) *HistoricActivityInstanceQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of historic activity instances
+func (q *HistoricActivityInstanceQuery) List(ctx context.Context) ([]*HistoricActivityInstance, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.listActivityInstances(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching historic activity instances, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *HistoricActivityInstanceQuery) Iterate(ctx context.Context) iter.Seq2[*HistoricActivityInstance, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*HistoricActivityInstance, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of historic activity instances
+func (q *HistoricActivityInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*HistoricActivityInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching historic activity instances
+func (q *HistoricActivityInstanceQuery) Count(ctx context.Context) (int64, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.countActivityInstances(ctx, q)
+	}
+	return 0, fmt.Errorf("unsupported service implementation")
+}
+
+// HistoricVariableInstanceQuery provides a fluent API for querying historic variable instances
+type HistoricVariableInstanceQuery struct {
+	variableName        string
+	variableNameLike    string
+	variableValue       interface{}
+	hasVariableValue    bool
+	typeName            string
+	processInstanceID   string
+	taskID              string
+	excludeTaskVariables bool
+	createdBefore       *time.Time
+	createdAfter        *time.Time
+	orderBy             string
+	ascending           bool
+	firstResult         int
+	maxResults          int
+	service             HistoryService
+}
+
+// VariableName filters by variable name
+func (q *HistoricVariableInstanceQuery) VariableName(name string) *HistoricVariableInstanceQuery {
+	q.variableName = name
+	return q
+}
+
+// VariableNameLike filters by variable name using a SQL LIKE pattern ('%' and '_' wildcards)
+func (q *HistoricVariableInstanceQuery) VariableNameLike(pattern string) *HistoricVariableInstanceQuery {
+	q.variableNameLike = pattern
+	return q
+}
+
+// VariableValueEquals filters by variable name and value
+func (q *HistoricVariableInstanceQuery) VariableValueEquals(name string, value interface{}) *HistoricVariableInstanceQuery {
+	q.variableName = name
+	q.variableValue = value
+	q.hasVariableValue = true
+	return q
+}
+
+// TypeName filters by variable type name
+func (q *HistoricVariableInstanceQuery) TypeName(typeName string) *HistoricVariableInstanceQuery {
+	q.typeName = typeName
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *HistoricVariableInstanceQuery) ProcessInstanceID(id string) *HistoricVariableInstanceQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// TaskID filters by task ID
+func (q *HistoricVariableInstanceQuery) TaskID(id string) *HistoricVariableInstanceQuery {
+	q.taskID = id
+	return q
+}
+
+// ExcludeTaskVariables filters to only process instance level variables
+func (q *HistoricVariableInstanceQuery) ExcludeTaskVariables() *HistoricVariableInstanceQuery {
+	q.excludeTaskVariables = true
+	return q
+}
+
+// CreatedBefore filters variables created before the given date
+func (q *HistoricVariableInstanceQuery) CreatedBefore(date time.Time) *HistoricVariableInstanceQuery {
+	q.createdBefore = &date
+	return q
+}
+
+// CreatedAfter filters variables created after the given date
+func (q *HistoricVariableInstanceQuery) CreatedAfter(date time.Time) *HistoricVariableInstanceQuery {
+	q.createdAfter = &date
+	return q
+}
+
+// OrderByVariableName orders results by variable name
+func (q *HistoricVariableInstanceQuery) OrderByVariableName() *HistoricVariableInstanceQuery {
+	q.orderBy = "name"
+	return q
+}
+
+// OrderByProcessInstanceID orders results by process instance ID
+func (q *HistoricVariableInstanceQuery) OrderByProcessInstanceID() *HistoricVariableInstanceQuery {
+	q.orderBy = "process_instance_id"
+	return q
+}
+
+// OrderByCreateTime orders results by create time
+func (q *HistoricVariableInstanceQuery) OrderByCreateTime() *HistoricVariableInstanceQuery {
+	q.orderBy = "create_time"
+	return q
+}
+
+// Asc sets ascending order
+func (q *HistoricVariableInstanceQuery) Asc() *HistoricVariableInstanceQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *HistoricVariableInstanceQuery) Desc() *HistoricVariableInstanceQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *HistoricVariableInstanceQuery) Limit(maxResults int) *HistoricVariableInstanceQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *HistoricVariableInstanceQuery) Offset(firstResult int) *HistoricVariableInstanceQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of historic variable instances
+func (q *HistoricVariableInstanceQuery) List(ctx context.Context) ([]*HistoricVariableInstance, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.listVariableInstances(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching historic variable instances, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *HistoricVariableInstanceQuery) Iterate(ctx context.Context) iter.Seq2[*HistoricVariableInstance, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*HistoricVariableInstance, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of historic variable instances
+func (q *HistoricVariableInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*HistoricVariableInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching historic variable instances
+func (q *HistoricVariableInstanceQuery) Count(ctx context.Context) (int64, error) {
+	if impl, ok := q.service.(*historyServiceImpl); ok {
+		return impl.countVariableInstances(ctx, q)
+	}
+	return 0, fmt.Errorf("unsupported service implementation")
+}
@@ -0,0 +1,948 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/like"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// historyServiceImpl is the default implementation of HistoryService
+type historyServiceImpl struct {
+	databaseDriver      string
+	databaseURL         string
+	processInstances    map[string]*HistoricProcessInstance
+	tasks               map[string]*HistoricTaskInstance
+	activities          map[string]*HistoricActivityInstance
+	variables           map[string]*HistoricVariableInstance
+	details             map[string]*HistoricDetail
+	indexer             HistoryIndexer
+	archiver            HistoryArchiver
+	cleanupInterval     time.Duration
+	store               *persistence.Store
+	ownsStore           bool
+	clock               clock.Clock
+	logger              *slog.Logger
+	batches             batch.BatchService
+	ownsBatches         bool
+	stopCleanup         context.CancelFunc
+	cleanupContext      context.Context
+	level               HistoryLevel
+	mu                  sync.RWMutex
+}
+
+// NewHistoryService creates a new history service
+func NewHistoryService(databaseDriver, databaseURL string) HistoryService {
+	cleanupContext, stopCleanup := context.WithCancel(context.Background())
+	s := &historyServiceImpl{
+		databaseDriver:   databaseDriver,
+		databaseURL:      databaseURL,
+		processInstances: make(map[string]*HistoricProcessInstance),
+		tasks:            make(map[string]*HistoricTaskInstance),
+		activities:       make(map[string]*HistoricActivityInstance),
+		variables:        make(map[string]*HistoricVariableInstance),
+		details:          make(map[string]*HistoricDetail),
+		clock:            clock.System(),
+		logger:           slog.Default(),
+		cleanupContext:   cleanupContext,
+		stopCleanup:      stopCleanup,
+		level:            HistoryLevelFull,
+	}
+	s.SetBatchService(batch.NewBatchService())
+	s.ownsBatches = true
+	return s
+}
+
+// SetBatchService sets the batch service deletion batches run in and registers the
+// job handlers of history deletion and cleanup
+func (s *historyServiceImpl) SetBatchService(batches batch.BatchService) {
+	handler := batch.JobHandlerFunc(s.executeDeletionJob)
+	batches.RegisterJobHandler(BatchTypeHistoricProcessInstanceDeletion, handler)
+	batches.RegisterJobHandler(BatchTypeHistoryCleanup, handler)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = batches
+	s.ownsBatches = false
+}
+
+// SetClock sets the clock history cleanup and batches are based on
+func (s *historyServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+	if s.ownsBatches {
+		s.batches.SetClock(c)
+	}
+}
+
+// SetLogger sets the logger failures of cleanup, archiving and indexing are logged to
+func (s *historyServiceImpl) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+	if s.ownsBatches {
+		s.batches.SetLogger(logger)
+	}
+}
+
+// GetHistoryLevel returns the level of history the service records
+func (s *historyServiceImpl) GetHistoryLevel() HistoryLevel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.level
+}
+
+// SetHistoryLevel sets the level of history the service records
+func (s *historyServiceImpl) SetHistoryLevel(level HistoryLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.level = level
+}
+
+// Initialize initializes the history service
+func (s *historyServiceImpl) Initialize(ctx context.Context) error {
+	// Open an own store when configured with a database URL and no store was set
+	if s.store == nil && s.databaseURL != "" {
+		store, err := persistence.Open(s.databaseDriver, s.databaseURL)
+		if err != nil {
+			return err
+		}
+		s.store = store
+		s.ownsStore = true
+	}
+	if s.store != nil {
+		if err := s.store.Initialize(ctx); err != nil {
+			return err
+		}
+		if err := s.load(ctx); err != nil {
+			return err
+		}
+	}
+
+	if indexer := s.getIndexer(); indexer != nil {
+		if err := indexer.Initialize(ctx); err != nil {
+			return fmt.Errorf("failed to initialize history indexer: %w", err)
+		}
+	}
+
+	if s.ownsBatches {
+		if err := s.batches.Initialize(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.cleanupInterval > 0 {
+		go s.runCleanup(s.cleanupInterval)
+	}
+	return nil
+}
+
+// Shutdown gracefully shuts down the history service
+func (s *historyServiceImpl) Shutdown(ctx context.Context) error {
+	s.stopCleanup()
+	if s.ownsBatches {
+		if err := s.batches.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if s.ownsStore {
+		return s.store.Close()
+	}
+	return nil
+}
+
+// CreateHistoricProcessInstanceQuery creates a new historic process instance query
+func (s *historyServiceImpl) CreateHistoricProcessInstanceQuery() *HistoricProcessInstanceQuery {
+	return &HistoricProcessInstanceQuery{
+		service: s,
+	}
+}
+
+// CreateHistoricTaskInstanceQuery creates a new historic task instance query
+func (s *historyServiceImpl) CreateHistoricTaskInstanceQuery() *HistoricTaskInstanceQuery {
+	return &HistoricTaskInstanceQuery{
+		service: s,
+	}
+}
+
+// CreateHistoricActivityInstanceQuery creates a new historic activity instance query
+func (s *historyServiceImpl) CreateHistoricActivityInstanceQuery() *HistoricActivityInstanceQuery {
+	return &HistoricActivityInstanceQuery{
+		service: s,
+	}
+}
+
+// CreateHistoricVariableInstanceQuery creates a new historic variable instance query
+func (s *historyServiceImpl) CreateHistoricVariableInstanceQuery() *HistoricVariableInstanceQuery {
+	return &HistoricVariableInstanceQuery{
+		service: s,
+	}
+}
+
+// DeleteHistoricProcessInstance deletes a historic process instance
+func (s *historyServiceImpl) DeleteHistoricProcessInstance(ctx context.Context, processInstanceID string) error {
+	s.mu.Lock()
+	if _, exists := s.processInstances[processInstanceID]; !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("historic process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+
+	deleted := s.deleteProcessInstances(map[string]bool{processInstanceID: true})
+	err := s.deleteFromStore(ctx, deleted)
+	s.mu.Unlock()
+
+	s.unindexProcessInstance(ctx, processInstanceID)
+	return err
+}
+
+// deleteProcessInstances deletes historic process instances and their related data.
+// It returns the IDs of the deleted entities by store table.
+// Callers must hold the write lock.
+func (s *historyServiceImpl) deleteProcessInstances(processInstanceIDs map[string]bool) map[string][]string {
+	deleted := make(map[string][]string)
+
+	for id := range processInstanceIDs {
+		if _, exists := s.processInstances[id]; exists {
+			delete(s.processInstances, id)
+			deleted[persistence.TableHistoricProcessInstances] = append(deleted[persistence.TableHistoricProcessInstances], id)
+		}
+	}
+
+	// Delete related data
+	for id, task := range s.tasks {
+		if processInstanceIDs[task.ProcessInstanceID] {
+			delete(s.tasks, id)
+			deleted[persistence.TableHistoricTaskInstances] = append(deleted[persistence.TableHistoricTaskInstances], id)
+		}
+	}
+
+	for id, activity := range s.activities {
+		if processInstanceIDs[activity.ProcessInstanceID] {
+			delete(s.activities, id)
+			deleted[persistence.TableHistoricActivityInstances] = append(deleted[persistence.TableHistoricActivityInstances], id)
+		}
+	}
+
+	for id, variable := range s.variables {
+		if processInstanceIDs[variable.ProcessInstanceID] {
+			delete(s.variables, id)
+			deleted[persistence.TableHistoricVariableInstances] = append(deleted[persistence.TableHistoricVariableInstances], id)
+		}
+	}
+
+	for id, detail := range s.details {
+		if processInstanceIDs[detail.ProcessInstanceID] {
+			delete(s.details, id)
+			deleted[persistence.TableHistoricDetails] = append(deleted[persistence.TableHistoricDetails], id)
+		}
+	}
+	return deleted
+}
+
+// DeleteHistoricProcessInstancesByQuery deletes historic process instances matching a query as a batch
+func (s *historyServiceImpl) DeleteHistoricProcessInstancesByQuery(ctx context.Context, query *HistoricProcessInstanceQuery, deleteReason string) (*Batch, error) {
+	if query == nil {
+		return nil, fmt.Errorf("query cannot be nil")
+	}
+
+	// Determine the instances to delete up front so that instances recorded
+	// while the batch runs are not deleted
+	instances, err := s.listProcessInstances(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	processInstanceIDs := make([]string, len(instances))
+	for i, instance := range instances {
+		processInstanceIDs[i] = instance.ID
+	}
+
+	return s.startDeletionBatch(ctx, BatchTypeHistoricProcessInstanceDeletion, deleteReason, processInstanceIDs)
+}
+
+// startDeletionBatch creates a batch deleting historic process instances in the background
+func (s *historyServiceImpl) startDeletionBatch(ctx context.Context, batchType, deleteReason string, processInstanceIDs []string) (*Batch, error) {
+	s.mu.RLock()
+	batches := s.batches
+	s.mu.RUnlock()
+
+	return batches.CreateBatch(ctx, batchType, processInstanceIDs, map[string]string{batch.ConfigurationDeleteReason: deleteReason})
+}
+
+// executeDeletionJob deletes the process instances of a job of a deletion batch.
+// With an archiver, they are archived first; instances failing to archive are kept.
+func (s *historyServiceImpl) executeDeletionJob(ctx context.Context, deletion *Batch, processInstanceIDs []string) error {
+	archivable := s.archive(ctx, deletion, processInstanceIDs)
+
+	s.mu.Lock()
+	deleted := s.deleteProcessInstances(archivable)
+	err := s.deleteFromStore(ctx, deleted)
+	s.mu.Unlock()
+
+	for id := range archivable {
+		s.unindexProcessInstance(ctx, id)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete historic process instances from store: %w", err)
+	}
+	return nil
+}
+
+// GetBatch retrieves a snapshot of a batch
+func (s *historyServiceImpl) GetBatch(ctx context.Context, batchID string) (*Batch, error) {
+	s.mu.RLock()
+	batches := s.batches
+	s.mu.RUnlock()
+
+	return batches.GetBatch(ctx, batchID)
+}
+
+// DeleteHistoricTaskInstance deletes a historic task instance
+func (s *historyServiceImpl) DeleteHistoricTaskInstance(ctx context.Context, taskID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return fmt.Errorf("historic task instance %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	delete(s.tasks, taskID)
+	if s.store != nil {
+		return s.store.Delete(ctx, persistence.TableHistoricTaskInstances, taskID)
+	}
+	return nil
+}
+
+// RecordProcessInstance records a process instance to history
+func (s *historyServiceImpl) RecordProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error {
+	if !s.GetHistoryLevel().Includes(HistoryLevelActivity) {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.processInstances[instance.ID] = instance
+	indexer := s.indexer
+	err := s.saveLocked(ctx, persistence.TableHistoricProcessInstances, instance.ID, instance)
+	s.mu.Unlock()
+
+	if indexer != nil {
+		if err := indexer.IndexProcessInstance(ctx, instance); err != nil {
+			s.logger.ErrorContext(ctx, "failed to index historic process instance", slog.String("processInstanceId", instance.ID), slog.Any("error", err))
+		}
+	}
+	return err
+}
+
+// RecordTaskInstance records a task instance to history
+func (s *historyServiceImpl) RecordTaskInstance(ctx context.Context, task *HistoricTaskInstance) error {
+	if !s.GetHistoryLevel().Includes(HistoryLevelActivity) {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.tasks[task.ID] = task
+	indexer := s.indexer
+	err := s.saveLocked(ctx, persistence.TableHistoricTaskInstances, task.ID, task)
+	s.mu.Unlock()
+
+	if indexer != nil {
+		if err := indexer.IndexTaskInstance(ctx, task); err != nil {
+			s.logger.ErrorContext(ctx, "failed to index historic task instance", slog.String("taskId", task.ID), slog.Any("error", err))
+		}
+	}
+	return err
+}
+
+// RecordActivityInstance records an activity instance to history
+func (s *historyServiceImpl) RecordActivityInstance(ctx context.Context, activity *HistoricActivityInstance) error {
+	if !s.GetHistoryLevel().Includes(HistoryLevelActivity) {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.activities[activity.ID] = activity
+	return s.saveLocked(ctx, persistence.TableHistoricActivityInstances, activity.ID, activity)
+}
+
+// RecordVariableInstance records a variable instance to history
+func (s *historyServiceImpl) RecordVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error {
+	if !s.GetHistoryLevel().Includes(HistoryLevelAudit) {
+		return nil
+	}
+	if err := setVariableType(variable); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	// Take over the process context so that searches can filter variables like instances
+	if instance, exists := s.processInstances[variable.ProcessInstanceID]; exists {
+		if variable.ProcessDefinitionKey == "" {
+			variable.ProcessDefinitionKey = instance.ProcessDefinitionKey
+		}
+		if variable.TenantID == "" {
+			variable.TenantID = instance.TenantID
+		}
+	}
+	s.variables[variable.ID] = variable
+	indexer := s.indexer
+	err := s.saveLocked(ctx, persistence.TableHistoricVariableInstances, variable.ID, newHistoricVariableInstanceState(variable))
+	s.mu.Unlock()
+
+	if indexer != nil {
+		if err := indexer.IndexVariableInstance(ctx, variable); err != nil {
+			s.logger.ErrorContext(ctx, "failed to index historic variable instance", slog.String("variableInstanceId", variable.ID), slog.Any("error", err))
+		}
+	}
+	return err
+}
+
+// SetIndexer enables mirroring of history data into a full-text search index
+func (s *historyServiceImpl) SetIndexer(indexer HistoryIndexer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.indexer = indexer
+}
+
+// SearchHistoric runs a full-text search over the indexed history data
+func (s *historyServiceImpl) SearchHistoric(ctx context.Context, freeText string, filters *SearchFilters) (*SearchResult, error) {
+	indexer := s.getIndexer()
+	if indexer == nil {
+		return nil, fmt.Errorf("history search requires an indexer")
+	}
+	return indexer.Search(ctx, freeText, filters)
+}
+
+// getIndexer returns the configured indexer, if any
+func (s *historyServiceImpl) getIndexer() HistoryIndexer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.indexer
+}
+
+// unindexProcessInstance removes a deleted process instance from the search index.
+// Index failures are logged since the history store remains the system of record.
+func (s *historyServiceImpl) unindexProcessInstance(ctx context.Context, processInstanceID string) {
+	indexer := s.getIndexer()
+	if indexer == nil {
+		return
+	}
+	if err := indexer.DeleteProcessInstance(ctx, processInstanceID); err != nil {
+		s.logger.ErrorContext(ctx, "failed to remove historic process instance from index", slog.String("processInstanceId", processInstanceID), slog.Any("error", err))
+	}
+}
+
+// listProcessInstances executes a historic process instance query
+func (s *historyServiceImpl) listProcessInstances(ctx context.Context, q *HistoricProcessInstanceQuery) ([]*HistoricProcessInstance, error) {
+	s.mu.RLock()
+	result := make([]*HistoricProcessInstance, 0)
+	for _, instance := range s.processInstances {
+		if identity.IsTenantAccessible(ctx, instance.TenantID) && s.matchesProcessInstanceQuery(instance, q) {
+			result = append(result, instance)
+		}
+	}
+	s.mu.RUnlock()
+
+	paging.Sort(result, func(a, b *HistoricProcessInstance) bool {
+		return a.ID < b.ID
+	}, func(a, b *HistoricProcessInstance) bool {
+		var c int
+		switch q.orderBy {
+		case "id":
+			c = strings.Compare(a.ID, b.ID)
+		case "end_time":
+			c = compareTimePtr(a.EndTime, b.EndTime)
+		case "duration":
+			c = compareInt64Ptr(a.DurationInMillis, b.DurationInMillis)
+		case "start_time":
+			c = a.StartTime.Compare(b.StartTime)
+		default:
+			// Unordered queries return instances in the order they started
+			return a.StartTime.Before(b.StartTime)
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// countProcessInstances counts the results of a historic process instance query
+func (s *historyServiceImpl) countProcessInstances(ctx context.Context, q *HistoricProcessInstanceQuery) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, instance := range s.processInstances {
+		if identity.IsTenantAccessible(ctx, instance.TenantID) && s.matchesProcessInstanceQuery(instance, q) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesProcessInstanceQuery checks a historic process instance against the query filters.
+// Callers must hold the read lock.
+func (s *historyServiceImpl) matchesProcessInstanceQuery(instance *HistoricProcessInstance, q *HistoricProcessInstanceQuery) bool {
+	if q.processInstanceID != "" && instance.ID != q.processInstanceID {
+		return false
+	}
+	if q.processInstanceBusinessKey != "" && instance.BusinessKey != q.processInstanceBusinessKey {
+		return false
+	}
+	for _, tag := range q.tags {
+		if !slices.Contains(instance.Tags, tag) {
+			return false
+		}
+	}
+	if len(q.tagIn) > 0 && !slices.ContainsFunc(q.tagIn, func(candidate string) bool { return slices.Contains(instance.Tags, candidate) }) {
+		return false
+	}
+	if q.processDefinitionID != "" && instance.ProcessDefinitionID != q.processDefinitionID {
+		return false
+	}
+	if q.processDefinitionKey != "" && instance.ProcessDefinitionKey != q.processDefinitionKey {
+		return false
+	}
+	if q.processDefinitionName != "" && instance.ProcessDefinitionName != q.processDefinitionName {
+		return false
+	}
+	if q.deploymentID != "" && instance.DeploymentID != q.deploymentID {
+		return false
+	}
+	if q.startUserID != "" && instance.StartUserID != q.startUserID {
+		return false
+	}
+	if q.superProcessInstanceID != "" && instance.SuperProcessInstanceID != q.superProcessInstanceID {
+		return false
+	}
+	if q.tenantID != "" && instance.TenantID != q.tenantID {
+		return false
+	}
+	if q.finished != nil && *q.finished && instance.EndTime == nil {
+		return false
+	}
+	if q.unfinished != nil && *q.unfinished && instance.EndTime != nil {
+		return false
+	}
+	if q.startedBefore != nil && !instance.StartTime.Before(*q.startedBefore) {
+		return false
+	}
+	if q.startedAfter != nil && !instance.StartTime.After(*q.startedAfter) {
+		return false
+	}
+	if q.finishedBefore != nil && (instance.EndTime == nil || !instance.EndTime.Before(*q.finishedBefore)) {
+		return false
+	}
+	if q.finishedAfter != nil && (instance.EndTime == nil || !instance.EndTime.After(*q.finishedAfter)) {
+		return false
+	}
+	for name, value := range q.variableValueEquals {
+		matched := false
+		for _, historicVariable := range s.variables {
+			if historicVariable.ProcessInstanceID == instance.ID && historicVariable.Name == name && variable.Equal(historicVariable.Value, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// listTaskInstances executes a historic task instance query
+func (s *historyServiceImpl) listTaskInstances(ctx context.Context, q *HistoricTaskInstanceQuery) ([]*HistoricTaskInstance, error) {
+	s.mu.RLock()
+	result := make([]*HistoricTaskInstance, 0)
+	for _, task := range s.tasks {
+		if identity.IsTenantAccessible(ctx, task.TenantID) && s.matchesTaskQuery(task, q) {
+			result = append(result, task)
+		}
+	}
+	s.mu.RUnlock()
+
+	paging.Sort(result, func(a, b *HistoricTaskInstance) bool {
+		return a.ID < b.ID
+	}, func(a, b *HistoricTaskInstance) bool {
+		var c int
+		switch q.orderBy {
+		case "id":
+			c = strings.Compare(a.ID, b.ID)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "priority":
+			c = a.Priority - b.Priority
+		case "due_date":
+			c = compareTimePtr(a.DueDate, b.DueDate)
+		case "end_time":
+			c = compareTimePtr(a.EndTime, b.EndTime)
+		case "duration":
+			c = compareInt64Ptr(a.DurationInMillis, b.DurationInMillis)
+		case "start_time":
+			c = a.StartTime.Compare(b.StartTime)
+		default:
+			// Unordered queries return tasks in the order they were created
+			return a.StartTime.Before(b.StartTime)
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// countTaskInstances counts the results of a historic task instance query
+func (s *historyServiceImpl) countTaskInstances(ctx context.Context, q *HistoricTaskInstanceQuery) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, task := range s.tasks {
+		if identity.IsTenantAccessible(ctx, task.TenantID) && s.matchesTaskQuery(task, q) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesTaskQuery checks a historic task instance against the query filters.
+// Callers must hold the read lock.
+func (s *historyServiceImpl) matchesTaskQuery(task *HistoricTaskInstance, q *HistoricTaskInstanceQuery) bool {
+	if q.taskID != "" && task.ID != q.taskID {
+		return false
+	}
+	if q.processInstanceID != "" && task.ProcessInstanceID != q.processInstanceID {
+		return false
+	}
+	if q.processDefinitionID != "" && task.ProcessDefinitionID != q.processDefinitionID {
+		return false
+	}
+	if q.processDefinitionKey != "" && task.ProcessDefinitionKey != q.processDefinitionKey {
+		return false
+	}
+	if q.executionID != "" && task.ExecutionID != q.executionID {
+		return false
+	}
+	if q.taskDefinitionKey != "" && task.TaskDefinitionKey != q.taskDefinitionKey {
+		return false
+	}
+	if q.taskDefinitionKeys != nil && !slices.Contains(q.taskDefinitionKeys, task.TaskDefinitionKey) {
+		return false
+	}
+	if q.assignee != "" && task.Assignee != q.assignee {
+		return false
+	}
+	if q.owner != "" && task.Owner != q.owner {
+		return false
+	}
+	if q.candidateUser != "" && !slices.Contains(task.CandidateUsers, q.candidateUser) {
+		return false
+	}
+	if q.candidateGroup != "" && !slices.Contains(task.CandidateGroups, q.candidateGroup) {
+		return false
+	}
+	if q.candidateGroupIn != nil && !slices.ContainsFunc(q.candidateGroupIn, func(candidate string) bool { return slices.Contains(task.CandidateGroups, candidate) }) {
+		return false
+	}
+	if q.taskName != "" && task.Name != q.taskName {
+		return false
+	}
+	if q.tenantID != "" && task.TenantID != q.tenantID {
+		return false
+	}
+	if q.finished != nil && *q.finished && task.EndTime == nil {
+		return false
+	}
+	if q.unfinished != nil && *q.unfinished && task.EndTime != nil {
+		return false
+	}
+	if q.startedBefore != nil && !task.StartTime.Before(*q.startedBefore) {
+		return false
+	}
+	if q.startedAfter != nil && !task.StartTime.After(*q.startedAfter) {
+		return false
+	}
+	if q.finishedBefore != nil && (task.EndTime == nil || !task.EndTime.Before(*q.finishedBefore)) {
+		return false
+	}
+	if q.finishedAfter != nil && (task.EndTime == nil || !task.EndTime.After(*q.finishedAfter)) {
+		return false
+	}
+	if q.priorityMin != nil && task.Priority < *q.priorityMin {
+		return false
+	}
+	if q.priorityMax != nil && task.Priority > *q.priorityMax {
+		return false
+	}
+	if q.dueBefore != nil && (task.DueDate == nil || !task.DueDate.Before(*q.dueBefore)) {
+		return false
+	}
+	if q.dueAfter != nil && (task.DueDate == nil || !task.DueDate.After(*q.dueAfter)) {
+		return false
+	}
+	for name, value := range q.variableValueEquals {
+		matched := false
+		for _, historicVariable := range s.variables {
+			if historicVariable.ProcessInstanceID == task.ProcessInstanceID && historicVariable.Name == name && variable.Equal(historicVariable.Value, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// listActivityInstances executes a historic activity instance query
+func (s *historyServiceImpl) listActivityInstances(ctx context.Context, q *HistoricActivityInstanceQuery) ([]*HistoricActivityInstance, error) {
+	s.mu.RLock()
+	result := make([]*HistoricActivityInstance, 0)
+	for _, activity := range s.activities {
+		if identity.IsTenantAccessible(ctx, activity.TenantID) && matchesActivityQuery(activity, q) {
+			result = append(result, activity)
+		}
+	}
+	s.mu.RUnlock()
+
+	paging.Sort(result, func(a, b *HistoricActivityInstance) bool {
+		return a.ID < b.ID
+	}, func(a, b *HistoricActivityInstance) bool {
+		var c int
+		switch q.orderBy {
+		case "activity_id":
+			c = strings.Compare(a.ActivityID, b.ActivityID)
+		case "activity_type":
+			c = strings.Compare(a.ActivityType, b.ActivityType)
+		case "end_time":
+			c = compareTimePtr(a.EndTime, b.EndTime)
+		case "duration":
+			c = compareInt64Ptr(a.DurationInMillis, b.DurationInMillis)
+		case "start_time":
+			c = a.StartTime.Compare(b.StartTime)
+		default:
+			// Unordered queries return activities in the order they started
+			return a.StartTime.Before(b.StartTime)
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// countActivityInstances counts the results of a historic activity instance query
+func (s *historyServiceImpl) countActivityInstances(ctx context.Context, q *HistoricActivityInstanceQuery) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, activity := range s.activities {
+		if identity.IsTenantAccessible(ctx, activity.TenantID) && matchesActivityQuery(activity, q) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesActivityQuery checks a historic activity instance against the query filters
+func matchesActivityQuery(activity *HistoricActivityInstance, q *HistoricActivityInstanceQuery) bool {
+	if q.activityID != "" && activity.ActivityID != q.activityID {
+		return false
+	}
+	if q.activityType != "" && activity.ActivityType != q.activityType {
+		return false
+	}
+	if q.processInstanceID != "" && activity.ProcessInstanceID != q.processInstanceID {
+		return false
+	}
+	if q.processDefinitionID != "" && activity.ProcessDefinitionID != q.processDefinitionID {
+		return false
+	}
+	if q.executionID != "" && activity.ExecutionID != q.executionID {
+		return false
+	}
+	if q.taskID != "" && activity.TaskID != q.taskID {
+		return false
+	}
+	if q.assignee != "" && activity.Assignee != q.assignee {
+		return false
+	}
+	if q.finished != nil && (activity.EndTime != nil) != *q.finished {
+		return false
+	}
+	if q.startedBefore != nil && !activity.StartTime.Before(*q.startedBefore) {
+		return false
+	}
+	if q.startedAfter != nil && !activity.StartTime.After(*q.startedAfter) {
+		return false
+	}
+	if q.finishedBefore != nil && (activity.EndTime == nil || !activity.EndTime.Before(*q.finishedBefore)) {
+		return false
+	}
+	if q.finishedAfter != nil && (activity.EndTime == nil || !activity.EndTime.After(*q.finishedAfter)) {
+		return false
+	}
+	return true
+}
+
+// listVariableInstances executes a historic variable instance query
+func (s *historyServiceImpl) listVariableInstances(ctx context.Context, q *HistoricVariableInstanceQuery) ([]*HistoricVariableInstance, error) {
+	s.mu.RLock()
+	result := make([]*HistoricVariableInstance, 0)
+	for _, variable := range s.variables {
+		if identity.IsTenantAccessible(ctx, variable.TenantID) && matchesVariableQuery(variable, q) {
+			result = append(result, variable)
+		}
+	}
+	s.mu.RUnlock()
+
+	paging.Sort(result, func(a, b *HistoricVariableInstance) bool {
+		return a.ID < b.ID
+	}, func(a, b *HistoricVariableInstance) bool {
+		var c int
+		switch q.orderBy {
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "process_instance_id":
+			c = strings.Compare(a.ProcessInstanceID, b.ProcessInstanceID)
+		case "create_time":
+			c = a.CreateTime.Compare(b.CreateTime)
+		default:
+			// Unordered queries return variables in the order they were created
+			return a.CreateTime.Before(b.CreateTime)
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// countVariableInstances counts the results of a historic variable instance query
+func (s *historyServiceImpl) countVariableInstances(ctx context.Context, q *HistoricVariableInstanceQuery) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int64
+	for _, variable := range s.variables {
+		if identity.IsTenantAccessible(ctx, variable.TenantID) && matchesVariableQuery(variable, q) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matchesVariableQuery checks a historic variable instance against the query filters
+func matchesVariableQuery(historicVariable *HistoricVariableInstance, q *HistoricVariableInstanceQuery) bool {
+	if q.variableName != "" && historicVariable.Name != q.variableName {
+		return false
+	}
+	if q.variableNameLike != "" && !like.Match(historicVariable.Name, q.variableNameLike) {
+		return false
+	}
+	if q.hasVariableValue && !variable.Equal(historicVariable.Value, q.variableValue) {
+		return false
+	}
+	if q.typeName != "" && historicVariable.TypeName != q.typeName {
+		return false
+	}
+	if q.processInstanceID != "" && historicVariable.ProcessInstanceID != q.processInstanceID {
+		return false
+	}
+	if q.taskID != "" && historicVariable.TaskID != q.taskID {
+		return false
+	}
+	if q.excludeTaskVariables && historicVariable.TaskID != "" {
+		return false
+	}
+	if q.createdBefore != nil && !historicVariable.CreateTime.Before(*q.createdBefore) {
+		return false
+	}
+	if q.createdAfter != nil && !historicVariable.CreateTime.After(*q.createdAfter) {
+		return false
+	}
+	return true
+}
+
+// compareTimePtr orders optional times, placing nil after set values
+func compareTimePtr(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// compareInt64Ptr orders optional integers, placing nil after set values
+func compareInt64Ptr(a, b *int64) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	}
+	return 0
+}
+
+// noOpHistoryService is a no-op implementation when history is disabled
+type noOpHistoryService struct{}
+
+// NewNoOpHistoryService creates a no-op history service
+func NewNoOpHistoryService() HistoryService {
+	return &noOpHistoryService{}
+}
+
+func (s *noOpHistoryService) Initialize(ctx context.Context) error                                   { return nil }
+func (s *noOpHistoryService) Shutdown(ctx context.Context) error                                     { return nil }
+func (s *noOpHistoryService) CreateHistoricProcessInstanceQuery() *HistoricProcessInstanceQuery      { return nil }
+func (s *noOpHistoryService) CreateHistoricTaskInstanceQuery() *HistoricTaskInstanceQuery            { return nil }
+func (s *noOpHistoryService) CreateHistoricActivityInstanceQuery() *HistoricActivityInstanceQuery    { return nil }
+func (s *noOpHistoryService) CreateHistoricVariableInstanceQuery() *HistoricVariableInstanceQuery    { return nil }
+func (s *noOpHistoryService) CreateHistoricDetailQuery() *HistoricDetailQuery                        { return nil }
+func (s *noOpHistoryService) CreateHistoricProcessInstanceReport() *HistoricProcessInstanceReport    { return nil }
+func (s *noOpHistoryService) CreateHistoricTaskInstanceReport() *HistoricTaskInstanceReport          { return nil }
+func (s *noOpHistoryService) DeleteHistoricProcessInstance(ctx context.Context, processInstanceID string) error { return nil }
+func (s *noOpHistoryService) DeleteHistoricProcessInstancesByQuery(ctx context.Context, query *HistoricProcessInstanceQuery, deleteReason string) (*Batch, error) { return nil, nil }
+func (s *noOpHistoryService) DeleteHistoricProcessInstancesBefore(ctx context.Context, date time.Time) (*Batch, error) { return nil, nil }
+func (s *noOpHistoryService) CleanupHistory(ctx context.Context) (*Batch, error)                      { return nil, nil }
+func (s *noOpHistoryService) SetCleanupInterval(interval time.Duration)                               {}
+func (s *noOpHistoryService) SetArchiver(archiver HistoryArchiver)                                    {}
+func (s *noOpHistoryService) GetBatch(ctx context.Context, batchID string) (*Batch, error)          { return nil, nil }
+func (s *noOpHistoryService) SetBatchService(batches batch.BatchService)                            {}
+func (s *noOpHistoryService) DeleteHistoricTaskInstance(ctx context.Context, taskID string) error    { return nil }
+func (s *noOpHistoryService) SetIndexer(indexer HistoryIndexer)                                  {}
+func (s *noOpHistoryService) SetStore(store *persistence.Store)                                   {}
+func (s *noOpHistoryService) SetClock(c clock.Clock)                                              {}
+func (s *noOpHistoryService) SetLogger(logger *slog.Logger)                                       {}
+func (s *noOpHistoryService) GetHistoryLevel() HistoryLevel                                       { return HistoryLevelNone }
+func (s *noOpHistoryService) SetHistoryLevel(level HistoryLevel)                                  {}
+func (s *noOpHistoryService) SearchHistoric(ctx context.Context, freeText string, filters *SearchFilters) (*SearchResult, error) { return nil, nil }
+func (s *noOpHistoryService) RecordProcessInstance(ctx context.Context, instance *HistoricProcessInstance) error { return nil }
+func (s *noOpHistoryService) RecordTaskInstance(ctx context.Context, task *HistoricTaskInstance) error { return nil }
+func (s *noOpHistoryService) RecordActivityInstance(ctx context.Context, activity *HistoricActivityInstance) error { return nil }
+func (s *noOpHistoryService) RecordVariableInstance(ctx context.Context, variable *HistoricVariableInstance) error { return nil }
+func (s *noOpHistoryService) RecordDetail(ctx context.Context, detail *HistoricDetail) error { return nil }
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// Event types of start event subscriptions
+const (
+	EventTypeMessage     = "message"
+	EventTypeSignal      = "signal"
+	EventTypeConditional = "conditional"
+	EventTypeTimer       = "timer"
+)
+
+// EventSubscription represents a start event of a deployed process definition
+// that starts new process instances when its event occurs
+type EventSubscription struct {
+	ID                  string
+	EventType           string
+	EventName           string
+	Condition           string
+	ProcessDefinitionID string
+	ActivityID          string
+	TenantID            string
+	CreateTime          time.Time
+}
+
+// startEventSubscriptions builds the subscriptions for the message, signal,
+// conditional and timer start events of a process model
+func startEventSubscriptions(processModel *model.ProcessModel, processDefinition *ProcessDefinition, now time.Time) []*EventSubscription {
+	subscriptions := make([]*EventSubscription, 0)
+
+	for _, node := range processModel.GetNodesByType(model.NodeTypeStartEvent) {
+		// Start events of subprocesses do not start process instances
+		if node.ParentID != "" {
+			continue
+		}
+		definition := &model.Node{Properties: node.GetMap("eventDefinition")}
+
+		subscription := &EventSubscription{
+			ID:                  uuid.New().String(),
+			EventType:           node.GetString("eventType"),
+			ProcessDefinitionID: processDefinition.ID,
+			ActivityID:          node.ID,
+			TenantID:            processDefinition.TenantID,
+			CreateTime:          now,
+		}
+
+		switch subscription.EventType {
+		case EventTypeMessage:
+			subscription.EventName = definition.GetString("messageName")
+		case EventTypeSignal:
+			subscription.EventName = definition.GetString("signalName")
+		case EventTypeConditional:
+			subscription.Condition = definition.GetString("condition")
+		case EventTypeTimer:
+			// The runtime schedules the start of instances from the timer definition
+		default:
+			continue
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions
+}
+
+// validateStartEventSubscriptions checks that subscriptions carry the event name or condition they need
+func validateStartEventSubscriptions(subscriptions []*EventSubscription) error {
+	for _, subscription := range subscriptions {
+		if subscription.EventType == EventTypeTimer {
+			continue
+		}
+		if subscription.EventType == EventTypeConditional {
+			if subscription.Condition == "" {
+				return fmt.Errorf("conditional start event '%s' must define a condition", subscription.ActivityID)
+			}
+			continue
+		}
+		if subscription.EventName == "" {
+			return fmt.Errorf("%s start event '%s' must define a %s name", subscription.EventType, subscription.ActivityID, subscription.EventType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/pkg/like"
+	"github.com/muixstudio/flowgo/pkg/paging"
+)
+
+// listProcessDefinitions returns the process definitions matching a query
+func (s *repositoryServiceImpl) listProcessDefinitions(ctx context.Context, q *ProcessDefinitionQuery) ([]*ProcessDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Versions are numbered per tenant and key, so the latest version is the highest one of its tenant and key
+	latestVersions := make(map[string]int)
+	if q.latestVersion {
+		for _, def := range s.definitions {
+			if key := def.TenantID + "/" + def.Key; def.Version > latestVersions[key] {
+				latestVersions[key] = def.Version
+			}
+		}
+	}
+
+	result := make([]*ProcessDefinition, 0)
+	for _, def := range s.definitions {
+		if q.latestVersion && def.Version != latestVersions[def.TenantID+"/"+def.Key] {
+			continue
+		}
+		if !identity.IsTenantAccessible(ctx, def.TenantID) {
+			continue
+		}
+		if matchesProcessDefinitionQuery(def, q) {
+			result = append(result, def)
+		}
+	}
+
+	paging.Sort(result, func(a, b *ProcessDefinition) bool {
+		return a.ID < b.ID
+	}, func(a, b *ProcessDefinition) bool {
+		var c int
+		switch q.orderBy {
+		case "key":
+			c = strings.Compare(a.Key, b.Key)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "version":
+			c = a.Version - b.Version
+		case "deployment_id":
+			c = strings.Compare(a.DeploymentID, b.DeploymentID)
+		default:
+			// Unordered queries return definitions by key and version
+			if a.Key != b.Key {
+				return a.Key < b.Key
+			}
+			return a.Version < b.Version
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// matchesProcessDefinitionQuery checks a process definition against the filters of a query
+func matchesProcessDefinitionQuery(def *ProcessDefinition, q *ProcessDefinitionQuery) bool {
+	if q.processDefinitionID != "" && def.ID != q.processDefinitionID {
+		return false
+	}
+	if q.processDefinitionKey != "" && def.Key != q.processDefinitionKey {
+		return false
+	}
+	if q.processDefinitionName != "" && def.Name != q.processDefinitionName {
+		return false
+	}
+	if q.processDefinitionNameLike != "" && !like.Match(def.Name, q.processDefinitionNameLike) {
+		return false
+	}
+	if q.category != "" && def.Category != q.category {
+		return false
+	}
+	if q.deploymentID != "" && def.DeploymentID != q.deploymentID {
+		return false
+	}
+	if q.tenantID != "" && def.TenantID != q.tenantID {
+		return false
+	}
+	if q.version != nil && def.Version != *q.version {
+		return false
+	}
+	if q.suspended != nil && def.Suspended != *q.suspended {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/persistence"
+)
+
+// saveDeploymentLocked writes a deployment and its process definitions to the store, if any.
+// Callers must hold the write lock.
+func (s *repositoryServiceImpl) saveDeploymentLocked(ctx context.Context, deployment *Deployment, definitions []*ProcessDefinition) error {
+	if s.store == nil {
+		return nil
+	}
+
+	if err := s.saveResourceContentsLocked(ctx, deployment); err != nil {
+		return err
+	}
+	if err := s.store.Save(ctx, persistence.TableDeployments, deployment.ID, deployment); err != nil {
+		return err
+	}
+	for _, def := range definitions {
+		if err := s.store.Save(ctx, persistence.TableProcessDefinitions, def.ID, def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveResourceContentsLocked writes the content of the resources of a deployment the
+// engine does not parse, such as diagrams and attachments, to the byte array table in
+// chunks of resourceChunkSize bytes and drops it from the deployment, so that it is
+// neither kept in memory nor loaded with the deployment. Callers must hold the write lock.
+func (s *repositoryServiceImpl) saveResourceContentsLocked(ctx context.Context, deployment *Deployment) error {
+	for _, resource := range deployment.Resources {
+		if !isStoredResource(resource) {
+			continue
+		}
+		for i := 0; i < resourceChunkCount(resource); i++ {
+			chunk := resource.Content[i*resourceChunkSize : min((i+1)*resourceChunkSize, len(resource.Content))]
+			if err := s.store.Put(ctx, persistence.TableByteArrays, resourceChunkID(resource, i), chunk); err != nil {
+				return err
+			}
+		}
+		resource.Content = nil
+	}
+	return nil
+}
+
+// deleteResourceContentsLocked removes the contents of the stored resources of a
+// deployment from the store, if any. Callers must hold the write lock.
+func (s *repositoryServiceImpl) deleteResourceContentsLocked(ctx context.Context, deployment *Deployment) error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.Delete(ctx, persistence.TableByteArrays, resourceChunkIDs(deployment)...)
+}
+
+// resourceChunkSize is the size in bytes of the chunks the content of stored resources
+// is written and read in
+const resourceChunkSize = 64 * 1024
+
+// isStoredResource reports whether the content of a resource is kept in the store
+// rather than with its deployment: the content of resources other than process
+// definitions, forms and decision tables
+func isStoredResource(resource *Resource) bool {
+	return resource.Size > 0 && !isBPMNResource(resource) && !isProcessDefinitionResource(resource) &&
+		!isFormResource(resource) && !isDecisionResource(resource)
+}
+
+// resourceChunkCount returns the number of chunks the content of a stored resource is kept in
+func resourceChunkCount(resource *Resource) int {
+	return int((resource.Size + resourceChunkSize - 1) / resourceChunkSize)
+}
+
+// resourceChunkID returns the ID a chunk of the content of a resource is stored under
+func resourceChunkID(resource *Resource, index int) string {
+	return fmt.Sprintf("%s:%d", resource.ID, index)
+}
+
+// resourceChunkIDs returns the IDs of the chunks of the stored resources of a deployment
+func resourceChunkIDs(deployment *Deployment) []string {
+	var ids []string
+	for _, resource := range deployment.Resources {
+		if !isStoredResource(resource) {
+			continue
+		}
+		for i := 0; i < resourceChunkCount(resource); i++ {
+			ids = append(ids, resourceChunkID(resource, i))
+		}
+	}
+	return ids
+}
+
+// resourceReader reads the content of a stored resource chunk by chunk, so that no more
+// than one chunk of it is held in memory
+type resourceReader struct {
+	ctx      context.Context
+	store    *persistence.Store
+	resource *Resource
+	next     int    // index of the next chunk to read
+	chunk    []byte // unread rest of the current chunk
+}
+
+// Read reads from the current chunk, loading the next one from the store when it is exhausted
+func (r *resourceReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.next >= resourceChunkCount(r.resource) {
+			return 0, io.EOF
+		}
+		chunk, err := r.store.Get(r.ctx, persistence.TableByteArrays, resourceChunkID(r.resource, r.next))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read resource %s: %w", r.resource.Name, err)
+		}
+		r.chunk = chunk
+		r.next++
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// Close releases the current chunk; later reads return io.EOF
+func (r *resourceReader) Close() error {
+	r.chunk = nil
+	r.next = resourceChunkCount(r.resource)
+	return nil
+}
+
+// saveProcessDefinitionLocked writes a process definition to the store, if any.
+// Callers must hold the write lock.
+func (s *repositoryServiceImpl) saveProcessDefinitionLocked(ctx context.Context, def *ProcessDefinition) error {
+	if s.store == nil {
+		return nil
+	}
+	return s.store.Save(ctx, persistence.TableProcessDefinitions, def.ID, def)
+}
+
+// load reads deployments and process definitions from the store and
+// rebuilds the start event subscriptions from the deployed models
+func (s *repositoryServiceImpl) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := persistence.Load(ctx, s.store, persistence.TableDeployments, func(deployment *Deployment) {
+		s.deployments[deployment.ID] = deployment
+	})
+	if err != nil {
+		return err
+	}
+
+	err = persistence.Load(ctx, s.store, persistence.TableProcessDefinitions, func(def *ProcessDefinition) {
+		s.definitions[def.ID] = def
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, def := range s.definitions {
+		deployment, exists := s.deployments[def.DeploymentID]
+		if !exists {
+			continue
+		}
+		for _, resource := range deployment.Resources {
+			if resource.Name != def.ResourceName {
+				continue
+			}
+			content, err := processModelContent(resource)
+			if err != nil {
+				continue
+			}
+			if processModel, err := model.Parse(content); err == nil {
+				s.subscriptions[def.ID] = startEventSubscriptions(processModel, def, s.clock.Now())
+			}
+		}
+	}
+	return nil
+}
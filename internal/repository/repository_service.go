@@ -0,0 +1,446 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/cache"
+	"github.com/muixstudio/flowgo/pkg/paging"
+)
+
+// RepositoryService provides operations for managing process definitions and deployments.
+// This service is responsible for:
+// - Deploying process definitions
+// - Querying process definitions
+// - Managing process definition lifecycle (suspend/activate)
+// - Managing deployments
+type RepositoryService interface {
+	// Initialize initializes the repository service
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the repository service
+	Shutdown(ctx context.Context) error
+
+	// CreateDeployment creates a new deployment builder
+	CreateDeployment() *DeploymentBuilder
+
+	// GetDeployment retrieves a deployment by ID
+	GetDeployment(ctx context.Context, deploymentID string) (*Deployment, error)
+
+	// DeleteDeployment deletes a deployment and optionally cascade delete related data
+	DeleteDeployment(ctx context.Context, deploymentID string, cascade bool) error
+
+	// CreateProcessDefinitionQuery creates a new process definition query
+	CreateProcessDefinitionQuery() *ProcessDefinitionQuery
+
+	// GetProcessDefinition retrieves a process definition by ID
+	GetProcessDefinition(ctx context.Context, processDefinitionID string) (*ProcessDefinition, error)
+
+	// GetProcessDefinitionByKey retrieves the latest version of a process definition by key.
+	// With a tenant context (see identity.WithTenantIDs) the definitions of its tenants are
+	// resolved; a key deployed for several tenants must be resolved with the tenant ID.
+	GetProcessDefinitionByKey(ctx context.Context, key string) (*ProcessDefinition, error)
+
+	// GetProcessDefinitionByKeyAndTenantID retrieves the latest version of a process definition
+	// by key deployed for a tenant, or without tenant for an empty tenantID
+	GetProcessDefinitionByKeyAndTenantID(ctx context.Context, key, tenantID string) (*ProcessDefinition, error)
+
+	// SuspendProcessDefinition suspends a process definition. With IncludeProcessInstances
+	// its running process instances and their tasks are suspended as well; with
+	// ExecutionDate the suspension is scheduled for the job executor.
+	SuspendProcessDefinition(ctx context.Context, processDefinitionID string, options ...SuspensionOption) error
+
+	// ActivateProcessDefinition activates a suspended process definition, taking the same
+	// options as SuspendProcessDefinition
+	ActivateProcessDefinition(ctx context.Context, processDefinitionID string, options ...SuspensionOption) error
+
+	// SetSuspensionHandler sets the handler suspending process instances together with
+	// their process definition and scheduling suspensions, i.e. the runtime service
+	SetSuspensionHandler(handler SuspensionHandler)
+
+	// GetProcessModel retrieves the process model (JSON content) for a process definition.
+	// Process definitions deployed as BPMN 2.0 XML are returned converted to JSON.
+	GetProcessModel(ctx context.Context, processDefinitionID string) ([]byte, error)
+
+	// GetParsedProcessModel returns the parsed process model of a process definition.
+	// Parsed models are kept in a least recently used cache keyed by definition ID and
+	// shared by all callers, so they must not be modified.
+	GetParsedProcessModel(ctx context.Context, processDefinitionID string) (*model.ProcessModel, error)
+
+	// SetProcessDefinitionCacheLimit sets the maximum number of parsed process models
+	// kept in the cache; 0 means unlimited
+	SetProcessDefinitionCacheLimit(limit int)
+
+	// GetProcessDefinitionCacheStats returns the size, capacity, hits, misses and
+	// evictions of the cache of parsed process models
+	GetProcessDefinitionCacheStats() cache.Stats
+
+	// GetProcessModelBPMN returns the process model of a process definition as BPMN 2.0 XML
+	// with a diagram, for standard modeling tools. Process definitions deployed as BPMN
+	// return their deployed document.
+	GetProcessModelBPMN(ctx context.Context, processDefinitionID string) ([]byte, error)
+
+	// GetProcessDiagram renders the process model of a process definition as SVG diagram.
+	// Nodes are placed at their bounds or laid out automatically.
+	GetProcessDiagram(ctx context.Context, processDefinitionID string) ([]byte, error)
+
+	// ValidateProcessDefinition validates a process definition without deploying it.
+	// Problems of the process model are returned together as model.ValidationErrors.
+	ValidateProcessDefinition(ctx context.Context, content []byte) error
+
+	// GetDeploymentResourceNames returns the names of the resources of a deployment, in
+	// the order they were added
+	GetDeploymentResourceNames(ctx context.Context, deploymentID string) ([]string, error)
+
+	// GetResource returns a deployment resource without its content, e.g. for its content
+	// type and size, and opens its content for reading. Resources other than process
+	// definitions, forms and decision tables are streamed from the store in chunks,
+	// without loading their whole content. The caller must close the returned reader.
+	GetResource(ctx context.Context, deploymentID, resourceName string) (*Resource, io.ReadCloser, error)
+
+	// GetResourceAsStream opens the content of a deployment resource for reading like
+	// GetResource. The caller must close the returned reader.
+	GetResourceAsStream(ctx context.Context, deploymentID, resourceName string) (io.ReadCloser, error)
+
+	// GetStartEventSubscriptions returns the message, signal, conditional or timer start event
+	// subscriptions of the latest active process definitions. An empty eventName matches all names.
+	GetStartEventSubscriptions(ctx context.Context, eventType, eventName string) ([]*EventSubscription, error)
+
+	// GetFormDefinition retrieves the most recently deployed form definition with a key
+	GetFormDefinition(ctx context.Context, formKey string) (*model.FormDefinition, error)
+
+	// GetDecisionDefinition retrieves the most recently deployed decision table with a key
+	GetDecisionDefinition(ctx context.Context, decisionKey string) (*model.DecisionDefinition, error)
+
+	// RegisterTemplate registers a process template that "template" nodes can
+	// reference; template nodes are expanded into full definitions at deployment time
+	RegisterTemplate(name string, template model.Template) error
+
+	// SetStore sets the store deployments and process definitions are persisted to.
+	// Without a store, or a database URL to open one from, the service keeps its state in memory.
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock deployment times and scheduled suspensions are based on
+	SetClock(c clock.Clock)
+}
+
+// FormContentType is the content type of form definition resources. Resources named
+// "*.form" are deployed as form definitions.
+const FormContentType = "application/vnd.flowgo.form+json"
+
+// DecisionContentType is the content type of decision table resources. Resources named
+// "*.dmn" are deployed as decision tables.
+const DecisionContentType = "application/vnd.flowgo.dmn+json"
+
+// Deployment represents a deployment of process definitions
+type Deployment struct {
+	ID           string
+	Name         string
+	DeployTime   time.Time
+	Category     string
+	TenantID     string
+	Resources    []*Resource
+}
+
+// Resource represents a resource in a deployment (e.g., process definition file).
+// Deployments hold the content of process definitions, forms and decision tables; the
+// content of other resources, such as diagrams and attachments, is kept in the store
+// and read with GetResource.
+type Resource struct {
+	ID           string
+	Name         string
+	DeploymentID string
+	Content      []byte
+	ContentType  string
+	Size         int64 // length of the content in bytes
+}
+
+// ProcessDefinition represents a deployed process definition
+type ProcessDefinition struct {
+	ID                  string
+	Key                 string
+	Name                string
+	Description         string
+	Version             int
+	Category            string
+	DeploymentID        string
+	ResourceName        string
+	TenantID            string
+	Suspended           bool
+	StartFormKey        string
+	HasStartFormKey     bool
+	HasGraphicalNotation bool
+	HistoryTimeToLive   *int // days the history of finished instances is kept, nil for forever
+}
+
+// DeploymentBuilder provides a fluent API for creating deployments
+type DeploymentBuilder struct {
+	name               string
+	category           string
+	tenantID           string
+	resources          []*Resource
+	duplicateFiltering bool
+	service            RepositoryService
+}
+
+// Name sets the deployment name
+func (b *DeploymentBuilder) Name(name string) *DeploymentBuilder {
+	b.name = name
+	return b
+}
+
+// Category sets the deployment category
+func (b *DeploymentBuilder) Category(category string) *DeploymentBuilder {
+	b.category = category
+	return b
+}
+
+// TenantID sets the tenant ID for multi-tenancy
+func (b *DeploymentBuilder) TenantID(tenantID string) *DeploymentBuilder {
+	b.tenantID = tenantID
+	return b
+}
+
+// EnableDuplicateFiltering makes Deploy return the latest deployment with the same name
+// and tenant instead of deploying again when it holds byte-identical resources, so that
+// redeploying unchanged resources does not create new process definition versions
+func (b *DeploymentBuilder) EnableDuplicateFiltering() *DeploymentBuilder {
+	b.duplicateFiltering = true
+	return b
+}
+
+// AddResource adds a resource to the deployment. The content type is detected
+// from the resource name and content at deployment time; JSON resources and BPMN 2.0
+// XML resources named "*.bpmn" or "*.bpmn20.xml" are deployed as process definitions.
+func (b *DeploymentBuilder) AddResource(name string, content []byte) *DeploymentBuilder {
+	resource := &Resource{
+		Name:    name,
+		Content: content,
+	}
+	b.resources = append(b.resources, resource)
+	return b
+}
+
+// AddResourceWithContentType adds a resource with an explicit content type
+func (b *DeploymentBuilder) AddResourceWithContentType(name, contentType string, content []byte) *DeploymentBuilder {
+	resource := &Resource{
+		Name:        name,
+		Content:     content,
+		ContentType: contentType,
+	}
+	b.resources = append(b.resources, resource)
+	return b
+}
+
+// AddProcessDefinition adds a process definition from JSON content
+func (b *DeploymentBuilder) AddProcessDefinition(name string, jsonContent []byte) *DeploymentBuilder {
+	return b.AddResource(name, jsonContent)
+}
+
+// AddFormDefinition adds a form definition from JSON content
+func (b *DeploymentBuilder) AddFormDefinition(name string, jsonContent []byte) *DeploymentBuilder {
+	return b.AddResourceWithContentType(name, FormContentType, jsonContent)
+}
+
+// AddDecisionDefinition adds a decision table from JSON content
+func (b *DeploymentBuilder) AddDecisionDefinition(name string, jsonContent []byte) *DeploymentBuilder {
+	return b.AddResourceWithContentType(name, DecisionContentType, jsonContent)
+}
+
+// Deploy executes the deployment
+func (b *DeploymentBuilder) Deploy(ctx context.Context) (*Deployment, error) {
+	// Cast to implementation type to call internal method
+	if impl, ok := b.service.(*repositoryServiceImpl); ok {
+		return impl.deployInternal(ctx, b)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// ProcessDefinitionQuery provides a fluent API for querying process definitions
+type ProcessDefinitionQuery struct {
+	processDefinitionID  string
+	processDefinitionKey string
+	processDefinitionName string
+	processDefinitionNameLike string
+	category             string
+	deploymentID         string
+	tenantID             string
+	version              *int
+	latestVersion        bool
+	suspended            *bool
+	orderBy              string
+	ascending            bool
+	firstResult          int
+	maxResults           int
+	service              RepositoryService
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *ProcessDefinitionQuery) ProcessDefinitionID(id string) *ProcessDefinitionQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *ProcessDefinitionQuery) ProcessDefinitionKey(key string) *ProcessDefinitionQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// ProcessDefinitionName filters by process definition name
+func (q *ProcessDefinitionQuery) ProcessDefinitionName(name string) *ProcessDefinitionQuery {
+	q.processDefinitionName = name
+	return q
+}
+
+// ProcessDefinitionNameLike filters by process definition name using a SQL LIKE pattern ('%' and '_' wildcards)
+func (q *ProcessDefinitionQuery) ProcessDefinitionNameLike(pattern string) *ProcessDefinitionQuery {
+	q.processDefinitionNameLike = pattern
+	return q
+}
+
+// Category filters by category
+func (q *ProcessDefinitionQuery) Category(category string) *ProcessDefinitionQuery {
+	q.category = category
+	return q
+}
+
+// DeploymentID filters by deployment ID
+func (q *ProcessDefinitionQuery) DeploymentID(deploymentID string) *ProcessDefinitionQuery {
+	q.deploymentID = deploymentID
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *ProcessDefinitionQuery) TenantID(tenantID string) *ProcessDefinitionQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// Version filters by specific version
+func (q *ProcessDefinitionQuery) Version(version int) *ProcessDefinitionQuery {
+	q.version = &version
+	return q
+}
+
+// LatestVersion filters to only the latest version of each process definition key
+func (q *ProcessDefinitionQuery) LatestVersion() *ProcessDefinitionQuery {
+	q.latestVersion = true
+	return q
+}
+
+// Active filters to only active (non-suspended) process definitions
+func (q *ProcessDefinitionQuery) Active() *ProcessDefinitionQuery {
+	falseVal := false
+	q.suspended = &falseVal
+	return q
+}
+
+// Suspended filters to only suspended process definitions
+func (q *ProcessDefinitionQuery) Suspended() *ProcessDefinitionQuery {
+	trueVal := true
+	q.suspended = &trueVal
+	return q
+}
+
+// OrderByProcessDefinitionKey orders results by process definition key
+func (q *ProcessDefinitionQuery) OrderByProcessDefinitionKey() *ProcessDefinitionQuery {
+	q.orderBy = "key"
+	return q
+}
+
+// OrderByProcessDefinitionName orders results by process definition name
+func (q *ProcessDefinitionQuery) OrderByProcessDefinitionName() *ProcessDefinitionQuery {
+	q.orderBy = "name"
+	return q
+}
+
+// OrderByProcessDefinitionVersion orders results by process definition version
+func (q *ProcessDefinitionQuery) OrderByProcessDefinitionVersion() *ProcessDefinitionQuery {
+	q.orderBy = "version"
+	return q
+}
+
+// OrderByDeploymentID orders results by deployment ID
+func (q *ProcessDefinitionQuery) OrderByDeploymentID() *ProcessDefinitionQuery {
+	q.orderBy = "deployment_id"
+	return q
+}
+
+// Asc sets ascending order
+func (q *ProcessDefinitionQuery) Asc() *ProcessDefinitionQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *ProcessDefinitionQuery) Desc() *ProcessDefinitionQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *ProcessDefinitionQuery) Limit(maxResults int) *ProcessDefinitionQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *ProcessDefinitionQuery) Offset(firstResult int) *ProcessDefinitionQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of process definitions
+func (q *ProcessDefinitionQuery) List(ctx context.Context) ([]*ProcessDefinition, error) {
+	if impl, ok := q.service.(*repositoryServiceImpl); ok {
+		return impl.listProcessDefinitions(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching process definitions, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *ProcessDefinitionQuery) Iterate(ctx context.Context) iter.Seq2[*ProcessDefinition, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*ProcessDefinition, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of process definitions
+func (q *ProcessDefinitionQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*ProcessDefinition, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching process definitions, ignoring Limit and Offset
+func (q *ProcessDefinitionQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	definitions, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(definitions)), nil
+}
+
+// SingleResult returns a single process definition or error if not exactly one result
+func (q *ProcessDefinitionQuery) SingleResult(ctx context.Context) (*ProcessDefinition, error) {
+	definitions, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(definitions) != 1 {
+		return nil, fmt.Errorf("expected one process definition, found %d", len(definitions))
+	}
+	return definitions[0], nil
+}
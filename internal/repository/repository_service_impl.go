@@ -0,0 +1,855 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/cache"
+)
+
+// repositoryServiceImpl is the default implementation of RepositoryService
+type repositoryServiceImpl struct {
+	databaseDriver    string
+	databaseURL       string
+	deployments       map[string]*Deployment
+	definitions       map[string]*ProcessDefinition
+	subscriptions     map[string][]*EventSubscription // processDefinitionID -> start event subscriptions
+	templates         *model.TemplateRegistry
+	processModels     *cache.LRU[string, *model.ProcessModel] // processDefinitionID -> parsed model
+	suspensionHandler SuspensionHandler
+	store             *persistence.Store
+	ownsStore         bool
+	clock             clock.Clock
+	mu                sync.RWMutex
+}
+
+// DefaultProcessDefinitionCacheLimit is the number of parsed process models the
+// repository service keeps in its cache by default
+const DefaultProcessDefinitionCacheLimit = 1000
+
+// NewRepositoryService creates a new repository service
+func NewRepositoryService(databaseDriver, databaseURL string) RepositoryService {
+	return &repositoryServiceImpl{
+		databaseDriver: databaseDriver,
+		databaseURL:    databaseURL,
+		deployments:    make(map[string]*Deployment),
+		definitions:    make(map[string]*ProcessDefinition),
+		subscriptions:  make(map[string][]*EventSubscription),
+		templates:      model.NewTemplateRegistry(),
+		processModels:  cache.NewLRU[string, *model.ProcessModel](DefaultProcessDefinitionCacheLimit),
+		clock:          clock.System(),
+	}
+}
+
+// SetClock sets the clock deployment times and scheduled suspensions are based on
+func (s *repositoryServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Initialize initializes the repository service
+func (s *repositoryServiceImpl) Initialize(ctx context.Context) error {
+	// Open an own store when configured with a database URL and no store was set
+	if s.store == nil && s.databaseURL != "" {
+		store, err := persistence.Open(s.databaseDriver, s.databaseURL)
+		if err != nil {
+			return err
+		}
+		s.store = store
+		s.ownsStore = true
+	}
+	if s.store == nil {
+		return nil
+	}
+
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+	return s.load(ctx)
+}
+
+// Shutdown gracefully shuts down the repository service
+func (s *repositoryServiceImpl) Shutdown(ctx context.Context) error {
+	if s.ownsStore {
+		return s.store.Close()
+	}
+	return nil
+}
+
+// SetStore sets the store deployments are persisted to
+func (s *repositoryServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// CreateDeployment creates a new deployment builder
+func (s *repositoryServiceImpl) CreateDeployment() *DeploymentBuilder {
+	return &DeploymentBuilder{
+		service:   s,
+		resources: make([]*Resource, 0),
+	}
+}
+
+// GetDeployment retrieves a deployment by ID
+func (s *repositoryServiceImpl) GetDeployment(ctx context.Context, deploymentID string) (*Deployment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deployment, exists := s.deployments[deploymentID]
+	if !exists {
+		return nil, fmt.Errorf("deployment %w: %s", errors.ErrNotFound, deploymentID)
+	}
+	return deployment, nil
+}
+
+// DeleteDeployment deletes a deployment
+func (s *repositoryServiceImpl) DeleteDeployment(ctx context.Context, deploymentID string, cascade bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deployment, exists := s.deployments[deploymentID]
+	if !exists {
+		return fmt.Errorf("deployment %w: %s", errors.ErrNotFound, deploymentID)
+	}
+
+	deletedDefinitions := make([]string, 0)
+	for id, def := range s.definitions {
+		if def.DeploymentID != deploymentID {
+			continue
+		}
+		// The process models of the deployment are gone, even if its definitions are kept
+		s.processModels.Remove(id)
+		if cascade {
+			// Delete all process definitions related to this deployment
+			delete(s.definitions, id)
+			delete(s.subscriptions, id)
+			deletedDefinitions = append(deletedDefinitions, id)
+		}
+	}
+
+	delete(s.deployments, deploymentID)
+
+	if s.store != nil {
+		if err := s.store.Delete(ctx, persistence.TableProcessDefinitions, deletedDefinitions...); err != nil {
+			return err
+		}
+		if err := s.store.Delete(ctx, persistence.TableDeployments, deploymentID); err != nil {
+			return err
+		}
+	}
+	return s.deleteResourceContentsLocked(ctx, deployment)
+}
+
+// CreateProcessDefinitionQuery creates a new process definition query
+func (s *repositoryServiceImpl) CreateProcessDefinitionQuery() *ProcessDefinitionQuery {
+	return &ProcessDefinitionQuery{
+		service: s,
+	}
+}
+
+// GetProcessDefinition retrieves a process definition by ID
+func (s *repositoryServiceImpl) GetProcessDefinition(ctx context.Context, processDefinitionID string) (*ProcessDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	def, exists := s.definitions[processDefinitionID]
+	if !exists {
+		return nil, fmt.Errorf("process definition %w: %s", errors.ErrNotFound, processDefinitionID)
+	}
+	return def, nil
+}
+
+// GetProcessDefinitionByKey retrieves the latest version of a process definition by key.
+// Versions are numbered per tenant; with a tenant context the definitions of its tenants
+// take precedence over those without tenant, without one the definitions without tenant
+// take precedence. A key deployed for several such tenants is ambiguous.
+func (s *repositoryServiceImpl) GetProcessDefinitionByKey(ctx context.Context, key string) (*ProcessDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	latestByTenant := make(map[string]*ProcessDefinition)
+	for _, def := range s.definitions {
+		if def.Key != key || !identity.IsTenantAccessible(ctx, def.TenantID) {
+			continue
+		}
+		if latest := latestByTenant[def.TenantID]; latest == nil || def.Version > latest.Version {
+			latestByTenant[def.TenantID] = def
+		}
+	}
+
+	if len(latestByTenant) > 1 {
+		_, tenantContext := identity.GetTenantIDs(ctx)
+		shared, hasShared := latestByTenant[""]
+		if !tenantContext && hasShared {
+			return shared, nil
+		}
+		delete(latestByTenant, "")
+	}
+	switch len(latestByTenant) {
+	case 0:
+		return nil, fmt.Errorf("process definition %w with key: %s", errors.ErrNotFound, key)
+	case 1:
+		for _, def := range latestByTenant {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("process definition key '%s' is deployed for several tenants, specify the tenant ID", key)
+}
+
+// GetProcessDefinitionByKeyAndTenantID retrieves the latest version of a process definition
+// by key deployed for a tenant, or without tenant for an empty tenantID
+func (s *repositoryServiceImpl) GetProcessDefinitionByKeyAndTenantID(ctx context.Context, key, tenantID string) (*ProcessDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latestDef *ProcessDefinition
+	if identity.IsTenantAccessible(ctx, tenantID) {
+		for _, def := range s.definitions {
+			if def.Key == key && def.TenantID == tenantID && (latestDef == nil || def.Version > latestDef.Version) {
+				latestDef = def
+			}
+		}
+	}
+
+	if latestDef == nil {
+		return nil, fmt.Errorf("process definition %w with key: %s and tenant ID: %s", errors.ErrNotFound, key, tenantID)
+	}
+	return latestDef, nil
+}
+
+// SuspendProcessDefinition suspends a process definition
+func (s *repositoryServiceImpl) SuspendProcessDefinition(ctx context.Context, processDefinitionID string, options ...SuspensionOption) error {
+	return s.setProcessDefinitionSuspended(ctx, processDefinitionID, true, options)
+}
+
+// ActivateProcessDefinition activates a suspended process definition
+func (s *repositoryServiceImpl) ActivateProcessDefinition(ctx context.Context, processDefinitionID string, options ...SuspensionOption) error {
+	return s.setProcessDefinitionSuspended(ctx, processDefinitionID, false, options)
+}
+
+// SetSuspensionHandler sets the handler of suspensions that include process instances or are scheduled
+func (s *repositoryServiceImpl) SetSuspensionHandler(handler SuspensionHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.suspensionHandler = handler
+}
+
+// setProcessDefinitionSuspended suspends or activates a process definition, schedules
+// it at the execution date of the options or applies it to the process instances
+func (s *repositoryServiceImpl) setProcessDefinitionSuspended(ctx context.Context, processDefinitionID string, suspended bool, options []SuspensionOption) error {
+	o := &suspensionOptions{}
+	for _, option := range options {
+		option(o)
+	}
+
+	s.mu.Lock()
+	def, exists := s.definitions[processDefinitionID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("process definition %w: %s", errors.ErrNotFound, processDefinitionID)
+	}
+	handler := s.suspensionHandler
+	scheduled := o.executionDate.After(s.clock.Now())
+	if handler == nil && (scheduled || o.includeProcessInstances) {
+		s.mu.Unlock()
+		return fmt.Errorf("suspending process instances or at an execution date requires a runtime service")
+	}
+	if scheduled {
+		s.mu.Unlock()
+		return handler.ScheduleProcessDefinitionSuspension(ctx, processDefinitionID, suspended, o.includeProcessInstances, o.executionDate)
+	}
+
+	def.Suspended = suspended
+	s.processModels.Remove(processDefinitionID)
+	err := s.saveProcessDefinitionLocked(ctx, def)
+	s.mu.Unlock()
+	if err != nil || !o.includeProcessInstances {
+		return err
+	}
+	return handler.SetProcessInstancesSuspended(ctx, processDefinitionID, suspended)
+}
+
+// GetProcessModel retrieves the process model for a process definition
+func (s *repositoryServiceImpl) GetProcessModel(ctx context.Context, processDefinitionID string) ([]byte, error) {
+	s.mu.RLock()
+	def, exists := s.definitions[processDefinitionID]
+	if !exists {
+		s.mu.RUnlock()
+		return nil, fmt.Errorf("process definition %w: %s", errors.ErrNotFound, processDefinitionID)
+	}
+
+	deployment, exists := s.deployments[def.DeploymentID]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("deployment %w: %s", errors.ErrNotFound, def.DeploymentID)
+	}
+
+	// Find the resource with the matching name
+	for _, resource := range deployment.Resources {
+		if resource.Name == def.ResourceName {
+			return processModelContent(resource)
+		}
+	}
+
+	return nil, fmt.Errorf("resource %w: %s", errors.ErrNotFound, def.ResourceName)
+}
+
+// GetParsedProcessModel returns the parsed process model of a process definition from
+// the cache, parsing and caching it on a miss
+func (s *repositoryServiceImpl) GetParsedProcessModel(ctx context.Context, processDefinitionID string) (*model.ProcessModel, error) {
+	if processModel, cached := s.processModels.Get(processDefinitionID); cached {
+		return processModel, nil
+	}
+
+	content, err := s.GetProcessModel(ctx, processDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	processModel, err := model.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse process model: %w", err)
+	}
+
+	s.processModels.Put(processDefinitionID, processModel)
+	return processModel, nil
+}
+
+// SetProcessDefinitionCacheLimit sets the maximum number of cached process models
+func (s *repositoryServiceImpl) SetProcessDefinitionCacheLimit(limit int) {
+	s.processModels.SetCapacity(limit)
+}
+
+// GetProcessDefinitionCacheStats returns the counters of the cache of process models
+func (s *repositoryServiceImpl) GetProcessDefinitionCacheStats() cache.Stats {
+	return s.processModels.Stats()
+}
+
+// GetProcessModelBPMN returns the process model of a process definition as BPMN 2.0 XML
+func (s *repositoryServiceImpl) GetProcessModelBPMN(ctx context.Context, processDefinitionID string) ([]byte, error) {
+	s.mu.RLock()
+	def, exists := s.definitions[processDefinitionID]
+	if !exists {
+		s.mu.RUnlock()
+		return nil, fmt.Errorf("process definition %w: %s", errors.ErrNotFound, processDefinitionID)
+	}
+	deployment, exists := s.deployments[def.DeploymentID]
+	s.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("deployment %w: %s", errors.ErrNotFound, def.DeploymentID)
+	}
+
+	for _, resource := range deployment.Resources {
+		if resource.Name != def.ResourceName {
+			continue
+		}
+		if isBPMNResource(resource) {
+			return resource.Content, nil
+		}
+		processModel, err := model.Parse(resource.Content)
+		if err != nil {
+			return nil, err
+		}
+		return processModel.MarshalBPMN()
+	}
+	return nil, fmt.Errorf("resource %w: %s", errors.ErrNotFound, def.ResourceName)
+}
+
+// GetProcessDiagram renders the process model of a process definition as SVG diagram
+func (s *repositoryServiceImpl) GetProcessDiagram(ctx context.Context, processDefinitionID string) ([]byte, error) {
+	processModel, err := s.GetParsedProcessModel(ctx, processDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	return processModel.RenderSVG(), nil
+}
+
+// GetDeploymentResourceNames returns the names of the resources of a deployment
+func (s *repositoryServiceImpl) GetDeploymentResourceNames(ctx context.Context, deploymentID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deployment, exists := s.deployments[deploymentID]
+	if !exists {
+		return nil, fmt.Errorf("deployment %w: %s", errors.ErrNotFound, deploymentID)
+	}
+
+	names := make([]string, len(deployment.Resources))
+	for i, resource := range deployment.Resources {
+		names[i] = resource.Name
+	}
+	return names, nil
+}
+
+// GetResource returns a deployment resource without its content and a reader of the
+// content. Stored resources are read chunk by chunk from the store, the others from the
+// content the deployment holds in memory.
+func (s *repositoryServiceImpl) GetResource(ctx context.Context, deploymentID, resourceName string) (*Resource, io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deployment, exists := s.deployments[deploymentID]
+	if !exists {
+		return nil, nil, fmt.Errorf("deployment %w: %s", errors.ErrNotFound, deploymentID)
+	}
+
+	for _, resource := range deployment.Resources {
+		if resource.Name != resourceName {
+			continue
+		}
+		metadata := *resource
+		metadata.Content = nil
+		if resource.Content == nil && resource.Size > 0 {
+			return &metadata, &resourceReader{ctx: ctx, store: s.store, resource: resource}, nil
+		}
+		// Deployments stored before resources had a size hold their content
+		metadata.Size = int64(len(resource.Content))
+		return &metadata, io.NopCloser(bytes.NewReader(resource.Content)), nil
+	}
+
+	return nil, nil, fmt.Errorf("resource %w: %s", errors.ErrNotFound, resourceName)
+}
+
+// GetResourceAsStream opens the content of a deployment resource for reading
+func (s *repositoryServiceImpl) GetResourceAsStream(ctx context.Context, deploymentID, resourceName string) (io.ReadCloser, error) {
+	_, content, err := s.GetResource(ctx, deploymentID, resourceName)
+	return content, err
+}
+
+// GetFormDefinition retrieves the most recently deployed form definition with a key
+func (s *repositoryServiceImpl) GetFormDefinition(ctx context.Context, formKey string) (*model.FormDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *model.FormDefinition
+	var latestDeployTime time.Time
+	for _, deployment := range s.deployments {
+		if latest != nil && !deployment.DeployTime.After(latestDeployTime) {
+			continue
+		}
+		for _, resource := range deployment.Resources {
+			if !isFormResource(resource) {
+				continue
+			}
+			form, err := model.ParseForm(resource.Content)
+			if err != nil || form.Key != formKey {
+				continue
+			}
+			latest, latestDeployTime = form, deployment.DeployTime
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("form definition %w: %s", errors.ErrNotFound, formKey)
+	}
+	return latest, nil
+}
+
+// GetDecisionDefinition retrieves the most recently deployed decision table with a key
+func (s *repositoryServiceImpl) GetDecisionDefinition(ctx context.Context, decisionKey string) (*model.DecisionDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var latest *model.DecisionDefinition
+	var latestDeployTime time.Time
+	for _, deployment := range s.deployments {
+		if latest != nil && !deployment.DeployTime.After(latestDeployTime) {
+			continue
+		}
+		for _, resource := range deployment.Resources {
+			if !isDecisionResource(resource) {
+				continue
+			}
+			decision, err := model.ParseDecision(resource.Content)
+			if err != nil || decision.Key != decisionKey {
+				continue
+			}
+			latest, latestDeployTime = decision, deployment.DeployTime
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("decision definition %w: %s", errors.ErrNotFound, decisionKey)
+	}
+	return latest, nil
+}
+
+// ValidateProcessDefinition validates a process definition without deploying it
+func (s *repositoryServiceImpl) ValidateProcessDefinition(ctx context.Context, content []byte) error {
+	// Parse the JSON content
+	var processData map[string]interface{}
+	if err := json.Unmarshal(content, &processData); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	// Validate required fields
+	if _, ok := processData["id"]; !ok {
+		return fmt.Errorf("process definition must have an 'id' field")
+	}
+	if _, ok := processData["name"]; !ok {
+		return fmt.Errorf("process definition must have a 'name' field")
+	}
+	if _, ok := processData["nodes"]; !ok {
+		return fmt.Errorf("process definition must have a 'nodes' field")
+	}
+	if _, ok := processData["edges"]; !ok {
+		return fmt.Errorf("process definition must have an 'edges' field")
+	}
+
+	processModel, err := model.Parse(content)
+	if err != nil {
+		return err
+	}
+	if errs := processModel.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// GetStartEventSubscriptions returns the start event subscriptions of the latest
+// active version of each process definition key
+func (s *repositoryServiceImpl) GetStartEventSubscriptions(ctx context.Context, eventType, eventName string) ([]*EventSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Older versions do not start instances once a newer version is deployed
+	latest := make(map[string]*ProcessDefinition)
+	for _, def := range s.definitions {
+		key := def.TenantID + "/" + def.Key
+		if current, exists := latest[key]; !exists || def.Version > current.Version {
+			latest[key] = def
+		}
+	}
+
+	result := make([]*EventSubscription, 0)
+	for _, def := range latest {
+		if def.Suspended {
+			continue
+		}
+		for _, subscription := range s.subscriptions[def.ID] {
+			if subscription.EventType != eventType {
+				continue
+			}
+			if eventName != "" && subscription.EventName != eventName {
+				continue
+			}
+			result = append(result, subscription)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProcessDefinitionID < result[j].ProcessDefinitionID
+	})
+	return result, nil
+}
+
+// RegisterTemplate registers a process template
+func (s *repositoryServiceImpl) RegisterTemplate(name string, template model.Template) error {
+	return s.templates.Register(name, template)
+}
+
+// expandTemplates replaces template nodes in a process definition with their
+// fragments. Content without template nodes is returned unchanged.
+func (s *repositoryServiceImpl) expandTemplates(content []byte) ([]byte, error) {
+	processModel, err := model.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(processModel.GetNodesByType(model.NodeTypeTemplate)) == 0 {
+		return content, nil
+	}
+
+	if err := s.templates.Expand(processModel); err != nil {
+		return nil, err
+	}
+	return processModel.Marshal()
+}
+
+// deployInternal is called by DeploymentBuilder to execute the deployment
+func (s *repositoryServiceImpl) deployInternal(ctx context.Context, builder *DeploymentBuilder) (*Deployment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if builder.duplicateFiltering {
+		if existing := s.findDuplicateDeploymentLocked(ctx, builder); existing != nil {
+			return existing, nil
+		}
+	}
+
+	// Create deployment
+	deployment := &Deployment{
+		ID:         uuid.New().String(),
+		Name:       builder.name,
+		DeployTime: s.clock.Now(),
+		Category:   builder.category,
+		TenantID:   builder.tenantID,
+		Resources:  make([]*Resource, len(builder.resources)),
+	}
+
+	// Process each resource to create process definitions. The deployment holds copies of
+	// the resources of the builder, as the content of stored resources is dropped from it.
+	definitions := make([]*ProcessDefinition, 0)
+	for i, builderResource := range builder.resources {
+		resource := *builderResource
+		deployment.Resources[i] = &resource
+		resource.ID = uuid.New().String()
+		resource.DeploymentID = deployment.ID
+		resource.Size = int64(len(resource.Content))
+		if resource.ContentType == "" {
+			resource.ContentType = detectContentType(resource.Name, resource.Content)
+		}
+
+		if isFormResource(&resource) {
+			if _, err := model.ParseForm(resource.Content); err != nil {
+				return nil, fmt.Errorf("invalid form definition '%s': %w", resource.Name, err)
+			}
+			continue
+		}
+		if isDecisionResource(&resource) {
+			if _, err := model.ParseDecision(resource.Content); err != nil {
+				return nil, fmt.Errorf("invalid decision definition '%s': %w", resource.Name, err)
+			}
+			continue
+		}
+
+		// BPMN 2.0 XML is converted to the process model and keeps its XML as resource.
+		// Other resources such as diagrams and attachments are stored as they are.
+		var content []byte
+		if isBPMNResource(&resource) {
+			converted, err := processModelContent(&resource)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BPMN process definition '%s': %w", resource.Name, err)
+			}
+			content = converted
+		} else if isProcessDefinitionResource(&resource) {
+			// Expand process templates into the full definition
+			expanded, err := s.expandTemplates(resource.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to expand templates in '%s': %w", resource.Name, err)
+			}
+			resource.Content = expanded
+			resource.Size = int64(len(expanded))
+			content = expanded
+		} else {
+			continue
+		}
+
+		// Parse process definition from JSON
+		var processData map[string]interface{}
+		if err := json.Unmarshal(content, &processData); err != nil {
+			return nil, fmt.Errorf("failed to parse process definition '%s': %w", resource.Name, err)
+		}
+
+		// Validate process definition
+		if err := s.ValidateProcessDefinition(ctx, content); err != nil {
+			return nil, fmt.Errorf("invalid process definition '%s': %w", resource.Name, err)
+		}
+
+		processModel, err := model.Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse process definition '%s': %w", resource.Name, err)
+		}
+		if processModel.HistoryTimeToLive != nil && *processModel.HistoryTimeToLive < 0 {
+			return nil, fmt.Errorf("invalid process definition '%s': history time to live cannot be negative", resource.Name)
+		}
+
+		// Extract process definition details
+		processID, _ := processData["id"].(string)
+		processName, _ := processData["name"].(string)
+		processDesc, _ := processData["description"].(string)
+
+		// Calculate version - find existing versions with the same key
+		version := 1
+		for _, existingDef := range s.definitions {
+			if existingDef.Key == processID && existingDef.Version >= version {
+				version = existingDef.Version + 1
+			}
+		}
+
+		// Create process definition
+		processDefinition := &ProcessDefinition{
+			ID:                  fmt.Sprintf("%s:%d:%s", processID, version, uuid.New().String()),
+			Key:                 processID,
+			Name:                processName,
+			Description:         processDesc,
+			Version:             version,
+			Category:            deployment.Category,
+			DeploymentID:        deployment.ID,
+			ResourceName:        resource.Name,
+			TenantID:            deployment.TenantID,
+			Suspended:           false,
+			HasGraphicalNotation: true,
+			HistoryTimeToLive:   processModel.HistoryTimeToLive,
+		}
+		if startEvent, err := findNoneStartEvent(processModel); err == nil {
+			processDefinition.StartFormKey = startEvent.GetString("formKey")
+			processDefinition.HasStartFormKey = processDefinition.StartFormKey != ""
+		}
+
+		// Register message, signal and conditional start events
+		subscriptions := startEventSubscriptions(processModel, processDefinition, s.clock.Now())
+		if err := validateStartEventSubscriptions(subscriptions); err != nil {
+			return nil, fmt.Errorf("invalid process definition '%s': %w", resource.Name, err)
+		}
+
+		s.definitions[processDefinition.ID] = processDefinition
+		s.subscriptions[processDefinition.ID] = subscriptions
+		definitions = append(definitions, processDefinition)
+	}
+
+	s.deployments[deployment.ID] = deployment
+
+	if err := s.saveDeploymentLocked(ctx, deployment, definitions); err != nil {
+		// Keep memory consistent with the store
+		for _, def := range definitions {
+			delete(s.definitions, def.ID)
+			delete(s.subscriptions, def.ID)
+		}
+		delete(s.deployments, deployment.ID)
+		_ = s.deleteResourceContentsLocked(ctx, deployment)
+		return nil, err
+	}
+	return deployment, nil
+}
+
+// findDuplicateDeploymentLocked returns the latest deployment with the name and tenant
+// of the builder if it holds the same resources with the same content, or nil
+func (s *repositoryServiceImpl) findDuplicateDeploymentLocked(ctx context.Context, builder *DeploymentBuilder) *Deployment {
+	var latest *Deployment
+	for _, deployment := range s.deployments {
+		if deployment.Name != builder.name || deployment.TenantID != builder.tenantID {
+			continue
+		}
+		if latest == nil || deployment.DeployTime.After(latest.DeployTime) {
+			latest = deployment
+		}
+	}
+	if latest == nil || len(latest.Resources) != len(builder.resources) {
+		return nil
+	}
+
+	deployed := make(map[string]*Resource, len(latest.Resources))
+	for _, resource := range latest.Resources {
+		deployed[resource.Name] = resource
+	}
+	for _, resource := range builder.resources {
+		existing, exists := deployed[resource.Name]
+		if !exists || !s.resourceContentEqual(ctx, existing, resource.Content) {
+			return nil
+		}
+	}
+	return latest
+}
+
+// resourceContentEqual reports whether a deployed resource holds the content, reading
+// stored content from the store
+func (s *repositoryServiceImpl) resourceContentEqual(ctx context.Context, resource *Resource, content []byte) bool {
+	if resource.Content != nil || resource.Size == 0 {
+		return bytes.Equal(resource.Content, content)
+	}
+	if resource.Size != int64(len(content)) {
+		return false
+	}
+	for i := 0; i < resourceChunkCount(resource); i++ {
+		chunk, err := s.store.Get(ctx, persistence.TableByteArrays, resourceChunkID(resource, i))
+		if err != nil || !bytes.Equal(chunk, content[i*resourceChunkSize:min((i+1)*resourceChunkSize, len(content))]) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectContentType determines the content type of a resource from its file
+// extension, falling back to sniffing the content
+func detectContentType(name string, content []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	switch ext {
+	case ".json":
+		return "application/json"
+	case ".bpmn", ".xml":
+		return "application/xml"
+	case ".svg":
+		return "image/svg+xml"
+	case ".form":
+		return FormContentType
+	case ".dmn":
+		return DecisionContentType
+	}
+	if ext != "" {
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			return contentType
+		}
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '{' && json.Valid(trimmed) {
+		return "application/json"
+	}
+	return http.DetectContentType(content)
+}
+
+// isFormResource reports whether a resource holds a JSON form definition
+func isFormResource(resource *Resource) bool {
+	mediaType, _, err := mime.ParseMediaType(resource.ContentType)
+	return err == nil && mediaType == FormContentType
+}
+
+// isDecisionResource reports whether a resource holds a JSON decision table
+func isDecisionResource(resource *Resource) bool {
+	mediaType, _, err := mime.ParseMediaType(resource.ContentType)
+	return err == nil && mediaType == DecisionContentType
+}
+
+// findNoneStartEvent returns the top-level start event without event type
+func findNoneStartEvent(processModel *model.ProcessModel) (*model.Node, error) {
+	for _, node := range processModel.GetChildNodes("") {
+		if node.Type == model.NodeTypeStartEvent && node.GetString("eventType") == "" {
+			return node, nil
+		}
+	}
+	return nil, fmt.Errorf("process '%s' has no none start event", processModel.ID)
+}
+
+// isBPMNResource reports whether a resource holds a BPMN 2.0 XML process definition,
+// named "*.bpmn" or "*.bpmn20.xml"
+func isBPMNResource(resource *Resource) bool {
+	name := strings.ToLower(resource.Name)
+	return strings.HasSuffix(name, ".bpmn") || strings.HasSuffix(name, ".bpmn20.xml")
+}
+
+// processModelContent returns the JSON process model of a process definition resource,
+// converting BPMN 2.0 XML
+func processModelContent(resource *Resource) ([]byte, error) {
+	if !isBPMNResource(resource) {
+		return resource.Content, nil
+	}
+	processModel, err := model.ParseBPMN(resource.Content)
+	if err != nil {
+		return nil, err
+	}
+	return processModel.Marshal()
+}
+
+// isProcessDefinitionResource reports whether a resource holds a JSON process definition
+func isProcessDefinitionResource(resource *Resource) bool {
+	mediaType, _, err := mime.ParseMediaType(resource.ContentType)
+	return err == nil && mediaType == "application/json"
+}
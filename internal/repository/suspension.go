@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// SuspensionOption configures the suspension or activation of a process definition
+type SuspensionOption func(*suspensionOptions)
+
+// suspensionOptions are the options of a suspension or activation
+type suspensionOptions struct {
+	includeProcessInstances bool
+	executionDate           time.Time
+}
+
+// IncludeProcessInstances suspends or activates the running process instances of the
+// process definition, and their tasks, together with it
+func IncludeProcessInstances() SuspensionOption {
+	return func(o *suspensionOptions) {
+		o.includeProcessInstances = true
+	}
+}
+
+// ExecutionDate schedules the suspension or activation at a future date, when the job
+// executor applies it. Dates in the past apply it right away.
+func ExecutionDate(date time.Time) SuspensionOption {
+	return func(o *suspensionOptions) {
+		o.executionDate = date
+	}
+}
+
+// SuspensionHandler applies suspensions of process definitions to their process
+// instances and schedules suspensions at a future date. The runtime service sets
+// itself as the handler of the repository service it runs process definitions of.
+type SuspensionHandler interface {
+	// SetProcessInstancesSuspended suspends or activates the running process instances
+	// of a process definition
+	SetProcessInstancesSuspended(ctx context.Context, processDefinitionID string, suspended bool) error
+
+	// ScheduleProcessDefinitionSuspension creates a job suspending or activating a process
+	// definition, and with includeProcessInstances its process instances, at a date
+	ScheduleProcessDefinitionSuspension(ctx context.Context, processDefinitionID string, suspended, includeProcessInstances bool, executionDate time.Time) error
+}
@@ -0,0 +1,591 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+	"github.com/muixstudio/flowgo/tracing"
+)
+
+// ActivityBehavior implements the execution semantics of a node type.
+// Execute is called when an execution arrives at a node; the behavior either
+// leaves the node right away or keeps the execution waiting until it is triggered.
+type ActivityBehavior interface {
+	Execute(ctx context.Context, execution *ActivityExecution) error
+}
+
+// TriggerableActivityBehavior is an activity behavior for wait states that
+// continue when their execution is signaled, e.g. user tasks
+type TriggerableActivityBehavior interface {
+	ActivityBehavior
+
+	// Trigger is called when a waiting execution is signaled
+	Trigger(ctx context.Context, execution *ActivityExecution, variables map[string]interface{}) error
+}
+
+// CancelableActivityBehavior is an activity behavior that cleans up when the activity
+// of an execution is cancelled, e.g. by an interrupting boundary event
+type CancelableActivityBehavior interface {
+	ActivityBehavior
+
+	// Cancel is called when the activity of a waiting execution is cancelled
+	Cancel(ctx context.Context, execution *ActivityExecution) error
+}
+
+// SuspendableActivityBehavior is an activity behavior that follows the suspension of
+// the process instance an execution waits in its activity, e.g. to suspend the tasks
+// it created
+type SuspendableActivityBehavior interface {
+	ActivityBehavior
+
+	// SetSuspended is called when the process instance of a waiting execution is
+	// suspended or activated
+	SetSuspended(ctx context.Context, execution *ActivityExecution, suspended bool) error
+}
+
+// waitingBehavior is a triggerable activity behavior whose nodes are wait states only
+// in some configurations, e.g. intermediate events that catch rather than throw
+type waitingBehavior interface {
+	TriggerableActivityBehavior
+
+	// waitsAt reports whether executions arriving at the node wait to be triggered
+	waitsAt(node *model.Node) bool
+}
+
+// ServiceTaskDelegate implements the logic of a service task.
+// Service tasks reference delegates by name through their "implementation" property.
+// A delegate that also implements TriggerableActivityBehavior makes the service task
+// a wait state, e.g. for work done by external workers.
+type ServiceTaskDelegate interface {
+	Execute(ctx context.Context, execution *ActivityExecution) error
+}
+
+// ServiceTaskFunc adapts a function to the ServiceTaskDelegate interface
+type ServiceTaskFunc func(ctx context.Context, execution *ActivityExecution) error
+
+// Execute calls f(ctx, execution)
+func (f ServiceTaskFunc) Execute(ctx context.Context, execution *ActivityExecution) error {
+	return f(ctx, execution)
+}
+
+// ActivityExecution is the view of an execution at a node that activity behaviors
+// and service task delegates work with
+type ActivityExecution struct {
+	Execution       *Execution
+	ProcessInstance *ProcessInstance
+	Node            *model.Node
+	Model           *model.ProcessModel
+
+	service *runtimeServiceImpl
+	agenda  *agenda
+}
+
+// GetVariable returns a variable visible to the execution, or nil if it is not set
+func (e *ActivityExecution) GetVariable(name string) interface{} {
+	return e.GetVariables()[name]
+}
+
+// GetVariables returns a copy of all variables visible to the execution
+func (e *ActivityExecution) GetVariables() map[string]interface{} {
+	e.service.mu.RLock()
+	defer e.service.mu.RUnlock()
+
+	variables, err := e.service.getVariablesLocked(e.Execution.ID)
+	if err != nil {
+		return make(map[string]interface{})
+	}
+	return variables
+}
+
+// SetVariable sets a variable. It is updated in the nearest scope that
+// defines it and otherwise created on the process instance.
+func (e *ActivityExecution) SetVariable(name string, value interface{}) {
+	e.SetVariables(map[string]interface{}{name: value})
+}
+
+// SetVariables sets multiple variables like SetVariable
+func (e *ActivityExecution) SetVariables(variables map[string]interface{}) {
+	e.service.mu.Lock()
+	defer e.service.mu.Unlock()
+
+	_ = e.service.setVariablesVisibleLocked(e.Execution.ID, variables)
+}
+
+// SetVariableLocal sets a variable in the nearest scope of the execution,
+// e.g. the enclosing subprocess
+func (e *ActivityExecution) SetVariableLocal(name string, value interface{}) {
+	e.service.mu.Lock()
+	defer e.service.mu.Unlock()
+
+	if chain, err := e.service.scopeChainLocked(e.Execution.ID); err == nil {
+		e.service.setVariablesLocked(e.Execution.ID, chain[0], map[string]interface{}{name: value})
+	}
+}
+
+// GetVariablesLocal returns a copy of the variables of the nearest scope of the execution
+func (e *ActivityExecution) GetVariablesLocal() map[string]interface{} {
+	e.service.mu.RLock()
+	defer e.service.mu.RUnlock()
+
+	chain, err := e.service.scopeChainLocked(e.Execution.ID)
+	if err != nil {
+		return make(map[string]interface{})
+	}
+	variables, err := e.service.contents.ResolveAll(context.Background(), e.service.variables[chain[0]])
+	if err != nil {
+		return make(map[string]interface{})
+	}
+	return variables
+}
+
+// ExpressionManager returns the manager that evaluates expressions with the custom functions of the engine
+func (e *ActivityExecution) ExpressionManager() *expression.Manager {
+	return e.service.expressions
+}
+
+// OutgoingEdges returns the sequence flows leaving the node
+func (e *ActivityExecution) OutgoingEdges() []*model.Edge {
+	return e.Model.GetOutgoingEdges(e.Node.ID)
+}
+
+// IncomingEdges returns the sequence flows entering the node
+func (e *ActivityExecution) IncomingEdges() []*model.Edge {
+	return e.Model.GetIncomingEdges(e.Node.ID)
+}
+
+// EvaluateCondition evaluates the condition of a sequence flow against the
+// process variables. Flows without a condition always evaluate to true.
+func (e *ActivityExecution) EvaluateCondition(edge *model.Edge) (bool, error) {
+	if edge.Condition == "" {
+		return true, nil
+	}
+	return e.service.evaluateCondition(edge.Condition, e.GetVariables())
+}
+
+// Leave continues the execution along every outgoing sequence flow whose
+// condition holds, falling back to the default flows when none does.
+// Leaving along several flows forks the execution into concurrent paths.
+func (e *ActivityExecution) Leave() error {
+	outgoing := e.OutgoingEdges()
+	if len(outgoing) == 0 {
+		e.End()
+		return nil
+	}
+
+	selected := make([]*model.Edge, 0, len(outgoing))
+	defaults := make([]*model.Edge, 0)
+	for _, edge := range outgoing {
+		if edge.IsDefault {
+			defaults = append(defaults, edge)
+			continue
+		}
+		ok, err := e.EvaluateCondition(edge)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate condition of sequence flow '%s': %w", edge.ID, err)
+		}
+		if ok {
+			selected = append(selected, edge)
+		}
+	}
+
+	if len(selected) == 0 {
+		selected = defaults
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no outgoing sequence flow of node '%s' can be taken", e.Node.ID)
+	}
+
+	e.Take(selected...)
+	return nil
+}
+
+// Take continues the execution along the given sequence flows without evaluating their conditions
+func (e *ActivityExecution) Take(edges ...*model.Edge) {
+	e.agenda.plan(func(ctx context.Context) error {
+		return e.service.takeEdges(ctx, e, edges)
+	})
+}
+
+// End ends the path of the execution. The process instance ends when its last path ends.
+func (e *ActivityExecution) End() {
+	e.agenda.plan(func(ctx context.Context) error {
+		return e.service.endExecution(ctx, e)
+	})
+}
+
+// passThroughBehavior leaves a node immediately, e.g. for start events
+type passThroughBehavior struct{}
+
+// Execute leaves the node
+func (b *passThroughBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	return execution.Leave()
+}
+
+// endEventBehavior ends the path that reaches the end event.
+// Error end events throw a business error with the code of their event definition;
+// compensation end events compensate the scope before the path ends; terminate end
+// events end all other paths of the scope as well.
+type endEventBehavior struct{}
+
+// Execute ends the path or throws the event
+func (b *endEventBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	switch execution.Node.GetString("eventType") {
+	case "error":
+		code, _ := execution.Node.GetMap("eventDefinition")["errorCode"].(string)
+		return NewBusinessError(code, "")
+	case eventTypeCompensation:
+		return execution.service.throwCompensation(ctx, execution)
+	case "signal":
+		if err := execution.service.throwSignal(ctx, execution); err != nil {
+			return err
+		}
+	case "terminate":
+		return execution.service.terminate(ctx, execution)
+	}
+	execution.End()
+	return nil
+}
+
+// intermediateEventBehavior executes intermediate events. Events without event
+// type pass through; timer, message, signal and conditional catch events wait for their
+// timer, message, signal or condition, or until their execution is signaled; signal throw
+// events ("isThrowing") broadcast their signal and pass through; compensation throw events
+// wait for the compensation handlers.
+type intermediateEventBehavior struct{}
+
+// Execute throws the event, or waits for it to occur
+func (b *intermediateEventBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	switch eventType := execution.Node.GetString("eventType"); eventType {
+	case "":
+		return execution.Leave()
+	case "timer":
+		s := execution.service
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		variables, err := s.getVariablesLocked(execution.Execution.ID)
+		if err != nil {
+			return err
+		}
+		return s.createTimerLocked(execution.Execution, execution.Node, variables)
+	case "message":
+		s := execution.service
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return s.createSubscriptionLocked(execution.Execution, execution.Node)
+	case "signal":
+		if execution.Node.GetBool("isThrowing") {
+			if err := execution.service.throwSignal(ctx, execution); err != nil {
+				return err
+			}
+			return execution.Leave()
+		}
+
+		s := execution.service
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return s.createSubscriptionLocked(execution.Execution, execution.Node)
+	case "conditional":
+		s := execution.service
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return s.createConditionalSubscriptionLocked(execution.Execution, execution.Node)
+	case eventTypeCompensation:
+		return execution.service.throwCompensation(ctx, execution)
+	default:
+		return fmt.Errorf("unsupported event type '%s' of intermediate event '%s'", eventType, execution.Node.ID)
+	}
+}
+
+// Trigger continues a catch event as if its timer, message, signal or condition occurred.
+// Its timers and subscriptions are removed when the execution leaves it.
+func (b *intermediateEventBehavior) Trigger(ctx context.Context, execution *ActivityExecution, variables map[string]interface{}) error {
+	return execution.Leave()
+}
+
+// waitsAt reports whether the intermediate event is a catch event
+func (b *intermediateEventBehavior) waitsAt(node *model.Node) bool {
+	switch node.GetString("eventType") {
+	case "timer", "message", "conditional":
+		return true
+	case "signal":
+		return !node.GetBool("isThrowing")
+	}
+	return false
+}
+
+// waitStateBehavior keeps the execution waiting until it is signaled.
+// It is the default behavior of user tasks when no task service is attached.
+type waitStateBehavior struct{}
+
+// Execute keeps the execution waiting
+func (b *waitStateBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	return nil
+}
+
+// Trigger leaves the node
+func (b *waitStateBehavior) Trigger(ctx context.Context, execution *ActivityExecution, variables map[string]interface{}) error {
+	return execution.Leave()
+}
+
+// serviceTaskBehavior invokes the delegate named by the "implementation" property, or
+// else by the "type" property, e.g. "external". Delegates that are triggerable activity
+// behaviors keep the execution waiting until it is signaled; others leave the node.
+type serviceTaskBehavior struct {
+	service *runtimeServiceImpl
+}
+
+// Execute runs the delegate in a span and leaves the node
+func (b *serviceTaskBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	delegate, err := b.delegate(execution.Node)
+	if err != nil {
+		return err
+	}
+
+	b.service.mu.RLock()
+	tracer := b.service.tracer
+	b.service.mu.RUnlock()
+	spanCtx, span := tracer.Start(ctx, "ServiceTask "+execution.Node.ID,
+		tracing.String(tracing.AttributeActivityID, execution.Node.ID),
+		tracing.String(tracing.AttributeServiceImplementation, serviceTaskImplementation(execution.Node)),
+		tracing.String(tracing.AttributeProcessDefinitionID, execution.ProcessInstance.ProcessDefinitionID),
+		tracing.String(tracing.AttributeProcessDefinitionKey, execution.ProcessInstance.ProcessDefinitionKey),
+		tracing.String(tracing.AttributeProcessInstanceID, execution.ProcessInstance.ID),
+	)
+	err = delegate.Execute(spanCtx, execution)
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+	if err != nil {
+		return fmt.Errorf("service task '%s' failed: %w", execution.Node.ID, err)
+	}
+	if _, waits := delegate.(TriggerableActivityBehavior); waits {
+		return nil
+	}
+	return execution.Leave()
+}
+
+// Trigger continues a service task whose delegate is a wait state
+func (b *serviceTaskBehavior) Trigger(ctx context.Context, execution *ActivityExecution, variables map[string]interface{}) error {
+	delegate, err := b.delegate(execution.Node)
+	if err != nil {
+		return err
+	}
+
+	behavior, triggerable := delegate.(TriggerableActivityBehavior)
+	if !triggerable {
+		return fmt.Errorf("service task '%s' cannot be signaled", execution.Node.ID)
+	}
+	return behavior.Trigger(ctx, execution, variables)
+}
+
+// waitsAt reports whether the delegate of the service task is a wait state
+func (b *serviceTaskBehavior) waitsAt(node *model.Node) bool {
+	delegate, err := b.delegate(node)
+	if err != nil {
+		return false
+	}
+	_, waits := delegate.(TriggerableActivityBehavior)
+	return waits
+}
+
+// Cancel cleans up the delegate of a cancelled service task
+func (b *serviceTaskBehavior) Cancel(ctx context.Context, execution *ActivityExecution) error {
+	delegate, err := b.delegate(execution.Node)
+	if err != nil {
+		return nil
+	}
+
+	if behavior, cancelable := delegate.(CancelableActivityBehavior); cancelable {
+		return behavior.Cancel(ctx, execution)
+	}
+	return nil
+}
+
+// serviceTaskImplementation returns the name of the delegate a service task references
+func serviceTaskImplementation(node *model.Node) string {
+	if name := node.GetString("implementation"); name != "" {
+		return name
+	}
+	return node.GetString("type")
+}
+
+// delegate returns the delegate a service task references
+func (b *serviceTaskBehavior) delegate(node *model.Node) (ServiceTaskDelegate, error) {
+	name := serviceTaskImplementation(node)
+	if name == "" {
+		return nil, fmt.Errorf("service task '%s' must have an 'implementation' property", node.ID)
+	}
+
+	b.service.mu.RLock()
+	delegate, exists := b.service.delegates[name]
+	b.service.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("service task '%s' references unknown implementation '%s'", node.ID, name)
+	}
+	return delegate, nil
+}
+
+// parallelGatewayBehavior forks into all outgoing flows and joins all incoming flows
+type parallelGatewayBehavior struct{}
+
+// Execute waits for all incoming paths and then forks into all outgoing flows
+func (b *parallelGatewayBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	joined, err := execution.service.join(execution)
+	if err != nil || !joined {
+		return err
+	}
+
+	// Conditions on the outgoing flows of a parallel gateway are ignored
+	outgoing := execution.OutgoingEdges()
+	if len(outgoing) == 0 {
+		execution.End()
+		return nil
+	}
+	execution.Take(outgoing...)
+	return nil
+}
+
+// exclusiveGatewayBehavior takes exactly one outgoing flow: the first one, in
+// definition order, whose condition holds, or else the default flow
+type exclusiveGatewayBehavior struct{}
+
+// Execute selects and takes the outgoing flow
+func (b *exclusiveGatewayBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	var defaultEdge *model.Edge
+	for _, edge := range execution.OutgoingEdges() {
+		if edge.IsDefault {
+			if defaultEdge == nil {
+				defaultEdge = edge
+			}
+			continue
+		}
+
+		ok, err := execution.EvaluateCondition(edge)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate condition of sequence flow '%s': %w", edge.ID, err)
+		}
+		if ok {
+			execution.Take(edge)
+			return nil
+		}
+	}
+
+	if defaultEdge == nil {
+		return fmt.Errorf("no outgoing sequence flow of exclusive gateway '%s' matches and there is no default flow", execution.Node.ID)
+	}
+	execution.Take(defaultEdge)
+	return nil
+}
+
+// subProcessBehavior runs the nodes inside an embedded subprocess in a variable
+// scope of its own. Input mappings initialize the local variables; output mappings
+// copy results to the enclosing scope when the subprocess completes.
+type subProcessBehavior struct{}
+
+// Execute enters the subprocess at its none start event
+func (b *subProcessBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	start, err := findNoneStartEvent(execution.Model, execution.Node.ID)
+	if err != nil {
+		return err
+	}
+
+	locals, err := execution.service.evaluateMappings(execution.Node.InputMappings, execution.GetVariables())
+	if err != nil {
+		return fmt.Errorf("subprocess '%s': %w", execution.Node.ID, err)
+	}
+
+	return execution.service.enterScope(ctx, execution, start, locals)
+}
+
+// callActivityBehavior starts the latest version of the process definition named by
+// the "calledElement" property as a child process instance. The execution waits
+// until the child instance ends and then leaves the call activity.
+type callActivityBehavior struct {
+	service *runtimeServiceImpl
+}
+
+// Execute starts the called process instance
+func (b *callActivityBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	node := execution.Node
+	variables := execution.GetVariables()
+
+	calledElement := node.GetString("calledElement")
+	if expression.IsExpression(calledElement) {
+		value, err := b.service.expressions.Evaluate(calledElement, variables)
+		if err != nil {
+			return fmt.Errorf("invalid called element of call activity '%s': %w", node.ID, err)
+		}
+		calledElement = fmt.Sprintf("%v", value)
+	}
+	if calledElement == "" {
+		return fmt.Errorf("call activity '%s' must have a 'calledElement' property", node.ID)
+	}
+
+	// The called process is resolved in the tenant of the calling one, falling back to
+	// definitions without tenant
+	var processDefinition *repository.ProcessDefinition
+	var err error
+	if tenantID := execution.ProcessInstance.TenantID; tenantID != "" {
+		processDefinition, err = b.service.repositoryService.GetProcessDefinitionByKeyAndTenantID(ctx, calledElement, tenantID)
+		if err != nil {
+			processDefinition, err = b.service.repositoryService.GetProcessDefinitionByKeyAndTenantID(ctx, calledElement, "")
+		}
+	} else {
+		processDefinition, err = b.service.repositoryService.GetProcessDefinitionByKey(ctx, calledElement)
+	}
+	if err != nil {
+		return fmt.Errorf("call activity '%s': %w", node.ID, err)
+	}
+
+	inputs, err := b.service.evaluateMappings(node.InputMappings, variables)
+	if err != nil {
+		return fmt.Errorf("call activity '%s': %w", node.ID, err)
+	}
+	if node.GetBool("inheritVariables") {
+		for k, v := range variables {
+			if _, mapped := inputs[k]; !mapped {
+				inputs[k] = v
+			}
+		}
+	}
+
+	businessKey := ""
+	if node.GetBool("inheritBusinessKey") {
+		businessKey = execution.ProcessInstance.BusinessKey
+	}
+
+	_, err = b.service.createProcessInstance(ctx, execution.agenda, processDefinition, processInstanceStart{
+		businessKey:    businessKey,
+		variables:      inputs,
+		superExecution: execution,
+	})
+	return err
+}
+
+// Cancel deletes the process instances started by the call activity
+func (b *callActivityBehavior) Cancel(ctx context.Context, execution *ActivityExecution) error {
+	b.service.mu.RLock()
+	called := make([]*ProcessInstance, 0)
+	for _, processInstance := range b.service.processInstances {
+		if processInstance.SuperExecutionID == execution.Execution.ID && processInstance.EndTime == nil {
+			called = append(called, processInstance)
+		}
+	}
+	b.service.mu.RUnlock()
+
+	for _, processInstance := range called {
+		if err := b.service.deleteProcessInstance(ctx, execution.agenda, processInstance, DeleteReasonCanceled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
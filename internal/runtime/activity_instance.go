@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// ActivityInstance is an activity a process instance is currently at. The root is
+// the process instance itself; subprocesses contain the activity instances running
+// inside them. An activity instance is identified by the ID of its execution, as in
+// ProcessInstanceModificationBuilder.CancelActivityInstance.
+type ActivityInstance struct {
+	ID                       string
+	ParentActivityInstanceID string
+	ActivityID               string // the process definition ID for the root
+	ActivityName             string
+	ActivityType             string // node type, e.g. model.NodeTypeUserTask; "processDefinition" for the root
+	ProcessInstanceID        string
+	ProcessDefinitionID      string
+	ExecutionIDs             []string
+	ChildActivityInstances   []*ActivityInstance
+}
+
+// ActivityTypeProcessDefinition is the activity type of the root activity instance
+const ActivityTypeProcessDefinition = "processDefinition"
+
+// GetActivityInstance returns the tree of the activity instances a process instance is currently at
+func (s *runtimeServiceImpl) GetActivityInstance(ctx context.Context, processInstanceID string) (*ActivityInstance, error) {
+	s.mu.RLock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	var executions []*Execution
+	if exists {
+		for _, execution := range s.executions {
+			if execution.ProcessInstanceID == processInstanceID && !execution.IsEventScope {
+				copied := *execution
+				executions = append(executions, &copied)
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	return buildActivityInstanceTree(processInstance, processModel, executions), nil
+}
+
+// buildActivityInstanceTree arranges the executions of a process instance at an
+// activity as activity instances below the activity instance of the closest enclosing
+// execution at an activity, e.g. a subprocess, or below the root
+func buildActivityInstanceTree(processInstance *ProcessInstance, processModel *model.ProcessModel, executions []*Execution) *ActivityInstance {
+	root := &ActivityInstance{
+		ID:                  processInstance.ID,
+		ActivityID:          processInstance.ProcessDefinitionID,
+		ActivityName:        processInstance.ProcessDefinitionName,
+		ActivityType:        ActivityTypeProcessDefinition,
+		ProcessInstanceID:   processInstance.ID,
+		ProcessDefinitionID: processInstance.ProcessDefinitionID,
+		ExecutionIDs:        []string{processInstance.ID},
+	}
+
+	byID := make(map[string]*Execution, len(executions))
+	instances := map[string]*ActivityInstance{processInstance.ID: root}
+	for _, execution := range executions {
+		byID[execution.ID] = execution
+		if execution.ActivityID == "" || execution.ID == processInstance.ID {
+			continue
+		}
+		instance := &ActivityInstance{
+			ID:                  execution.ID,
+			ActivityID:          execution.ActivityID,
+			ProcessInstanceID:   processInstance.ID,
+			ProcessDefinitionID: processInstance.ProcessDefinitionID,
+			ExecutionIDs:        []string{execution.ID},
+		}
+		if node := processModel.GetNode(execution.ActivityID); node != nil {
+			instance.ActivityName = node.Name
+			instance.ActivityType = node.Type
+		}
+		instances[execution.ID] = instance
+	}
+
+	for _, execution := range executions {
+		instance, exists := instances[execution.ID]
+		if !exists || instance == root {
+			continue
+		}
+		parent := root
+		for parentID := execution.ParentID; parentID != ""; parentID = byID[parentID].ParentID {
+			if enclosing, exists := instances[parentID]; exists {
+				parent = enclosing
+				break
+			}
+			if byID[parentID] == nil {
+				break
+			}
+		}
+		instance.ParentActivityInstanceID = parent.ID
+		parent.ChildActivityInstances = append(parent.ChildActivityInstances, instance)
+	}
+
+	sortActivityInstances(root)
+	return root
+}
+
+// sortActivityInstances orders the children of an activity instance tree by activity
+// and ID, so that the tree of an unchanged process instance is always the same
+func sortActivityInstances(instance *ActivityInstance) {
+	children := instance.ChildActivityInstances
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].ActivityID != children[j].ActivityID {
+			return children[i].ActivityID < children[j].ActivityID
+		}
+		return children[i].ID < children[j].ID
+	})
+	for _, child := range children {
+		sortActivityInstances(child)
+	}
+}
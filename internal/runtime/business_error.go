@@ -0,0 +1,265 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// BusinessError is a BPMN error thrown by an activity, e.g. returned by a service
+// task delegate. Instead of failing the process instance, the engine continues at the
+// nearest error boundary event or error event subprocess that catches its code.
+type BusinessError struct {
+	Code    string
+	Message string
+}
+
+// NewBusinessError creates a business error with an error code
+func NewBusinessError(code, message string) *BusinessError {
+	return &BusinessError{Code: code, Message: message}
+}
+
+// Error implements the error interface
+func (e *BusinessError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("business error '%s'", e.Code)
+	}
+	return fmt.Sprintf("business error '%s': %s", e.Code, e.Message)
+}
+
+// handleActivityError routes a business error returned by an activity behavior to its
+// handler. Other errors, and business errors nobody catches, are returned unchanged.
+func (s *runtimeServiceImpl) handleActivityError(ctx context.Context, current *ActivityExecution, err error) error {
+	var businessError *BusinessError
+	if err == nil || !errors.As(err, &businessError) {
+		return err
+	}
+
+	handled, handleErr := s.propagateBusinessError(ctx, current, businessError)
+	if handleErr != nil {
+		return handleErr
+	}
+	if !handled {
+		return err
+	}
+	return nil
+}
+
+// propagateBusinessError looks for a handler of a business error from the activity of
+// the execution outwards: error boundary events of the activity, then error event
+// subprocesses and error boundary events of each enclosing subprocess, then the call
+// activity that started the process instance. It reports whether a handler was found.
+func (s *runtimeServiceImpl) propagateBusinessError(ctx context.Context, current *ActivityExecution, businessError *BusinessError) (bool, error) {
+	processInstanceID := current.ProcessInstance.ID
+	execution, node := current.Execution, current.Node
+
+	for {
+		if boundaryEvent := findErrorHandler(getBoundaryEvents(current.Model, node.ID, "error"), businessError.Code); boundaryEvent != nil {
+			attached := &ActivityExecution{
+				Execution:       execution,
+				ProcessInstance: current.ProcessInstance,
+				Node:            node,
+				Model:           current.Model,
+				service:         s,
+				agenda:          current.agenda,
+			}
+			if err := s.cancelActivity(ctx, attached); err != nil {
+				return false, err
+			}
+			s.mu.Lock()
+			err := s.setVariablesVisibleLocked(execution.ID, errorVariables(boundaryEvent, businessError))
+			s.mu.Unlock()
+			if err != nil {
+				return false, err
+			}
+
+			current.agenda.plan(func(ctx context.Context) error {
+				return s.executeActivity(ctx, current.agenda, execution, current.ProcessInstance, current.Model, boundaryEvent)
+			})
+			return true, nil
+		}
+
+		s.mu.RLock()
+		scope, exists := s.executions[execution.ParentID]
+		s.mu.RUnlock()
+		if !exists {
+			return false, fmt.Errorf("scope execution %w: %s", errors.ErrNotFound, execution.ParentID)
+		}
+
+		// The root execution is the scope of the top level nodes
+		scopeNodeID := ""
+		if scope.ID != processInstanceID {
+			scopeNodeID = scope.ActivityID
+		}
+
+		if eventSubProcess, startEvent := findEventSubProcess(current.Model, scopeNodeID, "error", businessError.Code); eventSubProcess != nil {
+			scopeExecution := &ActivityExecution{
+				Execution:       scope,
+				ProcessInstance: current.ProcessInstance,
+				Model:           current.Model,
+				service:         s,
+				agenda:          current.agenda,
+			}
+			s.mu.Lock()
+			s.deleteEventSubProcessTriggersLocked(scope.ID, current.Model)
+			s.mu.Unlock()
+
+			if err := s.cancelChildExecutions(ctx, scopeExecution); err != nil {
+				return false, err
+			}
+			return true, s.startEventSubProcess(ctx, scopeExecution, eventSubProcess, startEvent, errorVariables(startEvent, businessError))
+		}
+
+		if scopeNodeID == "" {
+			break
+		}
+		execution, node = scope, current.Model.GetNode(scopeNodeID)
+		if node == nil {
+			return false, fmt.Errorf("node %w: %s", errors.ErrNotFound, scopeNodeID)
+		}
+	}
+
+	// Not caught inside the process instance: it ends and the error is thrown
+	// at the call activity that started it
+	if current.ProcessInstance.SuperExecutionID == "" {
+		return false, nil
+	}
+
+	root := &ActivityExecution{
+		Execution:       &Execution{ID: processInstanceID, ProcessInstanceID: processInstanceID},
+		ProcessInstance: current.ProcessInstance,
+		Model:           current.Model,
+		service:         s,
+		agenda:          current.agenda,
+	}
+	if err := s.cancelChildExecutions(ctx, root); err != nil {
+		return false, err
+	}
+
+	s.mu.Lock()
+	s.removeProcessInstanceLocked(processInstanceID)
+	superExecution := s.executions[current.ProcessInstance.SuperExecutionID]
+	superProcessInstance := s.processInstances[current.ProcessInstance.SuperProcessInstanceID]
+	s.mu.Unlock()
+
+	if superExecution == nil || superProcessInstance == nil {
+		return false, nil
+	}
+
+	processModel, err := s.getProcessModel(ctx, superProcessInstance.ProcessDefinitionID)
+	if err != nil {
+		return false, err
+	}
+	callActivity := processModel.GetNode(superExecution.ActivityID)
+	if callActivity == nil {
+		return false, fmt.Errorf("node %w: %s", errors.ErrNotFound, superExecution.ActivityID)
+	}
+
+	current.agenda.touch(superProcessInstance.ID)
+	return s.propagateBusinessError(ctx, &ActivityExecution{
+		Execution:       superExecution,
+		ProcessInstance: superProcessInstance,
+		Node:            callActivity,
+		Model:           processModel,
+		service:         s,
+		agenda:          current.agenda,
+	}, businessError)
+}
+
+// findErrorHandler returns the event catching an error code: an event for exactly
+// that code, or else an event without error code, which catches all errors
+func findErrorHandler(events []*model.Node, code string) *model.Node {
+	return findCodeHandler(events, "errorCode", code)
+}
+
+// findCodeHandler returns the event catching a code given by a property of the event
+// definitions: an event for exactly that code, or else an event without code
+func findCodeHandler(events []*model.Node, codeProperty, code string) *model.Node {
+	var catchAll *model.Node
+	for _, event := range events {
+		eventCode, _ := event.GetMap("eventDefinition")[codeProperty].(string)
+		if eventCode == code {
+			return event
+		}
+		if eventCode == "" && catchAll == nil {
+			catchAll = event
+		}
+	}
+	return catchAll
+}
+
+// findEventSubProcess returns the event subprocess directly inside a scope (empty for the
+// process level) whose start event of the event type matches, together with that start event.
+// Event subprocesses are subProcess nodes with the "triggeredByEvent" property. Start
+// events catch the code of their "errorCode" or "escalationCode", by event type.
+func findEventSubProcess(processModel *model.ProcessModel, scopeNodeID, eventType, code string) (*model.Node, *model.Node) {
+	startEvents := make([]*model.Node, 0)
+	for _, node := range processModel.GetChildNodes(scopeNodeID) {
+		if node.Type != model.NodeTypeSubProcess || !node.GetBool("triggeredByEvent") {
+			continue
+		}
+		for _, child := range processModel.GetChildNodes(node.ID) {
+			if child.Type == model.NodeTypeStartEvent && child.GetString("eventType") == eventType {
+				startEvents = append(startEvents, child)
+			}
+		}
+	}
+
+	startEvent := findCodeHandler(startEvents, eventType+"Code", code)
+	if startEvent == nil {
+		return nil, nil
+	}
+	return processModel.GetNode(startEvent.ParentID), startEvent
+}
+
+// startEventSubProcess starts an event subprocess in a scope at the given start event.
+// The event variables become local variables of the subprocess.
+func (s *runtimeServiceImpl) startEventSubProcess(ctx context.Context, scope *ActivityExecution, eventSubProcess, startEvent *model.Node, variables map[string]interface{}) error {
+	execution := &Execution{
+		ID:                uuid.New().String(),
+		ProcessInstanceID: scope.ProcessInstance.ID,
+		ParentID:          scope.Execution.ID,
+		ActivityID:        eventSubProcess.ID,
+		TenantID:          scope.Execution.TenantID,
+	}
+
+	s.mu.Lock()
+	s.executions[execution.ID] = execution
+	s.mu.Unlock()
+
+	current := &ActivityExecution{
+		Execution:       execution,
+		ProcessInstance: scope.ProcessInstance,
+		Node:            eventSubProcess,
+		Model:           scope.Model,
+		service:         s,
+		agenda:          scope.agenda,
+	}
+
+	locals, err := s.evaluateMappings(eventSubProcess.InputMappings, current.GetVariables())
+	if err != nil {
+		return fmt.Errorf("subprocess '%s': %w", eventSubProcess.ID, err)
+	}
+	for k, v := range variables {
+		locals[k] = v
+	}
+	return s.enterScope(ctx, current, startEvent, locals)
+}
+
+// errorVariables returns the code and message of a caught business error as the
+// variables named by the "errorCodeVariable" and "errorMessageVariable" properties
+// of the catching event's definition
+func errorVariables(event *model.Node, businessError *BusinessError) map[string]interface{} {
+	definition := event.GetMap("eventDefinition")
+	variables := make(map[string]interface{})
+	if name, _ := definition["errorCodeVariable"].(string); name != "" {
+		variables[name] = businessError.Code
+	}
+	if name, _ := definition["errorMessageVariable"].(string); name != "" {
+		variables[name] = businessError.Message
+	}
+	return variables
+}
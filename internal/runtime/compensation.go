@@ -0,0 +1,179 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// eventTypeCompensation is the event type of compensation boundary and throw events
+// and of the subscriptions recording completed activities that can be compensated
+const eventTypeCompensation = "compensation"
+
+// registerCompensationLocked records that the activity of an execution completed, so
+// that its compensation handlers can run later. The record belongs to the nearest scope
+// of the execution. Callers must hold the write lock.
+func (s *runtimeServiceImpl) registerCompensationLocked(current *ActivityExecution) error {
+	if current.Node == nil {
+		return nil
+	}
+	boundaryEvents := getBoundaryEvents(current.Model, current.Node.ID, eventTypeCompensation)
+	if len(boundaryEvents) == 0 {
+		return nil
+	}
+
+	chain, err := s.scopeChainLocked(current.Execution.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, boundaryEvent := range boundaryEvents {
+		subscription := &EventSubscription{
+			ID:                uuid.New().String(),
+			EventType:         eventTypeCompensation,
+			EventName:         current.Node.ID,
+			ProcessInstanceID: current.ProcessInstance.ID,
+			ExecutionID:       chain[0],
+			ActivityID:        boundaryEvent.ID,
+			CreateTime:        s.clock.Now(),
+		}
+		s.subscriptions[subscription.ID] = subscription
+	}
+	return nil
+}
+
+// compensate starts the compensation handlers of the completed activities of a scope,
+// or only of activityRef if it is set, in reverse order of completion. The handlers run
+// as child executions of the current execution, which continues once they have all
+// ended. It returns the number of started handlers.
+func (s *runtimeServiceImpl) compensate(ctx context.Context, current *ActivityExecution, scopeID, activityRef string) (int, error) {
+	s.mu.Lock()
+	subscriptions := make([]*EventSubscription, 0)
+	for id, subscription := range s.subscriptions {
+		if subscription.EventType != eventTypeCompensation || subscription.ExecutionID != scopeID {
+			continue
+		}
+		if activityRef != "" && subscription.EventName != activityRef {
+			continue
+		}
+		subscriptions = append(subscriptions, subscription)
+		// Each completed activity is compensated once
+		delete(s.subscriptions, id)
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreateTime.After(subscriptions[j].CreateTime)
+	})
+
+	handlers := make([]*Execution, len(subscriptions))
+	for i := range subscriptions {
+		handlers[i] = &Execution{
+			ID:                uuid.New().String(),
+			ProcessInstanceID: current.ProcessInstance.ID,
+			ParentID:          current.Execution.ID,
+			IsActive:          true,
+			IsConcurrent:      true,
+			TenantID:          current.Execution.TenantID,
+		}
+		s.executions[handlers[i].ID] = handlers[i]
+	}
+	if len(handlers) > 0 {
+		current.Execution.IsActive = false
+	}
+	s.mu.Unlock()
+
+	for i, subscription := range subscriptions {
+		boundaryEvent := current.Model.GetNode(subscription.ActivityID)
+		if boundaryEvent == nil {
+			return 0, fmt.Errorf("node %w: %s", errors.ErrNotFound, subscription.ActivityID)
+		}
+
+		handler := handlers[i]
+		current.agenda.plan(func(ctx context.Context) error {
+			return s.executeActivity(ctx, current.agenda, handler, current.ProcessInstance, current.Model, boundaryEvent)
+		})
+	}
+	return len(handlers), nil
+}
+
+// throwCompensation compensates the scope of the execution at a compensation throw event.
+// Intermediate throw events continue when all handlers have ended; end events end the path.
+func (s *runtimeServiceImpl) throwCompensation(ctx context.Context, execution *ActivityExecution) error {
+	s.mu.RLock()
+	chain, err := s.scopeChainLocked(execution.Execution.ID)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	activityRef, _ := execution.Node.GetMap("eventDefinition")["activityRef"].(string)
+	started, err := s.compensate(ctx, execution, chain[0], activityRef)
+	if err != nil || started > 0 {
+		return err
+	}
+
+	// Nothing to compensate
+	if execution.Node.Type == model.NodeTypeEndEvent {
+		execution.End()
+		return nil
+	}
+	return execution.Leave()
+}
+
+// CancelProcessInstance cancels all active paths of a process instance and runs the
+// compensation handlers of its completed activities in reverse order of completion.
+// The process instance ends when the last handler has ended.
+func (s *runtimeServiceImpl) CancelProcessInstance(ctx context.Context, processInstanceID string) error {
+	s.mu.RLock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	root := s.executions[processInstanceID]
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	if root == nil {
+		return fmt.Errorf("process instance '%s' has already ended", processInstanceID)
+	}
+	if processInstance.Suspended {
+		return &errors.SuspendedError{EntityType: "process instance", ID: processInstanceID}
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+
+	a := &agenda{}
+	a.touch(processInstanceID)
+	current := &ActivityExecution{
+		Execution:       root,
+		ProcessInstance: processInstance,
+		Model:           processModel,
+		service:         s,
+		agenda:          a,
+	}
+	if err := s.cancelChildExecutions(ctx, current); err != nil {
+		return err
+	}
+
+	started, err := s.compensate(ctx, current, root.ID, "")
+	if err != nil {
+		return err
+	}
+	if started == 0 {
+		s.mu.Lock()
+		endTime := s.clock.Now()
+		processInstance.EndTime = &endTime
+		s.deleteExecutionLocked(root.ID)
+		s.mu.Unlock()
+	}
+
+	if err := s.runAgenda(ctx, a); err != nil {
+		return err
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
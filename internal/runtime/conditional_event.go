@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// Conditional catch events (intermediate, boundary and event subprocess start events)
+// subscribe their execution like message events. Their conditions are evaluated whenever
+// an agenda runs out of operations and variables of the process instance changed since
+// the last evaluation; "variableName" in the event definition limits the evaluation to
+// changes of that variable. An event whose condition holds is triggered.
+
+// recordVariableChangeLocked notes that a variable of a process instance changed. An
+// empty name forces the next evaluation of all conditional events of the instance.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) recordVariableChangeLocked(processInstanceID, name string) {
+	if s.variableChanges[processInstanceID] == nil {
+		s.variableChanges[processInstanceID] = make(map[string]bool)
+	}
+	s.variableChanges[processInstanceID][name] = true
+}
+
+// recordScopeChangesLocked notes changes of variables of a scope execution.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) recordScopeChangesLocked(scopeID string, names ...string) {
+	scope, exists := s.executions[scopeID]
+	if !exists {
+		return
+	}
+	for _, name := range names {
+		s.recordVariableChangeLocked(scope.ProcessInstanceID, name)
+	}
+}
+
+// createConditionalSubscriptionLocked subscribes an execution to the condition of a
+// conditional event. The condition is evaluated the next time the agenda is idle.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) createConditionalSubscriptionLocked(execution *Execution, event *model.Node) error {
+	if condition, _ := event.GetMap("eventDefinition")["condition"].(string); condition == "" {
+		return fmt.Errorf("conditional event '%s' must define a condition", event.ID)
+	}
+
+	subscription := &EventSubscription{
+		ID:                uuid.New().String(),
+		EventType:         repository.EventTypeConditional,
+		ProcessInstanceID: execution.ProcessInstanceID,
+		ExecutionID:       execution.ID,
+		ActivityID:        event.ID,
+		CreateTime:        s.clock.Now(),
+	}
+	s.subscriptions[subscription.ID] = subscription
+	s.recordVariableChangeLocked(execution.ProcessInstanceID, "")
+	return nil
+}
+
+// runAgenda runs an agenda until it is empty and no conditional event is triggered by
+// the variable changes of the process instances it touched
+func (s *runtimeServiceImpl) runAgenda(ctx context.Context, a *agenda) error {
+	for {
+		if err := a.run(ctx); err != nil {
+			return err
+		}
+		if err := s.evaluateConditionalEvents(ctx, a); err != nil {
+			return err
+		}
+		if len(a.operations) == 0 {
+			return nil
+		}
+	}
+}
+
+// variablesChanged continues a process instance after its variables were changed from
+// outside, triggering the conditional events whose condition now holds, and saves it
+func (s *runtimeServiceImpl) variablesChanged(ctx context.Context, processInstanceID string) error {
+	a := &agenda{}
+	a.touch(processInstanceID)
+	if err := s.runAgenda(ctx, a); err != nil {
+		return err
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// evaluateConditionalEvents triggers the conditional events of the process instances
+// touched by an agenda whose condition holds after their variables changed
+func (s *runtimeServiceImpl) evaluateConditionalEvents(ctx context.Context, a *agenda) error {
+	s.mu.Lock()
+	changes := make(map[string]map[string]bool)
+	for _, processInstanceID := range a.touched {
+		if changed, exists := s.variableChanges[processInstanceID]; exists {
+			changes[processInstanceID] = changed
+			delete(s.variableChanges, processInstanceID)
+		}
+	}
+	candidates := make([]*EventSubscription, 0)
+	for _, subscription := range s.subscriptions {
+		if subscription.EventType != repository.EventTypeConditional || changes[subscription.ProcessInstanceID] == nil {
+			continue
+		}
+		if processInstance := s.processInstances[subscription.ProcessInstanceID]; processInstance != nil && !processInstance.Suspended {
+			candidates = append(candidates, subscription)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].CreateTime.Before(candidates[j].CreateTime)
+	})
+
+	for _, subscription := range candidates {
+		s.mu.RLock()
+		_, exists := s.subscriptions[subscription.ID]
+		processInstance := s.processInstances[subscription.ProcessInstanceID]
+		s.mu.RUnlock()
+		if !exists {
+			// Removed by an event triggered before, e.g. one interrupting its scope
+			continue
+		}
+
+		processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+		if err != nil {
+			return err
+		}
+		event := processModel.GetNode(subscription.ActivityID)
+		if event == nil {
+			return fmt.Errorf("node %w: %s", errors.ErrNotFound, subscription.ActivityID)
+		}
+
+		definition := event.GetMap("eventDefinition")
+		changed := changes[subscription.ProcessInstanceID]
+		if variableName, _ := definition["variableName"].(string); variableName != "" && !changed[""] && !changed[variableName] {
+			continue
+		}
+
+		s.mu.RLock()
+		variables, err := s.getVariablesLocked(subscription.ExecutionID)
+		s.mu.RUnlock()
+		if err != nil {
+			return err
+		}
+		condition, _ := definition["condition"].(string)
+		satisfied, err := s.expressions.EvaluateBool(condition, variables)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate condition of event '%s': %w", event.ID, err)
+		}
+		if satisfied {
+			if err := s.planEventDelivery(ctx, a, subscription, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
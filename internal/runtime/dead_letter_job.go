@@ -0,0 +1,75 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// GetDeadLetterJob retrieves a dead-letter job by ID
+func (s *runtimeServiceImpl) GetDeadLetterJob(ctx context.Context, jobID string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.deadLetterJobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("dead-letter job %w: %s", errors.ErrNotFound, jobID)
+	}
+	return job, nil
+}
+
+// GetDeadLetterJobs returns the dead-letter jobs of a process instance, or of all
+// process instances for an empty processInstanceID, oldest first
+func (s *runtimeServiceImpl) GetDeadLetterJobs(ctx context.Context, processInstanceID string) ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Job, 0)
+	for _, job := range s.deadLetterJobs {
+		if processInstanceID == "" || job.ProcessInstanceID == processInstanceID {
+			result = append(result, job)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreateTime.Before(result[j].CreateTime)
+	})
+	return result, nil
+}
+
+// GetJobExceptionStacktrace returns the failure of the last attempt of a job or dead-letter job
+func (s *runtimeServiceImpl) GetJobExceptionStacktrace(ctx context.Context, jobID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		if job, exists = s.deadLetterJobs[jobID]; !exists {
+			return "", fmt.Errorf("job %w: %s", errors.ErrNotFound, jobID)
+		}
+	}
+	return job.ExceptionStacktrace, nil
+}
+
+// ExecuteDeadLetterJob moves a dead-letter job back with one retry and runs it right away
+func (s *runtimeServiceImpl) ExecuteDeadLetterJob(ctx context.Context, jobID string) error {
+	if err := s.SetJobRetries(ctx, jobID, 1); err != nil {
+		return err
+	}
+	return s.ExecuteJob(ctx, jobID)
+}
+
+// DeleteDeadLetterJob removes a dead-letter job. The execution it belonged to stays
+// at its activity until it is signaled or the process instance is deleted.
+func (s *runtimeServiceImpl) DeleteDeadLetterJob(ctx context.Context, jobID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.deadLetterJobs[jobID]
+	if !exists {
+		return fmt.Errorf("dead-letter job %w: %s", errors.ErrNotFound, jobID)
+	}
+	delete(s.deadLetterJobs, jobID)
+	return s.saveProcessInstanceLocked(ctx, job.ProcessInstanceID)
+}
@@ -0,0 +1,109 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// eventTypeEscalation is the event type of escalation boundary events and of the start
+// events of escalation event subprocesses
+const eventTypeEscalation = "escalation"
+
+// ThrowEscalation throws an escalation at the activity an execution waits at, e.g. a user
+// task that is overdue, and runs the process instance until it waits again
+func (s *runtimeServiceImpl) ThrowEscalation(ctx context.Context, executionID, escalationCode string, variables map[string]interface{}) error {
+	s.mu.RLock()
+	execution, exists := s.executions[executionID]
+	var processInstance *ProcessInstance
+	if exists {
+		processInstance = s.processInstances[execution.ProcessInstanceID]
+	}
+	s.mu.RUnlock()
+
+	if !exists || processInstance == nil {
+		return fmt.Errorf("execution %w: %s", errors.ErrNotFound, executionID)
+	}
+	if processInstance.Suspended {
+		return &errors.SuspendedError{EntityType: "process instance", ID: processInstance.ID}
+	}
+	if execution.ActivityID == "" || !execution.IsActive {
+		return &errors.NotSignalableError{ExecutionID: executionID, ActivityID: execution.ActivityID, Reason: "it is not waiting at an activity"}
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+
+	a := &agenda{}
+	a.touch(processInstance.ID)
+	if err := s.propagateEscalation(ctx, a, execution, processInstance, processModel, escalationCode, variables); err != nil {
+		return err
+	}
+	if err := s.runAgenda(ctx, a); err != nil {
+		return err
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// propagateEscalation looks for the handler of an escalation from the activity of an
+// execution outwards: escalation boundary events of the activity, then escalation event
+// subprocesses and escalation boundary events of each enclosing subprocess. The first
+// handler catching the code is triggered with the variables; an escalation nobody
+// catches fails, so that the caller learns that the model defines no escalation path.
+func (s *runtimeServiceImpl) propagateEscalation(ctx context.Context, a *agenda, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, escalationCode string, variables map[string]interface{}) error {
+	activityID := execution.ActivityID
+	node := processModel.GetNode(activityID)
+	if node == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, activityID)
+	}
+
+	for {
+		if boundaryEvent := findCodeHandler(getBoundaryEvents(processModel, node.ID, eventTypeEscalation), "escalationCode", escalationCode); boundaryEvent != nil {
+			return s.triggerBoundaryEvent(ctx, a, execution, processInstance, processModel, boundaryEvent, escalationVariables(boundaryEvent, escalationCode, variables))
+		}
+
+		s.mu.RLock()
+		scope, exists := s.executions[execution.ParentID]
+		s.mu.RUnlock()
+		if !exists {
+			return fmt.Errorf("scope execution %w: %s", errors.ErrNotFound, execution.ParentID)
+		}
+
+		// The root execution is the scope of the top level nodes
+		scopeNodeID := ""
+		if scope.ID != processInstance.ID {
+			scopeNodeID = scope.ActivityID
+		}
+
+		if _, startEvent := findEventSubProcess(processModel, scopeNodeID, eventTypeEscalation, escalationCode); startEvent != nil {
+			return s.triggerEventSubProcess(ctx, a, scope, processInstance, processModel, startEvent, escalationVariables(startEvent, escalationCode, variables))
+		}
+
+		if scopeNodeID == "" {
+			break
+		}
+		execution, node = scope, processModel.GetNode(scopeNodeID)
+		if node == nil {
+			return fmt.Errorf("node %w: %s", errors.ErrNotFound, scopeNodeID)
+		}
+	}
+	return fmt.Errorf("%w: no escalation event catches escalation '%s' thrown at activity '%s'", errors.ErrValidation, escalationCode, activityID)
+}
+
+// escalationVariables returns the variables of an escalation together with its code as
+// the variable named by the "escalationCodeVariable" property of the catching event's
+// definition
+func escalationVariables(event *model.Node, escalationCode string, variables map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(variables)+1)
+	for name, value := range variables {
+		result[name] = value
+	}
+	if name, _ := event.GetMap("eventDefinition")["escalationCodeVariable"].(string); name != "" {
+		result[name] = escalationCode
+	}
+	return result
+}
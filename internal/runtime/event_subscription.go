@@ -0,0 +1,482 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// EventSubscription is a subscription of an execution to a message or signal, e.g.
+// of an execution waiting at a message catch event or of the scope of an event
+// subprocess with a message start event
+type EventSubscription struct {
+	ID                string
+	EventType         string
+	EventName         string
+	ProcessInstanceID string
+	ExecutionID       string
+	ActivityID        string // the event triggered by the subscription
+	CreateTime        time.Time
+}
+
+// Signal scopes of throw events
+const (
+	signalScopeGlobal          = "global"
+	signalScopeProcessInstance = "processInstance"
+)
+
+// getEventName returns the message or signal name of an event definition
+func getEventName(event *model.Node) string {
+	definition := event.GetMap("eventDefinition")
+	var name string
+	switch event.GetString("eventType") {
+	case repository.EventTypeMessage:
+		name, _ = definition["messageName"].(string)
+	case repository.EventTypeSignal:
+		name, _ = definition["signalName"].(string)
+	}
+	return name
+}
+
+// createEventSubProcessTriggersLocked creates the timers and subscriptions of the start
+// events of the event subprocesses in a scope. scopeNodeID is the subprocess node of
+// the scope, or empty for the process level. Callers must hold the write lock.
+func (s *runtimeServiceImpl) createEventSubProcessTriggersLocked(scope *Execution, processModel *model.ProcessModel, scopeNodeID string) error {
+	for _, node := range processModel.GetChildNodes(scopeNodeID) {
+		if node.Type != model.NodeTypeSubProcess || !node.GetBool("triggeredByEvent") {
+			continue
+		}
+
+		for _, startEvent := range processModel.GetChildNodes(node.ID) {
+			if startEvent.Type != model.NodeTypeStartEvent {
+				continue
+			}
+
+			switch eventType := startEvent.GetString("eventType"); eventType {
+			case "timer":
+				variables, err := s.getVariablesLocked(scope.ID)
+				if err != nil {
+					return err
+				}
+				if err := s.createTimerLocked(scope, startEvent, variables); err != nil {
+					return err
+				}
+			case repository.EventTypeMessage, repository.EventTypeSignal:
+				name := getEventName(startEvent)
+				if name == "" {
+					return fmt.Errorf("%s start event '%s' must define a %s name", eventType, startEvent.ID, eventType)
+				}
+				subscription := &EventSubscription{
+					ID:                uuid.New().String(),
+					EventType:         eventType,
+					EventName:         name,
+					ProcessInstanceID: scope.ProcessInstanceID,
+					ExecutionID:       scope.ID,
+					ActivityID:        startEvent.ID,
+					CreateTime:        s.clock.Now(),
+				}
+				s.subscriptions[subscription.ID] = subscription
+			case repository.EventTypeConditional:
+				if err := s.createConditionalSubscriptionLocked(scope, startEvent); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateEventNameLocked returns the message or signal name of an event, evaluating
+// expressions in it against the variables of an execution. Callers must hold the lock.
+func (s *runtimeServiceImpl) evaluateEventNameLocked(executionID string, event *model.Node) (string, error) {
+	eventType := event.GetString("eventType")
+	name := getEventName(event)
+	if name == "" {
+		return "", fmt.Errorf("%s event '%s' must define a %s name", eventType, event.ID, eventType)
+	}
+	if !expression.IsExpression(name) {
+		return name, nil
+	}
+
+	variables, err := s.getVariablesLocked(executionID)
+	if err != nil {
+		return "", err
+	}
+	if name, err = evaluateTemplate(s.expressions, name, variables); err != nil {
+		return "", fmt.Errorf("invalid %s name of event '%s': %w", eventType, event.ID, err)
+	}
+	return name, nil
+}
+
+// createSubscriptionLocked subscribes an execution to the message or signal of a catch
+// event. Callers must hold the write lock.
+func (s *runtimeServiceImpl) createSubscriptionLocked(execution *Execution, event *model.Node) error {
+	name, err := s.evaluateEventNameLocked(execution.ID, event)
+	if err != nil {
+		return err
+	}
+
+	subscription := &EventSubscription{
+		ID:                uuid.New().String(),
+		EventType:         event.GetString("eventType"),
+		EventName:         name,
+		ProcessInstanceID: execution.ProcessInstanceID,
+		ExecutionID:       execution.ID,
+		ActivityID:        event.ID,
+		CreateTime:        s.clock.Now(),
+	}
+	s.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+// createBoundarySubscriptionsLocked subscribes an execution to the messages, signals and
+// conditions of the boundary events attached to the node it arrived at.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) createBoundarySubscriptionsLocked(execution *Execution, processModel *model.ProcessModel, node *model.Node) error {
+	for _, eventType := range []string{repository.EventTypeMessage, repository.EventTypeSignal} {
+		for _, boundaryEvent := range getBoundaryEvents(processModel, node.ID, eventType) {
+			if err := s.createSubscriptionLocked(execution, boundaryEvent); err != nil {
+				return err
+			}
+		}
+	}
+	for _, boundaryEvent := range getBoundaryEvents(processModel, node.ID, repository.EventTypeConditional) {
+		if err := s.createConditionalSubscriptionLocked(execution, boundaryEvent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteCatchSubscriptionsLocked removes the subscriptions of an execution for the
+// intermediate and boundary events of the activity it leaves, keeping those of event
+// subprocesses and compensation handlers of its scope. Callers must hold the write lock.
+func (s *runtimeServiceImpl) deleteCatchSubscriptionsLocked(executionID string, processModel *model.ProcessModel) {
+	for id, subscription := range s.subscriptions {
+		if subscription.ExecutionID != executionID {
+			continue
+		}
+		if node := processModel.GetNode(subscription.ActivityID); node != nil &&
+			(node.Type == model.NodeTypeIntermediateEvent || node.Type == model.NodeTypeBoundaryEvent) {
+			delete(s.subscriptions, id)
+		}
+	}
+}
+
+// deleteSubscriptionsLocked removes the event subscriptions of an execution.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) deleteSubscriptionsLocked(executionID string) {
+	for id, subscription := range s.subscriptions {
+		if subscription.ExecutionID == executionID {
+			delete(s.subscriptions, id)
+		}
+	}
+}
+
+// deleteEventSubProcessTriggersLocked removes the timers and subscriptions of the event
+// subprocesses of a scope, keeping those of boundary events attached to the scope.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) deleteEventSubProcessTriggersLocked(scopeID string, processModel *model.ProcessModel) {
+	isStartEvent := func(activityID string) bool {
+		node := processModel.GetNode(activityID)
+		return node != nil && node.Type == model.NodeTypeStartEvent
+	}
+
+	for id, job := range s.jobs {
+		if job.Type == JobTypeTimer && job.ExecutionID == scopeID && isStartEvent(job.ActivityID) {
+			delete(s.jobs, id)
+		}
+	}
+	for id, subscription := range s.subscriptions {
+		if subscription.ExecutionID == scopeID && isStartEvent(subscription.ActivityID) {
+			delete(s.subscriptions, id)
+		}
+	}
+}
+
+// triggerEvent plans the continuation at an event that occurred for an execution:
+// an intermediate catch event the execution waits at, a boundary event attached to
+// the activity of the execution, or the start event of an event subprocess in the
+// scope of the execution. Event variables are set on the path that continues.
+func (s *runtimeServiceImpl) triggerEvent(ctx context.Context, a *agenda, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, event *model.Node, variables map[string]interface{}) error {
+	switch {
+	case event.Type == model.NodeTypeIntermediateEvent:
+		s.mu.Lock()
+		err := s.setVariablesVisibleLocked(execution.ID, variables)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		current := &ActivityExecution{
+			Execution:       execution,
+			ProcessInstance: processInstance,
+			Node:            event,
+			Model:           processModel,
+			service:         s,
+			agenda:          a,
+		}
+		return current.Leave()
+	case event.Type == model.NodeTypeBoundaryEvent:
+		return s.triggerBoundaryEvent(ctx, a, execution, processInstance, processModel, event, variables)
+	case event.Type == model.NodeTypeStartEvent && event.ParentID != "":
+		return s.triggerEventSubProcess(ctx, a, execution, processInstance, processModel, event, variables)
+	}
+	return fmt.Errorf("event '%s' of type '%s' cannot be triggered", event.ID, event.Type)
+}
+
+// triggerBoundaryEvent plans the continuation at a boundary event attached to the
+// activity of an execution. Interrupting events cancel the activity and move its
+// execution to the boundary event; non-interrupting events start a concurrent path.
+func (s *runtimeServiceImpl) triggerBoundaryEvent(ctx context.Context, a *agenda, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, boundaryEvent *model.Node, variables map[string]interface{}) error {
+	if !isInterrupting(boundaryEvent) {
+		s.mu.Lock()
+		concurrent := &Execution{
+			ID:                uuid.New().String(),
+			ProcessInstanceID: processInstance.ID,
+			ParentID:          execution.ParentID,
+			IsActive:          true,
+			IsConcurrent:      true,
+			TenantID:          execution.TenantID,
+		}
+		s.executions[concurrent.ID] = concurrent
+		err := s.setVariablesVisibleLocked(concurrent.ID, variables)
+		s.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		a.plan(func(ctx context.Context) error {
+			return s.executeActivity(ctx, a, concurrent, processInstance, processModel, boundaryEvent)
+		})
+		return nil
+	}
+
+	attached := &ActivityExecution{
+		Execution:       execution,
+		ProcessInstance: processInstance,
+		Node:            processModel.GetNode(execution.ActivityID),
+		Model:           processModel,
+		service:         s,
+		agenda:          a,
+	}
+	if err := s.cancelActivity(ctx, attached); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	err := s.setVariablesVisibleLocked(execution.ID, variables)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	a.plan(func(ctx context.Context) error {
+		return s.executeActivity(ctx, a, execution, processInstance, processModel, boundaryEvent)
+	})
+	return nil
+}
+
+// triggerEventSubProcess starts the event subprocess of a start event in a scope.
+// An interrupting start event first cancels everything else running in the scope.
+// The event variables become local variables of the event subprocess.
+func (s *runtimeServiceImpl) triggerEventSubProcess(ctx context.Context, a *agenda, scope *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, startEvent *model.Node, variables map[string]interface{}) error {
+	eventSubProcess := processModel.GetNode(startEvent.ParentID)
+	if eventSubProcess == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, startEvent.ParentID)
+	}
+
+	scopeExecution := &ActivityExecution{
+		Execution:       scope,
+		ProcessInstance: processInstance,
+		Model:           processModel,
+		service:         s,
+		agenda:          a,
+	}
+
+	if isInterrupting(startEvent) {
+		s.mu.Lock()
+		s.deleteEventSubProcessTriggersLocked(scope.ID, processModel)
+		s.mu.Unlock()
+
+		if err := s.cancelChildExecutions(ctx, scopeExecution); err != nil {
+			return err
+		}
+	}
+
+	return s.startEventSubProcess(ctx, scopeExecution, eventSubProcess, startEvent, variables)
+}
+
+// deliverEvent triggers the event of a subscription and runs the process instance
+// until it waits again
+func (s *runtimeServiceImpl) deliverEvent(ctx context.Context, subscription *EventSubscription, variables map[string]interface{}) error {
+	a := &agenda{}
+	if err := s.planEventDelivery(ctx, a, subscription, variables); err != nil {
+		return err
+	}
+	if err := s.runAgenda(ctx, a); err != nil {
+		return err
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// planEventDelivery triggers the event of a subscription on an agenda
+func (s *runtimeServiceImpl) planEventDelivery(ctx context.Context, a *agenda, subscription *EventSubscription, variables map[string]interface{}) error {
+	s.mu.RLock()
+	processInstance := s.processInstances[subscription.ProcessInstanceID]
+	execution := s.executions[subscription.ExecutionID]
+	s.mu.RUnlock()
+
+	if processInstance == nil || execution == nil {
+		return fmt.Errorf("execution %w: %s", errors.ErrNotFound, subscription.ExecutionID)
+	}
+	if processInstance.Suspended {
+		return &errors.SuspendedError{EntityType: "process instance", ID: processInstance.ID}
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+
+	event := processModel.GetNode(subscription.ActivityID)
+	if event == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, subscription.ActivityID)
+	}
+
+	a.touch(processInstance.ID)
+	return s.triggerEvent(ctx, a, execution, processInstance, processModel, event, variables)
+}
+
+// eventReceived delivers a message or signal to an execution subscribed to it
+func (s *runtimeServiceImpl) eventReceived(ctx context.Context, eventType, eventName, executionID string, variables map[string]interface{}) error {
+	s.mu.RLock()
+	var subscription *EventSubscription
+	for _, candidate := range s.subscriptions {
+		if candidate.EventType == eventType && candidate.EventName == eventName && candidate.ExecutionID == executionID {
+			subscription = candidate
+			break
+		}
+	}
+	s.mu.RUnlock()
+
+	if subscription == nil {
+		return fmt.Errorf("execution '%s' has no subscription to %s '%s'", executionID, eventType, eventName)
+	}
+	return s.deliverEvent(ctx, subscription, variables)
+}
+
+// MessageEventReceived delivers a message to an execution subscribed to it, e.g. to
+// the process instance (its root execution) for a message event subprocess
+func (s *runtimeServiceImpl) MessageEventReceived(ctx context.Context, messageName, executionID string, variables map[string]interface{}) error {
+	return s.eventReceived(ctx, repository.EventTypeMessage, messageName, executionID, variables)
+}
+
+// SignalEventReceivedByExecution delivers a signal to a single execution subscribed to it
+func (s *runtimeServiceImpl) SignalEventReceivedByExecution(ctx context.Context, signalName, executionID string, variables map[string]interface{}) error {
+	return s.eventReceived(ctx, repository.EventTypeSignal, signalName, executionID, variables)
+}
+
+// findSignalSubscriptions returns the subscriptions to a signal, oldest first, limited to
+// a process instance unless processInstanceID is empty. Subscriptions of suspended
+// process instances are skipped.
+func (s *runtimeServiceImpl) findSignalSubscriptions(signalName, processInstanceID string) []*EventSubscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subscriptions := make([]*EventSubscription, 0)
+	for _, subscription := range s.subscriptions {
+		if subscription.EventType != repository.EventTypeSignal || subscription.EventName != signalName {
+			continue
+		}
+		if processInstanceID != "" && subscription.ProcessInstanceID != processInstanceID {
+			continue
+		}
+		if processInstance := s.processInstances[subscription.ProcessInstanceID]; processInstance != nil && !processInstance.Suspended {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreateTime.Before(subscriptions[j].CreateTime)
+	})
+	return subscriptions
+}
+
+// notifySignalSubscriptions delivers a signal to subscriptions found before, skipping
+// those an earlier delivery removed, e.g. by interrupting their scope
+func (s *runtimeServiceImpl) notifySignalSubscriptions(ctx context.Context, subscriptions []*EventSubscription, variables map[string]interface{}) error {
+	for _, subscription := range subscriptions {
+		s.mu.RLock()
+		_, exists := s.subscriptions[subscription.ID]
+		s.mu.RUnlock()
+		if !exists {
+			continue
+		}
+
+		if err := s.deliverEvent(ctx, subscription, variables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// throwSignal plans the delivery of the signal of a throw event. A signal of global scope
+// (the default) reaches the executions of all process instances subscribed to it and
+// starts process instances by signal start event; "processInstance" scope limits it to
+// the executions of the throwing process instance. Callers leave or end the event.
+func (s *runtimeServiceImpl) throwSignal(ctx context.Context, execution *ActivityExecution) error {
+	s.mu.RLock()
+	name, err := s.evaluateEventNameLocked(execution.Execution.ID, execution.Node)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	scope, _ := execution.Node.GetMap("eventDefinition")["signalScope"].(string)
+	processInstanceID := ""
+	switch scope {
+	case "", signalScopeGlobal:
+	case signalScopeProcessInstance:
+		processInstanceID = execution.ProcessInstance.ID
+	default:
+		return fmt.Errorf("invalid signal scope '%s' of event '%s'", scope, execution.Node.ID)
+	}
+
+	a := execution.agenda
+	for _, subscription := range s.findSignalSubscriptions(name, processInstanceID) {
+		subscription := subscription
+		a.plan(func(ctx context.Context) error {
+			s.mu.RLock()
+			_, exists := s.subscriptions[subscription.ID]
+			s.mu.RUnlock()
+			if !exists {
+				return nil
+			}
+			return s.planEventDelivery(ctx, a, subscription, nil)
+		})
+	}
+	if processInstanceID != "" {
+		return nil
+	}
+
+	startSubscriptions, err := s.repositoryService.GetStartEventSubscriptions(ctx, repository.EventTypeSignal, name)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range startSubscriptions {
+		subscription := subscription
+		a.plan(func(ctx context.Context) error {
+			_, err := s.startProcessInstanceBySubscription(ctx, subscription, "", nil)
+			return err
+		})
+	}
+	return nil
+}
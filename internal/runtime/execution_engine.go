@@ -0,0 +1,689 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// agenda is the queue of operations of a single engine call. Running operations
+// from a queue instead of recursively keeps the stack flat for long processes.
+type agenda struct {
+	operations []func(ctx context.Context) error
+	touched    []string // IDs of the process instances the operations changed
+}
+
+// plan appends an operation to the agenda
+func (a *agenda) plan(operation func(ctx context.Context) error) {
+	a.operations = append(a.operations, operation)
+}
+
+// touch records that a process instance was changed and must be saved after the run
+func (a *agenda) touch(processInstanceID string) {
+	for _, id := range a.touched {
+		if id == processInstanceID {
+			return
+		}
+	}
+	a.touched = append(a.touched, processInstanceID)
+}
+
+// run executes operations until the agenda is empty or an operation fails
+func (a *agenda) run(ctx context.Context) error {
+	for len(a.operations) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		operation := a.operations[0]
+		a.operations = a.operations[1:]
+		if err := operation(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateCondition evaluates a sequence flow condition against variables
+func (s *runtimeServiceImpl) evaluateCondition(condition string, variables map[string]interface{}) (bool, error) {
+	return s.expressions.EvaluateBool(condition, variables)
+}
+
+// GetExpressionManager returns the manager evaluating the expressions of process definitions
+func (s *runtimeServiceImpl) GetExpressionManager() *expression.Manager {
+	return s.expressions
+}
+
+// SetClock sets the clock timers, jobs and the times of process instances are based
+// on. The now() function of expressions returns its time as well.
+func (s *runtimeServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+	s.expressions.SetClock(c)
+}
+
+// SetLogger sets the logger failures of jobs, timers and reloads are logged to
+func (s *runtimeServiceImpl) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// RegisterActivityBehavior registers the behavior for a node type and makes the type
+// known to the validation of process definitions
+func (s *runtimeServiceImpl) RegisterActivityBehavior(nodeType string, behavior ActivityBehavior) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.behaviors[nodeType] = behavior
+	model.RegisterNodeType(nodeType)
+}
+
+// RegisterServiceTask registers a service task delegate under a name
+func (s *runtimeServiceImpl) RegisterServiceTask(name string, delegate ServiceTaskDelegate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delegates[name] = delegate
+}
+
+// getProcessModel returns the parsed model of a process definition from the cache of
+// the repository service
+func (s *runtimeServiceImpl) getProcessModel(ctx context.Context, processDefinitionID string) (*model.ProcessModel, error) {
+	processModel, err := s.repositoryService.GetParsedProcessModel(ctx, processDefinitionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process model: %w", err)
+	}
+	return processModel, nil
+}
+
+// findStartEvent returns the start event a process instance starts at.
+// An empty startActivityID selects the none start event, i.e. the start event without event type.
+func findStartEvent(processModel *model.ProcessModel, startActivityID string) (*model.Node, error) {
+	if startActivityID != "" {
+		node := processModel.GetNode(startActivityID)
+		if node == nil {
+			return nil, fmt.Errorf("start event %w: %s", errors.ErrNotFound, startActivityID)
+		}
+		return node, nil
+	}
+
+	return findNoneStartEvent(processModel, "")
+}
+
+// findNoneStartEvent returns the start event without event type directly inside a
+// subprocess, or at the top level of the process for an empty parentID
+func findNoneStartEvent(processModel *model.ProcessModel, parentID string) (*model.Node, error) {
+	for _, node := range processModel.GetChildNodes(parentID) {
+		if node.Type == model.NodeTypeStartEvent && node.GetString("eventType") == "" {
+			return node, nil
+		}
+	}
+	if parentID != "" {
+		return nil, fmt.Errorf("subprocess '%s' has no none start event", parentID)
+	}
+	return nil, fmt.Errorf("process '%s' has no none start event", processModel.ID)
+}
+
+// executeActivity moves an execution to a node and runs the node's behavior, or
+// creates a job to run it in the background if the node is "asyncBefore"
+func (s *runtimeServiceImpl) executeActivity(ctx context.Context, a *agenda, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, node *model.Node) error {
+	s.mu.Lock()
+	if _, exists := s.executions[execution.ID]; !exists {
+		// Cancelled since the operation was planned, e.g. by a terminate end event
+		s.mu.Unlock()
+		return nil
+	}
+	s.deleteTimersLocked(execution.ID)
+	s.deleteCatchSubscriptionsLocked(execution.ID, processModel)
+	execution.ActivityID = node.ID
+	execution.IsActive = true
+	if isAsyncBefore(node) {
+		s.createJobLocked(ctx, execution, node, JobTypeAsyncBefore, nil)
+		s.mu.Unlock()
+		a.touch(processInstance.ID)
+		return nil
+	}
+	s.mu.Unlock()
+
+	return s.runActivity(ctx, a, execution, processInstance, processModel, node)
+}
+
+// runActivity runs the behavior of the node an execution arrived at
+func (s *runtimeServiceImpl) runActivity(ctx context.Context, a *agenda, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, node *model.Node) error {
+	s.mu.Lock()
+	behavior, exists := s.behaviors[node.Type]
+	err := s.createTimersLocked(execution, processModel, node)
+	if err == nil {
+		err = s.createBoundarySubscriptionsLocked(execution, processModel, node)
+	}
+	if err == nil {
+		s.fireHistoryEventLocked(HistoryEventActivityStart, processInstance, execution.ID, node, nil)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	a.touch(processInstance.ID)
+
+	if !exists {
+		return fmt.Errorf("unsupported type '%s' of node '%s'", node.Type, node.ID)
+	}
+
+	current := &ActivityExecution{
+		Execution:       execution,
+		ProcessInstance: processInstance,
+		Node:            node,
+		Model:           processModel,
+		service:         s,
+		agenda:          a,
+	}
+	return s.handleActivityError(ctx, current, behavior.Execute(ctx, current))
+}
+
+// takeEdges moves an execution along sequence flows, or creates a job to do so in
+// the background if the node it leaves is "asyncAfter"
+func (s *runtimeServiceImpl) takeEdges(ctx context.Context, current *ActivityExecution, edges []*model.Edge) error {
+	s.mu.Lock()
+	if _, exists := s.executions[current.Execution.ID]; !exists {
+		s.mu.Unlock()
+		return nil
+	}
+	err := s.registerCompensationLocked(current)
+	if err == nil {
+		s.fireActivityEndLocked(current, "")
+	}
+	if err == nil && current.Node != nil && current.Node.GetBool("asyncAfter") {
+		// The activity is complete: its boundary events no longer apply
+		s.deleteTimersLocked(current.Execution.ID)
+		if !current.Execution.IsScope {
+			s.deleteSubscriptionsLocked(current.Execution.ID)
+		}
+		s.createJobLocked(ctx, current.Execution, current.Node, JobTypeAsyncAfter, edges)
+		s.mu.Unlock()
+		current.agenda.touch(current.ProcessInstance.ID)
+		return nil
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return s.followEdges(current, edges)
+}
+
+// followEdges plans moving an execution along sequence flows. Taking several flows
+// replaces the execution with one concurrent execution per flow.
+func (s *runtimeServiceImpl) followEdges(current *ActivityExecution, edges []*model.Edge) error {
+	targets := make([]*model.Node, len(edges))
+	for i, edge := range edges {
+		targets[i] = current.Model.GetNode(edge.Target)
+		if targets[i] == nil {
+			return fmt.Errorf("sequence flow '%s' targets unknown node '%s'", edge.ID, edge.Target)
+		}
+	}
+
+	if len(edges) == 1 {
+		current.agenda.plan(func(ctx context.Context) error {
+			return s.executeActivity(ctx, current.agenda, current.Execution, current.ProcessInstance, current.Model, targets[0])
+		})
+		return nil
+	}
+
+	s.mu.Lock()
+	s.deleteExecutionLocked(current.Execution.ID)
+	forked := make([]*Execution, len(edges))
+	for i := range edges {
+		forked[i] = &Execution{
+			ID:                uuid.New().String(),
+			ProcessInstanceID: current.Execution.ProcessInstanceID,
+			ParentID:          current.Execution.ParentID,
+			IsActive:          true,
+			IsConcurrent:      true,
+			TenantID:          current.Execution.TenantID,
+		}
+		s.executions[forked[i].ID] = forked[i]
+	}
+	s.mu.Unlock()
+
+	for i := range forked {
+		execution, target := forked[i], targets[i]
+		current.agenda.plan(func(ctx context.Context) error {
+			return s.executeActivity(ctx, current.agenda, execution, current.ProcessInstance, current.Model, target)
+		})
+	}
+	return nil
+}
+
+// endExecution removes the execution of a finished path. When it was the last path
+// of its scope, the enclosing subprocess completes or, at the top level, the process instance ends.
+func (s *runtimeServiceImpl) endExecution(ctx context.Context, current *ActivityExecution) error {
+	s.mu.Lock()
+
+	if _, exists := s.executions[current.Execution.ID]; !exists {
+		s.mu.Unlock()
+		return nil
+	}
+	if err := s.registerCompensationLocked(current); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.fireActivityEndLocked(current, "")
+	s.deleteExecutionLocked(current.Execution.ID)
+
+	processInstanceID := current.ProcessInstance.ID
+	parentID := current.Execution.ParentID
+	for _, execution := range s.executions {
+		if execution.ProcessInstanceID == processInstanceID && execution.ParentID == parentID {
+			s.mu.Unlock()
+			return nil
+		}
+	}
+
+	if parentID == processInstanceID {
+		// Last path ended: the process instance is complete
+		endTime := s.clock.Now()
+		current.ProcessInstance.EndTime = &endTime
+		variables, err := s.contents.ResolveAll(ctx, s.variables[processInstanceID])
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.deleteExecutionLocked(processInstanceID)
+		s.fireHistoryEventLocked(HistoryEventProcessInstanceEnd, current.ProcessInstance, current.Execution.ID, current.Node, nil)
+		s.mu.Unlock()
+
+		if current.ProcessInstance.SuperExecutionID != "" {
+			return s.completeCallActivity(ctx, current, variables)
+		}
+		return nil
+	}
+
+	scope, exists := s.executions[parentID]
+	s.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("scope execution %w: %s", errors.ErrNotFound, parentID)
+	}
+	return s.leaveScope(ctx, current, scope)
+}
+
+// terminate cancels all other paths in the scope of an execution at a terminate end
+// event, including parallel branches and subprocesses, and then ends the execution,
+// which completes the scope. With the "terminateAll" property the whole process
+// instance is terminated, even from inside a subprocess.
+func (s *runtimeServiceImpl) terminate(ctx context.Context, current *ActivityExecution) error {
+	scopeID := current.Execution.ParentID
+	if current.Node.GetBool("terminateAll") {
+		scopeID = current.ProcessInstance.ID
+	}
+
+	s.mu.Lock()
+	scope, exists := s.executions[scopeID]
+	// Keep the terminating execution out of the cancellation
+	delete(s.executions, current.Execution.ID)
+	s.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("scope execution %w: %s", errors.ErrNotFound, scopeID)
+	}
+
+	err := s.cancelChildExecutions(ctx, &ActivityExecution{
+		Execution:       scope,
+		ProcessInstance: current.ProcessInstance,
+		Model:           current.Model,
+		service:         s,
+		agenda:          current.agenda,
+	})
+
+	s.mu.Lock()
+	current.Execution.ParentID = scope.ID
+	s.executions[current.Execution.ID] = current.Execution
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	current.End()
+	return nil
+}
+
+// deleteExecutionLocked removes an execution with its variables, timers, jobs and event subscriptions.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) deleteExecutionLocked(executionID string) {
+	s.deleteTimersLocked(executionID)
+	s.deleteJobsLocked(executionID)
+	s.deleteSubscriptionsLocked(executionID)
+	delete(s.executions, executionID)
+	delete(s.variables, executionID)
+}
+
+// cancelActivity cancels the activity an execution waits at together with everything
+// running inside it, e.g. the paths of a subprocess. The execution itself is kept.
+func (s *runtimeServiceImpl) cancelActivity(ctx context.Context, current *ActivityExecution) error {
+	if err := s.cancelChildExecutions(ctx, current); err != nil {
+		return err
+	}
+
+	if current.Node != nil {
+		s.mu.RLock()
+		behavior, cancelable := s.behaviors[current.Node.Type].(CancelableActivityBehavior)
+		s.mu.RUnlock()
+
+		if cancelable {
+			if err := behavior.Cancel(ctx, current); err != nil {
+				return fmt.Errorf("failed to cancel activity '%s': %w", current.Node.ID, err)
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.fireActivityEndLocked(current, DeleteReasonCanceled)
+	s.deleteTimersLocked(current.Execution.ID)
+	s.deleteJobsLocked(current.Execution.ID)
+	s.deleteSubscriptionsLocked(current.Execution.ID)
+	if current.Execution.IsScope {
+		delete(s.variables, current.Execution.ID)
+		current.Execution.IsScope = false
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// cancelChildExecutions cancels and removes the child executions of a scope execution
+func (s *runtimeServiceImpl) cancelChildExecutions(ctx context.Context, current *ActivityExecution) error {
+	s.mu.Lock()
+	children := make([]*Execution, 0)
+	for _, execution := range s.executions {
+		if execution.ParentID == current.Execution.ID && execution.ProcessInstanceID == current.Execution.ProcessInstanceID {
+			children = append(children, execution)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, child := range children {
+		childExecution := &ActivityExecution{
+			Execution:       child,
+			ProcessInstance: current.ProcessInstance,
+			Node:            current.Model.GetNode(child.ActivityID),
+			Model:           current.Model,
+			service:         s,
+			agenda:          current.agenda,
+		}
+		if err := s.cancelActivity(ctx, childExecution); err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.deleteExecutionLocked(child.ID)
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// enterScope turns the execution arriving at a subprocess into the scope of the
+// subprocess and starts a child execution at the given start event
+func (s *runtimeServiceImpl) enterScope(ctx context.Context, current *ActivityExecution, start *model.Node, variables map[string]interface{}) error {
+	child := &Execution{
+		ID:                uuid.New().String(),
+		ProcessInstanceID: current.Execution.ProcessInstanceID,
+		ParentID:          current.Execution.ID,
+		IsActive:          true,
+		TenantID:          current.Execution.TenantID,
+	}
+
+	s.mu.Lock()
+	current.Execution.IsScope = true
+	current.Execution.IsActive = false
+	s.variables[current.Execution.ID] = make(map[string]interface{})
+	s.setVariablesLocked(current.Execution.ID, current.Execution.ID, variables)
+	s.executions[child.ID] = child
+	err := s.createEventSubProcessTriggersLocked(current.Execution, current.Model, current.Node.ID)
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	current.agenda.plan(func(ctx context.Context) error {
+		return s.executeActivity(ctx, current.agenda, child, current.ProcessInstance, current.Model, start)
+	})
+	return nil
+}
+
+// leaveScope completes a subprocess: output mappings are applied to the enclosing
+// scopes, the local variables are dropped and the scope execution leaves the subprocess
+func (s *runtimeServiceImpl) leaveScope(ctx context.Context, current *ActivityExecution, scope *Execution) error {
+	node := current.Model.GetNode(scope.ActivityID)
+	if node == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, scope.ActivityID)
+	}
+
+	scopeExecution := &ActivityExecution{
+		Execution:       scope,
+		ProcessInstance: current.ProcessInstance,
+		Node:            node,
+		Model:           current.Model,
+		service:         s,
+		agenda:          current.agenda,
+	}
+
+	outputs, err := s.evaluateMappings(node.OutputMappings, scopeExecution.GetVariables())
+	if err != nil {
+		return fmt.Errorf("subprocess '%s': %w", node.ID, err)
+	}
+
+	s.mu.Lock()
+	// Event subprocesses and compensation of the inner activities end with the scope
+	s.deleteSubscriptionsLocked(scope.ID)
+	delete(s.variables, scope.ID)
+	scope.IsScope = false
+	scope.IsActive = true
+	err = s.setVariablesVisibleLocked(scope.ID, outputs)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return scopeExecution.Leave()
+}
+
+// completeCallActivity resumes the execution waiting at the call activity that
+// started a process instance which just completed. The output mappings of the
+// call activity are evaluated against the final variables of the called instance.
+func (s *runtimeServiceImpl) completeCallActivity(ctx context.Context, current *ActivityExecution, variables map[string]interface{}) error {
+	s.mu.RLock()
+	superExecution, exists := s.executions[current.ProcessInstance.SuperExecutionID]
+	superProcessInstance := s.processInstances[current.ProcessInstance.SuperProcessInstanceID]
+	s.mu.RUnlock()
+
+	if !exists || superProcessInstance == nil {
+		return fmt.Errorf("super execution %w: %s", errors.ErrNotFound, current.ProcessInstance.SuperExecutionID)
+	}
+
+	processModel, err := s.getProcessModel(ctx, superProcessInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+
+	node := processModel.GetNode(superExecution.ActivityID)
+	if node == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, superExecution.ActivityID)
+	}
+
+	outputs, err := s.evaluateMappings(node.OutputMappings, variables)
+	if err != nil {
+		return fmt.Errorf("call activity '%s': %w", node.ID, err)
+	}
+	if node.GetBool("inheritVariables") {
+		for k, v := range variables {
+			if _, mapped := outputs[k]; !mapped {
+				outputs[k] = v
+			}
+		}
+	}
+
+	execution := &ActivityExecution{
+		Execution:       superExecution,
+		ProcessInstance: superProcessInstance,
+		Node:            node,
+		Model:           processModel,
+		service:         s,
+		agenda:          current.agenda,
+	}
+	execution.SetVariables(outputs)
+	current.agenda.touch(superProcessInstance.ID)
+
+	return execution.Leave()
+}
+
+// join registers the arrival of an execution at a joining node. It returns true
+// for the execution that completes the join, which continues alone; the
+// executions that arrived earlier are removed.
+func (s *runtimeServiceImpl) join(current *ActivityExecution) (bool, error) {
+	incoming := len(current.IncomingEdges())
+	if incoming <= 1 {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current.Execution.IsActive = false
+
+	waiting := make([]*Execution, 0, incoming)
+	for _, execution := range s.executions {
+		if execution.ProcessInstanceID == current.Execution.ProcessInstanceID &&
+			execution.ParentID == current.Execution.ParentID &&
+			execution.ActivityID == current.Node.ID &&
+			!execution.IsActive &&
+			execution != current.Execution {
+			waiting = append(waiting, execution)
+		}
+	}
+
+	if len(waiting)+1 < incoming {
+		return false, nil
+	}
+
+	for _, execution := range waiting[:incoming-1] {
+		s.fireHistoryEventLocked(HistoryEventActivityEnd, current.ProcessInstance, execution.ID, current.Node, nil)
+		delete(s.executions, execution.ID)
+	}
+	current.Execution.IsActive = true
+
+	// The joined execution is concurrent only if other paths are still running in its scope
+	current.Execution.IsConcurrent = false
+	for _, execution := range s.executions {
+		if execution.ParentID == current.Execution.ParentID && execution != current.Execution &&
+			execution.ProcessInstanceID == current.Execution.ProcessInstanceID {
+			current.Execution.IsConcurrent = true
+			break
+		}
+	}
+	return true, nil
+}
+
+// trigger continues an execution that waits at a wait state. Executions at other nodes,
+// or waiting for an asynchronous continuation, are rejected with a NotSignalableError
+// before any variable is set.
+func (s *runtimeServiceImpl) trigger(ctx context.Context, executionID string, variables map[string]interface{}) error {
+	s.mu.Lock()
+	execution, exists := s.executions[executionID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("execution %w: %s", errors.ErrNotFound, executionID)
+	}
+	processInstance := s.processInstances[execution.ProcessInstanceID]
+	if processInstance.Suspended {
+		s.mu.Unlock()
+		return &errors.SuspendedError{EntityType: "process instance", ID: processInstance.ID}
+	}
+	if execution.ActivityID == "" {
+		s.mu.Unlock()
+		return &errors.NotSignalableError{ExecutionID: executionID, Reason: "it is not waiting at an activity"}
+	}
+	if !execution.IsActive {
+		s.mu.Unlock()
+		return &errors.NotSignalableError{ExecutionID: executionID, ActivityID: execution.ActivityID, Reason: "it is inactive, e.g. waiting at a joining gateway or in a subprocess"}
+	}
+	for _, job := range s.jobs {
+		if job.ExecutionID == executionID && (job.Type == JobTypeAsyncBefore || job.Type == JobTypeAsyncAfter) {
+			s.mu.Unlock()
+			return &errors.NotSignalableError{ExecutionID: executionID, ActivityID: execution.ActivityID, Reason: "it waits for an asynchronous continuation"}
+		}
+	}
+	s.mu.Unlock()
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+
+	node := processModel.GetNode(execution.ActivityID)
+	if node == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, execution.ActivityID)
+	}
+
+	s.mu.RLock()
+	behavior, triggerable := s.behaviors[node.Type].(TriggerableActivityBehavior)
+	s.mu.RUnlock()
+
+	if waiting, conditional := behavior.(waitingBehavior); conditional && !waiting.waitsAt(node) {
+		triggerable = false
+	}
+	if !triggerable {
+		return &errors.NotSignalableError{ExecutionID: executionID, ActivityID: node.ID, Reason: fmt.Sprintf("a node of type '%s' is not a wait state", node.Type)}
+	}
+
+	// Variables are only set once the execution is known to continue
+	s.mu.Lock()
+	err = s.setVariablesVisibleLocked(executionID, variables)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	a := &agenda{}
+	a.touch(processInstance.ID)
+	current := &ActivityExecution{
+		Execution:       execution,
+		ProcessInstance: processInstance,
+		Node:            node,
+		Model:           processModel,
+		service:         s,
+		agenda:          a,
+	}
+	if err := s.handleActivityError(ctx, current, behavior.Trigger(ctx, current, variables)); err != nil {
+		return err
+	}
+	if err := s.runAgenda(ctx, a); err != nil {
+		return err
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// setVariablesLocked sets variables in a variable scope on behalf of an execution, e.g.
+// one in a subprocess setting a process variable. Callers must hold the write lock.
+func (s *runtimeServiceImpl) setVariablesLocked(executionID, scopeID string, variables map[string]interface{}) {
+	if len(variables) == 0 {
+		return
+	}
+	if s.variables[scopeID] == nil {
+		s.variables[scopeID] = make(map[string]interface{})
+	}
+	previous := make(map[string]interface{})
+	for k, v := range variables {
+		if old, exists := s.variables[scopeID][k]; exists {
+			previous[k] = old
+		}
+		s.variables[scopeID][k] = variable.Unwrap(v)
+		s.recordScopeChangesLocked(scopeID, k)
+	}
+	s.fireVariableUpdatesLocked(executionID, scopeID, variables, previous)
+}
@@ -0,0 +1,171 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// History event types
+const (
+	HistoryEventProcessInstanceStart  = "process-instance-start"
+	HistoryEventProcessInstanceEnd    = "process-instance-end"
+	HistoryEventProcessInstanceUpdate = "process-instance-update"
+	HistoryEventActivityStart         = "activity-start"
+	HistoryEventActivityEnd           = "activity-end"
+	HistoryEventVariableUpdate        = "variable-update"
+)
+
+// HistoryEventHandler is told about the progress of process instances, e.g. by the
+// history manager recording it to history. Events are delivered after the process
+// instance they belong to was saved, in the order they happened.
+type HistoryEventHandler interface {
+	// HandleHistoryEvent handles a history event
+	HandleHistoryEvent(ctx context.Context, event *HistoryEvent) error
+}
+
+// HistoryEvent describes a step in the progress of a process instance
+type HistoryEvent struct {
+	Type string
+	Time time.Time
+
+	// ProcessInstance is a copy of the process instance at the time of the event
+	ProcessInstance *ProcessInstance
+
+	// ExecutionID, ActivityID, ActivityName and ActivityType describe the activity
+	// of activity events, or the start or end event of process instance events
+	ExecutionID  string
+	ActivityID   string
+	ActivityName string
+	ActivityType string
+
+	// VariableName and VariableValue describe the variable of variable updates. For
+	// updates of an existing variable, OldValue holds the value it replaced; Created
+	// tells the update created the variable. The activity is the one of the execution
+	// setting the variable, if any.
+	VariableName  string
+	VariableValue interface{}
+	OldValue      interface{}
+	Created       bool
+
+	// DeleteReason tells why an activity or process instance ended before completion
+	DeleteReason string
+}
+
+// DeleteReasonCanceled is the delete reason of activities cancelled before they completed,
+// e.g. by an interrupting boundary event
+const DeleteReasonCanceled = "canceled"
+
+// DeleteReasonDeleted is the delete reason of process instances deleted without a reason
+const DeleteReasonDeleted = "deleted"
+
+// SetHistoryEventHandler sets the handler told about the progress of process instances
+func (s *runtimeServiceImpl) SetHistoryEventHandler(handler HistoryEventHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.historyHandler = handler
+}
+
+// fireHistoryEventLocked queues a history event of a process instance until the
+// instance is saved. Nothing is queued without a handler. Callers must hold the write lock.
+func (s *runtimeServiceImpl) fireHistoryEventLocked(eventType string, processInstance *ProcessInstance, executionID string, node *model.Node, apply func(event *HistoryEvent)) {
+	if s.historyHandler == nil || processInstance == nil {
+		return
+	}
+
+	snapshot := *processInstance
+	event := &HistoryEvent{
+		Type:            eventType,
+		Time:            s.clock.Now(),
+		ProcessInstance: &snapshot,
+		ExecutionID:     executionID,
+	}
+	if node != nil {
+		event.ActivityID = node.ID
+		event.ActivityName = node.Name
+		event.ActivityType = node.Type
+	}
+	if apply != nil {
+		apply(event)
+	}
+	s.historyEvents[processInstance.ID] = append(s.historyEvents[processInstance.ID], event)
+}
+
+// fireVariableUpdatesLocked queues the updates of variables of a scope execution made
+// by an execution; previous holds the replaced values of variables that existed.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) fireVariableUpdatesLocked(executionID, scopeID string, variables, previous map[string]interface{}) {
+	if s.historyHandler == nil {
+		return
+	}
+	scope, exists := s.executions[scopeID]
+	if !exists {
+		return
+	}
+	processInstance := s.processInstances[scope.ProcessInstanceID]
+	activityID := ""
+	if execution, exists := s.executions[executionID]; exists {
+		activityID = execution.ActivityID
+	}
+	for name, value := range variables {
+		old, updated := previous[name]
+		if updated {
+			// Large values that were saved are kept in the content store
+			old, _ = s.contents.Resolve(context.Background(), old)
+		}
+		s.fireHistoryEventLocked(HistoryEventVariableUpdate, processInstance, executionID, nil, func(event *HistoryEvent) {
+			event.ActivityID = activityID
+			event.VariableName = name
+			event.VariableValue = variable.Unwrap(value)
+			event.OldValue = old
+			event.Created = !updated
+		})
+	}
+}
+
+// fireActivityEndLocked queues the end of the activity an execution is at, if any.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) fireActivityEndLocked(current *ActivityExecution, deleteReason string) {
+	if current.Node == nil {
+		return
+	}
+	s.fireHistoryEventLocked(HistoryEventActivityEnd, current.ProcessInstance, current.Execution.ID, current.Node, func(event *HistoryEvent) {
+		event.DeleteReason = deleteReason
+	})
+}
+
+// discardHistoryEventsLocked drops the queued history events and process instance
+// events of process instances that were rolled back. Callers must hold the write lock.
+func (s *runtimeServiceImpl) discardHistoryEventsLocked(processInstanceIDs ...string) {
+	for _, id := range processInstanceIDs {
+		delete(s.historyEvents, id)
+		delete(s.listenerEvents, id)
+	}
+}
+
+// flushHistoryEvents delivers the queued history events of process instances to the
+// handler. The caller must not hold the lock.
+func (s *runtimeServiceImpl) flushHistoryEvents(ctx context.Context, processInstanceIDs ...string) error {
+	s.mu.Lock()
+	handler := s.historyHandler
+	events := make([]*HistoryEvent, 0)
+	for _, id := range processInstanceIDs {
+		events = append(events, s.historyEvents[id]...)
+		delete(s.historyEvents, id)
+	}
+	s.mu.Unlock()
+
+	if handler == nil {
+		return nil
+	}
+	for _, event := range events {
+		if err := handler.HandleHistoryEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to record %s of process instance %s: %w", event.Type, event.ProcessInstance.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// Defaults of the HTTP service task
+const (
+	defaultHTTPTimeout       = 30 * time.Second
+	defaultHTTPRetryInterval = time.Second
+)
+
+// httpServiceTask is the built-in service task delegate "http". It calls the URL of the
+// node and stores the response in variables. Properties:
+//
+//   - url: the URL, may contain ${...} expressions
+//   - method: the HTTP method, GET by default
+//   - headers: map of header values, may contain expressions
+//   - body: a string template, or an object encoded as JSON after evaluating its expressions
+//   - timeout: timeout of one attempt as a duration, 30s by default
+//   - retries, retryInterval: attempts after a transport error or a 429/5xx response
+//   - responseVariable, statusCodeVariable: variables receiving the decoded body and status code
+//
+// Responses with other non-2xx status codes throw a BusinessError with the code
+// "HTTP_<status>" that error boundary events can catch.
+type httpServiceTask struct {
+	client *http.Client
+}
+
+// Execute performs the request
+func (t *httpServiceTask) Execute(ctx context.Context, execution *ActivityExecution) error {
+	node := execution.Node
+	variables := execution.GetVariables()
+
+	request, err := newHTTPRequest(execution.ExpressionManager(), node, variables)
+	if err != nil {
+		return err
+	}
+
+	timeout, err := getDuration(node, "timeout", defaultHTTPTimeout)
+	if err != nil {
+		return err
+	}
+	retryInterval, err := getDuration(node, "retryInterval", defaultHTTPRetryInterval)
+	if err != nil {
+		return err
+	}
+	retries, _ := node.GetInt("retries")
+
+	var response *httpResponse
+	for attempt := 0; ; attempt++ {
+		response, err = t.do(ctx, request, timeout)
+		if !shouldRetryHTTP(response, err) || attempt >= retries {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(retryInterval):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", request.method, request.url, err)
+	}
+
+	result := make(map[string]interface{})
+	if name := node.GetString("statusCodeVariable"); name != "" {
+		result[name] = response.statusCode
+	}
+	if name := node.GetString("responseVariable"); name != "" {
+		result[name] = response.decodeBody()
+	}
+	execution.SetVariables(result)
+
+	if response.statusCode < 200 || response.statusCode > 299 {
+		return NewBusinessError(fmt.Sprintf("HTTP_%d", response.statusCode),
+			fmt.Sprintf("%s %s returned %d", request.method, request.url, response.statusCode))
+	}
+	return nil
+}
+
+// httpRequest is the evaluated request of an HTTP service task
+type httpRequest struct {
+	method  string
+	url     string
+	headers map[string]string
+	body    []byte
+}
+
+// httpResponse is the received response of one attempt
+type httpResponse struct {
+	statusCode  int
+	contentType string
+	body        []byte
+}
+
+// newHTTPRequest evaluates the request properties of a node
+func newHTTPRequest(expressions *expression.Manager, node *model.Node, variables map[string]interface{}) (*httpRequest, error) {
+	request := &httpRequest{
+		method:  strings.ToUpper(node.GetString("method")),
+		headers: make(map[string]string),
+	}
+	if request.method == "" {
+		request.method = http.MethodGet
+	}
+
+	url, err := evaluateTemplate(expressions, node.GetString("url"), variables)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url of http task '%s': %w", node.ID, err)
+	}
+	if url == "" {
+		return nil, fmt.Errorf("http task '%s' must have a 'url' property", node.ID)
+	}
+	request.url = url
+
+	for name, value := range node.GetMap("headers") {
+		header, err := evaluateTemplate(expressions, fmt.Sprintf("%v", value), variables)
+		if err != nil {
+			return nil, fmt.Errorf("invalid header '%s' of http task '%s': %w", name, node.ID, err)
+		}
+		request.headers[name] = header
+	}
+
+	switch body := node.Properties["body"].(type) {
+	case nil:
+	case string:
+		text, err := evaluateTemplate(expressions, body, variables)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body of http task '%s': %w", node.ID, err)
+		}
+		request.body = []byte(text)
+	default:
+		value, err := evaluateTemplates(expressions, body, variables)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body of http task '%s': %w", node.ID, err)
+		}
+		if request.body, err = json.Marshal(value); err != nil {
+			return nil, fmt.Errorf("invalid body of http task '%s': %w", node.ID, err)
+		}
+		if _, exists := request.headers["Content-Type"]; !exists {
+			request.headers["Content-Type"] = "application/json"
+		}
+	}
+	return request, nil
+}
+
+// do performs one attempt of a request
+func (t *httpServiceTask) do(ctx context.Context, request *httpRequest, timeout time.Duration) (*httpResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var body io.Reader
+	if request.body != nil {
+		body = bytes.NewReader(request.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, request.method, request.url, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range request.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &httpResponse{
+		statusCode:  resp.StatusCode,
+		contentType: resp.Header.Get("Content-Type"),
+		body:        data,
+	}, nil
+}
+
+// shouldRetryHTTP reports whether an attempt failed in a way worth retrying
+func shouldRetryHTTP(response *httpResponse, err error) bool {
+	if err != nil {
+		return true
+	}
+	return response.statusCode == http.StatusTooManyRequests || response.statusCode >= 500
+}
+
+// decodeBody returns JSON bodies decoded and other bodies as string
+func (r *httpResponse) decodeBody() interface{} {
+	if strings.Contains(r.contentType, "json") {
+		var value interface{}
+		if err := json.Unmarshal(r.body, &value); err == nil {
+			return value
+		}
+	}
+	return string(r.body)
+}
+
+// getDuration reads a duration property given in ISO 8601 or Go syntax
+func getDuration(node *model.Node, key string, defaultValue time.Duration) (time.Duration, error) {
+	value := node.GetString(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	duration, err := expression.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s of node '%s': %w", key, node.ID, err)
+	}
+	return duration, nil
+}
+
+// evaluateTemplate evaluates the ${...} expressions embedded in a string
+func evaluateTemplate(expressions *expression.Manager, template string, variables map[string]interface{}) (string, error) {
+	if !expression.IsExpression(template) {
+		return template, nil
+	}
+	value, err := expressions.Evaluate(template, variables)
+	if err != nil {
+		return "", err
+	}
+	if value == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// evaluateTemplates evaluates the expressions in the strings of a JSON-like value.
+// Strings consisting of a single expression keep the type of its result.
+func evaluateTemplates(expressions *expression.Manager, value interface{}, variables map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !expression.IsExpression(v) {
+			return v, nil
+		}
+		return expressions.Evaluate(v, variables)
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			evaluated, err := evaluateTemplates(expressions, item, variables)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = evaluated
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			evaluated, err := evaluateTemplates(expressions, item, variables)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = evaluated
+		}
+		return result, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,525 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/tracing"
+)
+
+// Job types
+const (
+	JobTypeAsyncBefore = "async-before" // runs the activity of an execution
+	JobTypeAsyncAfter  = "async-after"  // leaves the activity of an execution along the recorded sequence flows
+	JobTypeTimer       = "timer"        // fires a timer boundary, intermediate or event subprocess start event
+	JobTypeTimerStart  = "timer-start"  // starts a process instance at a timer start event
+
+	JobTypeSuspendProcessDefinition  = "suspend-process-definition"  // suspends a process definition at a scheduled date
+	JobTypeActivateProcessDefinition = "activate-process-definition" // activates a process definition at a scheduled date
+)
+
+// Defaults of jobs of nodes without "retries" and "retryInterval" properties
+const (
+	defaultJobRetries       = 3
+	defaultJobRetryInterval = 10 * time.Second
+)
+
+// Job is a continuation of a process instance that runs in the background, e.g. at
+// an activity marked "asyncBefore" or "asyncAfter", or a due timer. The caller that
+// created it returns once the state up to the job is saved; the job executor acquires
+// and runs it. Jobs of timer start events and scheduled suspensions belong to a
+// process definition only.
+type Job struct {
+	ID                      string
+	Type                    string
+	ProcessDefinitionID     string
+	ProcessInstanceID       string
+	ExecutionID             string
+	ActivityID              string
+	EdgeIDs                 []string   // sequence flows an async-after job takes
+	Cycle                   string     // cycle of a repeating timer
+	Repetitions             int        // occurrences of the cycle left, including this one; -1 if unbounded
+	IncludeProcessInstances bool       // a scheduled suspension applies to the process instances as well
+	Retries                 int        // attempts left; a failed job without retries left becomes a dead-letter job
+	ErrorMessage            string     // error of the last failed attempt
+	ExceptionStacktrace     string     // error chain of the last failed attempt and, if it panicked, the stack
+	DueDate                 time.Time  // the job is not run before this time, e.g. after a failure
+	LockOwner               string     // job executor running the job
+	LockExpirationTime      *time.Time // after this time other job executors may acquire the job
+	CreateTime              time.Time
+	TenantID                string
+	TraceContext            map[string]string // trace context of the command that created the job
+}
+
+// JobNotifier is told when jobs can be acquired, e.g. by the job executor to
+// acquire them without waiting for its next poll
+type JobNotifier interface {
+	JobsAvailable()
+}
+
+// IsLocked returns whether the job is locked by a job executor at the given point in time
+func (j *Job) IsLocked(now time.Time) bool {
+	return j.LockOwner != "" && j.LockExpirationTime != nil && j.LockExpirationTime.After(now)
+}
+
+// isAsyncBefore reports whether the activity of a node runs in a job. The "async"
+// property is a synonym of "asyncBefore".
+func isAsyncBefore(node *model.Node) bool {
+	return node.GetBool("asyncBefore") || node.GetBool("async")
+}
+
+// newJob creates a job of a node that is due at a point in time. Its retries
+// follow the retry policy of the node.
+func (s *runtimeServiceImpl) newJob(node *model.Node, jobType string, dueDate time.Time) *Job {
+	retries := defaultJobRetries
+	if policy, err := getRetryPolicy(node); err == nil {
+		retries = policy.retries
+	}
+
+	return &Job{
+		ID:         uuid.New().String(),
+		Type:       jobType,
+		ActivityID: node.ID,
+		Retries:    retries,
+		DueDate:    dueDate,
+		CreateTime: s.clock.Now(),
+	}
+}
+
+// isProcessDefinitionJob reports whether a job belongs to a process definition
+// rather than a process instance
+func isProcessDefinitionJob(job *Job) bool {
+	switch job.Type {
+	case JobTypeTimerStart, JobTypeSuspendProcessDefinition, JobTypeActivateProcessDefinition:
+		return true
+	}
+	return false
+}
+
+// createJobLocked creates and schedules a job for an execution at a node. The job
+// keeps the trace context, so that the continuation joins the trace of the command.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) createJobLocked(ctx context.Context, execution *Execution, node *model.Node, jobType string, edges []*model.Edge) {
+	job := s.newJob(node, jobType, s.clock.Now())
+	job.TraceContext = make(map[string]string)
+	s.tracer.Inject(ctx, job.TraceContext)
+	if len(job.TraceContext) == 0 {
+		job.TraceContext = nil
+	}
+	job.ProcessInstanceID = execution.ProcessInstanceID
+	job.ExecutionID = execution.ID
+	job.TenantID = execution.TenantID
+	if processInstance := s.processInstances[execution.ProcessInstanceID]; processInstance != nil {
+		job.ProcessDefinitionID = processInstance.ProcessDefinitionID
+	}
+	for _, edge := range edges {
+		job.EdgeIDs = append(job.EdgeIDs, edge.ID)
+	}
+
+	s.jobs[job.ID] = job
+	s.scheduleJobLocked(job)
+}
+
+// scheduleJobLocked tells the job executor, if any, that a job can be acquired, or
+// arranges to tell it at the due date of the job. Jobs without retries left wait
+// until their retries are set again. Callers must hold the write lock.
+func (s *runtimeServiceImpl) scheduleJobLocked(job *Job) {
+	if s.jobNotifier == nil || s.stopped || job.Retries <= 0 {
+		return
+	}
+
+	notifier := s.jobNotifier
+	if delay := job.DueDate.Sub(s.clock.Now()); delay > 0 {
+		time.AfterFunc(delay, notifier.JobsAvailable)
+		return
+	}
+	notifier.JobsAvailable()
+}
+
+// deleteJobsLocked removes the jobs and dead-letter jobs of an execution.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) deleteJobsLocked(executionID string) {
+	for id, job := range s.jobs {
+		if job.ExecutionID == executionID {
+			delete(s.jobs, id)
+		}
+	}
+	for id, job := range s.deadLetterJobs {
+		if job.ExecutionID == executionID {
+			delete(s.deadLetterJobs, id)
+		}
+	}
+}
+
+// rescheduleJobsLocked schedules the pending jobs of a process instance again,
+// e.g. after it was activated. Callers must hold the write lock.
+func (s *runtimeServiceImpl) rescheduleJobsLocked(processInstanceID string) {
+	for _, job := range s.jobs {
+		if job.ProcessInstanceID == processInstanceID {
+			s.scheduleJobLocked(job)
+		}
+	}
+}
+
+// SetTracer sets the tracer reporting spans of jobs and service tasks
+func (s *runtimeServiceImpl) SetTracer(tracer tracing.Tracer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tracer == nil {
+		tracer = tracing.NoopTracer()
+	}
+	s.tracer = tracer
+}
+
+// SetJobNotifier sets the notifier told when jobs can be acquired
+func (s *runtimeServiceImpl) SetJobNotifier(notifier JobNotifier) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobNotifier = notifier
+}
+
+// AcquireJobs locks up to maxJobs due jobs for a lock owner, earliest due date first.
+// Jobs of suspended process instances, jobs without retries left and jobs locked by
+// another owner are skipped; locks expire after lockDuration. The jobs of timer
+// start events are brought up to date with the deployed process definitions first.
+func (s *runtimeServiceImpl) AcquireJobs(ctx context.Context, lockOwner string, maxJobs int, lockDuration time.Duration) ([]*Job, error) {
+	if lockOwner == "" {
+		return nil, fmt.Errorf("lock owner cannot be empty")
+	}
+	if maxJobs <= 0 {
+		return nil, fmt.Errorf("max jobs must be positive")
+	}
+
+	if err := s.syncTimerStartJobs(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	candidates := make([]*Job, 0)
+	for _, job := range s.jobs {
+		if job.Retries <= 0 || job.DueDate.After(now) || job.IsLocked(now) {
+			continue
+		}
+		if isProcessDefinitionJob(job) {
+			candidates = append(candidates, job)
+			continue
+		}
+		if processInstance := s.processInstances[job.ProcessInstanceID]; processInstance == nil || processInstance.Suspended {
+			continue
+		}
+		candidates = append(candidates, job)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].DueDate.Before(candidates[j].DueDate)
+	})
+	if len(candidates) > maxJobs {
+		candidates = candidates[:maxJobs]
+	}
+
+	expiration := now.Add(lockDuration)
+	for _, job := range candidates {
+		lockExpiration := expiration
+		job.LockOwner = lockOwner
+		job.LockExpirationTime = &lockExpiration
+	}
+	return candidates, nil
+}
+
+// ExecuteJob runs a job in a span continuing the trace the job was created in
+func (s *runtimeServiceImpl) ExecuteJob(ctx context.Context, jobID string) error {
+	s.mu.RLock()
+	job, exists := s.jobs[jobID]
+	if !exists {
+		s.mu.RUnlock()
+		return fmt.Errorf("job %w: %s", errors.ErrNotFound, jobID)
+	}
+	attributes := []tracing.Attribute{
+		tracing.String(tracing.AttributeJobID, job.ID),
+		tracing.String(tracing.AttributeJobType, job.Type),
+		tracing.String(tracing.AttributeActivityID, job.ActivityID),
+		tracing.String(tracing.AttributeProcessDefinitionID, job.ProcessDefinitionID),
+		tracing.String(tracing.AttributeProcessInstanceID, job.ProcessInstanceID),
+	}
+	if processInstance := s.processInstances[job.ProcessInstanceID]; processInstance != nil {
+		attributes = append(attributes, tracing.String(tracing.AttributeProcessDefinitionKey, processInstance.ProcessDefinitionKey))
+	}
+	tracer, traceContext := s.tracer, job.TraceContext
+	s.mu.RUnlock()
+
+	if len(traceContext) > 0 {
+		ctx = tracer.Extract(ctx, traceContext)
+	}
+	ctx, span := tracer.Start(ctx, "Job "+job.Type, attributes...)
+	defer span.End()
+
+	err := s.executeJob(ctx, jobID)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return err
+}
+
+// executeJob runs a job. When it fails, the job is kept with one retry less and
+// is due again after the wait of the retry policy of its node.
+func (s *runtimeServiceImpl) executeJob(ctx context.Context, jobID string) error {
+	s.mu.Lock()
+	job, exists := s.jobs[jobID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("job %w: %s", errors.ErrNotFound, jobID)
+	}
+	switch job.Type {
+	case JobTypeTimerStart:
+		s.mu.Unlock()
+		return s.executeTimerStartJob(ctx, job)
+	case JobTypeSuspendProcessDefinition, JobTypeActivateProcessDefinition:
+		s.mu.Unlock()
+		return s.executeSuspensionJob(ctx, job)
+	}
+	processInstance := s.processInstances[job.ProcessInstanceID]
+	execution := s.executions[job.ExecutionID]
+	if processInstance == nil || execution == nil {
+		delete(s.jobs, jobID)
+		s.mu.Unlock()
+		return nil
+	}
+	if processInstance.Suspended {
+		// The job is skipped without using up a retry; it is acquired again once the
+		// process instance is activated
+		job.LockOwner = ""
+		job.LockExpirationTime = nil
+		s.mu.Unlock()
+		return &errors.SuspendedError{EntityType: "process instance", ID: processInstance.ID}
+	}
+	delete(s.jobs, jobID)
+	s.mu.Unlock()
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+	node := processModel.GetNode(job.ActivityID)
+	if node == nil {
+		return fmt.Errorf("node %w: %s", errors.ErrNotFound, job.ActivityID)
+	}
+
+	a := &agenda{}
+	a.touch(processInstance.ID)
+	err = func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = &jobPanicError{value: r, stack: debug.Stack()}
+			}
+		}()
+		if err := s.runJob(ctx, a, job, execution, processInstance, processModel, node); err != nil {
+			return err
+		}
+		return s.runAgenda(ctx, a)
+	}()
+	if err != nil {
+		return s.failJob(ctx, a, job, node, err)
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// jobPanicError is the error of a job attempt that panicked
+type jobPanicError struct {
+	value interface{}
+	stack []byte
+}
+
+// Error returns the panic value
+func (e *jobPanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+// formatStacktrace describes a job failure: each error of the chain on its own
+// line and, for a panic, the stack of the panicking goroutine
+func formatStacktrace(err error) string {
+	var b strings.Builder
+	var panicked *jobPanicError
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		if b.Len() > 0 {
+			b.WriteString("caused by: ")
+		}
+		b.WriteString(current.Error())
+		b.WriteString("\n")
+		if p, ok := current.(*jobPanicError); ok {
+			panicked = p
+		}
+	}
+	if panicked != nil {
+		b.Write(panicked.stack)
+	}
+	return b.String()
+}
+
+// runJob plans the continuation of a job on an agenda
+func (s *runtimeServiceImpl) runJob(ctx context.Context, a *agenda, job *Job, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, node *model.Node) error {
+	switch job.Type {
+	case JobTypeAsyncBefore:
+		return s.runActivity(ctx, a, execution, processInstance, processModel, node)
+
+	case JobTypeTimer:
+		return s.runTimerJob(ctx, a, job, execution, processInstance, processModel, node)
+
+	case JobTypeAsyncAfter:
+		edges := make([]*model.Edge, 0, len(job.EdgeIDs))
+		for _, edgeID := range job.EdgeIDs {
+			for _, edge := range processModel.GetOutgoingEdges(node.ID) {
+				if edge.ID == edgeID {
+					edges = append(edges, edge)
+				}
+			}
+		}
+		current := &ActivityExecution{
+			Execution:       execution,
+			ProcessInstance: processInstance,
+			Node:            node,
+			Model:           processModel,
+			service:         s,
+			agenda:          a,
+		}
+		if len(edges) == 0 {
+			current.agenda.plan(func(ctx context.Context) error {
+				return s.endExecution(ctx, current)
+			})
+			return nil
+		}
+		return s.followEdges(current, edges)
+	}
+	return fmt.Errorf("unsupported job type '%s'", job.Type)
+}
+
+// failJob records a failed attempt of a job and saves the process instance. The job
+// is retried after the wait of the retry policy of its node; a job without retries
+// left becomes a dead-letter job that waits for an operator.
+func (s *runtimeServiceImpl) failJob(ctx context.Context, a *agenda, job *Job, node *model.Node, cause error) error {
+	policy, err := getRetryPolicy(node)
+	if err != nil {
+		s.logger.WarnContext(ctx, "using the default retry policy", slog.String("jobId", job.ID), slog.Any("error", err))
+		policy = retryPolicy{retries: defaultJobRetries}
+	}
+
+	s.mu.Lock()
+	job.Retries--
+	job.ErrorMessage = cause.Error()
+	job.ExceptionStacktrace = formatStacktrace(cause)
+	job.DueDate = s.clock.Now().Add(policy.retryInterval(job.Retries))
+	job.LockOwner = ""
+	job.LockExpirationTime = nil
+	if _, exists := s.executions[job.ExecutionID]; exists {
+		if job.Retries <= 0 {
+			job.Retries = 0
+			s.deadLetterJobs[job.ID] = job
+			s.logger.ErrorContext(ctx, "job has no retries left and was moved to the dead-letter jobs",
+				slog.String("jobId", job.ID), slog.String("activityId", job.ActivityID), slog.Any("error", cause))
+		} else {
+			s.jobs[job.ID] = job
+			s.scheduleJobLocked(job)
+		}
+	}
+	s.mu.Unlock()
+
+	if err := s.saveProcessInstances(ctx, append(a.touched, job.ProcessInstanceID)...); err != nil {
+		return err
+	}
+	return fmt.Errorf("job '%s' failed: %w", job.ID, cause)
+}
+
+// GetJob retrieves a job by ID
+func (s *runtimeServiceImpl) GetJob(ctx context.Context, jobID string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("job %w: %s", errors.ErrNotFound, jobID)
+	}
+	return job, nil
+}
+
+// GetJobs returns the jobs of a process instance, oldest first. An empty
+// processInstanceID returns the jobs of process definitions, e.g. of timer start events.
+func (s *runtimeServiceImpl) GetJobs(ctx context.Context, processInstanceID string) ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Job, 0)
+	for _, job := range s.jobs {
+		if job.ProcessInstanceID == processInstanceID {
+			result = append(result, job)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreateTime.Before(result[j].CreateTime)
+	})
+	return result, nil
+}
+
+// ListJobs returns all jobs, oldest first
+func (s *runtimeServiceImpl) ListJobs(ctx context.Context) ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		result = append(result, job)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreateTime.Before(result[j].CreateTime)
+	})
+	return result, nil
+}
+
+// SetJobRetries sets the retries of a job. Positive retries move a dead-letter job
+// back to the jobs the job executor acquires.
+func (s *runtimeServiceImpl) SetJobRetries(ctx context.Context, jobID string, retries int) error {
+	if retries < 0 {
+		return fmt.Errorf("retries cannot be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		job, exists = s.deadLetterJobs[jobID]
+		if !exists {
+			return fmt.Errorf("job %w: %s", errors.ErrNotFound, jobID)
+		}
+		if retries == 0 {
+			return nil
+		}
+		delete(s.deadLetterJobs, jobID)
+		s.jobs[jobID] = job
+	}
+
+	job.Retries = retries
+	// Failed jobs are due right away; timers keep their due date
+	if job.ErrorMessage != "" {
+		job.DueDate = s.clock.Now()
+	}
+	if isProcessDefinitionJob(job) {
+		s.scheduleJobLocked(job)
+		return s.saveJobLocked(ctx, job.ID)
+	}
+	if processInstance := s.processInstances[job.ProcessInstanceID]; processInstance != nil && !processInstance.Suspended {
+		s.scheduleJobLocked(job)
+	}
+	return s.saveProcessInstanceLocked(ctx, job.ProcessInstanceID)
+}
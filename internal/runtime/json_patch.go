@@ -0,0 +1,286 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JSON patch operations (RFC 6902)
+const (
+	PatchOpAdd     = "add"
+	PatchOpRemove  = "remove"
+	PatchOpReplace = "replace"
+	PatchOpMove    = "move"
+	PatchOpCopy    = "copy"
+	PatchOpTest    = "test"
+)
+
+// PatchOperation is a single operation of a JSON patch document.
+// Paths are JSON pointers whose first segment is the variable name,
+// e.g. "/order/items/0/quantity".
+type PatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// ParsePatch parses a JSON patch document
+func ParsePatch(patch []byte) ([]PatchOperation, error) {
+	var operations []PatchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("invalid JSON patch: %w", err)
+	}
+	return operations, nil
+}
+
+// applyPatch applies the operations to a copy of the variables and returns the copy.
+// Variables touched by the patch are stored in their JSON form afterwards.
+// The input map is never modified, so a failing patch leaves no partial changes.
+func applyPatch(variables map[string]interface{}, operations []PatchOperation) (map[string]interface{}, error) {
+	working := make(map[string]interface{}, len(variables))
+	for k, v := range variables {
+		working[k] = v
+	}
+
+	// Detach every variable the patch reads or writes from the original values
+	normalized := make(map[string]bool)
+	for _, operation := range operations {
+		for _, path := range []string{operation.Path, operation.From} {
+			tokens, err := parsePointer(path)
+			if err != nil || len(tokens) == 0 || normalized[tokens[0]] {
+				continue
+			}
+			if value, exists := working[tokens[0]]; exists {
+				copied, err := deepCopyJSON(value)
+				if err != nil {
+					return nil, fmt.Errorf("variable '%s' cannot be patched: %w", tokens[0], err)
+				}
+				working[tokens[0]] = copied
+			}
+			normalized[tokens[0]] = true
+		}
+	}
+
+	var root interface{} = working
+	for i, operation := range operations {
+		if err := applyOperation(&root, operation); err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %s) failed: %w", i, operation.Op, operation.Path, err)
+		}
+	}
+	return working, nil
+}
+
+// applyOperation applies a single operation to the document root
+func applyOperation(root *interface{}, operation PatchOperation) error {
+	tokens, err := parsePointer(operation.Path)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("patching the whole variable scope is not supported")
+	}
+
+	switch operation.Op {
+	case PatchOpAdd, PatchOpReplace:
+		value, err := deepCopyJSON(operation.Value)
+		if err != nil {
+			return err
+		}
+		return updateAt(root, tokens, operation.Op, value)
+
+	case PatchOpRemove:
+		return updateAt(root, tokens, PatchOpRemove, nil)
+
+	case PatchOpTest:
+		actual, err := getAt(*root, tokens)
+		if err != nil {
+			return err
+		}
+		expected, err := deepCopyJSON(operation.Value)
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			return fmt.Errorf("test failed: value at '%s' does not match", operation.Path)
+		}
+		return nil
+
+	case PatchOpMove, PatchOpCopy:
+		fromTokens, err := parsePointer(operation.From)
+		if err != nil {
+			return err
+		}
+		if len(fromTokens) == 0 {
+			return fmt.Errorf("'from' must reference a variable")
+		}
+		value, err := getAt(*root, fromTokens)
+		if err != nil {
+			return err
+		}
+		value, err = deepCopyJSON(value)
+		if err != nil {
+			return err
+		}
+		if operation.Op == PatchOpMove {
+			if strings.HasPrefix(operation.Path+"/", operation.From+"/") && operation.Path != operation.From {
+				return fmt.Errorf("cannot move a value into one of its children")
+			}
+			if err := updateAt(root, fromTokens, PatchOpRemove, nil); err != nil {
+				return err
+			}
+		}
+		return updateAt(root, tokens, PatchOpAdd, value)
+
+	default:
+		return fmt.Errorf("unsupported operation: %s", operation.Op)
+	}
+}
+
+// getAt returns the value referenced by the pointer tokens
+func getAt(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			value, exists := n[token]
+			if !exists {
+				return nil, fmt.Errorf("path segment '%s' not found", token)
+			}
+			node = value
+		case []interface{}:
+			index, err := parseIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("path segment '%s' does not reference an object or array", token)
+		}
+	}
+	return node, nil
+}
+
+// updateAt adds, replaces or removes the value referenced by the pointer tokens.
+// The node is updated in place; slices are written back since they may be reallocated.
+func updateAt(node *interface{}, tokens []string, op string, value interface{}) error {
+	token := tokens[0]
+	last := len(tokens) == 1
+
+	switch n := (*node).(type) {
+	case map[string]interface{}:
+		child, exists := n[token]
+		if !last {
+			if !exists {
+				return fmt.Errorf("path segment '%s' not found", token)
+			}
+			if err := updateAt(&child, tokens[1:], op, value); err != nil {
+				return err
+			}
+			n[token] = child
+			return nil
+		}
+		switch op {
+		case PatchOpAdd:
+			n[token] = value
+		case PatchOpReplace:
+			if !exists {
+				return fmt.Errorf("path segment '%s' not found", token)
+			}
+			n[token] = value
+		case PatchOpRemove:
+			if !exists {
+				return fmt.Errorf("path segment '%s' not found", token)
+			}
+			delete(n, token)
+		}
+		return nil
+
+	case []interface{}:
+		if !last {
+			index, err := parseIndex(token, len(n)-1)
+			if err != nil {
+				return err
+			}
+			child := n[index]
+			if err := updateAt(&child, tokens[1:], op, value); err != nil {
+				return err
+			}
+			n[index] = child
+			return nil
+		}
+		switch op {
+		case PatchOpAdd:
+			index := len(n)
+			if token != "-" {
+				var err error
+				if index, err = parseIndex(token, len(n)); err != nil {
+					return err
+				}
+			}
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+		case PatchOpReplace:
+			index, err := parseIndex(token, len(n)-1)
+			if err != nil {
+				return err
+			}
+			n[index] = value
+		case PatchOpRemove:
+			index, err := parseIndex(token, len(n)-1)
+			if err != nil {
+				return err
+			}
+			n = append(n[:index], n[index+1:]...)
+		}
+		*node = n
+		return nil
+
+	default:
+		return fmt.Errorf("path segment '%s' does not reference an object or array", token)
+	}
+}
+
+// parsePointer splits a JSON pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer: %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// parseIndex parses an array index and checks it against the maximum allowed index
+func parseIndex(token string, maxIndex int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+	if index > maxIndex {
+		return 0, fmt.Errorf("array index out of bounds: %d", index)
+	}
+	return index, nil
+}
+
+// deepCopyJSON converts a value into its generic JSON form, which also makes a deep copy
+func deepCopyJSON(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied interface{}
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
@@ -0,0 +1,132 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// Message correlation result types
+const (
+	MessageCorrelationResultTypeExecution         = "execution"
+	MessageCorrelationResultTypeProcessDefinition = "processDefinition"
+)
+
+// MessageCorrelationResult describes where a correlated message was delivered: to an
+// execution waiting for it, or to a message start event that started a process instance
+type MessageCorrelationResult struct {
+	ResultType      string
+	Execution       *Execution // the execution that received the message, if any
+	ProcessInstance *ProcessInstance
+}
+
+// CorrelateMessage delivers a message to exactly one receiver. Executions waiting for the
+// message are considered first, narrowed by business key and correlation keys (variables
+// the execution must see with equal values); without one, a message start event starts
+// a new process instance with the business key. It fails if no receiver or more than
+// one matches.
+func (s *runtimeServiceImpl) CorrelateMessage(ctx context.Context, messageName, businessKey string, correlationKeys, variables map[string]interface{}) (*MessageCorrelationResult, error) {
+	matches, err := s.findMessageSubscriptions(messageName, businessKey, correlationKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+	case 1:
+		subscription := matches[0]
+		s.mu.RLock()
+		result := &MessageCorrelationResult{
+			ResultType:      MessageCorrelationResultTypeExecution,
+			Execution:       s.executions[subscription.ExecutionID],
+			ProcessInstance: s.processInstances[subscription.ProcessInstanceID],
+		}
+		s.mu.RUnlock()
+
+		if err := s.deliverEvent(ctx, subscription, variables); err != nil {
+			return nil, err
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("message '%s' is ambiguous: %d executions match", messageName, len(matches))
+	}
+
+	// Correlation keys refer to variables of a running instance; a new one has none
+	if len(correlationKeys) > 0 {
+		return nil, fmt.Errorf("no execution found for message '%s' with the given correlation keys", messageName)
+	}
+
+	subscriptions, err := s.repositoryService.GetStartEventSubscriptions(ctx, repository.EventTypeMessage, messageName)
+	if err != nil {
+		return nil, err
+	}
+	switch len(subscriptions) {
+	case 0:
+		return nil, fmt.Errorf("no execution or message start event found for message: %s", messageName)
+	case 1:
+	default:
+		return nil, fmt.Errorf("message start event '%s' is ambiguous: %d process definitions subscribe to it", messageName, len(subscriptions))
+	}
+
+	processInstance, err := s.startProcessInstanceBySubscription(ctx, subscriptions[0], businessKey, variables)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageCorrelationResult{
+		ResultType:      MessageCorrelationResultTypeProcessDefinition,
+		ProcessInstance: processInstance,
+	}, nil
+}
+
+// findMessageSubscriptions returns the subscriptions to a message of executions that
+// match a business key and correlation keys, oldest first. Subscriptions of suspended
+// process instances are skipped.
+func (s *runtimeServiceImpl) findMessageSubscriptions(messageName, businessKey string, correlationKeys map[string]interface{}) ([]*EventSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]*EventSubscription, 0)
+	for _, subscription := range s.subscriptions {
+		if subscription.EventType != repository.EventTypeMessage || subscription.EventName != messageName {
+			continue
+		}
+		processInstance := s.processInstances[subscription.ProcessInstanceID]
+		if processInstance == nil || processInstance.Suspended {
+			continue
+		}
+		if businessKey != "" && processInstance.BusinessKey != businessKey {
+			continue
+		}
+
+		if len(correlationKeys) > 0 {
+			variables, err := s.getVariablesLocked(subscription.ExecutionID)
+			if err != nil {
+				return nil, err
+			}
+			if !matchesCorrelationKeys(variables, correlationKeys) {
+				continue
+			}
+		}
+		matches = append(matches, subscription)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].CreateTime.Before(matches[j].CreateTime)
+	})
+	return matches, nil
+}
+
+// matchesCorrelationKeys reports whether variables hold every correlation key with an
+// equal value, numbers of different types by value
+func matchesCorrelationKeys(variables, correlationKeys map[string]interface{}) bool {
+	for name, expected := range correlationKeys {
+		actual, exists := variables[name]
+		if !exists || !variable.Equal(actual, expected) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/pkg/paging"
+)
+
+// NativeProcessInstanceQuery selects running process instances with a native SQL query
+// or a filter expression, for reporting queries the ProcessInstanceQuery API cannot express.
+//
+// The SQL query runs against the process instance table (persistence.TableProcessInstances)
+// and must select process instance IDs in its first column; parameters are referenced as
+// #{name}. The filter is an expression evaluated for every running process instance, with
+// the instance available as "processInstance", its variables as "variables" and the
+// parameters by name, e.g. ${variables.amount > limit}. When both are set, the filter
+// applies to the instances selected by the SQL query. The SQL query requires a database;
+// the filter also works in memory.
+type NativeProcessInstanceQuery struct {
+	sql         string
+	filter      string
+	parameters  map[string]interface{}
+	firstResult int
+	maxResults  int
+	service     RuntimeService
+}
+
+// SQL sets the native SQL query selecting process instance IDs
+func (q *NativeProcessInstanceQuery) SQL(sql string) *NativeProcessInstanceQuery {
+	q.sql = sql
+	return q
+}
+
+// Filter sets the expression a process instance must satisfy
+func (q *NativeProcessInstanceQuery) Filter(filter string) *NativeProcessInstanceQuery {
+	q.filter = filter
+	return q
+}
+
+// Parameter sets a named parameter of the SQL query or filter
+func (q *NativeProcessInstanceQuery) Parameter(name string, value interface{}) *NativeProcessInstanceQuery {
+	if q.parameters == nil {
+		q.parameters = make(map[string]interface{})
+	}
+	q.parameters[name] = value
+	return q
+}
+
+// List executes the query and returns a list of running process instances
+func (q *NativeProcessInstanceQuery) List(ctx context.Context) ([]*ProcessInstance, error) {
+	if impl, ok := q.service.(*runtimeServiceImpl); ok {
+		processInstances, err := impl.listProcessInstancesNative(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return paging.Paginate(processInstances, q.firstResult, q.maxResults), nil
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// ListPage executes the query and returns a page of process instances
+func (q *NativeProcessInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*ProcessInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching process instances
+func (q *NativeProcessInstanceQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	processInstances, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(processInstances)), nil
+}
+
+// SingleResult returns a single process instance or error if not exactly one result
+func (q *NativeProcessInstanceQuery) SingleResult(ctx context.Context) (*ProcessInstance, error) {
+	processInstances, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(processInstances) != 1 {
+		return nil, fmt.Errorf("expected one process instance, found %d", len(processInstances))
+	}
+	return processInstances[0], nil
+}
+
+// CreateNativeProcessInstanceQuery creates a new native process instance query
+func (s *runtimeServiceImpl) CreateNativeProcessInstanceQuery() *NativeProcessInstanceQuery {
+	return &NativeProcessInstanceQuery{
+		service: s,
+	}
+}
+
+// listProcessInstancesNative executes a native process instance query. Instances selected
+// by SQL keep the order of the query; otherwise they are returned in the order they started.
+func (s *runtimeServiceImpl) listProcessInstancesNative(ctx context.Context, q *NativeProcessInstanceQuery) ([]*ProcessInstance, error) {
+	if q.sql == "" && q.filter == "" {
+		return nil, fmt.Errorf("native process instance query requires a SQL query or a filter")
+	}
+
+	if q.filter != "" {
+		if _, err := s.expressions.Compile(q.filter); err != nil {
+			return nil, fmt.Errorf("invalid process instance query filter: %w", err)
+		}
+	}
+
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+
+	var candidateIDs []string
+	if q.sql != "" {
+		if store == nil {
+			return nil, fmt.Errorf("native SQL process instance queries require a database")
+		}
+		ids, err := store.QueryIDs(ctx, q.sql, q.parameters)
+		if err != nil {
+			return nil, err
+		}
+		candidateIDs = ids
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	candidates := make([]*ProcessInstance, 0)
+	if q.sql != "" {
+		seen := make(map[string]bool)
+		for _, id := range candidateIDs {
+			if processInstance, exists := s.processInstances[id]; exists && processInstance.EndTime == nil && !seen[id] && identity.IsTenantAccessible(ctx, processInstance.TenantID) {
+				seen[id] = true
+				candidates = append(candidates, processInstance)
+			}
+		}
+	} else {
+		for _, processInstance := range s.processInstances {
+			if processInstance.EndTime == nil && identity.IsTenantAccessible(ctx, processInstance.TenantID) {
+				candidates = append(candidates, processInstance)
+			}
+		}
+		paging.Sort(candidates, func(a, b *ProcessInstance) bool {
+			return a.ID < b.ID
+		}, func(a, b *ProcessInstance) bool {
+			return a.StartTime.Before(b.StartTime)
+		})
+	}
+	if q.filter == "" {
+		return candidates, nil
+	}
+
+	result := make([]*ProcessInstance, 0, len(candidates))
+	for _, processInstance := range candidates {
+		processVariables, err := s.getVariablesLocked(processInstance.ID)
+		if err != nil {
+			return nil, err
+		}
+		variables := make(map[string]interface{}, len(q.parameters)+2)
+		for name, value := range q.parameters {
+			variables[name] = value
+		}
+		variables["processInstance"] = processInstance
+		variables["variables"] = processVariables
+
+		matches, err := s.expressions.EvaluateBool(q.filter, variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate process instance query filter for %s: %w", processInstance.ID, err)
+		}
+		if matches {
+			result = append(result, processInstance)
+		}
+	}
+	return result, nil
+}
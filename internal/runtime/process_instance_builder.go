@@ -0,0 +1,183 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// ProcessInstanceBuilder provides a fluent API for starting a process instance with
+// any combination of options, e.g. a business key, a name and the activities to start at
+type ProcessInstanceBuilder struct {
+	processDefinitionID    string
+	processDefinitionKey   string
+	tenantID               string
+	businessKey            string
+	name                   string
+	tags                   []string
+	startBeforeActivityIDs []string
+	initiator              string
+	variables              map[string]interface{}
+	transientVariables     map[string]interface{}
+	service                RuntimeService
+}
+
+// ProcessDefinitionID selects the process definition to start by ID
+func (b *ProcessInstanceBuilder) ProcessDefinitionID(id string) *ProcessInstanceBuilder {
+	b.processDefinitionID = id
+	return b
+}
+
+// ProcessDefinitionKey selects the latest version of the process definition to start by key
+func (b *ProcessInstanceBuilder) ProcessDefinitionKey(key string) *ProcessInstanceBuilder {
+	b.processDefinitionKey = key
+	return b
+}
+
+// TenantID selects the process definition of a tenant when starting by key
+func (b *ProcessInstanceBuilder) TenantID(tenantID string) *ProcessInstanceBuilder {
+	b.tenantID = tenantID
+	return b
+}
+
+// BusinessKey sets the business key of the process instance
+func (b *ProcessInstanceBuilder) BusinessKey(businessKey string) *ProcessInstanceBuilder {
+	b.businessKey = businessKey
+	return b
+}
+
+// ProcessInstanceName sets the human-readable name of the process instance
+func (b *ProcessInstanceBuilder) ProcessInstanceName(name string) *ProcessInstanceBuilder {
+	b.name = name
+	return b
+}
+
+// Tags attaches tags to the process instance
+func (b *ProcessInstanceBuilder) Tags(tags ...string) *ProcessInstanceBuilder {
+	b.tags = append(b.tags, tags...)
+	return b
+}
+
+// StartBeforeActivity starts the process instance at an activity instead of its start
+// event. Activities of several calls start as concurrent paths; they have to be at the
+// top level of the process, not inside a subprocess.
+func (b *ProcessInstanceBuilder) StartBeforeActivity(activityID string) *ProcessInstanceBuilder {
+	b.startBeforeActivityIDs = append(b.startBeforeActivityIDs, activityID)
+	return b
+}
+
+// Initiator sets the user starting the process instance, e.g. when a service starts it
+// on behalf of a user; by default it is the authenticated user
+func (b *ProcessInstanceBuilder) Initiator(userID string) *ProcessInstanceBuilder {
+	b.initiator = userID
+	return b
+}
+
+// SetVariable sets a variable of the process instance
+func (b *ProcessInstanceBuilder) SetVariable(name string, value interface{}) *ProcessInstanceBuilder {
+	return b.SetVariables(map[string]interface{}{name: value})
+}
+
+// SetVariables sets variables of the process instance
+func (b *ProcessInstanceBuilder) SetVariables(variables map[string]interface{}) *ProcessInstanceBuilder {
+	if b.variables == nil {
+		b.variables = make(map[string]interface{})
+	}
+	for k, v := range variables {
+		b.variables[k] = v
+	}
+	return b
+}
+
+// SetTransientVariable sets a variable that the process instance can read until it
+// first waits, e.g. a form submission evaluated by gateways, but that is neither
+// stored nor recorded in the history
+func (b *ProcessInstanceBuilder) SetTransientVariable(name string, value interface{}) *ProcessInstanceBuilder {
+	if b.transientVariables == nil {
+		b.transientVariables = make(map[string]interface{})
+	}
+	b.transientVariables[name] = value
+	return b
+}
+
+// Execute starts the process instance
+func (b *ProcessInstanceBuilder) Execute(ctx context.Context) (*ProcessInstance, error) {
+	if impl, ok := b.service.(*runtimeServiceImpl); ok {
+		return impl.startProcessInstanceWithBuilder(ctx, b)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// CreateProcessInstanceBuilder creates a builder for starting a process instance
+func (s *runtimeServiceImpl) CreateProcessInstanceBuilder() *ProcessInstanceBuilder {
+	return &ProcessInstanceBuilder{service: s}
+}
+
+// startProcessInstanceWithBuilder starts a process instance with the options of a builder
+func (s *runtimeServiceImpl) startProcessInstanceWithBuilder(ctx context.Context, b *ProcessInstanceBuilder) (*ProcessInstance, error) {
+	var processDefinition *repository.ProcessDefinition
+	var err error
+	switch {
+	case b.processDefinitionID != "":
+		processDefinition, err = s.repositoryService.GetProcessDefinition(ctx, b.processDefinitionID)
+	case b.processDefinitionKey != "" && b.tenantID != "":
+		processDefinition, err = s.repositoryService.GetProcessDefinitionByKeyAndTenantID(ctx, b.processDefinitionKey, b.tenantID)
+	case b.processDefinitionKey != "":
+		processDefinition, err = s.repositoryService.GetProcessDefinitionByKey(ctx, b.processDefinitionKey)
+	default:
+		return nil, fmt.Errorf("process definition ID or key is required")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process definition: %w", err)
+	}
+	tags := make([]string, 0, len(b.tags))
+	for _, tag := range b.tags {
+		if tag == "" {
+			return nil, fmt.Errorf("%w: tag cannot be empty", errors.ErrValidation)
+		}
+		if !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return s.startProcessInstance(ctx, processDefinition, processInstanceStart{
+		businessKey:            b.businessKey,
+		name:                   b.name,
+		tags:                   tags,
+		startBeforeActivityIDs: b.startBeforeActivityIDs,
+		startUserID:            b.initiator,
+		variables:              b.variables,
+		transientVariables:     b.transientVariables,
+	})
+}
+
+// setTransientVariablesLocked sets variables of a scope without recording them as
+// changes; callers must hold the write lock
+func (s *runtimeServiceImpl) setTransientVariablesLocked(scopeID string, variables map[string]interface{}) {
+	if len(variables) == 0 {
+		return
+	}
+	if s.variables[scopeID] == nil {
+		s.variables[scopeID] = make(map[string]interface{})
+	}
+	for k, v := range variables {
+		if _, exists := s.variables[scopeID][k]; !exists {
+			s.variables[scopeID][k] = variable.Unwrap(v)
+		}
+	}
+}
+
+// removeTransientVariablesLocked removes the transient variables of a scope that the
+// process did not replace with variables of its own; callers must hold the write lock
+func (s *runtimeServiceImpl) removeTransientVariablesLocked(scopeID string, variables map[string]interface{}) {
+	for k, v := range variables {
+		if current, exists := s.variables[scopeID][k]; exists && reflect.DeepEqual(current, variable.Unwrap(v)) {
+			delete(s.variables[scopeID], k)
+		}
+	}
+}
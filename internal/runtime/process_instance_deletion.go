@@ -0,0 +1,74 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// SetBatchService sets the batch service asynchronous operations run in and registers
+// the job handler of process instance deletion batches
+func (s *runtimeServiceImpl) SetBatchService(batches batch.BatchService) {
+	batches.RegisterJobHandler(batch.TypeProcessInstanceDeletion, batch.JobHandlerFunc(s.executeDeletionJob))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batches = batches
+}
+
+// DeleteProcessInstancesAsync deletes process instances by ID, and those matching a
+// query, as a batch
+func (s *runtimeServiceImpl) DeleteProcessInstancesAsync(ctx context.Context, processInstanceIDs []string, query *ProcessInstanceQuery, deleteReason string) (*batch.Batch, error) {
+	s.mu.RLock()
+	batches := s.batches
+	s.mu.RUnlock()
+
+	if batches == nil {
+		return nil, fmt.Errorf("no batch service set")
+	}
+
+	// Determine the instances to delete up front so that instances started while the
+	// batch runs are not deleted
+	ids := make([]string, 0, len(processInstanceIDs))
+	seen := make(map[string]bool)
+	for _, id := range processInstanceIDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if query != nil {
+		instances, err := query.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, instance := range instances {
+			if !seen[instance.ID] {
+				seen[instance.ID] = true
+				ids = append(ids, instance.ID)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no process instances to delete", errors.ErrValidation)
+	}
+
+	return batches.CreateBatch(ctx, batch.TypeProcessInstanceDeletion, ids, map[string]string{batch.ConfigurationDeleteReason: deleteReason})
+}
+
+// executeDeletionJob deletes the process instances of a job of a deletion batch.
+// Instances that no longer exist, e.g. because they ended meanwhile, are skipped.
+func (s *runtimeServiceImpl) executeDeletionJob(ctx context.Context, deletion *batch.Batch, processInstanceIDs []string) error {
+	deleteReason := deletion.Configuration[batch.ConfigurationDeleteReason]
+
+	var errs []error
+	for _, id := range processInstanceIDs {
+		if err := s.DeleteProcessInstance(ctx, id, deleteReason); err != nil && !errors.Is(err, errors.ErrNotFound) {
+			errs = append(errs, fmt.Errorf("failed to delete process instance %s: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+)
+
+// AddUserIdentityLink relates a user to a process instance, e.g. as participant
+func (s *runtimeServiceImpl) AddUserIdentityLink(ctx context.Context, processInstanceID, userID, linkType string) error {
+	if userID == "" {
+		return fmt.Errorf("user ID cannot be empty")
+	}
+	return s.addIdentityLink(ctx, &identity.IdentityLink{Type: linkType, UserID: userID, ProcessInstanceID: processInstanceID})
+}
+
+// AddGroupIdentityLink relates a group to a process instance
+func (s *runtimeServiceImpl) AddGroupIdentityLink(ctx context.Context, processInstanceID, groupID, linkType string) error {
+	if groupID == "" {
+		return fmt.Errorf("group ID cannot be empty")
+	}
+	return s.addIdentityLink(ctx, &identity.IdentityLink{Type: linkType, GroupID: groupID, ProcessInstanceID: processInstanceID})
+}
+
+// DeleteUserIdentityLink removes the relation of a user to a process instance
+func (s *runtimeServiceImpl) DeleteUserIdentityLink(ctx context.Context, processInstanceID, userID, linkType string) error {
+	return s.deleteIdentityLink(ctx, &identity.IdentityLink{Type: linkType, UserID: userID, ProcessInstanceID: processInstanceID})
+}
+
+// DeleteGroupIdentityLink removes the relation of a group to a process instance
+func (s *runtimeServiceImpl) DeleteGroupIdentityLink(ctx context.Context, processInstanceID, groupID, linkType string) error {
+	return s.deleteIdentityLink(ctx, &identity.IdentityLink{Type: linkType, GroupID: groupID, ProcessInstanceID: processInstanceID})
+}
+
+// GetIdentityLinksForProcessInstance returns the users and groups related to a process
+// instance, including the user who started it
+func (s *runtimeServiceImpl) GetIdentityLinksForProcessInstance(ctx context.Context, processInstanceID string) ([]*identity.IdentityLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	processInstance, exists := s.processInstances[processInstanceID]
+	if !exists {
+		return nil, fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	return s.identityLinksLocked(processInstance), nil
+}
+
+// identityLinksLocked returns all identity links of a process instance.
+// Callers must hold the read lock.
+func (s *runtimeServiceImpl) identityLinksLocked(processInstance *ProcessInstance) []*identity.IdentityLink {
+	links := make([]*identity.IdentityLink, 0)
+	if processInstance.StartUserID != "" {
+		links = append(links, &identity.IdentityLink{
+			Type:              identity.IdentityLinkTypeStarter,
+			UserID:            processInstance.StartUserID,
+			ProcessInstanceID: processInstance.ID,
+		})
+	}
+	return append(links, s.identityLinks[processInstance.ID]...)
+}
+
+// isInvolvedLocked reports whether a user, or one of the groups, is related to a
+// process instance by any identity link. Callers must hold the read lock.
+func (s *runtimeServiceImpl) isInvolvedLocked(processInstance *ProcessInstance, userID string, groupIDs []string) bool {
+	for _, link := range s.identityLinksLocked(processInstance) {
+		if userID != "" && link.UserID == userID {
+			return true
+		}
+		for _, groupID := range groupIDs {
+			if link.GroupID != "" && link.GroupID == groupID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addIdentityLink adds an identity link to a process instance
+func (s *runtimeServiceImpl) addIdentityLink(ctx context.Context, link *identity.IdentityLink) error {
+	if link.Type == "" {
+		return fmt.Errorf("identity link type cannot be empty")
+	}
+	if link.Type == identity.IdentityLinkTypeStarter {
+		return fmt.Errorf("the starter of a process instance cannot be changed")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.processInstances[link.ProcessInstanceID]; !exists {
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, link.ProcessInstanceID)
+	}
+	for _, existing := range s.identityLinks[link.ProcessInstanceID] {
+		if *existing == *link {
+			return nil // Already exists
+		}
+	}
+
+	s.identityLinks[link.ProcessInstanceID] = append(s.identityLinks[link.ProcessInstanceID], link)
+	return s.saveProcessInstanceLocked(ctx, link.ProcessInstanceID)
+}
+
+// deleteIdentityLink removes an identity link from a process instance
+func (s *runtimeServiceImpl) deleteIdentityLink(ctx context.Context, link *identity.IdentityLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.processInstances[link.ProcessInstanceID]; !exists {
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, link.ProcessInstanceID)
+	}
+
+	links := s.identityLinks[link.ProcessInstanceID]
+	for i, existing := range links {
+		if *existing == *link {
+			s.identityLinks[link.ProcessInstanceID] = append(links[:i], links[i+1:]...)
+			break
+		}
+	}
+	return s.saveProcessInstanceLocked(ctx, link.ProcessInstanceID)
+}
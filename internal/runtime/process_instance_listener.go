@@ -0,0 +1,122 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Process instance events listeners are notified of
+const (
+	// ProcessInstanceEventCancelled is fired for each process instance deleted before
+	// it completed, including the process instances of call activities it cancelled
+	ProcessInstanceEventCancelled = "PROCESS_CANCELLED"
+
+	// ProcessInstanceEventAll registers a listener for all process instance events
+	ProcessInstanceEventAll = "all"
+)
+
+// ProcessInstanceListener is notified of the lifecycle transitions of process instances,
+// e.g. to clean up records kept outside the engine. Listeners run after the process
+// instance was saved; their error is returned to the caller, but the transition stays.
+type ProcessInstanceListener interface {
+	// Notify handles a process instance event
+	Notify(ctx context.Context, event *ProcessInstanceEvent) error
+}
+
+// ProcessInstanceListenerFunc adapts a function to the ProcessInstanceListener interface
+type ProcessInstanceListenerFunc func(ctx context.Context, event *ProcessInstanceEvent) error
+
+// Notify calls f(ctx, event)
+func (f ProcessInstanceListenerFunc) Notify(ctx context.Context, event *ProcessInstanceEvent) error {
+	return f(ctx, event)
+}
+
+// ProcessInstanceEvent describes a lifecycle transition of a process instance
+type ProcessInstanceEvent struct {
+	EventName string
+
+	// Time is the time of the event
+	Time time.Time
+
+	// ProcessInstance is a copy of the process instance at the time of the event
+	ProcessInstance *ProcessInstance
+
+	// DeleteReason tells why a cancelled process instance was deleted
+	DeleteReason string
+}
+
+// registeredProcessInstanceListener is a listener for an event of all process instances
+type registeredProcessInstanceListener struct {
+	eventName string
+	listener  ProcessInstanceListener
+}
+
+// AddProcessInstanceListener registers a listener for an event of all process instances
+func (s *runtimeServiceImpl) AddProcessInstanceListener(eventName string, listener ProcessInstanceListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, registeredProcessInstanceListener{
+		eventName: eventName,
+		listener:  listener,
+	})
+}
+
+// fireProcessInstanceEventLocked queues an event of a process instance until the
+// instance is saved. Nothing is queued without a listener for the event. Callers must
+// hold the write lock.
+func (s *runtimeServiceImpl) fireProcessInstanceEventLocked(eventName string, processInstance *ProcessInstance, apply func(event *ProcessInstanceEvent)) {
+	if len(s.listenersOfLocked(eventName)) == 0 {
+		return
+	}
+
+	snapshot := *processInstance
+	event := &ProcessInstanceEvent{
+		EventName:       eventName,
+		Time:            s.clock.Now(),
+		ProcessInstance: &snapshot,
+	}
+	if apply != nil {
+		apply(event)
+	}
+	s.listenerEvents[processInstance.ID] = append(s.listenerEvents[processInstance.ID], event)
+}
+
+// listenersOfLocked returns the listeners registered for an event. Callers must hold the lock.
+func (s *runtimeServiceImpl) listenersOfLocked(eventName string) []ProcessInstanceListener {
+	listeners := make([]ProcessInstanceListener, 0)
+	for _, registered := range s.listeners {
+		if registered.eventName == eventName || registered.eventName == ProcessInstanceEventAll {
+			listeners = append(listeners, registered.listener)
+		}
+	}
+	return listeners
+}
+
+// notifyProcessInstanceListeners delivers the queued events of process instances to
+// their listeners. The caller must not hold the lock.
+func (s *runtimeServiceImpl) notifyProcessInstanceListeners(ctx context.Context, processInstanceIDs ...string) error {
+	s.mu.Lock()
+	events := make([]*ProcessInstanceEvent, 0)
+	for _, id := range processInstanceIDs {
+		events = append(events, s.listenerEvents[id]...)
+		delete(s.listenerEvents, id)
+	}
+	listeners := make(map[string][]ProcessInstanceListener)
+	for _, event := range events {
+		if _, exists := listeners[event.EventName]; !exists {
+			listeners[event.EventName] = s.listenersOfLocked(event.EventName)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, event := range events {
+		for _, listener := range listeners[event.EventName] {
+			if err := listener.Notify(ctx, event); err != nil {
+				return fmt.Errorf("process instance listener failed on %s of process instance %s: %w", event.EventName, event.ProcessInstance.ID, err)
+			}
+		}
+	}
+	return nil
+}
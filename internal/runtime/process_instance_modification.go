@@ -0,0 +1,296 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/model"
+)
+
+// Process instance modification instruction types
+const (
+	modificationStartBefore     = "startBeforeActivity"
+	modificationStartAfter      = "startAfterActivity"
+	modificationStartTransition = "startTransition"
+	modificationCancelInstance  = "cancelActivityInstance"
+	modificationCancelAll       = "cancelAllForActivity"
+)
+
+// modificationInstruction is one operation of a process instance modification
+type modificationInstruction struct {
+	kind     string
+	targetID string // activity, sequence flow or activity instance, depending on the kind
+}
+
+// ProcessInstanceModificationBuilder provides a fluent API for modifying a running
+// process instance, e.g. to repair an instance stuck at a failed activity. The
+// instructions are applied in order by Execute. An activity instance is identified
+// by the ID of the execution at the activity.
+type ProcessInstanceModificationBuilder struct {
+	processInstanceID string
+	instructions      []modificationInstruction
+	variables         map[string]interface{}
+	service           RuntimeService
+}
+
+// StartBeforeActivity starts a new path at an activity
+func (b *ProcessInstanceModificationBuilder) StartBeforeActivity(activityID string) *ProcessInstanceModificationBuilder {
+	b.instructions = append(b.instructions, modificationInstruction{kind: modificationStartBefore, targetID: activityID})
+	return b
+}
+
+// StartAfterActivity starts a new path on the single outgoing sequence flow of an activity
+func (b *ProcessInstanceModificationBuilder) StartAfterActivity(activityID string) *ProcessInstanceModificationBuilder {
+	b.instructions = append(b.instructions, modificationInstruction{kind: modificationStartAfter, targetID: activityID})
+	return b
+}
+
+// StartTransition starts a new path on a sequence flow
+func (b *ProcessInstanceModificationBuilder) StartTransition(transitionID string) *ProcessInstanceModificationBuilder {
+	b.instructions = append(b.instructions, modificationInstruction{kind: modificationStartTransition, targetID: transitionID})
+	return b
+}
+
+// CancelActivityInstance cancels an activity instance with everything running inside it
+func (b *ProcessInstanceModificationBuilder) CancelActivityInstance(activityInstanceID string) *ProcessInstanceModificationBuilder {
+	b.instructions = append(b.instructions, modificationInstruction{kind: modificationCancelInstance, targetID: activityInstanceID})
+	return b
+}
+
+// CancelAllForActivity cancels all instances of an activity
+func (b *ProcessInstanceModificationBuilder) CancelAllForActivity(activityID string) *ProcessInstanceModificationBuilder {
+	b.instructions = append(b.instructions, modificationInstruction{kind: modificationCancelAll, targetID: activityID})
+	return b
+}
+
+// SetVariables sets variables on the process instance before the instructions are applied
+func (b *ProcessInstanceModificationBuilder) SetVariables(variables map[string]interface{}) *ProcessInstanceModificationBuilder {
+	if b.variables == nil {
+		b.variables = make(map[string]interface{})
+	}
+	for k, v := range variables {
+		b.variables[k] = v
+	}
+	return b
+}
+
+// Execute applies the instructions. A process instance left without any running
+// activity is cancelled.
+func (b *ProcessInstanceModificationBuilder) Execute(ctx context.Context) error {
+	if impl, ok := b.service.(*runtimeServiceImpl); ok {
+		return impl.modifyProcessInstance(ctx, b)
+	}
+	return fmt.Errorf("unsupported service implementation")
+}
+
+// CreateProcessInstanceModification creates a builder for modifying a process instance
+func (s *runtimeServiceImpl) CreateProcessInstanceModification(processInstanceID string) *ProcessInstanceModificationBuilder {
+	return &ProcessInstanceModificationBuilder{
+		processInstanceID: processInstanceID,
+		service:           s,
+	}
+}
+
+// modifyProcessInstance applies the instructions of a modification one after another,
+// each running until the process instance waits again
+func (s *runtimeServiceImpl) modifyProcessInstance(ctx context.Context, b *ProcessInstanceModificationBuilder) error {
+	s.mu.RLock()
+	processInstance, exists := s.processInstances[b.processInstanceID]
+	s.mu.RUnlock()
+
+	if !exists || processInstance.EndTime != nil {
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, b.processInstanceID)
+	}
+	if processInstance.Suspended {
+		return &errors.SuspendedError{EntityType: "process instance", ID: processInstance.ID}
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+	if err := validateModification(processModel, b.instructions); err != nil {
+		return err
+	}
+
+	a := &agenda{}
+	a.touch(processInstance.ID)
+	s.mu.Lock()
+	s.setVariablesLocked(processInstance.ID, processInstance.ID, b.variables)
+	s.mu.Unlock()
+
+	for _, instruction := range b.instructions {
+		if err := s.applyModification(ctx, a, processInstance, processModel, instruction); err != nil {
+			return err
+		}
+		if err := s.runAgenda(ctx, a); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	s.removeEmptyScopesLocked(processInstance)
+	s.mu.Unlock()
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// validateModification checks that the activities and sequence flows of the instructions
+// exist, so that an invalid modification changes nothing
+func validateModification(processModel *model.ProcessModel, instructions []modificationInstruction) error {
+	for _, instruction := range instructions {
+		switch instruction.kind {
+		case modificationStartBefore, modificationCancelAll:
+			if processModel.GetNode(instruction.targetID) == nil {
+				return fmt.Errorf("node %w: %s", errors.ErrNotFound, instruction.targetID)
+			}
+		case modificationStartAfter:
+			if processModel.GetNode(instruction.targetID) == nil {
+				return fmt.Errorf("node %w: %s", errors.ErrNotFound, instruction.targetID)
+			}
+			if outgoing := processModel.GetOutgoingEdges(instruction.targetID); len(outgoing) != 1 {
+				return fmt.Errorf("cannot start after activity '%s': it has %d outgoing sequence flows", instruction.targetID, len(outgoing))
+			}
+		case modificationStartTransition:
+			if processModel.GetEdge(instruction.targetID) == nil {
+				return fmt.Errorf("sequence flow %w: %s", errors.ErrNotFound, instruction.targetID)
+			}
+		}
+	}
+	return nil
+}
+
+// applyModification applies a single instruction of a modification
+func (s *runtimeServiceImpl) applyModification(ctx context.Context, a *agenda, processInstance *ProcessInstance, processModel *model.ProcessModel, instruction modificationInstruction) error {
+	switch instruction.kind {
+	case modificationStartBefore:
+		return s.startBeforeActivity(a, processInstance, processModel, processModel.GetNode(instruction.targetID))
+	case modificationStartAfter:
+		edge := processModel.GetOutgoingEdges(instruction.targetID)[0]
+		return s.startBeforeActivity(a, processInstance, processModel, processModel.GetNode(edge.Target))
+	case modificationStartTransition:
+		edge := processModel.GetEdge(instruction.targetID)
+		target := processModel.GetNode(edge.Target)
+		if target == nil {
+			return fmt.Errorf("sequence flow '%s' targets unknown node '%s'", edge.ID, edge.Target)
+		}
+		return s.startBeforeActivity(a, processInstance, processModel, target)
+	case modificationCancelInstance:
+		s.mu.RLock()
+		execution, exists := s.executions[instruction.targetID]
+		s.mu.RUnlock()
+		if !exists || execution.ProcessInstanceID != processInstance.ID || execution.ID == processInstance.ID {
+			return fmt.Errorf("activity instance %w: %s", errors.ErrNotFound, instruction.targetID)
+		}
+		return s.cancelActivityInstance(ctx, a, processInstance, processModel, execution)
+	case modificationCancelAll:
+		s.mu.RLock()
+		executions := make([]*Execution, 0)
+		for _, execution := range s.executions {
+			if execution.ProcessInstanceID == processInstance.ID && execution.ID != processInstance.ID &&
+				execution.ActivityID == instruction.targetID {
+				executions = append(executions, execution)
+			}
+		}
+		s.mu.RUnlock()
+
+		for _, execution := range executions {
+			if err := s.cancelActivityInstance(ctx, a, processInstance, processModel, execution); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported modification instruction: %s", instruction.kind)
+}
+
+// startBeforeActivity plans a new concurrent path at a node. The scope the node belongs
+// to must be active: the process instance, or a single instance of its subprocess.
+func (s *runtimeServiceImpl) startBeforeActivity(a *agenda, processInstance *ProcessInstance, processModel *model.ProcessModel, node *model.Node) error {
+	s.mu.Lock()
+	parentID := processInstance.ID
+	if node.ParentID != "" {
+		parentID = ""
+		for _, execution := range s.executions {
+			if execution.ProcessInstanceID != processInstance.ID || !execution.IsScope || execution.ActivityID != node.ParentID {
+				continue
+			}
+			if parentID != "" {
+				s.mu.Unlock()
+				return fmt.Errorf("cannot start activity '%s': subprocess '%s' has several active instances", node.ID, node.ParentID)
+			}
+			parentID = execution.ID
+		}
+		if parentID == "" {
+			s.mu.Unlock()
+			return fmt.Errorf("cannot start activity '%s': subprocess '%s' is not active", node.ID, node.ParentID)
+		}
+	}
+
+	execution := &Execution{
+		ID:                uuid.New().String(),
+		ProcessInstanceID: processInstance.ID,
+		ParentID:          parentID,
+		IsActive:          true,
+		IsConcurrent:      true,
+		TenantID:          processInstance.TenantID,
+	}
+	s.executions[execution.ID] = execution
+	s.mu.Unlock()
+
+	a.plan(func(ctx context.Context) error {
+		return s.executeActivity(ctx, a, execution, processInstance, processModel, node)
+	})
+	return nil
+}
+
+// cancelActivityInstance cancels the activity an execution is at and removes the execution
+func (s *runtimeServiceImpl) cancelActivityInstance(ctx context.Context, a *agenda, processInstance *ProcessInstance, processModel *model.ProcessModel, execution *Execution) error {
+	current := &ActivityExecution{
+		Execution:       execution,
+		ProcessInstance: processInstance,
+		Node:            processModel.GetNode(execution.ActivityID),
+		Model:           processModel,
+		service:         s,
+		agenda:          a,
+	}
+	if err := s.cancelActivity(ctx, current); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.deleteExecutionLocked(execution.ID)
+	s.mu.Unlock()
+	return nil
+}
+
+// removeEmptyScopesLocked removes the subprocess scopes a modification left without
+// child executions and ends the process instance if nothing runs in it anymore.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) removeEmptyScopesLocked(processInstance *ProcessInstance) {
+	hasChildren := func(scopeID string) bool {
+		for _, execution := range s.executions {
+			if execution.ParentID == scopeID && execution.ProcessInstanceID == processInstance.ID {
+				return true
+			}
+		}
+		return false
+	}
+
+	for removed := true; removed; {
+		removed = false
+		for id, execution := range s.executions {
+			if execution.ProcessInstanceID == processInstance.ID && execution.IsScope && id != processInstance.ID && !hasChildren(id) {
+				s.deleteExecutionLocked(id)
+				removed = true
+			}
+		}
+	}
+
+	if _, exists := s.executions[processInstance.ID]; exists && !hasChildren(processInstance.ID) {
+		endTime := s.clock.Now()
+		processInstance.EndTime = &endTime
+		s.deleteExecutionLocked(processInstance.ID)
+	}
+}
@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// AddTag attaches a tag to a process instance
+func (s *runtimeServiceImpl) AddTag(ctx context.Context, processInstanceID, tag string) error {
+	if tag == "" {
+		return fmt.Errorf("%w: tag cannot be empty", errors.ErrValidation)
+	}
+	return s.updateTags(ctx, processInstanceID, func(tags []string) []string {
+		if slices.Contains(tags, tag) {
+			return tags
+		}
+		return append(tags, tag)
+	})
+}
+
+// RemoveTag detaches a tag from a process instance
+func (s *runtimeServiceImpl) RemoveTag(ctx context.Context, processInstanceID, tag string) error {
+	return s.updateTags(ctx, processInstanceID, func(tags []string) []string {
+		remaining := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != tag {
+				remaining = append(remaining, t)
+			}
+		}
+		return remaining
+	})
+}
+
+// updateTags replaces the tags of a running process instance and records the change in
+// the history. The update works on a copy, as snapshots of the instance share its tags.
+func (s *runtimeServiceImpl) updateTags(ctx context.Context, processInstanceID string, update func(tags []string) []string) error {
+	s.mu.Lock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	if !exists || processInstance.EndTime != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	tags := update(append([]string(nil), processInstance.Tags...))
+	if len(tags) == len(processInstance.Tags) && hasAllTags(processInstance.Tags, tags) {
+		s.mu.Unlock()
+		return nil
+	}
+	processInstance.Tags = tags
+	s.fireHistoryEventLocked(HistoryEventProcessInstanceUpdate, processInstance, "", nil, nil)
+	s.mu.Unlock()
+
+	return s.saveProcessInstances(ctx, processInstanceID)
+}
+
+// hasAllTags reports whether tags contains every one of the required tags
+func hasAllTags(tags, required []string) bool {
+	for _, tag := range required {
+		if !slices.Contains(tags, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnyTag reports whether tags contains at least one of the candidates
+func hasAnyTag(tags, candidates []string) bool {
+	for _, tag := range candidates {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
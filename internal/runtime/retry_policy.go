@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// retryPolicy decides how often a failed job is retried and how long it waits
+// before each retry
+type retryPolicy struct {
+	retries   int             // attempts of a new job, as in Job.Retries
+	intervals []time.Duration // wait before the first, second, ... retry; the last one repeats
+}
+
+// getRetryPolicy reads the retry policy of the jobs of a node. "retryTimeCycle" is
+// either "R<n>/<interval>", retrying n times after the interval, e.g. "R5/PT1M", or a
+// comma separated list of intervals with one retry each, e.g. "PT1M,PT10M,PT1H".
+// Without it, "retries" (attempts) and "retryInterval" apply.
+func getRetryPolicy(node *model.Node) (retryPolicy, error) {
+	if cycle := strings.TrimSpace(node.GetString("retryTimeCycle")); cycle != "" {
+		return parseRetryTimeCycle(node.ID, cycle)
+	}
+
+	policy := retryPolicy{retries: defaultJobRetries}
+	if value, ok := node.GetInt("retries"); ok {
+		policy.retries = value
+	}
+	interval, err := getDuration(node, "retryInterval", defaultJobRetryInterval)
+	if err != nil {
+		return retryPolicy{}, err
+	}
+	policy.intervals = []time.Duration{interval}
+	return policy, nil
+}
+
+// parseRetryTimeCycle parses the "retryTimeCycle" property of a node
+func parseRetryTimeCycle(nodeID, cycle string) (retryPolicy, error) {
+	invalid := fmt.Errorf("invalid retryTimeCycle of node '%s': %s", nodeID, cycle)
+
+	if strings.HasPrefix(cycle, "R") {
+		parts := strings.Split(cycle, "/")
+		if len(parts) != 2 {
+			return retryPolicy{}, invalid
+		}
+		retries, err := strconv.Atoi(parts[0][1:])
+		if err != nil || retries < 0 {
+			return retryPolicy{}, invalid
+		}
+		interval, err := expression.ParseDuration(parts[1])
+		if err != nil {
+			return retryPolicy{}, invalid
+		}
+		return retryPolicy{retries: retries + 1, intervals: []time.Duration{interval}}, nil
+	}
+
+	policy := retryPolicy{}
+	for _, part := range strings.Split(cycle, ",") {
+		interval, err := expression.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return retryPolicy{}, invalid
+		}
+		policy.intervals = append(policy.intervals, interval)
+	}
+	policy.retries = len(policy.intervals) + 1
+	return policy, nil
+}
+
+// retryInterval returns the wait before the next attempt of a job that failed with
+// retriesLeft retries left
+func (p retryPolicy) retryInterval(retriesLeft int) time.Duration {
+	if len(p.intervals) == 0 {
+		return defaultJobRetryInterval
+	}
+	retry := p.retries - retriesLeft - 1
+	if retry < 0 {
+		retry = 0
+	}
+	if retry >= len(p.intervals) {
+		retry = len(p.intervals) - 1
+	}
+	return p.intervals[retry]
+}
@@ -0,0 +1,215 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// processInstanceState is the persisted form of a process instance together
+// with its executions and the variables of its scopes
+type processInstanceState struct {
+	ProcessInstance *ProcessInstance
+	Executions      []*Execution
+	Variables       map[string]variable.Map // scope execution ID -> variables
+	Jobs            []*Job
+	DeadLetterJobs  []*Job
+	Subscriptions   []*EventSubscription
+	IdentityLinks   []*identity.IdentityLink
+}
+
+// SetStore sets the store process instances are persisted to
+func (s *runtimeServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+	s.contents = persistence.NewVariableContents(persistence.NewTableContentStore(store))
+	store.OnRollback(persistence.TableProcessInstances, func(ctx context.Context, processInstanceID string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err := s.reloadProcessInstanceLocked(ctx, processInstanceID); err != nil {
+			s.logger.ErrorContext(ctx, "failed to reload process instance", slog.String("processInstanceId", processInstanceID), slog.Any("error", err))
+		}
+	})
+}
+
+// SetContentStore sets the store large variable values are kept in
+func (s *runtimeServiceImpl) SetContentStore(contentStore persistence.ContentStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.contents = persistence.NewVariableContents(contentStore)
+}
+
+// saveProcessInstanceLocked writes the state of a process instance to the store, if any.
+// Process instances that no longer exist are deleted from the store.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) saveProcessInstanceLocked(ctx context.Context, processInstanceID string) error {
+	if s.store == nil {
+		return nil
+	}
+
+	processInstance, exists := s.processInstances[processInstanceID]
+	if !exists {
+		if err := s.contents.Remove(ctx, processInstanceID); err != nil {
+			return err
+		}
+		return s.store.Delete(ctx, persistence.TableProcessInstances, processInstanceID)
+	}
+
+	state := &processInstanceState{
+		ProcessInstance: processInstance,
+		Executions:      make([]*Execution, 0),
+		Jobs:            make([]*Job, 0),
+		DeadLetterJobs:  make([]*Job, 0),
+		Subscriptions:   make([]*EventSubscription, 0),
+		IdentityLinks:   s.identityLinks[processInstanceID],
+	}
+	scopes := make(map[string]map[string]interface{})
+	for _, execution := range s.executions {
+		if execution.ProcessInstanceID == processInstanceID {
+			state.Executions = append(state.Executions, execution)
+			if variables, exists := s.variables[execution.ID]; exists {
+				scopes[execution.ID] = variables
+			}
+		}
+	}
+	// Large values are kept in the content store
+	variables, err := s.contents.Externalize(ctx, processInstanceID, scopes)
+	if err != nil {
+		return err
+	}
+	state.Variables = variables
+	for _, job := range s.jobs {
+		if job.ProcessInstanceID == processInstanceID {
+			state.Jobs = append(state.Jobs, job)
+		}
+	}
+	for _, job := range s.deadLetterJobs {
+		if job.ProcessInstanceID == processInstanceID {
+			state.DeadLetterJobs = append(state.DeadLetterJobs, job)
+		}
+	}
+	for _, subscription := range s.subscriptions {
+		if subscription.ProcessInstanceID == processInstanceID {
+			state.Subscriptions = append(state.Subscriptions, subscription)
+		}
+	}
+	processInstance.Revision++
+	err = s.store.SaveRevision(ctx, persistence.TableProcessInstances, processInstanceID, state, processInstance.Revision)
+	var conflict *persistence.OptimisticLockingError
+	if errors.As(err, &conflict) {
+		// Continue from the state the other transaction saved; the failed command may be retried
+		if reloadErr := s.reloadProcessInstanceLocked(ctx, processInstanceID); reloadErr != nil {
+			return reloadErr
+		}
+	} else if err != nil {
+		processInstance.Revision--
+	}
+	return err
+}
+
+// reloadProcessInstanceLocked replaces the state of a process instance with the state
+// in the store, e.g. after another engine modified it. Callers must hold the write lock.
+func (s *runtimeServiceImpl) reloadProcessInstanceLocked(ctx context.Context, processInstanceID string) error {
+	data, err := s.store.Get(ctx, persistence.TableProcessInstances, processInstanceID)
+	s.removeProcessInstanceLocked(processInstanceID)
+	if err != nil {
+		// The process instance was deleted
+		return nil
+	}
+
+	state := &processInstanceState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("failed to load process instance %s: %w", processInstanceID, err)
+	}
+	s.restoreProcessInstanceLocked(state)
+	return nil
+}
+
+// saveProcessInstances writes the state of process instances to the store, if any,
+// and then delivers their queued history events and process instance events
+func (s *runtimeServiceImpl) saveProcessInstances(ctx context.Context, processInstanceIDs ...string) error {
+	s.mu.Lock()
+	for _, processInstanceID := range processInstanceIDs {
+		if err := s.saveProcessInstanceLocked(ctx, processInstanceID); err != nil {
+			s.mu.Unlock()
+			return err
+		}
+	}
+	s.mu.Unlock()
+
+	err := s.flushHistoryEvents(ctx, processInstanceIDs...)
+	if notifyErr := s.notifyProcessInstanceListeners(ctx, processInstanceIDs...); err == nil {
+		err = notifyErr
+	}
+	return err
+}
+
+// saveJobLocked writes a job that belongs to no process instance, e.g. of a timer
+// start event, to the store, if any. Jobs that no longer exist are deleted from the store.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) saveJobLocked(ctx context.Context, jobID string) error {
+	if s.store == nil {
+		return nil
+	}
+
+	job, exists := s.jobs[jobID]
+	if !exists {
+		return s.store.Delete(ctx, persistence.TableJobs, jobID)
+	}
+	return s.store.Save(ctx, persistence.TableJobs, jobID, job)
+}
+
+// load reads process instances with their executions and variables, and the jobs
+// of timer start events from the store
+func (s *runtimeServiceImpl) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := persistence.Load(ctx, s.store, persistence.TableJobs, func(job *Job) {
+		job.LockOwner = ""
+		job.LockExpirationTime = nil
+		s.jobs[job.ID] = job
+	})
+	if err != nil {
+		return err
+	}
+	return persistence.Load(ctx, s.store, persistence.TableProcessInstances, s.restoreProcessInstanceLocked)
+}
+
+// restoreProcessInstanceLocked adds a process instance read from the store with its
+// executions, variables, jobs and subscriptions. Callers must hold the write lock.
+func (s *runtimeServiceImpl) restoreProcessInstanceLocked(state *processInstanceState) {
+	s.processInstances[state.ProcessInstance.ID] = state.ProcessInstance
+	for _, execution := range state.Executions {
+		s.executions[execution.ID] = execution
+	}
+	for scopeID, variables := range state.Variables {
+		s.variables[scopeID] = variables
+	}
+	s.contents.Track(state.ProcessInstance.ID, state.Variables)
+	for _, subscription := range state.Subscriptions {
+		s.subscriptions[subscription.ID] = subscription
+	}
+	for _, job := range state.Jobs {
+		// Locks of job executors of a previous run are void
+		job.LockOwner = ""
+		job.LockExpirationTime = nil
+		s.jobs[job.ID] = job
+	}
+	for _, job := range state.DeadLetterJobs {
+		s.deadLetterJobs[job.ID] = job
+	}
+	if len(state.IdentityLinks) > 0 {
+		s.identityLinks[state.ProcessInstance.ID] = state.IdentityLinks
+	}
+}
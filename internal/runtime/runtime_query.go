@@ -0,0 +1,238 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/pkg/like"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// listProcessInstances returns the running process instances matching a query. A process
+// instance must match every Or group of the query in addition to its own filters.
+func (s *runtimeServiceImpl) listProcessInstances(ctx context.Context, q *ProcessInstanceQuery) ([]*ProcessInstance, error) {
+	if q.parent != nil {
+		return nil, fmt.Errorf("process instance query group started with Or() must be closed with EndOr()")
+	}
+	for _, group := range q.orQueries {
+		if len(group.orQueries) > 0 {
+			return nil, fmt.Errorf("nested Or() groups are not supported in process instance queries")
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*ProcessInstance, 0)
+	for _, processInstance := range s.processInstances {
+		if processInstance.EndTime != nil || !identity.IsTenantAccessible(ctx, processInstance.TenantID) {
+			continue
+		}
+		matches, err := s.matchesProcessInstanceQueryLocked(processInstance, q)
+		for _, group := range q.orQueries {
+			if err != nil || !matches {
+				break
+			}
+			matches, err = s.matchesAnyCriterionLocked(processInstance, group)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			result = append(result, processInstance)
+		}
+	}
+
+	paging.Sort(result, func(a, b *ProcessInstance) bool {
+		return a.ID < b.ID
+	}, func(a, b *ProcessInstance) bool {
+		var c int
+		switch q.orderBy {
+		case "id":
+			c = strings.Compare(a.ID, b.ID)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "process_definition_key":
+			c = strings.Compare(a.ProcessDefinitionKey, b.ProcessDefinitionKey)
+		case "start_time":
+			c = a.StartTime.Compare(b.StartTime)
+		default:
+			// Unordered queries return process instances in the order they were started
+			return a.StartTime.Before(b.StartTime)
+		}
+		return paging.Less(c, q.ascending)
+	})
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// matchesProcessInstanceQueryLocked checks a process instance against the criteria of a query.
+// Callers must hold the read lock.
+func (s *runtimeServiceImpl) matchesProcessInstanceQueryLocked(processInstance *ProcessInstance, q *ProcessInstanceQuery) (bool, error) {
+	if q.processInstanceID != "" && processInstance.ID != q.processInstanceID {
+		return false, nil
+	}
+	if q.processInstanceBusinessKey != "" && processInstance.BusinessKey != q.processInstanceBusinessKey {
+		return false, nil
+	}
+	if q.processInstanceName != "" && processInstance.Name != q.processInstanceName {
+		return false, nil
+	}
+	if q.processInstanceNameLike != "" && !like.Match(processInstance.Name, q.processInstanceNameLike) {
+		return false, nil
+	}
+	if !hasAllTags(processInstance.Tags, q.tags) {
+		return false, nil
+	}
+	if len(q.tagIn) > 0 && !hasAnyTag(processInstance.Tags, q.tagIn) {
+		return false, nil
+	}
+	if q.processDefinitionID != "" && processInstance.ProcessDefinitionID != q.processDefinitionID {
+		return false, nil
+	}
+	if q.processDefinitionKey != "" && processInstance.ProcessDefinitionKey != q.processDefinitionKey {
+		return false, nil
+	}
+	if q.processDefinitionName != "" && processInstance.ProcessDefinitionName != q.processDefinitionName {
+		return false, nil
+	}
+	if q.superProcessInstanceID != "" && processInstance.SuperProcessInstanceID != q.superProcessInstanceID {
+		return false, nil
+	}
+	if q.subProcessInstanceID != "" {
+		subProcessInstance, exists := s.processInstances[q.subProcessInstanceID]
+		if !exists || subProcessInstance.SuperProcessInstanceID != processInstance.ID {
+			return false, nil
+		}
+	}
+	if q.startUserID != "" && processInstance.StartUserID != q.startUserID {
+		return false, nil
+	}
+	if (q.involvedUser != "" || len(q.involvedGroups) > 0) && !s.isInvolvedLocked(processInstance, q.involvedUser, q.involvedGroups) {
+		return false, nil
+	}
+	if q.tenantID != "" && processInstance.TenantID != q.tenantID {
+		return false, nil
+	}
+	if q.suspended != nil && processInstance.Suspended != *q.suspended {
+		return false, nil
+	}
+	if q.active != nil && processInstance.Suspended == *q.active {
+		return false, nil
+	}
+	if len(q.variableValueEquals) > 0 {
+		return s.variablesMatchLocked(processInstance.ID, q.variableValueEquals)
+	}
+	return true, nil
+}
+
+// matchesAnyCriterionLocked checks whether a process instance matches at least one
+// filter of an Or group. A group without filters matches every process instance.
+// Callers must hold the read lock.
+func (s *runtimeServiceImpl) matchesAnyCriterionLocked(processInstance *ProcessInstance, group *ProcessInstanceQuery) (bool, error) {
+	criteria := group.criteria()
+	if len(criteria) == 0 {
+		return true, nil
+	}
+	for _, criterion := range criteria {
+		if matches, err := s.matchesProcessInstanceQueryLocked(processInstance, criterion); err != nil || matches {
+			return matches, err
+		}
+	}
+	return false, nil
+}
+
+// listExecutions returns the executions of running process instances matching a query
+func (s *runtimeServiceImpl) listExecutions(ctx context.Context, q *ExecutionQuery) ([]*Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Execution, 0)
+	for _, execution := range s.executions {
+		processInstance, exists := s.processInstances[execution.ProcessInstanceID]
+		if !exists || processInstance.EndTime != nil || !identity.IsTenantAccessible(ctx, processInstance.TenantID) {
+			continue
+		}
+		matches, err := s.matchesExecutionQueryLocked(execution, processInstance, q)
+		if err != nil {
+			return nil, err
+		}
+		if matches {
+			result = append(result, execution)
+		}
+	}
+
+	paging.Sort(result, func(a, b *Execution) bool {
+		return a.ID < b.ID
+	}, func(a, b *Execution) bool {
+		var c int
+		switch q.orderBy {
+		case "id":
+			c = strings.Compare(a.ID, b.ID)
+		case "process_instance_id":
+			c = strings.Compare(a.ProcessInstanceID, b.ProcessInstanceID)
+		default:
+			// Unordered queries group executions by process instance
+			if a.ProcessInstanceID != b.ProcessInstanceID {
+				return a.ProcessInstanceID < b.ProcessInstanceID
+			}
+			return a.ID < b.ID
+		}
+		return paging.Less(c, q.ascending)
+	})
+	return result, nil
+}
+
+// matchesExecutionQueryLocked checks an execution against the criteria of a query.
+// Callers must hold the read lock.
+func (s *runtimeServiceImpl) matchesExecutionQueryLocked(execution *Execution, processInstance *ProcessInstance, q *ExecutionQuery) (bool, error) {
+	if q.executionID != "" && execution.ID != q.executionID {
+		return false, nil
+	}
+	if q.processInstanceID != "" && execution.ProcessInstanceID != q.processInstanceID {
+		return false, nil
+	}
+	if q.processDefinitionID != "" && processInstance.ProcessDefinitionID != q.processDefinitionID {
+		return false, nil
+	}
+	if q.processDefinitionKey != "" && processInstance.ProcessDefinitionKey != q.processDefinitionKey {
+		return false, nil
+	}
+	if q.activityID != "" && execution.ActivityID != q.activityID {
+		return false, nil
+	}
+	if q.parentID != "" && execution.ParentID != q.parentID {
+		return false, nil
+	}
+	if q.tenantID != "" && processInstance.TenantID != q.tenantID {
+		return false, nil
+	}
+	if q.suspended != nil && processInstance.Suspended != *q.suspended {
+		return false, nil
+	}
+	if q.active != nil && (execution.IsActive && !processInstance.Suspended) != *q.active {
+		return false, nil
+	}
+	if len(q.variableValueEquals) > 0 {
+		return s.variablesMatchLocked(execution.ID, q.variableValueEquals)
+	}
+	return true, nil
+}
+
+// variablesMatchLocked checks that the variables visible to an execution have the
+// expected values. Callers must hold the read lock.
+func (s *runtimeServiceImpl) variablesMatchLocked(executionID string, expected map[string]interface{}) (bool, error) {
+	variables, err := s.getVariablesLocked(executionID)
+	if err != nil {
+		return false, err
+	}
+	for name, value := range expected {
+		actual, exists := variables[name]
+		if !exists || !variable.Equal(actual, value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
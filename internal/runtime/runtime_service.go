@@ -0,0 +1,758 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"time"
+
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/expression"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+	"github.com/muixstudio/flowgo/tracing"
+)
+
+// RuntimeService provides operations for managing process instances and executions.
+// This service is responsible for:
+// - Starting process instances
+// - Managing process instance lifecycle
+// - Setting and retrieving process variables
+// - Signaling events
+// - Managing executions
+type RuntimeService interface {
+	// Initialize initializes the runtime service
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the runtime service
+	Shutdown(ctx context.Context) error
+
+	// StartProcessInstanceByKey starts a process instance by process definition key
+	StartProcessInstanceByKey(ctx context.Context, processDefinitionKey string, variables map[string]interface{}) (*ProcessInstance, error)
+
+	// StartProcessInstanceByID starts a process instance by process definition ID
+	StartProcessInstanceByID(ctx context.Context, processDefinitionID string, variables map[string]interface{}) (*ProcessInstance, error)
+
+	// StartProcessInstanceByKeyWithBusinessKey starts a process instance with a business key
+	StartProcessInstanceByKeyWithBusinessKey(ctx context.Context, processDefinitionKey, businessKey string, variables map[string]interface{}) (*ProcessInstance, error)
+
+	// CreateProcessInstanceBuilder creates a builder for starting a process instance with
+	// a business key, a tenant, a name, start activities, transient variables or an initiator
+	CreateProcessInstanceBuilder() *ProcessInstanceBuilder
+
+	// StartProcessInstanceByMessage starts a process instance of the definition whose message start event matches the message name
+	StartProcessInstanceByMessage(ctx context.Context, messageName, businessKey string, variables map[string]interface{}) (*ProcessInstance, error)
+
+	// SignalEventReceived starts a process instance for every definition with a matching signal start event
+	// and notifies all running executions subscribed to the signal, e.g. signal catch events and
+	// signal event subprocesses. It returns the started process instances.
+	SignalEventReceived(ctx context.Context, signalName string, variables map[string]interface{}) ([]*ProcessInstance, error)
+
+	// SignalEventReceivedByExecution delivers a signal to a single execution subscribed to it
+	SignalEventReceivedByExecution(ctx context.Context, signalName, executionID string, variables map[string]interface{}) error
+
+	// EvaluateConditionalStartEvents evaluates the conditions of all conditional start events against
+	// the variables and starts a process instance for each condition that holds
+	EvaluateConditionalStartEvents(ctx context.Context, variables map[string]interface{}) ([]*ProcessInstance, error)
+
+	// CreateProcessInstanceModification creates a builder for starting and cancelling
+	// activities of a running process instance
+	CreateProcessInstanceModification(processInstanceID string) *ProcessInstanceModificationBuilder
+
+	// DeleteProcessInstance cancels a process instance and removes it: the tasks of its
+	// activities are deleted, its timers, jobs and subscriptions removed, the process
+	// instances started by its call activities deleted, and the historic process instance
+	// ends with the delete reason
+	DeleteProcessInstance(ctx context.Context, processInstanceID, deleteReason string) error
+
+	// DeleteProcessInstancesAsync deletes process instances by ID, and those matching the
+	// query if not nil, like DeleteProcessInstance, as a batch in jobs run in the
+	// background; the returned batch reports its progress. It needs a batch service.
+	DeleteProcessInstancesAsync(ctx context.Context, processInstanceIDs []string, query *ProcessInstanceQuery, deleteReason string) (*batch.Batch, error)
+
+	// CancelProcessInstance cancels all active paths of a process instance and runs the compensation
+	// handlers of its completed activities in reverse order; the instance ends after the last handler
+	CancelProcessInstance(ctx context.Context, processInstanceID string) error
+
+	// SuspendProcessInstance suspends a process instance: its tasks cannot be claimed or
+	// completed, its executions not signaled and its jobs are not executed, all failing
+	// with an *errors.SuspendedError, and its timers are paused until it is activated
+	SuspendProcessInstance(ctx context.Context, processInstanceID string) error
+
+	// ActivateProcessInstance activates a suspended process instance. Its timers are
+	// postponed by the time it was suspended.
+	ActivateProcessInstance(ctx context.Context, processInstanceID string) error
+
+	// CreateProcessInstanceQuery creates a new process instance query
+	CreateProcessInstanceQuery() *ProcessInstanceQuery
+
+	// CreateNativeProcessInstanceQuery creates a query selecting process instances with native SQL or a filter expression
+	CreateNativeProcessInstanceQuery() *NativeProcessInstanceQuery
+
+	// GetProcessInstance retrieves a process instance by ID
+	GetProcessInstance(ctx context.Context, processInstanceID string) (*ProcessInstance, error)
+
+	// GetActivityInstanceDiagram renders the process model of a process instance as SVG
+	// diagram with the activities the instance is currently at highlighted
+	GetActivityInstanceDiagram(ctx context.Context, processInstanceID string) ([]byte, error)
+
+	// SetProcessInstanceName renames a running process instance
+	SetProcessInstanceName(ctx context.Context, processInstanceID, name string) error
+
+	// AddTag attaches a tag to a running process instance, e.g. "vip" or "region:eu".
+	// Adding a tag the instance already has does nothing.
+	AddTag(ctx context.Context, processInstanceID, tag string) error
+
+	// RemoveTag detaches a tag from a running process instance
+	RemoveTag(ctx context.Context, processInstanceID, tag string) error
+
+	// GetActiveActivityIds returns the IDs of the activities the process instance is
+	// currently at, including the subprocesses they run in, sorted
+	GetActiveActivityIds(ctx context.Context, processInstanceID string) ([]string, error)
+
+	// GetActivityInstance returns the tree of the activity instances a process instance
+	// is currently at: the process instance with the activities it runs and the
+	// activities running inside its subprocesses
+	GetActivityInstance(ctx context.Context, processInstanceID string) (*ActivityInstance, error)
+
+	// AddUserIdentityLink relates a user to a process instance with a link type, e.g. identity.IdentityLinkTypeParticipant
+	AddUserIdentityLink(ctx context.Context, processInstanceID, userID, linkType string) error
+
+	// AddGroupIdentityLink relates a group to a process instance with a link type
+	AddGroupIdentityLink(ctx context.Context, processInstanceID, groupID, linkType string) error
+
+	// DeleteUserIdentityLink removes the relation of a user to a process instance
+	DeleteUserIdentityLink(ctx context.Context, processInstanceID, userID, linkType string) error
+
+	// DeleteGroupIdentityLink removes the relation of a group to a process instance
+	DeleteGroupIdentityLink(ctx context.Context, processInstanceID, groupID, linkType string) error
+
+	// GetIdentityLinksForProcessInstance returns the users and groups related to a process instance
+	GetIdentityLinksForProcessInstance(ctx context.Context, processInstanceID string) ([]*identity.IdentityLink, error)
+
+	// SetVariable sets a variable on a process instance
+	SetVariable(ctx context.Context, executionID, variableName string, value interface{}) error
+
+	// SetVariables sets multiple variables on a process instance
+	SetVariables(ctx context.Context, executionID string, variables map[string]interface{}) error
+
+	// GetVariable gets a variable from a process instance
+	GetVariable(ctx context.Context, executionID, variableName string) (interface{}, error)
+
+	// GetVariables gets all variables from a process instance
+	GetVariables(ctx context.Context, executionID string) (map[string]interface{}, error)
+
+	// RemoveVariable removes a variable from a process instance
+	RemoveVariable(ctx context.Context, executionID, variableName string) error
+
+	// GetVariableTyped gets a variable visible to an execution together with its type, or nil if it is not set
+	GetVariableTyped(ctx context.Context, executionID, variableName string) (*variable.TypedValue, error)
+
+	// SetVariableLocal sets a variable in the nearest scope of an execution, e.g. the enclosing subprocess
+	SetVariableLocal(ctx context.Context, executionID, variableName string, value interface{}) error
+
+	// SetVariablesLocal sets multiple variables in the nearest scope of an execution
+	SetVariablesLocal(ctx context.Context, executionID string, variables map[string]interface{}) error
+
+	// GetVariableLocal gets a variable of the nearest scope of an execution
+	GetVariableLocal(ctx context.Context, executionID, variableName string) (interface{}, error)
+
+	// GetVariablesLocal gets the variables of the nearest scope of an execution, without inherited ones
+	GetVariablesLocal(ctx context.Context, executionID string) (map[string]interface{}, error)
+
+	// RemoveVariableLocal removes a variable from the nearest scope of an execution
+	RemoveVariableLocal(ctx context.Context, executionID, variableName string) error
+
+	// PatchVariables atomically applies a JSON patch (RFC 6902) to the variables of an execution.
+	// Either all operations are applied or, if one fails, none of them.
+	PatchVariables(ctx context.Context, executionID string, jsonPatch []byte) error
+
+	// MessageEventReceived delivers a message to an execution subscribed to it, e.g. to a
+	// process instance (by its ID) with a message event subprocess
+	MessageEventReceived(ctx context.Context, messageName, executionID string, variables map[string]interface{}) error
+
+	// CorrelateMessage delivers a message to the one execution waiting for it that matches the
+	// business key and correlation keys, or else starts a process instance by message start
+	// event. It fails if no receiver or more than one matches.
+	CorrelateMessage(ctx context.Context, messageName, businessKey string, correlationKeys, variables map[string]interface{}) (*MessageCorrelationResult, error)
+
+	// Signal continues an execution waiting at a receive task, user task, waiting service
+	// task or intermediate catch event, which leaves it along its outgoing sequence flows.
+	// Catch events continue as if their timer, message, signal or condition occurred.
+	// Executions waiting elsewhere return an *errors.NotSignalableError.
+	Signal(ctx context.Context, executionID string) error
+
+	// SignalWithVariables signals an execution like Signal, first setting the variables
+	// where they are visible to it
+	SignalWithVariables(ctx context.Context, executionID string, variables map[string]interface{}) error
+
+	// ThrowEscalation throws an escalation at the activity an execution waits at. It is
+	// caught by the escalation boundary event of the activity, or of an enclosing
+	// subprocess, or the escalation event subprocess of an enclosing scope, whose
+	// escalation code matches; events without code catch all escalations. Interrupting
+	// handlers cancel the activity, non-interrupting ones start a concurrent path. The
+	// variables are set on the path that continues. An escalation nobody catches fails
+	// with errors.ErrValidation.
+	ThrowEscalation(ctx context.Context, executionID, escalationCode string, variables map[string]interface{}) error
+
+	// CreateExecutionQuery creates a new execution query
+	CreateExecutionQuery() *ExecutionQuery
+
+	// CreateVariableInstanceQuery creates a new query for the variables of running process
+	// instances, e.g. to find the instances whose "amount" exceeds a limit
+	CreateVariableInstanceQuery() *VariableInstanceQuery
+
+	// GetJob retrieves a job by ID
+	GetJob(ctx context.Context, jobID string) (*Job, error)
+
+	// GetJobs returns the jobs of a process instance, e.g. pending async continuations and timers.
+	// An empty processInstanceID returns the jobs of process definitions, e.g. of timer start events.
+	GetJobs(ctx context.Context, processInstanceID string) ([]*Job, error)
+
+	// ListJobs returns all jobs, i.e. of all process instances and of process definitions
+	ListJobs(ctx context.Context) ([]*Job, error)
+
+	// ExecuteJob runs a job right away. A failed job is kept with one retry less, or becomes
+	// a dead-letter job when it has no retries left.
+	ExecuteJob(ctx context.Context, jobID string) error
+
+	// SetJobRetries sets the retries of a job. Positive retries move a dead-letter job back
+	// to the jobs the job executor acquires.
+	SetJobRetries(ctx context.Context, jobID string, retries int) error
+
+	// GetDeadLetterJob retrieves a job that failed without retries left
+	GetDeadLetterJob(ctx context.Context, jobID string) (*Job, error)
+
+	// GetDeadLetterJobs returns the dead-letter jobs of a process instance, or all for an empty ID
+	GetDeadLetterJobs(ctx context.Context, processInstanceID string) ([]*Job, error)
+
+	// GetJobExceptionStacktrace returns the failure of the last attempt of a job or dead-letter job
+	GetJobExceptionStacktrace(ctx context.Context, jobID string) (string, error)
+
+	// ExecuteDeadLetterJob moves a dead-letter job back with one retry and runs it right away
+	ExecuteDeadLetterJob(ctx context.Context, jobID string) error
+
+	// DeleteDeadLetterJob removes a dead-letter job
+	DeleteDeadLetterJob(ctx context.Context, jobID string) error
+
+	// AcquireJobs locks up to maxJobs due jobs for a job executor
+	AcquireJobs(ctx context.Context, lockOwner string, maxJobs int, lockDuration time.Duration) ([]*Job, error)
+
+	// SetJobNotifier sets the notifier told when jobs can be acquired, e.g. the job executor
+	SetJobNotifier(notifier JobNotifier)
+
+	// RegisterActivityBehavior registers the behavior executing nodes of a type,
+	// replacing the built-in behavior for that type if there is one
+	RegisterActivityBehavior(nodeType string, behavior ActivityBehavior)
+
+	// RegisterServiceTask registers a delegate that service tasks reference through their "implementation" property
+	RegisterServiceTask(name string, delegate ServiceTaskDelegate)
+
+	// RegisterScriptEngine registers the engine evaluating the scripts of script tasks with a "scriptFormat",
+	// e.g. "javascript". The "expression" format is built in.
+	RegisterScriptEngine(format string, engine ScriptEngine)
+
+	// GetExpressionManager returns the manager evaluating the expressions of process definitions,
+	// e.g. to register custom functions
+	GetExpressionManager() *expression.Manager
+
+	// SetClock sets the clock timers, jobs and the times of process instances are based on
+	SetClock(c clock.Clock)
+
+	// SetLogger sets the logger failures of jobs, timers and reloads are logged to
+	SetLogger(logger *slog.Logger)
+
+	// SetTracer sets the tracer reporting spans of jobs and service tasks. The trace
+	// context of a command is kept in the jobs of its async continuations.
+	SetTracer(tracer tracing.Tracer)
+
+	// SetHistoryEventHandler sets the handler told about the progress of process instances,
+	// e.g. the history manager recording process instances, activities and variables
+	SetHistoryEventHandler(handler HistoryEventHandler)
+
+	// AddProcessInstanceListener registers a listener for an event of all process instances,
+	// e.g. ProcessInstanceEventCancelled, or for all events with ProcessInstanceEventAll
+	AddProcessInstanceListener(eventName string, listener ProcessInstanceListener)
+
+	// SetStore sets the store process instances, executions and variables are persisted to.
+	// Without a store the service keeps its state in memory.
+	SetStore(store *persistence.Store)
+
+	// SetContentStore sets the store large binary and JSON variable values are kept in,
+	// e.g. an object storage. By default they are kept in a table of the store.
+	SetContentStore(contentStore persistence.ContentStore)
+
+	// SetBatchService sets the batch service asynchronous operations, e.g.
+	// DeleteProcessInstancesAsync, run in
+	SetBatchService(batches batch.BatchService)
+}
+
+// DefaultInitiatorVariable is the variable holding the user who started a process
+// instance, unless the start event names another one with the "initiator" property
+const DefaultInitiatorVariable = "initiator"
+
+// ProcessInstance represents a running or completed process instance
+type ProcessInstance struct {
+	ID                   string
+	ProcessDefinitionID  string
+	ProcessDefinitionKey string
+	ProcessDefinitionName string
+	BusinessKey          string
+	Name                 string       // human-readable name of the instance
+	Tags                 []string     // labels categorizing the instance, in the order they were added
+	StartTime            time.Time
+	EndTime              *time.Time
+	StartUserID          string // authenticated user who started the process
+	Suspended            bool
+	SuspensionTime       *time.Time // when the instance was suspended; its timers are paused since then
+	TenantID             string
+	RootProcessInstanceID string
+	ParentProcessInstanceID string
+	SuperProcessInstanceID string // process instance whose call activity started this one
+	SuperExecutionID string       // execution waiting at that call activity
+	Revision         int          // persisted revision of the instance with its executions and variables
+}
+
+// Execution represents an execution (thread of control) within a process instance
+type Execution struct {
+	ID                  string
+	ProcessInstanceID   string
+	ParentID            string
+	ActivityID          string
+	IsActive            bool
+	IsConcurrent        bool
+	IsScope             bool
+	IsEventScope        bool
+	Suspended           bool
+	TenantID            string
+}
+
+// ProcessInstanceQuery provides a fluent API for querying process instances
+type ProcessInstanceQuery struct {
+	processInstanceID        string
+	processInstanceBusinessKey string
+	processInstanceName      string
+	processInstanceNameLike  string
+	tags                     []string
+	tagIn                    []string
+	processDefinitionID      string
+	processDefinitionKey     string
+	processDefinitionName    string
+	superProcessInstanceID   string
+	subProcessInstanceID     string
+	startUserID              string
+	involvedUser             string
+	involvedGroups           []string
+	tenantID                 string
+	suspended                *bool
+	active                   *bool
+	variableValueEquals      map[string]interface{}
+	orderBy                  string
+	ascending                bool
+	firstResult              int
+	maxResults               int
+	orQueries                []*ProcessInstanceQuery
+	parent                   *ProcessInstanceQuery // query enclosing an Or group
+	service                  RuntimeService
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *ProcessInstanceQuery) ProcessInstanceID(id string) *ProcessInstanceQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessInstanceBusinessKey filters by business key
+func (q *ProcessInstanceQuery) ProcessInstanceBusinessKey(businessKey string) *ProcessInstanceQuery {
+	q.processInstanceBusinessKey = businessKey
+	return q
+}
+
+// ProcessInstanceName filters by process instance name
+func (q *ProcessInstanceQuery) ProcessInstanceName(name string) *ProcessInstanceQuery {
+	q.processInstanceName = name
+	return q
+}
+
+// ProcessInstanceNameLike filters by process instance name using a SQL LIKE pattern ('%' and '_' wildcards)
+func (q *ProcessInstanceQuery) ProcessInstanceNameLike(pattern string) *ProcessInstanceQuery {
+	q.processInstanceNameLike = pattern
+	return q
+}
+
+// Tag filters to process instances with a tag. Process instances must have the tags
+// of all calls.
+func (q *ProcessInstanceQuery) Tag(tag string) *ProcessInstanceQuery {
+	q.tags = append(q.tags, tag)
+	return q
+}
+
+// TagIn filters to process instances with at least one of the tags
+func (q *ProcessInstanceQuery) TagIn(tags ...string) *ProcessInstanceQuery {
+	q.tagIn = tags
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *ProcessInstanceQuery) ProcessDefinitionID(id string) *ProcessInstanceQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *ProcessInstanceQuery) ProcessDefinitionKey(key string) *ProcessInstanceQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// ProcessDefinitionName filters by process definition name
+func (q *ProcessInstanceQuery) ProcessDefinitionName(name string) *ProcessInstanceQuery {
+	q.processDefinitionName = name
+	return q
+}
+
+// StartUserID filters by the user who started the process
+func (q *ProcessInstanceQuery) StartUserID(userID string) *ProcessInstanceQuery {
+	q.startUserID = userID
+	return q
+}
+
+// InvolvedUser filters process instances related to a user by any identity link
+func (q *ProcessInstanceQuery) InvolvedUser(userID string) *ProcessInstanceQuery {
+	q.involvedUser = userID
+	return q
+}
+
+// InvolvedGroups filters process instances related to one of the groups by any identity link
+func (q *ProcessInstanceQuery) InvolvedGroups(groupIDs ...string) *ProcessInstanceQuery {
+	q.involvedGroups = groupIDs
+	return q
+}
+
+// SuperProcessInstanceID filters by super process instance ID
+func (q *ProcessInstanceQuery) SuperProcessInstanceID(id string) *ProcessInstanceQuery {
+	q.superProcessInstanceID = id
+	return q
+}
+
+// SubProcessInstanceID filters by sub process instance ID
+func (q *ProcessInstanceQuery) SubProcessInstanceID(id string) *ProcessInstanceQuery {
+	q.subProcessInstanceID = id
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *ProcessInstanceQuery) TenantID(tenantID string) *ProcessInstanceQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// Active filters to only active process instances
+func (q *ProcessInstanceQuery) Active() *ProcessInstanceQuery {
+	trueVal := true
+	q.active = &trueVal
+	return q
+}
+
+// Suspended filters to only suspended process instances
+func (q *ProcessInstanceQuery) Suspended() *ProcessInstanceQuery {
+	trueVal := true
+	q.suspended = &trueVal
+	return q
+}
+
+// VariableValueEquals filters by the value of a process variable
+func (q *ProcessInstanceQuery) VariableValueEquals(name string, value interface{}) *ProcessInstanceQuery {
+	if q.variableValueEquals == nil {
+		q.variableValueEquals = make(map[string]interface{})
+	}
+	q.variableValueEquals[name] = value
+	return q
+}
+
+// OrderByProcessInstanceID orders results by process instance ID
+func (q *ProcessInstanceQuery) OrderByProcessInstanceID() *ProcessInstanceQuery {
+	q.orderBy = "id"
+	return q
+}
+
+// OrderByProcessInstanceName orders results by process instance name
+func (q *ProcessInstanceQuery) OrderByProcessInstanceName() *ProcessInstanceQuery {
+	q.orderBy = "name"
+	return q
+}
+
+// OrderByProcessDefinitionKey orders results by process definition key
+func (q *ProcessInstanceQuery) OrderByProcessDefinitionKey() *ProcessInstanceQuery {
+	q.orderBy = "process_definition_key"
+	return q
+}
+
+// OrderByStartTime orders results by start time
+func (q *ProcessInstanceQuery) OrderByStartTime() *ProcessInstanceQuery {
+	q.orderBy = "start_time"
+	return q
+}
+
+// Asc sets ascending order
+func (q *ProcessInstanceQuery) Asc() *ProcessInstanceQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *ProcessInstanceQuery) Desc() *ProcessInstanceQuery {
+	q.ascending = false
+	return q
+}
+
+// Or starts a group of filters of which a process instance must match at least one.
+// The group is closed with EndOr. Ordering and paging apply to the enclosing query only.
+func (q *ProcessInstanceQuery) Or() *ProcessInstanceQuery {
+	group := &ProcessInstanceQuery{parent: q, service: q.service}
+	q.orQueries = append(q.orQueries, group)
+	return group
+}
+
+// EndOr ends a group of filters started with Or and returns the enclosing query
+func (q *ProcessInstanceQuery) EndOr() *ProcessInstanceQuery {
+	if q.parent == nil {
+		return q
+	}
+	return q.parent
+}
+
+// criteria splits the filters of a query into queries with a single filter each
+func (q *ProcessInstanceQuery) criteria() []*ProcessInstanceQuery {
+	criteria := make([]*ProcessInstanceQuery, 0)
+	add := func(set bool, apply func(c *ProcessInstanceQuery)) {
+		if set {
+			c := &ProcessInstanceQuery{}
+			apply(c)
+			criteria = append(criteria, c)
+		}
+	}
+
+	add(q.processInstanceID != "", func(c *ProcessInstanceQuery) { c.processInstanceID = q.processInstanceID })
+	add(q.processInstanceBusinessKey != "", func(c *ProcessInstanceQuery) { c.processInstanceBusinessKey = q.processInstanceBusinessKey })
+	add(q.processInstanceName != "", func(c *ProcessInstanceQuery) { c.processInstanceName = q.processInstanceName })
+	add(q.processInstanceNameLike != "", func(c *ProcessInstanceQuery) { c.processInstanceNameLike = q.processInstanceNameLike })
+	for _, tag := range q.tags {
+		add(true, func(c *ProcessInstanceQuery) { c.tags = []string{tag} })
+	}
+	add(len(q.tagIn) > 0, func(c *ProcessInstanceQuery) { c.tagIn = q.tagIn })
+	add(q.processDefinitionID != "", func(c *ProcessInstanceQuery) { c.processDefinitionID = q.processDefinitionID })
+	add(q.processDefinitionKey != "", func(c *ProcessInstanceQuery) { c.processDefinitionKey = q.processDefinitionKey })
+	add(q.processDefinitionName != "", func(c *ProcessInstanceQuery) { c.processDefinitionName = q.processDefinitionName })
+	add(q.superProcessInstanceID != "", func(c *ProcessInstanceQuery) { c.superProcessInstanceID = q.superProcessInstanceID })
+	add(q.subProcessInstanceID != "", func(c *ProcessInstanceQuery) { c.subProcessInstanceID = q.subProcessInstanceID })
+	add(q.startUserID != "", func(c *ProcessInstanceQuery) { c.startUserID = q.startUserID })
+	add(q.involvedUser != "", func(c *ProcessInstanceQuery) { c.involvedUser = q.involvedUser })
+	add(len(q.involvedGroups) > 0, func(c *ProcessInstanceQuery) { c.involvedGroups = q.involvedGroups })
+	add(q.tenantID != "", func(c *ProcessInstanceQuery) { c.tenantID = q.tenantID })
+	add(q.suspended != nil, func(c *ProcessInstanceQuery) { c.suspended = q.suspended })
+	add(q.active != nil, func(c *ProcessInstanceQuery) { c.active = q.active })
+	for name, value := range q.variableValueEquals {
+		add(true, func(c *ProcessInstanceQuery) { c.variableValueEquals = map[string]interface{}{name: value} })
+	}
+	return criteria
+}
+
+// Limit sets the maximum number of results List returns
+func (q *ProcessInstanceQuery) Limit(maxResults int) *ProcessInstanceQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *ProcessInstanceQuery) Offset(firstResult int) *ProcessInstanceQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of running process instances
+func (q *ProcessInstanceQuery) List(ctx context.Context) ([]*ProcessInstance, error) {
+	if impl, ok := q.service.(*runtimeServiceImpl); ok {
+		return impl.listProcessInstances(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching process instances, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *ProcessInstanceQuery) Iterate(ctx context.Context) iter.Seq2[*ProcessInstance, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*ProcessInstance, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of process instances
+func (q *ProcessInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*ProcessInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching process instances, ignoring Limit and Offset
+func (q *ProcessInstanceQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	processInstances, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(processInstances)), nil
+}
+
+// SingleResult returns a single process instance or error if not exactly one result
+func (q *ProcessInstanceQuery) SingleResult(ctx context.Context) (*ProcessInstance, error) {
+	processInstances, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(processInstances) != 1 {
+		return nil, fmt.Errorf("expected one process instance, found %d", len(processInstances))
+	}
+	return processInstances[0], nil
+}
+
+// ExecutionQuery provides a fluent API for querying executions
+type ExecutionQuery struct {
+	executionID         string
+	processInstanceID   string
+	processDefinitionID string
+	processDefinitionKey string
+	activityID          string
+	parentID            string
+	tenantID            string
+	active              *bool
+	suspended           *bool
+	variableValueEquals map[string]interface{}
+	orderBy             string
+	ascending           bool
+	service             RuntimeService
+}
+
+// ExecutionID filters by execution ID
+func (q *ExecutionQuery) ExecutionID(id string) *ExecutionQuery {
+	q.executionID = id
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *ExecutionQuery) ProcessInstanceID(id string) *ExecutionQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *ExecutionQuery) ProcessDefinitionID(id string) *ExecutionQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ActivityID filters by activity ID
+func (q *ExecutionQuery) ActivityID(activityID string) *ExecutionQuery {
+	q.activityID = activityID
+	return q
+}
+
+// ParentID filters by parent execution ID
+func (q *ExecutionQuery) ParentID(parentID string) *ExecutionQuery {
+	q.parentID = parentID
+	return q
+}
+
+// Active filters to only active executions
+func (q *ExecutionQuery) Active() *ExecutionQuery {
+	trueVal := true
+	q.active = &trueVal
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *ExecutionQuery) ProcessDefinitionKey(key string) *ExecutionQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *ExecutionQuery) TenantID(tenantID string) *ExecutionQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// Suspended filters to executions of suspended process instances
+func (q *ExecutionQuery) Suspended() *ExecutionQuery {
+	trueVal := true
+	q.suspended = &trueVal
+	return q
+}
+
+// VariableValueEquals filters by the value of a variable visible to the execution
+func (q *ExecutionQuery) VariableValueEquals(name string, value interface{}) *ExecutionQuery {
+	if q.variableValueEquals == nil {
+		q.variableValueEquals = make(map[string]interface{})
+	}
+	q.variableValueEquals[name] = value
+	return q
+}
+
+// OrderByExecutionID orders results by execution ID
+func (q *ExecutionQuery) OrderByExecutionID() *ExecutionQuery {
+	q.orderBy = "id"
+	return q
+}
+
+// OrderByProcessInstanceID orders results by process instance ID
+func (q *ExecutionQuery) OrderByProcessInstanceID() *ExecutionQuery {
+	q.orderBy = "process_instance_id"
+	return q
+}
+
+// Asc sets ascending order
+func (q *ExecutionQuery) Asc() *ExecutionQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *ExecutionQuery) Desc() *ExecutionQuery {
+	q.ascending = false
+	return q
+}
+
+// List executes the query and returns a list of executions
+func (q *ExecutionQuery) List(ctx context.Context) ([]*Execution, error) {
+	if impl, ok := q.service.(*runtimeServiceImpl); ok {
+		return impl.listExecutions(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Count returns the count of matching executions
+func (q *ExecutionQuery) Count(ctx context.Context) (int64, error) {
+	executions, err := q.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(executions)), nil
+}
+
+// SingleResult returns a single execution or error if not exactly one result
+func (q *ExecutionQuery) SingleResult(ctx context.Context) (*Execution, error) {
+	executions, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(executions) != 1 {
+		return nil, fmt.Errorf("expected one execution, found %d", len(executions))
+	}
+	return executions[0], nil
+}
@@ -0,0 +1,720 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/batch"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/expression"
+	"github.com/muixstudio/flowgo/pkg/variable"
+	"github.com/muixstudio/flowgo/tracing"
+)
+
+// runtimeServiceImpl is the default implementation of RuntimeService
+type runtimeServiceImpl struct {
+	repositoryService repository.RepositoryService
+	enableAsync       bool
+	processInstances  map[string]*ProcessInstance
+	executions        map[string]*Execution
+	variables         map[string]map[string]interface{} // scope execution ID -> variables
+	behaviors         map[string]ActivityBehavior       // node type -> behavior
+	delegates         map[string]ServiceTaskDelegate    // implementation name -> delegate
+	scriptEngines     map[string]ScriptEngine           // script format -> engine
+	expressions       *expression.Manager
+	jobs              map[string]*Job
+	deadLetterJobs    map[string]*Job // jobs without retries left
+	jobNotifier       JobNotifier
+	subscriptions     map[string]*EventSubscription
+	variableChanges   map[string]map[string]bool // process instance ID -> names of changed variables
+	identityLinks     map[string][]*identity.IdentityLink // process instance ID -> links other than the starter
+	historyHandler    HistoryEventHandler
+	historyEvents     map[string][]*HistoryEvent // process instance ID -> events not yet delivered
+	listeners         []registeredProcessInstanceListener
+	listenerEvents    map[string][]*ProcessInstanceEvent // process instance ID -> events not yet delivered to listeners
+	tracer            tracing.Tracer
+	clock             clock.Clock
+	logger            *slog.Logger
+	stopped           bool
+	store             *persistence.Store
+	contents          *persistence.VariableContents // large variable values kept apart, if any
+	batches           batch.BatchService
+	mu                sync.RWMutex
+}
+
+// NewRuntimeService creates a new runtime service
+func NewRuntimeService(repositoryService repository.RepositoryService, enableAsync bool) RuntimeService {
+	s := &runtimeServiceImpl{
+		repositoryService: repositoryService,
+		enableAsync:       enableAsync,
+		processInstances:  make(map[string]*ProcessInstance),
+		executions:        make(map[string]*Execution),
+		variables:         make(map[string]map[string]interface{}),
+		delegates:         make(map[string]ServiceTaskDelegate),
+		scriptEngines:     map[string]ScriptEngine{"expression": &expressionScriptEngine{}},
+		expressions:       expression.NewManager(),
+		jobs:              make(map[string]*Job),
+		deadLetterJobs:    make(map[string]*Job),
+		subscriptions:     make(map[string]*EventSubscription),
+		variableChanges:   make(map[string]map[string]bool),
+		identityLinks:     make(map[string][]*identity.IdentityLink),
+		historyEvents:     make(map[string][]*HistoryEvent),
+		listenerEvents:    make(map[string][]*ProcessInstanceEvent),
+		tracer:            tracing.NoopTracer(),
+		clock:             clock.System(),
+		logger:            slog.Default(),
+	}
+	s.behaviors = map[string]ActivityBehavior{
+		model.NodeTypeStartEvent:        &passThroughBehavior{},
+		model.NodeTypeEndEvent:          &endEventBehavior{},
+		model.NodeTypeUserTask:          &waitStateBehavior{},
+		model.NodeTypeServiceTask:       &serviceTaskBehavior{service: s},
+		model.NodeTypeScriptTask:        &scriptTaskBehavior{service: s},
+		model.NodeTypeParallelGateway:   &parallelGatewayBehavior{},
+		model.NodeTypeExclusiveGateway:  &exclusiveGatewayBehavior{},
+		model.NodeTypeSubProcess:        &subProcessBehavior{},
+		model.NodeTypeCallActivity:      &callActivityBehavior{service: s},
+		model.NodeTypeBoundaryEvent:     &passThroughBehavior{},
+		model.NodeTypeIntermediateEvent: &intermediateEventBehavior{},
+	}
+	s.delegates["http"] = &httpServiceTask{client: &http.Client{}}
+
+	// Suspensions of process definitions reach their process instances through this service
+	if repositoryService != nil {
+		repositoryService.SetSuspensionHandler(s)
+	}
+	return s
+}
+
+// Initialize initializes the runtime service
+func (s *runtimeServiceImpl) Initialize(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+	return s.load(ctx)
+}
+
+// Shutdown gracefully shuts down the runtime service
+func (s *runtimeServiceImpl) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.stopped = true
+	return nil
+}
+
+// StartProcessInstanceByKey starts a process instance by process definition key
+func (s *runtimeServiceImpl) StartProcessInstanceByKey(ctx context.Context, processDefinitionKey string, variables map[string]interface{}) (*ProcessInstance, error) {
+	// Get the latest process definition by key
+	processDefinition, err := s.repositoryService.GetProcessDefinitionByKey(ctx, processDefinitionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process definition: %w", err)
+	}
+
+	return s.startProcessInstance(ctx, processDefinition, processInstanceStart{variables: variables})
+}
+
+// StartProcessInstanceByID starts a process instance by process definition ID
+func (s *runtimeServiceImpl) StartProcessInstanceByID(ctx context.Context, processDefinitionID string, variables map[string]interface{}) (*ProcessInstance, error) {
+	processDefinition, err := s.repositoryService.GetProcessDefinition(ctx, processDefinitionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process definition: %w", err)
+	}
+
+	return s.startProcessInstance(ctx, processDefinition, processInstanceStart{variables: variables})
+}
+
+// StartProcessInstanceByKeyWithBusinessKey starts a process instance with a business key
+func (s *runtimeServiceImpl) StartProcessInstanceByKeyWithBusinessKey(ctx context.Context, processDefinitionKey, businessKey string, variables map[string]interface{}) (*ProcessInstance, error) {
+	processDefinition, err := s.repositoryService.GetProcessDefinitionByKey(ctx, processDefinitionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process definition: %w", err)
+	}
+
+	return s.startProcessInstance(ctx, processDefinition, processInstanceStart{businessKey: businessKey, variables: variables})
+}
+
+// StartProcessInstanceByMessage starts a process instance by message start event
+func (s *runtimeServiceImpl) StartProcessInstanceByMessage(ctx context.Context, messageName, businessKey string, variables map[string]interface{}) (*ProcessInstance, error) {
+	subscriptions, err := s.repositoryService.GetStartEventSubscriptions(ctx, repository.EventTypeMessage, messageName)
+	if err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, fmt.Errorf("no process definition found for message start event: %s", messageName)
+	}
+	if len(subscriptions) > 1 {
+		return nil, fmt.Errorf("message start event '%s' is ambiguous: %d process definitions subscribe to it", messageName, len(subscriptions))
+	}
+
+	return s.startProcessInstanceBySubscription(ctx, subscriptions[0], businessKey, variables)
+}
+
+// SignalEventReceived broadcasts a signal: it starts process instances by signal start
+// event and delivers the signal to all executions subscribed to it
+func (s *runtimeServiceImpl) SignalEventReceived(ctx context.Context, signalName string, variables map[string]interface{}) ([]*ProcessInstance, error) {
+	subscriptions, err := s.repositoryService.GetStartEventSubscriptions(ctx, repository.EventTypeSignal, signalName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Executions of the instances started below do not receive the signal
+	executionSubscriptions := s.findSignalSubscriptions(signalName, "")
+
+	processInstances := make([]*ProcessInstance, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		processInstance, err := s.startProcessInstanceBySubscription(ctx, subscription, "", variables)
+		if err != nil {
+			return processInstances, err
+		}
+		processInstances = append(processInstances, processInstance)
+	}
+
+	if err := s.notifySignalSubscriptions(ctx, executionSubscriptions, variables); err != nil {
+		return processInstances, err
+	}
+	return processInstances, nil
+}
+
+// EvaluateConditionalStartEvents starts process instances by conditional start event
+func (s *runtimeServiceImpl) EvaluateConditionalStartEvents(ctx context.Context, variables map[string]interface{}) ([]*ProcessInstance, error) {
+	subscriptions, err := s.repositoryService.GetStartEventSubscriptions(ctx, repository.EventTypeConditional, "")
+	if err != nil {
+		return nil, err
+	}
+
+	processInstances := make([]*ProcessInstance, 0)
+	for _, subscription := range subscriptions {
+		satisfied, err := s.expressions.EvaluateBool(subscription.Condition, variables)
+		if err != nil {
+			return processInstances, fmt.Errorf("failed to evaluate condition of start event '%s': %w", subscription.ActivityID, err)
+		}
+		if !satisfied {
+			continue
+		}
+
+		processInstance, err := s.startProcessInstanceBySubscription(ctx, subscription, "", variables)
+		if err != nil {
+			return processInstances, err
+		}
+		processInstances = append(processInstances, processInstance)
+	}
+	return processInstances, nil
+}
+
+// startProcessInstanceBySubscription starts a process instance at the start event of a subscription
+func (s *runtimeServiceImpl) startProcessInstanceBySubscription(ctx context.Context, subscription *repository.EventSubscription, businessKey string, variables map[string]interface{}) (*ProcessInstance, error) {
+	processDefinition, err := s.repositoryService.GetProcessDefinition(ctx, subscription.ProcessDefinitionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process definition: %w", err)
+	}
+
+	return s.startProcessInstance(ctx, processDefinition, processInstanceStart{
+		businessKey:     businessKey,
+		startActivityID: subscription.ActivityID,
+		variables:       variables,
+	})
+}
+
+// processInstanceStart describes how a process instance is started
+type processInstanceStart struct {
+	businessKey string
+	name        string
+	tags        []string
+	// startActivityID is the start event that fired; empty means the none start event
+	startActivityID string
+	// startBeforeActivityIDs are the activities the instance starts at instead of a start event
+	startBeforeActivityIDs []string
+	// startUserID is the initiator; empty means the authenticated user
+	startUserID        string
+	variables          map[string]interface{}
+	transientVariables map[string]interface{}
+	// superExecution is the call activity execution starting the instance, if any
+	superExecution *ActivityExecution
+}
+
+// startProcessInstance is the internal method to start a process instance.
+// The process runs until every path has reached a wait state or an end event.
+func (s *runtimeServiceImpl) startProcessInstance(ctx context.Context, processDefinition *repository.ProcessDefinition, start processInstanceStart) (*ProcessInstance, error) {
+	a := &agenda{}
+	processInstance, err := s.createProcessInstance(ctx, a, processDefinition, start)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.runAgenda(ctx, a)
+	if len(start.transientVariables) > 0 {
+		s.mu.Lock()
+		s.removeTransientVariablesLocked(processInstance.ID, start.transientVariables)
+		s.mu.Unlock()
+	}
+	if err != nil {
+		// Also removes the instances started by call activities during the run
+		s.mu.Lock()
+		for _, id := range a.touched {
+			s.removeProcessInstanceLocked(id)
+		}
+		s.discardHistoryEventsLocked(a.touched...)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("failed to start process instance: %w", err)
+	}
+
+	if err := s.saveProcessInstances(ctx, a.touched...); err != nil {
+		return nil, err
+	}
+	return processInstance, nil
+}
+
+// createProcessInstance creates a process instance and plans its start on the agenda
+func (s *runtimeServiceImpl) createProcessInstance(ctx context.Context, a *agenda, processDefinition *repository.ProcessDefinition, start processInstanceStart) (*ProcessInstance, error) {
+	// Check if process definition is suspended
+	if processDefinition.Suspended {
+		return nil, &errors.SuspendedError{EntityType: "process definition", ID: processDefinition.ID}
+	}
+
+	processModel, err := s.getProcessModel(ctx, processDefinition.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var startEvent *model.Node
+	startBefore := make([]*model.Node, len(start.startBeforeActivityIDs))
+	if len(startBefore) == 0 {
+		if startEvent, err = findStartEvent(processModel, start.startActivityID); err != nil {
+			return nil, err
+		}
+	}
+	for i, activityID := range start.startBeforeActivityIDs {
+		if startBefore[i] = processModel.GetNode(activityID); startBefore[i] == nil {
+			return nil, fmt.Errorf("node %w: %s", errors.ErrNotFound, activityID)
+		}
+		if startBefore[i].ParentID != "" {
+			return nil, fmt.Errorf("cannot start at activity '%s': it is inside subprocess '%s'", activityID, startBefore[i].ParentID)
+		}
+	}
+	startUserID := start.startUserID
+	if startUserID == "" {
+		startUserID = identity.AuthenticatedUserID(ctx)
+	}
+	name := start.name
+	if name == "" && processModel.ProcessInstanceName != "" {
+		if name, err = s.evaluateProcessInstanceName(processModel, start); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create process instance
+	processInstance := &ProcessInstance{
+		ID:                   uuid.New().String(),
+		ProcessDefinitionID:  processDefinition.ID,
+		ProcessDefinitionKey: processDefinition.Key,
+		ProcessDefinitionName: processDefinition.Name,
+		BusinessKey:          start.businessKey,
+		Name:                 name,
+		Tags:                 start.tags,
+		StartTime:            s.clock.Now(),
+		StartUserID:          startUserID,
+		TenantID:             processDefinition.TenantID,
+		RootProcessInstanceID: "",
+	}
+	processInstance.RootProcessInstanceID = processInstance.ID
+	if superExecution := start.superExecution; superExecution != nil {
+		processInstance.RootProcessInstanceID = superExecution.ProcessInstance.RootProcessInstanceID
+		processInstance.ParentProcessInstanceID = superExecution.ProcessInstance.ID
+		processInstance.SuperProcessInstanceID = superExecution.ProcessInstance.ID
+		processInstance.SuperExecutionID = superExecution.Execution.ID
+	}
+
+	// The root execution is the scope of the process instance and holds its variables.
+	// Paths through the process are child executions of it.
+	scope := &Execution{
+		ID:                processInstance.ID,
+		ProcessInstanceID: processInstance.ID,
+		IsScope:           true,
+		TenantID:          processDefinition.TenantID,
+	}
+	// One path starts at the start event, or one path at each activity to start at
+	targets := startBefore
+	if startEvent != nil {
+		targets = []*model.Node{startEvent}
+	}
+	executions := make([]*Execution, len(targets))
+	for i := range targets {
+		executions[i] = &Execution{
+			ID:                uuid.New().String(),
+			ProcessInstanceID: processInstance.ID,
+			ParentID:          scope.ID,
+			IsActive:          true,
+			IsConcurrent:      len(targets) > 1,
+			TenantID:          processDefinition.TenantID,
+		}
+	}
+	execution := executions[0]
+
+	s.mu.Lock()
+	s.processInstances[processInstance.ID] = processInstance
+	s.executions[scope.ID] = scope
+	for _, path := range executions {
+		s.executions[path.ID] = path
+	}
+	s.fireHistoryEventLocked(HistoryEventProcessInstanceStart, processInstance, execution.ID, targets[0], nil)
+
+	// Variables declared in the model are defaults for the passed variables
+	s.setVariablesLocked(execution.ID, scope.ID, processModel.Variables)
+	s.setVariablesLocked(execution.ID, scope.ID, start.variables)
+	s.setTransientVariablesLocked(scope.ID, start.transientVariables)
+
+	// The user starting the process is its initiator; the start event may name the
+	// variable holding the initiator with the "initiator" property
+	if userID := processInstance.StartUserID; userID != "" {
+		initiatorVariable := DefaultInitiatorVariable
+		if startEvent != nil && startEvent.GetString("initiator") != "" {
+			initiatorVariable = startEvent.GetString("initiator")
+		}
+		if _, exists := start.variables[initiatorVariable]; !exists {
+			s.setVariablesLocked(execution.ID, scope.ID, map[string]interface{}{initiatorVariable: userID})
+		}
+	}
+
+	if err := s.createEventSubProcessTriggersLocked(scope, processModel, ""); err != nil {
+		s.removeProcessInstanceLocked(processInstance.ID)
+		s.discardHistoryEventsLocked(processInstance.ID)
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.mu.Unlock()
+
+	a.touch(processInstance.ID)
+	for i := range targets {
+		path, target := executions[i], targets[i]
+		a.plan(func(ctx context.Context) error {
+			return s.executeActivity(ctx, a, path, processInstance, processModel, target)
+		})
+	}
+	return processInstance, nil
+}
+
+// evaluateProcessInstanceName evaluates the process instance name of a model with the
+// variables a process instance starts with
+func (s *runtimeServiceImpl) evaluateProcessInstanceName(processModel *model.ProcessModel, start processInstanceStart) (string, error) {
+	if !expression.IsExpression(processModel.ProcessInstanceName) {
+		return processModel.ProcessInstanceName, nil
+	}
+
+	variables := make(map[string]interface{})
+	for _, values := range []map[string]interface{}{processModel.Variables, start.variables, start.transientVariables} {
+		for k, v := range values {
+			variables[k] = variable.Unwrap(v)
+		}
+	}
+	value, err := s.expressions.Evaluate(processModel.ProcessInstanceName, variables)
+	if err != nil {
+		return "", fmt.Errorf("invalid process instance name of process '%s': %w", processModel.ID, err)
+	}
+	if value == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// SetProcessInstanceName renames a process instance
+func (s *runtimeServiceImpl) SetProcessInstanceName(ctx context.Context, processInstanceID, name string) error {
+	s.mu.Lock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	if !exists || processInstance.EndTime != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	processInstance.Name = name
+	s.fireHistoryEventLocked(HistoryEventProcessInstanceUpdate, processInstance, "", nil, nil)
+	s.mu.Unlock()
+
+	return s.saveProcessInstances(ctx, processInstanceID)
+}
+
+// DeleteProcessInstance cancels and removes a process instance
+func (s *runtimeServiceImpl) DeleteProcessInstance(ctx context.Context, processInstanceID, deleteReason string) error {
+	s.mu.RLock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	if deleteReason == "" {
+		deleteReason = DeleteReasonDeleted
+	}
+
+	a := &agenda{}
+	if err := s.deleteProcessInstance(ctx, a, processInstance, deleteReason); err != nil {
+		return err
+	}
+	return s.saveProcessInstances(ctx, a.touched...)
+}
+
+// deleteProcessInstance cancels the activities of a running process instance, which
+// deletes their tasks and the process instances started by its call activities, ends
+// it with a delete reason and removes it. The caller saves the touched instances.
+func (s *runtimeServiceImpl) deleteProcessInstance(ctx context.Context, a *agenda, processInstance *ProcessInstance, deleteReason string) error {
+	a.touch(processInstance.ID)
+
+	s.mu.RLock()
+	root := s.executions[processInstance.ID]
+	s.mu.RUnlock()
+
+	if root != nil && processInstance.EndTime == nil {
+		processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+		if err != nil {
+			return err
+		}
+		current := &ActivityExecution{
+			Execution:       root,
+			ProcessInstance: processInstance,
+			Model:           processModel,
+			service:         s,
+			agenda:          a,
+		}
+		if err := s.cancelChildExecutions(ctx, current); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if processInstance.EndTime == nil {
+		endTime := s.clock.Now()
+		processInstance.EndTime = &endTime
+		s.fireHistoryEventLocked(HistoryEventProcessInstanceEnd, processInstance, processInstance.ID, nil, func(event *HistoryEvent) {
+			event.DeleteReason = deleteReason
+		})
+		s.fireProcessInstanceEventLocked(ProcessInstanceEventCancelled, processInstance, func(event *ProcessInstanceEvent) {
+			event.DeleteReason = deleteReason
+		})
+	}
+	s.removeProcessInstanceLocked(processInstance.ID)
+	return nil
+}
+
+// removeProcessInstanceLocked removes a process instance with its executions and variables.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) removeProcessInstanceLocked(processInstanceID string) {
+	for id, exec := range s.executions {
+		if exec.ProcessInstanceID == processInstanceID {
+			s.deleteExecutionLocked(id)
+		}
+	}
+	delete(s.processInstances, processInstanceID)
+	delete(s.identityLinks, processInstanceID)
+}
+
+// SuspendProcessInstance suspends a process instance and the tasks of its activities
+func (s *runtimeServiceImpl) SuspendProcessInstance(ctx context.Context, processInstanceID string) error {
+	return s.setProcessInstanceSuspended(ctx, processInstanceID, true)
+}
+
+// ActivateProcessInstance activates a suspended process instance and the tasks of its activities
+func (s *runtimeServiceImpl) ActivateProcessInstance(ctx context.Context, processInstanceID string) error {
+	return s.setProcessInstanceSuspended(ctx, processInstanceID, false)
+}
+
+// CreateProcessInstanceQuery creates a new process instance query
+func (s *runtimeServiceImpl) CreateProcessInstanceQuery() *ProcessInstanceQuery {
+	return &ProcessInstanceQuery{
+		service: s,
+	}
+}
+
+// GetProcessInstance retrieves a process instance by ID
+func (s *runtimeServiceImpl) GetProcessInstance(ctx context.Context, processInstanceID string) (*ProcessInstance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	processInstance, exists := s.processInstances[processInstanceID]
+	if !exists {
+		return nil, fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	return processInstance, nil
+}
+
+// GetActivityInstanceDiagram renders the process model of a process instance with its active activities highlighted
+func (s *runtimeServiceImpl) GetActivityInstanceDiagram(ctx context.Context, processInstanceID string) ([]byte, error) {
+	s.mu.RLock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	var activityIDs []string
+	if exists {
+		activityIDs = s.activeActivityIDsLocked(processInstanceID)
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+	return processModel.RenderSVG(activityIDs...), nil
+}
+
+// GetActiveActivityIds returns the IDs of the activities a process instance is currently at
+func (s *runtimeServiceImpl) GetActiveActivityIds(ctx context.Context, processInstanceID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.processInstances[processInstanceID]; !exists {
+		return nil, fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+	return s.activeActivityIDsLocked(processInstanceID), nil
+}
+
+// activeActivityIDsLocked returns the IDs of the activities the executions of a process
+// instance are at, including the subprocesses they run in, sorted and without duplicates
+func (s *runtimeServiceImpl) activeActivityIDsLocked(processInstanceID string) []string {
+	seen := make(map[string]bool)
+	activityIDs := make([]string, 0)
+	for _, execution := range s.executions {
+		if execution.ProcessInstanceID != processInstanceID || execution.ActivityID == "" || execution.IsEventScope {
+			continue
+		}
+		if !seen[execution.ActivityID] {
+			seen[execution.ActivityID] = true
+			activityIDs = append(activityIDs, execution.ActivityID)
+		}
+	}
+	sort.Strings(activityIDs)
+	return activityIDs
+}
+
+// SetVariable sets a variable on a process instance
+func (s *runtimeServiceImpl) SetVariable(ctx context.Context, executionID, variableName string, value interface{}) error {
+	return s.SetVariables(ctx, executionID, map[string]interface{}{variableName: value})
+}
+
+// SetVariables sets multiple variables on a process instance.
+// Variables already defined in a subprocess scope of the execution are updated there.
+func (s *runtimeServiceImpl) SetVariables(ctx context.Context, executionID string, variables map[string]interface{}) error {
+	s.mu.Lock()
+	if err := s.setVariablesVisibleLocked(executionID, variables); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	processInstanceID := s.executions[executionID].ProcessInstanceID
+	s.mu.Unlock()
+
+	return s.variablesChanged(ctx, processInstanceID)
+}
+
+// GetVariable gets a variable from a process instance
+func (s *runtimeServiceImpl) GetVariable(ctx context.Context, executionID, variableName string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	variables, err := s.getVariablesLocked(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return variables[variableName], nil
+}
+
+// GetVariables gets all variables visible to an execution
+func (s *runtimeServiceImpl) GetVariables(ctx context.Context, executionID string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getVariablesLocked(executionID)
+}
+
+// RemoveVariable removes a variable from a process instance
+func (s *runtimeServiceImpl) RemoveVariable(ctx context.Context, executionID, variableName string) error {
+	s.mu.Lock()
+	if err := s.removeVariableVisibleLocked(executionID, variableName); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	processInstanceID := s.executions[executionID].ProcessInstanceID
+	s.mu.Unlock()
+
+	return s.variablesChanged(ctx, processInstanceID)
+}
+
+// PatchVariables atomically applies a JSON patch to the variables of the nearest scope of an execution
+func (s *runtimeServiceImpl) PatchVariables(ctx context.Context, executionID string, jsonPatch []byte) error {
+	operations, err := ParsePatch(jsonPatch)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	current, err := s.contents.ResolveAll(ctx, s.variables[chain[0]])
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	patched, err := applyPatch(current, operations)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+
+	for name, value := range current {
+		if patchedValue, exists := patched[name]; !exists || !reflect.DeepEqual(value, patchedValue) {
+			s.recordScopeChangesLocked(chain[0], name)
+		}
+	}
+	for name, value := range patched {
+		if currentValue, exists := current[name]; !exists {
+			s.fireVariableUpdatesLocked(executionID, chain[0], map[string]interface{}{name: value}, nil)
+		} else if !reflect.DeepEqual(currentValue, value) {
+			s.fireVariableUpdatesLocked(executionID, chain[0], map[string]interface{}{name: value}, map[string]interface{}{name: currentValue})
+		}
+		if _, exists := s.variables[chain[0]][name]; !exists {
+			s.recordScopeChangesLocked(chain[0], name)
+		}
+	}
+	s.variables[chain[0]] = patched
+	processInstanceID := s.executions[executionID].ProcessInstanceID
+	s.mu.Unlock()
+
+	return s.variablesChanged(ctx, processInstanceID)
+}
+
+// Signal continues an execution waiting at a wait state
+func (s *runtimeServiceImpl) Signal(ctx context.Context, executionID string) error {
+	return s.SignalWithVariables(ctx, executionID, nil)
+}
+
+// SignalWithVariables continues an execution waiting at a wait state with variables.
+// The execution must wait at a receive task, user task, waiting service task or
+// intermediate catch event; it continues through the process from there.
+func (s *runtimeServiceImpl) SignalWithVariables(ctx context.Context, executionID string, variables map[string]interface{}) error {
+	return s.trigger(ctx, executionID, variables)
+}
+
+// CreateExecutionQuery creates a new execution query
+func (s *runtimeServiceImpl) CreateExecutionQuery() *ExecutionQuery {
+	return &ExecutionQuery{
+		service: s,
+	}
+}
@@ -0,0 +1,89 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultScriptTimeout limits the execution time of scripts without a "timeout" property
+const defaultScriptTimeout = 30 * time.Second
+
+// ScriptEngine evaluates the scripts of script tasks written in one language
+type ScriptEngine interface {
+	// Evaluate runs a script with access to the variables of the execution and returns
+	// the value of its last expression. Engines must stop when ctx is done.
+	Evaluate(ctx context.Context, script string, execution *ActivityExecution) (interface{}, error)
+}
+
+// scriptTaskBehavior runs the "script" of a script task with the engine registered for
+// its "scriptFormat" (javascript by default), stores the result in the "resultVariable"
+// if one is set and leaves the node. The "timeout" property limits the execution time.
+type scriptTaskBehavior struct {
+	service *runtimeServiceImpl
+}
+
+// Execute runs the script
+func (b *scriptTaskBehavior) Execute(ctx context.Context, execution *ActivityExecution) error {
+	node := execution.Node
+
+	script := node.GetString("script")
+	if script == "" {
+		return fmt.Errorf("script task '%s' must have a 'script' property", node.ID)
+	}
+
+	format := strings.ToLower(node.GetString("scriptFormat"))
+	if format == "" {
+		format = "javascript"
+	}
+
+	b.service.mu.RLock()
+	engine, exists := b.service.scriptEngines[format]
+	b.service.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("script task '%s': no script engine registered for format '%s'", node.ID, format)
+	}
+
+	timeout, err := getDuration(node, "timeout", defaultScriptTimeout)
+	if err != nil {
+		return err
+	}
+
+	scriptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := engine.Evaluate(scriptCtx, script, execution)
+	if err == nil && scriptCtx.Err() == context.DeadlineExceeded {
+		err = scriptCtx.Err()
+	}
+	if err != nil {
+		if scriptCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("script task '%s' timed out after %s", node.ID, timeout)
+		}
+		return fmt.Errorf("script task '%s' failed: %w", node.ID, err)
+	}
+
+	if name := node.GetString("resultVariable"); name != "" {
+		execution.SetVariable(name, result)
+	}
+	return execution.Leave()
+}
+
+// expressionScriptEngine is the built-in script engine for the "expression" format.
+// The script is an expression evaluated against the variables, e.g. "${price * quantity}".
+type expressionScriptEngine struct{}
+
+// Evaluate evaluates the expression
+func (e *expressionScriptEngine) Evaluate(ctx context.Context, script string, execution *ActivityExecution) (interface{}, error) {
+	return execution.ExpressionManager().Evaluate(script, execution.GetVariables())
+}
+
+// RegisterScriptEngine registers the engine evaluating scripts of a format, e.g. "javascript"
+func (s *runtimeServiceImpl) RegisterScriptEngine(format string, engine ScriptEngine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scriptEngines[strings.ToLower(format)] = engine
+}
@@ -0,0 +1,193 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/repository"
+)
+
+// setProcessInstanceSuspended suspends or activates a process instance and tells the
+// behaviors of the activities its executions wait in, e.g. to suspend their tasks.
+// Activated process instances have their pending jobs scheduled again and their
+// timers postponed by the time they were suspended.
+func (s *runtimeServiceImpl) setProcessInstanceSuspended(ctx context.Context, processInstanceID string, suspended bool) error {
+	s.mu.Lock()
+	processInstance, exists := s.processInstances[processInstanceID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("process instance %w: %s", errors.ErrNotFound, processInstanceID)
+	}
+
+	if suspended && !processInstance.Suspended {
+		now := s.clock.Now()
+		processInstance.SuspensionTime = &now
+	} else if !suspended && processInstance.Suspended {
+		s.resumeTimersLocked(processInstance)
+		processInstance.SuspensionTime = nil
+	}
+	processInstance.Suspended = suspended
+	if !suspended {
+		s.rescheduleJobsLocked(processInstanceID)
+	}
+	waiting := make([]*Execution, 0)
+	for _, execution := range s.executions {
+		if execution.ProcessInstanceID == processInstanceID && execution.ActivityID != "" {
+			waiting = append(waiting, execution)
+		}
+	}
+	err := s.saveProcessInstanceLocked(ctx, processInstanceID)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	processModel, err := s.getProcessModel(ctx, processInstance.ProcessDefinitionID)
+	if err != nil {
+		return err
+	}
+	for _, execution := range waiting {
+		node := processModel.GetNode(execution.ActivityID)
+		if node == nil {
+			continue
+		}
+
+		s.mu.RLock()
+		behavior, suspendable := s.behaviors[node.Type].(SuspendableActivityBehavior)
+		s.mu.RUnlock()
+		if !suspendable {
+			continue
+		}
+
+		current := &ActivityExecution{
+			Execution:       execution,
+			ProcessInstance: processInstance,
+			Node:            node,
+			Model:           processModel,
+			service:         s,
+			agenda:          &agenda{},
+		}
+		if err := behavior.SetSuspended(ctx, current, suspended); err != nil {
+			return fmt.Errorf("failed to suspend activity '%s': %w", node.ID, err)
+		}
+	}
+	return nil
+}
+
+// resumeTimersLocked postpones the timers of a process instance being activated by
+// the time it was suspended, so that they fire after the time they had left when it
+// was suspended. Callers must hold the write lock.
+func (s *runtimeServiceImpl) resumeTimersLocked(processInstance *ProcessInstance) {
+	if processInstance.SuspensionTime == nil {
+		return
+	}
+	paused := s.clock.Now().Sub(*processInstance.SuspensionTime)
+	if paused <= 0 {
+		return
+	}
+	for _, job := range s.jobs {
+		if job.ProcessInstanceID == processInstance.ID && job.Type == JobTypeTimer {
+			job.DueDate = job.DueDate.Add(paused)
+		}
+	}
+}
+
+// SetProcessInstancesSuspended suspends or activates the running process instances of
+// a process definition, oldest first
+func (s *runtimeServiceImpl) SetProcessInstancesSuspended(ctx context.Context, processDefinitionID string, suspended bool) error {
+	s.mu.RLock()
+	processInstances := make([]*ProcessInstance, 0)
+	for _, processInstance := range s.processInstances {
+		if processInstance.ProcessDefinitionID == processDefinitionID && processInstance.EndTime == nil && processInstance.Suspended != suspended {
+			processInstances = append(processInstances, processInstance)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(processInstances, func(i, j int) bool {
+		return processInstances[i].StartTime.Before(processInstances[j].StartTime)
+	})
+	for _, processInstance := range processInstances {
+		if err := s.setProcessInstanceSuspended(ctx, processInstance.ID, suspended); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleProcessDefinitionSuspension creates a job that suspends or activates a process
+// definition at a date
+func (s *runtimeServiceImpl) ScheduleProcessDefinitionSuspension(ctx context.Context, processDefinitionID string, suspended, includeProcessInstances bool, executionDate time.Time) error {
+	processDefinition, err := s.repositoryService.GetProcessDefinition(ctx, processDefinitionID)
+	if err != nil {
+		return err
+	}
+
+	jobType := JobTypeActivateProcessDefinition
+	if suspended {
+		jobType = JobTypeSuspendProcessDefinition
+	}
+	job := &Job{
+		ID:                      uuid.New().String(),
+		Type:                    jobType,
+		ProcessDefinitionID:     processDefinition.ID,
+		IncludeProcessInstances: includeProcessInstances,
+		Retries:                 defaultJobRetries,
+		DueDate:                 executionDate,
+		CreateTime:              s.clock.Now(),
+		TenantID:                processDefinition.TenantID,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs[job.ID] = job
+	s.scheduleJobLocked(job)
+	return s.saveJobLocked(ctx, job.ID)
+}
+
+// executeSuspensionJob suspends or activates the process definition of a scheduled
+// suspension job. A failed job is due again after the default retry interval.
+func (s *runtimeServiceImpl) executeSuspensionJob(ctx context.Context, job *Job) error {
+	options := make([]repository.SuspensionOption, 0, 1)
+	if job.IncludeProcessInstances {
+		options = append(options, repository.IncludeProcessInstances())
+	}
+
+	var err error
+	if job.Type == JobTypeSuspendProcessDefinition {
+		err = s.repositoryService.SuspendProcessDefinition(ctx, job.ProcessDefinitionID, options...)
+	} else {
+		err = s.repositoryService.ActivateProcessDefinition(ctx, job.ProcessDefinitionID, options...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		delete(s.jobs, job.ID)
+		return s.saveJobLocked(ctx, job.ID)
+	}
+
+	job.Retries--
+	job.ErrorMessage = err.Error()
+	job.ExceptionStacktrace = formatStacktrace(err)
+	job.DueDate = s.clock.Now().Add(defaultJobRetryInterval)
+	job.LockOwner = ""
+	job.LockExpirationTime = nil
+	if job.Retries <= 0 {
+		job.Retries = 0
+		s.logger.ErrorContext(ctx, "job suspending a process definition has no retries left",
+			slog.String("jobId", job.ID), slog.String("processDefinitionId", job.ProcessDefinitionID), slog.Any("error", err))
+	}
+	s.scheduleJobLocked(job)
+	if saveErr := s.saveJobLocked(ctx, job.ID); saveErr != nil {
+		return saveErr
+	}
+	return fmt.Errorf("job '%s' failed: %w", job.ID, err)
+}
@@ -0,0 +1,308 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+	"github.com/muixstudio/flowgo/pkg/schedule"
+)
+
+// getBoundaryEvents returns the boundary events of an event type attached to a node
+func getBoundaryEvents(processModel *model.ProcessModel, nodeID, eventType string) []*model.Node {
+	result := make([]*model.Node, 0)
+	for _, node := range processModel.GetNodesByType(model.NodeTypeBoundaryEvent) {
+		if node.GetString("attachedTo") == nodeID && node.GetString("eventType") == eventType {
+			result = append(result, node)
+		}
+	}
+	return result
+}
+
+// isInterrupting reports whether an event cancels the activity it is attached to, or
+// for the start event of an event subprocess, the rest of the enclosing scope.
+// Events interrupt unless "cancelActivity" (boundary events) or "isInterrupting"
+// (start events) is set to false.
+func isInterrupting(node *model.Node) bool {
+	key := "cancelActivity"
+	if node.Type == model.NodeTypeStartEvent {
+		key = "isInterrupting"
+	}
+	if interrupting, ok := node.Properties[key].(bool); ok {
+		return interrupting
+	}
+	return true
+}
+
+// evaluateTimer computes the first due date of a timer event definition. Dates are
+// RFC 3339 timestamps; durations use ISO 8601 or Go syntax; cycles are ISO 8601
+// repeating intervals or cron expressions. All may be expressions. The evaluated
+// cycle is returned for timers of type "cycle".
+func (s *runtimeServiceImpl) evaluateTimer(node *model.Node, variables map[string]interface{}, now time.Time) (time.Time, string, error) {
+	definition := node.GetMap("eventDefinition")
+	timerType, _ := definition["timerType"].(string)
+	timerValue, _ := definition["timerValue"].(string)
+
+	var value interface{} = timerValue
+	if expression.IsExpression(timerValue) {
+		var err error
+		if value, err = s.expressions.Evaluate(timerValue, variables); err != nil {
+			return time.Time{}, "", err
+		}
+	}
+
+	switch timerType {
+	case "date":
+		switch v := value.(type) {
+		case time.Time:
+			return v, "", nil
+		case string:
+			dueDate, err := time.Parse(time.RFC3339, v)
+			return dueDate, "", err
+		}
+	case "duration":
+		switch v := value.(type) {
+		case time.Duration:
+			return now.Add(v), "", nil
+		case string:
+			duration, err := expression.ParseDuration(v)
+			if err != nil {
+				return time.Time{}, "", err
+			}
+			return now.Add(duration), "", nil
+		}
+	case "cycle":
+		if v, ok := value.(string); ok {
+			cycle, err := schedule.Parse(v)
+			if err != nil {
+				return time.Time{}, "", err
+			}
+			dueDate, ok := cycle.Next(now)
+			if !ok || cycle.Repetitions() == 0 {
+				return time.Time{}, "", fmt.Errorf("timer cycle '%s' has no occurrences", v)
+			}
+			return dueDate, v, nil
+		}
+	default:
+		return time.Time{}, "", fmt.Errorf("unsupported timer type '%s'", timerType)
+	}
+	return time.Time{}, "", fmt.Errorf("unsupported %s value: %v", timerType, value)
+}
+
+// newTimerJob creates the job of a timer event due at its first occurrence
+func (s *runtimeServiceImpl) newTimerJob(event *model.Node, jobType string, variables map[string]interface{}, now time.Time) (*Job, error) {
+	dueDate, cycle, err := s.evaluateTimer(event, variables, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timer of event '%s': %w", event.ID, err)
+	}
+
+	job := s.newJob(event, jobType, dueDate)
+	if cycle != "" {
+		parsed, _ := schedule.Parse(cycle)
+		job.Cycle = cycle
+		job.Repetitions = parsed.Repetitions()
+	}
+	return job, nil
+}
+
+// nextTimerJob returns the job of the next occurrence of a timer cycle, or nil when
+// the cycle has no occurrences left. Occurrences missed, e.g. while the engine was
+// down, are skipped.
+func nextTimerJob(job *Job, now time.Time) *Job {
+	if job.Cycle == "" || job.Repetitions == 0 || job.Repetitions == 1 {
+		return nil
+	}
+	cycle, err := schedule.Parse(job.Cycle)
+	if err != nil {
+		return nil
+	}
+	dueDate, ok := cycle.Next(job.DueDate)
+	if ok && dueDate.Before(now) {
+		dueDate, ok = cycle.Next(now)
+	}
+	if !ok {
+		return nil
+	}
+
+	next := *job
+	next.ID = uuid.New().String()
+	next.DueDate = dueDate
+	next.CreateTime = now
+	next.ErrorMessage = ""
+	next.LockOwner = ""
+	next.LockExpirationTime = nil
+	if next.Repetitions > 0 {
+		next.Repetitions--
+	}
+	return &next
+}
+
+// createTimersLocked creates the timers of the timer boundary events attached to the
+// node an execution arrived at. Callers must hold the write lock.
+func (s *runtimeServiceImpl) createTimersLocked(execution *Execution, processModel *model.ProcessModel, node *model.Node) error {
+	boundaryEvents := getBoundaryEvents(processModel, node.ID, "timer")
+	if len(boundaryEvents) == 0 {
+		return nil
+	}
+
+	variables, err := s.getVariablesLocked(execution.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, boundaryEvent := range boundaryEvents {
+		if err := s.createTimerLocked(execution, boundaryEvent, variables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTimerLocked creates and schedules the timer job of a timer event for an execution.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) createTimerLocked(execution *Execution, event *model.Node, variables map[string]interface{}) error {
+	job, err := s.newTimerJob(event, JobTypeTimer, variables, s.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	job.ProcessInstanceID = execution.ProcessInstanceID
+	job.ExecutionID = execution.ID
+	job.TenantID = execution.TenantID
+	if processInstance := s.processInstances[execution.ProcessInstanceID]; processInstance != nil {
+		job.ProcessDefinitionID = processInstance.ProcessDefinitionID
+	}
+	s.jobs[job.ID] = job
+	s.scheduleJobLocked(job)
+	return nil
+}
+
+// deleteTimersLocked removes the timer jobs of an execution. Callers must hold the write lock.
+func (s *runtimeServiceImpl) deleteTimersLocked(executionID string) {
+	for id, job := range s.jobs {
+		if job.Type == JobTypeTimer && job.ExecutionID == executionID {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// runTimerJob fires the event of a timer job. The next occurrence of a timer cycle
+// is scheduled first, so that an interrupting event removes it with the other
+// timers of the execution.
+func (s *runtimeServiceImpl) runTimerJob(ctx context.Context, a *agenda, job *Job, execution *Execution, processInstance *ProcessInstance, processModel *model.ProcessModel, event *model.Node) error {
+	s.mu.Lock()
+	if next := nextTimerJob(job, s.clock.Now()); next != nil {
+		s.jobs[next.ID] = next
+		s.scheduleJobLocked(next)
+	}
+	// A retry of this occurrence must not schedule the next one again
+	job.Cycle = ""
+	s.mu.Unlock()
+
+	return s.triggerEvent(ctx, a, execution, processInstance, processModel, event, nil)
+}
+
+// syncTimerStartJobs creates the jobs of the timer start events of the latest active
+// process definitions and removes those of definitions that no longer start instances,
+// e.g. after a new version was deployed. Exhausted timer cycles are kept without
+// retries so that they are not created again.
+func (s *runtimeServiceImpl) syncTimerStartJobs(ctx context.Context) error {
+	subscriptions, err := s.repositoryService.GetStartEventSubscriptions(ctx, repository.EventTypeTimer, "")
+	if err != nil {
+		return err
+	}
+
+	events := make(map[string]*model.Node, len(subscriptions)) // job key -> start event
+	byKey := make(map[string]*repository.EventSubscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		processModel, err := s.getProcessModel(ctx, subscription.ProcessDefinitionID)
+		if err != nil {
+			return err
+		}
+		if event := processModel.GetNode(subscription.ActivityID); event != nil {
+			key := subscription.ProcessDefinitionID + "/" + subscription.ActivityID
+			events[key] = event
+			byKey[key] = subscription
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := make(map[string]bool)
+	for id, job := range s.jobs {
+		if job.Type != JobTypeTimerStart {
+			continue
+		}
+		key := job.ProcessDefinitionID + "/" + job.ActivityID
+		if _, exists := events[key]; !exists {
+			delete(s.jobs, id)
+			if err := s.saveJobLocked(ctx, id); err != nil {
+				return err
+			}
+			continue
+		}
+		existing[key] = true
+	}
+
+	now := s.clock.Now()
+	for key, event := range events {
+		if existing[key] {
+			continue
+		}
+		job, err := s.newTimerJob(event, JobTypeTimerStart, nil, now)
+		if err != nil {
+			// Keep the other timers running; the definition needs to be fixed and redeployed
+			s.logger.ErrorContext(ctx, "failed to schedule timer start event", slog.String("activityId", event.ID), slog.Any("error", err))
+			job = s.newJob(event, JobTypeTimerStart, now)
+			job.Retries = 0
+			job.ErrorMessage = err.Error()
+		}
+		job.ProcessDefinitionID = byKey[key].ProcessDefinitionID
+		job.TenantID = byKey[key].TenantID
+		s.jobs[job.ID] = job
+		s.scheduleJobLocked(job)
+		if err := s.saveJobLocked(ctx, job.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// executeTimerStartJob starts a process instance at the timer start event of a job.
+// The job moves on to the next occurrence of its cycle before the instance starts;
+// a one-off timer or exhausted cycle keeps the job without retries.
+func (s *runtimeServiceImpl) executeTimerStartJob(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	if next := nextTimerJob(job, s.clock.Now()); next != nil {
+		job.DueDate = next.DueDate
+		job.Repetitions = next.Repetitions
+	} else {
+		job.Retries = 0
+	}
+	job.LockOwner = ""
+	job.LockExpirationTime = nil
+	s.scheduleJobLocked(job)
+	err := s.saveJobLocked(ctx, job.ID)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	processDefinition, err := s.repositoryService.GetProcessDefinition(ctx, job.ProcessDefinitionID)
+	if err != nil {
+		return fmt.Errorf("failed to get process definition: %w", err)
+	}
+	if _, err := s.startProcessInstance(ctx, processDefinition, processInstanceStart{startActivityID: job.ActivityID}); err != nil {
+		s.mu.Lock()
+		job.ErrorMessage = err.Error()
+		s.mu.Unlock()
+		return fmt.Errorf("job '%s' failed: %w", job.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/pkg/like"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// VariableInstance is a variable of a running process instance as it is stored in a
+// scope: the process instance itself or an embedded subprocess. Variables are
+// identified by their scope and name.
+type VariableInstance struct {
+	Name                 string
+	TypeName             string
+	Value                interface{}
+	ScopeID              string // ID of the scope execution the variable is defined in
+	ActivityID           string // activity of the scope execution; empty for the process instance
+	ProcessInstanceID    string
+	ProcessDefinitionID  string
+	ProcessDefinitionKey string
+	TenantID             string
+}
+
+// Operators of variable value conditions
+const (
+	variableOperatorEquals             = "eq"
+	variableOperatorNotEquals          = "neq"
+	variableOperatorGreaterThan        = "gt"
+	variableOperatorGreaterThanOrEqual = "gteq"
+	variableOperatorLessThan           = "lt"
+	variableOperatorLessThanOrEqual    = "lteq"
+	variableOperatorLike               = "like"
+)
+
+// variableValueCondition is a condition on the value of the variables of a name
+type variableValueCondition struct {
+	name     string
+	operator string
+	value    interface{}
+}
+
+// VariableInstanceQuery provides a fluent API for querying the variables of running
+// process instances
+type VariableInstanceQuery struct {
+	variableName         string
+	variableNameLike     string
+	typeName             string
+	processInstanceID    string
+	processDefinitionKey string
+	executionID          string
+	scopeID              string
+	tenantID             string
+	valueConditions      []variableValueCondition
+	orderBy              string
+	ascending            bool
+	firstResult          int
+	maxResults           int
+	service              RuntimeService
+}
+
+// VariableName filters by variable name
+func (q *VariableInstanceQuery) VariableName(name string) *VariableInstanceQuery {
+	q.variableName = name
+	return q
+}
+
+// VariableNameLike filters by variable name using a SQL LIKE pattern ('%' and '_' wildcards)
+func (q *VariableInstanceQuery) VariableNameLike(pattern string) *VariableInstanceQuery {
+	q.variableNameLike = pattern
+	return q
+}
+
+// TypeName filters by variable type name, e.g. "string" or "long"
+func (q *VariableInstanceQuery) TypeName(typeName string) *VariableInstanceQuery {
+	q.typeName = typeName
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID, covering the variables of all its scopes
+func (q *VariableInstanceQuery) ProcessInstanceID(id string) *VariableInstanceQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *VariableInstanceQuery) ProcessDefinitionKey(key string) *VariableInstanceQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// ExecutionID filters to the variables visible to an execution: those of its scopes up
+// to the process instance, without the ones shadowed by a nearer scope
+func (q *VariableInstanceQuery) ExecutionID(id string) *VariableInstanceQuery {
+	q.executionID = id
+	return q
+}
+
+// ScopeID filters to the variables defined in a scope, i.e. the process instance or an
+// embedded subprocess, by the ID of its scope execution
+func (q *VariableInstanceQuery) ScopeID(id string) *VariableInstanceQuery {
+	q.scopeID = id
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *VariableInstanceQuery) TenantID(tenantID string) *VariableInstanceQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// VariableValueEquals filters to variables of a name with a value equal to the given one.
+// Numbers of different types are compared by value.
+func (q *VariableInstanceQuery) VariableValueEquals(name string, value interface{}) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorEquals, value)
+}
+
+// VariableValueNotEquals filters to variables of a name with a value other than the given one
+func (q *VariableInstanceQuery) VariableValueNotEquals(name string, value interface{}) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorNotEquals, value)
+}
+
+// VariableValueGreaterThan filters to variables of a name with a number, string or date
+// value greater than the given one
+func (q *VariableInstanceQuery) VariableValueGreaterThan(name string, value interface{}) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorGreaterThan, value)
+}
+
+// VariableValueGreaterThanOrEqual filters to variables of a name with a number, string or
+// date value greater than or equal to the given one
+func (q *VariableInstanceQuery) VariableValueGreaterThanOrEqual(name string, value interface{}) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorGreaterThanOrEqual, value)
+}
+
+// VariableValueLessThan filters to variables of a name with a number, string or date
+// value less than the given one
+func (q *VariableInstanceQuery) VariableValueLessThan(name string, value interface{}) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorLessThan, value)
+}
+
+// VariableValueLessThanOrEqual filters to variables of a name with a number, string or
+// date value less than or equal to the given one
+func (q *VariableInstanceQuery) VariableValueLessThanOrEqual(name string, value interface{}) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorLessThanOrEqual, value)
+}
+
+// VariableValueLike filters to variables of a name with a string value matching a SQL
+// LIKE pattern ('%' and '_' wildcards)
+func (q *VariableInstanceQuery) VariableValueLike(name, pattern string) *VariableInstanceQuery {
+	return q.addValueCondition(name, variableOperatorLike, pattern)
+}
+
+// addValueCondition adds a condition on the value of the variables of a name
+func (q *VariableInstanceQuery) addValueCondition(name, operator string, value interface{}) *VariableInstanceQuery {
+	q.valueConditions = append(q.valueConditions, variableValueCondition{name: name, operator: operator, value: value})
+	return q
+}
+
+// OrderByVariableName orders results by variable name
+func (q *VariableInstanceQuery) OrderByVariableName() *VariableInstanceQuery {
+	q.orderBy = "name"
+	return q
+}
+
+// OrderByVariableType orders results by variable type name
+func (q *VariableInstanceQuery) OrderByVariableType() *VariableInstanceQuery {
+	q.orderBy = "type"
+	return q
+}
+
+// OrderByProcessInstanceID orders results by process instance ID
+func (q *VariableInstanceQuery) OrderByProcessInstanceID() *VariableInstanceQuery {
+	q.orderBy = "process_instance_id"
+	return q
+}
+
+// Asc sets ascending order
+func (q *VariableInstanceQuery) Asc() *VariableInstanceQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *VariableInstanceQuery) Desc() *VariableInstanceQuery {
+	q.ascending = false
+	return q
+}
+
+// Limit sets the maximum number of results List returns
+func (q *VariableInstanceQuery) Limit(maxResults int) *VariableInstanceQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *VariableInstanceQuery) Offset(firstResult int) *VariableInstanceQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of variable instances
+func (q *VariableInstanceQuery) List(ctx context.Context) ([]*VariableInstance, error) {
+	if impl, ok := q.service.(*runtimeServiceImpl); ok {
+		return impl.listVariableInstances(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// ListPage executes the query and returns a page of variable instances
+func (q *VariableInstanceQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*VariableInstance, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching variable instances
+func (q *VariableInstanceQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	variables, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(variables)), nil
+}
+
+// SingleResult returns a single variable instance or error if not exactly one result
+func (q *VariableInstanceQuery) SingleResult(ctx context.Context) (*VariableInstance, error) {
+	variables, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(variables) != 1 {
+		return nil, fmt.Errorf("expected one variable instance, found %d", len(variables))
+	}
+	return variables[0], nil
+}
+
+// CreateVariableInstanceQuery creates a new variable instance query
+func (s *runtimeServiceImpl) CreateVariableInstanceQuery() *VariableInstanceQuery {
+	return &VariableInstanceQuery{
+		service:   s,
+		ascending: true,
+	}
+}
+
+// listVariableInstances returns the variables of running process instances matching a query
+func (s *runtimeServiceImpl) listVariableInstances(ctx context.Context, q *VariableInstanceQuery) ([]*VariableInstance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Scopes to consider, with the names each one contributes if restricted by visibility
+	scopes := make(map[string]map[string]bool)
+	if q.executionID != "" {
+		chain, err := s.scopeChainLocked(q.executionID)
+		if err != nil {
+			// An unknown execution has no variables
+			return []*VariableInstance{}, nil
+		}
+		seen := make(map[string]bool)
+		for _, scopeID := range chain {
+			visible := make(map[string]bool)
+			for name := range s.variables[scopeID] {
+				if !seen[name] {
+					seen[name] = true
+					visible[name] = true
+				}
+			}
+			scopes[scopeID] = visible
+		}
+	} else {
+		for scopeID := range s.variables {
+			scopes[scopeID] = nil
+		}
+	}
+
+	result := make([]*VariableInstance, 0)
+	for scopeID, visible := range scopes {
+		if q.scopeID != "" && scopeID != q.scopeID {
+			continue
+		}
+		execution, exists := s.executions[scopeID]
+		if !exists {
+			continue
+		}
+		processInstance, exists := s.processInstances[execution.ProcessInstanceID]
+		if !exists || processInstance.EndTime != nil || !identity.IsTenantAccessible(ctx, processInstance.TenantID) {
+			continue
+		}
+		if q.processInstanceID != "" && processInstance.ID != q.processInstanceID {
+			continue
+		}
+		if q.processDefinitionKey != "" && processInstance.ProcessDefinitionKey != q.processDefinitionKey {
+			continue
+		}
+		if q.tenantID != "" && processInstance.TenantID != q.tenantID {
+			continue
+		}
+
+		for name, stored := range s.variables[scopeID] {
+			if visible != nil && !visible[name] {
+				continue
+			}
+			if q.variableName != "" && name != q.variableName {
+				continue
+			}
+			if q.variableNameLike != "" && !like.Match(name, q.variableNameLike) {
+				continue
+			}
+			value, err := s.contents.Resolve(ctx, stored)
+			if err != nil {
+				return nil, err
+			}
+			typed, err := variable.Of(value)
+			if err != nil {
+				return nil, fmt.Errorf("variable '%s': %w", name, err)
+			}
+			if q.typeName != "" && typed.Type != q.typeName {
+				continue
+			}
+			if !matchesValueConditions(name, value, q.valueConditions) {
+				continue
+			}
+
+			activityID := ""
+			if execution.ID != processInstance.ID {
+				activityID = execution.ActivityID
+			}
+			result = append(result, &VariableInstance{
+				Name:                 name,
+				TypeName:             typed.Type,
+				Value:                value,
+				ScopeID:              scopeID,
+				ActivityID:           activityID,
+				ProcessInstanceID:    processInstance.ID,
+				ProcessDefinitionID:  processInstance.ProcessDefinitionID,
+				ProcessDefinitionKey: processInstance.ProcessDefinitionKey,
+				TenantID:             processInstance.TenantID,
+			})
+		}
+	}
+
+	paging.Sort(result, func(a, b *VariableInstance) bool {
+		if a.ProcessInstanceID != b.ProcessInstanceID {
+			return a.ProcessInstanceID < b.ProcessInstanceID
+		}
+		if a.ScopeID != b.ScopeID {
+			return a.ScopeID < b.ScopeID
+		}
+		return a.Name < b.Name
+	}, func(a, b *VariableInstance) bool {
+		var c int
+		switch q.orderBy {
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "type":
+			c = strings.Compare(a.TypeName, b.TypeName)
+		case "process_instance_id":
+			c = strings.Compare(a.ProcessInstanceID, b.ProcessInstanceID)
+		default:
+			return false
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	return paging.Paginate(result, q.firstResult, q.maxResults), nil
+}
+
+// matchesValueConditions checks the value of a variable against the conditions on
+// variables of its name
+func matchesValueConditions(name string, value interface{}, conditions []variableValueCondition) bool {
+	for _, condition := range conditions {
+		if condition.name != name {
+			return false
+		}
+		switch condition.operator {
+		case variableOperatorEquals:
+			if !variable.Equal(value, condition.value) {
+				return false
+			}
+		case variableOperatorNotEquals:
+			if variable.Equal(value, condition.value) {
+				return false
+			}
+		case variableOperatorLike:
+			s, ok := value.(string)
+			pattern, _ := condition.value.(string)
+			if !ok || !like.Match(s, pattern) {
+				return false
+			}
+		default:
+			c, comparable := variable.Compare(value, condition.value)
+			if !comparable {
+				return false
+			}
+			switch condition.operator {
+			case variableOperatorGreaterThan:
+				if c <= 0 {
+					return false
+				}
+			case variableOperatorGreaterThanOrEqual:
+				if c < 0 {
+					return false
+				}
+			case variableOperatorLessThan:
+				if c >= 0 {
+					return false
+				}
+			case variableOperatorLessThanOrEqual:
+				if c > 0 {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
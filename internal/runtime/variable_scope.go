@@ -0,0 +1,191 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// Variables live in scopes. The root execution is the scope of the process instance;
+// embedded subprocesses open nested scopes. An execution sees the variables of all
+// scopes from its nearest scope up to the process instance, inner ones shadowing outer ones.
+
+// scopeChainLocked returns the IDs of the scopes visible to an execution,
+// nearest first and the process instance last. Callers must hold the lock.
+func (s *runtimeServiceImpl) scopeChainLocked(executionID string) ([]string, error) {
+	execution, exists := s.executions[executionID]
+	if !exists {
+		return nil, fmt.Errorf("execution %w: %s", errors.ErrNotFound, executionID)
+	}
+
+	chain := make([]string, 0, 2)
+	for execution != nil {
+		if execution.IsScope {
+			chain = append(chain, execution.ID)
+		}
+		execution = s.executions[execution.ParentID]
+	}
+
+	// Root executions are always scopes; guard against inconsistent state
+	if len(chain) == 0 {
+		chain = append(chain, s.executions[executionID].ProcessInstanceID)
+	}
+	return chain, nil
+}
+
+// getVariablesLocked returns a copy of the variables visible to an execution, with
+// large values loaded from the content store. Callers must hold the lock.
+func (s *runtimeServiceImpl) getVariablesLocked(executionID string) (map[string]interface{}, error) {
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range s.variables[chain[i]] {
+			result[k] = v
+		}
+	}
+	return s.contents.ResolveAll(context.Background(), result)
+}
+
+// setVariablesVisibleLocked sets variables as seen from an execution: a variable is
+// updated in the nearest scope that defines it and otherwise created on the process instance.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) setVariablesVisibleLocked(executionID string, variables map[string]interface{}) error {
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range variables {
+		target := chain[len(chain)-1]
+		for _, scopeID := range chain {
+			if _, defined := s.variables[scopeID][name]; defined {
+				target = scopeID
+				break
+			}
+		}
+		s.setVariablesLocked(executionID, target, map[string]interface{}{name: value})
+	}
+	return nil
+}
+
+// removeVariableVisibleLocked removes a variable from the nearest scope of an execution that defines it.
+// Callers must hold the write lock.
+func (s *runtimeServiceImpl) removeVariableVisibleLocked(executionID, name string) error {
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		return err
+	}
+
+	for _, scopeID := range chain {
+		if _, defined := s.variables[scopeID][name]; defined {
+			delete(s.variables[scopeID], name)
+			s.recordScopeChangesLocked(scopeID, name)
+			break
+		}
+	}
+	return nil
+}
+
+// GetVariableTyped gets a variable visible to an execution with its type, or nil if it is not set
+func (s *runtimeServiceImpl) GetVariableTyped(ctx context.Context, executionID, variableName string) (*variable.TypedValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	variables, err := s.getVariablesLocked(executionID)
+	if err != nil {
+		return nil, err
+	}
+	value, exists := variables[variableName]
+	if !exists {
+		return nil, nil
+	}
+	typed, err := variable.Of(value)
+	if err != nil {
+		return nil, fmt.Errorf("variable '%s': %w", variableName, err)
+	}
+	return &typed, nil
+}
+
+// SetVariableLocal sets a variable in the nearest scope of an execution
+func (s *runtimeServiceImpl) SetVariableLocal(ctx context.Context, executionID, variableName string, value interface{}) error {
+	return s.SetVariablesLocal(ctx, executionID, map[string]interface{}{variableName: value})
+}
+
+// SetVariablesLocal sets multiple variables in the nearest scope of an execution,
+// e.g. the enclosing subprocess. They are dropped when the scope ends.
+func (s *runtimeServiceImpl) SetVariablesLocal(ctx context.Context, executionID string, variables map[string]interface{}) error {
+	s.mu.Lock()
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.setVariablesLocked(executionID, chain[0], variables)
+	processInstanceID := s.executions[executionID].ProcessInstanceID
+	s.mu.Unlock()
+
+	return s.variablesChanged(ctx, processInstanceID)
+}
+
+// GetVariableLocal gets a variable of the nearest scope of an execution
+func (s *runtimeServiceImpl) GetVariableLocal(ctx context.Context, executionID, variableName string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.contents.Resolve(ctx, s.variables[chain[0]][variableName])
+}
+
+// GetVariablesLocal gets the variables of the nearest scope of an execution,
+// without the variables inherited from enclosing scopes
+func (s *runtimeServiceImpl) GetVariablesLocal(ctx context.Context, executionID string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		return nil, err
+	}
+	return s.contents.ResolveAll(ctx, s.variables[chain[0]])
+}
+
+// RemoveVariableLocal removes a variable from the nearest scope of an execution.
+// A variable of the same name in an enclosing scope becomes visible again.
+func (s *runtimeServiceImpl) RemoveVariableLocal(ctx context.Context, executionID, variableName string) error {
+	s.mu.Lock()
+	chain, err := s.scopeChainLocked(executionID)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if _, defined := s.variables[chain[0]][variableName]; defined {
+		delete(s.variables[chain[0]], variableName)
+		s.recordScopeChangesLocked(chain[0], variableName)
+	}
+	processInstanceID := s.executions[executionID].ProcessInstanceID
+	s.mu.Unlock()
+
+	return s.variablesChanged(ctx, processInstanceID)
+}
+
+// evaluateMappings evaluates input or output mappings (target variable -> expression)
+func (s *runtimeServiceImpl) evaluateMappings(mappings map[string]string, variables map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(mappings))
+	for name, source := range mappings {
+		value, err := s.expressions.Evaluate(source, variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate mapping of variable '%s': %w", name, err)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,183 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in assignment strategy names
+const (
+	AssignmentStrategyRoundRobin          = "roundRobin"
+	AssignmentStrategyLeastLoaded         = "leastLoaded"
+	AssignmentStrategyStickyByBusinessKey = "stickyByBusinessKey"
+)
+
+// AssignmentStrategy picks an assignee for a newly created task that has
+// candidates but no assignee. Returning an empty user ID leaves the task unassigned.
+type AssignmentStrategy interface {
+	// Assign selects one of the candidates of the task
+	Assign(ctx context.Context, assignment *Assignment) (string, error)
+}
+
+// GroupMemberResolver resolves the members of a candidate group
+type GroupMemberResolver interface {
+	// GetGroupMembers returns the user IDs of all members of a group
+	GetGroupMembers(ctx context.Context, groupID string) ([]string, error)
+}
+
+// Assignment holds the information an AssignmentStrategy decides on
+type Assignment struct {
+	// Task is the task being created
+	Task *Task
+
+	// Candidates are the candidate users plus the members of all candidate groups, sorted and deduplicated
+	Candidates []string
+
+	// BusinessKey is the business key of the process instance the task belongs to, if any
+	BusinessKey string
+
+	// OpenTaskCount returns the number of open tasks currently assigned to a user
+	OpenTaskCount func(userID string) int
+}
+
+// RoundRobinStrategy assigns tasks to the candidates in turn.
+// A separate rotation is kept per distinct candidate set.
+type RoundRobinStrategy struct {
+	next map[string]int
+	mu   sync.Mutex
+}
+
+// NewRoundRobinStrategy creates a new round-robin assignment strategy
+func NewRoundRobinStrategy() *RoundRobinStrategy {
+	return &RoundRobinStrategy{
+		next: make(map[string]int),
+	}
+}
+
+// Assign selects the next candidate in the rotation
+func (s *RoundRobinStrategy) Assign(ctx context.Context, assignment *Assignment) (string, error) {
+	if len(assignment.Candidates) == 0 {
+		return "", nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.Join(assignment.Candidates, ",")
+	index := s.next[key] % len(assignment.Candidates)
+	s.next[key] = index + 1
+	return assignment.Candidates[index], nil
+}
+
+// LeastLoadedStrategy assigns tasks to the candidate with the fewest open tasks.
+// Ties are broken by the candidate order.
+type LeastLoadedStrategy struct{}
+
+// NewLeastLoadedStrategy creates a new least-loaded assignment strategy
+func NewLeastLoadedStrategy() *LeastLoadedStrategy {
+	return &LeastLoadedStrategy{}
+}
+
+// Assign selects the candidate with the lowest open task count
+func (s *LeastLoadedStrategy) Assign(ctx context.Context, assignment *Assignment) (string, error) {
+	if len(assignment.Candidates) == 0 {
+		return "", nil
+	}
+	if assignment.OpenTaskCount == nil {
+		return "", fmt.Errorf("open task count is not available")
+	}
+
+	selected := ""
+	minCount := 0
+	for _, candidate := range assignment.Candidates {
+		count := assignment.OpenTaskCount(candidate)
+		if selected == "" || count < minCount {
+			selected = candidate
+			minCount = count
+		}
+	}
+	return selected, nil
+}
+
+// StickyByBusinessKeyStrategy assigns all tasks of the same business key to the
+// same user as long as that user is still a candidate. The first task of a
+// business key is assigned by the fallback strategy.
+type StickyByBusinessKeyStrategy struct {
+	fallback  AssignmentStrategy
+	assignees map[string]string // businessKey -> userID
+	mu        sync.Mutex
+}
+
+// NewStickyByBusinessKeyStrategy creates a new sticky assignment strategy.
+// If fallback is nil, a round-robin strategy is used.
+func NewStickyByBusinessKeyStrategy(fallback AssignmentStrategy) *StickyByBusinessKeyStrategy {
+	if fallback == nil {
+		fallback = NewRoundRobinStrategy()
+	}
+	return &StickyByBusinessKeyStrategy{
+		fallback:  fallback,
+		assignees: make(map[string]string),
+	}
+}
+
+// Assign selects the user previously assigned to the business key, or asks the fallback strategy
+func (s *StickyByBusinessKeyStrategy) Assign(ctx context.Context, assignment *Assignment) (string, error) {
+	if assignment.BusinessKey == "" {
+		return s.fallback.Assign(ctx, assignment)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if previous, exists := s.assignees[assignment.BusinessKey]; exists {
+		for _, candidate := range assignment.Candidates {
+			if candidate == previous {
+				return previous, nil
+			}
+		}
+	}
+
+	userID, err := s.fallback.Assign(ctx, assignment)
+	if err != nil {
+		return "", err
+	}
+	if userID != "" {
+		s.assignees[assignment.BusinessKey] = userID
+	}
+	return userID, nil
+}
+
+// resolveCandidates collects the candidate users and the members of the candidate groups of a task
+func resolveCandidates(ctx context.Context, task *Task, resolver GroupMemberResolver) ([]string, error) {
+	seen := make(map[string]bool)
+	candidates := make([]string, 0, len(task.CandidateUsers))
+
+	add := func(userID string) {
+		if userID != "" && !seen[userID] {
+			seen[userID] = true
+			candidates = append(candidates, userID)
+		}
+	}
+
+	for _, userID := range task.CandidateUsers {
+		add(userID)
+	}
+
+	if resolver != nil {
+		for _, groupID := range task.CandidateGroups {
+			members, err := resolver.GetGroupMembers(ctx, groupID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve members of group '%s': %w", groupID, err)
+			}
+			for _, userID := range members {
+				add(userID)
+			}
+		}
+	}
+
+	sort.Strings(candidates)
+	return candidates, nil
+}
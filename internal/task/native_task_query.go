@@ -0,0 +1,179 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/pkg/paging"
+)
+
+// NativeTaskQuery selects tasks with a native SQL query or a filter expression, for
+// reporting queries the TaskQuery API cannot express.
+//
+// The SQL query runs against the task table (persistence.TableTasks) and must select
+// task IDs in its first column; parameters are referenced as #{name}. The filter is an
+// expression evaluated for every task, with the task available as "task", its local
+// variables as "variables" and the parameters by name, e.g.
+// ${task.priority >= minPriority && variables.region == "EU"}. When both are set, the
+// filter applies to the tasks selected by the SQL query. The SQL query requires a
+// database; the filter also works in memory.
+type NativeTaskQuery struct {
+	sql         string
+	filter      string
+	parameters  map[string]interface{}
+	firstResult int
+	maxResults  int
+	service     TaskService
+}
+
+// SQL sets the native SQL query selecting task IDs
+func (q *NativeTaskQuery) SQL(sql string) *NativeTaskQuery {
+	q.sql = sql
+	return q
+}
+
+// Filter sets the expression a task must satisfy
+func (q *NativeTaskQuery) Filter(filter string) *NativeTaskQuery {
+	q.filter = filter
+	return q
+}
+
+// Parameter sets a named parameter of the SQL query or filter
+func (q *NativeTaskQuery) Parameter(name string, value interface{}) *NativeTaskQuery {
+	if q.parameters == nil {
+		q.parameters = make(map[string]interface{})
+	}
+	q.parameters[name] = value
+	return q
+}
+
+// List executes the query and returns a list of tasks
+func (q *NativeTaskQuery) List(ctx context.Context) ([]*Task, error) {
+	if impl, ok := q.service.(*taskServiceImpl); ok {
+		tasks, err := impl.listTasksNative(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return paging.Paginate(tasks, q.firstResult, q.maxResults), nil
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// ListPage executes the query and returns a page of tasks
+func (q *NativeTaskQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*Task, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching tasks
+func (q *NativeTaskQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	tasks, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(tasks)), nil
+}
+
+// SingleResult returns a single task or error if not exactly one result
+func (q *NativeTaskQuery) SingleResult(ctx context.Context) (*Task, error) {
+	tasks, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) != 1 {
+		return nil, fmt.Errorf("expected one task, found %d", len(tasks))
+	}
+	return tasks[0], nil
+}
+
+// CreateNativeTaskQuery creates a new native task query
+func (s *taskServiceImpl) CreateNativeTaskQuery() *NativeTaskQuery {
+	return &NativeTaskQuery{
+		service: s,
+	}
+}
+
+// listTasksNative executes a native task query. Tasks selected by SQL keep the order
+// of the query; otherwise tasks are returned in the order they were created.
+func (s *taskServiceImpl) listTasksNative(ctx context.Context, q *NativeTaskQuery) ([]*Task, error) {
+	if q.sql == "" && q.filter == "" {
+		return nil, fmt.Errorf("native task query requires a SQL query or a filter")
+	}
+
+	if q.filter != "" {
+		if _, err := s.expressions.Compile(q.filter); err != nil {
+			return nil, fmt.Errorf("invalid task query filter: %w", err)
+		}
+	}
+
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+
+	var candidateIDs []string
+	if q.sql != "" {
+		if store == nil {
+			return nil, fmt.Errorf("native SQL task queries require a database")
+		}
+		ids, err := store.QueryIDs(ctx, q.sql, q.parameters)
+		if err != nil {
+			return nil, err
+		}
+		candidateIDs = ids
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	candidates := make([]*Task, 0)
+	if q.sql != "" {
+		seen := make(map[string]bool)
+		for _, id := range candidateIDs {
+			if task, exists := s.tasks[id]; exists && !seen[id] && identity.IsTenantAccessible(ctx, task.TenantID) {
+				seen[id] = true
+				candidates = append(candidates, task)
+			}
+		}
+	} else {
+		for _, task := range s.tasks {
+			if identity.IsTenantAccessible(ctx, task.TenantID) {
+				candidates = append(candidates, task)
+			}
+		}
+		paging.Sort(candidates, func(a, b *Task) bool {
+			return a.ID < b.ID
+		}, func(a, b *Task) bool {
+			return a.CreateTime.Before(b.CreateTime)
+		})
+	}
+	if q.filter == "" {
+		return candidates, nil
+	}
+
+	result := make([]*Task, 0, len(candidates))
+	for _, task := range candidates {
+		variables := make(map[string]interface{}, len(q.parameters)+2)
+		for name, value := range q.parameters {
+			variables[name] = value
+		}
+		localVariables, err := s.contents.ResolveAll(ctx, s.variables[task.ID])
+		if err != nil {
+			return nil, err
+		}
+		variables["task"] = task
+		variables["variables"] = localVariables
+
+		matches, err := s.expressions.EvaluateBool(q.filter, variables)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate task query filter for task %s: %w", task.ID, err)
+		}
+		if matches {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,148 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+)
+
+// AddUserIdentityLink relates a user to a task. Assignee, owner and candidate links set
+// the corresponding task fields; links of other types, e.g. participant, are kept with the task.
+func (s *taskServiceImpl) AddUserIdentityLink(ctx context.Context, taskID, userID, linkType string) error {
+	if userID == "" {
+		return fmt.Errorf("user ID cannot be empty")
+	}
+	switch linkType {
+	case identity.IdentityLinkTypeAssignee:
+		return s.SetAssignee(ctx, taskID, userID)
+	case identity.IdentityLinkTypeOwner:
+		return s.SetOwner(ctx, taskID, userID)
+	case identity.IdentityLinkTypeCandidate:
+		return s.AddCandidateUser(ctx, taskID, userID)
+	}
+	return s.addIdentityLink(ctx, &identity.IdentityLink{Type: linkType, UserID: userID, TaskID: taskID})
+}
+
+// AddGroupIdentityLink relates a group to a task. Candidate links add a candidate group.
+func (s *taskServiceImpl) AddGroupIdentityLink(ctx context.Context, taskID, groupID, linkType string) error {
+	if groupID == "" {
+		return fmt.Errorf("group ID cannot be empty")
+	}
+	switch linkType {
+	case identity.IdentityLinkTypeAssignee, identity.IdentityLinkTypeOwner:
+		return fmt.Errorf("a group cannot be the %s of a task", linkType)
+	case identity.IdentityLinkTypeCandidate:
+		return s.AddCandidateGroup(ctx, taskID, groupID)
+	}
+	return s.addIdentityLink(ctx, &identity.IdentityLink{Type: linkType, GroupID: groupID, TaskID: taskID})
+}
+
+// DeleteUserIdentityLink removes the relation of a user to a task
+func (s *taskServiceImpl) DeleteUserIdentityLink(ctx context.Context, taskID, userID, linkType string) error {
+	switch linkType {
+	case identity.IdentityLinkTypeAssignee:
+		return s.SetAssignee(ctx, taskID, "")
+	case identity.IdentityLinkTypeOwner:
+		return s.SetOwner(ctx, taskID, "")
+	case identity.IdentityLinkTypeCandidate:
+		return s.DeleteCandidateUser(ctx, taskID, userID)
+	}
+	return s.deleteIdentityLink(ctx, &identity.IdentityLink{Type: linkType, UserID: userID, TaskID: taskID})
+}
+
+// DeleteGroupIdentityLink removes the relation of a group to a task
+func (s *taskServiceImpl) DeleteGroupIdentityLink(ctx context.Context, taskID, groupID, linkType string) error {
+	if linkType == identity.IdentityLinkTypeCandidate {
+		return s.DeleteCandidateGroup(ctx, taskID, groupID)
+	}
+	return s.deleteIdentityLink(ctx, &identity.IdentityLink{Type: linkType, GroupID: groupID, TaskID: taskID})
+}
+
+// GetIdentityLinksForTask returns the users and groups related to a task, including
+// its assignee, owner and candidates
+func (s *taskServiceImpl) GetIdentityLinksForTask(ctx context.Context, taskID string) ([]*identity.IdentityLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	return s.identityLinksLocked(task), nil
+}
+
+// identityLinksLocked returns all identity links of a task. Callers must hold the read lock.
+func (s *taskServiceImpl) identityLinksLocked(task *Task) []*identity.IdentityLink {
+	links := make([]*identity.IdentityLink, 0)
+	if task.Assignee != "" {
+		links = append(links, &identity.IdentityLink{Type: identity.IdentityLinkTypeAssignee, UserID: task.Assignee, TaskID: task.ID})
+	}
+	if task.Owner != "" {
+		links = append(links, &identity.IdentityLink{Type: identity.IdentityLinkTypeOwner, UserID: task.Owner, TaskID: task.ID})
+	}
+	for _, userID := range task.CandidateUsers {
+		links = append(links, &identity.IdentityLink{Type: identity.IdentityLinkTypeCandidate, UserID: userID, TaskID: task.ID})
+	}
+	for _, groupID := range task.CandidateGroups {
+		links = append(links, &identity.IdentityLink{Type: identity.IdentityLinkTypeCandidate, GroupID: groupID, TaskID: task.ID})
+	}
+	return append(links, s.identityLinks[task.ID]...)
+}
+
+// isInvolvedLocked reports whether a user, or one of the groups, is related to a task
+// by any identity link. Callers must hold the read lock.
+func (s *taskServiceImpl) isInvolvedLocked(task *Task, userID string, groupIDs []string) bool {
+	for _, link := range s.identityLinksLocked(task) {
+		if userID != "" && link.UserID == userID {
+			return true
+		}
+		if link.GroupID != "" && slices.Contains(groupIDs, link.GroupID) {
+			return true
+		}
+	}
+	return false
+}
+
+// addIdentityLink keeps an identity link of a type without a task field
+func (s *taskServiceImpl) addIdentityLink(ctx context.Context, link *identity.IdentityLink) error {
+	if link.Type == "" {
+		return fmt.Errorf("identity link type cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[link.TaskID]; !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, link.TaskID)
+	}
+	for _, existing := range s.identityLinks[link.TaskID] {
+		if *existing == *link {
+			return nil // Already exists
+		}
+	}
+
+	s.identityLinks[link.TaskID] = append(s.identityLinks[link.TaskID], link)
+	return s.saveTaskLocked(ctx, link.TaskID)
+}
+
+// deleteIdentityLink removes an identity link of a type without a task field
+func (s *taskServiceImpl) deleteIdentityLink(ctx context.Context, link *identity.IdentityLink) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[link.TaskID]; !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, link.TaskID)
+	}
+
+	links := s.identityLinks[link.TaskID]
+	for i, existing := range links {
+		if *existing == *link {
+			s.identityLinks[link.TaskID] = append(links[:i], links[i+1:]...)
+			break
+		}
+	}
+	return s.saveTaskLocked(ctx, link.TaskID)
+}
@@ -0,0 +1,167 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Task events listeners are notified of
+const (
+	TaskEventCreate     = "create"
+	TaskEventAssignment = "assignment"
+	TaskEventComplete   = "complete"
+	TaskEventDelete     = "delete"
+	TaskEventEscalation = "escalation"
+
+	// TaskEventDue and TaskEventFollowUp are fired by CheckDueDates once the due
+	// date or the follow-up date of a task passed
+	TaskEventDue      = "due"
+	TaskEventFollowUp = "followUp"
+
+	// TaskEventAll registers a listener for all task events
+	TaskEventAll = "all"
+)
+
+// TaskListener is notified of the lifecycle transitions of tasks, e.g. to send
+// notifications or keep SLA records.
+//
+// Create, complete, delete and escalation listeners run before the transition takes effect:
+// an error aborts it, and create listeners may still change the task. Assignment
+// listeners run after the new assignee was stored; their error is returned to the
+// caller, but the assignment stays. Due and follow-up listeners are retried on the
+// next check when they fail.
+type TaskListener interface {
+	// Notify handles a task event
+	Notify(ctx context.Context, event *TaskEvent) error
+}
+
+// TaskListenerFunc adapts a function to the TaskListener interface
+type TaskListenerFunc func(ctx context.Context, event *TaskEvent) error
+
+// Notify calls f(ctx, event)
+func (f TaskListenerFunc) Notify(ctx context.Context, event *TaskEvent) error {
+	return f(ctx, event)
+}
+
+// TaskEvent describes a lifecycle transition of a task
+type TaskEvent struct {
+	EventName string
+
+	// Time is the time of the event
+	Time time.Time
+
+	// Task is the task being created, or a copy of the task for other events
+	Task *Task
+
+	// Variables are the variables visible to the task: the variables of its execution
+	// overridden by its local variables. On complete, they include the variables
+	// the task is completed with.
+	Variables map[string]interface{}
+
+	// EscalationCode is the code of the escalation of an escalation event
+	EscalationCode string
+}
+
+// registeredTaskListener is a listener for an event of all tasks, or of the tasks
+// of one task definition
+type registeredTaskListener struct {
+	taskDefinitionKey string
+	eventName         string
+	listener          TaskListener
+}
+
+// AddTaskListener registers a listener for an event of all tasks
+func (s *taskServiceImpl) AddTaskListener(eventName string, listener TaskListener) {
+	s.AddTaskListenerForTaskDefinition("", eventName, listener)
+}
+
+// AddTaskListenerForTaskDefinition registers a listener for an event of the tasks of a
+// user task, identified by its node ID. An empty key registers the listener for all tasks.
+func (s *taskServiceImpl) AddTaskListenerForTaskDefinition(taskDefinitionKey, eventName string, listener TaskListener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, registeredTaskListener{
+		taskDefinitionKey: taskDefinitionKey,
+		eventName:         eventName,
+		listener:          listener,
+	})
+}
+
+// notifyListeners notifies the listeners registered for an event of a task. The
+// variables of the event are only read if a listener is registered; a nil variables
+// function reads the variables visible to the task. The caller must not hold the lock.
+func (s *taskServiceImpl) notifyListeners(ctx context.Context, eventName string, task *Task, variables func() (map[string]interface{}, error)) error {
+	return s.notifyListenersOf(ctx, &TaskEvent{EventName: eventName, Task: task}, variables)
+}
+
+// notifyListenersOf notifies the listeners registered for an event, completing it with
+// its time and variables
+func (s *taskServiceImpl) notifyListenersOf(ctx context.Context, event *TaskEvent, variables func() (map[string]interface{}, error)) error {
+	eventName, task := event.EventName, event.Task
+
+	s.mu.RLock()
+	listeners := make([]TaskListener, 0)
+	for _, registered := range s.listeners {
+		if registered.taskDefinitionKey != "" && registered.taskDefinitionKey != task.TaskDefinitionKey {
+			continue
+		}
+		if registered.eventName == eventName || registered.eventName == TaskEventAll {
+			listeners = append(listeners, registered.listener)
+		}
+	}
+	s.mu.RUnlock()
+	if len(listeners) == 0 {
+		return nil
+	}
+
+	if variables == nil {
+		variables = func() (map[string]interface{}, error) {
+			return s.visibleVariables(ctx, task)
+		}
+	}
+	eventVariables, err := variables()
+	if err != nil {
+		return err
+	}
+	event.Time = s.clock.Now()
+	event.Variables = eventVariables
+	for _, listener := range listeners {
+		if err := listener.Notify(ctx, event); err != nil {
+			return fmt.Errorf("task listener failed on %s of task %s: %w", eventName, task.ID, err)
+		}
+	}
+	return nil
+}
+
+// visibleVariables returns the variables of the execution of a task overridden by
+// its local variables
+func (s *taskServiceImpl) visibleVariables(ctx context.Context, task *Task) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+	if task.ExecutionID != "" {
+		executionVariables, err := s.runtimeService.GetVariables(ctx, task.ExecutionID)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range executionVariables {
+			variables[name] = value
+		}
+	}
+	return s.withLocalVariables(ctx, task.ID, variables)
+}
+
+// withLocalVariables overrides variables with the local variables of a task
+func (s *taskServiceImpl) withLocalVariables(ctx context.Context, taskID string, variables map[string]interface{}) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	localVariables, err := s.contents.ResolveAll(ctx, s.variables[taskID])
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range localVariables {
+		variables[name] = value
+	}
+	return variables, nil
+}
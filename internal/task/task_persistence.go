@@ -0,0 +1,139 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// taskState is the persisted form of a task together with its comments,
+// attachments, local variables and identity links
+type taskState struct {
+	Task          *Task
+	Comments      []*Comment
+	Attachments   []*Attachment
+	Variables     variable.Map
+	IdentityLinks []*identity.IdentityLink
+}
+
+// SetStore sets the store tasks are persisted to
+func (s *taskServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+	s.contents = persistence.NewVariableContents(persistence.NewTableContentStore(store))
+	store.OnRollback(persistence.TableTasks, func(ctx context.Context, taskID string) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err := s.reloadTaskLocked(ctx, taskID); err != nil {
+			s.logger.ErrorContext(ctx, "failed to reload task", slog.String("taskId", taskID), slog.Any("error", err))
+		}
+	})
+}
+
+// SetContentStore sets the store large variable values are kept in
+func (s *taskServiceImpl) SetContentStore(contentStore persistence.ContentStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.contents = persistence.NewVariableContents(contentStore)
+}
+
+// saveTaskLocked writes the state of a task to the store, if any.
+// Tasks that no longer exist are deleted from the store.
+// Callers must hold the write lock.
+func (s *taskServiceImpl) saveTaskLocked(ctx context.Context, taskID string) error {
+	if s.store == nil {
+		return nil
+	}
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		if err := s.contents.Remove(ctx, taskID); err != nil {
+			return err
+		}
+		return s.store.Delete(ctx, persistence.TableTasks, taskID)
+	}
+
+	// Large values are kept in the content store
+	variables, err := s.contents.Externalize(ctx, taskID, map[string]map[string]interface{}{taskID: s.variables[taskID]})
+	if err != nil {
+		return err
+	}
+	task.Revision++
+	err = s.store.SaveRevision(ctx, persistence.TableTasks, taskID, &taskState{
+		Task:          task,
+		Comments:      s.comments[taskID],
+		Attachments:   s.attachments[taskID],
+		Variables:     variables[taskID],
+		IdentityLinks: s.identityLinks[taskID],
+	}, task.Revision)
+	var conflict *persistence.OptimisticLockingError
+	if errors.As(err, &conflict) {
+		// Continue from the state the other transaction saved; the failed command may be retried
+		if reloadErr := s.reloadTaskLocked(ctx, taskID); reloadErr != nil {
+			return reloadErr
+		}
+	} else if err != nil {
+		task.Revision--
+	}
+	return err
+}
+
+// reloadTaskLocked replaces the state of a task with the state in the store, e.g.
+// after another engine modified it. Callers must hold the write lock.
+func (s *taskServiceImpl) reloadTaskLocked(ctx context.Context, taskID string) error {
+	data, err := s.store.Get(ctx, persistence.TableTasks, taskID)
+	delete(s.tasks, taskID)
+	delete(s.comments, taskID)
+	delete(s.attachments, taskID)
+	delete(s.variables, taskID)
+	delete(s.identityLinks, taskID)
+	if err != nil {
+		// The task was deleted
+		return nil
+	}
+
+	state := &taskState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return fmt.Errorf("failed to load task %s: %w", taskID, err)
+	}
+	s.restoreTaskLocked(state)
+	return nil
+}
+
+// load reads tasks with their comments, attachments, variables and identity links from the store
+func (s *taskServiceImpl) load(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return persistence.Load(ctx, s.store, persistence.TableTasks, s.restoreTaskLocked)
+}
+
+// restoreTaskLocked adds a task read from the store with its comments, attachments,
+// variables and identity links. Callers must hold the write lock.
+func (s *taskServiceImpl) restoreTaskLocked(state *taskState) {
+	taskID := state.Task.ID
+	s.tasks[taskID] = state.Task
+	if len(state.Comments) > 0 {
+		s.comments[taskID] = state.Comments
+	}
+	if len(state.Attachments) > 0 {
+		s.attachments[taskID] = state.Attachments
+	}
+	if state.Variables != nil {
+		s.variables[taskID] = state.Variables
+		s.contents.Track(taskID, map[string]variable.Map{taskID: state.Variables})
+	}
+	if len(state.IdentityLinks) > 0 {
+		s.identityLinks[taskID] = state.IdentityLinks
+	}
+}
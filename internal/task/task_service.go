@@ -0,0 +1,724 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// TaskService provides operations for managing user tasks.
+// This service is responsible for:
+// - Querying tasks
+// - Claiming and unclaiming tasks
+// - Completing tasks
+// - Assigning and delegating tasks
+// - Managing task variables
+type TaskService interface {
+	// Initialize initializes the task service
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the task service
+	Shutdown(ctx context.Context) error
+
+	// CreateTaskQuery creates a new task query
+	CreateTaskQuery() *TaskQuery
+
+	// CreateNativeTaskQuery creates a query selecting tasks with native SQL or a filter expression
+	CreateNativeTaskQuery() *NativeTaskQuery
+
+	// GetTask retrieves a task by ID
+	GetTask(ctx context.Context, taskID string) (*Task, error)
+
+	// NewTask creates a new standalone task (not part of a process)
+	NewTask(ctx context.Context, taskID string) (*Task, error)
+
+	// SaveTask saves a standalone task
+	SaveTask(ctx context.Context, task *Task) error
+
+	// DeleteTask deletes a task
+	DeleteTask(ctx context.Context, taskID string) error
+
+	// Claim assigns a task to a specific user. Suspended tasks, i.e. the tasks of
+	// suspended process instances, cannot be claimed, unclaimed or assigned; they fail
+	// with an *errors.SuspendedError.
+	Claim(ctx context.Context, taskID, userID string) error
+
+	// Unclaim removes the assignee from a task
+	Unclaim(ctx context.Context, taskID string) error
+
+	// Complete completes a task. Suspended tasks fail with an *errors.SuspendedError.
+	Complete(ctx context.Context, taskID string) error
+
+	// CompleteWithVariables completes a task and sets variables as seen from the task
+	CompleteWithVariables(ctx context.Context, taskID string, variables map[string]interface{}) error
+
+	// CompleteWithVariablesLocal completes a task with local variables, which are not
+	// passed to the process
+	CompleteWithVariablesLocal(ctx context.Context, taskID string, variables map[string]interface{}) error
+
+	// Escalate routes a task to the escalation path of its process, e.g. because it is
+	// overdue or was rejected: the escalation listeners are notified, then an escalation
+	// with the code is thrown at the user task. An escalation boundary event of the user
+	// task or an enclosing subprocess, or an escalation event subprocess, catches it;
+	// an interrupting one ends the task. Tasks without matching escalation event fail
+	// with errors.ErrValidation; suspended tasks with an *errors.SuspendedError.
+	Escalate(ctx context.Context, taskID, escalationCode string) error
+
+	// SetAssignee sets the assignee of a task
+	SetAssignee(ctx context.Context, taskID, userID string) error
+
+	// SetOwner sets the owner of a task
+	SetOwner(ctx context.Context, taskID, userID string) error
+
+	// AddCandidateUser adds a candidate user to a task
+	AddCandidateUser(ctx context.Context, taskID, userID string) error
+
+	// AddCandidateGroup adds a candidate group to a task
+	AddCandidateGroup(ctx context.Context, taskID, groupID string) error
+
+	// DeleteCandidateUser removes a candidate user from a task
+	DeleteCandidateUser(ctx context.Context, taskID, userID string) error
+
+	// DeleteCandidateGroup removes a candidate group from a task
+	DeleteCandidateGroup(ctx context.Context, taskID, groupID string) error
+
+	// AddUserIdentityLink relates a user to a task with a link type, e.g. identity.IdentityLinkTypeParticipant
+	AddUserIdentityLink(ctx context.Context, taskID, userID, linkType string) error
+
+	// AddGroupIdentityLink relates a group to a task with a link type
+	AddGroupIdentityLink(ctx context.Context, taskID, groupID, linkType string) error
+
+	// DeleteUserIdentityLink removes the relation of a user to a task
+	DeleteUserIdentityLink(ctx context.Context, taskID, userID, linkType string) error
+
+	// DeleteGroupIdentityLink removes the relation of a group to a task
+	DeleteGroupIdentityLink(ctx context.Context, taskID, groupID, linkType string) error
+
+	// GetIdentityLinksForTask returns the users and groups related to a task
+	GetIdentityLinksForTask(ctx context.Context, taskID string) ([]*identity.IdentityLink, error)
+
+	// SetPriority sets the priority of a task
+	SetPriority(ctx context.Context, taskID string, priority int) error
+
+	// SetDueDate sets the due date of a task
+	SetDueDate(ctx context.Context, taskID string, dueDate time.Time) error
+
+	// SetFollowUpDate sets the follow-up date of a task, the date someone should look
+	// at it again, e.g. to remind a customer, before it is due
+	SetFollowUpDate(ctx context.Context, taskID string, followUpDate time.Time) error
+
+	// CheckDueDates notifies the TaskEventDue listeners of the tasks whose due date
+	// passed, and the TaskEventFollowUp listeners of those whose follow-up date passed.
+	// Each date is announced once; setting it again announces it anew. It returns the
+	// number of events fired.
+	CheckDueDates(ctx context.Context) (int, error)
+
+	// SetSLAMonitorInterval makes the service run CheckDueDates periodically while it is
+	// running. It must be called before Initialize; zero disables the monitor.
+	SetSLAMonitorInterval(interval time.Duration)
+
+	// GetTaskVariables gets the variables visible to a task: the variables of its
+	// execution overridden by its local variables
+	GetTaskVariables(ctx context.Context, taskID string) (map[string]interface{}, error)
+
+	// GetTaskVariable gets a variable visible to a task
+	GetTaskVariable(ctx context.Context, taskID, variableName string) (interface{}, error)
+
+	// GetTaskVariableTyped gets a variable visible to a task together with its type, or nil if it is not set
+	GetTaskVariableTyped(ctx context.Context, taskID, variableName string) (*variable.TypedValue, error)
+
+	// SetTaskVariable sets a variable as seen from a task: a local variable of the task
+	// is updated, otherwise the variable is set on the execution of the task
+	SetTaskVariable(ctx context.Context, taskID, variableName string, value interface{}) error
+
+	// SetTaskVariables sets multiple variables as seen from a task
+	SetTaskVariables(ctx context.Context, taskID string, variables map[string]interface{}) error
+
+	// RemoveTaskVariable removes a variable as seen from a task
+	RemoveTaskVariable(ctx context.Context, taskID, variableName string) error
+
+	// GetTaskVariablesLocal gets the local variables of a task
+	GetTaskVariablesLocal(ctx context.Context, taskID string) (map[string]interface{}, error)
+
+	// GetTaskVariableLocal gets a local variable of a task
+	GetTaskVariableLocal(ctx context.Context, taskID, variableName string) (interface{}, error)
+
+	// SetTaskVariableLocal sets a local variable of a task, which is discarded when the task ends
+	SetTaskVariableLocal(ctx context.Context, taskID, variableName string, value interface{}) error
+
+	// SetTaskVariablesLocal sets multiple local variables of a task
+	SetTaskVariablesLocal(ctx context.Context, taskID string, variables map[string]interface{}) error
+
+	// RemoveTaskVariableLocal removes a local variable of a task
+	RemoveTaskVariableLocal(ctx context.Context, taskID, variableName string) error
+
+	// AddComment adds a comment to a task
+	AddComment(ctx context.Context, taskID, message string) (*Comment, error)
+
+	// GetTaskComments gets all comments for a task
+	GetTaskComments(ctx context.Context, taskID string) ([]*Comment, error)
+
+	// CreateAttachment creates an attachment for a task
+	CreateAttachment(ctx context.Context, taskID, attachmentType, attachmentName, attachmentDescription string, content []byte) (*Attachment, error)
+
+	// GetTaskAttachments gets all attachments for a task
+	GetTaskAttachments(ctx context.Context, taskID string) ([]*Attachment, error)
+
+	// DeleteAttachment deletes an attachment
+	DeleteAttachment(ctx context.Context, attachmentID string) error
+
+	// GetInboxSummary returns the task counts shown in a user's inbox, computed in a single pass
+	GetInboxSummary(ctx context.Context, userID string) (*InboxSummary, error)
+
+	// RegisterAssignmentStrategy registers an assignment strategy under a name
+	// that tasks can reference in their AssignmentStrategy field
+	RegisterAssignmentStrategy(name string, strategy AssignmentStrategy)
+
+	// AddTaskListener registers a listener for an event of all tasks, e.g. TaskEventCreate
+	AddTaskListener(eventName string, listener TaskListener)
+
+	// AddTaskListenerForTaskDefinition registers a listener for an event of the tasks of a user task
+	AddTaskListenerForTaskDefinition(taskDefinitionKey, eventName string, listener TaskListener)
+
+	// SetGroupMemberResolver sets the resolver used to expand candidate groups into users
+	SetGroupMemberResolver(resolver GroupMemberResolver)
+
+	// SetStore sets the store tasks are persisted to. Without a store the service keeps its state in memory.
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock the create, claim and comment times of tasks are based on
+	SetClock(c clock.Clock)
+
+	// SetLogger sets the logger failures of reloads and due date checks are logged to
+	SetLogger(logger *slog.Logger)
+
+	// SetContentStore sets the store large binary and JSON variable values are kept in,
+	// e.g. an object storage. By default they are kept in a table of the store.
+	SetContentStore(contentStore persistence.ContentStore)
+}
+
+// Task represents a user task in a process
+type Task struct {
+	ID                  string
+	Name                string
+	Description         string
+	Priority            int
+	Owner               string
+	Assignee            string
+	DueDate             *time.Time
+	FollowUpDate        *time.Time
+	DueNotified         bool // whether the due date listeners were notified that the due date passed
+	FollowUpNotified    bool // whether the follow-up listeners were notified that the follow-up date passed
+	Category            string
+	FormKey             string
+	ParentTaskID        string
+	ProcessInstanceID   string
+	ProcessDefinitionID string
+	ExecutionID         string
+	TaskDefinitionKey   string
+	CreateTime          time.Time
+	ClaimTime           *time.Time
+	TenantID            string
+	Suspended           bool
+	CandidateUsers      []string
+	CandidateGroups     []string
+	AssignmentStrategy  string
+	Revision            int // persisted revision of the task with its variables
+
+	// TaskLocalVariables and ProcessVariables are the local variables of the task and
+	// the variables of its execution, set on the results of queries that include them
+	TaskLocalVariables map[string]interface{} `json:"-"`
+	ProcessVariables   map[string]interface{} `json:"-"`
+}
+
+// InboxCounts holds task counts per inbox bucket. A task counts as overdue
+// when it is assigned to or claimable by the user and its due date has passed.
+type InboxCounts struct {
+	Assigned  int64
+	Claimable int64
+	Delegated int64
+	Overdue   int64
+}
+
+// InboxSummary aggregates the open tasks of a user for dashboards
+type InboxSummary struct {
+	UserID string
+	InboxCounts
+
+	// ByProcessDefinition breaks the counts down by process definition ID;
+	// standalone tasks are counted under the empty ID
+	ByProcessDefinition map[string]*InboxCounts
+}
+
+// Comment represents a comment on a task
+type Comment struct {
+	ID      string
+	TaskID  string
+	UserID  string
+	Message string
+	Time    time.Time
+}
+
+// Attachment represents an attachment on a task
+type Attachment struct {
+	ID                string
+	Name              string
+	Description       string
+	Type              string
+	TaskID            string
+	ProcessInstanceID string
+	URL               string
+	Content           []byte
+	Time              time.Time
+}
+
+// TaskQuery provides a fluent API for querying tasks
+type TaskQuery struct {
+	taskID                     string
+	taskName                   string
+	taskDescription            string
+	assignee                   string
+	owner                      string
+	candidateUser              string
+	candidateGroup             string
+	candidateGroupIn           []string
+	involvedUser               string
+	involvedGroups             []string
+	candidateOrAssigned        string
+	candidateOrAssignedGroups  []string
+	processInstanceID          string
+	processDefinitionID        string
+	processDefinitionKey       string
+	executionID                string
+	taskDefinitionKey          string
+	category                   string
+	tenantID                   string
+	suspended                  *bool
+	active                     *bool
+	priorityMin                *int
+	priorityMax                *int
+	dueBefore                  *time.Time
+	dueAfter                   *time.Time
+	followUpBefore             *time.Time
+	followUpAfter              *time.Time
+	overdue                    *bool
+	createdBefore              *time.Time
+	createdAfter               *time.Time
+	variableValueEquals        map[string]interface{}
+	processVariableValueEquals map[string]interface{}
+	includeTaskVariables       bool
+	includeProcessVariables    bool
+	orderBy                    string
+	ascending                  bool
+	firstResult                int
+	maxResults                 int
+	orQueries                  []*TaskQuery
+	parent                     *TaskQuery // query enclosing an Or group
+	service                    TaskService
+}
+
+// TaskID filters by task ID
+func (q *TaskQuery) TaskID(id string) *TaskQuery {
+	q.taskID = id
+	return q
+}
+
+// TaskName filters by task name
+func (q *TaskQuery) TaskName(name string) *TaskQuery {
+	q.taskName = name
+	return q
+}
+
+// TaskDescription filters by task description
+func (q *TaskQuery) TaskDescription(description string) *TaskQuery {
+	q.taskDescription = description
+	return q
+}
+
+// TaskAssignee filters by assignee
+func (q *TaskQuery) TaskAssignee(assignee string) *TaskQuery {
+	q.assignee = assignee
+	return q
+}
+
+// TaskOwner filters by owner
+func (q *TaskQuery) TaskOwner(owner string) *TaskQuery {
+	q.owner = owner
+	return q
+}
+
+// TaskCandidateUser filters by candidate user
+func (q *TaskQuery) TaskCandidateUser(userID string) *TaskQuery {
+	q.candidateUser = userID
+	return q
+}
+
+// TaskCandidateGroup filters by candidate group
+func (q *TaskQuery) TaskCandidateGroup(groupID string) *TaskQuery {
+	q.candidateGroup = groupID
+	return q
+}
+
+// TaskCandidateOrAssigned filters the tasks in a user's inbox: tasks assigned to the
+// user, and unassigned tasks offered to the user directly, to one of the groups, or
+// to a group the user is a member of (resolved with the group member resolver)
+func (q *TaskQuery) TaskCandidateOrAssigned(userID string, groupIDs ...string) *TaskQuery {
+	q.candidateOrAssigned = userID
+	q.candidateOrAssignedGroups = groupIDs
+	return q
+}
+
+// TaskCandidateGroupIn filters by candidate group, matching unassigned tasks offered to
+// any of the groups, e.g. all groups of a user
+func (q *TaskQuery) TaskCandidateGroupIn(groupIDs ...string) *TaskQuery {
+	q.candidateGroupIn = groupIDs
+	return q
+}
+
+// TaskInvolvedUser filters tasks related to a user by any identity link
+func (q *TaskQuery) TaskInvolvedUser(userID string) *TaskQuery {
+	q.involvedUser = userID
+	return q
+}
+
+// TaskInvolvedGroups filters tasks related to one of the groups by any identity link
+func (q *TaskQuery) TaskInvolvedGroups(groupIDs ...string) *TaskQuery {
+	q.involvedGroups = groupIDs
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *TaskQuery) ProcessInstanceID(id string) *TaskQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *TaskQuery) ProcessDefinitionID(id string) *TaskQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ProcessDefinitionKey filters by process definition key
+func (q *TaskQuery) ProcessDefinitionKey(key string) *TaskQuery {
+	q.processDefinitionKey = key
+	return q
+}
+
+// ExecutionID filters by execution ID
+func (q *TaskQuery) ExecutionID(id string) *TaskQuery {
+	q.executionID = id
+	return q
+}
+
+// TaskDefinitionKey filters by task definition key
+func (q *TaskQuery) TaskDefinitionKey(key string) *TaskQuery {
+	q.taskDefinitionKey = key
+	return q
+}
+
+// TaskCategory filters by category
+func (q *TaskQuery) TaskCategory(category string) *TaskQuery {
+	q.category = category
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *TaskQuery) TenantID(tenantID string) *TaskQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// Active filters to only active tasks
+func (q *TaskQuery) Active() *TaskQuery {
+	trueVal := true
+	q.active = &trueVal
+	return q
+}
+
+// Suspended filters to only suspended tasks
+func (q *TaskQuery) Suspended() *TaskQuery {
+	trueVal := true
+	q.suspended = &trueVal
+	return q
+}
+
+// TaskPriority filters by priority
+func (q *TaskQuery) TaskPriority(priority int) *TaskQuery {
+	q.priorityMin = &priority
+	q.priorityMax = &priority
+	return q
+}
+
+// TaskPriorityMin filters by minimum priority
+func (q *TaskQuery) TaskPriorityMin(minPriority int) *TaskQuery {
+	q.priorityMin = &minPriority
+	return q
+}
+
+// TaskPriorityMax filters by maximum priority
+func (q *TaskQuery) TaskPriorityMax(maxPriority int) *TaskQuery {
+	q.priorityMax = &maxPriority
+	return q
+}
+
+// DueBefore filters tasks due before a specific date
+func (q *TaskQuery) DueBefore(date time.Time) *TaskQuery {
+	q.dueBefore = &date
+	return q
+}
+
+// DueAfter filters tasks due after a specific date
+func (q *TaskQuery) DueAfter(date time.Time) *TaskQuery {
+	q.dueAfter = &date
+	return q
+}
+
+// FollowUpBefore filters tasks with a follow-up date before a specific date
+func (q *TaskQuery) FollowUpBefore(date time.Time) *TaskQuery {
+	q.followUpBefore = &date
+	return q
+}
+
+// FollowUpAfter filters tasks with a follow-up date after a specific date
+func (q *TaskQuery) FollowUpAfter(date time.Time) *TaskQuery {
+	q.followUpAfter = &date
+	return q
+}
+
+// Overdue filters to only tasks whose due date passed
+func (q *TaskQuery) Overdue() *TaskQuery {
+	trueVal := true
+	q.overdue = &trueVal
+	return q
+}
+
+// TaskCreatedBefore filters tasks created before a specific date
+func (q *TaskQuery) TaskCreatedBefore(date time.Time) *TaskQuery {
+	q.createdBefore = &date
+	return q
+}
+
+// TaskCreatedAfter filters tasks created after a specific date
+func (q *TaskQuery) TaskCreatedAfter(date time.Time) *TaskQuery {
+	q.createdAfter = &date
+	return q
+}
+
+// TaskVariableValueEquals filters by the value of a task variable
+func (q *TaskQuery) TaskVariableValueEquals(name string, value interface{}) *TaskQuery {
+	if q.variableValueEquals == nil {
+		q.variableValueEquals = make(map[string]interface{})
+	}
+	q.variableValueEquals[name] = value
+	return q
+}
+
+// ProcessVariableValueEquals filters by the value of a process variable visible to the task
+func (q *TaskQuery) ProcessVariableValueEquals(name string, value interface{}) *TaskQuery {
+	if q.processVariableValueEquals == nil {
+		q.processVariableValueEquals = make(map[string]interface{})
+	}
+	q.processVariableValueEquals[name] = value
+	return q
+}
+
+// IncludeTaskVariables sets the local variables of each returned task in its
+// TaskLocalVariables, e.g. to show them in a worklist without a call per task
+func (q *TaskQuery) IncludeTaskVariables() *TaskQuery {
+	q.includeTaskVariables = true
+	return q
+}
+
+// IncludeProcessVariables sets the variables of the execution of each returned task
+// in its ProcessVariables; standalone tasks get none
+func (q *TaskQuery) IncludeProcessVariables() *TaskQuery {
+	q.includeProcessVariables = true
+	return q
+}
+
+// OrderByTaskID orders results by task ID
+func (q *TaskQuery) OrderByTaskID() *TaskQuery {
+	q.orderBy = "id"
+	return q
+}
+
+// OrderByTaskName orders results by task name
+func (q *TaskQuery) OrderByTaskName() *TaskQuery {
+	q.orderBy = "name"
+	return q
+}
+
+// OrderByTaskPriority orders results by priority
+func (q *TaskQuery) OrderByTaskPriority() *TaskQuery {
+	q.orderBy = "priority"
+	return q
+}
+
+// OrderByTaskCreateTime orders results by create time
+func (q *TaskQuery) OrderByTaskCreateTime() *TaskQuery {
+	q.orderBy = "create_time"
+	return q
+}
+
+// OrderByDueDate orders results by due date
+func (q *TaskQuery) OrderByDueDate() *TaskQuery {
+	q.orderBy = "due_date"
+	return q
+}
+
+// OrderByFollowUpDate orders results by follow-up date
+func (q *TaskQuery) OrderByFollowUpDate() *TaskQuery {
+	q.orderBy = "follow_up_date"
+	return q
+}
+
+// Asc sets ascending order
+func (q *TaskQuery) Asc() *TaskQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *TaskQuery) Desc() *TaskQuery {
+	q.ascending = false
+	return q
+}
+
+// Or starts a group of filters of which a task must match at least one, e.g.
+// Or().TaskAssignee(userID).TaskCandidateGroup(groupID).EndOr(). Ordering and
+// paging apply to the enclosing query only.
+func (q *TaskQuery) Or() *TaskQuery {
+	group := &TaskQuery{parent: q, service: q.service}
+	q.orQueries = append(q.orQueries, group)
+	return group
+}
+
+// EndOr ends a group of filters started with Or and returns the enclosing query
+func (q *TaskQuery) EndOr() *TaskQuery {
+	if q.parent == nil {
+		return q
+	}
+	return q.parent
+}
+
+// criteria splits the filters of a query into queries with a single filter each
+func (q *TaskQuery) criteria() []*TaskQuery {
+	criteria := make([]*TaskQuery, 0)
+	add := func(set bool, apply func(c *TaskQuery)) {
+		if set {
+			c := &TaskQuery{}
+			apply(c)
+			criteria = append(criteria, c)
+		}
+	}
+
+	add(q.taskID != "", func(c *TaskQuery) { c.taskID = q.taskID })
+	add(q.taskName != "", func(c *TaskQuery) { c.taskName = q.taskName })
+	add(q.taskDescription != "", func(c *TaskQuery) { c.taskDescription = q.taskDescription })
+	add(q.assignee != "", func(c *TaskQuery) { c.assignee = q.assignee })
+	add(q.owner != "", func(c *TaskQuery) { c.owner = q.owner })
+	add(q.candidateUser != "", func(c *TaskQuery) { c.candidateUser = q.candidateUser })
+	add(q.candidateGroup != "", func(c *TaskQuery) { c.candidateGroup = q.candidateGroup })
+	add(q.candidateGroupIn != nil, func(c *TaskQuery) { c.candidateGroupIn = q.candidateGroupIn })
+	add(q.involvedUser != "", func(c *TaskQuery) { c.involvedUser = q.involvedUser })
+	add(len(q.involvedGroups) > 0, func(c *TaskQuery) { c.involvedGroups = q.involvedGroups })
+	add(q.candidateOrAssigned != "", func(c *TaskQuery) {
+		c.candidateOrAssigned = q.candidateOrAssigned
+		c.candidateOrAssignedGroups = q.candidateOrAssignedGroups
+	})
+	add(q.processInstanceID != "", func(c *TaskQuery) { c.processInstanceID = q.processInstanceID })
+	add(q.processDefinitionID != "", func(c *TaskQuery) { c.processDefinitionID = q.processDefinitionID })
+	add(q.processDefinitionKey != "", func(c *TaskQuery) { c.processDefinitionKey = q.processDefinitionKey })
+	add(q.executionID != "", func(c *TaskQuery) { c.executionID = q.executionID })
+	add(q.taskDefinitionKey != "", func(c *TaskQuery) { c.taskDefinitionKey = q.taskDefinitionKey })
+	add(q.category != "", func(c *TaskQuery) { c.category = q.category })
+	add(q.tenantID != "", func(c *TaskQuery) { c.tenantID = q.tenantID })
+	add(q.suspended != nil, func(c *TaskQuery) { c.suspended = q.suspended })
+	add(q.active != nil, func(c *TaskQuery) { c.active = q.active })
+	add(q.priorityMin != nil, func(c *TaskQuery) { c.priorityMin = q.priorityMin })
+	add(q.priorityMax != nil, func(c *TaskQuery) { c.priorityMax = q.priorityMax })
+	add(q.dueBefore != nil, func(c *TaskQuery) { c.dueBefore = q.dueBefore })
+	add(q.dueAfter != nil, func(c *TaskQuery) { c.dueAfter = q.dueAfter })
+	add(q.followUpBefore != nil, func(c *TaskQuery) { c.followUpBefore = q.followUpBefore })
+	add(q.followUpAfter != nil, func(c *TaskQuery) { c.followUpAfter = q.followUpAfter })
+	add(q.overdue != nil, func(c *TaskQuery) { c.overdue = q.overdue })
+	add(q.createdBefore != nil, func(c *TaskQuery) { c.createdBefore = q.createdBefore })
+	add(q.createdAfter != nil, func(c *TaskQuery) { c.createdAfter = q.createdAfter })
+	for name, value := range q.variableValueEquals {
+		add(true, func(c *TaskQuery) { c.variableValueEquals = map[string]interface{}{name: value} })
+	}
+	for name, value := range q.processVariableValueEquals {
+		add(true, func(c *TaskQuery) { c.processVariableValueEquals = map[string]interface{}{name: value} })
+	}
+	return criteria
+}
+
+// Limit sets the maximum number of results List returns
+func (q *TaskQuery) Limit(maxResults int) *TaskQuery {
+	q.maxResults = maxResults
+	return q
+}
+
+// Offset sets the number of results List skips
+func (q *TaskQuery) Offset(firstResult int) *TaskQuery {
+	q.firstResult = firstResult
+	return q
+}
+
+// List executes the query and returns a list of tasks
+func (q *TaskQuery) List(ctx context.Context) ([]*Task, error) {
+	if impl, ok := q.service.(*taskServiceImpl); ok {
+		return impl.listTasks(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Iterate executes the query and streams the matching tasks, fetching them in
+// batches instead of materializing the whole result. Iteration stops at the first error.
+func (q *TaskQuery) Iterate(ctx context.Context) iter.Seq2[*Task, error] {
+	return paging.Iterate(ctx, q.firstResult, q.maxResults, func(firstResult, maxResults int) ([]*Task, error) {
+		page := *q
+		page.firstResult, page.maxResults = firstResult, maxResults
+		return page.List(ctx)
+	})
+}
+
+// ListPage executes the query and returns a page of tasks
+func (q *TaskQuery) ListPage(ctx context.Context, firstResult, maxResults int) ([]*Task, error) {
+	q.firstResult = firstResult
+	q.maxResults = maxResults
+	return q.List(ctx)
+}
+
+// Count returns the count of matching tasks, ignoring Limit and Offset
+func (q *TaskQuery) Count(ctx context.Context) (int64, error) {
+	unpaged := *q
+	unpaged.firstResult, unpaged.maxResults = 0, 0
+	unpaged.includeTaskVariables, unpaged.includeProcessVariables = false, false
+	tasks, err := unpaged.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(tasks)), nil
+}
+
+// SingleResult returns a single task or error if not exactly one result
+func (q *TaskQuery) SingleResult(ctx context.Context) (*Task, error) {
+	tasks, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) != 1 {
+		return nil, fmt.Errorf("expected one task, found %d", len(tasks))
+	}
+	return tasks[0], nil
+}
@@ -0,0 +1,1294 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/expression"
+	"github.com/muixstudio/flowgo/pkg/paging"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// taskServiceImpl is the default implementation of TaskService
+type taskServiceImpl struct {
+	runtimeService      runtime.RuntimeService
+	tasks               map[string]*Task
+	comments            map[string][]*Comment               // taskID -> comments
+	attachments         map[string][]*Attachment            // taskID -> attachments
+	variables           map[string]map[string]interface{}   // taskID -> variables
+	identityLinks       map[string][]*identity.IdentityLink // taskID -> links other than assignee, owner and candidates
+	strategies          map[string]AssignmentStrategy       // name -> strategy
+	groupMemberResolver GroupMemberResolver
+	listeners           []registeredTaskListener
+	store               *persistence.Store
+	clock               clock.Clock
+	logger              *slog.Logger
+	expressions         *expression.Manager           // evaluates the filters of native queries
+	contents            *persistence.VariableContents // large variable values kept apart, if any
+	slaInterval         time.Duration
+	slaContext          context.Context
+	stopSLAMonitor      context.CancelFunc
+	mu                  sync.RWMutex
+}
+
+// NewTaskService creates a new task service
+func NewTaskService(runtimeService runtime.RuntimeService) TaskService {
+	slaContext, stopSLAMonitor := context.WithCancel(context.Background())
+	s := &taskServiceImpl{
+		runtimeService: runtimeService,
+		tasks:          make(map[string]*Task),
+		comments:       make(map[string][]*Comment),
+		attachments:    make(map[string][]*Attachment),
+		variables:      make(map[string]map[string]interface{}),
+		identityLinks:  make(map[string][]*identity.IdentityLink),
+		clock:          clock.System(),
+		logger:         slog.Default(),
+		expressions:    expression.NewManager(),
+		slaContext:     slaContext,
+		stopSLAMonitor: stopSLAMonitor,
+		strategies: map[string]AssignmentStrategy{
+			AssignmentStrategyRoundRobin:          NewRoundRobinStrategy(),
+			AssignmentStrategyLeastLoaded:         NewLeastLoadedStrategy(),
+			AssignmentStrategyStickyByBusinessKey: NewStickyByBusinessKeyStrategy(nil),
+		},
+	}
+
+	// User tasks reached by process executions create tasks in this service. Filters
+	// of native queries use the custom functions of the runtime service.
+	if runtimeService != nil {
+		s.expressions = runtimeService.GetExpressionManager()
+		runtimeService.RegisterActivityBehavior(model.NodeTypeUserTask, &userTaskBehavior{service: s})
+	}
+	return s
+}
+
+// SetClock sets the clock the create, claim and comment times of tasks and the now()
+// function of native query filters are based on
+func (s *taskServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+	s.expressions.SetClock(c)
+}
+
+// SetLogger sets the logger failures of reloads and due date checks are logged to
+func (s *taskServiceImpl) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// Initialize initializes the task service
+func (s *taskServiceImpl) Initialize(ctx context.Context) error {
+	if s.store != nil {
+		if err := s.store.Initialize(ctx); err != nil {
+			return err
+		}
+		if err := s.load(ctx); err != nil {
+			return err
+		}
+	}
+
+	if s.slaInterval > 0 {
+		go s.runSLAMonitor(s.slaInterval)
+	}
+	return nil
+}
+
+// Shutdown gracefully shuts down the task service
+func (s *taskServiceImpl) Shutdown(ctx context.Context) error {
+	s.stopSLAMonitor()
+	return nil
+}
+
+// CreateTaskQuery creates a new task query
+func (s *taskServiceImpl) CreateTaskQuery() *TaskQuery {
+	return &TaskQuery{
+		service: s,
+	}
+}
+
+// GetTask retrieves a task by ID
+func (s *taskServiceImpl) GetTask(ctx context.Context, taskID string) (*Task, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	return task, nil
+}
+
+// NewTask creates a new standalone task
+func (s *taskServiceImpl) NewTask(ctx context.Context, taskID string) (*Task, error) {
+	if taskID == "" {
+		taskID = uuid.New().String()
+	}
+
+	task := &Task{
+		ID:         taskID,
+		CreateTime: s.clock.Now(),
+		Priority:   5, // Default priority
+	}
+	return task, nil
+}
+
+// SaveTask saves a standalone task
+func (s *taskServiceImpl) SaveTask(ctx context.Context, task *Task) error {
+	return s.saveTask(ctx, task, nil)
+}
+
+// saveTask saves a task. New tasks are assigned with their assignment strategy and
+// announced to the create and assignment listeners; variables provides the variables
+// visible to a new task, or is nil to read them from the services.
+func (s *taskServiceImpl) saveTask(ctx context.Context, task *Task, variables func() (map[string]interface{}, error)) error {
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+
+	s.mu.RLock()
+	_, exists := s.tasks[task.ID]
+	s.mu.RUnlock()
+
+	// Apply the assignment strategy when the task is created
+	if !exists {
+		if err := s.applyAssignmentStrategy(ctx, task); err != nil {
+			return fmt.Errorf("failed to assign task: %w", err)
+		}
+		if err := s.notifyListeners(ctx, TaskEventCreate, task, variables); err != nil {
+			return err
+		}
+		if task.Assignee != "" {
+			if err := s.notifyListeners(ctx, TaskEventAssignment, task, variables); err != nil {
+				return err
+			}
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[task.ID] = task
+	return s.saveTaskLocked(ctx, task.ID)
+}
+
+// RegisterAssignmentStrategy registers an assignment strategy under a name
+func (s *taskServiceImpl) RegisterAssignmentStrategy(name string, strategy AssignmentStrategy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.strategies[name] = strategy
+}
+
+// SetGroupMemberResolver sets the resolver used to expand candidate groups into users
+func (s *taskServiceImpl) SetGroupMemberResolver(resolver GroupMemberResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groupMemberResolver = resolver
+}
+
+// applyAssignmentStrategy assigns a new task using the strategy named on the task.
+// Tasks that already have an assignee or do not name a strategy are left untouched.
+func (s *taskServiceImpl) applyAssignmentStrategy(ctx context.Context, task *Task) error {
+	if task.Assignee != "" || task.AssignmentStrategy == "" {
+		return nil
+	}
+
+	s.mu.RLock()
+	strategy, exists := s.strategies[task.AssignmentStrategy]
+	resolver := s.groupMemberResolver
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("unknown assignment strategy: %s", task.AssignmentStrategy)
+	}
+
+	candidates, err := resolveCandidates(ctx, task, resolver)
+	if err != nil {
+		return err
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	assignment := &Assignment{
+		Task:          task,
+		Candidates:    candidates,
+		OpenTaskCount: s.openTaskCount,
+	}
+
+	if task.ProcessInstanceID != "" && s.runtimeService != nil {
+		processInstance, err := s.runtimeService.GetProcessInstance(ctx, task.ProcessInstanceID)
+		if err == nil {
+			assignment.BusinessKey = processInstance.BusinessKey
+		}
+	}
+
+	assignee, err := strategy.Assign(ctx, assignment)
+	if err != nil {
+		return err
+	}
+
+	task.Assignee = assignee
+	return nil
+}
+
+// openTaskCount returns the number of open tasks assigned to a user
+func (s *taskServiceImpl) openTaskCount(userID string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, task := range s.tasks {
+		if task.Assignee == userID {
+			count++
+		}
+	}
+	return count
+}
+
+// GetInboxSummary returns the task counts shown in a user's inbox
+func (s *taskServiceImpl) GetInboxSummary(ctx context.Context, userID string) (*InboxSummary, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	summary := &InboxSummary{
+		UserID:              userID,
+		ByProcessDefinition: make(map[string]*InboxCounts),
+	}
+	now := s.clock.Now()
+
+	// Collect all buckets in one pass over the tasks. Unassigned tasks offered
+	// only to groups are checked against group membership after the lock is released.
+	groupOnly := make([]*Task, 0)
+
+	s.mu.RLock()
+	resolver := s.groupMemberResolver
+	for _, task := range s.tasks {
+		if task.Suspended {
+			continue
+		}
+
+		switch {
+		case task.Assignee == userID:
+			countInboxTask(summary, task, inboxAssigned, now)
+		case task.Assignee != "":
+			if task.Owner == userID {
+				countInboxTask(summary, task, inboxDelegated, now)
+			}
+		case slices.Contains(task.CandidateUsers, userID):
+			countInboxTask(summary, task, inboxClaimable, now)
+		case len(task.CandidateGroups) > 0:
+			groupOnly = append(groupOnly, task)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(groupOnly) == 0 || resolver == nil {
+		return summary, nil
+	}
+
+	membership := make(map[string]bool)
+	for _, task := range groupOnly {
+		member, err := isGroupMember(ctx, resolver, task.CandidateGroups, userID, membership)
+		if err != nil {
+			return nil, err
+		}
+		if member {
+			countInboxTask(summary, task, inboxClaimable, now)
+		}
+	}
+
+	return summary, nil
+}
+
+// Inbox buckets a task can be counted in
+const (
+	inboxAssigned = iota
+	inboxClaimable
+	inboxDelegated
+)
+
+// countInboxTask adds a task to a bucket of the summary and of its process definition breakdown
+func countInboxTask(summary *InboxSummary, task *Task, bucket int, now time.Time) {
+	byDefinition, exists := summary.ByProcessDefinition[task.ProcessDefinitionID]
+	if !exists {
+		byDefinition = &InboxCounts{}
+		summary.ByProcessDefinition[task.ProcessDefinitionID] = byDefinition
+	}
+
+	// Delegated tasks are worked on by someone else and do not count as overdue for the owner
+	overdue := bucket != inboxDelegated && task.DueDate != nil && task.DueDate.Before(now)
+
+	for _, counts := range []*InboxCounts{&summary.InboxCounts, byDefinition} {
+		switch bucket {
+		case inboxAssigned:
+			counts.Assigned++
+		case inboxClaimable:
+			counts.Claimable++
+		case inboxDelegated:
+			counts.Delegated++
+		}
+		if overdue {
+			counts.Overdue++
+		}
+	}
+}
+
+// DeleteTask deletes a task
+func (s *taskServiceImpl) DeleteTask(ctx context.Context, taskID string) error {
+	s.mu.RLock()
+	task, exists := s.tasks[taskID]
+	var snapshot Task
+	if exists {
+		snapshot = *task
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	if err := s.notifyListeners(ctx, TaskEventDelete, &snapshot, nil); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	delete(s.tasks, taskID)
+	delete(s.comments, taskID)
+	delete(s.attachments, taskID)
+	delete(s.variables, taskID)
+	delete(s.identityLinks, taskID)
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// Claim assigns a task to a specific user
+func (s *taskServiceImpl) Claim(ctx context.Context, taskID, userID string) error {
+	return s.changeAssignee(ctx, taskID, func(task *Task) error {
+		if task.Assignee != "" && task.Assignee != userID {
+			return fmt.Errorf("task is %w by another user: %s", errors.ErrAlreadyClaimed, task.Assignee)
+		}
+
+		now := s.clock.Now()
+		task.Assignee = userID
+		task.ClaimTime = &now
+		return nil
+	})
+}
+
+// Unclaim removes the assignee from a task
+func (s *taskServiceImpl) Unclaim(ctx context.Context, taskID string) error {
+	return s.changeAssignee(ctx, taskID, func(task *Task) error {
+		task.Assignee = ""
+		task.ClaimTime = nil
+		return nil
+	})
+}
+
+// changeAssignee applies a change of the assignee to a task and notifies the
+// assignment listeners if the assignee changed
+func (s *taskServiceImpl) changeAssignee(ctx context.Context, taskID string, change func(task *Task) error) error {
+	snapshot, changed, err := s.applyAssigneeChange(ctx, taskID, change)
+	if err != nil || !changed {
+		return err
+	}
+	return s.notifyListeners(ctx, TaskEventAssignment, snapshot, nil)
+}
+
+// applyAssigneeChange applies and stores a change of the assignee to a task. It returns
+// a copy of the changed task and whether the assignee changed.
+func (s *taskServiceImpl) applyAssigneeChange(ctx context.Context, taskID string, change func(task *Task) error) (*Task, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, false, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	// Tasks cannot be claimed or assigned across tenants
+	if !identity.IsTenantAccessible(ctx, task.TenantID) {
+		return nil, false, fmt.Errorf("no permission to assign task %s of tenant '%s'", taskID, task.TenantID)
+	}
+	if task.Suspended {
+		return nil, false, &errors.SuspendedError{EntityType: "task", ID: taskID}
+	}
+
+	previousAssignee := task.Assignee
+	if err := change(task); err != nil {
+		return nil, false, err
+	}
+	if err := s.saveTaskLocked(ctx, taskID); err != nil {
+		return nil, false, err
+	}
+	snapshot := *task
+	return &snapshot, task.Assignee != previousAssignee, nil
+}
+
+// Complete completes a task
+func (s *taskServiceImpl) Complete(ctx context.Context, taskID string) error {
+	return s.CompleteWithVariables(ctx, taskID, nil)
+}
+
+// CompleteWithVariables completes a task and sets variables as seen from the task:
+// local variables of the task are updated, the others are set on its execution
+func (s *taskServiceImpl) CompleteWithVariables(ctx context.Context, taskID string, variables map[string]interface{}) error {
+	return s.complete(ctx, taskID, variables, false)
+}
+
+// CompleteWithVariablesLocal completes a task with local variables. They are seen by
+// the complete listeners but not passed to the execution.
+func (s *taskServiceImpl) CompleteWithVariablesLocal(ctx context.Context, taskID string, variables map[string]interface{}) error {
+	return s.complete(ctx, taskID, variables, true)
+}
+
+// complete sets the variables, continues the execution waiting at the task and deletes it
+func (s *taskServiceImpl) complete(ctx context.Context, taskID string, variables map[string]interface{}, local bool) error {
+	s.mu.Lock()
+	task, exists := s.tasks[taskID]
+	var snapshot Task
+	if exists {
+		snapshot = *task
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	// Tasks of suspended process instances are suspended with them
+	if snapshot.Suspended {
+		return &errors.SuspendedError{EntityType: "task", ID: taskID}
+	}
+
+	err := s.notifyListeners(ctx, TaskEventComplete, &snapshot, func() (map[string]interface{}, error) {
+		visible, err := s.visibleVariables(ctx, &snapshot)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range variables {
+			visible[name] = value
+		}
+		return visible, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.setVariables(ctx, taskID, variables, local); err != nil {
+		return fmt.Errorf("failed to set variables: %w", err)
+	}
+
+	// Continue the process execution waiting at the user task
+	if task.ExecutionID != "" {
+		if err := s.runtimeService.Signal(ctx, task.ExecutionID); err != nil {
+			return fmt.Errorf("failed to signal execution: %w", err)
+		}
+	}
+
+	// Delete the task
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tasks, taskID)
+	delete(s.variables, taskID)
+	delete(s.identityLinks, taskID)
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// Escalate throws an escalation at the user task of a task, after notifying the
+// escalation listeners
+func (s *taskServiceImpl) Escalate(ctx context.Context, taskID, escalationCode string) error {
+	s.mu.RLock()
+	task, exists := s.tasks[taskID]
+	var snapshot Task
+	if exists {
+		snapshot = *task
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	if snapshot.ExecutionID == "" {
+		return fmt.Errorf("%w: standalone task %s has no escalation path", errors.ErrValidation, taskID)
+	}
+	if snapshot.Suspended {
+		return &errors.SuspendedError{EntityType: "task", ID: taskID}
+	}
+
+	event := &TaskEvent{EventName: TaskEventEscalation, Task: &snapshot, EscalationCode: escalationCode}
+	if err := s.notifyListenersOf(ctx, event, nil); err != nil {
+		return err
+	}
+
+	// Interrupting escalation events cancel the user task, which deletes the task
+	if err := s.runtimeService.ThrowEscalation(ctx, snapshot.ExecutionID, escalationCode, nil); err != nil {
+		return fmt.Errorf("failed to escalate task %s: %w", taskID, err)
+	}
+	return nil
+}
+
+// SetAssignee sets the assignee of a task
+func (s *taskServiceImpl) SetAssignee(ctx context.Context, taskID, userID string) error {
+	return s.changeAssignee(ctx, taskID, func(task *Task) error {
+		task.Assignee = userID
+		return nil
+	})
+}
+
+// SetOwner sets the owner of a task
+func (s *taskServiceImpl) SetOwner(ctx context.Context, taskID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	task.Owner = userID
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// AddCandidateUser adds a candidate user to a task
+func (s *taskServiceImpl) AddCandidateUser(ctx context.Context, taskID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	// Check if user already exists
+	for _, u := range task.CandidateUsers {
+		if u == userID {
+			return nil // Already exists
+		}
+	}
+
+	task.CandidateUsers = append(task.CandidateUsers, userID)
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// AddCandidateGroup adds a candidate group to a task
+func (s *taskServiceImpl) AddCandidateGroup(ctx context.Context, taskID, groupID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	// Check if group already exists
+	for _, g := range task.CandidateGroups {
+		if g == groupID {
+			return nil // Already exists
+		}
+	}
+
+	task.CandidateGroups = append(task.CandidateGroups, groupID)
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// DeleteCandidateUser removes a candidate user from a task
+func (s *taskServiceImpl) DeleteCandidateUser(ctx context.Context, taskID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	for i, u := range task.CandidateUsers {
+		if u == userID {
+			task.CandidateUsers = append(task.CandidateUsers[:i], task.CandidateUsers[i+1:]...)
+			break
+		}
+	}
+
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// DeleteCandidateGroup removes a candidate group from a task
+func (s *taskServiceImpl) DeleteCandidateGroup(ctx context.Context, taskID, groupID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	for i, g := range task.CandidateGroups {
+		if g == groupID {
+			task.CandidateGroups = append(task.CandidateGroups[:i], task.CandidateGroups[i+1:]...)
+			break
+		}
+	}
+
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// SetPriority sets the priority of a task
+func (s *taskServiceImpl) SetPriority(ctx context.Context, taskID string, priority int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	task.Priority = priority
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// SetDueDate sets the due date of a task
+func (s *taskServiceImpl) SetDueDate(ctx context.Context, taskID string, dueDate time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	task.DueDate = &dueDate
+	task.DueNotified = false
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// SetFollowUpDate sets the follow-up date of a task
+func (s *taskServiceImpl) SetFollowUpDate(ctx context.Context, taskID string, followUpDate time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	task.FollowUpDate = &followUpDate
+	task.FollowUpNotified = false
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// GetTaskVariables gets the variables visible to a task: the variables of its
+// execution overridden by its local variables
+func (s *taskServiceImpl) GetTaskVariables(ctx context.Context, taskID string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	task, exists := s.tasks[taskID]
+	var snapshot Task
+	if exists {
+		snapshot = *task
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	return s.visibleVariables(ctx, &snapshot)
+}
+
+// GetTaskVariable gets a variable visible to a task
+func (s *taskServiceImpl) GetTaskVariable(ctx context.Context, taskID, variableName string) (interface{}, error) {
+	variables, err := s.GetTaskVariables(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	return variables[variableName], nil
+}
+
+// GetTaskVariableTyped gets a variable visible to a task with its type, or nil if it is not set
+func (s *taskServiceImpl) GetTaskVariableTyped(ctx context.Context, taskID, variableName string) (*variable.TypedValue, error) {
+	variables, err := s.GetTaskVariables(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	value, exists := variables[variableName]
+	if !exists {
+		return nil, nil
+	}
+	typed, err := variable.Of(value)
+	if err != nil {
+		return nil, fmt.Errorf("variable '%s': %w", variableName, err)
+	}
+	return &typed, nil
+}
+
+// SetTaskVariable sets a variable as seen from a task
+func (s *taskServiceImpl) SetTaskVariable(ctx context.Context, taskID, variableName string, value interface{}) error {
+	return s.setVariables(ctx, taskID, map[string]interface{}{variableName: value}, false)
+}
+
+// SetTaskVariables sets multiple variables as seen from a task
+func (s *taskServiceImpl) SetTaskVariables(ctx context.Context, taskID string, variables map[string]interface{}) error {
+	return s.setVariables(ctx, taskID, variables, false)
+}
+
+// RemoveTaskVariable removes a local variable of a task or, if the task has no such
+// variable, the variable of its execution
+func (s *taskServiceImpl) RemoveTaskVariable(ctx context.Context, taskID, variableName string) error {
+	s.mu.Lock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	if _, defined := s.variables[taskID][variableName]; defined || task.ExecutionID == "" {
+		delete(s.variables[taskID], variableName)
+		err := s.saveTaskLocked(ctx, taskID)
+		s.mu.Unlock()
+		return err
+	}
+	executionID := task.ExecutionID
+	s.mu.Unlock()
+
+	return s.runtimeService.RemoveVariable(ctx, executionID, variableName)
+}
+
+// GetTaskVariablesLocal gets the local variables of a task
+func (s *taskServiceImpl) GetTaskVariablesLocal(ctx context.Context, taskID string) (map[string]interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	return s.contents.ResolveAll(ctx, s.variables[taskID])
+}
+
+// GetTaskVariableLocal gets a local variable of a task
+func (s *taskServiceImpl) GetTaskVariableLocal(ctx context.Context, taskID, variableName string) (interface{}, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+	return s.contents.Resolve(ctx, s.variables[taskID][variableName])
+}
+
+// SetTaskVariableLocal sets a local variable of a task
+func (s *taskServiceImpl) SetTaskVariableLocal(ctx context.Context, taskID, variableName string, value interface{}) error {
+	return s.setVariables(ctx, taskID, map[string]interface{}{variableName: value}, true)
+}
+
+// SetTaskVariablesLocal sets multiple local variables of a task
+func (s *taskServiceImpl) SetTaskVariablesLocal(ctx context.Context, taskID string, variables map[string]interface{}) error {
+	return s.setVariables(ctx, taskID, variables, true)
+}
+
+// RemoveTaskVariableLocal removes a local variable of a task
+func (s *taskServiceImpl) RemoveTaskVariableLocal(ctx context.Context, taskID, variableName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	delete(s.variables[taskID], variableName)
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// setVariables sets variables of a task. Unless local is set, only variables the task
+// defines locally are updated on the task; the others are set on its execution, where
+// they are updated in the nearest scope defining them. Tasks without an execution
+// keep all variables locally.
+func (s *taskServiceImpl) setVariables(ctx context.Context, taskID string, variables map[string]interface{}, local bool) error {
+	s.mu.Lock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		s.mu.Unlock()
+		return fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	executionVariables := make(map[string]interface{})
+	for name, value := range variables {
+		if _, defined := s.variables[taskID][name]; !local && !defined && task.ExecutionID != "" {
+			executionVariables[name] = value
+			continue
+		}
+		if s.variables[taskID] == nil {
+			s.variables[taskID] = make(map[string]interface{})
+		}
+		s.variables[taskID][name] = variable.Unwrap(value)
+	}
+	executionID := task.ExecutionID
+	err := s.saveTaskLocked(ctx, taskID)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// The runtime service may call back into the task service, so it is called without the lock
+	if len(executionVariables) > 0 {
+		return s.runtimeService.SetVariables(ctx, executionID, executionVariables)
+	}
+	return nil
+}
+
+// AddComment adds a comment to a task
+func (s *taskServiceImpl) AddComment(ctx context.Context, taskID, message string) (*Comment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	comment := &Comment{
+		ID:      uuid.New().String(),
+		TaskID:  taskID,
+		UserID:  identity.AuthenticatedUserID(ctx),
+		Message: message,
+		Time:    s.clock.Now(),
+	}
+
+	s.comments[taskID] = append(s.comments[taskID], comment)
+	if err := s.saveTaskLocked(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// GetTaskComments gets all comments for a task
+func (s *taskServiceImpl) GetTaskComments(ctx context.Context, taskID string) ([]*Comment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	return s.comments[taskID], nil
+}
+
+// CreateAttachment creates an attachment for a task
+func (s *taskServiceImpl) CreateAttachment(ctx context.Context, taskID, attachmentType, attachmentName, attachmentDescription string, content []byte) (*Attachment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	attachment := &Attachment{
+		ID:                uuid.New().String(),
+		Name:              attachmentName,
+		Description:       attachmentDescription,
+		Type:              attachmentType,
+		TaskID:            taskID,
+		ProcessInstanceID: task.ProcessInstanceID,
+		Content:           content,
+		Time:              s.clock.Now(),
+	}
+
+	s.attachments[taskID] = append(s.attachments[taskID], attachment)
+	if err := s.saveTaskLocked(ctx, taskID); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// GetTaskAttachments gets all attachments for a task
+func (s *taskServiceImpl) GetTaskAttachments(ctx context.Context, taskID string) ([]*Attachment, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, exists := s.tasks[taskID]; !exists {
+		return nil, fmt.Errorf("task %w: %s", errors.ErrNotFound, taskID)
+	}
+
+	return s.attachments[taskID], nil
+}
+
+// DeleteAttachment deletes an attachment
+func (s *taskServiceImpl) DeleteAttachment(ctx context.Context, attachmentID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Find and delete the attachment
+	for taskID, attachments := range s.attachments {
+		for i, att := range attachments {
+			if att.ID == attachmentID {
+				s.attachments[taskID] = append(attachments[:i], attachments[i+1:]...)
+				return s.saveTaskLocked(ctx, taskID)
+			}
+		}
+	}
+
+	return fmt.Errorf("attachment %w: %s", errors.ErrNotFound, attachmentID)
+}
+
+// listTasks executes a task query. Filters on task fields are applied under the lock;
+// candidate group membership, process definition keys and process variables are
+// resolved afterwards, as they call other services. A task must match every Or group
+// of the query in addition to its own filters.
+func (s *taskServiceImpl) listTasks(ctx context.Context, q *TaskQuery) ([]*Task, error) {
+	if q.parent != nil {
+		return nil, fmt.Errorf("task query group started with Or() must be closed with EndOr()")
+	}
+	for _, group := range q.orQueries {
+		if len(group.orQueries) > 0 {
+			return nil, fmt.Errorf("nested Or() groups are not supported in task queries")
+		}
+	}
+
+	s.mu.RLock()
+	candidates := make([]*Task, 0)
+	for _, task := range s.tasks {
+		if identity.IsTenantAccessible(ctx, task.TenantID) && s.matchesTaskQueryLocked(task, q) {
+			candidates = append(candidates, task)
+		}
+	}
+	s.mu.RUnlock()
+
+	resolved := &taskQueryResolution{
+		membership:         make(map[string]bool),
+		assignedMembership: make(map[string]bool),
+		definitionKeys:     make(map[string]string),
+	}
+	result := make([]*Task, 0, len(candidates))
+	for _, task := range candidates {
+		matches, err := s.matchesResolvedFilters(ctx, task, q, resolved)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range q.orQueries {
+			if !matches {
+				break
+			}
+			if matches, err = s.matchesAnyCriterion(ctx, task, group, resolved); err != nil {
+				return nil, err
+			}
+		}
+		if matches {
+			result = append(result, task)
+		}
+	}
+
+	paging.Sort(result, func(a, b *Task) bool {
+		return a.ID < b.ID
+	}, func(a, b *Task) bool {
+		var c int
+		switch q.orderBy {
+		case "id":
+			c = strings.Compare(a.ID, b.ID)
+		case "name":
+			c = strings.Compare(a.Name, b.Name)
+		case "priority":
+			c = a.Priority - b.Priority
+		case "create_time":
+			c = a.CreateTime.Compare(b.CreateTime)
+		case "due_date":
+			c = compareDueDates(a.DueDate, b.DueDate)
+		case "follow_up_date":
+			c = compareDueDates(a.FollowUpDate, b.FollowUpDate)
+		default:
+			// Unordered queries return tasks in the order they were created
+			return a.CreateTime.Before(b.CreateTime)
+		}
+		return paging.Less(c, q.ascending)
+	})
+
+	page := paging.Paginate(result, q.firstResult, q.maxResults)
+	if q.includeTaskVariables || q.includeProcessVariables {
+		return s.withVariables(ctx, page, q.includeTaskVariables, q.includeProcessVariables)
+	}
+	return page, nil
+}
+
+// withVariables returns copies of tasks with their local variables and the variables
+// of their executions. The variables of each execution are read once.
+func (s *taskServiceImpl) withVariables(ctx context.Context, tasks []*Task, local, process bool) ([]*Task, error) {
+	executionVariables := make(map[string]map[string]interface{})
+	result := make([]*Task, len(tasks))
+	for i, task := range tasks {
+		hydrated := *task
+		if local {
+			s.mu.RLock()
+			variables, err := s.contents.ResolveAll(ctx, s.variables[task.ID])
+			s.mu.RUnlock()
+			if err != nil {
+				return nil, err
+			}
+			hydrated.TaskLocalVariables = make(map[string]interface{}, len(variables))
+			for name, value := range variables {
+				hydrated.TaskLocalVariables[name] = value
+			}
+		}
+		if process && task.ExecutionID != "" {
+			variables, exists := executionVariables[task.ExecutionID]
+			if !exists {
+				var err error
+				if variables, err = s.runtimeService.GetVariables(ctx, task.ExecutionID); err != nil {
+					return nil, err
+				}
+				executionVariables[task.ExecutionID] = variables
+			}
+			hydrated.ProcessVariables = make(map[string]interface{}, len(variables))
+			for name, value := range variables {
+				hydrated.ProcessVariables[name] = value
+			}
+		}
+		result[i] = &hydrated
+	}
+	return result, nil
+}
+
+// taskQueryResolution caches the values resolved through other services across the
+// tasks of a query
+type taskQueryResolution struct {
+	membership         map[string]bool   // group ID -> whether the candidate user is a member
+	assignedMembership map[string]bool   // group ID -> whether the candidate-or-assigned user is a member
+	definitionKeys     map[string]string // process instance ID -> process definition key
+}
+
+// matchesResolvedFilters checks a task against the filters of a query that are resolved
+// through the group member resolver and the runtime service
+func (s *taskServiceImpl) matchesResolvedFilters(ctx context.Context, task *Task, q *TaskQuery, resolved *taskQueryResolution) (bool, error) {
+	if q.candidateUser != "" && !slices.Contains(task.CandidateUsers, q.candidateUser) {
+		s.mu.RLock()
+		resolver := s.groupMemberResolver
+		s.mu.RUnlock()
+		member, err := isGroupMember(ctx, resolver, task.CandidateGroups, q.candidateUser, resolved.membership)
+		if err != nil || !member {
+			return false, err
+		}
+	}
+
+	// Unassigned tasks not offered to the user or the given groups may be offered to
+	// another group of the user
+	if q.candidateOrAssigned != "" && task.Assignee == "" && !isCandidate(task, q.candidateOrAssigned, q.candidateOrAssignedGroups) {
+		s.mu.RLock()
+		resolver := s.groupMemberResolver
+		s.mu.RUnlock()
+		member, err := isGroupMember(ctx, resolver, task.CandidateGroups, q.candidateOrAssigned, resolved.assignedMembership)
+		if err != nil || !member {
+			return false, err
+		}
+	}
+
+	if q.processDefinitionKey != "" {
+		if task.ProcessInstanceID == "" {
+			return false, nil
+		}
+		key, exists := resolved.definitionKeys[task.ProcessInstanceID]
+		if !exists {
+			if processInstance, err := s.runtimeService.GetProcessInstance(ctx, task.ProcessInstanceID); err == nil {
+				key = processInstance.ProcessDefinitionKey
+			}
+			resolved.definitionKeys[task.ProcessInstanceID] = key
+		}
+		if key != q.processDefinitionKey {
+			return false, nil
+		}
+	}
+
+	if len(q.processVariableValueEquals) > 0 {
+		if task.ExecutionID == "" {
+			return false, nil
+		}
+		variables, err := s.runtimeService.GetVariables(ctx, task.ExecutionID)
+		if err != nil || !variablesMatch(variables, q.processVariableValueEquals) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// matchesAnyCriterion checks whether a task matches at least one filter of an Or group.
+// A group without filters matches every task.
+func (s *taskServiceImpl) matchesAnyCriterion(ctx context.Context, task *Task, group *TaskQuery, resolved *taskQueryResolution) (bool, error) {
+	criteria := group.criteria()
+	if len(criteria) == 0 {
+		return true, nil
+	}
+	for _, criterion := range criteria {
+		s.mu.RLock()
+		matches := s.matchesTaskQueryLocked(task, criterion)
+		s.mu.RUnlock()
+		if !matches {
+			continue
+		}
+		if matches, err := s.matchesResolvedFilters(ctx, task, criterion, resolved); err != nil || matches {
+			return matches, err
+		}
+	}
+	return false, nil
+}
+
+// matchesTaskQueryLocked checks a task against the filters of a query on task fields.
+// Callers must hold the read lock.
+func (s *taskServiceImpl) matchesTaskQueryLocked(task *Task, q *TaskQuery) bool {
+	if q.taskID != "" && task.ID != q.taskID {
+		return false
+	}
+	if q.taskName != "" && task.Name != q.taskName {
+		return false
+	}
+	if q.taskDescription != "" && task.Description != q.taskDescription {
+		return false
+	}
+	if q.assignee != "" && task.Assignee != q.assignee {
+		return false
+	}
+	if q.owner != "" && task.Owner != q.owner {
+		return false
+	}
+	// Candidates see the tasks nobody has claimed yet
+	if q.candidateUser != "" && task.Assignee != "" {
+		return false
+	}
+	if q.candidateGroup != "" && (task.Assignee != "" || !slices.Contains(task.CandidateGroups, q.candidateGroup)) {
+		return false
+	}
+	if q.candidateGroupIn != nil && (task.Assignee != "" || !slices.ContainsFunc(q.candidateGroupIn, func(candidate string) bool { return slices.Contains(task.CandidateGroups, candidate) })) {
+		return false
+	}
+	if (q.involvedUser != "" || len(q.involvedGroups) > 0) && !s.isInvolvedLocked(task, q.involvedUser, q.involvedGroups) {
+		return false
+	}
+	if q.candidateOrAssigned != "" && task.Assignee != "" && task.Assignee != q.candidateOrAssigned {
+		return false
+	}
+	if q.processInstanceID != "" && task.ProcessInstanceID != q.processInstanceID {
+		return false
+	}
+	if q.processDefinitionID != "" && task.ProcessDefinitionID != q.processDefinitionID {
+		return false
+	}
+	if q.executionID != "" && task.ExecutionID != q.executionID {
+		return false
+	}
+	if q.taskDefinitionKey != "" && task.TaskDefinitionKey != q.taskDefinitionKey {
+		return false
+	}
+	if q.category != "" && task.Category != q.category {
+		return false
+	}
+	if q.tenantID != "" && task.TenantID != q.tenantID {
+		return false
+	}
+	if q.suspended != nil && *q.suspended && !task.Suspended {
+		return false
+	}
+	if q.active != nil && *q.active && task.Suspended {
+		return false
+	}
+	if q.priorityMin != nil && task.Priority < *q.priorityMin {
+		return false
+	}
+	if q.priorityMax != nil && task.Priority > *q.priorityMax {
+		return false
+	}
+	if q.dueBefore != nil && (task.DueDate == nil || !task.DueDate.Before(*q.dueBefore)) {
+		return false
+	}
+	if q.dueAfter != nil && (task.DueDate == nil || !task.DueDate.After(*q.dueAfter)) {
+		return false
+	}
+	if q.followUpBefore != nil && (task.FollowUpDate == nil || !task.FollowUpDate.Before(*q.followUpBefore)) {
+		return false
+	}
+	if q.followUpAfter != nil && (task.FollowUpDate == nil || !task.FollowUpDate.After(*q.followUpAfter)) {
+		return false
+	}
+	if q.overdue != nil && *q.overdue && (task.DueDate == nil || !task.DueDate.Before(s.clock.Now())) {
+		return false
+	}
+	if q.createdBefore != nil && !task.CreateTime.Before(*q.createdBefore) {
+		return false
+	}
+	if q.createdAfter != nil && !task.CreateTime.After(*q.createdAfter) {
+		return false
+	}
+
+	// Only the compared variables are loaded from the content store
+	variables := make(map[string]interface{}, len(q.variableValueEquals))
+	for name := range q.variableValueEquals {
+		if value, exists := s.variables[task.ID][name]; exists {
+			resolved, err := s.contents.Resolve(context.Background(), value)
+			if err != nil {
+				return false
+			}
+			variables[name] = resolved
+		}
+	}
+	return variablesMatch(variables, q.variableValueEquals)
+}
+
+// isCandidate reports whether a task is offered to a user directly or to one of the groups
+func isCandidate(task *Task, userID string, groupIDs []string) bool {
+	return slices.Contains(task.CandidateUsers, userID) || slices.ContainsFunc(groupIDs, func(candidate string) bool { return slices.Contains(task.CandidateGroups, candidate) })
+}
+
+// isGroupMember reports whether a user is a member of one of the groups. Results are
+// cached in membership across the tasks of a query.
+func isGroupMember(ctx context.Context, resolver GroupMemberResolver, groupIDs []string, userID string, membership map[string]bool) (bool, error) {
+	if resolver == nil {
+		return false, nil
+	}
+	for _, groupID := range groupIDs {
+		member, resolved := membership[groupID]
+		if !resolved {
+			members, err := resolver.GetGroupMembers(ctx, groupID)
+			if err != nil {
+				return false, fmt.Errorf("failed to resolve members of group %s: %w", groupID, err)
+			}
+			member = slices.Contains(members, userID)
+			membership[groupID] = member
+		}
+		if member {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// variablesMatch reports whether variables hold every expected value
+func variablesMatch(variables, expected map[string]interface{}) bool {
+	for name, value := range expected {
+		actual, exists := variables[name]
+		if !exists || !variable.Equal(actual, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareDueDates orders tasks without due or follow-up date last
+func compareDueDates(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
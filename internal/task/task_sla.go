@@ -0,0 +1,114 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// slaDate is a date of tasks whose passing is announced to the listeners of an event
+type slaDate struct {
+	eventName string
+	date      func(task *Task) *time.Time
+	notified  func(task *Task) *bool
+}
+
+// slaDates are the dates CheckDueDates watches
+var slaDates = []slaDate{
+	{
+		eventName: TaskEventDue,
+		date:      func(task *Task) *time.Time { return task.DueDate },
+		notified:  func(task *Task) *bool { return &task.DueNotified },
+	},
+	{
+		eventName: TaskEventFollowUp,
+		date:      func(task *Task) *time.Time { return task.FollowUpDate },
+		notified:  func(task *Task) *bool { return &task.FollowUpNotified },
+	},
+}
+
+// SetSLAMonitorInterval sets the interval CheckDueDates runs at while the service is running
+func (s *taskServiceImpl) SetSLAMonitorInterval(interval time.Duration) {
+	s.slaInterval = interval
+}
+
+// CheckDueDates notifies the listeners of the due and follow-up dates that passed.
+// Suspended tasks are skipped until they are activated. A task whose listener fails
+// is announced again on the next check; the other tasks are still announced.
+func (s *taskServiceImpl) CheckDueDates(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+
+	fired := 0
+	var errs []error
+	for _, watched := range slaDates {
+		s.mu.RLock()
+		passed := make([]Task, 0)
+		for _, task := range s.tasks {
+			date := watched.date(task)
+			if date != nil && !date.After(now) && !*watched.notified(task) && !task.Suspended {
+				passed = append(passed, *task)
+			}
+		}
+		s.mu.RUnlock()
+
+		// Announce the longest overdue tasks first
+		sort.Slice(passed, func(i, j int) bool {
+			if c := watched.date(&passed[i]).Compare(*watched.date(&passed[j])); c != 0 {
+				return c < 0
+			}
+			return passed[i].ID < passed[j].ID
+		})
+
+		for i := range passed {
+			task := &passed[i]
+			if err := s.notifyListeners(ctx, watched.eventName, task, nil); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if err := s.markNotified(ctx, task.ID, watched, *watched.date(task)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to save task %s: %w", task.ID, err))
+				continue
+			}
+			fired++
+		}
+	}
+	return fired, errors.Join(errs...)
+}
+
+// markNotified records that the passing of a date of a task was announced, unless
+// the task ended or the date changed meanwhile
+func (s *taskServiceImpl) markNotified(ctx context.Context, taskID string, watched slaDate, date time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil
+	}
+	if current := watched.date(task); current == nil || !current.Equal(date) {
+		return nil
+	}
+	*watched.notified(task) = true
+	return s.saveTaskLocked(ctx, taskID)
+}
+
+// runSLAMonitor runs CheckDueDates at an interval until the service shuts down
+func (s *taskServiceImpl) runSLAMonitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.slaContext.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CheckDueDates(s.slaContext); err != nil {
+				s.logger.ErrorContext(s.slaContext, "task due date check failed", slog.Any("error", err))
+			}
+		}
+	}
+}
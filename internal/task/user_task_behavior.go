@@ -0,0 +1,213 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// userTaskBehavior creates a task when an execution arrives at a user task.
+// The execution waits until the task is completed.
+type userTaskBehavior struct {
+	service *taskServiceImpl
+}
+
+// Execute creates the task from the properties of the user task node
+func (b *userTaskBehavior) Execute(ctx context.Context, execution *runtime.ActivityExecution) error {
+	node := execution.Node
+	variables := execution.GetVariables()
+	expressions := execution.ExpressionManager()
+
+	task, err := b.service.NewTask(ctx, "")
+	if err != nil {
+		return err
+	}
+	task.Name = node.Name
+	task.Description = node.Description
+	task.FormKey = node.GetString("formKey")
+	task.Category = node.GetString("category")
+	task.AssignmentStrategy = node.GetString("assignmentStrategy")
+	task.ProcessInstanceID = execution.ProcessInstance.ID
+	task.ProcessDefinitionID = execution.ProcessInstance.ProcessDefinitionID
+	task.ExecutionID = execution.Execution.ID
+	task.TaskDefinitionKey = node.ID
+	task.TenantID = execution.ProcessInstance.TenantID
+
+	if priority, ok := node.GetInt("priority"); ok {
+		task.Priority = priority
+	}
+
+	if task.Assignee, err = evaluateString(expressions, node.GetString("assignee"), variables); err != nil {
+		return fmt.Errorf("invalid assignee of user task '%s': %w", node.ID, err)
+	}
+	if task.Owner, err = evaluateString(expressions, node.GetString("owner"), variables); err != nil {
+		return fmt.Errorf("invalid owner of user task '%s': %w", node.ID, err)
+	}
+	if task.CandidateUsers, err = evaluateStrings(expressions, node.GetStringSlice("candidateUsers"), variables); err != nil {
+		return fmt.Errorf("invalid candidate users of user task '%s': %w", node.ID, err)
+	}
+	if task.CandidateGroups, err = evaluateStrings(expressions, node.GetStringSlice("candidateGroups"), variables); err != nil {
+		return fmt.Errorf("invalid candidate groups of user task '%s': %w", node.ID, err)
+	}
+	if dueDate := node.GetString("dueDate"); dueDate != "" {
+		if task.DueDate, err = evaluateDueDate(expressions, dueDate, variables, task.CreateTime); err != nil {
+			return fmt.Errorf("invalid due date of user task '%s': %w", node.ID, err)
+		}
+	}
+	if followUpDate := node.GetString("followUpDate"); followUpDate != "" {
+		if task.FollowUpDate, err = evaluateDueDate(expressions, followUpDate, variables, task.CreateTime); err != nil {
+			return fmt.Errorf("invalid follow-up date of user task '%s': %w", node.ID, err)
+		}
+	}
+
+	// Listeners see the variables of the execution, read without calling back into the runtime service
+	return b.service.saveTask(ctx, task, func() (map[string]interface{}, error) {
+		return b.service.withLocalVariables(ctx, task.ID, variables)
+	})
+}
+
+// Trigger continues the execution after the task was completed
+func (b *userTaskBehavior) Trigger(ctx context.Context, execution *runtime.ActivityExecution, variables map[string]interface{}) error {
+	return execution.Leave()
+}
+
+// Cancel deletes the open task of the execution, e.g. when an interrupting
+// boundary event fires
+func (b *userTaskBehavior) Cancel(ctx context.Context, execution *runtime.ActivityExecution) error {
+	b.service.mu.RLock()
+	canceled := make([]Task, 0)
+	for _, task := range b.service.tasks {
+		if task.ExecutionID == execution.Execution.ID {
+			canceled = append(canceled, *task)
+		}
+	}
+	b.service.mu.RUnlock()
+
+	for i := range canceled {
+		task := &canceled[i]
+		err := b.service.notifyListeners(ctx, TaskEventDelete, task, func() (map[string]interface{}, error) {
+			return b.service.withLocalVariables(ctx, task.ID, execution.GetVariables())
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	b.service.mu.Lock()
+	defer b.service.mu.Unlock()
+
+	for _, task := range canceled {
+		id := task.ID
+		delete(b.service.tasks, id)
+		delete(b.service.comments, id)
+		delete(b.service.attachments, id)
+		delete(b.service.variables, id)
+		delete(b.service.identityLinks, id)
+		if err := b.service.saveTaskLocked(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateString evaluates a property that may contain an expression, e.g. "${initiator}"
+func evaluateString(expressions *expression.Manager, value string, variables map[string]interface{}) (string, error) {
+	if !expression.IsExpression(value) {
+		return value, nil
+	}
+	result, err := expressions.Evaluate(value, variables)
+	if err != nil || result == nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v", result), nil
+}
+
+// evaluateStrings evaluates a list property. An expression resulting in a list
+// or a comma separated string contributes several values.
+func evaluateStrings(expressions *expression.Manager, values []string, variables map[string]interface{}) ([]string, error) {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if !expression.IsExpression(value) {
+			result = append(result, value)
+			continue
+		}
+
+		evaluated, err := expressions.Evaluate(value, variables)
+		if err != nil {
+			return nil, err
+		}
+		switch v := evaluated.(type) {
+		case nil:
+		case []string:
+			result = append(result, v...)
+		case []interface{}:
+			for _, item := range v {
+				result = append(result, fmt.Sprintf("%v", item))
+			}
+		default:
+			for _, item := range strings.Split(fmt.Sprintf("%v", v), ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					result = append(result, item)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// evaluateDueDate evaluates a due or follow-up date given as an expression, an RFC 3339
+// timestamp or a duration (ISO 8601 or Go syntax) relative to the creation time
+func evaluateDueDate(expressions *expression.Manager, value string, variables map[string]interface{}, created time.Time) (*time.Time, error) {
+	var result interface{} = value
+	if expression.IsExpression(value) {
+		var err error
+		if result, err = expressions.Evaluate(value, variables); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v := result.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return &v, nil
+	case *time.Time:
+		return v, nil
+	case time.Duration:
+		dueDate := created.Add(v)
+		return &dueDate, nil
+	case string:
+		if dueDate, err := time.Parse(time.RFC3339, v); err == nil {
+			return &dueDate, nil
+		}
+		duration, err := expression.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("expected a timestamp or duration: %s", v)
+		}
+		dueDate := created.Add(duration)
+		return &dueDate, nil
+	}
+	return nil, fmt.Errorf("unsupported due date value: %v", result)
+}
+
+// SetSuspended suspends or activates the open task of the execution together with its
+// process instance
+func (b *userTaskBehavior) SetSuspended(ctx context.Context, execution *runtime.ActivityExecution, suspended bool) error {
+	b.service.mu.Lock()
+	defer b.service.mu.Unlock()
+
+	for _, task := range b.service.tasks {
+		if task.ExecutionID != execution.Execution.ID || task.Suspended == suspended {
+			continue
+		}
+		task.Suspended = suspended
+		if err := b.service.saveTaskLocked(ctx, task.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
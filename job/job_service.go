@@ -0,0 +1,73 @@
+package job
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/muixstudio/flowgo/internal/runtime"
+)
+
+// JobService runs jobs, e.g. async continuations and due timers, in the background.
+// This service is responsible for:
+// - Acquiring due jobs under a lock owned by this job executor
+// - Running acquired jobs on a pool of worker goroutines
+// - Draining running jobs on shutdown
+type JobService interface {
+	// Initialize starts the job executor if it is enabled
+	Initialize(ctx context.Context) error
+
+	// Shutdown stops acquiring jobs and waits until running jobs are done or ctx is done
+	Shutdown(ctx context.Context) error
+
+	// GetJob retrieves a job by ID
+	GetJob(ctx context.Context, jobID string) (*runtime.Job, error)
+
+	// GetJobs returns the jobs of a process instance, or of process definitions for an empty ID
+	GetJobs(ctx context.Context, processInstanceID string) ([]*runtime.Job, error)
+
+	// ExecuteJob runs a job right away in the caller's goroutine
+	ExecuteJob(ctx context.Context, jobID string) error
+
+	// SetJobRetries sets the retries of a job, e.g. to run a job without retries left again
+	SetJobRetries(ctx context.Context, jobID string, retries int) error
+
+	// GetLockOwner returns the ID the job executor locks jobs with
+	GetLockOwner() string
+
+	// IsActive returns whether the job executor is acquiring and running jobs
+	IsActive() bool
+
+	// SetLogger sets the logger the job executor logs its start and failed jobs to
+	SetLogger(logger *slog.Logger)
+}
+
+// ExecutorConfig configures the job executor
+type ExecutorConfig struct {
+	// Enabled starts the job executor on Initialize
+	Enabled bool
+
+	// Workers is the number of jobs run concurrently
+	Workers int
+
+	// PollInterval is the time between acquisitions when no jobs were due
+	PollInterval time.Duration
+
+	// LockDuration is the time after which jobs of an executor that stopped
+	// responding can be acquired by other executors
+	LockDuration time.Duration
+
+	// MaxJobsPerAcquisition limits the number of jobs locked at once
+	MaxJobsPerAcquisition int
+}
+
+// DefaultExecutorConfig returns a job executor configuration with default values
+func DefaultExecutorConfig() ExecutorConfig {
+	return ExecutorConfig{
+		Enabled:               true,
+		Workers:               4,
+		PollInterval:          5 * time.Second,
+		LockDuration:          5 * time.Minute,
+		MaxJobsPerAcquisition: 10,
+	}
+}
@@ -0,0 +1,216 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/internal/runtime"
+)
+
+// jobServiceImpl is the default implementation of JobService
+type jobServiceImpl struct {
+	runtimeService runtime.RuntimeService
+	config         ExecutorConfig
+	lockOwner      string
+	logger         *slog.Logger
+	wakeUp         chan struct{} // signals the acquisition loop to acquire right away
+	slots          chan struct{} // one entry per running job
+	stop           chan struct{}
+	acquisition    sync.WaitGroup
+	workers        sync.WaitGroup
+	cancel         context.CancelFunc
+	active         bool
+	mu             sync.Mutex
+}
+
+// NewJobService creates a new job service executing the jobs of the runtime service.
+// Zero values in config are replaced by the defaults.
+func NewJobService(runtimeService runtime.RuntimeService, config ExecutorConfig) JobService {
+	defaults := DefaultExecutorConfig()
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.LockDuration <= 0 {
+		config.LockDuration = defaults.LockDuration
+	}
+	if config.MaxJobsPerAcquisition <= 0 {
+		config.MaxJobsPerAcquisition = defaults.MaxJobsPerAcquisition
+	}
+
+	return &jobServiceImpl{
+		runtimeService: runtimeService,
+		config:         config,
+		lockOwner:      uuid.New().String(),
+		logger:         slog.Default(),
+		wakeUp:         make(chan struct{}, 1),
+		slots:          make(chan struct{}, config.Workers),
+	}
+}
+
+// Initialize starts the acquisition loop if the job executor is enabled
+func (s *jobServiceImpl) Initialize(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.config.Enabled || s.active {
+		return nil
+	}
+
+	var executorCtx context.Context
+	executorCtx, s.cancel = context.WithCancel(context.Background())
+	s.stop = make(chan struct{})
+	s.active = true
+
+	s.runtimeService.SetJobNotifier(s)
+	s.acquisition.Add(1)
+	go s.acquireJobs(executorCtx, s.stop)
+
+	s.logger.InfoContext(ctx, "job executor started", slog.String("lockOwner", s.lockOwner), slog.Int("workers", s.config.Workers))
+	return nil
+}
+
+// Shutdown stops the acquisition loop and waits for running jobs. If ctx is done
+// first, the running jobs are cancelled.
+func (s *jobServiceImpl) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.active {
+		s.mu.Unlock()
+		return nil
+	}
+	s.active = false
+	close(s.stop)
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	s.runtimeService.SetJobNotifier(nil)
+	s.acquisition.Wait()
+
+	drained := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		cancel()
+		return nil
+	case <-ctx.Done():
+		cancel()
+		<-drained
+		return fmt.Errorf("job executor did not drain in time: %w", ctx.Err())
+	}
+}
+
+// JobsAvailable wakes up the acquisition loop
+func (s *jobServiceImpl) JobsAvailable() {
+	select {
+	case s.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+// acquireJobs is the acquisition loop. It locks as many due jobs as workers are
+// idle and polls again after the poll interval or when woken up.
+func (s *jobServiceImpl) acquireJobs(ctx context.Context, stop chan struct{}) {
+	defer s.acquisition.Done()
+
+	for {
+		idle := cap(s.slots) - len(s.slots)
+		if idle > s.config.MaxJobsPerAcquisition {
+			idle = s.config.MaxJobsPerAcquisition
+		}
+
+		acquired := 0
+		if idle > 0 {
+			jobs, err := s.runtimeService.AcquireJobs(ctx, s.lockOwner, idle, s.config.LockDuration)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "failed to acquire jobs", slog.Any("error", err))
+			}
+			for _, job := range jobs {
+				s.slots <- struct{}{}
+				s.workers.Add(1)
+				go s.runJob(ctx, job.ID)
+			}
+			acquired = len(jobs)
+		}
+
+		// More jobs may be due right away when a full batch was acquired
+		if acquired > 0 && acquired == idle {
+			select {
+			case <-stop:
+				return
+			default:
+				continue
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-s.wakeUp:
+		case <-time.After(s.config.PollInterval):
+		}
+	}
+}
+
+// runJob runs an acquired job on a worker slot
+func (s *jobServiceImpl) runJob(ctx context.Context, jobID string) {
+	defer s.workers.Done()
+	defer func() {
+		<-s.slots
+		// A worker became idle
+		s.JobsAvailable()
+	}()
+
+	// Jobs of process instances suspended since they were acquired are skipped
+	if err := s.runtimeService.ExecuteJob(ctx, jobID); err != nil && !errors.Is(err, errors.ErrSuspended) {
+		s.logger.ErrorContext(ctx, "failed to execute job", slog.String("jobId", jobID), slog.Any("error", err))
+	}
+}
+
+// GetJob retrieves a job by ID
+func (s *jobServiceImpl) GetJob(ctx context.Context, jobID string) (*runtime.Job, error) {
+	return s.runtimeService.GetJob(ctx, jobID)
+}
+
+// GetJobs returns the jobs of a process instance
+func (s *jobServiceImpl) GetJobs(ctx context.Context, processInstanceID string) ([]*runtime.Job, error) {
+	return s.runtimeService.GetJobs(ctx, processInstanceID)
+}
+
+// ExecuteJob runs a job right away in the caller's goroutine
+func (s *jobServiceImpl) ExecuteJob(ctx context.Context, jobID string) error {
+	return s.runtimeService.ExecuteJob(ctx, jobID)
+}
+
+// SetJobRetries sets the retries of a job
+func (s *jobServiceImpl) SetJobRetries(ctx context.Context, jobID string, retries int) error {
+	return s.runtimeService.SetJobRetries(ctx, jobID, retries)
+}
+
+// GetLockOwner returns the ID the job executor locks jobs with
+func (s *jobServiceImpl) GetLockOwner() string {
+	return s.lockOwner
+}
+
+// IsActive returns whether the job executor is acquiring and running jobs
+func (s *jobServiceImpl) IsActive() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active
+}
+
+// SetLogger sets the logger the job executor logs its start and failed jobs to
+func (s *jobServiceImpl) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
@@ -0,0 +1,186 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/muixstudio/flowgo/internal/runtime"
+)
+
+// JobQuery provides a fluent API for querying jobs
+type JobQuery struct {
+	jobID               string
+	jobType             string
+	processInstanceID   string
+	processDefinitionID string
+	executionID         string
+	activityID          string
+	tenantID            string
+	timers              bool
+	executable          bool
+	withException       bool
+	noRetriesLeft       bool
+	locked              bool
+	deadLetter          bool
+	dueBefore           *time.Time
+	dueAfter            *time.Time
+	orderBy             string
+	ascending           bool
+	service             ManagementService
+}
+
+// JobID filters by job ID
+func (q *JobQuery) JobID(id string) *JobQuery {
+	q.jobID = id
+	return q
+}
+
+// JobType filters by job type, e.g. runtime.JobTypeAsyncBefore
+func (q *JobQuery) JobType(jobType string) *JobQuery {
+	q.jobType = jobType
+	return q
+}
+
+// ProcessInstanceID filters by process instance ID
+func (q *JobQuery) ProcessInstanceID(id string) *JobQuery {
+	q.processInstanceID = id
+	return q
+}
+
+// ProcessDefinitionID filters by process definition ID
+func (q *JobQuery) ProcessDefinitionID(id string) *JobQuery {
+	q.processDefinitionID = id
+	return q
+}
+
+// ExecutionID filters by execution ID
+func (q *JobQuery) ExecutionID(id string) *JobQuery {
+	q.executionID = id
+	return q
+}
+
+// ActivityID filters by the activity the job belongs to
+func (q *JobQuery) ActivityID(id string) *JobQuery {
+	q.activityID = id
+	return q
+}
+
+// TenantID filters by tenant ID
+func (q *JobQuery) TenantID(tenantID string) *JobQuery {
+	q.tenantID = tenantID
+	return q
+}
+
+// Timers filters to timer jobs, including those of timer start events
+func (q *JobQuery) Timers() *JobQuery {
+	q.timers = true
+	return q
+}
+
+// Executable filters to jobs with retries left that are due
+func (q *JobQuery) Executable() *JobQuery {
+	q.executable = true
+	return q
+}
+
+// WithException filters to jobs whose last attempt failed
+func (q *JobQuery) WithException() *JobQuery {
+	q.withException = true
+	return q
+}
+
+// NoRetriesLeft filters to jobs without retries left
+func (q *JobQuery) NoRetriesLeft() *JobQuery {
+	q.noRetriesLeft = true
+	return q
+}
+
+// Locked filters to jobs currently locked by a job executor
+func (q *JobQuery) Locked() *JobQuery {
+	q.locked = true
+	return q
+}
+
+// DeadLetter queries dead-letter jobs instead of the jobs the job executor acquires
+func (q *JobQuery) DeadLetter() *JobQuery {
+	q.deadLetter = true
+	return q
+}
+
+// DueBefore filters to jobs due before a specific date
+func (q *JobQuery) DueBefore(date time.Time) *JobQuery {
+	q.dueBefore = &date
+	return q
+}
+
+// DueAfter filters to jobs due after a specific date
+func (q *JobQuery) DueAfter(date time.Time) *JobQuery {
+	q.dueAfter = &date
+	return q
+}
+
+// OrderByJobID orders results by job ID
+func (q *JobQuery) OrderByJobID() *JobQuery {
+	q.orderBy = "id"
+	return q
+}
+
+// OrderByJobDueDate orders results by due date
+func (q *JobQuery) OrderByJobDueDate() *JobQuery {
+	q.orderBy = "due_date"
+	return q
+}
+
+// OrderByJobRetries orders results by retries left
+func (q *JobQuery) OrderByJobRetries() *JobQuery {
+	q.orderBy = "retries"
+	return q
+}
+
+// OrderByCreateTime orders results by create time
+func (q *JobQuery) OrderByCreateTime() *JobQuery {
+	q.orderBy = "create_time"
+	return q
+}
+
+// Asc sets ascending order
+func (q *JobQuery) Asc() *JobQuery {
+	q.ascending = true
+	return q
+}
+
+// Desc sets descending order
+func (q *JobQuery) Desc() *JobQuery {
+	q.ascending = false
+	return q
+}
+
+// List executes the query and returns a list of jobs
+func (q *JobQuery) List(ctx context.Context) ([]*runtime.Job, error) {
+	if impl, ok := q.service.(*managementServiceImpl); ok {
+		return impl.listJobs(ctx, q)
+	}
+	return nil, fmt.Errorf("unsupported service implementation")
+}
+
+// Count returns the count of matching jobs
+func (q *JobQuery) Count(ctx context.Context) (int64, error) {
+	jobs, err := q.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(jobs)), nil
+}
+
+// SingleResult returns a single job or error if not exactly one result
+func (q *JobQuery) SingleResult(ctx context.Context) (*runtime.Job, error) {
+	jobs, err := q.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) != 1 {
+		return nil, fmt.Errorf("expected one job, found %d", len(jobs))
+	}
+	return jobs[0], nil
+}
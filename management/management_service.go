@@ -0,0 +1,87 @@
+package management
+
+import (
+	"context"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/cache"
+)
+
+// ManagementService provides operations for operators troubleshooting the engine.
+// This service is responsible for:
+// - Querying jobs and dead-letter jobs, i.e. jobs that failed without retries left
+// - Executing jobs, inspecting their failures and resetting their retries
+// - Reporting the row counts of the engine tables and the version of the schema
+// - Storing engine properties, e.g. the schema version
+// - Reporting the statistics of the process definition cache
+type ManagementService interface {
+	// Initialize loads the engine properties
+	Initialize(ctx context.Context) error
+
+	// Shutdown gracefully shuts down the management service
+	Shutdown(ctx context.Context) error
+
+	// CreateJobQuery creates a new job query
+	CreateJobQuery() *JobQuery
+
+	// GetJob retrieves a job by ID
+	GetJob(ctx context.Context, jobID string) (*runtime.Job, error)
+
+	// ExecuteJob runs a job right away in the caller's goroutine
+	ExecuteJob(ctx context.Context, jobID string) error
+
+	// GetDeadLetterJob retrieves a dead-letter job by ID
+	GetDeadLetterJob(ctx context.Context, jobID string) (*runtime.Job, error)
+
+	// GetDeadLetterJobs returns the dead-letter jobs of a process instance, or all for an empty ID
+	GetDeadLetterJobs(ctx context.Context, processInstanceID string) ([]*runtime.Job, error)
+
+	// GetJobExceptionStacktrace returns the error chain, and for panics the stack, of the
+	// last failed attempt of a job or dead-letter job
+	GetJobExceptionStacktrace(ctx context.Context, jobID string) (string, error)
+
+	// SetJobRetries sets the retries of a job. Positive retries move a dead-letter job back
+	// to the jobs the job executor acquires.
+	SetJobRetries(ctx context.Context, jobID string, retries int) error
+
+	// ExecuteDeadLetterJob moves a dead-letter job back with one retry and runs it right away
+	ExecuteDeadLetterJob(ctx context.Context, jobID string) error
+
+	// DeleteDeadLetterJob removes a dead-letter job
+	DeleteDeadLetterJob(ctx context.Context, jobID string) error
+
+	// GetTableNames returns the names of the engine tables
+	GetTableNames() []string
+
+	// GetTableCount returns the number of rows of each engine table.
+	// It needs a store, either of a database or of the "memory" driver.
+	GetTableCount(ctx context.Context) (map[string]int64, error)
+
+	// GetSchemaVersion returns the version of the database schema, the latest version
+	// of the engine and whether a migration failed part way
+	GetSchemaVersion(ctx context.Context) (persistence.SchemaVersion, error)
+
+	// GetProcessDefinitionCacheStats returns the size, capacity, hits, misses and
+	// evictions of the cache of parsed process models
+	GetProcessDefinitionCacheStats(ctx context.Context) (cache.Stats, error)
+
+	// GetProperties returns the engine properties
+	GetProperties(ctx context.Context) (map[string]string, error)
+
+	// GetProperty returns the value of an engine property
+	GetProperty(ctx context.Context, name string) (string, error)
+
+	// SetProperty creates or updates an engine property
+	SetProperty(ctx context.Context, name, value string) error
+
+	// DeleteProperty removes an engine property
+	DeleteProperty(ctx context.Context, name string) error
+
+	// SetStore sets the store engine properties are persisted to and tables are counted in
+	SetStore(store *persistence.Store)
+
+	// SetClock sets the clock the due dates of job queries are based on
+	SetClock(c clock.Clock)
+}
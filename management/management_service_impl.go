@@ -0,0 +1,322 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/identity"
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/persistence"
+	"github.com/muixstudio/flowgo/pkg/cache"
+)
+
+// property is the persisted form of an engine property
+type property struct {
+	Name  string
+	Value string
+}
+
+// managementServiceImpl is the default implementation of ManagementService
+type managementServiceImpl struct {
+	runtimeService    runtime.RuntimeService
+	repositoryService repository.RepositoryService
+	properties        map[string]string
+	store             *persistence.Store
+	clock             clock.Clock
+	mu                sync.RWMutex
+}
+
+// NewManagementService creates a new management service for the jobs of the runtime
+// service and the process definition cache of the repository service
+func NewManagementService(runtimeService runtime.RuntimeService, repositoryService repository.RepositoryService) ManagementService {
+	return &managementServiceImpl{
+		runtimeService:    runtimeService,
+		repositoryService: repositoryService,
+		properties:        make(map[string]string),
+		clock:             clock.System(),
+	}
+}
+
+// SetClock sets the clock the due dates of job queries are based on
+func (s *managementServiceImpl) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// Initialize loads the engine properties from the store, if any
+func (s *managementServiceImpl) Initialize(ctx context.Context) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.Initialize(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return persistence.Load(ctx, s.store, persistence.TableProperties, func(p *property) {
+		s.properties[p.Name] = p.Value
+	})
+}
+
+// Shutdown gracefully shuts down the management service
+func (s *managementServiceImpl) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// SetStore sets the store engine properties are persisted to
+func (s *managementServiceImpl) SetStore(store *persistence.Store) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.store = store
+}
+
+// CreateJobQuery creates a new job query
+func (s *managementServiceImpl) CreateJobQuery() *JobQuery {
+	return &JobQuery{service: s}
+}
+
+// listJobs executes a job query
+func (s *managementServiceImpl) listJobs(ctx context.Context, q *JobQuery) ([]*runtime.Job, error) {
+	var jobs []*runtime.Job
+	var err error
+	if q.deadLetter {
+		jobs, err = s.runtimeService.GetDeadLetterJobs(ctx, q.processInstanceID)
+	} else {
+		jobs, err = s.runtimeService.ListJobs(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	result := make([]*runtime.Job, 0)
+	for _, job := range jobs {
+		if identity.IsTenantAccessible(ctx, job.TenantID) && matchesJobQuery(job, q, now) {
+			result = append(result, job)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		var c int
+		switch q.orderBy {
+		case "id":
+			c = strings.Compare(a.ID, b.ID)
+		case "due_date":
+			c = a.DueDate.Compare(b.DueDate)
+		case "retries":
+			c = a.Retries - b.Retries
+		case "create_time":
+			c = a.CreateTime.Compare(b.CreateTime)
+		default:
+			// Unordered queries return jobs in the order they were created
+			return a.CreateTime.Before(b.CreateTime)
+		}
+		if q.ascending {
+			return c < 0
+		}
+		return c > 0
+	})
+	return result, nil
+}
+
+// matchesJobQuery checks a job against the query filters
+func matchesJobQuery(job *runtime.Job, q *JobQuery, now time.Time) bool {
+	if q.jobID != "" && job.ID != q.jobID {
+		return false
+	}
+	if q.jobType != "" && job.Type != q.jobType {
+		return false
+	}
+	if q.processInstanceID != "" && job.ProcessInstanceID != q.processInstanceID {
+		return false
+	}
+	if q.processDefinitionID != "" && job.ProcessDefinitionID != q.processDefinitionID {
+		return false
+	}
+	if q.executionID != "" && job.ExecutionID != q.executionID {
+		return false
+	}
+	if q.activityID != "" && job.ActivityID != q.activityID {
+		return false
+	}
+	if q.tenantID != "" && job.TenantID != q.tenantID {
+		return false
+	}
+	if q.timers && job.Type != runtime.JobTypeTimer && job.Type != runtime.JobTypeTimerStart {
+		return false
+	}
+	if q.executable && (job.Retries <= 0 || job.DueDate.After(now)) {
+		return false
+	}
+	if q.withException && job.ErrorMessage == "" {
+		return false
+	}
+	if q.noRetriesLeft && job.Retries > 0 {
+		return false
+	}
+	if q.locked && !job.IsLocked(now) {
+		return false
+	}
+	if q.dueBefore != nil && !job.DueDate.Before(*q.dueBefore) {
+		return false
+	}
+	if q.dueAfter != nil && !job.DueDate.After(*q.dueAfter) {
+		return false
+	}
+	return true
+}
+
+// GetJob retrieves a job by ID
+func (s *managementServiceImpl) GetJob(ctx context.Context, jobID string) (*runtime.Job, error) {
+	return s.runtimeService.GetJob(ctx, jobID)
+}
+
+// ExecuteJob runs a job right away in the caller's goroutine
+func (s *managementServiceImpl) ExecuteJob(ctx context.Context, jobID string) error {
+	return s.runtimeService.ExecuteJob(ctx, jobID)
+}
+
+// GetDeadLetterJob retrieves a dead-letter job by ID
+func (s *managementServiceImpl) GetDeadLetterJob(ctx context.Context, jobID string) (*runtime.Job, error) {
+	return s.runtimeService.GetDeadLetterJob(ctx, jobID)
+}
+
+// GetDeadLetterJobs returns the dead-letter jobs of a process instance
+func (s *managementServiceImpl) GetDeadLetterJobs(ctx context.Context, processInstanceID string) ([]*runtime.Job, error) {
+	return s.runtimeService.GetDeadLetterJobs(ctx, processInstanceID)
+}
+
+// GetJobExceptionStacktrace returns the failure of the last attempt of a job
+func (s *managementServiceImpl) GetJobExceptionStacktrace(ctx context.Context, jobID string) (string, error) {
+	return s.runtimeService.GetJobExceptionStacktrace(ctx, jobID)
+}
+
+// SetJobRetries sets the retries of a job or dead-letter job
+func (s *managementServiceImpl) SetJobRetries(ctx context.Context, jobID string, retries int) error {
+	return s.runtimeService.SetJobRetries(ctx, jobID, retries)
+}
+
+// ExecuteDeadLetterJob runs a dead-letter job again
+func (s *managementServiceImpl) ExecuteDeadLetterJob(ctx context.Context, jobID string) error {
+	return s.runtimeService.ExecuteDeadLetterJob(ctx, jobID)
+}
+
+// DeleteDeadLetterJob removes a dead-letter job
+func (s *managementServiceImpl) DeleteDeadLetterJob(ctx context.Context, jobID string) error {
+	return s.runtimeService.DeleteDeadLetterJob(ctx, jobID)
+}
+
+// GetTableNames returns the names of the engine tables
+func (s *managementServiceImpl) GetTableNames() []string {
+	return persistence.Tables()
+}
+
+// GetTableCount returns the number of rows of each engine table
+func (s *managementServiceImpl) GetTableCount(ctx context.Context) (map[string]int64, error) {
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+
+	if store == nil {
+		return nil, fmt.Errorf("no store configured; the management service has no tables")
+	}
+
+	result := make(map[string]int64)
+	for _, table := range persistence.Tables() {
+		count, err := store.Count(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		result[table] = count
+	}
+	return result, nil
+}
+
+// GetSchemaVersion returns the version of the database schema
+func (s *managementServiceImpl) GetSchemaVersion(ctx context.Context) (persistence.SchemaVersion, error) {
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+
+	if store == nil {
+		return persistence.SchemaVersion{}, fmt.Errorf("no store configured; the management service has no schema")
+	}
+	return store.GetSchemaVersion(ctx)
+}
+
+// GetProcessDefinitionCacheStats returns the counters of the process definition cache
+func (s *managementServiceImpl) GetProcessDefinitionCacheStats(ctx context.Context) (cache.Stats, error) {
+	if s.repositoryService == nil {
+		return cache.Stats{}, fmt.Errorf("no repository service configured")
+	}
+	return s.repositoryService.GetProcessDefinitionCacheStats(), nil
+}
+
+// GetProperties returns the engine properties
+func (s *managementServiceImpl) GetProperties(ctx context.Context) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string]string, len(s.properties))
+	for name, value := range s.properties {
+		result[name] = value
+	}
+	return result, nil
+}
+
+// GetProperty returns the value of an engine property
+func (s *managementServiceImpl) GetProperty(ctx context.Context, name string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.properties[name]
+	if !exists {
+		return "", fmt.Errorf("property %w: %s", errors.ErrNotFound, name)
+	}
+	return value, nil
+}
+
+// SetProperty creates or updates an engine property
+func (s *managementServiceImpl) SetProperty(ctx context.Context, name, value string) error {
+	if name == "" {
+		return fmt.Errorf("property name cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.store != nil {
+		if err := s.store.Save(ctx, persistence.TableProperties, name, &property{Name: name, Value: value}); err != nil {
+			return err
+		}
+	}
+	s.properties[name] = value
+	return nil
+}
+
+// DeleteProperty removes an engine property
+func (s *managementServiceImpl) DeleteProperty(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.properties[name]; !exists {
+		return fmt.Errorf("property %w: %s", errors.ErrNotFound, name)
+	}
+	if s.store != nil {
+		if err := s.store.Delete(ctx, persistence.TableProperties, name); err != nil {
+			return err
+		}
+	}
+	delete(s.properties, name)
+	return nil
+}
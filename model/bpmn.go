@@ -0,0 +1,514 @@
+package model
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BPMNNamespace is the XML namespace of BPMN 2.0 process models
+const BPMNNamespace = "http://www.omg.org/spec/BPMN/20100524/MODEL"
+
+// BPMNDINamespace is the XML namespace of BPMN 2.0 diagram interchange, the layout of diagrams
+const BPMNDINamespace = "http://www.omg.org/spec/BPMN/20100524/DI"
+
+// xsiNamespace is the XML namespace of schema instance attributes such as xsi:type
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// bpmnElement is an element of a BPMN document. Documents are read as generic element
+// trees, so that extension elements and attributes of any vendor can be converted.
+type bpmnElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr     `xml:",any,attr"`
+	Children []*bpmnElement `xml:",any"`
+	Text     string         `xml:",chardata"`
+}
+
+// attr returns an unqualified attribute, the standard BPMN attributes
+func (e *bpmnElement) attr(name string) string {
+	for _, attr := range e.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// hasAttr reports whether an unqualified attribute is present
+func (e *bpmnElement) hasAttr(name string) bool {
+	for _, attr := range e.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == name {
+			return true
+		}
+	}
+	return false
+}
+
+// child returns the first BPMN child element with a name, or nil
+func (e *bpmnElement) child(name string) *bpmnElement {
+	for _, child := range e.Children {
+		if child.XMLName.Space == BPMNNamespace && child.XMLName.Local == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// text returns the trimmed text of the first BPMN child element with a name
+func (e *bpmnElement) text(name string) string {
+	if child := e.child(name); child != nil {
+		return strings.TrimSpace(child.Text)
+	}
+	return ""
+}
+
+// isExtensionAttr reports whether an attribute is a vendor extension such as
+// camunda:assignee or flowable:candidateGroups
+func isExtensionAttr(attr xml.Attr) bool {
+	switch attr.Name.Space {
+	case "", "xmlns", xsiNamespace, BPMNNamespace, "http://www.w3.org/XML/1998/namespace":
+		return false
+	}
+	return true
+}
+
+// bpmnConverter converts the process of a BPMN document into a process model
+type bpmnConverter struct {
+	model       *ProcessModel
+	messages    map[string]string // message ID -> name
+	signals     map[string]string // signal ID -> name
+	errors      map[string]string // error ID -> error code
+	escalations map[string]string // escalation ID -> escalation code
+	defaults    []string          // IDs of default sequence flows
+}
+
+// ParseBPMN parses a BPMN 2.0 XML document into a ProcessModel. The executable process
+// of the document is converted: events, tasks, gateways, subprocesses and sequence
+// flows become nodes and edges; extension attributes of Camunda, Flowable or Activiti
+// (e.g. camunda:assignee, flowable:candidateGroups) become node properties and the shapes
+// of the diagram become node bounds. Manual and abstract tasks pass through; elements
+// without counterpart in the model are rejected.
+func ParseBPMN(content []byte) (*ProcessModel, error) {
+	var definitions bpmnElement
+	if err := xml.Unmarshal(content, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid BPMN XML: %w", err)
+	}
+	if definitions.XMLName.Space != BPMNNamespace || definitions.XMLName.Local != "definitions" {
+		return nil, fmt.Errorf("not a BPMN 2.0 document: root element is '%s'", definitions.XMLName.Local)
+	}
+
+	c := &bpmnConverter{
+		messages:    make(map[string]string),
+		signals:     make(map[string]string),
+		errors:      make(map[string]string),
+		escalations: make(map[string]string),
+	}
+	var process *bpmnElement
+	for _, child := range definitions.Children {
+		if child.XMLName.Space != BPMNNamespace {
+			continue
+		}
+		switch child.XMLName.Local {
+		case "message":
+			c.messages[child.attr("id")] = child.attr("name")
+		case "signal":
+			c.signals[child.attr("id")] = child.attr("name")
+		case "error":
+			c.errors[child.attr("id")] = child.attr("errorCode")
+		case "escalation":
+			c.escalations[child.attr("id")] = child.attr("escalationCode")
+		case "process":
+			if child.attr("isExecutable") == "false" {
+				continue
+			}
+			if process != nil {
+				return nil, fmt.Errorf("BPMN document defines more than one executable process: %s, %s", process.attr("id"), child.attr("id"))
+			}
+			process = child
+		}
+	}
+	if process == nil {
+		return nil, fmt.Errorf("BPMN document defines no executable process")
+	}
+
+	c.model = &ProcessModel{
+		ID:          process.attr("id"),
+		Name:        process.attr("name"),
+		Description: process.text("documentation"),
+		Nodes:       make([]*Node, 0),
+		Edges:       make([]*Edge, 0),
+	}
+	if c.model.Name == "" {
+		c.model.Name = c.model.ID
+	}
+	for _, attr := range process.Attrs {
+		if isExtensionAttr(attr) && attr.Name.Local == "historyTimeToLive" {
+			days, err := strconv.Atoi(attr.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid history time to live of process '%s': %s", c.model.ID, attr.Value)
+			}
+			c.model.HistoryTimeToLive = &days
+		}
+	}
+
+	if err := c.convertScope(process, ""); err != nil {
+		return nil, err
+	}
+	c.convertDiagram(&definitions)
+	for _, flowID := range c.defaults {
+		edge := c.model.GetEdge(flowID)
+		if edge == nil {
+			return nil, fmt.Errorf("default sequence flow not found: %s", flowID)
+		}
+		edge.IsDefault = true
+	}
+	return c.model, nil
+}
+
+// convertDiagram sets the bounds of nodes from the shapes of the BPMN diagram, if any
+func (c *bpmnConverter) convertDiagram(definitions *bpmnElement) {
+	for _, diagram := range definitions.Children {
+		if diagram.XMLName.Space != BPMNDINamespace || diagram.XMLName.Local != "BPMNDiagram" {
+			continue
+		}
+		for _, plane := range diagram.Children {
+			for _, shape := range plane.Children {
+				if shape.XMLName.Local != "BPMNShape" {
+					continue
+				}
+				node := c.model.GetNode(shape.attr("bpmnElement"))
+				if node == nil {
+					continue
+				}
+				for _, bounds := range shape.Children {
+					if bounds.XMLName.Local == "Bounds" {
+						node.Bounds = &Bounds{
+							X:      parseCoordinate(bounds.attr("x")),
+							Y:      parseCoordinate(bounds.attr("y")),
+							Width:  parseCoordinate(bounds.attr("width")),
+							Height: parseCoordinate(bounds.attr("height")),
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// parseCoordinate parses a coordinate of a diagram, zero if invalid
+func parseCoordinate(value string) float64 {
+	coordinate, _ := strconv.ParseFloat(value, 64)
+	return coordinate
+}
+
+// convertScope converts the flow elements of a process or subprocess
+func (c *bpmnConverter) convertScope(scope *bpmnElement, parentID string) error {
+	for _, element := range scope.Children {
+		if element.XMLName.Space != BPMNNamespace {
+			continue
+		}
+
+		var err error
+		switch name := element.XMLName.Local; name {
+		case "sequenceFlow":
+			c.model.Edges = append(c.model.Edges, &Edge{
+				ID:        element.attr("id"),
+				Name:      element.attr("name"),
+				Source:    element.attr("sourceRef"),
+				Target:    element.attr("targetRef"),
+				Condition: element.text("conditionExpression"),
+			})
+		case "startEvent", "endEvent", "intermediateCatchEvent", "intermediateThrowEvent", "boundaryEvent":
+			err = c.convertEvent(element, parentID)
+		case "userTask", "serviceTask", "sendTask", "scriptTask", "businessRuleTask", "callActivity", "receiveTask", "manualTask", "task":
+			err = c.convertTask(element, parentID)
+		case "exclusiveGateway", "parallelGateway", "inclusiveGateway", "eventBasedGateway":
+			c.addNode(element, name, parentID)
+		case "subProcess":
+			node := c.addNode(element, NodeTypeSubProcess, parentID)
+			if element.attr("triggeredByEvent") == "true" {
+				node.Properties["triggeredByEvent"] = true
+			}
+			err = c.convertScope(element, node.ID)
+		case "documentation", "extensionElements", "laneSet", "ioSpecification", "property",
+			"dataObject", "dataObjectReference", "dataStoreReference", "textAnnotation", "association":
+			// Not executed
+		default:
+			err = fmt.Errorf("unsupported BPMN element '%s' (%s)", name, element.attr("id"))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addNode adds the node of an element with its name, documentation, default flow and extensions
+func (c *bpmnConverter) addNode(element *bpmnElement, nodeType, parentID string) *Node {
+	node := &Node{
+		ID:          element.attr("id"),
+		Type:        nodeType,
+		ParentID:    parentID,
+		Name:        element.attr("name"),
+		Description: element.text("documentation"),
+		Properties:  make(map[string]interface{}),
+	}
+	if flowID := element.attr("default"); flowID != "" {
+		c.defaults = append(c.defaults, flowID)
+	}
+	c.convertExtensions(element, node)
+	c.model.Nodes = append(c.model.Nodes, node)
+	return node
+}
+
+// convertTask converts a task or call activity
+func (c *bpmnConverter) convertTask(element *bpmnElement, parentID string) error {
+	id := element.attr("id")
+	if element.child("multiInstanceLoopCharacteristics") != nil || element.child("standardLoopCharacteristics") != nil {
+		return fmt.Errorf("unsupported loop characteristics of activity '%s'", id)
+	}
+
+	switch element.XMLName.Local {
+	case "userTask":
+		c.addNode(element, NodeTypeUserTask, parentID)
+	case "serviceTask", "sendTask":
+		c.addNode(element, NodeTypeServiceTask, parentID)
+	case "businessRuleTask":
+		c.addNode(element, NodeTypeBusinessRuleTask, parentID)
+	case "scriptTask":
+		node := c.addNode(element, NodeTypeScriptTask, parentID)
+		node.Properties["scriptFormat"] = element.attr("scriptFormat")
+		node.Properties["script"] = element.text("script")
+	case "callActivity":
+		node := c.addNode(element, NodeTypeCallActivity, parentID)
+		node.Properties["calledElement"] = element.attr("calledElement")
+	case "receiveTask":
+		// Receive tasks wait for their message like message catch events
+		node := c.addNode(element, NodeTypeIntermediateEvent, parentID)
+		node.Properties["eventType"] = "message"
+		node.Properties["eventDefinition"] = map[string]interface{}{"messageName": c.messageName(element.attr("messageRef"))}
+	default:
+		// Manual and abstract tasks have no behavior in the engine
+		c.addNode(element, NodeTypeIntermediateEvent, parentID)
+	}
+	return nil
+}
+
+// convertEvent converts an event with its event definition
+func (c *bpmnConverter) convertEvent(element *bpmnElement, parentID string) error {
+	var node *Node
+	switch element.XMLName.Local {
+	case "startEvent":
+		node = c.addNode(element, NodeTypeStartEvent, parentID)
+		if element.hasAttr("isInterrupting") {
+			node.Properties["isInterrupting"] = element.attr("isInterrupting") != "false"
+		}
+	case "endEvent":
+		node = c.addNode(element, NodeTypeEndEvent, parentID)
+	case "boundaryEvent":
+		node = c.addNode(element, NodeTypeBoundaryEvent, parentID)
+		node.Properties["attachedTo"] = element.attr("attachedToRef")
+		if element.hasAttr("cancelActivity") {
+			node.Properties["cancelActivity"] = element.attr("cancelActivity") != "false"
+		}
+	default:
+		node = c.addNode(element, NodeTypeIntermediateEvent, parentID)
+		if element.XMLName.Local == "intermediateThrowEvent" {
+			node.Properties["isThrowing"] = true
+		}
+	}
+
+	for _, definition := range element.Children {
+		if definition.XMLName.Space != BPMNNamespace || !strings.HasSuffix(definition.XMLName.Local, "EventDefinition") {
+			continue
+		}
+		eventType, eventDefinition, err := c.convertEventDefinition(definition)
+		if err != nil {
+			return fmt.Errorf("event '%s': %w", node.ID, err)
+		}
+		node.Properties["eventType"] = eventType
+		if len(eventDefinition) > 0 {
+			node.Properties["eventDefinition"] = eventDefinition
+		}
+		break
+	}
+
+	if node.Properties["isThrowing"] == true {
+		switch node.GetString("eventType") {
+		case "", "signal", "compensation":
+		default:
+			return fmt.Errorf("unsupported %s throw event '%s'", node.GetString("eventType"), node.ID)
+		}
+	}
+	return nil
+}
+
+// convertEventDefinition returns the event type and the event definition properties
+// of a BPMN event definition
+func (c *bpmnConverter) convertEventDefinition(definition *bpmnElement) (string, map[string]interface{}, error) {
+	// Extensions such as camunda:variableName of conditional events are event definition properties
+	properties := make(map[string]interface{})
+	for _, attr := range definition.Attrs {
+		if isExtensionAttr(attr) {
+			properties[attr.Name.Local] = attr.Value
+		}
+	}
+	switch definition.XMLName.Local {
+	case "messageEventDefinition":
+		properties["messageName"] = c.messageName(definition.attr("messageRef"))
+		return "message", properties, nil
+	case "signalEventDefinition":
+		signalName := c.signals[definition.attr("signalRef")]
+		if signalName == "" {
+			signalName = definition.attr("signalRef")
+		}
+		properties["signalName"] = signalName
+		return "signal", properties, nil
+	case "timerEventDefinition":
+		for _, timerType := range []string{"Date", "Duration", "Cycle"} {
+			if value := definition.text("time" + timerType); value != "" {
+				properties["timerType"] = strings.ToLower(timerType)
+				properties["timerValue"] = value
+				return "timer", properties, nil
+			}
+		}
+		return "", nil, fmt.Errorf("timer event definition has no timeDate, timeDuration or timeCycle")
+	case "errorEventDefinition":
+		if errorRef := definition.attr("errorRef"); errorRef != "" {
+			errorCode, exists := c.errors[errorRef]
+			if !exists {
+				errorCode = errorRef
+			}
+			properties["errorCode"] = errorCode
+		}
+		return "error", properties, nil
+	case "escalationEventDefinition":
+		if escalationRef := definition.attr("escalationRef"); escalationRef != "" {
+			escalationCode, exists := c.escalations[escalationRef]
+			if !exists {
+				escalationCode = escalationRef
+			}
+			properties["escalationCode"] = escalationCode
+		}
+		return "escalation", properties, nil
+	case "conditionalEventDefinition":
+		properties["condition"] = definition.text("condition")
+		return "conditional", properties, nil
+	case "terminateEventDefinition":
+		return "terminate", properties, nil
+	case "compensateEventDefinition":
+		if activityRef := definition.attr("activityRef"); activityRef != "" {
+			properties["activityRef"] = activityRef
+		}
+		return "compensation", properties, nil
+	}
+	return "", nil, fmt.Errorf("unsupported event definition '%s'", definition.XMLName.Local)
+}
+
+// messageName returns the name of a referenced message, or the reference itself
+func (c *bpmnConverter) messageName(messageRef string) string {
+	if name := c.messages[messageRef]; name != "" {
+		return name
+	}
+	return messageRef
+}
+
+// convertExtensions converts the extension attributes and elements of an element into
+// node properties and variable mappings
+func (c *bpmnConverter) convertExtensions(element *bpmnElement, node *Node) {
+	for _, attr := range element.Attrs {
+		if !isExtensionAttr(attr) {
+			continue
+		}
+		switch name, value := attr.Name.Local, attr.Value; name {
+		case "candidateUsers", "candidateGroups":
+			node.Properties[name] = splitList(value)
+		case "priority", "retries":
+			if number, err := strconv.Atoi(value); err == nil {
+				node.Properties[name] = number
+			} else {
+				node.Properties[name] = value
+			}
+		case "class":
+			node.Properties["implementation"] = value
+		case "delegateExpression":
+			// Delegates are registered by name: ${approvalDelegate} references "approvalDelegate"
+			node.Properties["implementation"] = strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(value, "${"), "#{"), "}")
+		default:
+			switch value {
+			case "true":
+				node.Properties[name] = true
+			case "false":
+				node.Properties[name] = false
+			default:
+				node.Properties[name] = value
+			}
+		}
+	}
+
+	extensions := element.child("extensionElements")
+	if extensions == nil {
+		return
+	}
+	for _, extension := range extensions.Children {
+		switch extension.XMLName.Local {
+		case "inputOutput":
+			for _, parameter := range extension.Children {
+				value := strings.TrimSpace(parameter.Text)
+				switch parameter.XMLName.Local {
+				case "inputParameter":
+					node.InputMappings = setMapping(node.InputMappings, parameter.attr("name"), value)
+				case "outputParameter":
+					node.OutputMappings = setMapping(node.OutputMappings, parameter.attr("name"), value)
+				}
+			}
+		case "in", "out":
+			if extension.attr("variables") == "all" {
+				node.Properties["inheritVariables"] = true
+			}
+			if extension.XMLName.Local == "in" && extension.attr("businessKey") != "" {
+				node.Properties["inheritBusinessKey"] = true
+			}
+			target := extension.attr("target")
+			if target == "" {
+				continue
+			}
+			source := extension.attr("sourceExpression")
+			if source == "" {
+				source = "${" + extension.attr("source") + "}"
+			}
+			if extension.XMLName.Local == "in" {
+				node.InputMappings = setMapping(node.InputMappings, target, source)
+			} else {
+				node.OutputMappings = setMapping(node.OutputMappings, target, source)
+			}
+		case "properties":
+			for _, property := range extension.Children {
+				if property.XMLName.Local == "property" {
+					node.Properties[property.attr("name")] = property.attr("value")
+				}
+			}
+		}
+	}
+}
+
+// setMapping sets a variable mapping, creating the mappings if needed
+func setMapping(mappings map[string]string, name, source string) map[string]string {
+	if mappings == nil {
+		mappings = make(map[string]string)
+	}
+	mappings[name] = source
+	return mappings
+}
+
+// splitList splits a comma separated attribute such as camunda:candidateGroups
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
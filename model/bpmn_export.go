@@ -0,0 +1,416 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FlowGoNamespace is the XML namespace of FlowGo extension attributes and elements in
+// exported BPMN documents
+const FlowGoNamespace = "https://github.com/muixstudio/flowgo/bpmn"
+
+// bpmnProperties are node properties exported as standard BPMN attributes or elements
+// rather than as FlowGo extensions
+var bpmnProperties = map[string]bool{
+	"eventType":        true,
+	"eventDefinition":  true,
+	"isThrowing":       true,
+	"attachedTo":       true,
+	"cancelActivity":   true,
+	"isInterrupting":   true,
+	"calledElement":    true,
+	"scriptFormat":     true,
+	"script":           true,
+	"triggeredByEvent": true,
+}
+
+// xmlNode is an element of a BPMN document being exported
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     string
+}
+
+// newXMLNode creates an element with attributes given as name/value pairs; empty values are left out
+func newXMLNode(name string, attrs ...string) *xmlNode {
+	n := &xmlNode{name: name}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		n.attr(attrs[i], attrs[i+1])
+	}
+	return n
+}
+
+// attr adds an attribute unless its value is empty
+func (n *xmlNode) attr(name, value string) {
+	if value != "" {
+		n.attrs = append(n.attrs, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+	}
+}
+
+// add appends a child element and returns it
+func (n *xmlNode) add(child *xmlNode) *xmlNode {
+	n.children = append(n.children, child)
+	return child
+}
+
+// encode writes the element with its children
+func (n *xmlNode) encode(encoder *xml.Encoder) error {
+	start := xml.StartElement{Name: xml.Name{Local: n.name}, Attr: n.attrs}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.text != "" {
+		if err := encoder.EncodeToken(xml.CharData(n.text)); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.children {
+		if err := child.encode(encoder); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// bpmnExporter converts a process model into a BPMN document
+type bpmnExporter struct {
+	model       *ProcessModel
+	defaults    map[string]string // node ID -> default sequence flow ID
+	messages    map[string]bool
+	signals     map[string]bool
+	errors      map[string]bool
+	escalations map[string]bool
+}
+
+// MarshalBPMN serializes the process model as BPMN 2.0 XML with a diagram, so that
+// standard modeling tools can display and edit it. Properties without BPMN counterpart
+// are exported as FlowGo extension attributes, which ParseBPMN reads back.
+func (m *ProcessModel) MarshalBPMN() ([]byte, error) {
+	e := &bpmnExporter{
+		model:       m,
+		defaults:    make(map[string]string),
+		messages:    make(map[string]bool),
+		signals:     make(map[string]bool),
+		errors:      make(map[string]bool),
+		escalations: make(map[string]bool),
+	}
+	for _, edge := range m.Edges {
+		if edge.IsDefault {
+			e.defaults[edge.Source] = edge.ID
+		}
+	}
+
+	process := newXMLNode("bpmn:process", "id", m.ID, "name", m.Name, "isExecutable", "true")
+	if m.HistoryTimeToLive != nil {
+		process.attr("flowgo:historyTimeToLive", strconv.Itoa(*m.HistoryTimeToLive))
+	}
+	if m.Description != "" {
+		process.add(&xmlNode{name: "bpmn:documentation", text: m.Description})
+	}
+	if err := e.exportScope(process, ""); err != nil {
+		return nil, err
+	}
+
+	definitions := newXMLNode("bpmn:definitions",
+		"xmlns:bpmn", BPMNNamespace,
+		"xmlns:bpmndi", BPMNDINamespace,
+		"xmlns:dc", "http://www.omg.org/spec/DD/20100524/DC",
+		"xmlns:di", "http://www.omg.org/spec/DD/20100524/DI",
+		"xmlns:xsi", xsiNamespace,
+		"xmlns:flowgo", FlowGoNamespace,
+		"id", "Definitions_"+m.ID,
+		"targetNamespace", "http://bpmn.io/schema/bpmn",
+		"exporter", "FlowGo")
+	for _, name := range sortedKeys(e.messages) {
+		definitions.add(newXMLNode("bpmn:message", "id", bpmnRefID("Message", name), "name", name))
+	}
+	for _, name := range sortedKeys(e.signals) {
+		definitions.add(newXMLNode("bpmn:signal", "id", bpmnRefID("Signal", name), "name", name))
+	}
+	for _, code := range sortedKeys(e.errors) {
+		definitions.add(newXMLNode("bpmn:error", "id", bpmnRefID("Error", code), "errorCode", code))
+	}
+	for _, code := range sortedKeys(e.escalations) {
+		definitions.add(newXMLNode("bpmn:escalation", "id", bpmnRefID("Escalation", code), "escalationCode", code))
+	}
+	definitions.add(process)
+	definitions.add(e.exportDiagram())
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := definitions.encode(encoder); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// exportScope exports the nodes of a process or subprocess and the sequence flows between them
+func (e *bpmnExporter) exportScope(scope *xmlNode, parentID string) error {
+	for _, node := range e.model.GetChildNodes(parentID) {
+		element, err := e.exportNode(node)
+		if err != nil {
+			return err
+		}
+		scope.add(element)
+	}
+
+	for _, edge := range e.model.Edges {
+		if source := e.model.GetNode(edge.Source); source == nil || source.ParentID != parentID {
+			continue
+		}
+		flow := scope.add(newXMLNode("bpmn:sequenceFlow", "id", edge.ID, "name", edge.Name, "sourceRef", edge.Source, "targetRef", edge.Target))
+		if edge.Condition != "" {
+			flow.add(&xmlNode{name: "bpmn:conditionExpression", attrs: []xml.Attr{{Name: xml.Name{Local: "xsi:type"}, Value: "bpmn:tFormalExpression"}}, text: edge.Condition})
+		}
+	}
+	return nil
+}
+
+// exportNode exports a node as the BPMN element of its type
+func (e *bpmnExporter) exportNode(node *Node) (*xmlNode, error) {
+	var element *xmlNode
+	switch node.Type {
+	case NodeTypeStartEvent, NodeTypeEndEvent, NodeTypeUserTask, NodeTypeServiceTask, NodeTypeScriptTask,
+		NodeTypeBusinessRuleTask, NodeTypeCallActivity, NodeTypeSubProcess, NodeTypeExclusiveGateway, NodeTypeParallelGateway,
+		NodeTypeInclusiveGateway, NodeTypeEventBasedGateway, NodeTypeBoundaryEvent:
+		element = newXMLNode("bpmn:" + node.Type)
+	case NodeTypeIntermediateEvent:
+		switch {
+		case node.GetBool("isThrowing"), node.GetString("eventType") == "", node.GetString("eventType") == "compensation":
+			element = newXMLNode("bpmn:intermediateThrowEvent")
+		default:
+			element = newXMLNode("bpmn:intermediateCatchEvent")
+		}
+	default:
+		return nil, fmt.Errorf("node '%s' of type '%s' has no BPMN counterpart", node.ID, node.Type)
+	}
+
+	element.attr("id", node.ID)
+	element.attr("name", node.Name)
+	element.attr("default", e.defaults[node.ID])
+	switch node.Type {
+	case NodeTypeBoundaryEvent:
+		element.attr("attachedToRef", node.GetString("attachedTo"))
+		if cancelActivity, ok := node.Properties["cancelActivity"].(bool); ok {
+			element.attr("cancelActivity", strconv.FormatBool(cancelActivity))
+		}
+	case NodeTypeStartEvent:
+		if interrupting, ok := node.Properties["isInterrupting"].(bool); ok {
+			element.attr("isInterrupting", strconv.FormatBool(interrupting))
+		}
+	case NodeTypeCallActivity:
+		element.attr("calledElement", node.GetString("calledElement"))
+	case NodeTypeScriptTask:
+		element.attr("scriptFormat", node.GetString("scriptFormat"))
+	case NodeTypeSubProcess:
+		if node.GetBool("triggeredByEvent") {
+			element.attr("triggeredByEvent", "true")
+		}
+	}
+
+	// Remaining properties are FlowGo extensions
+	for _, name := range sortedKeys(node.Properties) {
+		if bpmnProperties[name] {
+			continue
+		}
+		value, err := extensionValue(node.Properties[name])
+		if err != nil {
+			return nil, fmt.Errorf("property '%s' of node '%s': %w", name, node.ID, err)
+		}
+		element.attr("flowgo:"+name, value)
+	}
+
+	if node.Description != "" {
+		element.add(&xmlNode{name: "bpmn:documentation", text: node.Description})
+	}
+	if len(node.InputMappings) > 0 || len(node.OutputMappings) > 0 {
+		inputOutput := newXMLNode("flowgo:inputOutput")
+		for _, name := range sortedKeys(node.InputMappings) {
+			inputOutput.add(&xmlNode{name: "flowgo:inputParameter", attrs: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}}, text: node.InputMappings[name]})
+		}
+		for _, name := range sortedKeys(node.OutputMappings) {
+			inputOutput.add(&xmlNode{name: "flowgo:outputParameter", attrs: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: name}}, text: node.OutputMappings[name]})
+		}
+		element.add(newXMLNode("bpmn:extensionElements")).add(inputOutput)
+	}
+
+	switch node.Type {
+	case NodeTypeScriptTask:
+		element.add(&xmlNode{name: "bpmn:script", text: node.GetString("script")})
+	case NodeTypeSubProcess:
+		if err := e.exportScope(element, node.ID); err != nil {
+			return nil, err
+		}
+	}
+	if eventType := node.GetString("eventType"); eventType != "" {
+		definition, err := e.exportEventDefinition(node, eventType)
+		if err != nil {
+			return nil, err
+		}
+		element.add(definition)
+	}
+	return element, nil
+}
+
+// exportEventDefinition exports the event definition of an event
+func (e *bpmnExporter) exportEventDefinition(node *Node, eventType string) (*xmlNode, error) {
+	definition := &Node{Properties: node.GetMap("eventDefinition")}
+	switch eventType {
+	case "message":
+		name := definition.GetString("messageName")
+		e.messages[name] = true
+		return newXMLNode("bpmn:messageEventDefinition", "messageRef", bpmnRefID("Message", name)), nil
+	case "signal":
+		name := definition.GetString("signalName")
+		e.signals[name] = true
+		return newXMLNode("bpmn:signalEventDefinition", "signalRef", bpmnRefID("Signal", name), "flowgo:signalScope", definition.GetString("signalScope")), nil
+	case "error":
+		element := newXMLNode("bpmn:errorEventDefinition")
+		if code := definition.GetString("errorCode"); code != "" {
+			e.errors[code] = true
+			element.attr("errorRef", bpmnRefID("Error", code))
+		}
+		element.attr("flowgo:errorCodeVariable", definition.GetString("errorCodeVariable"))
+		element.attr("flowgo:errorMessageVariable", definition.GetString("errorMessageVariable"))
+		return element, nil
+	case "escalation":
+		element := newXMLNode("bpmn:escalationEventDefinition")
+		if code := definition.GetString("escalationCode"); code != "" {
+			e.escalations[code] = true
+			element.attr("escalationRef", bpmnRefID("Escalation", code))
+		}
+		element.attr("flowgo:escalationCodeVariable", definition.GetString("escalationCodeVariable"))
+		return element, nil
+	case "timer":
+		timerType := definition.GetString("timerType")
+		if timerType == "" {
+			return nil, fmt.Errorf("timer event '%s' has no timer type", node.ID)
+		}
+		element := newXMLNode("bpmn:timerEventDefinition")
+		element.add(&xmlNode{
+			name:  "bpmn:time" + strings.ToUpper(timerType[:1]) + timerType[1:],
+			attrs: []xml.Attr{{Name: xml.Name{Local: "xsi:type"}, Value: "bpmn:tFormalExpression"}},
+			text:  definition.GetString("timerValue"),
+		})
+		return element, nil
+	case "conditional":
+		element := newXMLNode("bpmn:conditionalEventDefinition", "flowgo:variableName", definition.GetString("variableName"))
+		element.add(&xmlNode{
+			name:  "bpmn:condition",
+			attrs: []xml.Attr{{Name: xml.Name{Local: "xsi:type"}, Value: "bpmn:tFormalExpression"}},
+			text:  definition.GetString("condition"),
+		})
+		return element, nil
+	case "terminate":
+		return newXMLNode("bpmn:terminateEventDefinition"), nil
+	case "compensation":
+		return newXMLNode("bpmn:compensateEventDefinition", "activityRef", definition.GetString("activityRef")), nil
+	}
+	return nil, fmt.Errorf("event '%s' of type '%s' has no BPMN counterpart", node.ID, eventType)
+}
+
+// exportDiagram exports the layout of the model as BPMN diagram
+func (e *bpmnExporter) exportDiagram() *xmlNode {
+	layout := e.model.Layout()
+	plane := newXMLNode("bpmndi:BPMNPlane", "id", "BPMNPlane_"+e.model.ID, "bpmnElement", e.model.ID)
+	for _, node := range e.model.Nodes {
+		bounds, exists := layout.Nodes[node.ID]
+		if !exists {
+			continue
+		}
+		shape := plane.add(newXMLNode("bpmndi:BPMNShape", "id", node.ID+"_di", "bpmnElement", node.ID))
+		if node.Type == NodeTypeSubProcess {
+			shape.attr("isExpanded", "true")
+		}
+		shape.add(newXMLNode("dc:Bounds",
+			"x", formatCoordinate(bounds.X), "y", formatCoordinate(bounds.Y),
+			"width", formatCoordinate(bounds.Width), "height", formatCoordinate(bounds.Height)))
+	}
+	for _, edge := range e.model.Edges {
+		waypoints := layout.Waypoints(edge)
+		if len(waypoints) == 0 {
+			continue
+		}
+		shape := plane.add(newXMLNode("bpmndi:BPMNEdge", "id", edge.ID+"_di", "bpmnElement", edge.ID))
+		for _, point := range waypoints {
+			shape.add(newXMLNode("di:waypoint", "x", formatCoordinate(point.X), "y", formatCoordinate(point.Y)))
+		}
+	}
+
+	diagram := newXMLNode("bpmndi:BPMNDiagram", "id", "BPMNDiagram_"+e.model.ID)
+	diagram.add(plane)
+	return diagram
+}
+
+// extensionValue formats a property as extension attribute value. Lists are comma
+// separated; objects are JSON.
+func extensionValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case []string:
+		return strings.Join(v, ","), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				content, err := json.Marshal(v)
+				return string(content), err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	}
+	content, err := json.Marshal(value)
+	return string(content), err
+}
+
+// bpmnRefID returns the ID of a message, signal or error element for its name,
+// replacing characters that are not valid in XML IDs
+func bpmnRefID(kind, name string) string {
+	id := []rune(kind + "_")
+	for _, r := range name {
+		if r == '_' || r == '-' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			id = append(id, r)
+		} else {
+			id = append(id, '_')
+		}
+	}
+	return string(id)
+}
+
+// formatCoordinate formats a coordinate of a diagram
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// sortedKeys returns the keys of a map in order, for a stable output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Hit policies of decision tables
+const (
+	HitPolicyUnique    = "UNIQUE"
+	HitPolicyFirst     = "FIRST"
+	HitPolicyAny       = "ANY"
+	HitPolicyRuleOrder = "RULE ORDER"
+	HitPolicyCollect   = "COLLECT"
+)
+
+// DecisionDefinition is the parsed form of a JSON decision table. Business rule
+// tasks reference a decision by its key with the "decisionRef" property.
+type DecisionDefinition struct {
+	Key       string            `json:"key"`
+	Name      string            `json:"name,omitempty"`
+	HitPolicy string            `json:"hitPolicy,omitempty"` // UNIQUE by default
+	Inputs    []*DecisionInput  `json:"inputs"`
+	Outputs   []*DecisionOutput `json:"outputs"`
+	Rules     []*DecisionRule   `json:"rules"`
+}
+
+// DecisionInput is a column of a decision table whose value the input entries of the
+// rules test. The expression is a variable name or an ${...} expression.
+type DecisionInput struct {
+	ID         string `json:"id"`
+	Label      string `json:"label,omitempty"`
+	Expression string `json:"expression"`
+}
+
+// DecisionOutput is a column of a decision table. Matching rules produce a result
+// entry named by the output.
+type DecisionOutput struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+	Name  string `json:"name"`
+}
+
+// DecisionRule is a row of a decision table. It matches when each input entry holds for
+// the value of its input, e.g. "< 1000", "[1..10]", "\"gold\",\"silver\"" or "-" for
+// any value. Output entries are literal values or ${...} expressions.
+type DecisionRule struct {
+	ID            string        `json:"id,omitempty"`
+	Description   string        `json:"description,omitempty"`
+	InputEntries  []string      `json:"inputEntries"`
+	OutputEntries []interface{} `json:"outputEntries"`
+}
+
+// ParseDecision parses and validates a JSON decision table
+func ParseDecision(content []byte) (*DecisionDefinition, error) {
+	var decision DecisionDefinition
+	if err := json.Unmarshal(content, &decision); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if decision.Key == "" {
+		return nil, fmt.Errorf("decision key cannot be empty")
+	}
+
+	switch decision.HitPolicy {
+	case "":
+		decision.HitPolicy = HitPolicyUnique
+	case HitPolicyUnique, HitPolicyFirst, HitPolicyAny, HitPolicyRuleOrder, HitPolicyCollect:
+	default:
+		return nil, fmt.Errorf("unsupported hit policy '%s' of decision '%s'", decision.HitPolicy, decision.Key)
+	}
+
+	for _, input := range decision.Inputs {
+		if input.Expression == "" {
+			return nil, fmt.Errorf("input '%s' of decision '%s' must have an expression", input.ID, decision.Key)
+		}
+	}
+	if len(decision.Outputs) == 0 {
+		return nil, fmt.Errorf("decision '%s' must have at least one output", decision.Key)
+	}
+	outputNames := make(map[string]bool)
+	for _, output := range decision.Outputs {
+		if output.Name == "" {
+			return nil, fmt.Errorf("output '%s' of decision '%s' must have a name", output.ID, decision.Key)
+		}
+		if outputNames[output.Name] {
+			return nil, fmt.Errorf("duplicate output name '%s' of decision '%s'", output.Name, decision.Key)
+		}
+		outputNames[output.Name] = true
+	}
+
+	for i, rule := range decision.Rules {
+		if len(rule.InputEntries) != len(decision.Inputs) {
+			return nil, fmt.Errorf("rule %d of decision '%s' has %d input entries, expected %d", i+1, decision.Key, len(rule.InputEntries), len(decision.Inputs))
+		}
+		if len(rule.OutputEntries) != len(decision.Outputs) {
+			return nil, fmt.Errorf("rule %d of decision '%s' has %d output entries, expected %d", i+1, decision.Key, len(rule.OutputEntries), len(decision.Outputs))
+		}
+	}
+	return &decision, nil
+}
@@ -0,0 +1,210 @@
+package model
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Diagram styles
+const (
+	diagramStroke          = "#333333"
+	diagramFill            = "#ffffff"
+	diagramHighlightStroke = "#e53935"
+	diagramHighlightFill   = "#ffebee"
+	diagramFontSize        = 12
+	diagramCharWidth       = 7 // approximate width of a character of the font, for wrapping labels
+)
+
+// RenderSVG renders the model as an SVG diagram with the layout of its nodes. The nodes
+// with the given IDs are highlighted, e.g. the active activities of a process instance.
+func (m *ProcessModel) RenderSVG(highlighted ...string) []byte {
+	layout := m.Layout()
+	highlight := make(map[string]bool, len(highlighted))
+	for _, id := range highlighted {
+		highlight[id] = true
+	}
+
+	width, height := 0.0, 0.0
+	extend := func(x, y float64) {
+		width, height = max(width, x), max(height, y)
+	}
+	for _, bounds := range layout.Nodes {
+		extend(bounds.X+bounds.Width, bounds.Y+bounds.Height)
+	}
+
+	edges := &bytes.Buffer{}
+	for _, edge := range m.Edges {
+		waypoints := layout.Waypoints(edge)
+		if len(waypoints) < 2 {
+			continue
+		}
+		points := make([]string, len(waypoints))
+		for i, point := range waypoints {
+			points[i] = formatCoordinate(point.X) + "," + formatCoordinate(point.Y)
+			extend(point.X, point.Y)
+		}
+		fmt.Fprintf(edges, `<polyline id="%s" points="%s" fill="none" stroke="%s" stroke-width="1.5" marker-end="url(#arrow)"/>`+"\n",
+			escapeXML(edge.ID), strings.Join(points, " "), diagramStroke)
+		if edge.Name != "" {
+			fmt.Fprintf(edges, `<text x="%s" y="%s" font-size="%d">%s</text>`+"\n",
+				formatCoordinate(waypoints[0].X+5), formatCoordinate(waypoints[0].Y-5), diagramFontSize, escapeXML(edge.Name))
+		}
+	}
+
+	nodes := &bytes.Buffer{}
+	for _, node := range m.diagramOrder() {
+		bounds, exists := layout.Nodes[node.ID]
+		if !exists {
+			continue
+		}
+		renderNode(nodes, node, bounds, highlight[node.ID])
+	}
+
+	svg := &bytes.Buffer{}
+	fmt.Fprintf(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="0 0 %s %s" font-family="Arial, sans-serif">`+"\n",
+		formatCoordinate(width+layoutMargin), formatCoordinate(height+layoutMargin),
+		formatCoordinate(width+layoutMargin), formatCoordinate(height+layoutMargin))
+	fmt.Fprintf(svg, `<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto"><path d="M0,0 L10,5 L0,10 z" fill="%s"/></marker></defs>`+"\n", diagramStroke)
+	svg.Write(edges.Bytes())
+	svg.Write(nodes.Bytes())
+	svg.WriteString("</svg>\n")
+	return svg.Bytes()
+}
+
+// diagramOrder returns the nodes in drawing order: subprocesses before the nodes inside
+// them and boundary events after the nodes they are attached to
+func (m *ProcessModel) diagramOrder() []*Node {
+	depth := make(map[string]int, len(m.Nodes))
+	for _, node := range m.Nodes {
+		for parent := m.GetNode(node.ParentID); parent != nil && depth[node.ID] < len(m.Nodes); parent = m.GetNode(parent.ParentID) {
+			depth[node.ID]++
+		}
+		if node.Type == NodeTypeBoundaryEvent {
+			depth[node.ID] += len(m.Nodes)
+		}
+	}
+
+	ordered := append([]*Node(nil), m.Nodes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return depth[ordered[i].ID] < depth[ordered[j].ID]
+	})
+	return ordered
+}
+
+// renderNode draws the shape of a node with its label
+func renderNode(buf *bytes.Buffer, node *Node, bounds Bounds, highlighted bool) {
+	stroke, fill, strokeWidth := diagramStroke, diagramFill, 1.5
+	class := node.Type
+	if highlighted {
+		stroke, fill, strokeWidth = diagramHighlightStroke, diagramHighlightFill, 3
+		class += " highlighted"
+	}
+	switch node.Type {
+	case NodeTypeEndEvent, NodeTypeCallActivity:
+		strokeWidth += 2
+	case NodeTypeSubProcess:
+		// The nodes inside are drawn on top of the subprocess
+		fill = "none"
+	}
+	style := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%s"`, fill, stroke, formatCoordinate(strokeWidth))
+	center := bounds.Center()
+	x, y, w, h := formatCoordinate(bounds.X), formatCoordinate(bounds.Y), formatCoordinate(bounds.Width), formatCoordinate(bounds.Height)
+
+	fmt.Fprintf(buf, `<g id="%s" class="%s">`+"\n", escapeXML(node.ID), class)
+	switch node.Type {
+	case NodeTypeStartEvent, NodeTypeEndEvent, NodeTypeIntermediateEvent, NodeTypeBoundaryEvent:
+		r := bounds.Width / 2
+		fmt.Fprintf(buf, `<circle cx="%s" cy="%s" r="%s" %s/>`+"\n", formatCoordinate(center.X), formatCoordinate(center.Y), formatCoordinate(r), style)
+		if node.Type == NodeTypeIntermediateEvent || node.Type == NodeTypeBoundaryEvent {
+			fmt.Fprintf(buf, `<circle cx="%s" cy="%s" r="%s" fill="none" stroke="%s"/>`+"\n", formatCoordinate(center.X), formatCoordinate(center.Y), formatCoordinate(r-3), stroke)
+		}
+		renderLabel(buf, node.Name, center.X, bounds.Y+bounds.Height+diagramFontSize+2, bounds.Width*3)
+	case NodeTypeExclusiveGateway, NodeTypeParallelGateway, NodeTypeInclusiveGateway, NodeTypeEventBasedGateway:
+		fmt.Fprintf(buf, `<polygon points="%s,%s %s,%s %s,%s %s,%s" %s/>`+"\n",
+			formatCoordinate(center.X), y, formatCoordinate(bounds.X+bounds.Width), formatCoordinate(center.Y),
+			formatCoordinate(center.X), formatCoordinate(bounds.Y+bounds.Height), x, formatCoordinate(center.Y), style)
+		renderGatewayMarker(buf, node.Type, center, bounds.Width/5, stroke)
+		renderLabel(buf, node.Name, center.X, bounds.Y+bounds.Height+diagramFontSize+2, bounds.Width*3)
+	case NodeTypeSubProcess:
+		fmt.Fprintf(buf, `<rect x="%s" y="%s" width="%s" height="%s" rx="10" ry="10" %s/>`+"\n", x, y, w, h, style)
+		renderLabel(buf, node.Name, center.X, bounds.Y+diagramFontSize+6, bounds.Width)
+	default:
+		fmt.Fprintf(buf, `<rect x="%s" y="%s" width="%s" height="%s" rx="10" ry="10" %s/>`+"\n", x, y, w, h, style)
+		renderLabel(buf, node.Name, center.X, center.Y, bounds.Width-10)
+	}
+	buf.WriteString("</g>\n")
+}
+
+// renderGatewayMarker draws the marker of a gateway type inside its diamond
+func renderGatewayMarker(buf *bytes.Buffer, nodeType string, center Point, size float64, stroke string) {
+	line := func(x1, y1, x2, y2 float64) {
+		fmt.Fprintf(buf, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="3"/>`+"\n",
+			formatCoordinate(x1), formatCoordinate(y1), formatCoordinate(x2), formatCoordinate(y2), stroke)
+	}
+	switch nodeType {
+	case NodeTypeExclusiveGateway:
+		line(center.X-size, center.Y-size, center.X+size, center.Y+size)
+		line(center.X-size, center.Y+size, center.X+size, center.Y-size)
+	case NodeTypeParallelGateway:
+		line(center.X, center.Y-size*1.3, center.X, center.Y+size*1.3)
+		line(center.X-size*1.3, center.Y, center.X+size*1.3, center.Y)
+	case NodeTypeInclusiveGateway:
+		fmt.Fprintf(buf, `<circle cx="%s" cy="%s" r="%s" fill="none" stroke="%s" stroke-width="3"/>`+"\n",
+			formatCoordinate(center.X), formatCoordinate(center.Y), formatCoordinate(size*1.2), stroke)
+	case NodeTypeEventBasedGateway:
+		for _, r := range []float64{size * 1.3, size} {
+			fmt.Fprintf(buf, `<circle cx="%s" cy="%s" r="%s" fill="none" stroke="%s"/>`+"\n",
+				formatCoordinate(center.X), formatCoordinate(center.Y), formatCoordinate(r), stroke)
+		}
+	}
+}
+
+// renderLabel draws a label centered on a point, wrapped to lines of at most maxWidth
+func renderLabel(buf *bytes.Buffer, label string, centerX, centerY, maxWidth float64) {
+	if label == "" {
+		return
+	}
+	lines := wrapLabel(label, int(math.Max(1, maxWidth/diagramCharWidth)))
+	top := centerY - float64(len(lines)-1)*diagramFontSize/2
+	fmt.Fprintf(buf, `<text x="%s" y="%s" font-size="%d" text-anchor="middle" dominant-baseline="middle">`,
+		formatCoordinate(centerX), formatCoordinate(top), diagramFontSize)
+	for i, line := range lines {
+		dy := "0"
+		if i > 0 {
+			dy = formatCoordinate(diagramFontSize)
+		}
+		fmt.Fprintf(buf, `<tspan x="%s" dy="%s">%s</tspan>`, formatCoordinate(centerX), dy, escapeXML(line))
+	}
+	buf.WriteString("</text>\n")
+}
+
+// wrapLabel splits a label into lines of at most maxChars characters at word boundaries
+func wrapLabel(label string, maxChars int) []string {
+	lines := make([]string, 0)
+	current := ""
+	for _, word := range strings.Fields(label) {
+		if current != "" && len([]rune(current))+1+len([]rune(word)) > maxChars {
+			lines = append(lines, current)
+			current = ""
+		}
+		if current != "" {
+			current += " "
+		}
+		current += word
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// escapeXML escapes text for XML content and attributes
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// Form field types
+const (
+	FormFieldTypeString  = "string"
+	FormFieldTypeLong    = "long"
+	FormFieldTypeDouble  = "double"
+	FormFieldTypeBoolean = "boolean"
+	FormFieldTypeDate    = "date"
+	FormFieldTypeEnum    = "enum"
+)
+
+// FormDefinition is the parsed form of a JSON form definition. Start events and user
+// tasks reference a form by its key with the "formKey" property.
+type FormDefinition struct {
+	Key    string       `json:"key"`
+	Name   string       `json:"name,omitempty"`
+	Fields []*FormField `json:"fields"`
+}
+
+// FormField is an input of a form. The value of a field is stored in the
+// variable named by its ID.
+type FormField struct {
+	ID           string       `json:"id"`
+	Label        string       `json:"label,omitempty"`
+	Type         string       `json:"type"`
+	DefaultValue interface{}  `json:"defaultValue,omitempty"`
+	Readonly     bool         `json:"readonly,omitempty"`
+	Options      []FormOption `json:"options,omitempty"` // values of an enum field
+	Validation   *Validation  `json:"validation,omitempty"`
+}
+
+// FormOption is a value of an enum field
+type FormOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label,omitempty"`
+}
+
+// Validation holds the constraints of a form field. Min and Max bound the value of
+// numeric fields; MinLength, MaxLength and Pattern constrain string fields.
+type Validation struct {
+	Required  bool     `json:"required,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+}
+
+// ParseForm parses and validates a JSON form definition
+func ParseForm(content []byte) (*FormDefinition, error) {
+	var form FormDefinition
+	if err := json.Unmarshal(content, &form); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if form.Key == "" {
+		return nil, fmt.Errorf("form key cannot be empty")
+	}
+
+	fieldIDs := make(map[string]bool)
+	for _, field := range form.Fields {
+		if field.ID == "" {
+			return nil, fmt.Errorf("form field ID cannot be empty")
+		}
+		if fieldIDs[field.ID] {
+			return nil, fmt.Errorf("duplicate form field ID: %s", field.ID)
+		}
+		fieldIDs[field.ID] = true
+
+		switch field.Type {
+		case FormFieldTypeString, FormFieldTypeLong, FormFieldTypeDouble, FormFieldTypeBoolean, FormFieldTypeDate:
+		case FormFieldTypeEnum:
+			if len(field.Options) == 0 {
+				return nil, fmt.Errorf("enum form field '%s' must have options", field.ID)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported type '%s' of form field '%s'", field.Type, field.ID)
+		}
+
+		if field.Validation != nil && field.Validation.Pattern != "" {
+			if _, err := regexp.Compile(field.Validation.Pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern of form field '%s': %w", field.ID, err)
+			}
+		}
+	}
+	return &form, nil
+}
+
+// GetField returns the field with the given ID, or nil if it does not exist
+func (f *FormDefinition) GetField(fieldID string) *FormField {
+	for _, field := range f.Fields {
+		if field.ID == fieldID {
+			return field
+		}
+	}
+	return nil
+}
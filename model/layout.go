@@ -0,0 +1,275 @@
+package model
+
+// Bounds is the position and size of a node in a diagram, in pixels from the top left
+type Bounds struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Point is a position in a diagram
+type Point struct {
+	X float64
+	Y float64
+}
+
+// Center returns the center of the bounds
+func (b Bounds) Center() Point {
+	return Point{X: b.X + b.Width/2, Y: b.Y + b.Height/2}
+}
+
+// Layout spacing, in pixels
+const (
+	layoutMargin        = 40
+	layoutColumnSpacing = 60
+	layoutRowSpacing    = 40
+	layoutScopePadding  = 30
+)
+
+// Layout is the position of every node of a process model in a diagram
+type Layout struct {
+	Nodes map[string]Bounds
+	model *ProcessModel
+}
+
+// Layout positions the nodes of the model. Nodes with bounds, e.g. imported from a
+// BPMN diagram, keep them; models without bounds are laid out automatically from left
+// to right, one column per step from the start events, with subprocesses expanded.
+func (m *ProcessModel) Layout() *Layout {
+	layout := &Layout{Nodes: make(map[string]Bounds), model: m}
+	if m.hasBounds() {
+		for _, node := range m.Nodes {
+			if node.Bounds != nil {
+				layout.Nodes[node.ID] = *node.Bounds
+			} else {
+				layout.Nodes[node.ID] = Bounds{Width: nodeWidth(node.Type), Height: nodeHeight(node.Type)}
+			}
+		}
+		return layout
+	}
+
+	m.layoutScope(layout, "", layoutMargin, layoutMargin)
+	m.layoutBoundaryEvents(layout)
+	return layout
+}
+
+// hasBounds reports whether any node of the model has bounds
+func (m *ProcessModel) hasBounds() bool {
+	for _, node := range m.Nodes {
+		if node.Bounds != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutScope lays out the nodes of a process or subprocess from an origin and returns
+// the size they take
+func (m *ProcessModel) layoutScope(layout *Layout, parentID string, originX, originY float64) (float64, float64) {
+	nodes := make([]*Node, 0)
+	inScope := make(map[string]bool)
+	for _, node := range m.GetChildNodes(parentID) {
+		if node.Type != NodeTypeBoundaryEvent {
+			nodes = append(nodes, node)
+			inScope[node.ID] = true
+		}
+	}
+
+	// Sequence flows leaving boundary events continue from the node they are attached to
+	source := func(edge *Edge) string {
+		if node := m.GetNode(edge.Source); node != nil && node.Type == NodeTypeBoundaryEvent {
+			return node.GetString("attachedTo")
+		}
+		return edge.Source
+	}
+
+	// Columns are the breadth-first distances from the nodes without incoming flows
+	column := make(map[string]int)
+	queue := make([]string, 0)
+	for _, node := range nodes {
+		if len(m.GetIncomingEdges(node.ID)) == 0 {
+			column[node.ID] = 0
+			queue = append(queue, node.ID)
+		}
+	}
+	for len(queue) > 0 || len(column) < len(nodes) {
+		if len(queue) == 0 {
+			// Nodes only reachable through cycles start a new column
+			for _, node := range nodes {
+				if _, placed := column[node.ID]; !placed {
+					column[node.ID] = 0
+					queue = append(queue, node.ID)
+					break
+				}
+			}
+		}
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range m.Edges {
+			if source(edge) != current || !inScope[edge.Target] {
+				continue
+			}
+			if _, placed := column[edge.Target]; !placed {
+				column[edge.Target] = column[current] + 1
+				queue = append(queue, edge.Target)
+			}
+		}
+	}
+
+	// Size the nodes, laying out subprocesses first
+	sizes := make(map[string]Bounds)
+	columns := make([][]*Node, 0)
+	for _, node := range nodes {
+		size := Bounds{Width: nodeWidth(node.Type), Height: nodeHeight(node.Type)}
+		if node.Type == NodeTypeSubProcess && len(m.GetChildNodes(node.ID)) > 0 {
+			width, height := m.layoutScope(layout, node.ID, 0, 0)
+			size = Bounds{Width: width + 2*layoutScopePadding, Height: height + 2*layoutScopePadding}
+		}
+		sizes[node.ID] = size
+
+		for len(columns) <= column[node.ID] {
+			columns = append(columns, nil)
+		}
+		columns[column[node.ID]] = append(columns[column[node.ID]], node)
+	}
+
+	// Columns are as wide as their widest node, rows as high as their highest node
+	columnWidths := make([]float64, len(columns))
+	rowHeights := make([]float64, 0)
+	for c, columnNodes := range columns {
+		for r, node := range columnNodes {
+			columnWidths[c] = max(columnWidths[c], sizes[node.ID].Width)
+			if r == len(rowHeights) {
+				rowHeights = append(rowHeights, 0)
+			}
+			rowHeights[r] = max(rowHeights[r], sizes[node.ID].Height)
+		}
+	}
+
+	x := originX
+	var width, height float64
+	for c, columnNodes := range columns {
+		y := originY
+		for r, node := range columnNodes {
+			size := sizes[node.ID]
+			bounds := Bounds{
+				X:      x + (columnWidths[c]-size.Width)/2,
+				Y:      y + (rowHeights[r]-size.Height)/2,
+				Width:  size.Width,
+				Height: size.Height,
+			}
+			if node.Type == NodeTypeSubProcess {
+				m.moveScope(layout, node.ID, bounds.X+layoutScopePadding, bounds.Y+layoutScopePadding)
+			}
+			layout.Nodes[node.ID] = bounds
+			y += rowHeights[r] + layoutRowSpacing
+		}
+		width = x + columnWidths[c] - originX
+		height = max(height, y-layoutRowSpacing-originY)
+		x += columnWidths[c] + layoutColumnSpacing
+	}
+	return width, height
+}
+
+// moveScope moves the laid out nodes of a subprocess by an offset
+func (m *ProcessModel) moveScope(layout *Layout, parentID string, dx, dy float64) {
+	for _, node := range m.GetChildNodes(parentID) {
+		bounds, exists := layout.Nodes[node.ID]
+		if !exists {
+			continue
+		}
+		bounds.X += dx
+		bounds.Y += dy
+		layout.Nodes[node.ID] = bounds
+		if node.Type == NodeTypeSubProcess {
+			m.moveScope(layout, node.ID, dx, dy)
+		}
+	}
+}
+
+// layoutBoundaryEvents places boundary events on the bottom border of the nodes they
+// are attached to, from right to left
+func (m *ProcessModel) layoutBoundaryEvents(layout *Layout) {
+	attached := make(map[string]int)
+	for _, node := range m.GetNodesByType(NodeTypeBoundaryEvent) {
+		host, exists := layout.Nodes[node.GetString("attachedTo")]
+		if !exists {
+			continue
+		}
+		size := nodeWidth(NodeTypeBoundaryEvent)
+		index := attached[node.GetString("attachedTo")]
+		attached[node.GetString("attachedTo")]++
+		layout.Nodes[node.ID] = Bounds{
+			X:      host.X + host.Width - size - 10 - float64(index)*(size+10),
+			Y:      host.Y + host.Height - size/2,
+			Width:  size,
+			Height: size,
+		}
+	}
+}
+
+// Waypoints returns the points of a sequence flow from its source to its target. Flows
+// in line run straight; flows splitting into other rows leave vertically first, other
+// flows turn into their target from above or below; flows going back loop below.
+func (l *Layout) Waypoints(edge *Edge) []Point {
+	source, sourceExists := l.Nodes[edge.Source]
+	target, targetExists := l.Nodes[edge.Target]
+	if !sourceExists || !targetExists {
+		return nil
+	}
+	sourceCenter, targetCenter := source.Center(), target.Center()
+
+	if target.X < source.X+source.Width {
+		// Loop back below both nodes
+		bottom := max(source.Y+source.Height, target.Y+target.Height) + layoutRowSpacing/2
+		return []Point{
+			{X: sourceCenter.X, Y: source.Y + source.Height},
+			{X: sourceCenter.X, Y: bottom},
+			{X: targetCenter.X, Y: bottom},
+			{X: targetCenter.X, Y: target.Y + target.Height},
+		}
+	}
+
+	from := Point{X: source.X + source.Width, Y: sourceCenter.Y}
+	to := Point{X: target.X, Y: targetCenter.Y}
+	if sourceCenter.Y >= target.Y && sourceCenter.Y <= target.Y+target.Height {
+		to.Y = from.Y
+		return []Point{from, to}
+	}
+
+	below := targetCenter.Y > sourceCenter.Y
+	if len(l.model.GetOutgoingEdges(edge.Source)) > 1 {
+		from = Point{X: sourceCenter.X, Y: source.Y}
+		if below {
+			from.Y = source.Y + source.Height
+		}
+		return []Point{from, {X: from.X, Y: to.Y}, to}
+	}
+	to = Point{X: targetCenter.X, Y: target.Y + target.Height}
+	if below {
+		to.Y = target.Y
+	}
+	return []Point{from, {X: to.X, Y: from.Y}, to}
+}
+
+// nodeWidth returns the default width of nodes of a type
+func nodeWidth(nodeType string) float64 {
+	switch nodeType {
+	case NodeTypeStartEvent, NodeTypeEndEvent, NodeTypeIntermediateEvent, NodeTypeBoundaryEvent:
+		return 36
+	case NodeTypeExclusiveGateway, NodeTypeParallelGateway, NodeTypeInclusiveGateway, NodeTypeEventBasedGateway:
+		return 50
+	}
+	return 100
+}
+
+// nodeHeight returns the default height of nodes of a type
+func nodeHeight(nodeType string) float64 {
+	switch nodeType {
+	case NodeTypeStartEvent, NodeTypeEndEvent, NodeTypeIntermediateEvent, NodeTypeBoundaryEvent,
+		NodeTypeExclusiveGateway, NodeTypeParallelGateway, NodeTypeInclusiveGateway, NodeTypeEventBasedGateway:
+		return nodeWidth(nodeType)
+	}
+	return 80
+}
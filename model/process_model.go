@@ -0,0 +1,204 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Node types supported in process definitions
+const (
+	NodeTypeStartEvent        = "startEvent"
+	NodeTypeEndEvent          = "endEvent"
+	NodeTypeUserTask          = "userTask"
+	NodeTypeServiceTask       = "serviceTask"
+	NodeTypeScriptTask        = "scriptTask"
+	NodeTypeBusinessRuleTask  = "businessRuleTask"
+	NodeTypeCallActivity      = "callActivity"
+	NodeTypeSubProcess        = "subProcess"
+	NodeTypeExclusiveGateway  = "exclusiveGateway"
+	NodeTypeParallelGateway   = "parallelGateway"
+	NodeTypeInclusiveGateway  = "inclusiveGateway"
+	NodeTypeEventBasedGateway = "eventBasedGateway"
+	NodeTypeIntermediateEvent = "intermediateEvent"
+	NodeTypeBoundaryEvent     = "boundaryEvent"
+	NodeTypeTemplate          = "template"
+)
+
+// ProcessModel is the parsed form of a JSON process definition
+type ProcessModel struct {
+	ID          string                 `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Version     int                    `json:"version,omitempty"`
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	Nodes       []*Node                `json:"nodes"`
+	Edges       []*Edge                `json:"edges"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+
+	// ProcessInstanceName names the process instances started without a name, e.g.
+	// "Order ${orderId}"; an expression is evaluated with the start variables
+	ProcessInstanceName string `json:"processInstanceName,omitempty"`
+
+	// HistoryTimeToLive is the number of days the history of finished instances is
+	// kept before history cleanup removes it; nil keeps it forever
+	HistoryTimeToLive *int `json:"historyTimeToLive,omitempty"`
+}
+
+// Node is an activity, event or gateway in a process model.
+// Nodes inside a subprocess reference the subprocess node through ParentID.
+type Node struct {
+	ID                string                 `json:"id"`
+	Type              string                 `json:"type"`
+	ParentID          string                 `json:"parentId,omitempty"`
+	Name              string                 `json:"name,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	Properties        map[string]interface{} `json:"properties,omitempty"`
+	InputMappings     map[string]string      `json:"inputMappings,omitempty"`
+	OutputMappings    map[string]string      `json:"outputMappings,omitempty"`
+	ExtensionElements map[string]interface{} `json:"extensionElements,omitempty"`
+
+	// Bounds is the position of the node in diagrams; nil lays the model out automatically
+	Bounds *Bounds `json:"bounds,omitempty"`
+}
+
+// Edge is a sequence flow connecting two nodes
+type Edge struct {
+	ID                string                 `json:"id"`
+	Name              string                 `json:"name,omitempty"`
+	Source            string                 `json:"source"`
+	Target            string                 `json:"target"`
+	Condition         string                 `json:"condition,omitempty"`
+	IsDefault         bool                   `json:"isDefault,omitempty"`
+	ExtensionElements map[string]interface{} `json:"extensionElements,omitempty"`
+}
+
+// Parse parses a JSON process definition into a ProcessModel
+func Parse(content []byte) (*ProcessModel, error) {
+	var processModel ProcessModel
+	if err := json.Unmarshal(content, &processModel); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return &processModel, nil
+}
+
+// Marshal serializes the process model back to JSON
+func (m *ProcessModel) Marshal() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// GetNode returns the node with the given ID, or nil if it does not exist
+func (m *ProcessModel) GetNode(nodeID string) *Node {
+	for _, node := range m.Nodes {
+		if node.ID == nodeID {
+			return node
+		}
+	}
+	return nil
+}
+
+// GetEdge returns the edge with the given ID, or nil if it does not exist
+func (m *ProcessModel) GetEdge(edgeID string) *Edge {
+	for _, edge := range m.Edges {
+		if edge.ID == edgeID {
+			return edge
+		}
+	}
+	return nil
+}
+
+// GetNodesByType returns all nodes of the given type
+func (m *ProcessModel) GetNodesByType(nodeType string) []*Node {
+	nodes := make([]*Node, 0)
+	for _, node := range m.Nodes {
+		if node.Type == nodeType {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// GetChildNodes returns the nodes directly inside a subprocess.
+// An empty parentID returns the top-level nodes of the process.
+func (m *ProcessModel) GetChildNodes(parentID string) []*Node {
+	nodes := make([]*Node, 0)
+	for _, node := range m.Nodes {
+		if node.ParentID == parentID {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// GetOutgoingEdges returns the edges leaving a node
+func (m *ProcessModel) GetOutgoingEdges(nodeID string) []*Edge {
+	edges := make([]*Edge, 0)
+	for _, edge := range m.Edges {
+		if edge.Source == nodeID {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// GetIncomingEdges returns the edges entering a node
+func (m *ProcessModel) GetIncomingEdges(nodeID string) []*Edge {
+	edges := make([]*Edge, 0)
+	for _, edge := range m.Edges {
+		if edge.Target == nodeID {
+			edges = append(edges, edge)
+		}
+	}
+	return edges
+}
+
+// GetString returns a string property of the node
+func (n *Node) GetString(key string) string {
+	value, _ := n.Properties[key].(string)
+	return value
+}
+
+// GetBool returns a boolean property of the node
+func (n *Node) GetBool(key string) bool {
+	value, _ := n.Properties[key].(bool)
+	return value
+}
+
+// GetInt returns an integer property of the node and whether it was set
+func (n *Node) GetInt(key string) (int, bool) {
+	switch value := n.Properties[key].(type) {
+	case int:
+		return value, true
+	case int64:
+		return int(value), true
+	case float64:
+		return int(value), true
+	}
+	return 0, false
+}
+
+// GetStringSlice returns a string list property of the node
+func (n *Node) GetStringSlice(key string) []string {
+	switch value := n.Properties[key].(type) {
+	case []string:
+		return value
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	}
+	return nil
+}
+
+// GetMap returns a nested object property of the node
+func (n *Node) GetMap(key string) map[string]interface{} {
+	value, _ := n.Properties[key].(map[string]interface{})
+	return value
+}
@@ -0,0 +1,297 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Built-in template names
+const (
+	TemplateApprovalChain       = "approvalChain"
+	TemplateRetryWithEscalation = "retryWithEscalation"
+)
+
+// maxTemplateExpansionDepth limits nested template expansion to catch templates that expand into themselves
+const maxTemplateExpansionDepth = 16
+
+// Template is a parameterized process fragment that a "template" node expands into
+type Template interface {
+	// Expand builds the fragment for a template node. Node and edge IDs of the
+	// fragment should be prefixed with nodeID to stay unique within the model.
+	Expand(nodeID string, parameters map[string]interface{}) (*Fragment, error)
+}
+
+// TemplateFunc adapts a function to the Template interface
+type TemplateFunc func(nodeID string, parameters map[string]interface{}) (*Fragment, error)
+
+// Expand calls f(nodeID, parameters)
+func (f TemplateFunc) Expand(nodeID string, parameters map[string]interface{}) (*Fragment, error) {
+	return f(nodeID, parameters)
+}
+
+// Fragment is the result of expanding a template
+type Fragment struct {
+	Nodes []*Node
+	Edges []*Edge
+
+	// Entry is the node that incoming edges of the template node are connected to
+	Entry string
+
+	// Exit is the node that outgoing edges of the template node leave from
+	Exit string
+}
+
+// TemplateRegistry holds the templates available to process definitions
+type TemplateRegistry struct {
+	templates map[string]Template
+	mu        sync.RWMutex
+}
+
+// NewTemplateRegistry creates a registry with the built-in templates registered
+func NewTemplateRegistry() *TemplateRegistry {
+	r := &TemplateRegistry{
+		templates: make(map[string]Template),
+	}
+	r.templates[TemplateApprovalChain] = TemplateFunc(expandApprovalChain)
+	r.templates[TemplateRetryWithEscalation] = TemplateFunc(expandRetryWithEscalation)
+	return r
+}
+
+// Register registers a template under a name, replacing any existing template with that name
+func (r *TemplateRegistry) Register(name string, template Template) error {
+	if name == "" {
+		return fmt.Errorf("template name cannot be empty")
+	}
+	if template == nil {
+		return fmt.Errorf("template cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.templates[name] = template
+	return nil
+}
+
+// Get returns the template registered under a name
+func (r *TemplateRegistry) Get(name string) (Template, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	template, exists := r.templates[name]
+	return template, exists
+}
+
+// Expand replaces all template nodes of a model with their fragments.
+// Templates may produce further template nodes, which are expanded as well.
+func (r *TemplateRegistry) Expand(m *ProcessModel) error {
+	for depth := 0; depth < maxTemplateExpansionDepth; depth++ {
+		templateNodes := m.GetNodesByType(NodeTypeTemplate)
+		if len(templateNodes) == 0 {
+			return nil
+		}
+
+		for _, node := range templateNodes {
+			if err := r.expandNode(m, node); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("template expansion exceeded maximum depth of %d", maxTemplateExpansionDepth)
+}
+
+// expandNode replaces a single template node with its fragment and rewires the edges
+func (r *TemplateRegistry) expandNode(m *ProcessModel, node *Node) error {
+	name := node.GetString("template")
+	if name == "" {
+		return fmt.Errorf("template node '%s' must have a 'template' property", node.ID)
+	}
+
+	template, exists := r.Get(name)
+	if !exists {
+		return fmt.Errorf("template node '%s' references unknown template '%s'", node.ID, name)
+	}
+
+	fragment, err := template.Expand(node.ID, node.GetMap("parameters"))
+	if err != nil {
+		return fmt.Errorf("failed to expand template '%s' for node '%s': %w", name, node.ID, err)
+	}
+	if fragment.Entry == "" || fragment.Exit == "" {
+		return fmt.Errorf("template '%s' did not define entry and exit nodes", name)
+	}
+
+	for _, fragmentNode := range fragment.Nodes {
+		if existing := m.GetNode(fragmentNode.ID); existing != nil && existing != node {
+			return fmt.Errorf("template '%s' produced node '%s' which already exists", name, fragmentNode.ID)
+		}
+	}
+
+	// Fragments placed inside a subprocess belong to that subprocess
+	for _, fragmentNode := range fragment.Nodes {
+		if fragmentNode.ParentID == "" {
+			fragmentNode.ParentID = node.ParentID
+		}
+	}
+
+	nodes := make([]*Node, 0, len(m.Nodes)+len(fragment.Nodes))
+	for _, n := range m.Nodes {
+		if n == node {
+			nodes = append(nodes, fragment.Nodes...)
+			continue
+		}
+		nodes = append(nodes, n)
+	}
+	m.Nodes = nodes
+
+	for _, edge := range m.Edges {
+		if edge.Target == node.ID {
+			edge.Target = fragment.Entry
+		}
+		if edge.Source == node.ID {
+			edge.Source = fragment.Exit
+		}
+	}
+	m.Edges = append(m.Edges, fragment.Edges...)
+
+	return nil
+}
+
+// expandApprovalChain builds a sequence of approval user tasks, one per level.
+//
+// Parameters:
+//   - levels: number of approval levels (required)
+//   - candidateGroups: candidate group per level (optional)
+//   - name: base name of the approval tasks (optional)
+func expandApprovalChain(nodeID string, parameters map[string]interface{}) (*Fragment, error) {
+	params := &Node{Properties: parameters}
+
+	levels, ok := params.GetInt("levels")
+	if !ok || levels < 1 {
+		return nil, fmt.Errorf("parameter 'levels' must be a positive integer")
+	}
+
+	groups := params.GetStringSlice("candidateGroups")
+	if len(groups) > 0 && len(groups) < levels {
+		return nil, fmt.Errorf("parameter 'candidateGroups' must list a group for each of the %d levels", levels)
+	}
+
+	name := params.GetString("name")
+	if name == "" {
+		name = "Approval"
+	}
+
+	fragment := &Fragment{}
+	for level := 1; level <= levels; level++ {
+		properties := make(map[string]interface{})
+		if len(groups) > 0 {
+			properties["candidateGroups"] = []interface{}{groups[level-1]}
+		}
+
+		fragment.Nodes = append(fragment.Nodes, &Node{
+			ID:         fmt.Sprintf("%s-level-%d", nodeID, level),
+			Type:       NodeTypeUserTask,
+			Name:       fmt.Sprintf("%s (level %d)", name, level),
+			Properties: properties,
+		})
+
+		if level > 1 {
+			fragment.Edges = append(fragment.Edges, &Edge{
+				ID:     fmt.Sprintf("%s-flow-%d", nodeID, level-1),
+				Source: fmt.Sprintf("%s-level-%d", nodeID, level-1),
+				Target: fmt.Sprintf("%s-level-%d", nodeID, level),
+			})
+		}
+	}
+
+	fragment.Entry = fragment.Nodes[0].ID
+	fragment.Exit = fragment.Nodes[len(fragment.Nodes)-1].ID
+	return fragment, nil
+}
+
+// expandRetryWithEscalation builds a service task that, once its retries are
+// exhausted, escalates to a user task from which the work can be retried.
+//
+// Parameters:
+//   - implementation: service task implementation (required)
+//   - retries: number of automatic retries (optional, default 3)
+//   - retryInterval: time between retries (optional)
+//   - escalationGroup: candidate group of the escalation task (required)
+//   - retryVariable: variable deciding whether to retry after escalation (optional, default "retry")
+func expandRetryWithEscalation(nodeID string, parameters map[string]interface{}) (*Fragment, error) {
+	params := &Node{Properties: parameters}
+
+	implementation := params.GetString("implementation")
+	if implementation == "" {
+		return nil, fmt.Errorf("parameter 'implementation' is required")
+	}
+
+	escalationGroup := params.GetString("escalationGroup")
+	if escalationGroup == "" {
+		return nil, fmt.Errorf("parameter 'escalationGroup' is required")
+	}
+
+	retries, ok := params.GetInt("retries")
+	if !ok {
+		retries = 3
+	}
+
+	retryVariable := params.GetString("retryVariable")
+	if retryVariable == "" {
+		retryVariable = "retry"
+	}
+
+	work := &Node{
+		ID:   nodeID + "-work",
+		Type: NodeTypeServiceTask,
+		Name: params.GetString("name"),
+		Properties: map[string]interface{}{
+			"implementation": implementation,
+			"retries":        retries,
+		},
+	}
+	if retryInterval := params.GetString("retryInterval"); retryInterval != "" {
+		work.Properties["retryInterval"] = retryInterval
+	}
+
+	failed := &Node{
+		ID:   nodeID + "-failed",
+		Type: NodeTypeBoundaryEvent,
+		Properties: map[string]interface{}{
+			"eventType":      "error",
+			"attachedTo":     work.ID,
+			"cancelActivity": true,
+		},
+	}
+
+	escalate := &Node{
+		ID:   nodeID + "-escalate",
+		Type: NodeTypeUserTask,
+		Name: "Resolve failed work",
+		Properties: map[string]interface{}{
+			"candidateGroups": []interface{}{escalationGroup},
+		},
+	}
+
+	decide := &Node{
+		ID:   nodeID + "-decide",
+		Type: NodeTypeExclusiveGateway,
+	}
+
+	done := &Node{
+		ID:   nodeID + "-done",
+		Type: NodeTypeExclusiveGateway,
+	}
+
+	return &Fragment{
+		Nodes: []*Node{work, failed, escalate, decide, done},
+		Edges: []*Edge{
+			{ID: nodeID + "-flow-work-done", Source: work.ID, Target: done.ID},
+			{ID: nodeID + "-flow-failed-escalate", Source: failed.ID, Target: escalate.ID},
+			{ID: nodeID + "-flow-escalate-decide", Source: escalate.ID, Target: decide.ID},
+			{ID: nodeID + "-flow-retry", Source: decide.ID, Target: work.ID, Condition: fmt.Sprintf("${%s == true}", retryVariable)},
+			{ID: nodeID + "-flow-give-up", Source: decide.ID, Target: done.ID, IsDefault: true},
+		},
+		Entry: work.ID,
+		Exit:  done.ID,
+	}, nil
+}
@@ -0,0 +1,367 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// nodeTypes are the node types process models may use: the built-in types and the
+// types registered for custom activity behaviors
+var (
+	nodeTypes = map[string]bool{
+		NodeTypeStartEvent:        true,
+		NodeTypeEndEvent:          true,
+		NodeTypeUserTask:          true,
+		NodeTypeServiceTask:       true,
+		NodeTypeScriptTask:        true,
+		NodeTypeBusinessRuleTask:  true,
+		NodeTypeCallActivity:      true,
+		NodeTypeSubProcess:        true,
+		NodeTypeExclusiveGateway:  true,
+		NodeTypeParallelGateway:   true,
+		NodeTypeInclusiveGateway:  true,
+		NodeTypeEventBasedGateway: true,
+		NodeTypeIntermediateEvent: true,
+		NodeTypeBoundaryEvent:     true,
+		NodeTypeTemplate:          true,
+	}
+	nodeTypesMu sync.RWMutex
+)
+
+// RegisterNodeType makes a custom node type known to validation, e.g. the type of a
+// custom activity behavior
+func RegisterNodeType(nodeType string) {
+	nodeTypesMu.Lock()
+	defer nodeTypesMu.Unlock()
+
+	nodeTypes[nodeType] = true
+}
+
+// isKnownNodeType reports whether nodes of a type can be executed
+func isKnownNodeType(nodeType string) bool {
+	nodeTypesMu.RLock()
+	defer nodeTypesMu.RUnlock()
+
+	return nodeTypes[nodeType]
+}
+
+// eventTypes are the event types of events
+var eventTypes = map[string]bool{
+	"":             true,
+	"timer":        true,
+	"message":      true,
+	"signal":       true,
+	"conditional":  true,
+	"error":        true,
+	"escalation":   true,
+	"compensation": true,
+	"terminate":    true,
+}
+
+// ValidationError is a problem of a process model, located at a node or sequence flow if
+// it concerns one
+type ValidationError struct {
+	NodeID  string
+	EdgeID  string
+	Message string
+}
+
+// Error returns the problem with its location
+func (e *ValidationError) Error() string {
+	switch {
+	case e.NodeID != "":
+		return fmt.Sprintf("node '%s': %s", e.NodeID, e.Message)
+	case e.EdgeID != "":
+		return fmt.Sprintf("sequence flow '%s': %s", e.EdgeID, e.Message)
+	}
+	return e.Message
+}
+
+// Is reports the error as errors.ErrValidation
+func (e *ValidationError) Is(target error) bool {
+	return target == errors.ErrValidation
+}
+
+// ValidationErrors are all problems found in a process model
+type ValidationErrors []*ValidationError
+
+// Error lists the problems
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	if len(e) == 1 {
+		return messages[0]
+	}
+	return fmt.Sprintf("%d problems: %s", len(e), strings.Join(messages, "; "))
+}
+
+// Is reports the errors as errors.ErrValidation
+func (e ValidationErrors) Is(target error) bool {
+	return target == errors.ErrValidation
+}
+
+// Validate checks that the model can be executed: node types are known and nodes have the
+// properties their type requires, sequence flows connect existing nodes of the same scope,
+// the process and its subprocesses have the start events they need and every node other
+// than start events, boundary events, event subprocesses and compensation handlers is
+// reached by a sequence flow. It returns all problems found, or nil.
+func (m *ProcessModel) Validate() ValidationErrors {
+	v := &modelValidator{model: m, nodes: make(map[string]*Node)}
+	v.validateNodes()
+	v.validateEdges()
+	v.validateStartEvents("")
+	for _, node := range m.Nodes {
+		if node.Type == NodeTypeSubProcess {
+			v.validateStartEvents(node.ID)
+		}
+	}
+	v.validateConnections()
+	return v.errors
+}
+
+// modelValidator collects the problems of a process model
+type modelValidator struct {
+	model  *ProcessModel
+	nodes  map[string]*Node
+	errors ValidationErrors
+}
+
+// nodeError records a problem of a node
+func (v *modelValidator) nodeError(node *Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, &ValidationError{NodeID: node.ID, Message: fmt.Sprintf(format, args...)})
+}
+
+// edgeError records a problem of a sequence flow
+func (v *modelValidator) edgeError(edge *Edge, format string, args ...interface{}) {
+	v.errors = append(v.errors, &ValidationError{EdgeID: edge.ID, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateNodes checks the IDs, types and properties of the nodes
+func (v *modelValidator) validateNodes() {
+	for _, node := range v.model.Nodes {
+		if node.ID == "" {
+			v.errors = append(v.errors, &ValidationError{Message: fmt.Sprintf("node of type '%s' has no ID", node.Type)})
+			continue
+		}
+		if _, duplicate := v.nodes[node.ID]; duplicate {
+			v.nodeError(node, "duplicate node ID")
+			continue
+		}
+		v.nodes[node.ID] = node
+	}
+
+	for _, node := range v.model.Nodes {
+		if node.ID == "" {
+			continue
+		}
+		if !isKnownNodeType(node.Type) {
+			v.nodeError(node, "unknown node type '%s'", node.Type)
+			continue
+		}
+		if node.ParentID != "" {
+			if parent := v.nodes[node.ParentID]; parent == nil || parent.Type != NodeTypeSubProcess {
+				v.nodeError(node, "parent '%s' is not a subprocess", node.ParentID)
+			}
+		}
+		v.validateProperties(node)
+	}
+}
+
+// validateProperties checks that a node has the properties its type requires
+func (v *modelValidator) validateProperties(node *Node) {
+	switch node.Type {
+	case NodeTypeServiceTask:
+		implementation := node.GetString("implementation")
+		if implementation == "" {
+			implementation = node.GetString("type")
+		}
+		if implementation == "" {
+			v.nodeError(node, "service task must have an 'implementation' or 'type' property")
+		}
+		if implementation == "http" && node.GetString("url") == "" {
+			v.nodeError(node, "http service task must have a 'url' property")
+		}
+	case NodeTypeScriptTask:
+		if node.GetString("script") == "" {
+			v.nodeError(node, "script task must have a 'script' property")
+		}
+	case NodeTypeBusinessRuleTask:
+		if node.GetString("decisionRef") == "" {
+			v.nodeError(node, "business rule task must have a 'decisionRef' property")
+		}
+	case NodeTypeCallActivity:
+		if node.GetString("calledElement") == "" {
+			v.nodeError(node, "call activity must have a 'calledElement' property")
+		}
+	case NodeTypeBoundaryEvent:
+		attachedTo := node.GetString("attachedTo")
+		if attachedTo == "" {
+			v.nodeError(node, "boundary event must have an 'attachedTo' property")
+		} else if host := v.nodes[attachedTo]; host == nil || host.ID == node.ID {
+			v.nodeError(node, "boundary event is attached to unknown node '%s'", attachedTo)
+		}
+		if node.GetString("eventType") == "" {
+			v.nodeError(node, "boundary event must have an 'eventType' property")
+		}
+	}
+
+	switch node.Type {
+	case NodeTypeStartEvent, NodeTypeEndEvent, NodeTypeIntermediateEvent, NodeTypeBoundaryEvent:
+		v.validateEventDefinition(node)
+	}
+}
+
+// validateEventDefinition checks that an event has a known event type and the event
+// definition properties the type requires
+func (v *modelValidator) validateEventDefinition(node *Node) {
+	eventType := node.GetString("eventType")
+	if !eventTypes[eventType] {
+		v.nodeError(node, "unknown event type '%s'", eventType)
+		return
+	}
+
+	definition := &Node{Properties: node.GetMap("eventDefinition")}
+	switch eventType {
+	case "timer":
+		switch definition.GetString("timerType") {
+		case "date", "duration", "cycle":
+		default:
+			v.nodeError(node, "timer event must have a 'timerType' of date, duration or cycle")
+		}
+		if definition.GetString("timerValue") == "" {
+			v.nodeError(node, "timer event must have a 'timerValue'")
+		}
+	case "message":
+		if definition.GetString("messageName") == "" {
+			v.nodeError(node, "message event must have a 'messageName'")
+		}
+	case "signal":
+		if definition.GetString("signalName") == "" {
+			v.nodeError(node, "signal event must have a 'signalName'")
+		}
+	case "conditional":
+		condition := definition.GetString("condition")
+		if condition == "" {
+			v.nodeError(node, "conditional event must have a 'condition'")
+		} else if _, err := expression.Compile(condition); err != nil {
+			v.nodeError(node, "invalid condition: %v", err)
+		}
+	}
+}
+
+// validateEdges checks that sequence flows connect existing nodes of the same scope
+// and that their conditions compile
+func (v *modelValidator) validateEdges() {
+	edgeIDs := make(map[string]bool)
+	for _, edge := range v.model.Edges {
+		if edge.ID == "" {
+			v.errors = append(v.errors, &ValidationError{Message: fmt.Sprintf("sequence flow from '%s' to '%s' has no ID", edge.Source, edge.Target)})
+		} else if edgeIDs[edge.ID] {
+			v.edgeError(edge, "duplicate sequence flow ID")
+		}
+		edgeIDs[edge.ID] = true
+
+		source, target := v.nodes[edge.Source], v.nodes[edge.Target]
+		if source == nil {
+			v.edgeError(edge, "source '%s' does not exist", edge.Source)
+		}
+		if target == nil {
+			v.edgeError(edge, "target '%s' does not exist", edge.Target)
+		}
+		if source != nil && target != nil && source.ParentID != target.ParentID {
+			v.edgeError(edge, "connects '%s' and '%s' of different scopes", edge.Source, edge.Target)
+		}
+
+		if edge.Condition != "" {
+			if _, err := expression.Compile(edge.Condition); err != nil {
+				v.edgeError(edge, "invalid condition: %v", err)
+			}
+		}
+	}
+}
+
+// validateStartEvents checks the start events of the process or a subprocess. The
+// process needs at least one start event and at most one without event type; an embedded
+// subprocess needs exactly one without event type and an event subprocess exactly one
+// with an event type.
+func (v *modelValidator) validateStartEvents(scopeID string) {
+	var scope *Node
+	if scopeID != "" {
+		scope = v.nodes[scopeID]
+	}
+
+	starts, noneStarts := 0, 0
+	for _, node := range v.model.GetChildNodes(scopeID) {
+		if node.Type != NodeTypeStartEvent {
+			continue
+		}
+		starts++
+		if node.GetString("eventType") == "" {
+			noneStarts++
+		}
+	}
+
+	switch {
+	case scope == nil:
+		if starts == 0 {
+			v.errors = append(v.errors, &ValidationError{Message: "process must have a start event"})
+		}
+		if noneStarts > 1 {
+			v.errors = append(v.errors, &ValidationError{Message: fmt.Sprintf("process has %d start events without event type, expected at most one", noneStarts)})
+		}
+	case scope.GetBool("triggeredByEvent"):
+		if starts != 1 || noneStarts != 0 {
+			v.nodeError(scope, "event subprocess must have exactly one start event with an event type")
+		}
+	default:
+		if noneStarts != 1 {
+			v.nodeError(scope, "subprocess must have exactly one start event without event type, found %d", noneStarts)
+		}
+	}
+}
+
+// validateConnections checks that start events have no incoming and end events no
+// outgoing sequence flows, and that all other nodes are reached by a sequence flow
+func (v *modelValidator) validateConnections() {
+	incoming := make(map[string]int)
+	outgoing := make(map[string]int)
+	for _, edge := range v.model.Edges {
+		incoming[edge.Target]++
+		outgoing[edge.Source]++
+	}
+
+	for _, node := range v.model.Nodes {
+		if node.ID == "" {
+			continue
+		}
+		switch node.Type {
+		case NodeTypeStartEvent:
+			if incoming[node.ID] > 0 {
+				v.nodeError(node, "start event cannot have incoming sequence flows")
+			}
+			continue
+		case NodeTypeEndEvent:
+			if outgoing[node.ID] > 0 {
+				v.nodeError(node, "end event cannot have outgoing sequence flows")
+			}
+		case NodeTypeBoundaryEvent:
+			continue
+		case NodeTypeSubProcess:
+			if node.GetBool("triggeredByEvent") {
+				continue
+			}
+		}
+		if node.GetBool("isForCompensation") {
+			continue
+		}
+		if incoming[node.ID] == 0 {
+			v.nodeError(node, "node is not reached by any sequence flow")
+		}
+	}
+}
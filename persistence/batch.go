@@ -0,0 +1,180 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultBatchSize is the number of documents a session writes with one statement
+// when it is flushed
+const DefaultBatchSize = 100
+
+// batchDocument is a document written in a batch
+type batchDocument struct {
+	id       string
+	data     []byte
+	revision int
+}
+
+// batchWriter is a transaction writing many documents of a table with one statement
+type batchWriter interface {
+	// putBatch inserts or replaces documents
+	putBatch(ctx context.Context, table string, documents []batchDocument) error
+
+	// insertRevisionBatch writes the first revisions of documents, i.e. replacing
+	// revision 0 or inserting them
+	insertRevisionBatch(ctx context.Context, table string, documents []batchDocument) error
+}
+
+// SetBatchSize sets the number of documents a session writes with one statement;
+// 1 writes every document with its own statements
+func (s *Store) SetBatchSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batchSize = size
+}
+
+// getBatchSize returns the number of documents written with one statement
+func (s *Store) getBatchSize() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.batchSize
+}
+
+// writeBatched executes the pending writes of a session grouped by table and kind:
+// the deletions of a table with one statement, new documents and documents without
+// revision with multi-row inserts, and updates of revisions one by one, as they are
+// checked against the stored revision
+func (s *Session) writeBatched(ctx context.Context, writer batchWriter, tx Transaction, entries map[sessionKey]*sessionEntry, order []sessionKey, batchSize int) error {
+	tables := make([]string, 0)
+	deletes := make(map[string][]string)
+	puts := make(map[string][]batchDocument)
+	inserts := make(map[string][]batchDocument)
+	for _, key := range order {
+		if _, seen := deletes[key.table]; !seen {
+			tables = append(tables, key.table)
+			deletes[key.table] = nil
+		}
+
+		entry := entries[key]
+		document := batchDocument{id: key.id, data: entry.data, revision: entry.revision}
+		switch {
+		case entry.deleted:
+			deletes[key.table] = append(deletes[key.table], key.id)
+		case entry.revision > 0 && entry.expected > 0:
+			if err := tx.SaveRevision(ctx, key.table, key.id, entry.data, entry.revision, entry.expected); err != nil {
+				return err
+			}
+		case entry.revision > 0:
+			inserts[key.table] = append(inserts[key.table], document)
+		default:
+			puts[key.table] = append(puts[key.table], document)
+		}
+	}
+
+	for _, table := range tables {
+		for _, ids := range chunks(deletes[table], batchSize) {
+			if err := tx.Delete(ctx, table, ids...); err != nil {
+				return err
+			}
+		}
+		for _, documents := range chunks(inserts[table], batchSize) {
+			if err := writer.insertRevisionBatch(ctx, table, documents); err != nil {
+				return err
+			}
+		}
+		for _, documents := range chunks(puts[table], batchSize) {
+			if err := writer.putBatch(ctx, table, documents); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// chunks splits values into slices of at most size values
+func chunks[T any](values []T, size int) [][]T {
+	result := make([][]T, 0, (len(values)+size-1)/size)
+	for len(values) > size {
+		result = append(result, values[:size])
+		values = values[size:]
+	}
+	if len(values) > 0 {
+		result = append(result, values)
+	}
+	return result
+}
+
+// putBatch inserts or replaces documents with one multi-row upsert. PostgreSQL uses
+// the upsert as well: COPY cannot replace stored documents.
+func (d *sqlDocuments) putBatch(ctx context.Context, table string, documents []batchDocument) error {
+	if len(documents) == 1 {
+		return d.Put(ctx, table, documents[0].id, documents[0].data)
+	}
+
+	now := d.clock.Now().UTC()
+	args := make([]interface{}, 0, len(documents)*3)
+	for _, document := range documents {
+		args = append(args, document.id, string(document.data), now)
+	}
+	if _, err := d.exec.ExecContext(ctx, d.dialect.UpsertRows(table, len(documents)), args...); err != nil {
+		return fmt.Errorf("failed to write %d documents to %s: %w", len(documents), table, err)
+	}
+	return nil
+}
+
+// insertRevisionBatch writes the first revisions of documents. Documents already
+// stored, e.g. written before they had revisions, are updated one by one; the others
+// are inserted with one multi-row insert.
+func (d *sqlDocuments) insertRevisionBatch(ctx context.Context, table string, documents []batchDocument) error {
+	if len(documents) == 1 {
+		return d.SaveRevision(ctx, table, documents[0].id, documents[0].data, documents[0].revision, 0)
+	}
+
+	ids := make([]interface{}, len(documents))
+	for i, document := range documents {
+		ids[i] = document.id
+	}
+	statement := fmt.Sprintf("SELECT id FROM %s WHERE id IN %s", table, valueRows(1, len(ids), d.dialect.Placeholder))
+	rows, err := d.exec.QueryContext(ctx, statement, ids...)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	stored := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		stored[id] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+
+	now := d.clock.Now().UTC()
+	args := make([]interface{}, 0, len(documents)*4)
+	for _, document := range documents {
+		if stored[document.id] {
+			if err := d.SaveRevision(ctx, table, document.id, document.data, document.revision, 0); err != nil {
+				return err
+			}
+			continue
+		}
+		args = append(args, document.id, string(document.data), now, document.revision)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	statement = fmt.Sprintf("INSERT INTO %s (id, data, updated_at, revision) VALUES %s",
+		table, valueRows(len(args)/4, 4, d.dialect.Placeholder))
+	if _, err := d.exec.ExecContext(ctx, statement, args...); err != nil {
+		return fmt.Errorf("failed to write %d documents to %s: %w", len(args)/4, table, err)
+	}
+	return nil
+}
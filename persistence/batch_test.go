@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// roundTrip is the time the benchmarks wait for each statement, like for a database on
+// another host of the network
+const roundTrip = 50 * time.Microsecond
+
+// roundTripConnector connects to a database that executes nothing, but answers each
+// statement after a round trip and counts the statements it received
+type roundTripConnector struct {
+	statements atomic.Int64
+}
+
+func (c *roundTripConnector) Connect(context.Context) (driver.Conn, error) {
+	return &roundTripConn{connector: c}, nil
+}
+
+func (c *roundTripConnector) Driver() driver.Driver {
+	return nil
+}
+
+// roundTripConn is a connection of a roundTripConnector
+type roundTripConn struct {
+	connector *roundTripConnector
+}
+
+func (c *roundTripConn) Prepare(query string) (driver.Stmt, error) {
+	return &roundTripStmt{conn: c, query: query}, nil
+}
+
+func (c *roundTripConn) Close() error {
+	return nil
+}
+
+func (c *roundTripConn) Begin() (driver.Tx, error) {
+	c.send()
+	return c, nil
+}
+
+func (c *roundTripConn) Commit() error {
+	c.send()
+	return nil
+}
+
+func (c *roundTripConn) Rollback() error {
+	c.send()
+	return nil
+}
+
+// send waits a round trip for a statement
+func (c *roundTripConn) send() {
+	c.connector.statements.Add(1)
+	time.Sleep(roundTrip)
+}
+
+// roundTripStmt is a statement of a roundTripConn. Updates change no rows, so that
+// documents are inserted; queries return no rows.
+type roundTripStmt struct {
+	conn  *roundTripConn
+	query string
+}
+
+func (s *roundTripStmt) Close() error {
+	return nil
+}
+
+func (s *roundTripStmt) NumInput() int {
+	return -1
+}
+
+func (s *roundTripStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.send()
+	if strings.HasPrefix(s.query, "UPDATE") {
+		return driver.RowsAffected(0), nil
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *roundTripStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.conn.send()
+	return emptyRows{}, nil
+}
+
+// emptyRows is a result without rows
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string {
+	return []string{"id"}
+}
+
+func (emptyRows) Close() error {
+	return nil
+}
+
+func (emptyRows) Next([]driver.Value) error {
+	return io.EOF
+}
+
+// BenchmarkFlush flushes sessions writing the documents a started process instance
+// writes, a process instance, a task, and their history, with batch sizes from 1,
+// i.e. one statement per document, to DefaultBatchSize. The statements/op metric is
+// the number of round trips to the database.
+func BenchmarkFlush(b *testing.B) {
+	const instances = 20
+
+	for _, size := range []int{1, 10, DefaultBatchSize} {
+		b.Run(fmt.Sprintf("batch=%d", size), func(b *testing.B) {
+			connector := &roundTripConnector{}
+			db := sql.OpenDB(connector)
+			defer db.Close()
+			store, err := NewStore(db, "postgres")
+			if err != nil {
+				b.Fatal(err)
+			}
+			store.SetBatchSize(size)
+			document := map[string]interface{}{"name": "benchmark", "priority": 50}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				session := store.NewSession()
+				ctx := WithSession(context.Background(), session)
+				for j := 0; j < instances; j++ {
+					id := fmt.Sprintf("%d-%d", i, j)
+					_ = store.SaveRevision(ctx, TableProcessInstances, id, document, 1)
+					_ = store.SaveRevision(ctx, TableTasks, id, document, 1)
+					_ = store.Save(ctx, TableHistoricProcessInstances, id, document)
+					_ = store.Save(ctx, TableHistoricTaskInstances, id, document)
+					_ = store.Save(ctx, TableHistoricActivityInstances, id+"-start", document)
+					_ = store.Save(ctx, TableHistoricActivityInstances, id+"-task", document)
+				}
+				if err := session.Flush(ctx); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(connector.statements.Load())/float64(b.N), "statements/op")
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package persistence
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// LargeValueThreshold is the serialized size in bytes above which binary and JSON
+// variable values are kept in the content store instead of the documents of their owners
+const LargeValueThreshold = 16 * 1024
+
+// ContentStore stores large variable values apart from the process instances and
+// tasks holding them, e.g. in a table or an object storage
+type ContentStore interface {
+	// PutContent stores content under an ID
+	PutContent(ctx context.Context, id string, data []byte) error
+
+	// GetContent returns the content stored under an ID
+	GetContent(ctx context.Context, id string) ([]byte, error)
+
+	// DeleteContent removes the content stored under the IDs
+	DeleteContent(ctx context.Context, ids ...string) error
+}
+
+// tableContentStore keeps contents in the byte array table of a store
+type tableContentStore struct {
+	store *Store
+}
+
+// NewTableContentStore creates a content store keeping contents in the byte array table of a store
+func NewTableContentStore(store *Store) ContentStore {
+	return &tableContentStore{store: store}
+}
+
+// PutContent stores content under an ID
+func (s *tableContentStore) PutContent(ctx context.Context, id string, data []byte) error {
+	return s.store.Put(ctx, TableByteArrays, id, data)
+}
+
+// GetContent returns the content stored under an ID
+func (s *tableContentStore) GetContent(ctx context.Context, id string) ([]byte, error) {
+	return s.store.Get(ctx, TableByteArrays, id)
+}
+
+// DeleteContent removes the content stored under the IDs
+func (s *tableContentStore) DeleteContent(ctx context.Context, ids ...string) error {
+	return s.store.Delete(ctx, TableByteArrays, ids...)
+}
+
+// VariableContents keeps the large variable values of owners, e.g. process instances,
+// in a content store. Persisted variables refer to them with a variable.Reference,
+// which is loaded when the variable is first read. A nil VariableContents keeps all
+// values in the documents.
+type VariableContents struct {
+	mu           sync.Mutex
+	contentStore ContentStore
+	references   map[string]map[string]*variable.Reference // owner ID -> scope and variable name -> reference
+	loaded       map[string]interface{}                    // content ID -> value
+}
+
+// NewVariableContents creates variable contents kept in a content store
+func NewVariableContents(contentStore ContentStore) *VariableContents {
+	return &VariableContents{
+		contentStore: contentStore,
+		references:   make(map[string]map[string]*variable.Reference),
+		loaded:       make(map[string]interface{}),
+	}
+}
+
+// Externalize returns the variables of the scopes of an owner to persist: large values
+// are written to the content store, unless unchanged, and replaced by references.
+// Contents of the owner that are no longer referenced are deleted.
+func (c *VariableContents) Externalize(ctx context.Context, ownerID string, scopes map[string]map[string]interface{}) (map[string]variable.Map, error) {
+	result := make(map[string]variable.Map, len(scopes))
+	if c == nil {
+		for scopeID, variables := range scopes {
+			result[scopeID] = variables
+		}
+		return result, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous := c.references[ownerID]
+	current := make(map[string]*variable.Reference)
+	for scopeID, variables := range scopes {
+		persisted := make(variable.Map, len(variables))
+		for name, value := range variables {
+			key := scopeID + "/" + name
+			reference, err := c.externalizeLocked(ctx, value, previous[key])
+			if err != nil {
+				return nil, fmt.Errorf("variable '%s': %w", name, err)
+			}
+			if reference == nil {
+				persisted[name] = value
+				continue
+			}
+			persisted[name] = reference
+			current[key] = reference
+		}
+		result[scopeID] = persisted
+	}
+
+	if err := c.replaceLocked(ctx, ownerID, current); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// externalizeLocked returns the reference to a large value, writing the value to the
+// content store if it differs from the previous one. Small values have no reference.
+// Callers must hold the lock.
+func (c *VariableContents) externalizeLocked(ctx context.Context, value interface{}, previous *variable.Reference) (*variable.Reference, error) {
+	if reference, ok := value.(*variable.Reference); ok {
+		return reference, nil // Not loaded, so unchanged
+	}
+
+	typed, err := variable.Of(value)
+	if err != nil {
+		return nil, err
+	}
+	if typed.Type != variable.TypeBytes && typed.Type != variable.TypeJSON {
+		return nil, nil
+	}
+	data, err := json.Marshal(typed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) <= LargeValueThreshold {
+		return nil, nil
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if previous != nil && previous.Hash == hash {
+		return previous, nil
+	}
+
+	reference := &variable.Reference{
+		ContentID: uuid.New().String(),
+		Type:      typed.Type,
+		Size:      len(data),
+		Hash:      hash,
+	}
+	if err := c.contentStore.PutContent(ctx, reference.ContentID, data); err != nil {
+		return nil, err
+	}
+	c.loaded[reference.ContentID] = value
+	return reference, nil
+}
+
+// Track registers the references in the loaded variables of the scopes of an owner
+func (c *VariableContents) Track(ownerID string, scopes map[string]variable.Map) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	references := make(map[string]*variable.Reference)
+	for scopeID, variables := range scopes {
+		for name, value := range variables {
+			if reference, ok := value.(*variable.Reference); ok {
+				references[scopeID+"/"+name] = reference
+			}
+		}
+	}
+	if len(references) > 0 {
+		c.references[ownerID] = references
+	}
+}
+
+// Remove deletes the contents of an owner, e.g. a completed task
+func (c *VariableContents) Remove(ctx context.Context, ownerID string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.replaceLocked(ctx, ownerID, nil)
+}
+
+// replaceLocked replaces the references of an owner, deleting the contents no longer
+// referenced. Callers must hold the lock.
+func (c *VariableContents) replaceLocked(ctx context.Context, ownerID string, references map[string]*variable.Reference) error {
+	referenced := make(map[string]bool, len(references))
+	for _, reference := range references {
+		referenced[reference.ContentID] = true
+	}
+	unreferenced := make([]string, 0)
+	for _, reference := range c.references[ownerID] {
+		if !referenced[reference.ContentID] {
+			unreferenced = append(unreferenced, reference.ContentID)
+			delete(c.loaded, reference.ContentID)
+		}
+	}
+
+	if len(references) > 0 {
+		c.references[ownerID] = references
+	} else {
+		delete(c.references, ownerID)
+	}
+	if len(unreferenced) == 0 {
+		return nil
+	}
+	return c.contentStore.DeleteContent(ctx, unreferenced...)
+}
+
+// Resolve returns the value of a variable, loading it from the content store if it is a reference
+func (c *VariableContents) Resolve(ctx context.Context, value interface{}) (interface{}, error) {
+	reference, ok := value.(*variable.Reference)
+	if !ok || c == nil {
+		return value, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if loaded, exists := c.loaded[reference.ContentID]; exists {
+		return loaded, nil
+	}
+	data, err := c.contentStore.GetContent(ctx, reference.ContentID)
+	if err != nil {
+		return nil, err
+	}
+	var typed variable.TypedValue
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, fmt.Errorf("invalid content %s: %w", reference.ContentID, err)
+	}
+	c.loaded[reference.ContentID] = typed.Value
+	return typed.Value, nil
+}
+
+// ResolveAll returns a copy of variables in which references are replaced by their values
+func (c *VariableContents) ResolveAll(ctx context.Context, variables map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		resolved, err := c.Resolve(ctx, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load variable '%s': %w", name, err)
+		}
+		result[name] = resolved
+	}
+	return result, nil
+}
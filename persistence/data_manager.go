@@ -0,0 +1,296 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+)
+
+// DataManager keeps the documents of some engine tables in a custom data store, e.g.
+// a collection per table in MongoDB or DynamoDB. Documents are JSON; a data manager
+// stores them as they are, with the revision given to SaveRevision, and reports
+// missing documents with errors wrapping errors.ErrNotFound and revision conflicts
+// with an *OptimisticLockingError.
+//
+// A data manager that also implements Begin(ctx) (Transaction, error) takes part in
+// the transactions of commands; otherwise its writes are applied one by one when a
+// command is flushed.
+type DataManager interface {
+	Documents
+
+	// Scan calls fn for every document of a table
+	Scan(ctx context.Context, table string, fn func(id string, data []byte) error) error
+
+	// Count returns the number of documents in a table
+	Count(ctx context.Context, table string) (int64, error)
+}
+
+// RepositoryDataManager keeps deployments and process definitions
+type RepositoryDataManager interface {
+	DataManager
+}
+
+// ExecutionDataManager keeps process instances, each one document with its executions
+// and variables
+type ExecutionDataManager interface {
+	DataManager
+}
+
+// TaskDataManager keeps tasks and external tasks
+type TaskDataManager interface {
+	DataManager
+}
+
+// JobDataManager keeps jobs, including dead-letter jobs
+type JobDataManager interface {
+	DataManager
+}
+
+// VariableDataManager keeps large binary and JSON variable values apart from the
+// process instances and tasks holding them
+type VariableDataManager interface {
+	DataManager
+}
+
+// HistoryDataManager keeps historic process, task, activity and variable instances
+// and historic details
+type HistoryDataManager interface {
+	DataManager
+}
+
+// DataManagers are the data managers of an engine. Tables of nil data managers, and
+// engine properties and authorizations, stay in the storage of the store.
+type DataManagers struct {
+	Repository RepositoryDataManager
+	Execution  ExecutionDataManager
+	Task       TaskDataManager
+	Job        JobDataManager
+	Variable   VariableDataManager
+	History    HistoryDataManager
+}
+
+// tables returns the data manager of each table that has one
+func (m DataManagers) tables() map[string]DataManager {
+	result := make(map[string]DataManager)
+	assign := func(manager DataManager, tables ...string) {
+		for _, table := range tables {
+			result[table] = manager
+		}
+	}
+	if m.Repository != nil {
+		assign(m.Repository, TableDeployments, TableProcessDefinitions)
+	}
+	if m.Execution != nil {
+		assign(m.Execution, TableProcessInstances)
+	}
+	if m.Task != nil {
+		assign(m.Task, TableTasks, TableExternalTasks)
+	}
+	if m.Job != nil {
+		assign(m.Job, TableJobs)
+	}
+	if m.Variable != nil {
+		assign(m.Variable, TableByteArrays)
+	}
+	if m.History != nil {
+		assign(m.History, TableHistoricProcessInstances, TableHistoricTaskInstances,
+			TableHistoricActivityInstances, TableHistoricVariableInstances, TableHistoricDetails)
+	}
+	return result
+}
+
+// WithDataManagers returns a store keeping the tables of the data managers in them and
+// the other tables in the storage of s. Native queries still read the database of s.
+func (s *Store) WithDataManagers(managers DataManagers) *Store {
+	return &Store{
+		storage:      &dataManagerStorage{storage: s.storage, managers: managers.tables()},
+		db:           s.db,
+		dialect:      s.dialect,
+		schemaUpdate: s.schemaUpdate,
+		batchSize:    s.batchSize,
+	}
+}
+
+// transactionalDataManager is a data manager taking part in transactions
+type transactionalDataManager interface {
+	Begin(ctx context.Context) (Transaction, error)
+}
+
+// initializingDataManager is a data manager preparing its data store, e.g. creating
+// collections and indexes, when the engine starts
+type initializingDataManager interface {
+	Initialize(ctx context.Context) error
+}
+
+// dataManagerStorage routes the documents of each table to its data manager, or to a
+// storage for tables without one
+type dataManagerStorage struct {
+	storage  Storage
+	managers map[string]DataManager
+}
+
+// documents returns the data manager or storage of a table
+func (s *dataManagerStorage) documents(table string) DataManager {
+	if manager, exists := s.managers[table]; exists {
+		return manager
+	}
+	return s.storage
+}
+
+// Initialize initializes the storage and the data managers
+func (s *dataManagerStorage) Initialize(ctx context.Context) error {
+	if err := s.storage.Initialize(ctx); err != nil {
+		return err
+	}
+	initialized := make(map[DataManager]bool)
+	for _, manager := range s.managers {
+		if initialized[manager] {
+			continue
+		}
+		initialized[manager] = true
+		if initializer, ok := manager.(initializingDataManager); ok {
+			if err := initializer.Initialize(ctx); err != nil {
+				return fmt.Errorf("failed to initialize data manager: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// Get returns a document
+func (s *dataManagerStorage) Get(ctx context.Context, table, id string) ([]byte, error) {
+	return s.documents(table).Get(ctx, table, id)
+}
+
+// Put writes a document
+func (s *dataManagerStorage) Put(ctx context.Context, table, id string, data []byte) error {
+	return s.documents(table).Put(ctx, table, id, data)
+}
+
+// SaveRevision writes a revision of a document that replaces the expected revision
+func (s *dataManagerStorage) SaveRevision(ctx context.Context, table, id string, data []byte, revision, expected int) error {
+	return s.documents(table).SaveRevision(ctx, table, id, data, revision, expected)
+}
+
+// Delete removes documents
+func (s *dataManagerStorage) Delete(ctx context.Context, table string, ids ...string) error {
+	return s.documents(table).Delete(ctx, table, ids...)
+}
+
+// Scan calls fn for every document of a table
+func (s *dataManagerStorage) Scan(ctx context.Context, table string, fn func(id string, data []byte) error) error {
+	return s.documents(table).Scan(ctx, table, fn)
+}
+
+// Count returns the number of documents in a table
+func (s *dataManagerStorage) Count(ctx context.Context, table string) (int64, error) {
+	return s.documents(table).Count(ctx, table)
+}
+
+// Begin starts a transaction spanning the storage and the transactional data managers
+func (s *dataManagerStorage) Begin(ctx context.Context) (Transaction, error) {
+	return &dataManagerTransaction{ctx: ctx, storage: s, transactions: make(map[Documents]Transaction)}, nil
+}
+
+// Close closes the storage; the data managers are closed by their owners
+func (s *dataManagerStorage) Close() error {
+	return s.storage.Close()
+}
+
+// dataManagerTransaction writes each table in a transaction of its storage or data
+// manager, begun on the first write, or directly to data managers without transactions
+type dataManagerTransaction struct {
+	ctx          context.Context
+	storage      *dataManagerStorage
+	transactions map[Documents]Transaction
+	order        []Transaction // transactions in the order they were begun
+}
+
+// documents returns the transaction, or the data manager without transactions, a
+// table is written with
+func (t *dataManagerTransaction) documents(table string) (Documents, error) {
+	var target DataManager = t.storage.storage
+	if manager, exists := t.storage.managers[table]; exists {
+		target = manager
+	}
+	if transaction, exists := t.transactions[target]; exists {
+		return transaction, nil
+	}
+
+	var begin func(ctx context.Context) (Transaction, error)
+	if target == DataManager(t.storage.storage) {
+		begin = t.storage.storage.Begin
+	} else if transactional, ok := target.(transactionalDataManager); ok {
+		begin = transactional.Begin
+	} else {
+		return target, nil
+	}
+
+	transaction, err := begin(t.ctx)
+	if err != nil {
+		return nil, err
+	}
+	t.transactions[target] = transaction
+	t.order = append(t.order, transaction)
+	return transaction, nil
+}
+
+// Get returns a document, as written in the transaction
+func (t *dataManagerTransaction) Get(ctx context.Context, table, id string) ([]byte, error) {
+	documents, err := t.documents(table)
+	if err != nil {
+		return nil, err
+	}
+	return documents.Get(ctx, table, id)
+}
+
+// Put writes a document
+func (t *dataManagerTransaction) Put(ctx context.Context, table, id string, data []byte) error {
+	documents, err := t.documents(table)
+	if err != nil {
+		return err
+	}
+	return documents.Put(ctx, table, id, data)
+}
+
+// SaveRevision writes a revision of a document that replaces the expected revision
+func (t *dataManagerTransaction) SaveRevision(ctx context.Context, table, id string, data []byte, revision, expected int) error {
+	documents, err := t.documents(table)
+	if err != nil {
+		return err
+	}
+	return documents.SaveRevision(ctx, table, id, data, revision, expected)
+}
+
+// Delete removes documents
+func (t *dataManagerTransaction) Delete(ctx context.Context, table string, ids ...string) error {
+	documents, err := t.documents(table)
+	if err != nil {
+		return err
+	}
+	return documents.Delete(ctx, table, ids...)
+}
+
+// Commit commits the transactions in the order they were begun. If one fails, the
+// ones not committed yet are rolled back.
+func (t *dataManagerTransaction) Commit() error {
+	for i, transaction := range t.order {
+		if err := transaction.Commit(); err != nil {
+			for _, remaining := range t.order[i+1:] {
+				_ = remaining.Rollback()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Rollback rolls back all transactions
+func (t *dataManagerTransaction) Rollback() error {
+	var firstErr error
+	for _, transaction := range t.order {
+		if err := transaction.Rollback(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,152 @@
+package persistence
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Dialect describes the SQL flavor of a database
+type Dialect struct {
+	// Name is the engine-level driver name, e.g. "mysql"
+	Name string
+
+	// SQLDrivers are the database/sql driver names that can serve this dialect, in order of preference
+	SQLDrivers []string
+
+	// DocumentType is the column type holding JSON documents
+	DocumentType string
+
+	// TimestampType is the column type holding timestamps
+	TimestampType string
+
+	// Placeholder returns the bind parameter for the n-th (1-based) argument
+	Placeholder func(n int) string
+
+	// Upsert returns the statement inserting or replacing a document
+	Upsert func(table string) string
+
+	// UpsertRows returns the statement inserting or replacing a number of documents
+	UpsertRows func(table string, rows int) string
+
+	// SingleConnection limits the pool to one connection, for embedded
+	// databases that serialize writers anyway
+	SingleConnection bool
+}
+
+// dialects holds the supported dialects by name
+var dialects = map[string]*Dialect{
+	"mysql": {
+		Name:          "mysql",
+		SQLDrivers:    []string{"mysql"},
+		DocumentType:  "LONGTEXT",
+		TimestampType: "DATETIME(6)",
+		Placeholder:   questionMark,
+		Upsert: func(table string) string {
+			return "INSERT INTO " + table + " (id, data, updated_at) VALUES (?, ?, ?) " +
+				"ON DUPLICATE KEY UPDATE data = VALUES(data), updated_at = VALUES(updated_at)"
+		},
+		UpsertRows: func(table string, rows int) string {
+			return "INSERT INTO " + table + " (id, data, updated_at) VALUES " + valueRows(rows, 3, questionMark) + " " +
+				"ON DUPLICATE KEY UPDATE data = VALUES(data), updated_at = VALUES(updated_at)"
+		},
+	},
+	"postgres": {
+		Name:          "postgres",
+		SQLDrivers:    []string{"postgres", "pgx"},
+		DocumentType:  "TEXT",
+		TimestampType: "TIMESTAMPTZ",
+		Placeholder:   dollarNumber,
+		Upsert: func(table string) string {
+			return "INSERT INTO " + table + " (id, data, updated_at) VALUES ($1, $2, $3) " +
+				"ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at"
+		},
+		UpsertRows: func(table string, rows int) string {
+			return "INSERT INTO " + table + " (id, data, updated_at) VALUES " + valueRows(rows, 3, dollarNumber) + " " +
+				"ON CONFLICT (id) DO UPDATE SET data = EXCLUDED.data, updated_at = EXCLUDED.updated_at"
+		},
+	},
+	"sqlite": {
+		Name:          "sqlite",
+		SQLDrivers:    []string{"sqlite", "sqlite3"},
+		DocumentType:  "TEXT",
+		TimestampType: "TIMESTAMP",
+		Placeholder:   questionMark,
+		Upsert: func(table string) string {
+			return "INSERT INTO " + table + " (id, data, updated_at) VALUES (?, ?, ?) " +
+				"ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at"
+		},
+		UpsertRows: func(table string, rows int) string {
+			return "INSERT INTO " + table + " (id, data, updated_at) VALUES " + valueRows(rows, 3, questionMark) + " " +
+				"ON CONFLICT (id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at"
+		},
+		SingleConnection: true,
+	},
+}
+
+// questionMark is the bind parameter of MySQL and SQLite
+func questionMark(n int) string {
+	return "?"
+}
+
+// dollarNumber is the numbered bind parameter of PostgreSQL
+func dollarNumber(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// valueRows returns the bind parameters of rows of a multi-row VALUES clause, e.g.
+// "($1, $2), ($3, $4)"
+func valueRows(rows, columns int, placeholder func(n int) string) string {
+	var sb strings.Builder
+	for row := 0; row < rows; row++ {
+		if row > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for column := 0; column < columns; column++ {
+			if column > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(placeholder(row*columns + column + 1))
+		}
+		sb.WriteByte(')')
+	}
+	return sb.String()
+}
+
+// dialectAliases maps alternative driver names to dialect names
+var dialectAliases = map[string]string{
+	"postgresql": "postgres",
+	"pgx":        "postgres",
+	"sqlite3":    "sqlite",
+}
+
+// GetDialect returns the dialect registered under a driver name
+func GetDialect(name string) (*Dialect, error) {
+	name = strings.ToLower(name)
+	if alias, exists := dialectAliases[name]; exists {
+		name = alias
+	}
+
+	dialect, exists := dialects[name]
+	if !exists {
+		return nil, fmt.Errorf("unsupported database driver: %s", name)
+	}
+	return dialect, nil
+}
+
+// sqlDriver returns the first registered database/sql driver that serves the dialect.
+// Drivers register themselves when imported, e.g. _ "github.com/go-sql-driver/mysql".
+func (d *Dialect) sqlDriver() (string, error) {
+	registered := make(map[string]bool)
+	for _, name := range sql.Drivers() {
+		registered[name] = true
+	}
+	for _, name := range d.SQLDrivers {
+		if registered[name] {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no database/sql driver registered for %s (expected one of %s); import a driver package",
+		d.Name, strings.Join(d.SQLDrivers, ", "))
+}
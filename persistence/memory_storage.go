@@ -0,0 +1,224 @@
+package persistence
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// memoryStorage keeps the documents in maps, with the revisions and transactions of
+// a database. It is lost when the process exits.
+type memoryStorage struct {
+	tables map[string]map[string]memoryDocument
+	mu     sync.RWMutex
+}
+
+// memoryDocument is a stored document with its revision
+type memoryDocument struct {
+	data     []byte
+	revision int
+}
+
+// NewMemoryStorage creates a storage keeping the documents in memory
+func NewMemoryStorage() Storage {
+	return &memoryStorage{tables: make(map[string]map[string]memoryDocument)}
+}
+
+// Initialize creates the engine tables if they do not exist yet
+func (s *memoryStorage) Initialize(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, table := range tables {
+		s.table(table)
+	}
+	return nil
+}
+
+// Get returns a document
+func (s *memoryStorage) Get(ctx context.Context, table, id string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.get(table, id)
+}
+
+// Put writes a document
+func (s *memoryStorage) Put(ctx context.Context, table, id string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(table, id, data)
+	return nil
+}
+
+// SaveRevision writes a revision of a document that replaces the expected revision
+func (s *memoryStorage) SaveRevision(ctx context.Context, table, id string, data []byte, revision, expected int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.saveRevision(table, id, data, revision, expected)
+}
+
+// Delete removes documents
+func (s *memoryStorage) Delete(ctx context.Context, table string, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.table(table), id)
+	}
+	return nil
+}
+
+// Scan calls fn for every document of a table, ordered by ID. fn may use the storage.
+func (s *memoryStorage) Scan(ctx context.Context, table string, fn func(id string, data []byte) error) error {
+	s.mu.RLock()
+	ids := make([]string, 0, len(s.tables[table]))
+	documents := make(map[string][]byte, len(s.tables[table]))
+	for id, document := range s.tables[table] {
+		ids = append(ids, id)
+		documents[id] = document.data
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := fn(id, documents[id]); err != nil {
+			return fmt.Errorf("failed to load %s %s: %w", table, id, err)
+		}
+	}
+	return nil
+}
+
+// Count returns the number of documents in a table
+func (s *memoryStorage) Count(ctx context.Context, table string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.tables[table])), nil
+}
+
+// Begin starts a transaction. It holds the storage exclusively until it is committed
+// or rolled back, so that its writes become visible together.
+func (s *memoryStorage) Begin(ctx context.Context) (Transaction, error) {
+	s.mu.Lock()
+	return &memoryTransaction{storage: s}, nil
+}
+
+// Close releases nothing; the documents stay readable
+func (s *memoryStorage) Close() error {
+	return nil
+}
+
+// table returns the documents of a table, adding the table if it does not exist
+func (s *memoryStorage) table(table string) map[string]memoryDocument {
+	documents, exists := s.tables[table]
+	if !exists {
+		documents = make(map[string]memoryDocument)
+		s.tables[table] = documents
+	}
+	return documents
+}
+
+// get returns a document; the caller holds the lock
+func (s *memoryStorage) get(table, id string) ([]byte, error) {
+	document, exists := s.tables[table][id]
+	if !exists {
+		return nil, fmt.Errorf("%s %w: %s", table, errors.ErrNotFound, id)
+	}
+	return document.data, nil
+}
+
+// put writes a document keeping its revision; the caller holds the lock
+func (s *memoryStorage) put(table, id string, data []byte) {
+	documents := s.table(table)
+	documents[id] = memoryDocument{data: append([]byte(nil), data...), revision: documents[id].revision}
+}
+
+// saveRevision writes a revision of a document; the caller holds the lock
+func (s *memoryStorage) saveRevision(table, id string, data []byte, revision, expected int) error {
+	documents := s.table(table)
+	document, exists := documents[id]
+	if (exists && document.revision != expected) || (!exists && expected > 0) {
+		return &OptimisticLockingError{Table: table, ID: id, Revision: expected}
+	}
+	documents[id] = memoryDocument{data: append([]byte(nil), data...), revision: revision}
+	return nil
+}
+
+// memoryTransaction applies its writes to the storage right away and remembers how
+// to undo them on rollback
+type memoryTransaction struct {
+	storage *memoryStorage
+	undo    []func()
+	done    bool
+}
+
+// Get returns a document, as written in the transaction
+func (t *memoryTransaction) Get(ctx context.Context, table, id string) ([]byte, error) {
+	return t.storage.get(table, id)
+}
+
+// Put writes a document
+func (t *memoryTransaction) Put(ctx context.Context, table, id string, data []byte) error {
+	t.remember(table, id)
+	t.storage.put(table, id, data)
+	return nil
+}
+
+// SaveRevision writes a revision of a document that replaces the expected revision
+func (t *memoryTransaction) SaveRevision(ctx context.Context, table, id string, data []byte, revision, expected int) error {
+	t.remember(table, id)
+	return t.storage.saveRevision(table, id, data, revision, expected)
+}
+
+// Delete removes documents
+func (t *memoryTransaction) Delete(ctx context.Context, table string, ids ...string) error {
+	for _, id := range ids {
+		t.remember(table, id)
+		delete(t.storage.table(table), id)
+	}
+	return nil
+}
+
+// Commit keeps the writes and releases the storage
+func (t *memoryTransaction) Commit() error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	t.done = true
+	t.undo = nil
+	t.storage.mu.Unlock()
+	return nil
+}
+
+// Rollback undoes the writes and releases the storage
+func (t *memoryTransaction) Rollback() error {
+	if t.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	t.done = true
+	for i := len(t.undo) - 1; i >= 0; i-- {
+		t.undo[i]()
+	}
+	t.undo = nil
+	t.storage.mu.Unlock()
+	return nil
+}
+
+// remember records how to restore a document to its state before a write
+func (t *memoryTransaction) remember(table, id string) {
+	documents := t.storage.table(table)
+	previous, existed := documents[id]
+	t.undo = append(t.undo, func() {
+		if existed {
+			documents[id] = previous
+		} else {
+			delete(documents, id)
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// TableSchemaVersion is the table holding the version of the schema, i.e. the last
+// migration applied
+const TableSchemaVersion = "flowgo_ge_schema_version"
+
+// SchemaUpdate is what a store does with the schema of its database on Initialize
+type SchemaUpdate string
+
+// Schema updates
+const (
+	// SchemaUpdateMigrate creates the schema and applies the migrations not applied yet
+	SchemaUpdateMigrate SchemaUpdate = "migrate"
+	// SchemaUpdateValidate leaves the schema as it is and fails if it is not at the
+	// latest version, e.g. when a database administrator applies the migrations
+	SchemaUpdateValidate SchemaUpdate = "validate"
+)
+
+// migrationFiles are the migrations of the schema, named <version>_<name>.up.sql.
+// They are templates of the dialect, e.g. {{.DocumentType}} for the column type of
+// documents; statements end with a semicolon.
+//
+//go:embed migrations/*.up.sql
+var migrationFiles embed.FS
+
+// Migration is a step of the schema of the engine
+type Migration struct {
+	// Version is the schema version after the migration
+	Version int
+	// Name describes the migration, e.g. "create_tables"
+	Name string
+	// source is the SQL template of the migration
+	source string
+}
+
+// SchemaVersion is the state of the schema of a database
+type SchemaVersion struct {
+	// Version is the version of the schema, 0 if it was not created yet
+	Version int `json:"version"`
+	// LatestVersion is the version of the latest migration of the engine
+	LatestVersion int `json:"latestVersion"`
+	// Dirty reports that a migration failed part way; the schema needs to be repaired
+	// by hand and the version corrected before the engine can start
+	Dirty bool `json:"dirty"`
+}
+
+// UpToDate reports whether the schema is at the latest version
+func (v SchemaVersion) UpToDate() bool {
+	return !v.Dirty && v.Version == v.LatestVersion
+}
+
+// Migrations returns the migrations of the schema ordered by version
+func Migrations() ([]Migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]Migration, 0, len(names))
+	for _, name := range names {
+		base := strings.TrimSuffix(path.Base(name), ".up.sql")
+		versionText, migrationName, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s: %w", name, err)
+		}
+		source, err := migrationFiles.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: migrationName, source: string(source)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// latestSchemaVersion returns the version of the latest migration
+func latestSchemaVersion() (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+	if len(migrations) == 0 {
+		return 0, nil
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// Statements returns the SQL statements of a migration in a dialect
+func (m Migration) Statements(dialect *Dialect) ([]string, error) {
+	tmpl, err := template.New(m.Name).Parse(m.source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid migration %d_%s: %w", m.Version, m.Name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, dialect); err != nil {
+		return nil, fmt.Errorf("invalid migration %d_%s: %w", m.Version, m.Name, err)
+	}
+
+	statements := make([]string, 0)
+	for _, statement := range strings.Split(buf.String(), ";") {
+		lines := make([]string, 0)
+		for _, line := range strings.Split(statement, "\n") {
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			statements = append(statements, strings.Join(lines, " "))
+		}
+	}
+	return statements, nil
+}
+
+// SetSchemaUpdate sets what Initialize does with the schema of the database; the
+// default is SchemaUpdateMigrate
+func (s *Store) SetSchemaUpdate(update SchemaUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.schemaUpdate = update
+}
+
+// GetSchemaVersion returns the version of the schema of the database. A store
+// without database is always at the latest version.
+func (s *Store) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	latest, err := latestSchemaVersion()
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+	if s.db == nil {
+		return SchemaVersion{Version: latest, LatestVersion: latest}, nil
+	}
+
+	version, dirty, exists, err := s.readSchemaVersion(ctx)
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+	if !exists {
+		if version, err = s.detectSchemaVersion(ctx); err != nil {
+			return SchemaVersion{}, err
+		}
+	}
+	return SchemaVersion{Version: version, LatestVersion: latest, Dirty: dirty}, nil
+}
+
+// ValidateSchema returns an error if the schema of the database is not at the latest version
+func (s *Store) ValidateSchema(ctx context.Context) error {
+	version, err := s.GetSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version.Dirty {
+		return fmt.Errorf("schema version %d is dirty: a migration failed and the schema needs to be repaired", version.Version)
+	}
+	if version.Version != version.LatestVersion {
+		return fmt.Errorf("schema version %d does not match the engine schema version %d", version.Version, version.LatestVersion)
+	}
+	return nil
+}
+
+// Migrate applies the migrations newer than the schema of the database, each
+// recorded as the schema version once it succeeded. A schema created before the
+// version was recorded is taken over at the version its tables match.
+func (s *Store) Migrate(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+
+	version, dirty, exists, err := s.readSchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("schema version %d is dirty: a migration failed and the schema needs to be repaired", version)
+	}
+	if !exists {
+		if version, err = s.detectSchemaVersion(ctx); err != nil {
+			return err
+		}
+		statement := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version INTEGER NOT NULL, dirty INTEGER NOT NULL)", TableSchemaVersion)
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to create table %s: %w", TableSchemaVersion, err)
+		}
+		if err := s.writeSchemaVersion(ctx, version, false); err != nil {
+			return err
+		}
+	}
+
+	migrations, err := Migrations()
+	if err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			continue
+		}
+		if err := s.applyMigration(ctx, migration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMigration runs the statements of a migration. The version is marked dirty
+// while they run, so that a migration failing part way is not taken as applied.
+func (s *Store) applyMigration(ctx context.Context, migration Migration) error {
+	statements, err := migration.Statements(s.dialect)
+	if err != nil {
+		return err
+	}
+	if err := s.writeSchemaVersion(ctx, migration.Version, true); err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return s.writeSchemaVersion(ctx, migration.Version, false)
+}
+
+// readSchemaVersion reads the recorded schema version; exists is false if none is recorded
+func (s *Store) readSchemaVersion(ctx context.Context) (version int, dirty, exists bool, err error) {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("SELECT version FROM %s WHERE 1 = 0", TableSchemaVersion)); err != nil {
+		return 0, false, false, nil
+	}
+
+	var dirtyFlag int
+	statement := fmt.Sprintf("SELECT version, dirty FROM %s", TableSchemaVersion)
+	if err := s.db.QueryRowContext(ctx, statement).Scan(&version, &dirtyFlag); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, false, false, nil
+		}
+		return 0, false, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, dirtyFlag != 0, true, nil
+}
+
+// writeSchemaVersion records the schema version, replacing the recorded one
+func (s *Store) writeSchemaVersion(ctx context.Context, version int, dirty bool) error {
+	dirtyFlag := 0
+	if dirty {
+		dirtyFlag = 1
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s", TableSchemaVersion)); err != nil {
+		return fmt.Errorf("failed to write schema version: %w", err)
+	}
+	p := s.dialect.Placeholder
+	statement := fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (%s, %s)", TableSchemaVersion, p(1), p(2))
+	if _, err := s.db.ExecContext(ctx, statement, version, dirtyFlag); err != nil {
+		return fmt.Errorf("failed to write schema version: %w", err)
+	}
+	return nil
+}
+
+// detectSchemaVersion returns the version the tables of a schema without recorded
+// version match: 0 without tables, 1 with tables without revisions, else 2
+func (s *Store) detectSchemaVersion(ctx context.Context) (int, error) {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("SELECT id FROM %s WHERE 1 = 0", TableDeployments)); err != nil {
+		return 0, nil
+	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf("SELECT revision FROM %s WHERE 1 = 0", TableDeployments)); err != nil {
+		return 1, nil
+	}
+	return 2, nil
+}
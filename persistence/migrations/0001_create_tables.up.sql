@@ -0,0 +1,15 @@
+-- Tables of the engine, each holding JSON documents keyed by ID
+CREATE TABLE IF NOT EXISTS flowgo_re_deployment (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_re_process_definition (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ru_process_instance (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ru_task (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ru_ext_task (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ru_job (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_hi_process_instance (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_hi_task_instance (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_hi_activity_instance (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_hi_variable_instance (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_hi_detail (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ge_property (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ru_authorization (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
+CREATE TABLE IF NOT EXISTS flowgo_ge_bytearray (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL);
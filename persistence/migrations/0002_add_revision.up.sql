@@ -0,0 +1,15 @@
+-- Revisions of the documents for optimistic locking
+ALTER TABLE flowgo_re_deployment ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_re_process_definition ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ru_process_instance ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ru_task ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ru_ext_task ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ru_job ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_hi_process_instance ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_hi_task_instance ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_hi_activity_instance ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_hi_variable_instance ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_hi_detail ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ge_property ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ru_authorization ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE flowgo_ge_bytearray ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Batches of long-running administrative operations with the items they run on
+CREATE TABLE IF NOT EXISTS flowgo_ru_batch (id VARCHAR(255) NOT NULL PRIMARY KEY, data {{.DocumentType}} NOT NULL, updated_at {{.TimestampType}} NOT NULL, revision INTEGER NOT NULL DEFAULT 0);
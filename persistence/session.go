@@ -0,0 +1,233 @@
+package persistence
+
+import (
+	"context"
+	"sync"
+)
+
+// Session is the unit of work of a command. While a context carries it, the writes
+// of the store are collected instead of executed: repeated writes of a document are
+// merged into the last one, and documents read are cached. Flush executes the
+// collected inserts, updates and deletes in one transaction of the storage, so that a
+// command changes the storage completely or not at all. When the writes are
+// discarded or their transaction fails, the rollback handlers of the tables restore
+// the state of the services from the storage.
+//
+// Queries (Scan, Count and QueryIDs) read the storage and do not see the pending
+// writes of a session.
+type Session struct {
+	store   *Store
+	mu      sync.Mutex
+	entries map[sessionKey]*sessionEntry
+	order   []sessionKey // keys in the order of their first write
+	reads   map[sessionKey][]byte
+}
+
+// sessionKey identifies a document of a session
+type sessionKey struct {
+	table string
+	id    string
+}
+
+// sessionEntry is the pending write of a document
+type sessionEntry struct {
+	data     []byte
+	deleted  bool
+	revision int // revision written, 0 for documents without revision check
+	expected int // revision the stored document must have
+}
+
+// sessionContextKey is the context key of the session
+type sessionContextKey struct{}
+
+// NewSession starts a unit of work on the store
+func (s *Store) NewSession() *Session {
+	return &Session{
+		store:   s,
+		entries: make(map[sessionKey]*sessionEntry),
+		order:   make([]sessionKey, 0),
+		reads:   make(map[sessionKey][]byte),
+	}
+}
+
+// WithSession returns a context whose store writes are collected in a session
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// GetSession returns the session of a context, if any
+func GetSession(ctx context.Context) (*Session, bool) {
+	session, ok := ctx.Value(sessionContextKey{}).(*Session)
+	return session, ok && session != nil
+}
+
+// session returns the session of a context for this store, or nil
+func (s *Store) session(ctx context.Context) *Session {
+	if session, ok := GetSession(ctx); ok && session.store == s {
+		return session
+	}
+	return nil
+}
+
+// OnRollback registers the function called for documents of a table whose pending
+// writes in a session were discarded or failed, e.g. to reload their state from the store
+func (s *Store) OnRollback(table string, fn func(ctx context.Context, id string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rollbackHandlers == nil {
+		s.rollbackHandlers = make(map[string]func(ctx context.Context, id string))
+	}
+	s.rollbackHandlers[table] = fn
+}
+
+// put records the write of a document. For documents with revision check, the revision
+// the stored document must have is the one before the first write in the session.
+func (s *Session) put(table, id string, data []byte, revision int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(table, id, revision-1)
+	entry.data = data
+	entry.deleted = false
+	entry.revision = revision
+}
+
+// delete records the deletion of a document
+func (s *Session) delete(table, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(table, id, 0)
+	entry.data = nil
+	entry.deleted = true
+}
+
+// entry returns the pending write of a document, adding it if there is none
+func (s *Session) entry(table, id string, expected int) *sessionEntry {
+	key := sessionKey{table: table, id: id}
+	entry, exists := s.entries[key]
+	if !exists {
+		entry = &sessionEntry{expected: expected}
+		s.entries[key] = entry
+		s.order = append(s.order, key)
+	}
+	return entry
+}
+
+// get returns the pending write or the cached read of a document
+func (s *Session) get(table, id string) (data []byte, deleted, cached bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := sessionKey{table: table, id: id}
+	if entry, exists := s.entries[key]; exists {
+		return entry.data, entry.deleted, true
+	}
+	data, cached = s.reads[key]
+	return data, false, cached
+}
+
+// cache remembers a document read in the session
+func (s *Session) cache(table, id string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reads[sessionKey{table: table, id: id}] = data
+}
+
+// Pending returns the number of documents with pending writes
+func (s *Session) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Flush executes the pending writes in one transaction and clears them. If a write
+// fails, e.g. with an *OptimisticLockingError because a document was modified
+// concurrently, nothing is written and the documents are rolled back.
+func (s *Session) Flush(ctx context.Context) error {
+	entries, order := s.clear()
+	if len(order) == 0 {
+		return nil
+	}
+
+	tx, err := s.store.storage.Begin(ctx)
+	if err != nil {
+		s.rollback(ctx, order)
+		return err
+	}
+	if err := s.writeAll(ctx, tx, entries, order); err != nil {
+		_ = tx.Rollback()
+		s.rollback(ctx, order)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		s.rollback(ctx, order)
+		return err
+	}
+	return nil
+}
+
+// writeAll executes the pending writes in a transaction, in batches if the
+// transaction supports them
+func (s *Session) writeAll(ctx context.Context, tx Transaction, entries map[sessionKey]*sessionEntry, order []sessionKey) error {
+	if writer, ok := tx.(batchWriter); ok {
+		if batchSize := s.store.getBatchSize(); batchSize > 1 {
+			return s.writeBatched(ctx, writer, tx, entries, order, batchSize)
+		}
+	}
+	for _, key := range order {
+		if err := s.write(ctx, tx, key, entries[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write executes the pending write of a document in a transaction
+func (s *Session) write(ctx context.Context, tx Transaction, key sessionKey, entry *sessionEntry) error {
+	switch {
+	case entry.deleted:
+		return tx.Delete(ctx, key.table, key.id)
+	case entry.revision > 0:
+		return tx.SaveRevision(ctx, key.table, key.id, entry.data, entry.revision, entry.expected)
+	}
+	return tx.Put(ctx, key.table, key.id, entry.data)
+}
+
+// Discard drops the pending writes, e.g. when the command failed, and rolls back the
+// documents
+func (s *Session) Discard(ctx context.Context) {
+	_, order := s.clear()
+	s.rollback(ctx, order)
+}
+
+// clear removes and returns the pending writes
+func (s *Session) clear() (map[sessionKey]*sessionEntry, []sessionKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, order := s.entries, s.order
+	s.entries = make(map[sessionKey]*sessionEntry)
+	s.order = make([]sessionKey, 0)
+	s.reads = make(map[sessionKey][]byte)
+	return entries, order
+}
+
+// rollback calls the rollback handlers of the tables of documents whose writes were
+// not executed
+func (s *Session) rollback(ctx context.Context, order []sessionKey) {
+	s.store.mu.Lock()
+	handlers := s.store.rollbackHandlers
+	s.store.mu.Unlock()
+
+	// The handlers read the store outside of the session
+	ctx = WithSession(ctx, nil)
+	for _, key := range order {
+		if handler := handlers[key.table]; handler != nil {
+			handler(ctx, key.id)
+		}
+	}
+}
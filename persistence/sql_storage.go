@@ -0,0 +1,180 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// sqlStorage keeps the documents in the tables of a SQL database
+type sqlStorage struct {
+	sqlDocuments
+	db *sql.DB
+}
+
+// newSQLStorage creates a storage on a connection pool
+func newSQLStorage(db *sql.DB, dialect *Dialect) *sqlStorage {
+	return &sqlStorage{
+		sqlDocuments: sqlDocuments{exec: db, dialect: dialect, clock: clock.System()},
+		db:           db,
+	}
+}
+
+// Initialize connects to the database; the tables are created by the migrations of
+// the store
+func (s *sqlStorage) Initialize(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return nil
+}
+
+// Scan calls fn for every document of a table
+func (s *sqlStorage) Scan(ctx context.Context, table string, fn func(id string, data []byte) error) error {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf("SELECT id, data FROM %s", table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		if err := fn(id, []byte(data)); err != nil {
+			return fmt.Errorf("failed to load %s %s: %w", table, id, err)
+		}
+	}
+	return rows.Err()
+}
+
+// Count returns the number of documents in a table
+func (s *sqlStorage) Count(ctx context.Context, table string) (int64, error) {
+	var count int64
+	if err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(*) FROM %s", table)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", table, err)
+	}
+	return count, nil
+}
+
+// Begin starts a database transaction
+func (s *sqlStorage) Begin(ctx context.Context) (Transaction, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	return &sqlTransaction{sqlDocuments: sqlDocuments{exec: tx, dialect: s.dialect, clock: s.clock}, tx: tx}, nil
+}
+
+// Close closes the connection pool
+func (s *sqlStorage) Close() error {
+	return s.db.Close()
+}
+
+// sqlTransaction writes documents in a database transaction
+type sqlTransaction struct {
+	sqlDocuments
+	tx *sql.Tx
+}
+
+// Commit commits the database transaction
+func (t *sqlTransaction) Commit() error {
+	if err := t.tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// Rollback rolls the database transaction back
+func (t *sqlTransaction) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// executor runs statements on the connection pool or in a transaction
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// sqlDocuments reads and writes documents with the statements of a dialect
+type sqlDocuments struct {
+	exec    executor
+	dialect *Dialect
+	clock   clock.Clock // tells the update times of written documents
+}
+
+// Get reads a document
+func (d *sqlDocuments) Get(ctx context.Context, table, id string) ([]byte, error) {
+	var data string
+	statement := fmt.Sprintf("SELECT data FROM %s WHERE id = %s", table, d.dialect.Placeholder(1))
+	if err := d.exec.QueryRowContext(ctx, statement, id).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s %w: %s", table, errors.ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to read %s %s: %w", table, id, err)
+	}
+	return []byte(data), nil
+}
+
+// Put writes a document
+func (d *sqlDocuments) Put(ctx context.Context, table, id string, data []byte) error {
+	if _, err := d.exec.ExecContext(ctx, d.dialect.Upsert(table), id, string(data), d.clock.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to write %s %s: %w", table, id, err)
+	}
+	return nil
+}
+
+// SaveRevision writes a revision of a document that replaces the expected revision
+func (d *sqlDocuments) SaveRevision(ctx context.Context, table, id string, data []byte, revision, expected int) error {
+	p := d.dialect.Placeholder
+	now := d.clock.Now().UTC()
+	statement := fmt.Sprintf("UPDATE %s SET data = %s, updated_at = %s, revision = %s WHERE id = %s AND revision = %s",
+		table, p(1), p(2), p(3), p(4), p(5))
+	result, err := d.exec.ExecContext(ctx, statement, string(data), now, revision, id, expected)
+	if err != nil {
+		return fmt.Errorf("failed to write %s %s: %w", table, id, err)
+	}
+	if updated, err := result.RowsAffected(); err == nil && updated > 0 {
+		return nil
+	}
+	if expected > 0 {
+		return &OptimisticLockingError{Table: table, ID: id, Revision: expected}
+	}
+
+	statement = fmt.Sprintf("INSERT INTO %s (id, data, updated_at, revision) VALUES (%s, %s, %s, %s)", table, p(1), p(2), p(3), p(4))
+	if _, err := d.exec.ExecContext(ctx, statement, id, string(data), now, revision); err != nil {
+		if _, getErr := d.Get(ctx, table, id); getErr == nil {
+			return &OptimisticLockingError{Table: table, ID: id, Revision: expected}
+		}
+		return fmt.Errorf("failed to write %s %s: %w", table, id, err)
+	}
+	return nil
+}
+
+// Delete removes documents
+func (d *sqlDocuments) Delete(ctx context.Context, table string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	placeholders := make([]byte, 0, len(ids)*4)
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		if i > 0 {
+			placeholders = append(placeholders, ", "...)
+		}
+		placeholders = append(placeholders, d.dialect.Placeholder(i+1)...)
+		args[i] = id
+	}
+
+	statement := fmt.Sprintf("DELETE FROM %s WHERE id IN (%s)", table, placeholders)
+	if _, err := d.exec.ExecContext(ctx, statement, args...); err != nil {
+		return fmt.Errorf("failed to delete from %s: %w", table, err)
+	}
+	return nil
+}
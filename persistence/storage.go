@@ -0,0 +1,60 @@
+package persistence
+
+import (
+	"context"
+)
+
+// DriverMemory is the driver name of the storage keeping the documents in memory,
+// e.g. for tests and demos. It needs no data source name.
+const DriverMemory = "memory"
+
+// Documents reads and writes the documents of the engine tables
+type Documents interface {
+	// Get returns the document stored under an ID, or an error wrapping
+	// errors.ErrNotFound if there is none
+	Get(ctx context.Context, table, id string) ([]byte, error)
+
+	// Put inserts or replaces the document stored under an ID
+	Put(ctx context.Context, table, id string, data []byte) error
+
+	// SaveRevision writes a revision of a document that replaces the expected revision,
+	// or inserts it if expected is 0 and there is none. If the stored document has
+	// another revision, an *OptimisticLockingError is returned.
+	SaveRevision(ctx context.Context, table, id string, data []byte, revision, expected int) error
+
+	// Delete removes the documents stored under the IDs
+	Delete(ctx context.Context, table string, ids ...string) error
+}
+
+// Storage is the backend a store keeps its documents in: a SQL database, or memory
+// for the "memory" driver. Both behave the same, so that services work alike on
+// either, including revision checks and transactions.
+type Storage interface {
+	Documents
+
+	// Initialize creates the engine tables if they do not exist yet
+	Initialize(ctx context.Context) error
+
+	// Scan calls fn for every document of a table
+	Scan(ctx context.Context, table string, fn func(id string, data []byte) error) error
+
+	// Count returns the number of documents in a table
+	Count(ctx context.Context, table string) (int64, error)
+
+	// Begin starts a transaction whose writes become visible together on Commit
+	Begin(ctx context.Context) (Transaction, error)
+
+	// Close releases the resources of the storage
+	Close() error
+}
+
+// Transaction is a unit of writes of a storage, applied completely or not at all
+type Transaction interface {
+	Documents
+
+	// Commit applies the writes of the transaction
+	Commit() error
+
+	// Rollback discards the writes of the transaction
+	Rollback() error
+}
@@ -0,0 +1,411 @@
+// Package persistence stores engine state in a SQL database, or in memory with the
+// "memory" driver.
+//
+// Services keep their working set in memory and write each aggregate (a deployment,
+// a process instance with its executions and variables, a task with its comments, ...)
+// through to the storage as a JSON document. On Initialize the documents are loaded back.
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/muixstudio/flowgo/clock"
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// Tables of the engine schema
+const (
+	TableDeployments               = "flowgo_re_deployment"
+	TableProcessDefinitions        = "flowgo_re_process_definition"
+	TableProcessInstances          = "flowgo_ru_process_instance"
+	TableTasks                     = "flowgo_ru_task"
+	TableExternalTasks             = "flowgo_ru_ext_task"
+	TableJobs                      = "flowgo_ru_job"
+	TableHistoricProcessInstances  = "flowgo_hi_process_instance"
+	TableHistoricTaskInstances     = "flowgo_hi_task_instance"
+	TableHistoricActivityInstances = "flowgo_hi_activity_instance"
+	TableHistoricVariableInstances = "flowgo_hi_variable_instance"
+	TableHistoricDetails           = "flowgo_hi_detail"
+	TableProperties                = "flowgo_ge_property"
+	TableAuthorizations            = "flowgo_ru_authorization"
+	TableByteArrays                = "flowgo_ge_bytearray"
+	TableBatches                   = "flowgo_ru_batch"
+)
+
+// tables lists all tables created by Initialize
+var tables = []string{
+	TableDeployments,
+	TableProcessDefinitions,
+	TableProcessInstances,
+	TableTasks,
+	TableExternalTasks,
+	TableJobs,
+	TableHistoricProcessInstances,
+	TableHistoricTaskInstances,
+	TableHistoricActivityInstances,
+	TableHistoricVariableInstances,
+	TableHistoricDetails,
+	TableProperties,
+	TableAuthorizations,
+	TableByteArrays,
+	TableBatches,
+}
+
+// Tables returns the names of all engine tables
+func Tables() []string {
+	return append([]string(nil), tables...)
+}
+
+// Store reads and writes JSON documents keyed by ID in the engine tables of a storage
+type Store struct {
+	storage          Storage
+	db               *sql.DB  // connection pool of a SQL storage, nil in memory
+	dialect          *Dialect // dialect of a SQL storage, nil in memory
+	schemaUpdate     SchemaUpdate
+	batchSize        int
+	initialized      bool
+	rollbackHandlers map[string]func(ctx context.Context, id string)
+	mu               sync.Mutex
+}
+
+// Open opens a store for a driver name ("mysql", "postgres", "sqlite" or "memory") and
+// data source name, e.g. Open("sqlite", "file:flowgo.db"). The matching database/sql driver
+// must be registered by importing it; the "memory" driver needs none and ignores the
+// data source name.
+func Open(driver, dataSourceName string) (*Store, error) {
+	if strings.EqualFold(driver, DriverMemory) {
+		return NewMemoryStore(), nil
+	}
+
+	dialect, err := GetDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDriver, err := dialect.sqlDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(sqlDriver, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if dialect.SingleConnection {
+		db.SetMaxOpenConns(1)
+	}
+	return &Store{storage: newSQLStorage(db, dialect), db: db, dialect: dialect, batchSize: DefaultBatchSize}, nil
+}
+
+// NewStore creates a store on an existing connection pool
+func NewStore(db *sql.DB, driver string) (*Store, error) {
+	dialect, err := GetDialect(driver)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{storage: newSQLStorage(db, dialect), db: db, dialect: dialect, batchSize: DefaultBatchSize}, nil
+}
+
+// NewMemoryStore creates a store keeping the documents in memory
+func NewMemoryStore() *Store {
+	return NewStorageStore(NewMemoryStorage())
+}
+
+// NewStorageStore creates a store on a storage, e.g. a custom backend
+func NewStorageStore(storage Storage) *Store {
+	return &Store{storage: storage, batchSize: DefaultBatchSize}
+}
+
+// DB returns the underlying connection pool, or nil if the store is not kept in a
+// SQL database
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Dialect returns the SQL dialect of the store, or nil if the store is not kept in a
+// SQL database
+func (s *Store) Dialect() *Dialect {
+	return s.dialect
+}
+
+// SetClock sets the clock the update times of documents written to a SQL database
+// are based on, e.g. a test clock. By default, the clock of the operating system is used.
+func (s *Store) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if storage, ok := s.storage.(*sqlStorage); ok {
+		storage.clock = c
+	}
+}
+
+// Initialize creates the engine tables if they do not exist yet, and in a database
+// applies the pending migrations of the schema, or only validates it with
+// SchemaUpdateValidate. It is safe to call from every service; the schema is
+// bootstrapped once.
+func (s *Store) Initialize(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initialized {
+		return nil
+	}
+	if err := s.storage.Initialize(ctx); err != nil {
+		return err
+	}
+	if s.db != nil {
+		if s.schemaUpdate == SchemaUpdateValidate {
+			if err := s.ValidateSchema(ctx); err != nil {
+				return err
+			}
+		} else if err := s.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.initialized = true
+	return nil
+}
+
+// Put inserts or replaces the document stored under an ID. Within a session the
+// write is deferred until the session is flushed.
+func (s *Store) Put(ctx context.Context, table, id string, data []byte) error {
+	if session := s.session(ctx); session != nil {
+		session.put(table, id, data, 0)
+		return nil
+	}
+	return s.storage.Put(ctx, table, id, data)
+}
+
+// Save stores a value as the JSON document under an ID
+func (s *Store) Save(ctx context.Context, table, id string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %w", table, id, err)
+	}
+	return s.Put(ctx, table, id, data)
+}
+
+// SaveRevision stores a value as revision of the JSON document under an ID, detecting
+// concurrent modification: the stored document must still be the previous revision,
+// or not exist yet for revision 1. Otherwise another transaction, e.g. of another
+// engine, changed it since it was read and an *OptimisticLockingError is returned.
+// Within a session the check happens when the session is flushed.
+func (s *Store) SaveRevision(ctx context.Context, table, id string, value interface{}, revision int) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %s: %w", table, id, err)
+	}
+	if session := s.session(ctx); session != nil {
+		session.put(table, id, data, revision)
+		return nil
+	}
+	return s.storage.SaveRevision(ctx, table, id, data, revision, revision-1)
+}
+
+// OptimisticLockingError reports that a document was changed by another transaction
+// since it was read. The operation can be retried once the current state is read.
+type OptimisticLockingError struct {
+	Table    string
+	ID       string
+	Revision int // revision the write expected to replace
+}
+
+// Error describes the conflicting document
+func (e *OptimisticLockingError) Error() string {
+	return fmt.Sprintf("%s %s was modified concurrently since revision %d", e.Table, e.ID, e.Revision)
+}
+
+// Is reports the error as errors.ErrOptimisticLock
+func (e *OptimisticLockingError) Is(target error) bool {
+	return target == errors.ErrOptimisticLock
+}
+
+// Get returns the document stored under an ID. Within a session it returns the
+// pending write of the document, or the document read before in the session.
+func (s *Store) Get(ctx context.Context, table, id string) ([]byte, error) {
+	session := s.session(ctx)
+	if session != nil {
+		if data, deleted, cached := session.get(table, id); cached {
+			if deleted {
+				return nil, fmt.Errorf("%s %w: %s", table, errors.ErrNotFound, id)
+			}
+			return data, nil
+		}
+	}
+
+	data, err := s.storage.Get(ctx, table, id)
+	if err == nil && session != nil {
+		session.cache(table, id, data)
+	}
+	return data, err
+}
+
+// Delete removes the documents stored under the IDs. Within a session the deletion is
+// deferred until the session is flushed.
+func (s *Store) Delete(ctx context.Context, table string, ids ...string) error {
+	if session := s.session(ctx); session != nil {
+		for _, id := range ids {
+			session.delete(table, id)
+		}
+		return nil
+	}
+	return s.storage.Delete(ctx, table, ids...)
+}
+
+// Scan calls fn for every document of a table
+func (s *Store) Scan(ctx context.Context, table string, fn func(id string, data []byte) error) error {
+	return s.storage.Scan(ctx, table, fn)
+}
+
+// Count returns the number of documents in a table
+func (s *Store) Count(ctx context.Context, table string) (int64, error) {
+	return s.storage.Count(ctx, table)
+}
+
+// QueryIDs runs a native, read-only SQL query and returns the values of its first
+// column, which must hold document IDs. Named parameters are written as #{name} and
+// bound with the placeholders of the dialect. It needs a SQL database.
+//
+// The query runs in a read-only transaction that is always rolled back, so that
+// nothing it writes, e.g. through a data-modifying WITH clause, is kept even where
+// the database does not enforce read-only transactions.
+func (s *Store) QueryIDs(ctx context.Context, query string, parameters map[string]interface{}) ([]string, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("native queries require a SQL database")
+	}
+
+	if words := strings.Fields(query); len(words) == 0 || (!strings.EqualFold(words[0], "SELECT") && !strings.EqualFold(words[0], "WITH")) {
+		return nil, fmt.Errorf("native query must be a SELECT statement")
+	}
+	statement, args, err := bindNativeQuery(query, parameters, s.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute native query: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute native query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute native query: %w", err)
+	}
+	values := make([]interface{}, len(columns))
+	for i := range values {
+		values[i] = new(interface{})
+	}
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		if err := rows.Scan(values...); err != nil {
+			return nil, fmt.Errorf("failed to read native query result: %w", err)
+		}
+		switch id := (*values[0].(*interface{})).(type) {
+		case string:
+			ids = append(ids, id)
+		case []byte:
+			ids = append(ids, string(id))
+		default:
+			return nil, fmt.Errorf("first column of native query must hold IDs, got %T", id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+// bindNativeQuery replaces the #{name} parameters of a native query with the
+// placeholders of a dialect and returns the statement with the values to bind.
+// String literals, quoted identifiers and comments are copied as they are. A query
+// of more than one statement is rejected; a trailing semicolon is dropped.
+func bindNativeQuery(query string, parameters map[string]interface{}, dialect *Dialect) (string, []interface{}, error) {
+	var sb strings.Builder
+	args := make([]interface{}, 0)
+	for i := 0; i < len(query); {
+		switch {
+		case query[i] == '\'' || query[i] == '"' || query[i] == '`':
+			// A quote is escaped by doubling it
+			quote := query[i]
+			end := i + 1
+			for ; end < len(query); end++ {
+				if query[end] == quote {
+					if end+1 < len(query) && query[end+1] == quote {
+						end++
+						continue
+					}
+					break
+				}
+			}
+			if end == len(query) {
+				return "", nil, fmt.Errorf("unterminated quote in native query")
+			}
+			sb.WriteString(query[i : end+1])
+			i = end + 1
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			sb.WriteString(query[i : i+end])
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return "", nil, fmt.Errorf("unterminated comment in native query")
+			}
+			sb.WriteString(query[i : i+end+4])
+			i += end + 4
+		case strings.HasPrefix(query[i:], "#{"):
+			end := strings.IndexByte(query[i:], '}')
+			if end < 0 {
+				return "", nil, fmt.Errorf("unterminated parameter in native query")
+			}
+			name := query[i+2 : i+end]
+			value, exists := parameters[name]
+			if !exists {
+				return "", nil, fmt.Errorf("missing value for native query parameter '%s'", name)
+			}
+			args = append(args, value)
+			sb.WriteString(dialect.Placeholder(len(args)))
+			i += end + 1
+		case query[i] == ';':
+			if strings.TrimSpace(query[i+1:]) != "" {
+				return "", nil, fmt.Errorf("native query must be a single statement")
+			}
+			i = len(query)
+		default:
+			sb.WriteByte(query[i])
+			i++
+		}
+	}
+	return strings.TrimSpace(sb.String()), args, nil
+}
+
+// Close closes the underlying storage, e.g. its connection pool
+func (s *Store) Close() error {
+	return s.storage.Close()
+}
+
+// Load decodes every document of a table and passes the values to fn
+func Load[T any](ctx context.Context, store *Store, table string, fn func(value *T)) error {
+	return store.Scan(ctx, table, func(id string, data []byte) error {
+		value := new(T)
+		if err := json.Unmarshal(data, value); err != nil {
+			return err
+		}
+		fn(value)
+		return nil
+	})
+}
@@ -0,0 +1,152 @@
+// Package cache provides a size-limited least recently used cache, e.g. for parsed
+// process models that are expensive to build but never change.
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Stats are the counters of a cache
+type Stats struct {
+	// Size is the number of entries in the cache
+	Size int `json:"size"`
+	// Capacity is the maximum number of entries; 0 means unlimited
+	Capacity int `json:"capacity"`
+	// Hits is the number of lookups that found an entry
+	Hits int64 `json:"hits"`
+	// Misses is the number of lookups that found no entry
+	Misses int64 `json:"misses"`
+	// Evictions is the number of entries removed to make room for new ones
+	Evictions int64 `json:"evictions"`
+}
+
+// LRU is a cache that evicts the least recently used entry when it is full.
+// It is safe for concurrent use.
+type LRU[K comparable, V any] struct {
+	capacity  int
+	items     map[K]*list.Element
+	order     *list.List // front is the most recently used entry
+	hits      int64
+	misses    int64
+	evictions int64
+	mu        sync.Mutex
+}
+
+// lruEntry is an entry of the order list
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// NewLRU creates a cache holding at most capacity entries; a capacity of 0 or less
+// means unlimited
+func NewLRU[K comparable, V any](capacity int) *LRU[K, V] {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &LRU[K, V]{
+		capacity: capacity,
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the value of a key and marks it as most recently used
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, exists := c.items[key]
+	if !exists {
+		c.misses++
+		var zero V
+		return zero, false
+	}
+	c.hits++
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// Put adds or replaces the value of a key, evicting the least recently used entries
+// when the cache is full
+func (c *LRU[K, V]) Put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.items[key]; exists {
+		element.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	c.evictLocked()
+}
+
+// Remove removes the entry of a key, if any
+func (c *LRU[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, exists := c.items[key]; exists {
+		c.order.Remove(element)
+		delete(c.items, key)
+	}
+}
+
+// Clear removes all entries; the counters are kept
+func (c *LRU[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+// SetCapacity changes the maximum number of entries, evicting entries if the cache
+// holds more
+func (c *LRU[K, V]) SetCapacity(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if capacity < 0 {
+		capacity = 0
+	}
+	c.capacity = capacity
+	c.evictLocked()
+}
+
+// Len returns the number of entries
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.items)
+}
+
+// Stats returns the size, capacity and counters of the cache
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return Stats{
+		Size:      len(c.items),
+		Capacity:  c.capacity,
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// evictLocked removes the least recently used entries beyond the capacity
+func (c *LRU[K, V]) evictLocked() {
+	if c.capacity == 0 {
+		return
+	}
+	for len(c.items) > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+		c.evictions++
+	}
+}
@@ -0,0 +1,459 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+)
+
+// evaluator evaluates a syntax tree against variables and functions
+type evaluator struct {
+	variables map[string]interface{}
+	functions map[string]Function
+	clock     clock.Clock // time of now(), unless a function replaces it
+}
+
+// eval evaluates a node
+func (e *evaluator) eval(n node) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		// Unknown variables evaluate to nil so that conditions can test optional variables
+		return e.variables[n.name], nil
+
+	case *unaryNode:
+		operand, err := e.eval(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			b, err := toBool(operand)
+			if err != nil {
+				return nil, err
+			}
+			return !b, nil
+		}
+		return arithmetic("-", int64(0), operand)
+
+	case *binaryNode:
+		return e.evalBinary(n)
+
+	case *ternaryNode:
+		condition, err := e.eval(n.condition)
+		if err != nil {
+			return nil, err
+		}
+		b, err := toBool(condition)
+		if err != nil {
+			return nil, err
+		}
+		if b {
+			return e.eval(n.then)
+		}
+		return e.eval(n.otherwise)
+
+	case *memberNode:
+		target, err := e.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		return property(target, n.name)
+
+	case *indexNode:
+		target, err := e.eval(n.target)
+		if err != nil {
+			return nil, err
+		}
+		index, err := e.eval(n.index)
+		if err != nil {
+			return nil, err
+		}
+		return indexValue(target, index)
+
+	case *callNode:
+		fn, exists := e.functions[n.name]
+		if !exists && n.name == "now" {
+			fn, exists = fnNow(e.clock), true
+		}
+		if !exists {
+			return nil, fmt.Errorf("unknown function '%s'", n.name)
+		}
+		args := make([]interface{}, len(n.args))
+		for i, arg := range n.args {
+			value, err := e.eval(arg)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		result, err := fn(args...)
+		if err != nil {
+			return nil, fmt.Errorf("function '%s' failed: %w", n.name, err)
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("unsupported expression node %T", n)
+}
+
+// evalBinary evaluates a binary operation, short-circuiting logical operators
+func (e *evaluator) evalBinary(n *binaryNode) (interface{}, error) {
+	left, err := e.eval(n.left)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.op == "&&" || n.op == "||" {
+		l, err := toBool(left)
+		if err != nil {
+			return nil, err
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+		right, err := e.eval(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return toBool(right)
+	}
+
+	right, err := e.eval(n.right)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equals(left, right), nil
+	case "!=":
+		return !equals(left, right), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	default:
+		return arithmetic(n.op, left, right)
+	}
+}
+
+// toBool converts a value to a boolean; nil is false
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false", "":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("cannot use %v (%T) as boolean", value, value)
+}
+
+// toNumber converts numeric values to int64 or float64
+func toNumber(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return nil, false
+}
+
+// toFloat converts a numeric value to float64
+func toFloat(value interface{}) (float64, bool) {
+	n, ok := toNumber(value)
+	if !ok {
+		return 0, false
+	}
+	if i, isInt := n.(int64); isInt {
+		return float64(i), true
+	}
+	return n.(float64), true
+}
+
+// equals compares two values, treating all numeric types alike
+func equals(left, right interface{}) bool {
+	if l, ok := toFloat(left); ok {
+		if r, ok := toFloat(right); ok {
+			return l == r
+		}
+	}
+	if l, ok := left.(time.Time); ok {
+		if r, ok := right.(time.Time); ok {
+			return l.Equal(r)
+		}
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// compare orders two numbers, strings, times or durations
+func compare(left, right interface{}) (int, error) {
+	if l, ok := toFloat(left); ok {
+		if r, ok := toFloat(right); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	switch l := left.(type) {
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	case time.Time:
+		if r, ok := right.(time.Time); ok {
+			switch {
+			case l.Before(r):
+				return -1, nil
+			case l.After(r):
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case time.Duration:
+		if r, ok := right.(time.Duration); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot compare %v (%T) with %v (%T)", left, left, right, right)
+}
+
+// arithmetic applies an arithmetic operator
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	// Date arithmetic
+	if l, ok := left.(time.Time); ok {
+		if r, ok := right.(time.Duration); ok {
+			switch op {
+			case "+":
+				return l.Add(r), nil
+			case "-":
+				return l.Add(-r), nil
+			}
+		}
+		if r, ok := right.(time.Time); ok && op == "-" {
+			return l.Sub(r), nil
+		}
+	}
+	if l, ok := left.(time.Duration); ok {
+		if r, ok := right.(time.Duration); ok {
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			}
+		}
+		if r, ok := right.(time.Time); ok && op == "+" {
+			return r.Add(l), nil
+		}
+	}
+
+	// String concatenation
+	if op == "+" {
+		if l, ok := left.(string); ok {
+			return l + stringify(right), nil
+		}
+		if r, ok := right.(string); ok {
+			return stringify(left) + r, nil
+		}
+	}
+
+	l, lok := toNumber(left)
+	r, rok := toNumber(right)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply '%s' to %v (%T) and %v (%T)", op, left, left, right, right)
+	}
+
+	li, lInt := l.(int64)
+	ri, rInt := r.(int64)
+	if lInt && rInt {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/":
+			if ri == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			if li%ri == 0 {
+				return li / ri, nil
+			}
+			return float64(li) / float64(ri), nil
+		case "%":
+			if ri == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, _ := toFloat(l)
+	rf, _ := toFloat(r)
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case "%":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(lf, rf), nil
+	}
+	return nil, fmt.Errorf("unknown operator '%s'", op)
+}
+
+// property reads a named property from a map or struct
+func property(target interface{}, name string) (interface{}, error) {
+	if target == nil {
+		return nil, nil
+	}
+
+	if m, ok := target.(map[string]interface{}); ok {
+		return m[name], nil
+	}
+
+	v := reflect.ValueOf(target)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !value.IsValid() {
+			return nil, nil
+		}
+		return value.Interface(), nil
+	case reflect.Struct:
+		field := v.FieldByName(name)
+		if !field.IsValid() {
+			// Allow lower camel case access to exported fields
+			field = v.FieldByName(strings.ToUpper(name[:1]) + name[1:])
+		}
+		if field.IsValid() && field.CanInterface() {
+			return field.Interface(), nil
+		}
+		return nil, fmt.Errorf("unknown property '%s' on %T", name, target)
+	}
+
+	return nil, fmt.Errorf("cannot read property '%s' of %v (%T)", name, target, target)
+}
+
+// indexValue reads an element of a list or map
+func indexValue(target, index interface{}) (interface{}, error) {
+	if target == nil {
+		return nil, nil
+	}
+	if name, ok := index.(string); ok {
+		return property(target, name)
+	}
+
+	n, ok := toNumber(index)
+	i, isInt := n.(int64)
+	if !ok || !isInt {
+		return nil, fmt.Errorf("invalid index %v (%T)", index, index)
+	}
+
+	v := reflect.ValueOf(target)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array, reflect.String:
+		if i < 0 || int(i) >= v.Len() {
+			return nil, fmt.Errorf("index %d out of range", i)
+		}
+		if v.Kind() == reflect.String {
+			return string(v.String()[i]), nil
+		}
+		return v.Index(int(i)).Interface(), nil
+	}
+	return nil, fmt.Errorf("cannot index %v (%T)", target, target)
+}
+
+// stringify renders a value for string concatenation and templates
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return fmt.Sprintf("%d", int64(v))
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,178 @@
+// Package expression implements the expression language used in process
+// definitions, e.g. for sequence flow conditions and conditional events.
+//
+// Expressions are written as ${...}. A source consisting of a single
+// expression evaluates to the expression's value; a source mixing text and
+// expressions evaluates to a string with each expression substituted.
+package expression
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/muixstudio/flowgo/clock"
+)
+
+// segment is a literal text part or a parsed expression of a source
+type segment struct {
+	text string
+	expr node
+}
+
+// Expression is a compiled expression source
+type Expression struct {
+	source   string
+	segments []segment
+}
+
+// IsExpression reports whether the source contains an ${...} expression
+func IsExpression(source string) bool {
+	return strings.Contains(source, "${")
+}
+
+// Compile parses an expression source
+func Compile(source string) (*Expression, error) {
+	e := &Expression{source: source}
+
+	rest := source
+	for {
+		start := strings.Index(rest, "${")
+		if start < 0 {
+			if rest != "" {
+				e.segments = append(e.segments, segment{text: rest})
+			}
+			return e, nil
+		}
+		if start > 0 {
+			e.segments = append(e.segments, segment{text: rest[:start]})
+		}
+
+		end := findClosingBrace(rest, start+2)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated expression in '%s'", source)
+		}
+
+		body := rest[start+2 : end]
+		if strings.TrimSpace(body) == "" {
+			return nil, fmt.Errorf("empty expression in '%s'", source)
+		}
+		n, err := parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression '%s': %w", source, err)
+		}
+		e.segments = append(e.segments, segment{expr: n})
+		rest = rest[end+1:]
+	}
+}
+
+// findClosingBrace returns the index of the brace closing an expression, skipping quoted strings
+func findClosingBrace(s string, from int) int {
+	var quote byte
+	for i := from; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// Source returns the original source of the expression
+func (e *Expression) Source() string {
+	return e.source
+}
+
+// Evaluate evaluates the expression with the given variables
+func (e *Expression) Evaluate(variables map[string]interface{}) (interface{}, error) {
+	return e.EvaluateWithFunctions(variables, nil)
+}
+
+// EvaluateWithFunctions evaluates the expression with the given variables and
+// additional functions. Additional functions take precedence over built-ins.
+// now() tells the time of the system clock.
+func (e *Expression) EvaluateWithFunctions(variables map[string]interface{}, functions map[string]Function) (interface{}, error) {
+	return e.evaluate(variables, functions, clock.System())
+}
+
+// evaluate evaluates the expression with the given variables, additional functions
+// and the clock now() tells the time of
+func (e *Expression) evaluate(variables map[string]interface{}, functions map[string]Function, c clock.Clock) (interface{}, error) {
+	ev := &evaluator{variables: variables, functions: builtinFunctions, clock: c}
+	if len(functions) > 0 {
+		ev.functions = make(map[string]Function, len(builtinFunctions)+len(functions))
+		for name, fn := range builtinFunctions {
+			ev.functions[name] = fn
+		}
+		for name, fn := range functions {
+			ev.functions[name] = fn
+		}
+	}
+
+	if len(e.segments) == 0 {
+		return "", nil
+	}
+	if len(e.segments) == 1 {
+		if e.segments[0].expr == nil {
+			return e.segments[0].text, nil
+		}
+		result, err := ev.eval(e.segments[0].expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate '%s': %w", e.source, err)
+		}
+		return result, nil
+	}
+
+	var sb strings.Builder
+	for _, seg := range e.segments {
+		if seg.expr == nil {
+			sb.WriteString(seg.text)
+			continue
+		}
+		value, err := ev.eval(seg.expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate '%s': %w", e.source, err)
+		}
+		sb.WriteString(stringify(value))
+	}
+	return sb.String(), nil
+}
+
+// EvaluateBool evaluates the expression and converts the result to a boolean
+func (e *Expression) EvaluateBool(variables map[string]interface{}) (bool, error) {
+	result, err := e.Evaluate(variables)
+	if err != nil {
+		return false, err
+	}
+	b, err := toBool(result)
+	if err != nil {
+		return false, fmt.Errorf("expression '%s' did not evaluate to a boolean: %w", e.source, err)
+	}
+	return b, nil
+}
+
+// Evaluate compiles and evaluates an expression source
+func Evaluate(source string, variables map[string]interface{}) (interface{}, error) {
+	e, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+	return e.Evaluate(variables)
+}
+
+// EvaluateBool compiles and evaluates an expression source as a boolean
+func EvaluateBool(source string, variables map[string]interface{}) (bool, error) {
+	e, err := Compile(source)
+	if err != nil {
+		return false, err
+	}
+	return e.EvaluateBool(variables)
+}
@@ -0,0 +1,175 @@
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/clock"
+)
+
+// Function is a function callable from expressions
+type Function func(args ...interface{}) (interface{}, error)
+
+// builtinFunctions are available to every expression, together with now(), which
+// tells the time of the clock of the evaluation
+var builtinFunctions = map[string]Function{
+	"duration": fnDuration,
+	"date":     fnDate,
+	"len":      fnLen,
+	"contains": fnContains,
+	"upper":    fnUpper,
+	"lower":    fnLower,
+}
+
+// fnNow returns the now() function telling the time of a clock
+func fnNow(c clock.Clock) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("expected no arguments")
+		}
+		return c.Now(), nil
+	}
+}
+
+// fnDuration parses an ISO 8601 or Go duration string
+func fnDuration(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument")
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string argument")
+	}
+	return ParseDuration(s)
+}
+
+// fnDate parses an RFC 3339 date or date-time string
+func fnDate(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument")
+	}
+	s, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a string argument")
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %s", s)
+	}
+	return t, nil
+}
+
+// fnLen returns the length of a string, list or map
+func fnLen(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument")
+	}
+	if args[0] == nil {
+		return int64(0), nil
+	}
+	v := reflect.ValueOf(args[0])
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return int64(v.Len()), nil
+	}
+	return nil, fmt.Errorf("cannot take length of %T", args[0])
+}
+
+// fnContains reports whether a string contains a substring or a list contains an element
+func fnContains(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("expected 2 arguments")
+	}
+	if s, ok := args[0].(string); ok {
+		return strings.Contains(s, stringify(args[1])), nil
+	}
+	if args[0] == nil {
+		return false, nil
+	}
+	v := reflect.ValueOf(args[0])
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if equals(v.Index(i).Interface(), args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case reflect.Map:
+		value, err := property(args[0], stringify(args[1]))
+		return err == nil && value != nil, nil
+	}
+	return nil, fmt.Errorf("cannot search in %T", args[0])
+}
+
+// fnUpper converts a string to upper case
+func fnUpper(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument")
+	}
+	return strings.ToUpper(stringify(args[0])), nil
+}
+
+// fnLower converts a string to lower case
+func fnLower(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument")
+	}
+	return strings.ToLower(stringify(args[0])), nil
+}
+
+// isoDurationPattern matches ISO 8601 durations such as P1Y2M3DT4H5M6S or P2W
+var isoDurationPattern = regexp.MustCompile(`^P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration parses an ISO 8601 duration (e.g. "P2D", "PT30M") or a Go
+// duration string (e.g. "5m"). Months count as 30 days and years as 365 days.
+func ParseDuration(s string) (time.Duration, error) {
+	if !strings.HasPrefix(s, "P") {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		return d, nil
+	}
+
+	matches := isoDurationPattern.FindStringSubmatch(s)
+	if matches == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration: %s", s)
+	}
+
+	units := []time.Duration{
+		365 * 24 * time.Hour,
+		30 * 24 * time.Hour,
+		7 * 24 * time.Hour,
+		24 * time.Hour,
+		time.Hour,
+		time.Minute,
+	}
+
+	var d time.Duration
+	for i, unit := range units {
+		if matches[i+1] == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(matches[i+1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		d += time.Duration(n) * unit
+	}
+	if matches[7] != "" {
+		seconds, err := strconv.ParseFloat(matches[7], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		d += time.Duration(seconds * float64(time.Second))
+	}
+	return d, nil
+}
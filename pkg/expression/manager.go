@@ -0,0 +1,111 @@
+package expression
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/muixstudio/flowgo/clock"
+)
+
+// Manager evaluates expressions with custom functions in addition to the
+// built-ins and caches compiled expressions by source
+type Manager struct {
+	mu        sync.RWMutex
+	functions map[string]Function
+	cache     map[string]*Expression
+	clock     clock.Clock
+}
+
+// NewManager creates an expression manager without custom functions whose now()
+// tells the time of the system clock
+func NewManager() *Manager {
+	return &Manager{
+		functions: make(map[string]Function),
+		cache:     make(map[string]*Expression),
+		clock:     clock.System(),
+	}
+}
+
+// SetClock sets the clock the now() function of expressions tells the time of,
+// e.g. the clock of the engine
+func (m *Manager) SetClock(c clock.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.clock = c
+}
+
+// RegisterFunction makes a function callable from expressions, e.g. ${discount(amount)}.
+// Custom functions take precedence over built-in functions of the same name.
+func (m *Manager) RegisterFunction(name string, fn Function) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.functions[name] = fn
+}
+
+// Functions returns the names of the registered custom functions
+func (m *Manager) Functions() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.functions))
+	for name := range m.functions {
+		names = append(names, name)
+	}
+	return names
+}
+
+// Compile returns the compiled expression of a source
+func (m *Manager) Compile(source string) (*Expression, error) {
+	m.mu.RLock()
+	e, exists := m.cache[source]
+	m.mu.RUnlock()
+	if exists {
+		return e, nil
+	}
+
+	e, err := Compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.cache[source] = e
+	m.mu.Unlock()
+	return e, nil
+}
+
+// Evaluate compiles and evaluates an expression source with the custom functions
+func (m *Manager) Evaluate(source string, variables map[string]interface{}) (interface{}, error) {
+	e, err := m.Compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	c := m.clock
+	functions := m.functions
+	if len(functions) > 0 {
+		functions = make(map[string]Function, len(m.functions))
+		for name, fn := range m.functions {
+			functions[name] = fn
+		}
+	}
+	m.mu.RUnlock()
+
+	return e.evaluate(variables, functions, c)
+}
+
+// EvaluateBool compiles and evaluates an expression source as a boolean
+func (m *Manager) EvaluateBool(source string, variables map[string]interface{}) (bool, error) {
+	result, err := m.Evaluate(source, variables)
+	if err != nil {
+		return false, err
+	}
+	b, err := toBool(result)
+	if err != nil {
+		return false, fmt.Errorf("expression '%s' did not evaluate to a boolean: %w", source, err)
+	}
+	return b, nil
+}
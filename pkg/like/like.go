@@ -0,0 +1,45 @@
+// Package like matches values against the SQL LIKE patterns of queries, e.g. the name
+// filters of process definition, process instance and variable queries.
+package like
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/muixstudio/flowgo/pkg/cache"
+)
+
+// patternCacheSize is the number of compiled patterns kept for reuse
+const patternCacheSize = 256
+
+// patterns caches the compiled patterns, as queries tend to repeat their filters
+var patterns = cache.NewLRU[string, *regexp.Regexp](patternCacheSize)
+
+// Match reports whether a value matches a SQL LIKE pattern: % matches any sequence of
+// characters, line breaks included, and _ matches a single character
+func Match(value, pattern string) bool {
+	re, ok := patterns.Get(pattern)
+	if !ok {
+		re = compile(pattern)
+		patterns.Put(pattern, re)
+	}
+	return re.MatchString(value)
+}
+
+// compile translates a LIKE pattern into a regular expression matching whole values
+func compile(pattern string) *regexp.Regexp {
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			sb.WriteString(".*")
+		case '_':
+			sb.WriteString(".")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.MustCompile(sb.String())
+}
@@ -0,0 +1,85 @@
+// Package paging provides the helpers the queries of the services share to stream
+// their results page by page.
+package paging
+
+import (
+	"context"
+	"iter"
+	"sort"
+)
+
+// Paginate returns the requested page of a result list; maxResults <= 0 means no limit
+func Paginate[T any](result []T, firstResult, maxResults int) []T {
+	if firstResult > 0 {
+		if firstResult >= len(result) {
+			return result[:0]
+		}
+		result = result[firstResult:]
+	}
+	if maxResults > 0 && maxResults < len(result) {
+		result = result[:maxResults]
+	}
+	return result
+}
+
+// Sort sorts the results of a query in the requested order given by less. The results
+// are sorted by tieBreak, e.g. by ID, first, so that results tied in the requested
+// order keep the same relative order across pages.
+func Sort[T any](result []T, tieBreak, less func(a, b T) bool) {
+	sort.Slice(result, func(i, j int) bool {
+		return tieBreak(result[i], result[j])
+	})
+	sort.SliceStable(result, func(i, j int) bool {
+		return less(result[i], result[j])
+	})
+}
+
+// Less reports whether a result comes before another it compares to as c, following
+// the direction the results are requested in
+func Less(c int, ascending bool) bool {
+	if ascending {
+		return c < 0
+	}
+	return c > 0
+}
+
+// BatchSize is the number of results an iterator fetches at a time
+const BatchSize = 500
+
+// Iterate streams the results of a query, fetching them in pages of BatchSize.
+// It starts at firstResult and stops after maxResults results if maxResults > 0.
+func Iterate[T any](ctx context.Context, firstResult, maxResults int, listPage func(firstResult, maxResults int) ([]T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		remaining := maxResults
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			size := BatchSize
+			if maxResults > 0 && remaining < size {
+				size = remaining
+			}
+			page, err := listPage(firstResult, size)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(page) < size {
+				return
+			}
+			firstResult += len(page)
+			if maxResults > 0 {
+				if remaining -= len(page); remaining == 0 {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are shorthands for common cron expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+var (
+	monthNames   = map[string]int{"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6, "JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12}
+	weekdayNames = map[string]int{"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6}
+)
+
+// cronField is the set of allowed values of one field of a cron expression
+type cronField struct {
+	values     map[int]bool
+	restricted bool // false for "*" and "?"
+}
+
+// cronSchedule is a parsed cron expression
+type cronSchedule struct {
+	second, minute, hour, dayOfMonth, month, dayOfWeek cronField
+}
+
+// parseCron parses a cron expression with 5 fields (minute hour day-of-month month
+// day-of-week) or 6 fields (with seconds first)
+func parseCron(value string) (*cronSchedule, error) {
+	if macro, ok := cronMacros[strings.ToLower(value)]; ok {
+		value = macro
+	}
+
+	fields := strings.Fields(value)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("invalid cron expression: %s", value)
+	}
+
+	specs := []struct {
+		min, max int
+		names    map[string]int
+	}{
+		{0, 59, nil},
+		{0, 59, nil},
+		{0, 23, nil},
+		{1, 31, nil},
+		{1, 12, monthNames},
+		{0, 7, weekdayNames},
+	}
+	parsed := make([]cronField, len(fields))
+	for i, field := range fields {
+		f, err := parseCronField(field, specs[i].min, specs[i].max, specs[i].names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression '%s': %w", value, err)
+		}
+		parsed[i] = f
+	}
+
+	// Sunday is 0 or 7
+	if parsed[5].values[7] {
+		parsed[5].values[0] = true
+	}
+	return &cronSchedule{
+		second:     parsed[0],
+		minute:     parsed[1],
+		hour:       parsed[2],
+		dayOfMonth: parsed[3],
+		month:      parsed[4],
+		dayOfWeek:  parsed[5],
+	}, nil
+}
+
+// parseCronField parses a comma separated list of values, ranges and steps such
+// as "*/15", "1-5", "MON,WED" or "10-40/10"
+func parseCronField(field string, min, max int, names map[string]int) (cronField, error) {
+	result := cronField{values: make(map[int]bool)}
+	if field == "*" || field == "?" {
+		for v := min; v <= max; v++ {
+			result.values[v] = true
+		}
+		return result, nil
+	}
+	result.restricted = true
+
+	parseValue := func(s string) (int, error) {
+		if v, ok := names[strings.ToUpper(s)]; ok {
+			return v, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil || v < min || v > max {
+			return 0, fmt.Errorf("invalid value '%s'", s)
+		}
+		return v, nil
+	}
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return cronField{}, fmt.Errorf("invalid step in '%s'", item)
+			}
+			rangePart, step = item[:i], n
+		}
+
+		var from, to int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			from, to = min, max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if from, err = parseValue(bounds[0]); err != nil {
+				return cronField{}, err
+			}
+			if to, err = parseValue(bounds[1]); err != nil {
+				return cronField{}, err
+			}
+			if from > to {
+				return cronField{}, fmt.Errorf("invalid range '%s'", rangePart)
+			}
+		default:
+			v, err := parseValue(rangePart)
+			if err != nil {
+				return cronField{}, err
+			}
+			from, to = v, v
+			// "5/15" means every 15 starting at 5
+			if step > 1 {
+				to = max
+			}
+		}
+
+		for v := from; v <= to; v += step {
+			result.values[v] = true
+		}
+	}
+	return result, nil
+}
+
+// matchesDay reports whether a day matches the day-of-month and day-of-week fields.
+// When both are restricted, a day matching either of them matches.
+func (c *cronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := c.dayOfMonth.values[t.Day()]
+	dayOfWeek := c.dayOfWeek.values[int(t.Weekday())]
+	if c.dayOfMonth.restricted && c.dayOfWeek.restricted {
+		return dayOfMonth || dayOfWeek
+	}
+	return dayOfMonth && dayOfWeek
+}
+
+// next returns the first point in time after a given one that matches the
+// expression, searching up to five years ahead
+func (c *cronSchedule) next(after time.Time) (time.Time, bool) {
+	t := after.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	loc := t.Location()
+
+	for t.Before(limit) {
+		if !c.month.values[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.hour.values[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !c.minute.values[t.Minute()] {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+		if !c.second.values[t.Second()] {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,108 @@
+// Package schedule computes the due dates of repeating timers. Cycles are ISO 8601
+// repeating intervals such as "R3/PT10M" or "R/2026-01-01T08:00:00Z/P1D", or cron
+// expressions such as "0 9 * * MON-FRI" (5 fields) or "0 0 9 * * ?" (6 fields with
+// seconds first).
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// Cycle is a parsed timer cycle
+type Cycle struct {
+	repetitions int           // number of occurrences; -1 if unbounded
+	start       time.Time     // first occurrence of a repeating interval, if given
+	interval    time.Duration // time between occurrences of a repeating interval
+	cron        *cronSchedule
+}
+
+// Parse parses an ISO 8601 repeating interval or a cron expression
+func Parse(value string) (*Cycle, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "R") {
+		return parseRepeatingInterval(value)
+	}
+
+	cron, err := parseCron(value)
+	if err != nil {
+		return nil, err
+	}
+	return &Cycle{repetitions: -1, cron: cron}, nil
+}
+
+// parseRepeatingInterval parses "R[n]/duration", "R[n]/start/duration" or "R[n]/duration/end"
+func parseRepeatingInterval(value string) (*Cycle, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid repeating interval: %s", value)
+	}
+
+	cycle := &Cycle{repetitions: -1}
+	if count := parts[0][1:]; count != "" {
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid repeating interval: %s", value)
+		}
+		cycle.repetitions = n
+	}
+
+	var end time.Time
+	for _, part := range parts[1:] {
+		if strings.HasPrefix(part, "P") {
+			interval, err := expression.ParseDuration(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid repeating interval: %s", value)
+			}
+			cycle.interval = interval
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid repeating interval: %s", value)
+		}
+		if cycle.interval == 0 {
+			cycle.start = date
+		} else {
+			end = date
+		}
+	}
+	if cycle.interval <= 0 {
+		return nil, fmt.Errorf("invalid repeating interval: %s", value)
+	}
+
+	// An end date fixes the first occurrence relative to it
+	if !end.IsZero() {
+		if cycle.repetitions < 0 {
+			return nil, fmt.Errorf("repeating interval with an end date needs a repetition count: %s", value)
+		}
+		cycle.start = end.Add(-time.Duration(cycle.repetitions-1) * cycle.interval)
+	}
+	return cycle, nil
+}
+
+// Repetitions returns the number of occurrences of the cycle, or -1 if it repeats forever
+func (c *Cycle) Repetitions() int {
+	return c.repetitions
+}
+
+// Next returns the first occurrence after a point in time. Occurrences of a
+// repeating interval without start date follow each other at the interval.
+// The number of repetitions is not taken into account; callers count them.
+func (c *Cycle) Next(after time.Time) (time.Time, bool) {
+	if c.cron != nil {
+		return c.cron.next(after)
+	}
+	switch {
+	case c.start.IsZero():
+		return after.Add(c.interval), true
+	case c.start.After(after):
+		return c.start, true
+	}
+	elapsed := after.Sub(c.start)
+	return c.start.Add((elapsed/c.interval + 1) * c.interval), true
+}
@@ -0,0 +1,62 @@
+package variable
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Equal compares two variable values. Numbers of different types are compared by
+// value, so that a long variable equals a query value or correlation key given as a
+// float64, e.g. one decoded from JSON. Other values are compared with
+// reflect.DeepEqual.
+func Equal(a, b interface{}) bool {
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			return af == bf
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Compare orders two numbers, strings or dates. It reports false for values of other
+// or different kinds.
+func Compare(a, b interface{}) (int, bool) {
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			switch {
+			case af < bf:
+				return -1, true
+			case af > bf:
+				return 1, true
+			}
+			return 0, true
+		}
+		return 0, false
+	}
+	switch av := a.(type) {
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case time.Time:
+		if bv, ok := b.(time.Time); ok {
+			return av.Compare(bv), true
+		}
+	}
+	return 0, false
+}
+
+// number converts a number of any kind to float64
+func number(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
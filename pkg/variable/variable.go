@@ -0,0 +1,318 @@
+// Package variable implements typed process variable values.
+//
+// Variables hold values of a small set of types with a stable serialization, so
+// that they keep their type when they are persisted and loaded again: a long
+// stays an int64 instead of becoming a float64 and a date stays a time.Time.
+package variable
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Variable types
+const (
+	TypeNull    = "null"
+	TypeString  = "string"
+	TypeLong    = "long"
+	TypeDouble  = "double"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeJSON    = "json"
+	TypeBytes   = "bytes"
+)
+
+// TypedValue is a variable value together with its type. The value has the Go type
+// of the variable type: nil, string, int64, float64, bool, time.Time, []byte, or
+// any JSON-serializable value for json.
+type TypedValue struct {
+	Type  string
+	Value interface{}
+}
+
+// NullValue returns a typed null value
+func NullValue() TypedValue {
+	return TypedValue{Type: TypeNull}
+}
+
+// StringValue returns a typed string value
+func StringValue(value string) TypedValue {
+	return TypedValue{Type: TypeString, Value: value}
+}
+
+// LongValue returns a typed integer value
+func LongValue(value int64) TypedValue {
+	return TypedValue{Type: TypeLong, Value: value}
+}
+
+// DoubleValue returns a typed floating point value
+func DoubleValue(value float64) TypedValue {
+	return TypedValue{Type: TypeDouble, Value: value}
+}
+
+// BooleanValue returns a typed boolean value
+func BooleanValue(value bool) TypedValue {
+	return TypedValue{Type: TypeBoolean, Value: value}
+}
+
+// DateValue returns a typed date value
+func DateValue(value time.Time) TypedValue {
+	return TypedValue{Type: TypeDate, Value: value}
+}
+
+// JSONValue returns a typed value serialized as JSON, e.g. a map, slice or struct.
+// It is loaded as generic JSON: maps, slices, strings, float64, bool and nil.
+func JSONValue(value interface{}) TypedValue {
+	return TypedValue{Type: TypeJSON, Value: value}
+}
+
+// BytesValue returns a typed binary value
+func BytesValue(value []byte) TypedValue {
+	return TypedValue{Type: TypeBytes, Value: value}
+}
+
+// Of returns the typed value of a Go value. Integers become longs, floating point
+// numbers doubles; maps, slices and structs are serialized as JSON.
+func Of(value interface{}) (TypedValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return NullValue(), nil
+	case TypedValue:
+		return v, nil
+	case *TypedValue:
+		if v == nil {
+			return NullValue(), nil
+		}
+		return *v, nil
+	case *Reference:
+		return TypedValue{Type: v.Type, Value: v}, nil
+	case string:
+		return StringValue(v), nil
+	case bool:
+		return BooleanValue(v), nil
+	case time.Time:
+		return DateValue(v), nil
+	case *time.Time:
+		if v == nil {
+			return NullValue(), nil
+		}
+		return DateValue(*v), nil
+	case []byte:
+		return BytesValue(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return LongValue(i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return TypedValue{}, fmt.Errorf("invalid number: %s", v)
+		}
+		return DoubleValue(f), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return LongValue(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			return TypedValue{}, fmt.Errorf("integer out of range: %d", rv.Uint())
+		}
+		return LongValue(int64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return DoubleValue(rv.Float()), nil
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Ptr:
+		return JSONValue(value), nil
+	}
+	return TypedValue{}, fmt.Errorf("unsupported variable value of type %T", value)
+}
+
+// Reference refers to a large value kept apart from the variables holding it, e.g.
+// in a content store. The services holding the variable load the value when it is read.
+type Reference struct {
+	ContentID string
+	Type      string
+	Size      int
+	Hash      string
+}
+
+// Unwrap returns the Go value of a typed value; other values are returned as they are
+func Unwrap(value interface{}) interface{} {
+	switch v := value.(type) {
+	case TypedValue:
+		return v.Value
+	case *TypedValue:
+		if v == nil {
+			return nil
+		}
+		return v.Value
+	}
+	return value
+}
+
+// envelope is the serialized form of a typed value or of a reference to it
+type envelope struct {
+	Type      string          `json:"type"`
+	Value     json.RawMessage `json:"value,omitempty"`
+	ContentID string          `json:"contentId,omitempty"`
+	Size      int             `json:"size,omitempty"`
+	Hash      string          `json:"hash,omitempty"`
+}
+
+// MarshalJSON serializes the value together with its type
+func (v TypedValue) MarshalJSON() ([]byte, error) {
+	value := v
+	if value.Type == "" {
+		var err error
+		if value, err = Of(v.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	if reference, ok := value.Value.(*Reference); ok {
+		return json.Marshal(envelope{Type: value.Type, ContentID: reference.ContentID, Size: reference.Size, Hash: reference.Hash})
+	}
+
+	data, err := json.Marshal(value.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s value: %w", value.Type, err)
+	}
+	return json.Marshal(envelope{Type: value.Type, Value: data})
+}
+
+// UnmarshalJSON restores a serialized value with its type. Values serialized
+// without a type, e.g. by earlier versions, get the type of their JSON value.
+func (v *TypedValue) UnmarshalJSON(data []byte) error {
+	var e envelope
+	if !isEnvelope(data, &e) {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		typed, err := Of(value)
+		if err != nil {
+			return err
+		}
+		*v = typed
+		return nil
+	}
+
+	if e.ContentID != "" {
+		*v = TypedValue{Type: e.Type, Value: &Reference{ContentID: e.ContentID, Type: e.Type, Size: e.Size, Hash: e.Hash}}
+		return nil
+	}
+
+	var err error
+	switch e.Type {
+	case TypeNull:
+		*v = NullValue()
+	case TypeString:
+		var s string
+		err = json.Unmarshal(e.Value, &s)
+		*v = StringValue(s)
+	case TypeLong:
+		var i int64
+		err = json.Unmarshal(e.Value, &i)
+		*v = LongValue(i)
+	case TypeDouble:
+		var f float64
+		err = json.Unmarshal(e.Value, &f)
+		*v = DoubleValue(f)
+	case TypeBoolean:
+		var b bool
+		err = json.Unmarshal(e.Value, &b)
+		*v = BooleanValue(b)
+	case TypeDate:
+		var t time.Time
+		err = json.Unmarshal(e.Value, &t)
+		*v = DateValue(t)
+	case TypeJSON:
+		var value interface{}
+		err = json.Unmarshal(e.Value, &value)
+		*v = JSONValue(value)
+	case TypeBytes:
+		var b []byte
+		err = json.Unmarshal(e.Value, &b)
+		*v = BytesValue(b)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value: %w", e.Type, err)
+	}
+	return nil
+}
+
+// isEnvelope reports whether data is a serialized typed value, an object with exactly
+// a known type and a value, or a serialized reference
+func isEnvelope(data []byte, e *envelope) bool {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	if _, isValue := fields["value"]; !isValue || len(fields) != 2 {
+		if _, isReference := fields["contentId"]; !isReference {
+			return false
+		}
+		for name := range fields {
+			if name != "type" && name != "contentId" && name != "size" && name != "hash" {
+				return false
+			}
+		}
+	}
+	if err := json.Unmarshal(data, e); err != nil {
+		return false
+	}
+
+	switch e.Type {
+	case TypeNull, TypeString, TypeLong, TypeDouble, TypeBoolean, TypeDate, TypeJSON, TypeBytes:
+		return true
+	}
+	return false
+}
+
+// Map holds variables by name. It is serialized with the type of every value, so
+// that the values are restored with their types.
+type Map map[string]interface{}
+
+// MarshalJSON serializes the variables with their types
+func (m Map) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	typed := make(map[string]TypedValue, len(m))
+	for name, value := range m {
+		typedValue, err := Of(value)
+		if err != nil {
+			return nil, fmt.Errorf("variable '%s': %w", name, err)
+		}
+		typed[name] = typedValue
+	}
+	return json.Marshal(typed)
+}
+
+// UnmarshalJSON restores serialized variables with their types
+func (m *Map) UnmarshalJSON(data []byte) error {
+	var typed map[string]TypedValue
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return err
+	}
+	if typed == nil {
+		*m = nil
+		return nil
+	}
+
+	*m = make(Map, len(typed))
+	for name, value := range typed {
+		(*m)[name] = value.Value
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package rest
+
+import "net/http"
+
+// registerHistoryRoutes registers the routes of history. While history is disabled, the
+// history service has no queries and the lists are empty.
+func (s *Server) registerHistoryRoutes() {
+	s.handle("GET /history/process-instances", s.listHistoricProcessInstances)
+	s.handle("GET /history/tasks", s.listHistoricTaskInstances)
+	s.handle("GET /history/activity-instances", s.listHistoricActivityInstances)
+	s.handle("GET /history/variable-instances", s.listHistoricVariableInstances)
+}
+
+// listHistoricProcessInstances returns a page of the historic process instances matching the query parameters
+func (s *Server) listHistoricProcessInstances(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetHistoryService().CreateHistoricProcessInstanceQuery()
+	if query == nil {
+		return s.writeJSON(w, http.StatusOK, emptyPage[*HistoricProcessInstanceDTO]())
+	}
+	if processInstanceID := p.String("processInstanceId"); processInstanceID != "" {
+		query.ProcessInstanceID(processInstanceID)
+	}
+	if businessKey := p.String("businessKey"); businessKey != "" {
+		query.ProcessInstanceBusinessKey(businessKey)
+	}
+	for _, tag := range p.Strings("tags") {
+		query.Tag(tag)
+	}
+	if tagIn := p.Strings("tagIn"); len(tagIn) > 0 {
+		query.TagIn(tagIn...)
+	}
+	if processDefinitionID := p.String("processDefinitionId"); processDefinitionID != "" {
+		query.ProcessDefinitionID(processDefinitionID)
+	}
+	if processDefinitionKey := p.String("processDefinitionKey"); processDefinitionKey != "" {
+		query.ProcessDefinitionKey(processDefinitionKey)
+	}
+	if startUserID := p.String("startUserId"); startUserID != "" {
+		query.StartUserID(startUserID)
+	}
+	if tenantID := p.String("tenantId"); tenantID != "" {
+		query.TenantID(tenantID)
+	}
+	if p.Bool("finished") {
+		query.Finished()
+	}
+	if p.Bool("unfinished") {
+		query.Unfinished()
+	}
+	if startedBefore := p.Time("startedBefore"); startedBefore != nil {
+		query.StartedBefore(*startedBefore)
+	}
+	if startedAfter := p.Time("startedAfter"); startedAfter != nil {
+		query.StartedAfter(*startedAfter)
+	}
+	if finishedBefore := p.Time("finishedBefore"); finishedBefore != nil {
+		query.FinishedBefore(*finishedBefore)
+	}
+	if finishedAfter := p.Time("finishedAfter"); finishedAfter != nil {
+		query.FinishedAfter(*finishedAfter)
+	}
+	p.Sort(map[string]func(){
+		"id":        func() { query.OrderByProcessInstanceID() },
+		"startTime": func() { query.OrderByStartTime() },
+		"endTime":   func() { query.OrderByEndTime() },
+		"duration":  func() { query.OrderByDuration() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newHistoricProcessInstanceDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
+
+// listHistoricTaskInstances returns a page of the historic task instances matching the query parameters
+func (s *Server) listHistoricTaskInstances(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetHistoryService().CreateHistoricTaskInstanceQuery()
+	if query == nil {
+		return s.writeJSON(w, http.StatusOK, emptyPage[*HistoricTaskInstanceDTO]())
+	}
+	if taskID := p.String("taskId"); taskID != "" {
+		query.TaskID(taskID)
+	}
+	if processInstanceID := p.String("processInstanceId"); processInstanceID != "" {
+		query.ProcessInstanceID(processInstanceID)
+	}
+	if processDefinitionKey := p.String("processDefinitionKey"); processDefinitionKey != "" {
+		query.ProcessDefinitionKey(processDefinitionKey)
+	}
+	if taskDefinitionKeys := p.Strings("taskDefinitionKeyIn"); taskDefinitionKeys != nil {
+		query.TaskDefinitionKeyIn(taskDefinitionKeys...)
+	}
+	if assignee := p.String("assignee"); assignee != "" {
+		query.TaskAssignee(assignee)
+	}
+	if owner := p.String("owner"); owner != "" {
+		query.TaskOwner(owner)
+	}
+	if candidateUser := p.String("candidateUser"); candidateUser != "" {
+		query.TaskCandidateUser(candidateUser)
+	}
+	if candidateGroup := p.String("candidateGroup"); candidateGroup != "" {
+		query.TaskCandidateGroup(candidateGroup)
+	}
+	if candidateGroupIn := p.Strings("candidateGroupIn"); candidateGroupIn != nil {
+		query.TaskCandidateGroupIn(candidateGroupIn...)
+	}
+	if tenantID := p.String("tenantId"); tenantID != "" {
+		query.TenantID(tenantID)
+	}
+	if p.Bool("finished") {
+		query.Finished()
+	}
+	if p.Bool("unfinished") {
+		query.Unfinished()
+	}
+	if finishedBefore := p.Time("finishedBefore"); finishedBefore != nil {
+		query.FinishedBefore(*finishedBefore)
+	}
+	if finishedAfter := p.Time("finishedAfter"); finishedAfter != nil {
+		query.FinishedAfter(*finishedAfter)
+	}
+	if priorityMin := p.Int("priorityMin"); priorityMin != nil {
+		query.TaskPriorityMin(*priorityMin)
+	}
+	if priorityMax := p.Int("priorityMax"); priorityMax != nil {
+		query.TaskPriorityMax(*priorityMax)
+	}
+	if dueBefore := p.Time("dueBefore"); dueBefore != nil {
+		query.DueBefore(*dueBefore)
+	}
+	if dueAfter := p.Time("dueAfter"); dueAfter != nil {
+		query.DueAfter(*dueAfter)
+	}
+	p.Sort(map[string]func(){
+		"id":        func() { query.OrderByTaskID() },
+		"name":      func() { query.OrderByTaskName() },
+		"priority":  func() { query.OrderByTaskPriority() },
+		"dueDate":   func() { query.OrderByDueDate() },
+		"startTime": func() { query.OrderByStartTime() },
+		"endTime":   func() { query.OrderByEndTime() },
+		"duration":  func() { query.OrderByDuration() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newHistoricTaskInstanceDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
+
+// listHistoricActivityInstances returns a page of the historic activity instances matching the query parameters
+func (s *Server) listHistoricActivityInstances(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetHistoryService().CreateHistoricActivityInstanceQuery()
+	if query == nil {
+		return s.writeJSON(w, http.StatusOK, emptyPage[*HistoricActivityInstanceDTO]())
+	}
+	if activityID := p.String("activityId"); activityID != "" {
+		query.ActivityID(activityID)
+	}
+	if activityType := p.String("activityType"); activityType != "" {
+		query.ActivityType(activityType)
+	}
+	if processInstanceID := p.String("processInstanceId"); processInstanceID != "" {
+		query.ProcessInstanceID(processInstanceID)
+	}
+	if executionID := p.String("executionId"); executionID != "" {
+		query.ExecutionID(executionID)
+	}
+	if taskAssignee := p.String("assignee"); taskAssignee != "" {
+		query.TaskAssignee(taskAssignee)
+	}
+	if p.Bool("finished") {
+		query.Finished()
+	}
+	if p.Bool("unfinished") {
+		query.Unfinished()
+	}
+	p.Sort(map[string]func(){
+		"activityId":   func() { query.OrderByActivityID() },
+		"activityType": func() { query.OrderByActivityType() },
+		"startTime":    func() { query.OrderByStartTime() },
+		"endTime":      func() { query.OrderByEndTime() },
+		"duration":     func() { query.OrderByDuration() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newHistoricActivityInstanceDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
+
+// listHistoricVariableInstances returns a page of the historic variable instances matching the query parameters
+func (s *Server) listHistoricVariableInstances(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetHistoryService().CreateHistoricVariableInstanceQuery()
+	if query == nil {
+		return s.writeJSON(w, http.StatusOK, emptyPage[*HistoricVariableInstanceDTO]())
+	}
+	if name := p.String("name"); name != "" {
+		query.VariableName(name)
+	}
+	if nameLike := p.String("nameLike"); nameLike != "" {
+		query.VariableNameLike(nameLike)
+	}
+	if processInstanceID := p.String("processInstanceId"); processInstanceID != "" {
+		query.ProcessInstanceID(processInstanceID)
+	}
+	if taskID := p.String("taskId"); taskID != "" {
+		query.TaskID(taskID)
+	}
+	p.Sort(map[string]func(){
+		"name":              func() { query.OrderByVariableName() },
+		"processInstanceId": func() { query.OrderByProcessInstanceID() },
+		"createTime":        func() { query.OrderByCreateTime() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newHistoricVariableInstanceDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/muixstudio/flowgo/engine"
+)
+
+// metricsContentType is the content type of the Prometheus text exposition format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler serves the metrics of a process engine in the Prometheus text format,
+// for scraping at an endpoint such as /metrics. It is not part of the REST API so that
+// it can be served without authentication, e.g. on an internal port:
+//
+//	mux.Handle("/metrics", rest.MetricsHandler(processEngine))
+func MetricsHandler(processEngine engine.ProcessEngine) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := processEngine.GetMetrics(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", metricsContentType)
+		_, _ = w.Write(formatMetrics(metrics))
+	})
+}
+
+// formatMetrics writes engine metrics in the Prometheus text format
+func formatMetrics(metrics *engine.EngineMetrics) []byte {
+	buf := &bytes.Buffer{}
+
+	writeMetricHeader(buf, "flowgo_commands_total", "counter", "Number of commands executed.")
+	for _, command := range metrics.Commands {
+		fmt.Fprintf(buf, "flowgo_commands_total{command=%s} %d\n", quoteLabel(command.Command), command.Count)
+	}
+
+	writeMetricHeader(buf, "flowgo_command_errors_total", "counter", "Number of commands that failed.")
+	for _, command := range metrics.Commands {
+		fmt.Fprintf(buf, "flowgo_command_errors_total{command=%s} %d\n", quoteLabel(command.Command), command.Errors)
+	}
+
+	writeMetricHeader(buf, "flowgo_command_duration_seconds", "histogram", "Duration of command executions.")
+	for _, command := range metrics.Commands {
+		label := quoteLabel(command.Command)
+		for i, bound := range engine.DurationBuckets {
+			fmt.Fprintf(buf, "flowgo_command_duration_seconds_bucket{command=%s,le=\"%s\"} %d\n",
+				label, strconv.FormatFloat(bound, 'g', -1, 64), command.BucketCounts[i])
+		}
+		fmt.Fprintf(buf, "flowgo_command_duration_seconds_bucket{command=%s,le=\"+Inf\"} %d\n", label, command.Count)
+		fmt.Fprintf(buf, "flowgo_command_duration_seconds_sum{command=%s} %s\n",
+			label, strconv.FormatFloat(command.TotalDuration.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(buf, "flowgo_command_duration_seconds_count{command=%s} %d\n", label, command.Count)
+	}
+
+	writeMetricHeader(buf, "flowgo_process_instances_active", "gauge", "Number of process instances that are not suspended.")
+	fmt.Fprintf(buf, "flowgo_process_instances_active %d\n", metrics.ActiveProcessInstances)
+
+	writeMetricHeader(buf, "flowgo_tasks_open", "gauge", "Number of tasks not completed yet.")
+	fmt.Fprintf(buf, "flowgo_tasks_open %d\n", metrics.OpenTasks)
+
+	writeMetricHeader(buf, "flowgo_jobs_pending", "gauge", "Number of jobs waiting to be executed.")
+	fmt.Fprintf(buf, "flowgo_jobs_pending %d\n", metrics.PendingJobs)
+
+	return buf.Bytes()
+}
+
+// writeMetricHeader writes the help and type lines of a metric
+func writeMetricHeader(buf *bytes.Buffer, name, metricType, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// labelEscaper escapes label values of the Prometheus text format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabel returns a label value quoted and escaped
+func quoteLabel(value string) string {
+	return `"` + labelEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,283 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/muixstudio/flowgo/api/repository"
+)
+
+// maxDeploymentSize limits the size of deployment uploads
+const maxDeploymentSize = 32 << 20
+
+// registerRepositoryRoutes registers the routes of deployments and process definitions
+func (s *Server) registerRepositoryRoutes() {
+	s.handle("POST /deployments", s.createDeployment)
+	s.handle("GET /deployments/{id}", s.getDeployment)
+	s.handle("DELETE /deployments/{id}", s.deleteDeployment)
+	s.handle("GET /deployments/{id}/resources", s.listDeploymentResources)
+	s.handle("GET /deployments/{id}/resources/{name...}", s.getDeploymentResource)
+
+	s.handle("GET /process-definitions", s.listProcessDefinitions)
+	s.handle("GET /process-definitions/{id}", s.getProcessDefinition)
+	s.handle("GET /process-definitions/{id}/model", s.getProcessModel)
+	s.handle("GET /process-definitions/{id}/bpmn", s.getProcessModelBPMN)
+	s.handle("GET /process-definitions/{id}/diagram", s.getProcessDiagram)
+	s.handle("PUT /process-definitions/{id}/suspended", s.setProcessDefinitionSuspended)
+	s.handle("POST /process-definitions/{id}/start", s.startProcessInstanceByID)
+	s.handle("POST /process-definitions/key/{key}/start", s.startProcessInstanceByKey)
+}
+
+// createDeployment deploys the files of a multipart form. The form fields name,
+// category and tenantId describe the deployment; enableDuplicateFiltering=true returns
+// the latest deployment of the name instead when its resources are unchanged.
+func (s *Server) createDeployment(w http.ResponseWriter, r *http.Request) error {
+	if err := r.ParseMultipartForm(maxDeploymentSize); err != nil {
+		return badRequest("deployments must be uploaded as multipart form: %v", err)
+	}
+
+	builder := s.engine.GetRepositoryService().CreateDeployment().
+		Name(r.FormValue("name")).
+		Category(r.FormValue("category")).
+		TenantID(r.FormValue("tenantId"))
+	if r.FormValue("enableDuplicateFiltering") == "true" {
+		builder.EnableDuplicateFiltering()
+	}
+	resources := 0
+	for _, files := range r.MultipartForm.File {
+		for _, header := range files {
+			file, err := header.Open()
+			if err != nil {
+				return err
+			}
+			content, err := io.ReadAll(file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+			builder.AddResource(header.Filename, content)
+			resources++
+		}
+	}
+	if resources == 0 {
+		return badRequest("a deployment needs at least one resource")
+	}
+
+	deployment, err := builder.Deploy(r.Context())
+	if err != nil {
+		// Deployments fail on invalid resources
+		if errorStatus(err) == http.StatusInternalServerError {
+			return badRequest("%v", err)
+		}
+		return err
+	}
+	return s.writeJSON(w, http.StatusCreated, newDeploymentDTO(deployment))
+}
+
+// getDeployment returns a deployment
+func (s *Server) getDeployment(w http.ResponseWriter, r *http.Request) error {
+	deployment, err := s.engine.GetRepositoryService().GetDeployment(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, newDeploymentDTO(deployment))
+}
+
+// deleteDeployment deletes a deployment; with cascade=true together with its process instances
+func (s *Server) deleteDeployment(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	cascade := p.Bool("cascade")
+	if err := p.Err(); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetRepositoryService().DeleteDeployment(r.Context(), r.PathValue("id"), cascade); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// listDeploymentResources returns the names of the resources of a deployment
+func (s *Server) listDeploymentResources(w http.ResponseWriter, r *http.Request) error {
+	names, err := s.engine.GetRepositoryService().GetDeploymentResourceNames(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, names)
+}
+
+// getDeploymentResource returns the content of a deployment resource with its content type
+func (s *Server) getDeploymentResource(w http.ResponseWriter, r *http.Request) error {
+	resource, content, err := s.engine.GetRepositoryService().GetResource(r.Context(), r.PathValue("id"), r.PathValue("name"))
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	contentType := resource.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(resource.Size, 10))
+	_, err = io.Copy(w, content)
+	return err
+}
+
+// listProcessDefinitions returns a page of the process definitions matching the query parameters
+func (s *Server) listProcessDefinitions(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetRepositoryService().CreateProcessDefinitionQuery()
+	if key := p.String("key"); key != "" {
+		query.ProcessDefinitionKey(key)
+	}
+	if name := p.String("name"); name != "" {
+		query.ProcessDefinitionName(name)
+	}
+	if nameLike := p.String("nameLike"); nameLike != "" {
+		query.ProcessDefinitionNameLike(nameLike)
+	}
+	if category := p.String("category"); category != "" {
+		query.Category(category)
+	}
+	if deploymentID := p.String("deploymentId"); deploymentID != "" {
+		query.DeploymentID(deploymentID)
+	}
+	if tenantID := p.String("tenantId"); tenantID != "" {
+		query.TenantID(tenantID)
+	}
+	if version := p.Int("version"); version != nil {
+		query.Version(*version)
+	}
+	if p.Bool("latestVersion") {
+		query.LatestVersion()
+	}
+	if p.Bool("active") {
+		query.Active()
+	}
+	if p.Bool("suspended") {
+		query.Suspended()
+	}
+	p.Sort(map[string]func(){
+		"key":          func() { query.OrderByProcessDefinitionKey() },
+		"name":         func() { query.OrderByProcessDefinitionName() },
+		"version":      func() { query.OrderByProcessDefinitionVersion() },
+		"deploymentId": func() { query.OrderByDeploymentID() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newProcessDefinitionDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
+
+// getProcessDefinition returns a process definition
+func (s *Server) getProcessDefinition(w http.ResponseWriter, r *http.Request) error {
+	definition, err := s.engine.GetRepositoryService().GetProcessDefinition(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, newProcessDefinitionDTO(definition))
+}
+
+// getProcessModel returns the JSON process model of a process definition as deployed
+func (s *Server) getProcessModel(w http.ResponseWriter, r *http.Request) error {
+	content, err := s.engine.GetRepositoryService().GetProcessModel(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(content)
+	return err
+}
+
+// getProcessModelBPMN returns the process model of a process definition as BPMN 2.0 XML
+func (s *Server) getProcessModelBPMN(w http.ResponseWriter, r *http.Request) error {
+	content, err := s.engine.GetRepositoryService().GetProcessModelBPMN(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_, err = w.Write(content)
+	return err
+}
+
+// getProcessDiagram returns the process model of a process definition as SVG diagram
+func (s *Server) getProcessDiagram(w http.ResponseWriter, r *http.Request) error {
+	content, err := s.engine.GetRepositoryService().GetProcessDiagram(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, err = w.Write(content)
+	return err
+}
+
+// setProcessDefinitionSuspended suspends or activates a process definition, with
+// includeProcessInstances=true together with its process instances and with an
+// executionDate at that date
+func (s *Server) setProcessDefinitionSuspended(w http.ResponseWriter, r *http.Request) error {
+	var state ProcessDefinitionSuspensionStateDTO
+	if err := decodeJSON(r, &state); err != nil {
+		return err
+	}
+
+	options := make([]repository.SuspensionOption, 0, 2)
+	if state.IncludeProcessInstances {
+		options = append(options, repository.IncludeProcessInstances())
+	}
+	if state.ExecutionDate != nil {
+		options = append(options, repository.ExecutionDate(*state.ExecutionDate))
+	}
+
+	repositoryService := s.engine.GetRepositoryService()
+	var err error
+	if state.Suspended {
+		err = repositoryService.SuspendProcessDefinition(r.Context(), r.PathValue("id"), options...)
+	} else {
+		err = repositoryService.ActivateProcessDefinition(r.Context(), r.PathValue("id"), options...)
+	}
+	if err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// startProcessInstanceByID starts an instance of a process definition version. Business
+// keys are only supported starting by key.
+func (s *Server) startProcessInstanceByID(w http.ResponseWriter, r *http.Request) error {
+	var start StartProcessInstanceDTO
+	if err := decodeJSON(r, &start); err != nil {
+		return err
+	}
+	if start.BusinessKey != "" {
+		return badRequest("a business key can only be given starting a process instance by key")
+	}
+
+	instance, err := s.engine.GetRuntimeService().StartProcessInstanceByID(r.Context(), r.PathValue("id"), start.Variables)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusCreated, newProcessInstanceDTO(instance))
+}
+
+// startProcessInstanceByKey starts an instance of the latest version of a process definition
+func (s *Server) startProcessInstanceByKey(w http.ResponseWriter, r *http.Request) error {
+	var start StartProcessInstanceDTO
+	if err := decodeJSON(r, &start); err != nil {
+		return err
+	}
+
+	instance, err := s.engine.GetRuntimeService().StartProcessInstanceByKeyWithBusinessKey(r.Context(), r.PathValue("key"), start.BusinessKey, start.Variables)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusCreated, newProcessInstanceDTO(instance))
+}
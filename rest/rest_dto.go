@@ -0,0 +1,354 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/muixstudio/flowgo/api/history"
+	"github.com/muixstudio/flowgo/api/repository"
+	"github.com/muixstudio/flowgo/api/runtime"
+	"github.com/muixstudio/flowgo/api/task"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// DeploymentDTO is the JSON form of a deployment
+type DeploymentDTO struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	DeployTime time.Time `json:"deployTime"`
+	Category   string    `json:"category,omitempty"`
+	TenantID   string    `json:"tenantId,omitempty"`
+	Resources  []string  `json:"resources"`
+}
+
+// ProcessDefinitionDTO is the JSON form of a process definition
+type ProcessDefinitionDTO struct {
+	ID                string `json:"id"`
+	Key               string `json:"key"`
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	Version           int    `json:"version"`
+	Category          string `json:"category,omitempty"`
+	DeploymentID      string `json:"deploymentId"`
+	ResourceName      string `json:"resourceName"`
+	TenantID          string `json:"tenantId,omitempty"`
+	Suspended         bool   `json:"suspended"`
+	StartFormKey      string `json:"startFormKey,omitempty"`
+	HistoryTimeToLive *int   `json:"historyTimeToLive,omitempty"`
+}
+
+// ProcessInstanceDTO is the JSON form of a process instance
+type ProcessInstanceDTO struct {
+	ID                     string     `json:"id"`
+	ProcessDefinitionID    string     `json:"processDefinitionId"`
+	ProcessDefinitionKey   string     `json:"processDefinitionKey"`
+	ProcessDefinitionName  string     `json:"processDefinitionName,omitempty"`
+	BusinessKey            string     `json:"businessKey,omitempty"`
+	Name                   string     `json:"name,omitempty"`
+	Tags                   []string   `json:"tags,omitempty"`
+	StartTime              time.Time  `json:"startTime"`
+	EndTime                *time.Time `json:"endTime,omitempty"`
+	StartUserID            string     `json:"startUserId,omitempty"`
+	Suspended              bool       `json:"suspended"`
+	TenantID               string     `json:"tenantId,omitempty"`
+	SuperProcessInstanceID string     `json:"superProcessInstanceId,omitempty"`
+}
+
+// ActivityInstanceDTO is the JSON form of an activity instance tree
+type ActivityInstanceDTO struct {
+	ID                       string                 `json:"id"`
+	ParentActivityInstanceID string                 `json:"parentActivityInstanceId,omitempty"`
+	ActivityID               string                 `json:"activityId"`
+	ActivityName             string                 `json:"activityName,omitempty"`
+	ActivityType             string                 `json:"activityType"`
+	ProcessInstanceID        string                 `json:"processInstanceId"`
+	ProcessDefinitionID      string                 `json:"processDefinitionId"`
+	ExecutionIDs             []string               `json:"executionIds"`
+	ChildActivityInstances   []*ActivityInstanceDTO `json:"childActivityInstances"`
+}
+
+// TaskDTO is the JSON form of a task
+type TaskDTO struct {
+	ID                  string                 `json:"id"`
+	Name                string                 `json:"name"`
+	Description         string                 `json:"description,omitempty"`
+	Priority            int                    `json:"priority"`
+	Owner               string                 `json:"owner,omitempty"`
+	Assignee            string                 `json:"assignee,omitempty"`
+	DueDate             *time.Time             `json:"dueDate,omitempty"`
+	FollowUpDate        *time.Time             `json:"followUpDate,omitempty"`
+	Category            string                 `json:"category,omitempty"`
+	FormKey             string                 `json:"formKey,omitempty"`
+	ProcessInstanceID   string                 `json:"processInstanceId,omitempty"`
+	ProcessDefinitionID string                 `json:"processDefinitionId,omitempty"`
+	ExecutionID         string                 `json:"executionId,omitempty"`
+	TaskDefinitionKey   string                 `json:"taskDefinitionKey,omitempty"`
+	CreateTime          time.Time              `json:"createTime"`
+	TenantID            string                 `json:"tenantId,omitempty"`
+	Suspended           bool                   `json:"suspended"`
+	CandidateUsers      []string               `json:"candidateUsers,omitempty"`
+	CandidateGroups     []string               `json:"candidateGroups,omitempty"`
+	TaskLocalVariables  map[string]interface{} `json:"taskLocalVariables,omitempty"`
+	ProcessVariables    map[string]interface{} `json:"processVariables,omitempty"`
+}
+
+// HistoricProcessInstanceDTO is the JSON form of a historic process instance
+type HistoricProcessInstanceDTO struct {
+	ID                   string     `json:"id"`
+	BusinessKey          string     `json:"businessKey,omitempty"`
+	Tags                 []string   `json:"tags,omitempty"`
+	ProcessDefinitionID  string     `json:"processDefinitionId"`
+	ProcessDefinitionKey string     `json:"processDefinitionKey"`
+	StartTime            time.Time  `json:"startTime"`
+	EndTime              *time.Time `json:"endTime,omitempty"`
+	DurationInMillis     *int64     `json:"durationInMillis,omitempty"`
+	StartUserID          string     `json:"startUserId,omitempty"`
+	StartActivityID      string     `json:"startActivityId,omitempty"`
+	EndActivityID        string     `json:"endActivityId,omitempty"`
+	DeleteReason         string     `json:"deleteReason,omitempty"`
+	TenantID             string     `json:"tenantId,omitempty"`
+	RemovalTime          *time.Time `json:"removalTime,omitempty"`
+}
+
+// HistoricTaskInstanceDTO is the JSON form of a historic task instance
+type HistoricTaskInstanceDTO struct {
+	ID                   string     `json:"id"`
+	ProcessDefinitionID  string     `json:"processDefinitionId,omitempty"`
+	ProcessDefinitionKey string     `json:"processDefinitionKey,omitempty"`
+	ProcessInstanceID    string     `json:"processInstanceId,omitempty"`
+	Name                 string     `json:"name"`
+	TaskDefinitionKey    string     `json:"taskDefinitionKey,omitempty"`
+	Owner                string     `json:"owner,omitempty"`
+	Assignee             string     `json:"assignee,omitempty"`
+	StartTime            time.Time  `json:"startTime"`
+	EndTime              *time.Time `json:"endTime,omitempty"`
+	DurationInMillis     *int64     `json:"durationInMillis,omitempty"`
+	DeleteReason         string     `json:"deleteReason,omitempty"`
+	Priority             int        `json:"priority"`
+	DueDate              *time.Time `json:"dueDate,omitempty"`
+	FollowUpDate         *time.Time `json:"followUpDate,omitempty"`
+	TenantID             string     `json:"tenantId,omitempty"`
+}
+
+// HistoricActivityInstanceDTO is the JSON form of a historic activity instance
+type HistoricActivityInstanceDTO struct {
+	ID                string     `json:"id"`
+	ActivityID        string     `json:"activityId"`
+	ActivityName      string     `json:"activityName,omitempty"`
+	ActivityType      string     `json:"activityType"`
+	ProcessInstanceID string     `json:"processInstanceId"`
+	ExecutionID       string     `json:"executionId"`
+	TaskID            string     `json:"taskId,omitempty"`
+	Assignee          string     `json:"assignee,omitempty"`
+	StartTime         time.Time  `json:"startTime"`
+	EndTime           *time.Time `json:"endTime,omitempty"`
+	DurationInMillis  *int64     `json:"durationInMillis,omitempty"`
+	DeleteReason      string     `json:"deleteReason,omitempty"`
+}
+
+// HistoricVariableInstanceDTO is the JSON form of a historic variable instance
+type HistoricVariableInstanceDTO struct {
+	ID                string              `json:"id"`
+	Name              string              `json:"name"`
+	Value             variable.TypedValue `json:"value"`
+	ProcessInstanceID string              `json:"processInstanceId,omitempty"`
+	TaskID            string              `json:"taskId,omitempty"`
+	CreateTime        time.Time           `json:"createTime"`
+	LastUpdatedTime   *time.Time          `json:"lastUpdatedTime,omitempty"`
+}
+
+// StartProcessInstanceDTO is the request body starting a process instance
+type StartProcessInstanceDTO struct {
+	BusinessKey string       `json:"businessKey,omitempty"`
+	Variables   variable.Map `json:"variables,omitempty"`
+}
+
+// VariablesDTO is the request body passing variables, e.g. completing a task
+type VariablesDTO struct {
+	Variables variable.Map `json:"variables,omitempty"`
+}
+
+// SuspensionStateDTO is the request body suspending or activating an entity
+type SuspensionStateDTO struct {
+	Suspended bool `json:"suspended"`
+}
+
+// ProcessDefinitionSuspensionStateDTO is the request body suspending or activating a
+// process definition, optionally with its process instances or at a later date
+type ProcessDefinitionSuspensionStateDTO struct {
+	Suspended               bool       `json:"suspended"`
+	IncludeProcessInstances bool       `json:"includeProcessInstances,omitempty"`
+	ExecutionDate           *time.Time `json:"executionDate,omitempty"`
+}
+
+// UserIDDTO is the request body naming a user, e.g. claiming a task
+type UserIDDTO struct {
+	UserID string `json:"userId"`
+}
+
+// EscalationDTO is the request body escalating a task
+type EscalationDTO struct {
+	EscalationCode string `json:"escalationCode"`
+}
+
+func newDeploymentDTO(deployment *repository.Deployment) *DeploymentDTO {
+	dto := &DeploymentDTO{
+		ID:         deployment.ID,
+		Name:       deployment.Name,
+		DeployTime: deployment.DeployTime,
+		Category:   deployment.Category,
+		TenantID:   deployment.TenantID,
+		Resources:  make([]string, len(deployment.Resources)),
+	}
+	for i, resource := range deployment.Resources {
+		dto.Resources[i] = resource.Name
+	}
+	return dto
+}
+
+func newProcessDefinitionDTO(definition *repository.ProcessDefinition) *ProcessDefinitionDTO {
+	return &ProcessDefinitionDTO{
+		ID:                definition.ID,
+		Key:               definition.Key,
+		Name:              definition.Name,
+		Description:       definition.Description,
+		Version:           definition.Version,
+		Category:          definition.Category,
+		DeploymentID:      definition.DeploymentID,
+		ResourceName:      definition.ResourceName,
+		TenantID:          definition.TenantID,
+		Suspended:         definition.Suspended,
+		StartFormKey:      definition.StartFormKey,
+		HistoryTimeToLive: definition.HistoryTimeToLive,
+	}
+}
+
+func newProcessInstanceDTO(instance *runtime.ProcessInstance) *ProcessInstanceDTO {
+	return &ProcessInstanceDTO{
+		ID:                     instance.ID,
+		ProcessDefinitionID:    instance.ProcessDefinitionID,
+		ProcessDefinitionKey:   instance.ProcessDefinitionKey,
+		ProcessDefinitionName:  instance.ProcessDefinitionName,
+		BusinessKey:            instance.BusinessKey,
+		Name:                   instance.Name,
+		Tags:                   instance.Tags,
+		StartTime:              instance.StartTime,
+		EndTime:                instance.EndTime,
+		StartUserID:            instance.StartUserID,
+		Suspended:              instance.Suspended,
+		TenantID:               instance.TenantID,
+		SuperProcessInstanceID: instance.SuperProcessInstanceID,
+	}
+}
+
+func newActivityInstanceDTO(instance *runtime.ActivityInstance) *ActivityInstanceDTO {
+	children := make([]*ActivityInstanceDTO, len(instance.ChildActivityInstances))
+	for i, child := range instance.ChildActivityInstances {
+		children[i] = newActivityInstanceDTO(child)
+	}
+	return &ActivityInstanceDTO{
+		ID:                       instance.ID,
+		ParentActivityInstanceID: instance.ParentActivityInstanceID,
+		ActivityID:               instance.ActivityID,
+		ActivityName:             instance.ActivityName,
+		ActivityType:             instance.ActivityType,
+		ProcessInstanceID:        instance.ProcessInstanceID,
+		ProcessDefinitionID:      instance.ProcessDefinitionID,
+		ExecutionIDs:             instance.ExecutionIDs,
+		ChildActivityInstances:   children,
+	}
+}
+
+func newTaskDTO(t *task.Task) *TaskDTO {
+	return &TaskDTO{
+		ID:                  t.ID,
+		Name:                t.Name,
+		Description:         t.Description,
+		Priority:            t.Priority,
+		Owner:               t.Owner,
+		Assignee:            t.Assignee,
+		DueDate:             t.DueDate,
+		FollowUpDate:        t.FollowUpDate,
+		Category:            t.Category,
+		FormKey:             t.FormKey,
+		ProcessInstanceID:   t.ProcessInstanceID,
+		ProcessDefinitionID: t.ProcessDefinitionID,
+		ExecutionID:         t.ExecutionID,
+		TaskDefinitionKey:   t.TaskDefinitionKey,
+		CreateTime:          t.CreateTime,
+		TenantID:            t.TenantID,
+		Suspended:           t.Suspended,
+		CandidateUsers:      t.CandidateUsers,
+		CandidateGroups:     t.CandidateGroups,
+		TaskLocalVariables:  t.TaskLocalVariables,
+		ProcessVariables:    t.ProcessVariables,
+	}
+}
+
+func newHistoricProcessInstanceDTO(instance *history.HistoricProcessInstance) *HistoricProcessInstanceDTO {
+	return &HistoricProcessInstanceDTO{
+		ID:                   instance.ID,
+		BusinessKey:          instance.BusinessKey,
+		Tags:                 instance.Tags,
+		ProcessDefinitionID:  instance.ProcessDefinitionID,
+		ProcessDefinitionKey: instance.ProcessDefinitionKey,
+		StartTime:            instance.StartTime,
+		EndTime:              instance.EndTime,
+		DurationInMillis:     instance.DurationInMillis,
+		StartUserID:          instance.StartUserID,
+		StartActivityID:      instance.StartActivityID,
+		EndActivityID:        instance.EndActivityID,
+		DeleteReason:         instance.DeleteReason,
+		TenantID:             instance.TenantID,
+		RemovalTime:          instance.RemovalTime,
+	}
+}
+
+func newHistoricTaskInstanceDTO(t *history.HistoricTaskInstance) *HistoricTaskInstanceDTO {
+	return &HistoricTaskInstanceDTO{
+		ID:                   t.ID,
+		ProcessDefinitionID:  t.ProcessDefinitionID,
+		ProcessDefinitionKey: t.ProcessDefinitionKey,
+		ProcessInstanceID:    t.ProcessInstanceID,
+		Name:                 t.Name,
+		TaskDefinitionKey:    t.TaskDefinitionKey,
+		Owner:                t.Owner,
+		Assignee:             t.Assignee,
+		StartTime:            t.StartTime,
+		EndTime:              t.EndTime,
+		DurationInMillis:     t.DurationInMillis,
+		DeleteReason:         t.DeleteReason,
+		Priority:             t.Priority,
+		DueDate:              t.DueDate,
+		FollowUpDate:         t.FollowUpDate,
+		TenantID:             t.TenantID,
+	}
+}
+
+func newHistoricActivityInstanceDTO(activity *history.HistoricActivityInstance) *HistoricActivityInstanceDTO {
+	return &HistoricActivityInstanceDTO{
+		ID:                activity.ID,
+		ActivityID:        activity.ActivityID,
+		ActivityName:      activity.ActivityName,
+		ActivityType:      activity.ActivityType,
+		ProcessInstanceID: activity.ProcessInstanceID,
+		ExecutionID:       activity.ExecutionID,
+		TaskID:            activity.TaskID,
+		Assignee:          activity.Assignee,
+		StartTime:         activity.StartTime,
+		EndTime:           activity.EndTime,
+		DurationInMillis:  activity.DurationInMillis,
+		DeleteReason:      activity.DeleteReason,
+	}
+}
+
+func newHistoricVariableInstanceDTO(instance *history.HistoricVariableInstance) *HistoricVariableInstanceDTO {
+	return &HistoricVariableInstanceDTO{
+		ID:                instance.ID,
+		Name:              instance.Name,
+		Value:             variable.TypedValue{Type: instance.TypeName, Value: instance.Value},
+		ProcessInstanceID: instance.ProcessInstanceID,
+		TaskID:            instance.TaskID,
+		CreateTime:        instance.CreateTime,
+		LastUpdatedTime:   instance.LastUpdatedTime,
+	}
+}
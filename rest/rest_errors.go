@@ -0,0 +1,70 @@
+package rest
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/muixstudio/flowgo/errors"
+)
+
+// ErrorDTO is the body of error responses
+type ErrorDTO struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// statusError is an error answered with a specific HTTP status
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// writeError writes an error response with the status the error maps to. Server errors
+// are logged, since the client cannot tell their cause.
+func (s *Server) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+	if status >= http.StatusInternalServerError {
+		s.logger.ErrorContext(r.Context(), "REST request failed",
+			slog.String("method", r.Method), slog.String("path", r.URL.Path), slog.Any("error", err))
+	}
+	s.writeJSON(w, status, &ErrorDTO{Type: http.StatusText(status), Message: err.Error()})
+}
+
+// errorStatus maps an error to an HTTP status by the kind of error the services
+// report. Errors of other kinds are told by their message, e.g. lacking permissions.
+func errorStatus(err error) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status
+	}
+
+	switch {
+	case errors.Is(err, errors.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errors.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, errors.ErrSuspended), errors.Is(err, errors.ErrAlreadyClaimed), errors.Is(err, errors.ErrOptimisticLock),
+		errors.Is(err, errors.ErrNotSignalable):
+		return http.StatusConflict
+	}
+
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "not found"):
+		return http.StatusNotFound
+	case strings.Contains(message, "permission"):
+		return http.StatusForbidden
+	case strings.Contains(message, "suspended"), strings.Contains(message, "already"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxResults is the page size of lists requested without maxResults
+const defaultMaxResults = 100
+
+// PageDTO is a page of a list together with the total number of its items
+type PageDTO[T any] struct {
+	Items       []T   `json:"items"`
+	Count       int64 `json:"count"`
+	FirstResult int   `json:"firstResult"`
+	MaxResults  int   `json:"maxResults"`
+}
+
+// emptyPage returns a page without items
+func emptyPage[T any]() *PageDTO[T] {
+	return &PageDTO[T]{Items: []T{}, MaxResults: defaultMaxResults}
+}
+
+// queryParams reads the query parameters of a request. Parsing errors are kept, so that
+// filters can be read in a row and checked once.
+type queryParams struct {
+	r   *http.Request
+	err error
+}
+
+// params returns the query parameters of a request
+func params(r *http.Request) *queryParams {
+	return &queryParams{r: r}
+}
+
+// String returns a parameter, or empty if missing
+func (p *queryParams) String(name string) string {
+	return p.r.URL.Query().Get(name)
+}
+
+// Strings returns a comma separated parameter as list, or nil if missing
+func (p *queryParams) Strings(name string) []string {
+	value := p.String(name)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// Bool returns a boolean parameter, or false if missing
+func (p *queryParams) Bool(name string) bool {
+	value := p.String(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil && p.err == nil {
+		p.err = badRequest("query parameter '%s' must be a boolean: %s", name, value)
+	}
+	return b
+}
+
+// Int returns an integer parameter, or nil if missing
+func (p *queryParams) Int(name string) *int {
+	value := p.String(name)
+	if value == "" {
+		return nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		if p.err == nil {
+			p.err = badRequest("query parameter '%s' must be an integer: %s", name, value)
+		}
+		return nil
+	}
+	return &i
+}
+
+// Time returns an RFC 3339 date parameter, or nil if missing
+func (p *queryParams) Time(name string) *time.Time {
+	value := p.String(name)
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if p.err == nil {
+			p.err = badRequest("query parameter '%s' must be an RFC 3339 date: %s", name, value)
+		}
+		return nil
+	}
+	return &t
+}
+
+// Ascending tells whether the sortOrder parameter requests ascending order, the default
+func (p *queryParams) Ascending() bool {
+	switch order := p.String("sortOrder"); order {
+	case "", "asc":
+		return true
+	case "desc":
+		return false
+	default:
+		if p.err == nil {
+			p.err = badRequest("query parameter 'sortOrder' must be asc or desc: %s", order)
+		}
+		return true
+	}
+}
+
+// Sort applies the ordering named by the sortBy parameter, if any
+func (p *queryParams) Sort(orderings map[string]func()) {
+	sortBy := p.String("sortBy")
+	if sortBy == "" {
+		return
+	}
+	order, exists := orderings[sortBy]
+	if !exists {
+		if p.err == nil {
+			p.err = badRequest("unsupported sortBy: %s", sortBy)
+		}
+		return
+	}
+	order()
+}
+
+// Page returns the firstResult and maxResults parameters
+func (p *queryParams) Page() (firstResult, maxResults int) {
+	maxResults = defaultMaxResults
+	if first := p.Int("firstResult"); first != nil {
+		firstResult = *first
+	}
+	if max := p.Int("maxResults"); max != nil {
+		maxResults = *max
+	}
+	if (firstResult < 0 || maxResults < 1) && p.err == nil {
+		p.err = badRequest("firstResult must not be negative and maxResults must be positive")
+	}
+	return firstResult, maxResults
+}
+
+// Err returns the first parsing error
+func (p *queryParams) Err() error {
+	return p.err
+}
+
+// pageQuery is a query that can be counted and listed by page
+type pageQuery[T any] interface {
+	Count(ctx context.Context) (int64, error)
+	ListPage(ctx context.Context, firstResult, maxResults int) ([]T, error)
+}
+
+// listPage runs a query for the page requested by the query parameters and converts
+// its results to DTOs
+func listPage[T, D any](ctx context.Context, p *queryParams, query pageQuery[T], convert func(T) D) (*PageDTO[D], error) {
+	firstResult, maxResults := p.Page()
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	count, err := query.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results, err := query.ListPage(ctx, firstResult, maxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PageDTO[D]{
+		Items:       make([]D, len(results)),
+		Count:       count,
+		FirstResult: firstResult,
+		MaxResults:  maxResults,
+	}
+	for i, result := range results {
+		page.Items[i] = convert(result)
+	}
+	return page, nil
+}
@@ -0,0 +1,124 @@
+// Package rest exposes a process engine over HTTP, so that clients not written in Go
+// can deploy process definitions, start process instances, work on tasks and query
+// history. Requests and responses are JSON; lists are paginated.
+//
+//	server := rest.NewServer(processEngine)
+//	http.ListenAndServe(":8080", server)
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/muixstudio/flowgo/engine"
+	"github.com/muixstudio/flowgo/identity"
+)
+
+// Authenticator identifies the user of a request, e.g. from a bearer token. Commands run
+// with the permissions of the authenticated user when authorization is enabled.
+type Authenticator interface {
+	// Authenticate returns the user of a request and the groups the user belongs to.
+	// An empty user ID runs the request unauthenticated; an error rejects it.
+	Authenticate(r *http.Request) (userID string, groupIDs []string, err error)
+}
+
+// Server serves the REST API of a process engine. It implements http.Handler.
+type Server struct {
+	engine        engine.ProcessEngine
+	authenticator Authenticator
+	mux           *http.ServeMux
+	logger        *slog.Logger
+}
+
+// NewServer creates a server for the REST API of a process engine
+func NewServer(processEngine engine.ProcessEngine) *Server {
+	s := &Server{
+		engine: processEngine,
+		mux:    http.NewServeMux(),
+		logger: slog.Default(),
+	}
+	s.registerRepositoryRoutes()
+	s.registerRuntimeRoutes()
+	s.registerTaskRoutes()
+	s.registerHistoryRoutes()
+	return s
+}
+
+// SetAuthenticator sets the authenticator identifying the users of requests.
+// Without one, requests run unauthenticated.
+func (s *Server) SetAuthenticator(authenticator Authenticator) {
+	s.authenticator = authenticator
+}
+
+// SetLogger sets the logger receiving failed requests and responses that could not be
+// written. By default, the default logger of log/slog is used.
+func (s *Server) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
+
+// ServeHTTP serves a request to the REST API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handlerFunc handles a request and writes a successful response. Returned errors are
+// written as error responses.
+type handlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// handle registers a handler for a route pattern, e.g. "GET /tasks/{id}"
+func (s *Server) handle(pattern string, handler handlerFunc) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if s.authenticator != nil {
+			userID, groupIDs, err := s.authenticator.Authenticate(r)
+			if err != nil {
+				s.writeError(w, r, &statusError{status: http.StatusUnauthorized, err: err})
+				return
+			}
+			if userID != "" {
+				r = r.WithContext(identity.WithAuthentication(r.Context(), userID, groupIDs...))
+			}
+		}
+
+		if err := handler(w, r); err != nil {
+			s.writeError(w, r, err)
+		}
+	})
+}
+
+// writeJSON writes a value as JSON response
+func (s *Server) writeJSON(w http.ResponseWriter, status int, value interface{}) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		// The status was sent already; the client sees a truncated body
+		s.logger.Error("failed to write REST response", slog.Any("error", err))
+	}
+	return nil
+}
+
+// writeNoContent writes an empty successful response
+func writeNoContent(w http.ResponseWriter) error {
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// maxRequestSize limits the size of JSON request bodies
+const maxRequestSize = 10 << 20
+
+// decodeJSON reads a JSON request body into a value. An empty body leaves the value unchanged.
+func decodeJSON(r *http.Request, value interface{}) error {
+	err := json.NewDecoder(io.LimitReader(r.Body, maxRequestSize)).Decode(value)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return badRequest("invalid request body: %v", err)
+	}
+	return nil
+}
+
+// badRequest returns an error answered with status 400
+func badRequest(format string, args ...interface{}) error {
+	return &statusError{status: http.StatusBadRequest, err: fmt.Errorf(format, args...)}
+}
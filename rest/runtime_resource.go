@@ -0,0 +1,219 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// registerRuntimeRoutes registers the routes of process instances and their variables
+func (s *Server) registerRuntimeRoutes() {
+	s.handle("GET /process-instances", s.listProcessInstances)
+	s.handle("GET /process-instances/{id}", s.getProcessInstance)
+	s.handle("DELETE /process-instances/{id}", s.deleteProcessInstance)
+	s.handle("PUT /process-instances/{id}/suspended", s.setProcessInstanceSuspended)
+	s.handle("GET /process-instances/{id}/diagram", s.getActivityInstanceDiagram)
+	s.handle("GET /process-instances/{id}/activity-instances", s.getActivityInstance)
+	s.handle("PUT /process-instances/{id}/tags/{tag}", s.addProcessInstanceTag)
+	s.handle("DELETE /process-instances/{id}/tags/{tag}", s.removeProcessInstanceTag)
+
+	s.handle("GET /process-instances/{id}/variables", s.getProcessInstanceVariables)
+	s.handle("PUT /process-instances/{id}/variables", s.setProcessInstanceVariables)
+	s.handle("GET /process-instances/{id}/variables/{name}", s.getProcessInstanceVariable)
+	s.handle("PUT /process-instances/{id}/variables/{name}", s.setProcessInstanceVariable)
+	s.handle("DELETE /process-instances/{id}/variables/{name}", s.removeProcessInstanceVariable)
+}
+
+// listProcessInstances returns a page of the process instances matching the query parameters
+func (s *Server) listProcessInstances(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetRuntimeService().CreateProcessInstanceQuery()
+	if businessKey := p.String("businessKey"); businessKey != "" {
+		query.ProcessInstanceBusinessKey(businessKey)
+	}
+	if name := p.String("name"); name != "" {
+		query.ProcessInstanceName(name)
+	}
+	if nameLike := p.String("nameLike"); nameLike != "" {
+		query.ProcessInstanceNameLike(nameLike)
+	}
+	for _, tag := range p.Strings("tags") {
+		query.Tag(tag)
+	}
+	if tagIn := p.Strings("tagIn"); len(tagIn) > 0 {
+		query.TagIn(tagIn...)
+	}
+	if processDefinitionID := p.String("processDefinitionId"); processDefinitionID != "" {
+		query.ProcessDefinitionID(processDefinitionID)
+	}
+	if processDefinitionKey := p.String("processDefinitionKey"); processDefinitionKey != "" {
+		query.ProcessDefinitionKey(processDefinitionKey)
+	}
+	if startUserID := p.String("startUserId"); startUserID != "" {
+		query.StartUserID(startUserID)
+	}
+	if involvedUser := p.String("involvedUser"); involvedUser != "" {
+		query.InvolvedUser(involvedUser)
+	}
+	if superProcessInstanceID := p.String("superProcessInstanceId"); superProcessInstanceID != "" {
+		query.SuperProcessInstanceID(superProcessInstanceID)
+	}
+	if tenantID := p.String("tenantId"); tenantID != "" {
+		query.TenantID(tenantID)
+	}
+	if p.Bool("active") {
+		query.Active()
+	}
+	if p.Bool("suspended") {
+		query.Suspended()
+	}
+	p.Sort(map[string]func(){
+		"id":                   func() { query.OrderByProcessInstanceID() },
+		"name":                 func() { query.OrderByProcessInstanceName() },
+		"processDefinitionKey": func() { query.OrderByProcessDefinitionKey() },
+		"startTime":            func() { query.OrderByStartTime() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newProcessInstanceDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
+
+// getProcessInstance returns a process instance
+func (s *Server) getProcessInstance(w http.ResponseWriter, r *http.Request) error {
+	instance, err := s.engine.GetRuntimeService().GetProcessInstance(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, newProcessInstanceDTO(instance))
+}
+
+// getActivityInstanceDiagram returns the SVG diagram of a process instance with its active activities highlighted
+func (s *Server) getActivityInstanceDiagram(w http.ResponseWriter, r *http.Request) error {
+	content, err := s.engine.GetRuntimeService().GetActivityInstanceDiagram(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, err = w.Write(content)
+	return err
+}
+
+// getActivityInstance returns the tree of the activity instances a process instance is at
+func (s *Server) getActivityInstance(w http.ResponseWriter, r *http.Request) error {
+	instance, err := s.engine.GetRuntimeService().GetActivityInstance(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, newActivityInstanceDTO(instance))
+}
+
+// deleteProcessInstance deletes a process instance for the reason given by deleteReason
+func (s *Server) deleteProcessInstance(w http.ResponseWriter, r *http.Request) error {
+	err := s.engine.GetRuntimeService().DeleteProcessInstance(r.Context(), r.PathValue("id"), params(r).String("deleteReason"))
+	if err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// setProcessInstanceSuspended suspends or activates a process instance
+func (s *Server) setProcessInstanceSuspended(w http.ResponseWriter, r *http.Request) error {
+	var state SuspensionStateDTO
+	if err := decodeJSON(r, &state); err != nil {
+		return err
+	}
+
+	runtimeService := s.engine.GetRuntimeService()
+	var err error
+	if state.Suspended {
+		err = runtimeService.SuspendProcessInstance(r.Context(), r.PathValue("id"))
+	} else {
+		err = runtimeService.ActivateProcessInstance(r.Context(), r.PathValue("id"))
+	}
+	if err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// getProcessInstanceVariables returns the variables of a process instance with their types
+func (s *Server) getProcessInstanceVariables(w http.ResponseWriter, r *http.Request) error {
+	variables, err := s.engine.GetRuntimeService().GetVariables(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, variable.Map(variables))
+}
+
+// setProcessInstanceVariables sets variables of a process instance, keeping the others
+func (s *Server) setProcessInstanceVariables(w http.ResponseWriter, r *http.Request) error {
+	var variables variable.Map
+	if err := decodeJSON(r, &variables); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetRuntimeService().SetVariables(r.Context(), r.PathValue("id"), variables); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// getProcessInstanceVariable returns a variable of a process instance with its type
+func (s *Server) getProcessInstanceVariable(w http.ResponseWriter, r *http.Request) error {
+	value, err := s.engine.GetRuntimeService().GetVariableTyped(r.Context(), r.PathValue("id"), r.PathValue("name"))
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("variable %w: %s", errors.ErrNotFound, r.PathValue("name"))
+	}
+	return s.writeJSON(w, http.StatusOK, value)
+}
+
+// setProcessInstanceVariable sets a variable of a process instance. The body is the value,
+// optionally typed as {"type": "long", "value": 42}.
+func (s *Server) setProcessInstanceVariable(w http.ResponseWriter, r *http.Request) error {
+	var value variable.TypedValue
+	if err := decodeJSON(r, &value); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetRuntimeService().SetVariable(r.Context(), r.PathValue("id"), r.PathValue("name"), value.Value); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// removeProcessInstanceVariable removes a variable of a process instance
+func (s *Server) removeProcessInstanceVariable(w http.ResponseWriter, r *http.Request) error {
+	if err := s.engine.GetRuntimeService().RemoveVariable(r.Context(), r.PathValue("id"), r.PathValue("name")); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// addProcessInstanceTag attaches a tag to a process instance
+func (s *Server) addProcessInstanceTag(w http.ResponseWriter, r *http.Request) error {
+	if err := s.engine.GetRuntimeService().AddTag(r.Context(), r.PathValue("id"), r.PathValue("tag")); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// removeProcessInstanceTag detaches a tag from a process instance
+func (s *Server) removeProcessInstanceTag(w http.ResponseWriter, r *http.Request) error {
+	if err := s.engine.GetRuntimeService().RemoveTag(r.Context(), r.PathValue("id"), r.PathValue("tag")); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
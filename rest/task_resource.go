@@ -0,0 +1,260 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/muixstudio/flowgo/errors"
+	"github.com/muixstudio/flowgo/pkg/variable"
+)
+
+// registerTaskRoutes registers the routes of tasks and their variables
+func (s *Server) registerTaskRoutes() {
+	s.handle("GET /tasks", s.listTasks)
+	s.handle("GET /tasks/{id}", s.getTask)
+	s.handle("POST /tasks/{id}/claim", s.claimTask)
+	s.handle("POST /tasks/{id}/unclaim", s.unclaimTask)
+	s.handle("PUT /tasks/{id}/assignee", s.setTaskAssignee)
+	s.handle("POST /tasks/{id}/complete", s.completeTask)
+	s.handle("POST /tasks/{id}/escalate", s.escalateTask)
+
+	s.handle("GET /tasks/{id}/variables", s.getTaskVariables)
+	s.handle("PUT /tasks/{id}/variables", s.setTaskVariables)
+	s.handle("GET /tasks/{id}/variables/{name}", s.getTaskVariable)
+	s.handle("PUT /tasks/{id}/variables/{name}", s.setTaskVariable)
+	s.handle("DELETE /tasks/{id}/variables/{name}", s.removeTaskVariable)
+}
+
+// listTasks returns a page of the tasks matching the query parameters
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) error {
+	p := params(r)
+	query := s.engine.GetTaskService().CreateTaskQuery()
+	if name := p.String("name"); name != "" {
+		query.TaskName(name)
+	}
+	if assignee := p.String("assignee"); assignee != "" {
+		query.TaskAssignee(assignee)
+	}
+	if owner := p.String("owner"); owner != "" {
+		query.TaskOwner(owner)
+	}
+	if candidateUser := p.String("candidateUser"); candidateUser != "" {
+		query.TaskCandidateUser(candidateUser)
+	}
+	if candidateGroup := p.String("candidateGroup"); candidateGroup != "" {
+		query.TaskCandidateGroup(candidateGroup)
+	}
+	if candidateGroupIn := p.Strings("candidateGroupIn"); candidateGroupIn != nil {
+		query.TaskCandidateGroupIn(candidateGroupIn...)
+	}
+	if candidateOrAssigned := p.String("candidateOrAssigned"); candidateOrAssigned != "" {
+		query.TaskCandidateOrAssigned(candidateOrAssigned, p.Strings("candidateOrAssignedGroups")...)
+	}
+	if involvedUser := p.String("involvedUser"); involvedUser != "" {
+		query.TaskInvolvedUser(involvedUser)
+	}
+	if p.Bool("includeTaskVariables") {
+		query.IncludeTaskVariables()
+	}
+	if p.Bool("includeProcessVariables") {
+		query.IncludeProcessVariables()
+	}
+	if processInstanceID := p.String("processInstanceId"); processInstanceID != "" {
+		query.ProcessInstanceID(processInstanceID)
+	}
+	if processDefinitionID := p.String("processDefinitionId"); processDefinitionID != "" {
+		query.ProcessDefinitionID(processDefinitionID)
+	}
+	if processDefinitionKey := p.String("processDefinitionKey"); processDefinitionKey != "" {
+		query.ProcessDefinitionKey(processDefinitionKey)
+	}
+	if taskDefinitionKey := p.String("taskDefinitionKey"); taskDefinitionKey != "" {
+		query.TaskDefinitionKey(taskDefinitionKey)
+	}
+	if category := p.String("category"); category != "" {
+		query.TaskCategory(category)
+	}
+	if tenantID := p.String("tenantId"); tenantID != "" {
+		query.TenantID(tenantID)
+	}
+	if p.Bool("active") {
+		query.Active()
+	}
+	if p.Bool("suspended") {
+		query.Suspended()
+	}
+	if priorityMin := p.Int("priorityMin"); priorityMin != nil {
+		query.TaskPriorityMin(*priorityMin)
+	}
+	if priorityMax := p.Int("priorityMax"); priorityMax != nil {
+		query.TaskPriorityMax(*priorityMax)
+	}
+	if dueBefore := p.Time("dueBefore"); dueBefore != nil {
+		query.DueBefore(*dueBefore)
+	}
+	if dueAfter := p.Time("dueAfter"); dueAfter != nil {
+		query.DueAfter(*dueAfter)
+	}
+	if followUpBefore := p.Time("followUpBefore"); followUpBefore != nil {
+		query.FollowUpBefore(*followUpBefore)
+	}
+	if followUpAfter := p.Time("followUpAfter"); followUpAfter != nil {
+		query.FollowUpAfter(*followUpAfter)
+	}
+	if p.Bool("overdue") {
+		query.Overdue()
+	}
+	if createdBefore := p.Time("createdBefore"); createdBefore != nil {
+		query.TaskCreatedBefore(*createdBefore)
+	}
+	if createdAfter := p.Time("createdAfter"); createdAfter != nil {
+		query.TaskCreatedAfter(*createdAfter)
+	}
+	p.Sort(map[string]func(){
+		"id":           func() { query.OrderByTaskID() },
+		"name":         func() { query.OrderByTaskName() },
+		"priority":     func() { query.OrderByTaskPriority() },
+		"createTime":   func() { query.OrderByTaskCreateTime() },
+		"dueDate":      func() { query.OrderByDueDate() },
+		"followUpDate": func() { query.OrderByFollowUpDate() },
+	})
+	if p.Ascending() {
+		query.Asc()
+	} else {
+		query.Desc()
+	}
+
+	page, err := listPage(r.Context(), p, query, newTaskDTO)
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, page)
+}
+
+// getTask returns a task
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) error {
+	t, err := s.engine.GetTaskService().GetTask(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, newTaskDTO(t))
+}
+
+// claimTask claims a task for a user
+func (s *Server) claimTask(w http.ResponseWriter, r *http.Request) error {
+	var user UserIDDTO
+	if err := decodeJSON(r, &user); err != nil {
+		return err
+	}
+	if user.UserID == "" {
+		return badRequest("userId is required")
+	}
+
+	if err := s.engine.GetTaskService().Claim(r.Context(), r.PathValue("id"), user.UserID); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// unclaimTask releases a claimed task
+func (s *Server) unclaimTask(w http.ResponseWriter, r *http.Request) error {
+	if err := s.engine.GetTaskService().Unclaim(r.Context(), r.PathValue("id")); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// setTaskAssignee assigns a task to a user, or unassigns it for an empty user ID
+func (s *Server) setTaskAssignee(w http.ResponseWriter, r *http.Request) error {
+	var user UserIDDTO
+	if err := decodeJSON(r, &user); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetTaskService().SetAssignee(r.Context(), r.PathValue("id"), user.UserID); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// completeTask completes a task, optionally setting variables
+func (s *Server) completeTask(w http.ResponseWriter, r *http.Request) error {
+	var body VariablesDTO
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetTaskService().CompleteWithVariables(r.Context(), r.PathValue("id"), body.Variables); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// escalateTask routes a task to the escalation path of its process
+func (s *Server) escalateTask(w http.ResponseWriter, r *http.Request) error {
+	var body EscalationDTO
+	if err := decodeJSON(r, &body); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetTaskService().Escalate(r.Context(), r.PathValue("id"), body.EscalationCode); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// getTaskVariables returns the variables visible to a task with their types
+func (s *Server) getTaskVariables(w http.ResponseWriter, r *http.Request) error {
+	variables, err := s.engine.GetTaskService().GetTaskVariables(r.Context(), r.PathValue("id"))
+	if err != nil {
+		return err
+	}
+	return s.writeJSON(w, http.StatusOK, variable.Map(variables))
+}
+
+// setTaskVariables sets variables as seen from a task, keeping the others
+func (s *Server) setTaskVariables(w http.ResponseWriter, r *http.Request) error {
+	var variables variable.Map
+	if err := decodeJSON(r, &variables); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetTaskService().SetTaskVariables(r.Context(), r.PathValue("id"), variables); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// getTaskVariable returns a variable visible to a task with its type
+func (s *Server) getTaskVariable(w http.ResponseWriter, r *http.Request) error {
+	value, err := s.engine.GetTaskService().GetTaskVariableTyped(r.Context(), r.PathValue("id"), r.PathValue("name"))
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("variable %w: %s", errors.ErrNotFound, r.PathValue("name"))
+	}
+	return s.writeJSON(w, http.StatusOK, value)
+}
+
+// setTaskVariable sets a variable as seen from a task. The body is the value, optionally
+// typed as {"type": "long", "value": 42}.
+func (s *Server) setTaskVariable(w http.ResponseWriter, r *http.Request) error {
+	var value variable.TypedValue
+	if err := decodeJSON(r, &value); err != nil {
+		return err
+	}
+
+	if err := s.engine.GetTaskService().SetTaskVariable(r.Context(), r.PathValue("id"), r.PathValue("name"), value.Value); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
+
+// removeTaskVariable removes a variable as seen from a task
+func (s *Server) removeTaskVariable(w http.ResponseWriter, r *http.Request) error {
+	if err := s.engine.GetTaskService().RemoveTaskVariable(r.Context(), r.PathValue("id"), r.PathValue("name")); err != nil {
+		return err
+	}
+	return writeNoContent(w)
+}
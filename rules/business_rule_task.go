@@ -0,0 +1,87 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// Mappings of decision results to the result variable of business rule tasks
+const (
+	MapResultList     = "resultList"
+	MapSingleResult   = "singleResult"
+	MapSingleEntry    = "singleEntry"
+	MapCollectEntries = "collectEntries"
+)
+
+// businessRuleTaskBehavior evaluates the decision named by the "decisionRef" property
+// with the variables of the execution, stores the result in the "resultVariable" if one
+// is set and leaves the node. The "mapDecisionResult" property selects how the result is
+// stored: the list of all matching outputs (resultList, the default), the outputs of the
+// single matching rule (singleResult), the single output value of it (singleEntry) or the
+// values of the single output of all matching rules (collectEntries).
+type businessRuleTaskBehavior struct {
+	service *rulesServiceImpl
+}
+
+// Execute evaluates the decision
+func (b *businessRuleTaskBehavior) Execute(ctx context.Context, execution *runtime.ActivityExecution) error {
+	node := execution.Node
+	variables := execution.GetVariables()
+
+	decisionRef := node.GetString("decisionRef")
+	if expression.IsExpression(decisionRef) {
+		value, err := execution.ExpressionManager().Evaluate(decisionRef, variables)
+		if err != nil {
+			return fmt.Errorf("invalid decision reference of business rule task '%s': %w", node.ID, err)
+		}
+		decisionRef = fmt.Sprintf("%v", value)
+	}
+	if decisionRef == "" {
+		return fmt.Errorf("business rule task '%s' must have a 'decisionRef' property", node.ID)
+	}
+
+	result, err := b.service.EvaluateDecisionTable(ctx, decisionRef, variables)
+	if err != nil {
+		return fmt.Errorf("business rule task '%s' failed: %w", node.ID, err)
+	}
+
+	if name := node.GetString("resultVariable"); name != "" {
+		value, err := mapDecisionResult(result, node.GetString("mapDecisionResult"))
+		if err != nil {
+			return fmt.Errorf("business rule task '%s': %w", node.ID, err)
+		}
+		execution.SetVariable(name, value)
+	}
+	return execution.Leave()
+}
+
+// mapDecisionResult converts a decision result to the value of a result variable
+func mapDecisionResult(result DecisionResult, mapping string) (interface{}, error) {
+	switch mapping {
+	case "", MapResultList:
+		list := make([]interface{}, len(result))
+		for i, outputs := range result {
+			list[i] = outputs
+		}
+		return list, nil
+	case MapSingleResult:
+		return result.SingleResult()
+	case MapSingleEntry:
+		return result.SingleEntry()
+	case MapCollectEntries:
+		values := make([]interface{}, 0, len(result))
+		for _, outputs := range result {
+			if len(outputs) != 1 {
+				return nil, fmt.Errorf("cannot collect entries of a decision with %d outputs", len(outputs))
+			}
+			for _, value := range outputs {
+				values = append(values, value)
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported decision result mapping '%s'", mapping)
+}
@@ -0,0 +1,65 @@
+// Package rules evaluates decision tables for business rule tasks and applications.
+//
+// Decision tables are JSON resources deployed alongside process definitions (see
+// repository.DeploymentBuilder.AddDecisionDefinition). Business rule tasks reference a
+// decision with the "decisionRef" property, evaluate it with the process variables and
+// store the result in the variable named by the "resultVariable" property.
+package rules
+
+import (
+	"context"
+	"fmt"
+)
+
+// RulesService evaluates deployed decision tables.
+// This service is responsible for:
+// - Evaluating decision tables against variables
+// - Executing business rule tasks of process instances
+type RulesService interface {
+	// EvaluateDecisionTable evaluates the most recently deployed decision table with a key
+	// and returns the outputs of the matching rules
+	EvaluateDecisionTable(ctx context.Context, decisionKey string, variables map[string]interface{}) (DecisionResult, error)
+}
+
+// DecisionResult holds the output entries of the matching rules of a decision table,
+// in rule order. Each entry maps the output names to their values.
+type DecisionResult []map[string]interface{}
+
+// SingleResult returns the output entries of the only matching rule, or nil if no rule
+// matched. It fails when several rules matched.
+func (r DecisionResult) SingleResult() (map[string]interface{}, error) {
+	switch len(r) {
+	case 0:
+		return nil, nil
+	case 1:
+		return r[0], nil
+	}
+	return nil, fmt.Errorf("expected a single result, found %d", len(r))
+}
+
+// SingleEntry returns the value of the only output of the only matching rule, or nil if
+// no rule matched. It fails when several rules matched or the rule has several outputs.
+func (r DecisionResult) SingleEntry() (interface{}, error) {
+	result, err := r.SingleResult()
+	if err != nil || result == nil {
+		return nil, err
+	}
+	if len(result) != 1 {
+		return nil, fmt.Errorf("expected a single output entry, found %d", len(result))
+	}
+	for _, value := range result {
+		return value, nil
+	}
+	return nil, nil
+}
+
+// CollectEntries returns the values of an output of all matching rules
+func (r DecisionResult) CollectEntries(outputName string) []interface{} {
+	values := make([]interface{}, 0, len(r))
+	for _, result := range r {
+		if value, exists := result[outputName]; exists {
+			values = append(values, value)
+		}
+	}
+	return values
+}
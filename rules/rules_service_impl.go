@@ -0,0 +1,260 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/muixstudio/flowgo/internal/repository"
+	"github.com/muixstudio/flowgo/internal/runtime"
+	"github.com/muixstudio/flowgo/model"
+	"github.com/muixstudio/flowgo/pkg/expression"
+)
+
+// cellInputVariable is the variable input entries test, bound to the value of their input
+const cellInputVariable = "cellInput"
+
+// rulesServiceImpl is the default implementation of RulesService
+type rulesServiceImpl struct {
+	repositoryService repository.RepositoryService
+	expressions       *expression.Manager
+}
+
+// NewRulesService creates a new rules service. Decision tables evaluate their
+// expressions with the custom functions of the runtime service, and business rule
+// tasks reached by process executions evaluate their decision in this service.
+func NewRulesService(repositoryService repository.RepositoryService, runtimeService runtime.RuntimeService) RulesService {
+	s := &rulesServiceImpl{
+		repositoryService: repositoryService,
+		expressions:       expression.NewManager(),
+	}
+	if runtimeService != nil {
+		s.expressions = runtimeService.GetExpressionManager()
+		runtimeService.RegisterActivityBehavior(model.NodeTypeBusinessRuleTask, &businessRuleTaskBehavior{service: s})
+	}
+	return s
+}
+
+// EvaluateDecisionTable evaluates the most recently deployed decision table with a key
+func (s *rulesServiceImpl) EvaluateDecisionTable(ctx context.Context, decisionKey string, variables map[string]interface{}) (DecisionResult, error) {
+	decision, err := s.repositoryService.GetDecisionDefinition(ctx, decisionKey)
+	if err != nil {
+		return nil, err
+	}
+	return s.evaluate(decision, variables)
+}
+
+// evaluate evaluates a decision table and applies its hit policy to the matching rules
+func (s *rulesServiceImpl) evaluate(decision *model.DecisionDefinition, variables map[string]interface{}) (DecisionResult, error) {
+	inputs := make([]interface{}, len(decision.Inputs))
+	for i, input := range decision.Inputs {
+		value, err := s.evaluateInput(input, variables)
+		if err != nil {
+			return nil, fmt.Errorf("decision '%s': invalid input '%s': %w", decision.Key, input.ID, err)
+		}
+		inputs[i] = value
+	}
+
+	result := make(DecisionResult, 0)
+	for i, rule := range decision.Rules {
+		matched, err := s.matches(rule, inputs, variables)
+		if err != nil {
+			return nil, fmt.Errorf("decision '%s': rule %d: %w", decision.Key, i+1, err)
+		}
+		if !matched {
+			continue
+		}
+
+		outputs, err := s.evaluateOutputs(decision, rule, variables)
+		if err != nil {
+			return nil, fmt.Errorf("decision '%s': rule %d: %w", decision.Key, i+1, err)
+		}
+		result = append(result, outputs)
+
+		if decision.HitPolicy == model.HitPolicyFirst {
+			break
+		}
+	}
+
+	switch decision.HitPolicy {
+	case model.HitPolicyUnique:
+		if len(result) > 1 {
+			return nil, fmt.Errorf("decision '%s' has hit policy UNIQUE but %d rules matched", decision.Key, len(result))
+		}
+	case model.HitPolicyAny:
+		if len(result) > 1 {
+			for _, outputs := range result[1:] {
+				if !reflect.DeepEqual(outputs, result[0]) {
+					return nil, fmt.Errorf("decision '%s' has hit policy ANY but matching rules have different outputs", decision.Key)
+				}
+			}
+			result = result[:1]
+		}
+	}
+	return result, nil
+}
+
+// evaluateInput returns the value of an input: the variable it names or the value of its expression
+func (s *rulesServiceImpl) evaluateInput(input *model.DecisionInput, variables map[string]interface{}) (interface{}, error) {
+	if expression.IsExpression(input.Expression) {
+		return s.expressions.Evaluate(input.Expression, variables)
+	}
+	return variables[strings.TrimSpace(input.Expression)], nil
+}
+
+// matches reports whether all input entries of a rule hold for the input values
+func (s *rulesServiceImpl) matches(rule *model.DecisionRule, inputs []interface{}, variables map[string]interface{}) (bool, error) {
+	for i, entry := range rule.InputEntries {
+		condition, err := inputEntryCondition(entry)
+		if err != nil {
+			return false, err
+		}
+		if condition == "" {
+			continue
+		}
+
+		scope := make(map[string]interface{}, len(variables)+1)
+		for name, value := range variables {
+			scope[name] = value
+		}
+		scope[cellInputVariable] = inputs[i]
+
+		ok, err := s.expressions.EvaluateBool(condition, scope)
+		if err != nil {
+			return false, fmt.Errorf("invalid input entry '%s': %w", entry, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// evaluateOutputs returns the output entries of a matching rule by output name.
+// String entries with ${...} expressions are evaluated, other entries are literal values.
+func (s *rulesServiceImpl) evaluateOutputs(decision *model.DecisionDefinition, rule *model.DecisionRule, variables map[string]interface{}) (map[string]interface{}, error) {
+	outputs := make(map[string]interface{}, len(decision.Outputs))
+	for i, output := range decision.Outputs {
+		value := rule.OutputEntries[i]
+		if source, ok := value.(string); ok && expression.IsExpression(source) {
+			evaluated, err := s.expressions.Evaluate(source, variables)
+			if err != nil {
+				return nil, fmt.Errorf("invalid output entry of '%s': %w", output.Name, err)
+			}
+			value = evaluated
+		}
+		outputs[output.Name] = value
+	}
+	return outputs, nil
+}
+
+// inputEntryCondition translates an input entry into a boolean expression on the
+// cellInput variable. Entries are "-" or empty for any value, ${...} expressions,
+// comparisons such as "< 10", ranges such as "[1..10]" or "]0..1[", literals compared
+// for equality, comma separated lists of those matching any of them, and not(...)
+// negating a list. It returns an empty condition for entries matching any value.
+func inputEntryCondition(entry string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || entry == "-" {
+		return "", nil
+	}
+	if expression.IsExpression(entry) {
+		return entry, nil
+	}
+
+	negate := false
+	if strings.HasPrefix(entry, "not(") && strings.HasSuffix(entry, ")") {
+		negate = true
+		entry = strings.TrimSpace(entry[len("not(") : len(entry)-1])
+	}
+
+	tests := splitTests(entry)
+	conditions := make([]string, 0, len(tests))
+	for _, test := range tests {
+		condition, err := unaryTestCondition(test)
+		if err != nil {
+			return "", fmt.Errorf("invalid input entry '%s': %w", entry, err)
+		}
+		conditions = append(conditions, "("+condition+")")
+	}
+
+	condition := strings.Join(conditions, " || ")
+	if negate {
+		condition = "!(" + condition + ")"
+	}
+	return "${" + condition + "}", nil
+}
+
+// unaryTestCondition translates a single comparison, range or literal into an expression body
+func unaryTestCondition(test string) (string, error) {
+	test = strings.TrimSpace(test)
+	if test == "" {
+		return "", fmt.Errorf("empty test")
+	}
+
+	for _, op := range []string{"<=", ">=", "!=", "==", "<", ">"} {
+		if strings.HasPrefix(test, op) {
+			operand := strings.TrimSpace(test[len(op):])
+			if operand == "" {
+				return "", fmt.Errorf("missing operand of '%s'", op)
+			}
+			return cellInputVariable + " " + op + " (" + operand + ")", nil
+		}
+	}
+
+	if isRange(test) {
+		bounds := strings.SplitN(test[1:len(test)-1], "..", 2)
+		low, high := strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+		if low == "" || high == "" {
+			return "", fmt.Errorf("invalid range '%s'", test)
+		}
+		lowOp, highOp := ">=", "<="
+		if test[0] != '[' {
+			lowOp = ">"
+		}
+		if test[len(test)-1] != ']' {
+			highOp = "<"
+		}
+		return cellInputVariable + " " + lowOp + " (" + low + ") && " + cellInputVariable + " " + highOp + " (" + high + ")", nil
+	}
+
+	return cellInputVariable + " == (" + test + ")", nil
+}
+
+// isRange reports whether a test is a range such as "[1..10]", "]1..10[" or "(1..10)"
+func isRange(test string) bool {
+	if len(test) < 2 || !strings.Contains(test, "..") {
+		return false
+	}
+	return strings.ContainsRune("[](", rune(test[0])) && strings.ContainsRune("[])", rune(test[len(test)-1]))
+}
+
+// splitTests splits a comma separated list of tests, ignoring commas in quoted
+// strings, parentheses and ranges
+func splitTests(entry string) []string {
+	tests := make([]string, 0)
+	depth := 0
+	var quote rune
+	start := 0
+	for i, r := range entry {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '(' || r == '[':
+			depth++
+		case r == ')' || r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case r == ',' && depth == 0:
+			tests = append(tests, entry[start:i])
+			start = i + 1
+		}
+	}
+	return append(tests, entry[start:])
+}
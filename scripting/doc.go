@@ -0,0 +1,14 @@
+// Package scripting provides script engines for script tasks.
+//
+// The JavaScript engine is backed by github.com/dop251/goja, a JavaScript
+// interpreter written in Go, so it needs no cgo or external runtime. The process
+// engine registers it for the "javascript" script format, the default format of
+// script tasks. A runtime service used on its own registers it explicitly:
+//
+//	runtimeService.RegisterScriptEngine("javascript", scripting.NewJavaScriptEngine())
+//
+// Scripts see the process variables as globals and an "execution" object with
+// getVariable, setVariable and setVariableLocal. The value of the last expression
+// of a script is its result. A script is interrupted when the "timeout" of its
+// script task passes.
+package scripting
@@ -0,0 +1,67 @@
+package scripting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+	"github.com/muixstudio/flowgo/internal/runtime"
+)
+
+// javaScriptEngine evaluates JavaScript scripts with goja
+type javaScriptEngine struct{}
+
+// NewJavaScriptEngine creates a JavaScript script engine
+func NewJavaScriptEngine() runtime.ScriptEngine {
+	return &javaScriptEngine{}
+}
+
+// Evaluate runs a script in a new VM, which is interrupted when ctx is done
+func (e *javaScriptEngine) Evaluate(ctx context.Context, script string, execution *runtime.ActivityExecution) (interface{}, error) {
+	vm := goja.New()
+
+	for name, value := range execution.GetVariables() {
+		if err := vm.Set(name, value); err != nil {
+			return nil, fmt.Errorf("failed to set variable '%s': %w", name, err)
+		}
+	}
+	if err := vm.Set("execution", map[string]interface{}{
+		"processInstanceId": execution.ProcessInstance.ID,
+		"activityId":        execution.Node.ID,
+		"getVariable": func(name string) interface{} {
+			return execution.GetVariable(name)
+		},
+		"setVariable": func(name string, value interface{}) {
+			execution.SetVariable(name, value)
+		},
+		"setVariableLocal": func(name string, value interface{}) {
+			execution.SetVariableLocal(name, value)
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	value, err := vm.RunString(script)
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if value == nil || goja.IsUndefined(value) || goja.IsNull(value) {
+		return nil, nil
+	}
+	return value.Export(), nil
+}
@@ -0,0 +1,110 @@
+// Package tracing defines the tracer the engine reports spans of commands, jobs and
+// service tasks to. It has the shape of the OpenTelemetry tracing API, so that an
+// adapter of a few lines connects the engine to an OpenTelemetry SDK without the
+// engine depending on it:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string, attributes ...tracing.Attribute) (context.Context, tracing.Span) {
+//		ctx, span := t.tracer.Start(ctx, name)
+//		s := otelSpan{span}
+//		s.SetAttributes(attributes...)
+//		return ctx, s
+//	}
+//
+//	func (t otelTracer) Inject(ctx context.Context, carrier map[string]string) {
+//		otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(carrier))
+//	}
+//
+//	func (t otelTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+//		return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+//	}
+//
+// Without a tracer the engine uses NoopTracer.
+package tracing
+
+import "context"
+
+// Attribute keys of the spans of the engine
+const (
+	AttributeCommandType           = "flowgo.command.type"
+	AttributeProcessDefinitionID   = "flowgo.process_definition.id"
+	AttributeProcessDefinitionKey  = "flowgo.process_definition.key"
+	AttributeProcessInstanceID     = "flowgo.process_instance.id"
+	AttributeActivityID            = "flowgo.activity.id"
+	AttributeTaskID                = "flowgo.task.id"
+	AttributeJobID                 = "flowgo.job.id"
+	AttributeJobType               = "flowgo.job.type"
+	AttributeServiceImplementation = "flowgo.service_task.implementation"
+)
+
+// Attribute is a key-value pair describing a span
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// String returns an attribute
+func String(key, value string) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer starts spans and propagates their context through carriers, e.g. the jobs
+// of async continuations, so that work continued in the background joins the trace
+// it was started in
+type Tracer interface {
+	// Start starts a span as child of the span of a context, if any, and returns a
+	// context carrying the new span
+	Start(ctx context.Context, spanName string, attributes ...Attribute) (context.Context, Span)
+
+	// Inject writes the trace context of a context to a carrier, e.g. as W3C traceparent
+	Inject(ctx context.Context, carrier map[string]string)
+
+	// Extract returns a context carrying the trace context of a carrier
+	Extract(ctx context.Context, carrier map[string]string) context.Context
+}
+
+// Span is an operation of a trace
+type Span interface {
+	// SetAttributes adds attributes to the span
+	SetAttributes(attributes ...Attribute)
+
+	// RecordError records an error and marks the span as failed
+	RecordError(err error)
+
+	// End completes the span
+	End()
+}
+
+// NoopTracer returns a tracer that records nothing
+func NoopTracer() Tracer {
+	return noopTracer{}
+}
+
+// noopTracer is a tracer that records nothing
+type noopTracer struct{}
+
+// Start returns the context and a span that records nothing
+func (noopTracer) Start(ctx context.Context, spanName string, attributes ...Attribute) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// Inject writes nothing
+func (noopTracer) Inject(ctx context.Context, carrier map[string]string) {}
+
+// Extract returns the context unchanged
+func (noopTracer) Extract(ctx context.Context, carrier map[string]string) context.Context {
+	return ctx
+}
+
+// noopSpan is a span that records nothing
+type noopSpan struct{}
+
+// SetAttributes does nothing
+func (noopSpan) SetAttributes(attributes ...Attribute) {}
+
+// RecordError does nothing
+func (noopSpan) RecordError(err error) {}
+
+// End does nothing
+func (noopSpan) End() {}